@@ -2,9 +2,14 @@ package asmgen
 
 import (
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/constant"
+	"github.com/yuya-takeyama/petitgo/scanner"
 	"github.com/yuya-takeyama/petitgo/token"
 )
 
@@ -16,6 +21,18 @@ type ARM64Generator struct {
 	stackSize      int
 	stringLiterals map[string]string // string value -> label name
 	stringCount    int
+	types          typeEnv
+	descriptors    map[string]bool // types whose descriptors are referenced
+	copies         map[string]bool // types whose copy routines are referenced
+
+	function       string          // symbol of the function being generated
+	literals       int             // function literals seen in the function
+	captured       map[string]bool // variables of the function captured by closures
+	cells          map[int]bool    // stack offsets holding the addresses of heap cells
+	closures       []*closure      // function literals waiting to be generated
+	staticClosures map[string]bool // declared functions used as values
+	branches       branchStack     // targets of break, continue and goto
+	defers         deferFrame      // exit code and results of a function with defer statements
 }
 
 // NewARM64Generator creates a new ARM64 assembly generator
@@ -25,6 +42,10 @@ func NewARM64Generator() *ARM64Generator {
 		stackSize:      0,
 		stringLiterals: make(map[string]string),
 		stringCount:    0,
+		types:          newTypeEnv(),
+		descriptors:    make(map[string]bool),
+		copies:         make(map[string]bool),
+		staticClosures: make(map[string]bool),
 	}
 }
 
@@ -38,51 +59,150 @@ func (g *ARM64Generator) Generate(statements []ast.Statement) string {
 	g.writeLine(".p2align 2")
 	g.writeLine("")
 
-	// Generate all functions first
+	g.types.collectFunctions(statements)
+
+	// Generate all functions first, each followed by its function literals
 	for _, stmt := range statements {
 		if funcStmt, ok := stmt.(*ast.FuncStatement); ok {
-			g.generateFunction(funcStmt)
+			g.generateFunction(funcStmt, nil)
+			for len(g.closures) > 0 {
+				c := g.closures[0]
+				g.closures = g.closures[1:]
+				g.generateFunction(c.funcStatement(), c)
+			}
 		}
 	}
+	g.generateCopyRoutines()
 
-	// Generate string literals in data section
+	// Generate type descriptors, static closures and string literals in
+	// data section; run-time errors panic with string values
+	g.typeDescriptor("string")
+	g.generateTypeDescriptors()
+	g.generateStaticClosures()
 	g.generateStringLiterals()
+	g.generateGlobals()
 
 	return g.output.String()
 }
 
-func (g *ARM64Generator) generateFunction(funcStmt *ast.FuncStatement) {
+// generateFunction generates a declared function, or the function of a
+// literal when c is not nil
+func (g *ARM64Generator) generateFunction(funcStmt *ast.FuncStatement, c *closure) {
 	// Reset variables for each function
 	g.variables = make(map[string]int)
 	g.stackSize = 0
+	g.types.enterFunction(funcStmt)
+	g.function = funcSymbol(funcStmt)
+	g.literals = 0
+	g.captured = escapingVariables(funcStmt)
+	g.cells = make(map[int]bool)
+	g.branches.reset()
+	start := g.output.Len()
 
-	g.writeLine(fmt.Sprintf("_%s:", funcStmt.Name))
+	g.writeLine(fmt.Sprintf("_%s:", funcSymbol(funcStmt)))
 	g.writeLine("    // Function prologue")
 	g.writeLine("    stp x29, x30, [sp, #-16]!") // Save frame pointer and link register
 	g.writeLine("    mov x29, sp")               // Set frame pointer
-	g.writeLine("    sub sp, sp, #64")           // Reserve 64 bytes for local variables
+	// Reserve space for local variables; the size is patched in later
+	g.writeLine("    sub sp, sp, #" + frameSizePlaceholder)
+
+	// The captured variables of a closure are the heap cells listed in the
+	// closure passed in x9
+	if c != nil {
+		for i, name := range c.captures {
+			offset := g.allocateCell(name, c.types[i])
+			g.writeLine(fmt.Sprintf("    // Captured: %s", name))
+			g.writeLine(fmt.Sprintf("    ldr x16, [x9, #%d]", 8*(i+1)))
+			g.writeLine(fmt.Sprintf("    str x16, [x29, #-%d]", offset))
+		}
+	}
+
+	// Package-level variables are used through their addresses, unless a
+	// closure captured them already
+	for _, name := range g.types.usedGlobals(funcStmt) {
+		if _, captured := g.variables[name]; captured {
+			continue
+		}
+		offset := g.allocateCell(name, g.types.globalTypes[name])
+		g.writeLine(fmt.Sprintf("    // Package-level variable: %s", name))
+		g.writeLine(fmt.Sprintf("    adrp x16, %s@PAGE", globalSymbol(name)))
+		g.writeLine(fmt.Sprintf("    add x16, x16, %s@PAGEOFF", globalSymbol(name)))
+		g.writeLine(fmt.Sprintf("    str x16, [x29, #-%d]", offset))
+	}
 
-	// For functions with parameters, handle the first parameter in x0
-	if len(funcStmt.Parameters) > 0 {
+	// Parameters arrive in x0-x7; the receiver of a method comes first and
+	// interface values take two
+	register := 0
+	for _, param := range funcParameters(funcStmt) {
+		words := g.types.words(ast.TypeName(param.Type))
+		if register+words > len(arm64ArgumentRegisters) {
+			break
+		}
 		// Store parameter in stack
-		g.stackSize += 8
-		g.variables[funcStmt.Parameters[0].Name] = g.stackSize
-		g.writeLine(fmt.Sprintf("    // Parameter: %s", funcStmt.Parameters[0].Name))
-		g.writeLine(fmt.Sprintf("    str x0, [x29, #-%d]", g.stackSize))
+		offset := g.allocate(param.Name, ast.TypeName(param.Type))
+		g.writeLine(fmt.Sprintf("    // Parameter: %s", param.Name))
+		g.writeLine(fmt.Sprintf("    str %s, %s", arm64ArgumentRegisters[register], g.operand(offset, 0)))
+		if words == 2 {
+			g.writeLine(fmt.Sprintf("    str %s, %s", arm64ArgumentRegisters[register+1], g.operand(offset, 1)))
+		}
+		register += words
+	}
+
+	// A struct or an array passed by value, including a value receiver, is
+	// copied so the function cannot change the caller's value
+	for _, param := range funcParameters(funcStmt) {
+		typeName := constant.CanonicalType(ast.TypeName(param.Type))
+		if offset, exists := g.variables[param.Name]; exists && g.types.isValueBlock(typeName) {
+			g.writeLine(fmt.Sprintf("    // Copy of %s", param.Name))
+			g.writeLine(fmt.Sprintf("    ldr x0, %s", g.operand(offset, 0)))
+			g.copyValue(typeName)
+			g.writeLine(fmt.Sprintf("    str x0, %s", g.operand(offset, 0)))
+		}
+	}
+
+	if funcStmt.Name == "main" && funcStmt.Receiver == nil {
+		g.initializeGlobals()
+	}
+
+	// Named results are local variables starting at their zero values
+	if funcStmt.HasNamedResults() {
+		for _, result := range funcStmt.Results {
+			offset := g.allocate(result.Name, ast.TypeName(result.Type))
+			g.writeLine(fmt.Sprintf("    // Named result: %s", result.Name))
+			g.generateExpressionAs(g.types.zeroValue(ast.TypeName(result.Type)), ast.TypeName(result.Type))
+			g.storeValue(offset, ast.TypeName(result.Type))
+		}
+	}
+
+	g.defers = deferFrame{}
+	if containsDefer(funcStmt.Body) {
+		g.enterDeferFrame(funcStmt)
 	}
 
 	// Generate function body
 	g.generateBlock(funcStmt.Body)
 
 	// Function epilogue only for main (other functions use explicit return)
-	if funcStmt.Name == "main" {
+	if g.defers.exitLabel != "" {
+		// Every way out of the function goes through the exit code
+		if !endsWithReturn(funcStmt.Body) {
+			g.writeLine("    b " + g.defers.exitLabel)
+		}
+		g.generateDeferExit(funcStmt.Name == "main")
+	} else if funcStmt.Name == "main" {
 		g.writeLine("    // Exit")
-		g.writeLine("    add sp, sp, #64")         // Restore stack space
+		g.writeLine("    add sp, sp, #" + frameSizePlaceholder)
 		g.writeLine("    ldp x29, x30, [sp], #16") // Restore frame pointer and link register
 		g.writeLine("    mov x0, #0")              // exit status
 		g.writeLine("    mov x16, #1")             // sys_exit
 		g.writeLine("    svc #0x80")               // system call
+	} else if !endsWithReturn(funcStmt.Body) {
+		// Return at the end of a function without a final return statement
+		g.generateReturnStatement(&ast.ReturnStatement{})
 	}
+
+	// The frame holds every local allocated in the body
+	patchFrameSize(&g.output, start, g.stackSize)
 	g.writeLine("")
 }
 
@@ -93,11 +213,15 @@ func (g *ARM64Generator) generateBlock(block *ast.BlockStatement) {
 }
 
 func (g *ARM64Generator) generateStatement(stmt ast.Statement) {
+	g.types.checkAssignment(stmt)
 	switch s := stmt.(type) {
 	case *ast.ExpressionStatement:
 		if callNode, ok := s.Expression.(*ast.CallNode); ok {
-			if callNode.Function == "println" && len(callNode.Arguments) > 0 {
-				g.generatePrintln(callNode.Arguments[0])
+			if callNode.Function == "println" && callNode.Receiver == nil {
+				g.generatePrintln(callNode.Arguments)
+			} else {
+				// Function and method calls run for their side effects
+				g.generateFunctionCall(callNode)
 			}
 		}
 	case *ast.AssignStatement:
@@ -105,68 +229,258 @@ func (g *ARM64Generator) generateStatement(stmt ast.Statement) {
 		if offset, exists := g.variables[s.Name]; exists {
 			// Variable reassignment
 			g.writeLine(fmt.Sprintf("    // %s = value (reassignment)", s.Name))
-			g.generateExpression(s.Value)
-			g.writeLine(fmt.Sprintf("    str x0, [x29, #-%d]", offset))
+			g.generateStoredValue(s.Value, g.types.varTypes[s.Name])
+			g.storeValue(offset, g.types.varTypes[s.Name])
 		} else {
 			// New variable assignment
-			g.stackSize += 8
-			g.variables[s.Name] = g.stackSize
+			typeName := g.types.exprType(s.Value)
+			offset := g.allocate(s.Name, typeName)
 			g.writeLine(fmt.Sprintf("    // %s := value", s.Name))
-			g.generateExpression(s.Value)
-			g.writeLine(fmt.Sprintf("    str x0, [x29, #-%d]", g.stackSize))
+			if isHidden(s.Name) {
+				g.generateExpression(s.Value)
+			} else {
+				g.generateStoredValue(s.Value, typeName)
+			}
+			g.storeValue(offset, typeName)
 		}
 	case *ast.VarStatement:
-		g.stackSize += 8
-		g.variables[s.Name] = g.stackSize
-		g.writeLine(fmt.Sprintf("    // var %s", s.Name))
-		g.generateExpression(s.Value)
-		g.writeLine(fmt.Sprintf("    str x0, [x29, #-%d]", g.stackSize))
+		g.generateVarStatement(s)
+	case *ast.TupleVarStatement:
+		g.generateTupleVar(s)
+	case *ast.DeclGroup:
+		for _, decl := range s.Decls {
+			g.generateStatement(decl)
+		}
 	case *ast.IfStatement:
 		g.generateIfStatement(s)
 	case *ast.ForStatement:
 		g.generateForStatement(s)
+	case *ast.RangeStatement:
+		g.generateRangeStatement(s)
 	case *ast.ReturnStatement:
 		g.generateReturnStatement(s)
 	case *ast.ReassignStatement:
-		// Variable reassignment
-		if offset, exists := g.variables[s.Name]; exists {
-			g.writeLine(fmt.Sprintf("    // %s = value", s.Name))
-			g.generateExpression(s.Value)
-			g.writeLine(fmt.Sprintf("    str x0, [x29, #-%d]", offset))
-		}
+		g.generateAssign(s.Target, s.Value)
+	case *ast.TupleAssignStatement:
+		g.generateTupleAssign(s)
+	case *ast.CompoundAssignStatement:
+		g.generateOpAssign(s.Target, s.Operator.BinaryOperator(), s.Value, s.Pos)
+	case *ast.IncStatement:
+		// x++ is generated as x += 1
+		g.generateOpAssign(s.Target, token.ADD, &ast.NumberNode{Value: 1}, s.Pos)
+	case *ast.DecStatement:
+		g.generateOpAssign(s.Target, token.SUB, &ast.NumberNode{Value: 1}, s.Pos)
 	case *ast.SwitchStatement:
 		g.generateSwitchStatement(s)
+	case *ast.TypeSwitchStatement:
+		g.generateTypeSwitchStatement(s)
+	case *ast.LabeledStatement:
+		g.writeLine(g.branches.gotoLabel(s.Label, g.getNewLabel) + ":")
+		g.branches.labelNext(s)
+		g.generateStatement(s.Statement)
+	case *ast.BreakStatement:
+		g.writeLine("    b " + g.branches.breakLabel(s.Label))
+	case *ast.ContinueStatement:
+		g.writeLine("    b " + g.branches.continueLabel(s.Label))
+	case *ast.GotoStatement:
+		g.writeLine("    b " + g.branches.gotoLabel(s.Label, g.getNewLabel))
+	case *ast.DeferStatement:
+		g.generateDeferStatement(s)
+	}
+}
+
+// allocate reserves a stack slot for a new variable of a type and returns
+// its offset. An interface value keeps its data word at the offset and its
+// type descriptor in the word above.
+func (g *ARM64Generator) allocate(name, typeName string) int {
+	if g.captured[name] {
+		offset := g.allocateCell(name, typeName)
+		g.writeLine(fmt.Sprintf("    mov x16, #%d", g.types.size(typeName)))
+		g.writeLine("    bl _alloc")
+		g.writeLine(fmt.Sprintf("    str x16, [x29, #-%d]", offset))
+		return offset
 	}
+	g.stackSize += g.types.size(typeName)
+	g.variables[name] = g.stackSize
+	g.types.declare(name, typeName)
+	return g.stackSize
 }
 
-func (g *ARM64Generator) generatePrintln(arg ast.ASTNode) {
-	g.generateExpression(arg)
+// allocateCell reserves a stack slot for the address of the heap cell of a
+// variable and returns its offset
+func (g *ARM64Generator) allocateCell(name, typeName string) int {
+	g.stackSize += 8
+	g.variables[name] = g.stackSize
+	g.types.declare(name, typeName)
+	g.cells[g.stackSize] = true
+	return g.stackSize
+}
 
-	// Check argument type to determine print function
-	switch arg.(type) {
-	case *ast.StringNode:
-		g.writeLine("    // Print string in x0")
+// operand returns the memory operand of a word of the variable at a stack
+// offset: 0 for the data word and 1 for the type descriptor of an interface
+// value. The address of a heap cell is loaded into x17 first.
+func (g *ARM64Generator) operand(offset, word int) string {
+	if g.cells[offset] {
+		g.writeLine(fmt.Sprintf("    ldr x17, [x29, #-%d]", offset))
+		if word == 0 {
+			return "[x17]"
+		}
+		return fmt.Sprintf("[x17, #%d]", 8*word)
+	}
+	return fmt.Sprintf("[x29, #-%d]", offset-8*word)
+}
+
+// storeValue stores the value in x0 in the variable at a stack offset, along
+// with the type descriptor in x1 for an interface value
+func (g *ARM64Generator) storeValue(offset int, typeName string) {
+	g.writeLine("    str x0, " + g.operand(offset, 0))
+	if g.types.isInterface(typeName) {
+		g.writeLine("    str x1, " + g.operand(offset, 1))
+	}
+}
+
+// loadValue loads the variable at a stack offset into x0, along with the
+// type descriptor into x1 for an interface value
+func (g *ARM64Generator) loadValue(offset int, typeName string) {
+	g.writeLine("    ldr x0, " + g.operand(offset, 0))
+	if g.types.isInterface(typeName) {
+		g.writeLine("    ldr x1, " + g.operand(offset, 1))
+	}
+}
+
+// generatePrintln prints the arguments of println separated by spaces and
+// followed by a newline; the results of a sole call returning several
+// results are printed like arguments. The print routines end what they
+// print with the byte at print_end, a newline unless it is set to a space
+// for an argument before the last.
+func (g *ARM64Generator) generatePrintln(args []ast.ASTNode) {
+	hidden := func() string { return "." + g.getNewLabel() }
+	if assign, results, ok := g.types.spreadArguments(args, hidden); ok {
+		g.generateStatement(assign)
+		args = results
+	}
+	g.types.checkSingleValues(args)
+	if len(args) == 0 {
+		label := g.getStringLabel("")
+		g.writeLine(fmt.Sprintf("    adrp x0, %s@PAGE", label))
+		g.writeLine(fmt.Sprintf("    add x0, x0, %s@PAGEOFF", label))
 		g.writeLine("    bl _print_string")
-	default:
-		g.writeLine("    // Print number in x0")
-		g.writeLine("    bl _print_number")
+		return
+	}
+
+	// Every argument is evaluated before any is printed
+	if len(args) == 1 {
+		g.generateExpression(args[0])
+	} else {
+		for _, arg := range args {
+			g.generateExpression(arg)
+			g.writeLine("    str x0, [sp, #-16]!")
+		}
+	}
+	for i, arg := range args {
+		separated := i < len(args)-1
+		if len(args) > 1 {
+			g.writeLine(fmt.Sprintf("    ldr x0, [sp, #%d]", 16*(len(args)-1-i)))
+		}
+		if separated {
+			g.setPrintEnd(32) // ASCII space
+		}
+
+		// Check argument type to determine print function
+		switch g.types.exprType(arg) {
+		case "string":
+			g.writeLine("    // Print string in x0")
+			g.writeLine("    bl _print_string")
+		case "float64":
+			g.writeLine("    // Print float64 bits in x0")
+			g.writeLine("    bl _print_float")
+		default:
+			g.writeLine("    // Print number in x0")
+			g.writeLine("    bl _print_number")
+		}
+		if separated {
+			g.setPrintEnd(10) // ASCII newline
+		}
+	}
+	if len(args) > 1 {
+		g.writeLine(fmt.Sprintf("    add sp, sp, #%d", 16*len(args)))
 	}
 }
 
+// setPrintEnd sets the byte the print routines end with
+func (g *ARM64Generator) setPrintEnd(end int) {
+	g.writeLine("    adrp x9, print_end@PAGE")
+	g.writeLine(fmt.Sprintf("    mov w10, #%d", end))
+	g.writeLine("    strb w10, [x9, print_end@PAGEOFF]")
+}
+
 func (g *ARM64Generator) generateExpression(expr ast.ASTNode) {
 	switch e := expr.(type) {
 	case *ast.NumberNode:
-		g.writeLine(fmt.Sprintf("    mov x0, #%d", e.Value))
+		if e.Value >= -65536 && e.Value <= 65535 {
+			g.writeLine(fmt.Sprintf("    mov x0, #%d", e.Value))
+		} else {
+			g.loadImmediate(uint64(e.Value))
+		}
+	case *ast.RuneNode:
+		if e.Value <= 65535 {
+			g.writeLine(fmt.Sprintf("    mov x0, #%d", e.Value))
+		} else {
+			g.loadImmediate(uint64(e.Value))
+		}
+	case *ast.BooleanNode:
+		if e.Value {
+			g.writeLine("    mov x0, #1")
+		} else {
+			g.writeLine("    mov x0, #0")
+		}
+	case *ast.FloatNode:
+		// float64 values are kept as raw bits in x0
+		g.writeLine(fmt.Sprintf("    // float64 %v", e.Value))
+		g.loadImmediate(math.Float64bits(e.Value))
+	case *ast.ConstExpr:
+		g.generateExpression(constLiteral(e.Value))
 	case *ast.VariableNode:
 		if offset, exists := g.variables[e.Name]; exists {
-			g.writeLine(fmt.Sprintf("    ldr x0, [x29, #-%d]", offset))
+			g.loadValue(offset, g.types.varTypes[e.Name])
+		} else if v, isConstant := g.types.constants[e.Name]; isConstant {
+			g.generateExpression(constLiteral(v))
+		} else if _, isFunction := g.types.funcType(e.Name); isFunction {
+			// A declared function used as a value
+			g.staticClosures[e.Name] = true
+			g.writeLine(fmt.Sprintf("    adrp x0, %s@PAGE", staticClosureSymbol(e.Name)))
+			g.writeLine(fmt.Sprintf("    add x0, x0, %s@PAGEOFF", staticClosureSymbol(e.Name)))
+		} else if e.Name == "nil" {
+			g.writeLine("    mov x0, #0")
 		}
+	case *ast.FuncLiteral:
+		g.generateFuncLiteral(e)
 	case *ast.StringNode:
 		// Get or create string label
 		label := g.getStringLabel(e.Value)
 		g.writeLine(fmt.Sprintf("    adrp x0, %s@PAGE", label))
 		g.writeLine(fmt.Sprintf("    add x0, x0, %s@PAGEOFF", label))
 	case *ast.BinaryOpNode:
+		if e.Operator == token.LAND || e.Operator == token.LOR {
+			g.generateLogicalOp(e)
+			return
+		}
+		if g.types.isFloat(e.Left) || g.types.isFloat(e.Right) {
+			g.generateFloatBinaryOp(e)
+			return
+		}
+		if operand, ok := g.types.nilComparison(e); ok {
+			// An interface is nil when it has no type descriptor
+			g.generateExpression(operand)
+			g.writeLine("    cmp x1, #0")
+			if e.Operator == token.EQL {
+				g.writeLine("    cset x0, eq")
+			} else {
+				g.writeLine("    cset x0, ne")
+			}
+			return
+		}
+
 		// Left operand
 		g.generateExpression(e.Left)
 		g.writeLine("    str x0, [sp, #-16]!")
@@ -176,6 +490,15 @@ func (g *ARM64Generator) generateExpression(expr ast.ASTNode) {
 		g.writeLine("    mov x1, x0")
 		g.writeLine("    ldr x0, [sp], #16")
 
+		// Strings are compared by content
+		if g.types.isStringComparison(e) {
+			g.generateEqual("string")
+			if e.Operator == token.NEQ {
+				g.writeLine("    eor x0, x0, #1")
+			}
+			return
+		}
+
 		// Operation
 		switch e.Operator {
 		case token.ADD:
@@ -185,7 +508,34 @@ func (g *ARM64Generator) generateExpression(expr ast.ASTNode) {
 		case token.MUL:
 			g.writeLine("    mul x0, x0, x1")
 		case token.QUO:
-			g.writeLine("    udiv x0, x0, x1")
+			g.writeLine("    cbz x1, _divide_panic")
+			g.writeLine("    sdiv x0, x0, x1")
+		case token.REM:
+			g.writeLine("    cbz x1, _divide_panic")
+			g.writeLine("    sdiv x2, x0, x1")
+			g.writeLine("    msub x0, x2, x1, x0") // x0 - (x0 / x1) * x1
+		case token.AND:
+			g.writeLine("    and x0, x0, x1")
+		case token.OR:
+			g.writeLine("    orr x0, x0, x1")
+		case token.XOR:
+			g.writeLine("    eor x0, x0, x1")
+		case token.AND_NOT:
+			g.writeLine("    bic x0, x0, x1")
+		case token.SHL:
+			// lsl only uses the low 6 bits of the count; counts of 64 or
+			// more shift out all bits, and negative ones panic
+			g.writeLine("    tbnz x1, #63, _shift_panic")
+			g.writeLine("    lsl x2, x0, x1")
+			g.writeLine("    cmp x1, #64")
+			g.writeLine("    csel x0, x2, xzr, lo")
+		case token.SHR:
+			// Counts of 64 or more are clamped to 63 to fill with the sign bit
+			g.writeLine("    tbnz x1, #63, _shift_panic")
+			g.writeLine("    mov x2, #63")
+			g.writeLine("    cmp x1, x2")
+			g.writeLine("    csel x1, x1, x2, lo")
+			g.writeLine("    asr x0, x0, x1")
 		case token.EQL:
 			g.writeLine("    cmp x0, x1")
 			g.writeLine("    cset x0, eq")
@@ -205,15 +555,171 @@ func (g *ARM64Generator) generateExpression(expr ast.ASTNode) {
 			g.writeLine("    cmp x0, x1")
 			g.writeLine("    cset x0, ge")
 		}
+
+		// rune and byte arithmetic wraps around
+		g.truncateInteger(g.types.exprType(e))
+	case *ast.UnaryOpNode:
+		if e.Operator == token.AND {
+			g.generateAddress(e.Operand)
+			return
+		}
+		g.generateExpression(e.Operand)
+		switch e.Operator {
+		case token.SUB:
+			if g.types.isFloat(e.Operand) {
+				g.writeLine("    eor x0, x0, #0x8000000000000000") // Flip the sign bit
+			} else {
+				g.writeLine("    neg x0, x0")
+			}
+		case token.NOT:
+			g.writeLine("    eor x0, x0, #1")
+		case token.XOR:
+			g.writeLine("    mvn x0, x0")
+		}
+		g.truncateInteger(g.types.exprType(e))
 	case *ast.CallNode:
 		g.generateFunctionCall(e)
+	case *ast.DerefNode:
+		g.generateDeref(e)
+	case *ast.NewNode:
+		g.generateNew(e)
 	case *ast.FieldAccessNode:
 		g.generateFieldAccess(e)
+	case *ast.StructLiteral:
+		g.generateStructLiteral(e)
 	case *ast.SliceLiteral:
-		g.generateSliceLiteral(e)
+		g.generateElements(e.TypeName(), e.Elements)
+	case *ast.ArrayLiteral:
+		g.generateElements(e.TypeName(), e.Elements)
+	case *ast.MapLiteral:
+		g.generateMapLiteral(e)
 	case *ast.IndexAccess:
+		if _, _, _, isMap := g.types.mapIndex(e); isMap {
+			g.generateMapIndex(e)
+			return
+		}
 		g.generateIndexAccess(e)
+	case *ast.SliceExpr:
+		g.generateSliceExpr(e)
+	case *ast.TypeAssertNode:
+		g.generateTypeAssert(e, false)
+	}
+}
+
+// generateLogicalOp generates && and || with short-circuit evaluation: the
+// right operand is skipped when the left one decides the result
+func (g *ARM64Generator) generateLogicalOp(e *ast.BinaryOpNode) {
+	endLabel := g.getNewLabel()
+
+	g.generateExpression(e.Left)
+	if e.Operator == token.LAND {
+		g.writeLine("    cbz x0, " + endLabel) // false && x is false
+	} else {
+		g.writeLine("    cbnz x0, " + endLabel) // true || x is true
+	}
+
+	g.generateExpression(e.Right)
+
+	g.writeLine(endLabel + ":")
+	g.writeLine("    cmp x0, #0")
+	g.writeLine("    cset x0, ne")
+}
+
+// loadImmediate loads a 64-bit constant into x0 with movz/movk
+func (g *ARM64Generator) loadImmediate(value uint64) {
+	g.writeLine(fmt.Sprintf("    movz x0, #0x%x", value&0xffff))
+	for shift := 16; shift < 64; shift += 16 {
+		if chunk := (value >> shift) & 0xffff; chunk != 0 {
+			g.writeLine(fmt.Sprintf("    movk x0, #0x%x, lsl #%d", chunk, shift))
+		}
+	}
+}
+
+// generateExpressionAs generates an expression and converts an int result
+// to float64 when the destination type requires it. A value assigned to an
+// interface type becomes an interface value.
+func (g *ARM64Generator) generateExpressionAs(expr ast.ASTNode, typeName string) {
+	if g.types.isInterface(typeName) {
+		g.types.checkImplements(expr, typeName)
+		g.generateInterfaceValue(expr)
+		return
+	}
+	g.generateExpression(expr)
+	if typeName == "float64" && !g.types.isFloat(expr) {
+		g.writeLine("    scvtf d0, x0")
+		g.writeLine("    fmov x0, d0")
+	}
+}
+
+// generateInterfaceValue generates an interface value: the data word in x0
+// and the address of the type descriptor of the dynamic type in x1. nil
+// leaves zero in both.
+func (g *ARM64Generator) generateInterfaceValue(expr ast.ASTNode) {
+	switch typeName := g.types.exprType(expr); {
+	case g.types.isNil(expr):
+		g.writeLine("    mov x0, #0")
+		g.writeLine("    mov x1, #0")
+	case g.types.isInterface(typeName):
+		// Interface values share the type descriptor of their dynamic type
+		g.generateExpression(expr)
+	default:
+		g.generateExpression(expr)
+		descriptor := g.typeDescriptor(typeName)
+		g.writeLine(fmt.Sprintf("    // type descriptor of %s", typeName))
+		g.writeLine(fmt.Sprintf("    adrp x1, %s@PAGE", descriptor))
+		g.writeLine(fmt.Sprintf("    add x1, x1, %s@PAGEOFF", descriptor))
+	}
+}
+
+// generateFloatBinaryOp generates code for float64 arithmetic and comparison.
+// Operands are loaded into d0 (left) and d1 (right); int operands are converted.
+func (g *ARM64Generator) generateFloatBinaryOp(e *ast.BinaryOpNode) {
+	// Left operand
+	g.generateExpressionAs(e.Left, "float64")
+	g.writeLine("    str x0, [sp, #-16]!")
+
+	// Right operand
+	g.generateExpressionAs(e.Right, "float64")
+	g.writeLine("    fmov d1, x0")
+	g.writeLine("    ldr x0, [sp], #16")
+	g.writeLine("    fmov d0, x0")
+
+	// Operation (mi/ls are false for unordered operands, unlike lt/le)
+	switch e.Operator {
+	case token.ADD:
+		g.writeLine("    fadd d0, d0, d1")
+	case token.SUB:
+		g.writeLine("    fsub d0, d0, d1")
+	case token.MUL:
+		g.writeLine("    fmul d0, d0, d1")
+	case token.QUO:
+		g.writeLine("    fdiv d0, d0, d1")
+	case token.EQL:
+		g.writeLine("    fcmp d0, d1")
+		g.writeLine("    cset x0, eq")
+		return
+	case token.NEQ:
+		g.writeLine("    fcmp d0, d1")
+		g.writeLine("    cset x0, ne")
+		return
+	case token.LSS:
+		g.writeLine("    fcmp d0, d1")
+		g.writeLine("    cset x0, mi")
+		return
+	case token.LEQ:
+		g.writeLine("    fcmp d0, d1")
+		g.writeLine("    cset x0, ls")
+		return
+	case token.GTR:
+		g.writeLine("    fcmp d0, d1")
+		g.writeLine("    cset x0, gt")
+		return
+	case token.GEQ:
+		g.writeLine("    fcmp d0, d1")
+		g.writeLine("    cset x0, ge")
+		return
 	}
+	g.writeLine("    fmov x0, d0")
 }
 
 func (g *ARM64Generator) generateIfStatement(stmt *ast.IfStatement) {
@@ -235,169 +741,1757 @@ func (g *ARM64Generator) generateIfStatement(stmt *ast.IfStatement) {
 	}
 }
 
+// generateFuncLiteral creates the closure of a function literal in x0: a
+// heap block holding the code address followed by the heap cells of the
+// captured variables. The function itself is generated later.
+func (g *ARM64Generator) generateFuncLiteral(lit *ast.FuncLiteral) {
+	g.literals++
+	c := &closure{symbol: closureSymbol(g.function, g.literals), literal: lit}
+	for _, name := range freeVariables(lit) {
+		if _, exists := g.variables[name]; exists {
+			c.captures = append(c.captures, name)
+			c.types = append(c.types, g.types.varTypes[name])
+		}
+	}
+	g.closures = append(g.closures, c)
+
+	g.writeLine(fmt.Sprintf("    // Closure: %s", c.symbol))
+	g.writeLine(fmt.Sprintf("    mov x16, #%d", 8*(len(c.captures)+1)))
+	g.writeLine("    bl _alloc")
+	g.writeLine(fmt.Sprintf("    adrp x0, _%s@PAGE", c.symbol))
+	g.writeLine(fmt.Sprintf("    add x0, x0, _%s@PAGEOFF", c.symbol))
+	g.writeLine("    str x0, [x16]")
+	for i, name := range c.captures {
+		g.writeLine(fmt.Sprintf("    ldr x0, [x29, #-%d] // %s", g.variables[name], name))
+		g.writeLine(fmt.Sprintf("    str x0, [x16, #%d]", 8*(i+1)))
+	}
+	g.writeLine("    mov x0, x16")
+}
+
+// generateForStatement generates a for loop. continue jumps to the update
+// statement.
 func (g *ARM64Generator) generateForStatement(stmt *ast.ForStatement) {
+	if stmt.Init != nil {
+		g.generateStatement(stmt.Init)
+	}
+
 	startLabel := g.getNewLabel()
 	endLabel := g.getNewLabel()
+	continueLabel := g.getNewLabel()
 
 	g.writeLine(startLabel + ":")
-	g.generateExpression(stmt.Condition)
-	g.writeLine("    cbz x0, " + endLabel)
+	if stmt.Condition != nil {
+		g.generateExpression(stmt.Condition)
+		g.writeLine("    cbz x0, " + endLabel)
+	}
 
+	g.branches.push(endLabel, continueLabel)
 	g.generateBlock(stmt.Body)
+	g.branches.pop()
 
+	g.writeLine(continueLabel + ":")
+	g.renewCells(loopVariables(stmt.Init))
+	if stmt.Update != nil {
+		g.generateStatement(stmt.Update)
+	}
 	g.writeLine("    b " + startLabel)
 	g.writeLine(endLabel + ":")
 }
 
-func (g *ARM64Generator) generateReturnStatement(stmt *ast.ReturnStatement) {
-	if stmt.Value != nil {
-		g.generateExpression(stmt.Value)
+// renewCells moves the captured variables among names to new heap cells,
+// which start with their current values
+func (g *ARM64Generator) renewCells(names []string) {
+	for _, name := range names {
+		if !g.captured[name] {
+			continue
+		}
+		offset := g.variables[name]
+		size := g.types.size(g.types.varTypes[name])
+		g.writeLine(fmt.Sprintf("    mov x16, #%d", size))
+		g.writeLine("    bl _alloc")
+		g.writeLine(fmt.Sprintf("    ldr x0, [x29, #-%d]", offset))
+		for word := 0; word < size; word += 8 {
+			g.writeLine(fmt.Sprintf("    ldr x1, [x0, #%d]", word))
+			g.writeLine(fmt.Sprintf("    str x1, [x16, #%d]", word))
+		}
+		g.writeLine(fmt.Sprintf("    str x16, [x29, #-%d]", offset))
 	}
-	g.writeLine("    add sp, sp, #64")         // Restore stack space
-	g.writeLine("    ldp x29, x30, [sp], #16") // Restore frame pointer and link register
-	g.writeLine("    ret")                     // Return
 }
 
-func (g *ARM64Generator) generateSwitchStatement(stmt *ast.SwitchStatement) {
+// generateRangeStatement lowers a range loop into an index loop. The range
+// expression is kept in a hidden local along with the index, and the key and
+// value are stored in the iteration variables at the start of each
+// iteration. With := the variables get slots of their own, visible only in
+// the body, and a variable captured by a closure gets a new heap cell in
+// every iteration.
+func (g *ARM64Generator) generateRangeStatement(stmt *ast.RangeStatement) {
+	rangeType := g.types.exprType(stmt.Expression)
+	keyType, valueType := rangeTypes(rangeType)
+	startLabel := g.getNewLabel()
+	nextLabel := g.getNewLabel()
 	endLabel := g.getNewLabel()
 
-	// Generate the switch value and store it
-	g.writeLine("    // switch expression")
-	g.generateExpression(stmt.Value)
-	g.writeLine("    str x0, [sp, #-16]!") // Store switch value on stack
-
-	// Generate case comparisons and labels
-	caseLabels := make([]string, len(stmt.Cases))
-	for i := range stmt.Cases {
-		caseLabels[i] = g.getNewLabel()
+	// Hidden locals: the range expression, the index and a scratch word
+	g.writeLine(fmt.Sprintf("    // for range %s", rangeType))
+	g.generateExpression(stmt.Expression)
+	if isArrayType(rangeType) && isIterationVariable(stmt.Value) && !isNewValue(stmt.Expression) {
+		// The values come from a copy of the array
+		g.copyValue(rangeType)
 	}
+	g.stackSize += 24
+	rangeOffset := g.stackSize
+	indexOffset := rangeOffset - 8
+	scratchOffset := rangeOffset - 16
+	g.writeLine(fmt.Sprintf("    str x0, [x29, #-%d]", rangeOffset))
+	g.writeLine(fmt.Sprintf("    str xzr, [x29, #-%d]", indexOffset))
 
-	defaultLabel := g.getNewLabel()
-	if stmt.Default != nil {
-		defaultLabel = g.getNewLabel()
-	}
+	// The iteration variables are only visible inside the body
+	restore := g.shadowRangeVariables(stmt)
 
-	// Compare each case value
-	for i, caseStmt := range stmt.Cases {
-		g.writeLine(fmt.Sprintf("    // case %d comparison", i))
-		g.generateExpression(caseStmt.Value)
-		g.writeLine("    ldr x1, [sp]") // Load switch value from stack
-		g.writeLine("    cmp x1, x0")
-		g.writeLine(fmt.Sprintf("    beq %s", caseLabels[i]))
+	g.writeLine(startLabel + ":")
+	switch {
+	case isSliceType(rangeType):
+		g.writeLine(fmt.Sprintf("    ldr x2, [x29, #-%d]", rangeOffset))
+		g.writeLine("    cbz x2, " + endLabel)
+		g.writeLine(fmt.Sprintf("    ldr x0, [x29, #-%d]", indexOffset))
+		g.writeLine("    ldr x3, [x2, #8]")
+		g.writeLine("    cmp x0, x3")
+		g.writeLine("    b.ge " + endLabel)
+		if isIterationVariable(stmt.Value) {
+			g.writeLine("    ldr x2, [x2]")
+			if g.types.isInterface(valueType) {
+				g.writeLine("    add x2, x2, x0, lsl #4")
+				g.writeLine("    ldr x0, [x2]")
+				g.writeLine("    ldr x1, [x2, #8]")
+			} else {
+				g.writeLine("    ldr x0, [x2, x0, lsl #3]")
+			}
+			g.bindRangeVariable(stmt, stmt.Value, valueType)
+		}
+	case rangeType == "string":
+		// Each iteration decodes the rune at the index and moves the index
+		// past it
+		g.writeLine(fmt.Sprintf("    ldr x0, [x29, #-%d]", rangeOffset))
+		g.writeLine(fmt.Sprintf("    ldr x1, [x29, #-%d]", indexOffset))
+		g.writeLine("    add x0, x0, x1")
+		g.writeLine("    ldrb w2, [x0]")
+		g.writeLine("    cbz w2, " + endLabel)
+		g.writeLine("    bl _decode_rune")
+		g.writeLine(fmt.Sprintf("    ldr x2, [x29, #-%d]", indexOffset))
+		g.writeLine("    add x1, x1, x2")
+		g.writeLine(fmt.Sprintf("    str x1, [x29, #-%d]", scratchOffset))
+		g.bindRangeVariable(stmt, stmt.Value, valueType)
+	case ast.IsMapType(rangeType):
+		// The index walks the entries, skipping the ones not in use
+		g.writeLine(fmt.Sprintf("    ldr x2, [x29, #-%d]", rangeOffset))
+		g.writeLine("    cbz x2, " + endLabel)
+		g.writeLine(fmt.Sprintf("    ldr x0, [x29, #-%d]", indexOffset))
+		g.writeLine("    ldr x3, [x2, #8]")
+		g.writeLine("    cmp x0, x3")
+		g.writeLine("    b.ge " + endLabel)
+		g.writeLine("    ldr x3, [x2, #32]")
+		g.writeLine("    add x3, x3, x0, lsl #5")
+		g.writeLine("    ldr x4, [x3]")
+		g.writeLine("    cmp x4, #1")
+		g.writeLine("    b.ne " + nextLabel)
+		g.writeLine(fmt.Sprintf("    str x3, [x29, #-%d]", scratchOffset))
+		if isIterationVariable(stmt.Value) {
+			g.writeLine(fmt.Sprintf("    ldr x3, [x29, #-%d]", scratchOffset))
+			g.writeLine("    ldr x0, [x3, #16]")
+			g.writeLine("    ldr x1, [x3, #24]")
+			g.bindRangeVariable(stmt, stmt.Value, valueType)
+		}
+		if isIterationVariable(stmt.Key) {
+			g.writeLine(fmt.Sprintf("    ldr x3, [x29, #-%d]", scratchOffset))
+			g.writeLine("    ldr x0, [x3, #8]")
+			g.bindRangeVariable(stmt, stmt.Key, keyType)
+		}
+	default:
+		// for i := range n counts from 0 to n-1
+		g.writeLine(fmt.Sprintf("    ldr x0, [x29, #-%d]", indexOffset))
+		g.writeLine(fmt.Sprintf("    ldr x1, [x29, #-%d]", rangeOffset))
+		g.writeLine("    cmp x0, x1")
+		g.writeLine("    b.ge " + endLabel)
+	}
+	if !ast.IsMapType(rangeType) && isIterationVariable(stmt.Key) {
+		// The key is the index itself
+		g.writeLine(fmt.Sprintf("    ldr x0, [x29, #-%d]", indexOffset))
+		g.bindRangeVariable(stmt, stmt.Key, keyType)
 	}
 
-	// If no case matched, go to default or end
-	if stmt.Default != nil {
-		g.writeLine(fmt.Sprintf("    b %s", defaultLabel))
+	g.branches.push(endLabel, nextLabel)
+	g.generateBlock(stmt.Body)
+	g.branches.pop()
+
+	g.writeLine(nextLabel + ":")
+	if rangeType == "string" {
+		g.writeLine(fmt.Sprintf("    ldr x0, [x29, #-%d]", scratchOffset))
 	} else {
-		g.writeLine(fmt.Sprintf("    b %s", endLabel))
+		g.writeLine(fmt.Sprintf("    ldr x0, [x29, #-%d]", indexOffset))
+		g.writeLine("    add x0, x0, #1")
 	}
+	g.writeLine(fmt.Sprintf("    str x0, [x29, #-%d]", indexOffset))
+	g.writeLine("    b " + startLabel)
+	g.writeLine(endLabel + ":")
+	restore()
+}
 
-	// Generate case bodies
-	for i, caseStmt := range stmt.Cases {
-		g.writeLine(fmt.Sprintf("%s:", caseLabels[i]))
-		g.writeLine(fmt.Sprintf("    // case %d body", i))
-		g.generateBlock(caseStmt.Body)
-		g.writeLine(fmt.Sprintf("    b %s", endLabel)) // break to end
+// bindRangeVariable stores the value in x0 (and x1) in an iteration
+// variable of a range loop. With := the variable gets a new slot.
+func (g *ARM64Generator) bindRangeVariable(stmt *ast.RangeStatement, name, typeName string) {
+	if !isIterationVariable(name) {
+		return
 	}
-
-	// Generate default case if exists
-	if stmt.Default != nil {
-		g.writeLine(fmt.Sprintf("%s:", defaultLabel))
-		g.writeLine("    // default case")
-		g.generateBlock(stmt.Default)
+	if g.types.isValueBlock(typeName) {
+		g.copyValue(typeName)
+	}
+	if stmt.Define {
+		g.storeValue(g.allocate(name, typeName), typeName)
+		return
+	}
+	if offset, exists := g.variables[name]; exists {
+		g.storeValue(offset, g.types.varTypes[name])
 	}
-
-	// End label
-	g.writeLine(fmt.Sprintf("%s:", endLabel))
-	g.writeLine("    add sp, sp, #16") // Restore stack (remove stored switch value)
 }
 
-func (g *ARM64Generator) generateFunctionCall(call *ast.CallNode) {
-	if len(call.Arguments) > 0 {
-		g.generateExpression(call.Arguments[0])
+// shadowRangeVariables returns a function restoring the variables that the
+// iteration variables declared by a range loop shadow
+func (g *ARM64Generator) shadowRangeVariables(stmt *ast.RangeStatement) (restore func()) {
+	if !stmt.Define {
+		return func() {}
+	}
+	offsets := make(map[string]int)
+	types := make(map[string]string)
+	for _, name := range []string{stmt.Key, stmt.Value} {
+		if offset, exists := g.variables[name]; exists {
+			offsets[name] = offset
+			types[name] = g.types.varTypes[name]
+		}
+	}
+	return func() {
+		for _, name := range []string{stmt.Key, stmt.Value} {
+			if offset, shadowed := offsets[name]; shadowed {
+				g.variables[name] = offset
+				g.types.varTypes[name] = types[name]
+			} else {
+				delete(g.variables, name)
+				delete(g.types.varTypes, name)
+			}
+		}
 	}
-	g.writeLine(fmt.Sprintf("    bl _%s", call.Function))
 }
 
-func (g *ARM64Generator) generateFieldAccess(node *ast.FieldAccessNode) {
-	g.writeLine("    // Field access: obj.field")
-	g.generateExpression(node.Object)
-	fieldOffset := getFieldOffset(node.Field)
-	g.writeLine(fmt.Sprintf("    // Access field '%s' at offset %d", node.Field, fieldOffset))
-	g.writeLine(fmt.Sprintf("    ldr x0, [x0, #%d]", fieldOffset))
-}
+// arm64ArgumentRegisters pass the arguments of a call in order
+var arm64ArgumentRegisters = []string{"x0", "x1", "x2", "x3", "x4", "x5", "x6", "x7"}
 
-func (g *ARM64Generator) generateSliceLiteral(node *ast.SliceLiteral) {
-	g.writeLine("    // Slice literal creation")
-	elementCount := len(node.Elements)
-	g.writeLine(fmt.Sprintf("    mov x0, #%d", elementCount))
-}
+// arm64ResultRegisters hold the results of a call in order, so a function
+// returns at most len(arm64ResultRegisters) values. Callers store them into
+// their stack slots right after the call.
+var arm64ResultRegisters = []string{"x0", "x1", "x2", "x3", "x4", "x5", "x6", "x7"}
 
-func (g *ARM64Generator) generateIndexAccess(node *ast.IndexAccess) {
-	g.writeLine("    // Index access: arr[index]")
-	g.generateExpression(node.Object)
-	g.writeLine("    str x0, [sp, #-16]!") // Store array base address
-	g.generateExpression(node.Index)
-	g.writeLine("    ldr x1, [sp], #16") // Load array base address
-	g.writeLine("    lsl x0, x0, #3")    // x0 = index * 8
-	g.writeLine("    add x0, x1, x0")    // x0 = base + offset
-	g.writeLine("    ldr x0, [x0]")      // Load value at address
+// generateTypeAssert generates a type assertion x.(T). The result is left in
+// x0, with the type descriptor in x1 when T is an interface type, and x2 is
+// set to 1 when the assertion holds. A failed assertion panics, unless
+// commaOk is set for v, ok := x.(T); then the result is the zero value of T
+// and x2 is 0.
+func (g *ARM64Generator) generateTypeAssert(e *ast.TypeAssertNode, commaOk bool) {
+	okLabel := g.getNewLabel()
+	endLabel := g.getNewLabel()
+
+	g.generateExpression(e.Expression)
+	g.writeLine(fmt.Sprintf("    // Type assertion: .(%s)", e.Type))
+	g.generateTypeTest(e.Type, okLabel)
+	if commaOk {
+		g.generateExpressionAs(g.types.zeroValue(e.Type), e.Type)
+		g.writeLine("    mov x2, #0")
+		g.writeLine("    b " + endLabel)
+	} else {
+		g.writeLine("    bl _type_assert_panic")
+	}
+	g.writeLine(okLabel + ":")
+	g.writeLine("    mov x2, #1")
+	g.writeLine(endLabel + ":")
 }
 
-func (g *ARM64Generator) getNewLabel() string {
-	g.labelNum++
-	return fmt.Sprintf("L%d", g.labelNum)
+// generateTypeTest branches to label when the type descriptor in x1 matches
+// typeName: nil matches a nil interface, a concrete type its own
+// descriptor, and an interface type every descriptor whose method table has
+// all of its methods. It falls through otherwise.
+func (g *ARM64Generator) generateTypeTest(typeName, label string) {
+	switch {
+	case typeName == "nil":
+		g.writeLine("    cbz x1, " + label)
+	case g.types.isInterface(typeName):
+		nextLabel := g.getNewLabel()
+		g.writeLine("    cbz x1, " + nextLabel)
+		for _, method := range g.types.interfaces[typeName] {
+			g.writeLine(fmt.Sprintf("    ldr x2, [x1, #%d] // %s", g.types.methodOffset(method), method))
+			g.writeLine("    cbz x2, " + nextLabel)
+		}
+		g.writeLine("    b " + label)
+		g.writeLine(nextLabel + ":")
+	default:
+		descriptor := g.typeDescriptor(constant.CanonicalType(typeName))
+		g.writeLine(fmt.Sprintf("    adrp x2, %s@PAGE", descriptor))
+		g.writeLine(fmt.Sprintf("    add x2, x2, %s@PAGEOFF", descriptor))
+		g.writeLine("    cmp x1, x2")
+		g.writeLine("    beq " + label)
+	}
 }
 
-func (g *ARM64Generator) getStringLabel(value string) string {
-	if label, exists := g.stringLiterals[value]; exists {
-		return label
+// generateTypeSwitchStatement generates a type switch. The interface value
+// is kept in a hidden local and its type descriptor is tested against the
+// types of each case in source order. The bound variable gets a slot of its
+// own in every clause.
+func (g *ARM64Generator) generateTypeSwitchStatement(stmt *ast.TypeSwitchStatement) {
+	if stmt.Init != nil {
+		g.generateStatement(stmt.Init)
 	}
 
-	label := fmt.Sprintf("str_%d", g.stringCount)
-	g.stringLiterals[value] = label
-	g.stringCount++
-	return label
-}
+	endLabel := g.getNewLabel()
 
-func (g *ARM64Generator) generateStringLiterals() {
-	if len(g.stringLiterals) == 0 {
-		return
+	g.writeLine("    // type switch expression")
+	valueType := g.types.exprType(stmt.Value)
+	g.generateInterfaceValue(stmt.Value)
+	g.stackSize += 16
+	valueOffset := g.stackSize
+	g.storeValue(valueOffset, "any")
+
+	caseLabels := make([]string, len(stmt.Cases))
+	for i := range stmt.Cases {
+		caseLabels[i] = g.getNewLabel()
 	}
 
-	g.writeLine("")
-	g.writeLine(".section __TEXT,__cstring,cstring_literals")
+	// If no case matches, go to the default clause or the end
+	defaultLabel := endLabel
+	for i, clause := range stmt.Cases {
+		if clause.IsDefault() {
+			defaultLabel = caseLabels[i]
+			continue
+		}
+		g.writeLine(fmt.Sprintf("    // case %d: %s", i, strings.Join(clause.Types, ", ")))
+		g.writeLine(fmt.Sprintf("    ldr x1, [x29, #-%d]", valueOffset-8))
+		for _, typeName := range clause.Types {
+			g.generateTypeTest(typeName, caseLabels[i])
+		}
+	}
+	g.writeLine(fmt.Sprintf("    b %s", defaultLabel))
 
-	for value, label := range g.stringLiterals {
-		g.writeLine(fmt.Sprintf("%s:", label))
-		g.writeLine(fmt.Sprintf("    .asciz \"%s\"", value))
+	// The bound variable is only visible inside the clauses
+	previousOffset, shadowed := g.variables[stmt.Binding]
+	previousType := g.types.varTypes[stmt.Binding]
+
+	g.branches.push(endLabel, "")
+	for i, clause := range stmt.Cases {
+		g.writeLine(fmt.Sprintf("%s:", caseLabels[i]))
+		if clause.IsDefault() {
+			g.writeLine("    // default case")
+		} else {
+			g.writeLine(fmt.Sprintf("    // case %d body", i))
+		}
+		if stmt.Binding != "" {
+			// The variable has the type of the case in clauses listing a
+			// single type, and the type of x in the others
+			bindingType := valueType
+			if len(clause.Types) == 1 && clause.Types[0] != "nil" {
+				bindingType = constant.CanonicalType(clause.Types[0])
+			}
+			offset := g.allocate(stmt.Binding, bindingType)
+			g.writeLine(fmt.Sprintf("    ldr x0, [x29, #-%d]", valueOffset))
+			g.writeLine(fmt.Sprintf("    ldr x1, [x29, #-%d]", valueOffset-8))
+			g.storeValue(offset, bindingType)
+		}
+		g.generateBlock(clause.Body)
+		g.writeLine(fmt.Sprintf("    b %s", endLabel)) // break to end
 	}
-}
+	g.branches.pop()
 
-func (g *ARM64Generator) writeLine(s string) {
-	g.output.WriteString(s + "\n")
+	if stmt.Binding != "" {
+		if shadowed {
+			g.variables[stmt.Binding] = previousOffset
+			g.types.varTypes[stmt.Binding] = previousType
+		} else {
+			delete(g.variables, stmt.Binding)
+			delete(g.types.varTypes, stmt.Binding)
+		}
+	}
+
+	// End label
+	g.writeLine(fmt.Sprintf("%s:", endLabel))
 }
 
-// getFieldOffset returns a simple field offset (simplified implementation)
-func getFieldOffset(fieldName string) int {
-	switch fieldName {
-	case "x", "name", "first":
-		return 0
-	case "y", "age", "second":
-		return 8
-	case "z", "third":
-		return 16
-	default:
-		return 0
+func (g *ARM64Generator) generateReturnStatement(stmt *ast.ReturnStatement) {
+	if g.defers.exitLabel != "" {
+		g.generateDeferReturn(stmt)
+		return
 	}
-}
 
-func (g *ARM64Generator) GenerateRuntime() string {
-	runtime := `
-// Runtime function to print numbers
-.p2align 2
-_print_number:
+	values := g.types.returnValues(stmt)
+	switch {
+	case len(values) == 1 && g.types.isMultiValueCall(values[0]):
+		// return f() passes the result registers of f through
+		g.generateExpression(values[0])
+	case len(values) == 1:
+		g.generateStoredValue(values[0], g.types.resultType(0))
+	case len(values) > 1:
+		// Evaluate every result before filling the result registers
+		if len(values) > len(arm64ResultRegisters) {
+			values = values[:len(arm64ResultRegisters)]
+		}
+		for i, value := range values {
+			g.generateStoredValue(value, g.types.resultType(i))
+			g.writeLine("    str x0, [sp, #-16]!")
+		}
+		for i := len(values) - 1; i >= 0; i-- {
+			g.writeLine(fmt.Sprintf("    ldr %s, [sp], #16", arm64ResultRegisters[i]))
+		}
+	}
+	g.writeLine("    add sp, sp, #" + frameSizePlaceholder)
+	g.writeLine("    ldp x29, x30, [sp], #16") // Restore frame pointer and link register
+	g.writeLine("    ret")                     // Return
+}
+
+// enterDeferFrame prepares a function with defer statements: its results
+// are kept in its named results or in hidden variables starting at their
+// zero values, which a recovered panic returns
+func (g *ARM64Generator) enterDeferFrame(funcStmt *ast.FuncStatement) {
+	g.defers.exitLabel = g.getNewLabel()
+	for _, result := range funcStmt.Results {
+		name := result.Name
+		if !funcStmt.HasNamedResults() {
+			name = "." + g.getNewLabel()
+			offset := g.allocate(name, ast.TypeName(result.Type))
+			g.writeLine(fmt.Sprintf("    // Result: %s", name))
+			g.generateExpressionAs(g.types.zeroValue(ast.TypeName(result.Type)), ast.TypeName(result.Type))
+			g.storeValue(offset, ast.TypeName(result.Type))
+		}
+		g.defers.results = append(g.defers.results, name)
+	}
+}
+
+// generateDeferReturn generates a return statement of a function with defer
+// statements, which stores the results and jumps to the exit code
+func (g *ARM64Generator) generateDeferReturn(stmt *ast.ReturnStatement) {
+	results := g.defers.results
+	if len(stmt.Values) == 1 && g.types.isMultiValueCall(stmt.Values[0]) {
+		// return f() stores the result registers of f, one word each
+		g.generateExpression(stmt.Values[0])
+		if len(results) > len(arm64ResultRegisters) {
+			results = results[:len(arm64ResultRegisters)]
+		}
+		for i := range results {
+			g.writeLine(fmt.Sprintf("    str %s, [sp, #-16]!", arm64ResultRegisters[i]))
+		}
+		for i := len(results) - 1; i >= 0; i-- {
+			g.writeLine("    ldr x0, [sp], #16")
+			g.storeValue(g.variables[results[i]], g.types.resultType(i))
+		}
+		g.writeLine("    b " + g.defers.exitLabel)
+		return
+	}
+
+	// Evaluate every result before storing any, as in return b, a. A bare
+	// return leaves the named results as they are.
+	values := stmt.Values
+	if len(values) > len(results) {
+		values = values[:len(results)]
+	}
+	for i, value := range values {
+		typeName := g.types.resultType(i)
+		g.generateStoredValue(value, typeName)
+		g.writeLine("    str x0, [sp, #-16]!")
+		if g.types.isInterface(typeName) {
+			g.writeLine("    str x1, [sp, #-16]!")
+		}
+	}
+	for i := len(values) - 1; i >= 0; i-- {
+		typeName := g.types.resultType(i)
+		if g.types.isInterface(typeName) {
+			g.writeLine("    ldr x1, [sp], #16")
+		}
+		g.writeLine("    ldr x0, [sp], #16")
+		g.storeValue(g.variables[results[i]], typeName)
+	}
+	g.writeLine("    b " + g.defers.exitLabel)
+}
+
+// generateDeferExit generates the exit code of a function with defer
+// statements: it runs the deferred calls and returns the results, or exits
+// for main
+func (g *ARM64Generator) generateDeferExit(main bool) {
+	g.writeLine(g.defers.exitLabel + ":")
+	g.writeLine("    // Exit code: a recovered panic resumes here")
+	g.writeLine("    sub sp, x29, #" + frameSizePlaceholder)
+	g.writeLine("    mov x0, x29")
+	g.writeLine("    bl _run_defers")
+
+	if main {
+		g.writeLine("    // Exit")
+		g.writeLine("    add sp, sp, #" + frameSizePlaceholder)
+		g.writeLine("    ldp x29, x30, [sp], #16")
+		g.writeLine("    mov x0, #0")  // exit status
+		g.writeLine("    mov x16, #1") // sys_exit
+		g.writeLine("    svc #0x80")
+		return
+	}
+
+	results := g.defers.results
+	switch {
+	case len(results) == 1:
+		g.loadValue(g.variables[results[0]], g.types.resultType(0))
+	case len(results) > 1:
+		if len(results) > len(arm64ResultRegisters) {
+			results = results[:len(arm64ResultRegisters)]
+		}
+		for i, name := range results {
+			g.loadValue(g.variables[name], g.types.resultType(i))
+			g.writeLine("    str x0, [sp, #-16]!")
+		}
+		for i := len(results) - 1; i >= 0; i-- {
+			g.writeLine(fmt.Sprintf("    ldr %s, [sp], #16", arm64ResultRegisters[i]))
+		}
+	}
+	g.writeLine("    add sp, sp, #" + frameSizePlaceholder)
+	g.writeLine("    ldp x29, x30, [sp], #16")
+	g.writeLine("    ret")
+}
+
+// generateDeferStatement evaluates the function value and the arguments of
+// a deferred call into a defer record and pushes it onto the defer chain
+func (g *ARM64Generator) generateDeferStatement(s *ast.DeferStatement) {
+	call := s.Call
+	if g.types.isRecover(call) {
+		// A deferred recover() is not called by a deferred function, so
+		// it never recovers
+		g.writeLine("    // defer recover(): nothing to do")
+		return
+	}
+	if g.types.isBuiltinCall(call) {
+		hidden := make([]string, len(call.Arguments))
+		for i, arg := range call.Arguments {
+			hidden[i] = "." + g.getNewLabel()
+			g.captured[hidden[i]] = true
+			g.generateStatement(&ast.AssignStatement{Name: hidden[i], Value: arg, Pos: s.Pos})
+		}
+		call = &ast.CallNode{Callee: deferredBuiltin(call, hidden), Pos: call.Pos}
+	}
+
+	call = g.types.fieldCall(call)
+	symbol := g.types.callSymbol(call)
+	args := callArguments(call)
+	words := 0
+	for i := range args {
+		n := g.types.words(g.types.paramType(symbol, i))
+		if words+n > len(arm64ArgumentRegisters) {
+			args = args[:i]
+			break
+		}
+		words += n
+	}
+
+	g.writeLine(fmt.Sprintf("    // defer %s", symbol))
+	dynamic := g.types.isDynamicCall(call)
+	switch {
+	case dynamic:
+		// The method of the receiver's type descriptor is the code of the
+		// closure, and its data word the receiver
+		g.generateExpression(args[0])
+		g.writeLine("    cbz x1, _nil_panic")
+		g.writeLine(fmt.Sprintf("    ldr x2, [x1, #%d]", g.types.methodOffset(call.Function)))
+		g.writeLine("    str x2, [sp, #-16]!")
+		g.writeLine("    str x0, [sp, #-16]!")
+		g.pushArguments(symbol, args, 1)
+	case ast.IsFuncType(symbol):
+		g.generateExpression(callee(call))
+		g.writeLine("    str x0, [sp, #-16]!")
+		g.pushArguments(symbol, args, 0)
+	default:
+		g.staticClosures[symbol] = true
+		g.writeLine(fmt.Sprintf("    adrp x0, %s@PAGE", staticClosureSymbol(symbol)))
+		g.writeLine(fmt.Sprintf("    add x0, x0, %s@PAGEOFF", staticClosureSymbol(symbol)))
+		g.writeLine("    str x0, [sp, #-16]!")
+		g.pushArguments(symbol, args, 0)
+	}
+
+	g.writeLine(fmt.Sprintf("    mov x16, #%d", 8*(deferRecordWords+len(arm64ArgumentRegisters))))
+	g.writeLine("    bl _alloc")
+	for i := words - 1; i >= 0; i-- {
+		g.writeLine("    ldr x0, [sp], #16")
+		g.writeLine(fmt.Sprintf("    str x0, [x16, #%d]", 8*(deferRecordWords+i)))
+	}
+	g.writeLine("    ldr x0, [sp], #16")
+	if dynamic {
+		g.writeLine("    str x0, [x16, #32] // code")
+		g.writeLine("    add x0, x16, #32")
+	}
+	g.writeLine("    str x0, [x16, #24] // closure")
+	g.writeLine("    str x29, [x16, #8] // frame")
+	g.writeLine(fmt.Sprintf("    adr x0, %s", g.defers.exitLabel))
+	g.writeLine("    str x0, [x16, #16] // resume")
+	g.writeLine("    adrp x17, defer_top@PAGE")
+	g.writeLine("    add x17, x17, defer_top@PAGEOFF")
+	g.writeLine("    ldr x0, [x17]")
+	g.writeLine("    str x0, [x16]      // next")
+	g.writeLine("    str x16, [x17]")
+}
+
+// generatePanicBuiltin generates panic(v) and recover(), and reports
+// whether call is one of them
+func (g *ARM64Generator) generatePanicBuiltin(call *ast.CallNode) bool {
+	switch {
+	case call.Function == "panic" && len(call.Arguments) == 1 && g.types.isBuiltinCall(call):
+		g.writeLine("    // panic(v)")
+		g.generateInterfaceValue(call.Arguments[0])
+		g.writeLine("    bl _panic")
+	case g.types.isRecover(call):
+		// _recover tells a deferred call run by _panic by its return address
+		g.writeLine("    // recover()")
+		g.writeLine("    ldr x0, [x29, #8]")
+		g.writeLine("    bl _recover")
+	default:
+		return false
+	}
+	return true
+}
+
+// generateVarStatement declares a variable, initialized with its value or
+// with the zero value of its type
+func (g *ARM64Generator) generateVarStatement(s *ast.VarStatement) {
+	typeName := ast.TypeName(s.Type)
+	if typeName == "" {
+		typeName = g.types.exprType(s.Value)
+	}
+	offset := g.allocate(s.Name, typeName)
+	g.writeLine(fmt.Sprintf("    // var %s", s.Name))
+	if s.Value == nil {
+		g.generateZeroValue(typeName)
+	} else {
+		g.generateStoredValue(s.Value, typeName)
+	}
+	g.storeValue(offset, typeName)
+}
+
+// initializeGlobals assigns the package-level variables their values, or
+// the zero values that are not zero bytes, in declaration order
+func (g *ARM64Generator) initializeGlobals() {
+	for _, decl := range g.types.globalDecls {
+		switch s := decl.(type) {
+		case *ast.VarStatement:
+			g.initializeGlobal(s.Name, s.Value)
+		case *ast.TupleVarStatement:
+			if s.Values != nil {
+				g.generateTupleAssign(&ast.TupleAssignStatement{Targets: variableTargets(s.Names, s.Pos), Values: s.Values, Pos: s.Pos})
+				continue
+			}
+			for _, name := range s.Names {
+				g.initializeGlobal(name, nil)
+			}
+		}
+	}
+}
+
+// initializeGlobal assigns a package-level variable its value, or its zero
+// value when value is nil
+func (g *ARM64Generator) initializeGlobal(name string, value ast.ASTNode) {
+	offset, exists := g.variables[name]
+	if !exists {
+		// The blank identifier only evaluates the value
+		if value != nil {
+			g.generateExpression(value)
+		}
+		return
+	}
+	typeName := g.types.globalTypes[name]
+	g.writeLine(fmt.Sprintf("    // var %s", name))
+	switch {
+	case value != nil:
+		g.generateStoredValue(value, typeName)
+	case g.types.needsZeroing(typeName):
+		g.generateZeroValue(typeName)
+	default:
+		return
+	}
+	g.storeValue(offset, typeName)
+}
+
+// generateTupleVar declares several variables at once. Their values are
+// assigned like those of a tuple assignment, so the results of a call and
+// the comma-ok forms work the same way.
+func (g *ARM64Generator) generateTupleVar(s *ast.TupleVarStatement) {
+	if s.Values == nil {
+		for _, name := range s.Names {
+			if name != "_" {
+				g.generateVarStatement(&ast.VarStatement{Name: name, Type: s.Type, Pos: s.Pos})
+			}
+		}
+		return
+	}
+	assign := &ast.TupleAssignStatement{Targets: variableTargets(s.Names, s.Pos), Define: true, Values: s.Values, Pos: s.Pos}
+	for i, name := range s.Names {
+		if name == "_" {
+			continue
+		}
+		typeName := ast.TypeName(s.Type)
+		if typeName == "" {
+			typeName = g.types.tupleValueType(assign, i)
+		}
+		g.allocate(name, typeName)
+	}
+	g.generateTupleAssign(assign)
+}
+
+// generateZeroValue leaves the zero value of a type in x0 (and x1). The
+// zero value of a struct is a new struct on the heap with all fields zero,
+// and that of an array a new array of zero elements.
+func (g *ARM64Generator) generateZeroValue(typeName string) {
+	if isArrayType(typeName) {
+		g.generateElements(typeName, nil)
+		return
+	}
+	if !g.types.isStruct(typeName) {
+		g.generateExpressionAs(g.types.zeroValue(typeName), typeName)
+		return
+	}
+	g.writeLine(fmt.Sprintf("    // Zero value: %s", typeName))
+	g.writeLine(fmt.Sprintf("    mov x16, #%d", g.types.structSize(typeName)))
+	g.writeLine("    bl _alloc")
+	g.writeLine("    mov x0, x16")
+	if fields := g.types.blockFields(typeName, nil); len(fields) > 0 {
+		g.writeLine("    str x0, [sp, #-16]!")
+		g.generateZeroFields(typeName, fields)
+		g.writeLine("    ldr x0, [sp], #16")
+	}
+}
+
+// generateZeroFields stores the zero values of fields of a struct in its
+// block on top of the stack
+func (g *ARM64Generator) generateZeroFields(typeName string, fields []string) {
+	for _, name := range fields {
+		g.generateZeroValue(constant.CanonicalType(ast.TypeName(g.types.field(typeName, name).Type)))
+		g.writeLine("    ldr x2, [sp]")
+		g.writeLine(fmt.Sprintf("    str x0, [x2, #%d] // %s", g.types.fieldOffset(typeName, name), name))
+	}
+}
+
+// generateStoredValue generates a value stored as a value of a type, in x0
+// (and x1), copying a struct or an array that is not new
+func (g *ARM64Generator) generateStoredValue(expr ast.ASTNode, typeName string) {
+	g.generateExpressionAs(expr, typeName)
+	if copyType := g.types.storedCopyType(expr, typeName); copyType != "" {
+		g.copyValue(copyType)
+	}
+}
+
+// copyValue replaces the struct or array in x0 by a copy of it
+func (g *ARM64Generator) copyValue(typeName string) {
+	g.copies[typeName] = true
+	g.writeLine("    bl " + copySymbol(typeName))
+}
+
+// generateCopyRoutines generates the routines copying the types in
+// g.copies, and those of their fields and elements
+func (g *ARM64Generator) generateCopyRoutines() {
+	done := make(map[string]bool)
+	for typeNames := pendingCopies(g.copies, done); len(typeNames) > 0; typeNames = pendingCopies(g.copies, done) {
+		for _, typeName := range typeNames {
+			done[typeName] = true
+			g.generateCopyRoutine(typeName)
+		}
+	}
+}
+
+// generateCopyRoutine generates the routine copying the struct or array in
+// x0 to a new block returned in x0. It changes x9 to x11, x16 and x17.
+func (g *ARM64Generator) generateCopyRoutine(typeName string) {
+	g.writeLine("")
+	g.writeLine(".p2align 2")
+	g.writeLine(copySymbol(typeName) + ":")
+	g.writeLine("    stp x29, x30, [sp, #-16]!")
+	if !isArrayType(typeName) {
+		g.writeLine(fmt.Sprintf("    mov x9, #%d", g.types.structSize(typeName)))
+		g.writeLine("    bl _copy_struct")
+		if fields := g.types.blockFields(typeName, nil); len(fields) > 0 {
+			g.writeLine("    str x0, [sp, #-16]!")
+			for _, name := range fields {
+				offset := g.types.fieldOffset(typeName, name)
+				g.writeLine("    ldr x0, [sp]")
+				g.writeLine(fmt.Sprintf("    ldr x0, [x0, #%d] // %s", offset, name))
+				g.copyValue(constant.CanonicalType(ast.TypeName(g.types.field(typeName, name).Type)))
+				g.writeLine("    ldr x11, [sp]")
+				g.writeLine(fmt.Sprintf("    str x0, [x11, #%d]", offset))
+			}
+			g.writeLine("    ldr x0, [sp], #16")
+		}
+		g.writeLine("    ldp x29, x30, [sp], #16")
+		g.writeLine("    ret")
+		return
+	}
+
+	// An array gets a new header and a copy of its elements
+	elementType := constant.CanonicalType(sliceElementType(typeName))
+	length := arrayLength(typeName)
+	g.writeLine("    ldr x0, [x0]")
+	g.writeLine(fmt.Sprintf("    mov x9, #%d", length*g.types.size(elementType)))
+	g.writeLine("    bl _copy_struct")
+	g.writeLine(fmt.Sprintf("    mov x16, #%d", sliceHeaderSize))
+	g.writeLine("    bl _alloc")
+	g.writeLine("    str x0, [x16]")
+	g.writeLine(fmt.Sprintf("    mov x9, #%d", length))
+	g.writeLine("    str x9, [x16, #8]")
+	g.writeLine("    str x9, [x16, #16]")
+	g.writeLine("    mov x0, x16")
+	if g.types.isValueBlock(elementType) {
+		// The header and the index of the element being copied are kept on
+		// the stack
+		loopLabel := g.getNewLabel()
+		doneLabel := g.getNewLabel()
+		g.writeLine("    stp x0, xzr, [sp, #-16]!")
+		g.writeLine(loopLabel + ":")
+		g.writeLine("    ldr x9, [sp, #8]")
+		g.writeLine(fmt.Sprintf("    mov x10, #%d", length))
+		g.writeLine("    cmp x9, x10")
+		g.writeLine("    b.ge " + doneLabel)
+		g.writeLine("    ldr x10, [sp]")
+		g.writeLine("    ldr x10, [x10]")
+		g.writeLine("    ldr x0, [x10, x9, lsl #3]")
+		g.copyValue(elementType)
+		g.writeLine("    ldp x10, x9, [sp]")
+		g.writeLine("    ldr x10, [x10]")
+		g.writeLine("    str x0, [x10, x9, lsl #3]")
+		g.writeLine("    add x9, x9, #1")
+		g.writeLine("    str x9, [sp, #8]")
+		g.writeLine("    b " + loopLabel)
+		g.writeLine(doneLabel + ":")
+		g.writeLine("    ldr x0, [sp], #16")
+	}
+	g.writeLine("    ldp x29, x30, [sp], #16")
+	g.writeLine("    ret")
+}
+
+// generateTupleAssign assigns several values at once. The results of a call
+// are stored straight from the result registers; other values are all
+// pushed before any variable is stored, so a, b = b, a swaps them. Targets
+// that are not all variables are assigned through hidden variables.
+func (g *ARM64Generator) generateTupleAssign(s *ast.TupleAssignStatement) {
+	if !assignsVariables(s) {
+		hidden := func() string { return "." + g.getNewLabel() }
+		for _, stmt := range lowerTupleAssign(s, hidden) {
+			g.generateStatement(stmt)
+		}
+		return
+	}
+	names := s.Names()
+	g.writeLine(fmt.Sprintf("    // %s %s values", strings.Join(names, ", "), assignOperator(s)))
+	if assert, ok := commaOkAssertion(s); ok {
+		// v, ok := x.(T) leaves the result in x0 (and x1) and ok in x2
+		g.generateTypeAssert(assert, true)
+		if offset, ok := g.tupleSlot(s, 0); ok {
+			g.storeValue(offset, g.types.varTypes[names[0]])
+		}
+		if offset, ok := g.tupleSlot(s, 1); ok {
+			g.writeLine("    str x2, " + g.operand(offset, 0))
+		}
+		return
+	}
+	if index, ok := g.types.commaOkMapIndex(s); ok {
+		// v, ok := m[k] leaves the value in x0 (and x1) and ok in x2
+		g.generateMapIndex(index)
+		if offset, ok := g.tupleSlot(s, 0); ok {
+			g.storeValue(offset, g.types.varTypes[names[0]])
+		}
+		if offset, ok := g.tupleSlot(s, 1); ok {
+			g.writeLine("    str x2, " + g.operand(offset, 0))
+		}
+		return
+	}
+	if len(s.Values) == 1 {
+		g.generateExpression(s.Values[0])
+		for i, name := range names {
+			if offset, ok := g.tupleSlot(s, i); ok && i < len(arm64ResultRegisters) {
+				g.writeLine(fmt.Sprintf("    str %s, %s", arm64ResultRegisters[i], g.operand(offset, 0)))
+			} else if ok {
+				g.writeLine(fmt.Sprintf("    // %s: no result register", name))
+			}
+		}
+		return
+	}
+
+	for i, value := range s.Values {
+		g.generateStoredValue(value, g.types.varTypes[names[i]])
+		g.writeLine("    str x0, [sp, #-16]!")
+	}
+	for i := len(names) - 1; i >= 0; i-- {
+		g.writeLine("    ldr x0, [sp], #16")
+		if offset, ok := g.tupleSlot(s, i); ok {
+			g.writeLine("    str x0, " + g.operand(offset, 0))
+		}
+	}
+}
+
+// tupleSlot returns the stack offset of the i-th variable of a tuple
+// assignment, allocating it when := declares a new variable. It reports
+// false for the blank identifier and unknown variables.
+func (g *ARM64Generator) tupleSlot(s *ast.TupleAssignStatement, i int) (int, bool) {
+	name := s.Names()[i]
+	if name == "_" {
+		return 0, false
+	}
+	if offset, exists := g.variables[name]; exists {
+		return offset, true
+	}
+	if !s.Define {
+		return 0, false
+	}
+	return g.allocate(name, g.types.tupleValueType(s, i)), true
+}
+
+func (g *ARM64Generator) generateSwitchStatement(stmt *ast.SwitchStatement) {
+	if stmt.Init != nil {
+		g.generateStatement(stmt.Init)
+	}
+
+	endLabel := g.getNewLabel()
+
+	// Generate the switch value and store it in a hidden local, so that
+	// returns from the case bodies leave the stack balanced. A tagless
+	// switch compares the cases with true.
+	valueType := "bool"
+	g.writeLine("    // switch expression")
+	if stmt.Value != nil {
+		valueType = g.types.exprType(stmt.Value)
+		g.generateExpression(stmt.Value)
+	} else {
+		g.writeLine("    mov x0, #1")
+	}
+	g.stackSize += 8
+	valueOffset := g.stackSize
+	g.writeLine(fmt.Sprintf("    str x0, [x29, #-%d]", valueOffset))
+
+	// Generate case comparisons and labels
+	caseLabels := make([]string, len(stmt.Cases))
+	for i := range stmt.Cases {
+		caseLabels[i] = g.getNewLabel()
+	}
+
+	// If no case matches, go to the default clause or the end
+	defaultLabel := endLabel
+
+	// Compare each case value in source order
+	for i, caseStmt := range stmt.Cases {
+		if caseStmt.IsDefault() {
+			defaultLabel = caseLabels[i]
+			continue
+		}
+		for _, value := range caseStmt.Values {
+			g.writeLine(fmt.Sprintf("    // case %d comparison", i))
+			g.generateExpressionAs(value, valueType)
+			switch valueType {
+			case "string", "float64":
+				g.writeLine("    mov x1, x0")
+				g.writeLine(fmt.Sprintf("    ldr x0, [x29, #-%d]", valueOffset))
+				g.generateEqual(valueType)
+				g.writeLine(fmt.Sprintf("    cbnz x0, %s", caseLabels[i]))
+			default:
+				g.writeLine(fmt.Sprintf("    ldr x1, [x29, #-%d]", valueOffset)) // Load switch value
+				g.writeLine("    cmp x1, x0")
+				g.writeLine(fmt.Sprintf("    beq %s", caseLabels[i]))
+			}
+		}
+	}
+	g.writeLine(fmt.Sprintf("    b %s", defaultLabel))
+
+	// Generate case bodies in source order, so that fallthrough just
+	// continues into the next body
+	g.branches.push(endLabel, "")
+	for i, caseStmt := range stmt.Cases {
+		g.writeLine(fmt.Sprintf("%s:", caseLabels[i]))
+		if caseStmt.IsDefault() {
+			g.writeLine("    // default case")
+		} else {
+			g.writeLine(fmt.Sprintf("    // case %d body", i))
+		}
+		g.generateBlock(caseStmt.Body)
+		if !caseStmt.Fallthrough() {
+			g.writeLine(fmt.Sprintf("    b %s", endLabel)) // break to end
+		}
+	}
+	g.branches.pop()
+
+	// End label
+	g.writeLine(fmt.Sprintf("%s:", endLabel))
+}
+
+// generateEqual compares the values of type typeName in x0 and x1 and
+// leaves 1 in x0 if they are equal, 0 otherwise
+func (g *ARM64Generator) generateEqual(typeName string) {
+	switch typeName {
+	case "string":
+		g.writeLine("    bl _string_equal")
+	case "float64":
+		// eq is false for unordered (NaN) operands
+		g.writeLine("    fmov d0, x0")
+		g.writeLine("    fmov d1, x1")
+		g.writeLine("    fcmp d0, d1")
+		g.writeLine("    cset x0, eq")
+	default:
+		g.writeLine("    cmp x0, x1")
+		g.writeLine("    cset x0, eq")
+	}
+}
+
+func (g *ARM64Generator) generateFunctionCall(call *ast.CallNode) {
+	// f(g()) passes the results of g as the arguments of f
+	if !isConversion(call) && !g.types.isBuiltinCall(call) {
+		hidden := func() string { return "." + g.getNewLabel() }
+		if assign, results, ok := g.types.spreadArguments(call.Arguments, hidden); ok {
+			g.generateStatement(assign)
+			spread := *call
+			spread.Arguments = results
+			call = &spread
+		}
+	}
+	g.types.checkSingleValues(call.Arguments)
+
+	// Conversions between int, int32 (rune) and float64
+	if isConversion(call) {
+		arg := call.Arguments[0]
+		g.generateExpression(arg)
+		if call.Function == "float64" {
+			if !g.types.isFloat(arg) {
+				g.writeLine("    scvtf d0, x0")
+				g.writeLine("    fmov x0, d0")
+			}
+			return
+		}
+		if g.types.isFloat(arg) {
+			g.writeLine("    fmov d0, x0")
+			g.writeLine("    fcvtzs x0, d0") // Truncate toward zero
+		}
+		g.truncateInteger(constant.CanonicalType(call.Function))
+		return
+	}
+
+	if g.generateMapBuiltin(call) || g.generateSliceBuiltin(call) || g.generatePanicBuiltin(call) {
+		return
+	}
+
+	call = g.types.fieldCall(call)
+	symbol := g.types.callSymbol(call)
+	args := callArguments(call)
+
+	// Interface values take two argument registers
+	words := 0
+	for i := range args {
+		n := g.types.words(g.types.paramType(symbol, i))
+		if words+n > len(arm64ArgumentRegisters) {
+			args = args[:i]
+			break
+		}
+		words += n
+	}
+
+	if g.types.isDynamicCall(call) {
+		// The method is looked up in the method table of the receiver's
+		// type descriptor, and its data word becomes the receiver. Calling
+		// a method of a nil interface value panics.
+		g.writeLine(fmt.Sprintf("    // Dynamic call: %s", symbol))
+		g.generateExpression(args[0])
+		g.writeLine("    cbz x1, _nil_panic")
+		g.writeLine("    str x1, [sp, #-16]!")
+		g.writeLine("    str x0, [sp, #-16]!")
+		g.pushArguments(symbol, args, 1)
+		g.popArguments(words)
+		g.writeLine("    ldr x16, [sp], #16")
+		g.writeLine(fmt.Sprintf("    ldr x16, [x16, #%d]", g.types.methodOffset(call.Function)))
+		g.writeLine("    blr x16")
+		return
+	}
+
+	if ast.IsFuncType(symbol) {
+		// The closure goes to x9 and its first word is the code address
+		g.writeLine(fmt.Sprintf("    // Closure call: %s", symbol))
+		g.generateExpression(callee(call))
+		g.writeLine("    str x0, [sp, #-16]!")
+		g.pushArguments(symbol, args, 0)
+		g.popArguments(words)
+		g.writeLine("    ldr x9, [sp], #16")
+		g.writeLine("    ldr x16, [x9]")
+		g.writeLine("    blr x16")
+		return
+	}
+
+	switch {
+	case words == 1:
+		g.generateExpressionAs(args[0], g.types.paramType(symbol, 0))
+	case words > 1:
+		// Evaluate every argument before filling the argument registers
+		g.pushArguments(symbol, args, 0)
+		g.popArguments(words)
+	}
+	g.writeLine(fmt.Sprintf("    bl _%s", symbol))
+}
+
+// pushArguments evaluates the arguments of a call from index start on and
+// pushes their words: the data word and then the type descriptor of an
+// interface value
+func (g *ARM64Generator) pushArguments(symbol string, args []ast.ASTNode, start int) {
+	for i := start; i < len(args); i++ {
+		paramType := g.types.paramType(symbol, i)
+		g.generateExpressionAs(args[i], paramType)
+		g.writeLine("    str x0, [sp, #-16]!")
+		if g.types.isInterface(paramType) {
+			g.writeLine("    str x1, [sp, #-16]!")
+		}
+	}
+}
+
+// popArguments pops the pushed words of the arguments into the argument registers
+func (g *ARM64Generator) popArguments(words int) {
+	for i := words - 1; i >= 0; i-- {
+		g.writeLine(fmt.Sprintf("    ldr %s, [sp], #16", arm64ArgumentRegisters[i]))
+	}
+}
+
+func (g *ARM64Generator) generateFieldAccess(node *ast.FieldAccessNode) {
+	g.writeLine("    // Field access: obj.field")
+	g.generateExpression(node.Object)
+	objectType := g.types.exprType(node.Object)
+	if ast.IsPointerType(objectType) {
+		g.generateNilCheck()
+	}
+	fieldOffset := g.types.fieldOffset(objectType, node.Field)
+	g.writeLine(fmt.Sprintf("    // Access field '%s' at offset %d", node.Field, fieldOffset))
+	if g.types.isInterface(g.types.exprType(node)) {
+		g.writeLine(fmt.Sprintf("    ldr x1, [x0, #%d]", fieldOffset+8))
+	}
+	g.writeLine(fmt.Sprintf("    ldr x0, [x0, #%d]", fieldOffset))
+}
+
+// generateStructLiteral creates a struct on the heap, leaving its address
+// in x0. Fields without a value keep the zero bytes of the new block.
+func (g *ARM64Generator) generateStructLiteral(node *ast.StructLiteral) {
+	size := g.types.structSize(node.TypeName)
+	if !g.types.isStruct(node.TypeName) {
+		size = 8 * len(node.Fields)
+	}
+	g.writeLine(fmt.Sprintf("    // Struct literal: %s", node.TypeName))
+	g.writeLine(fmt.Sprintf("    mov x16, #%d", size))
+	g.writeLine("    bl _alloc")
+	g.writeLine("    str x16, [sp, #-16]!")
+	names, values := g.types.literalFields(node)
+	for _, name := range names {
+		fieldType := ""
+		if field := g.types.field(node.TypeName, name); field != nil {
+			fieldType = constant.CanonicalType(ast.TypeName(field.Type))
+		}
+		offset := g.types.fieldOffset(node.TypeName, name)
+		g.generateStoredValue(values[name], fieldType)
+		g.writeLine("    ldr x2, [sp]")
+		g.writeLine(fmt.Sprintf("    str x0, [x2, #%d] // %s", offset, name))
+		if g.types.isInterface(fieldType) {
+			g.writeLine(fmt.Sprintf("    str x1, [x2, #%d]", offset+8))
+		}
+	}
+	g.generateZeroFields(node.TypeName, g.types.blockFields(node.TypeName, values))
+	g.writeLine("    ldr x0, [sp], #16")
+}
+
+// generateAddress leaves the address of an operand of & in x0: the heap
+// cell or stack slot of a variable, a field or an element in its heap
+// block, or a new heap cell holding any other value. The address of a
+// struct is its value.
+func (g *ARM64Generator) generateAddress(x ast.ASTNode) {
+	typeName := g.types.exprType(x)
+	if g.types.isStruct(typeName) {
+		g.generateExpression(x)
+		return
+	}
+	if g.generateLocation(x) {
+		return
+	}
+	g.writeLine(fmt.Sprintf("    // Address of a new %s", typeName))
+	g.generateExpression(x)
+	g.writeLine(fmt.Sprintf("    mov x16, #%d", g.types.size(typeName)))
+	g.writeLine("    bl _alloc")
+	g.writeLine("    str x0, [x16]")
+	if g.types.isInterface(typeName) {
+		g.writeLine("    str x1, [x16, #8]")
+	}
+	g.writeLine("    mov x0, x16")
+}
+
+// generateLocation leaves the address of the storage of x in x0: the heap
+// cell or stack slot of a variable, a field or an element in its heap
+// block, or the value a pointer points to. It reports whether x has such
+// storage.
+func (g *ARM64Generator) generateLocation(x ast.ASTNode) bool {
+	switch x := x.(type) {
+	case *ast.VariableNode:
+		if offset, exists := g.variables[x.Name]; exists {
+			g.writeLine(fmt.Sprintf("    // Address of %s", x.Name))
+			if g.cells[offset] {
+				g.writeLine(fmt.Sprintf("    ldr x0, [x29, #-%d]", offset))
+			} else {
+				g.writeLine(fmt.Sprintf("    sub x0, x29, #%d", offset))
+			}
+			return true
+		}
+	case *ast.FieldAccessNode:
+		objectType := g.types.exprType(x.Object)
+		g.writeLine(fmt.Sprintf("    // Address of field '%s'", x.Field))
+		g.generateExpression(x.Object)
+		g.generateNilCheck()
+		g.writeLine(fmt.Sprintf("    add x0, x0, #%d", g.types.fieldOffset(objectType, x.Field)))
+		return true
+	case *ast.IndexAccess:
+		if objectType := g.types.exprType(x.Object); isSliceType(objectType) {
+			g.writeLine("    // Address of slice element")
+			g.generateExpression(x.Object)
+			g.writeLine("    str x0, [sp, #-16]!")
+			g.generateExpression(x.Index)
+			g.writeLine("    ldr x1, [sp], #16")
+			g.generateIndexCheck()
+			g.writeLine("    ldr x1, [x1]")
+			g.writeLine(fmt.Sprintf("    mov x2, #%d", g.types.size(sliceElementType(objectType))))
+			g.writeLine("    madd x0, x0, x2, x1")
+			return true
+		}
+	case *ast.DerefNode:
+		// &*p is p, once p is known not to be nil
+		g.generateExpression(x.Operand)
+		g.generateNilCheck()
+		return true
+	}
+	return false
+}
+
+// generateDeref loads the value a pointer points to into x0 (and x1)
+func (g *ARM64Generator) generateDeref(node *ast.DerefNode) {
+	elementType := pointerElementType(g.types.exprType(node.Operand))
+	g.writeLine("    // Dereference: *p")
+	g.generateExpression(node.Operand)
+	g.generateNilCheck()
+	if g.types.isStruct(elementType) {
+		return
+	}
+	if g.types.isInterface(elementType) {
+		g.writeLine("    ldr x1, [x0, #8]")
+	}
+	g.writeLine("    ldr x0, [x0]")
+}
+
+// generateAssign generates target = value (see asmgen/assign.go). The
+// value is stored at the location of the target, except that a variable
+// gets its value like at its declaration and a map entry is stored by the
+// runtime. Assigning a struct through a pointer, *p = value, copies its
+// fields into the block p points to.
+func (g *ARM64Generator) generateAssign(target, value ast.ASTNode) {
+	typeName := g.types.exprType(target)
+	switch t := target.(type) {
+	case *ast.VariableNode:
+		if offset, exists := g.variables[t.Name]; exists {
+			g.writeLine(fmt.Sprintf("    // %s = value", t.Name))
+			g.generateStoredValue(value, g.types.varTypes[t.Name])
+			g.storeValue(offset, g.types.varTypes[t.Name])
+		}
+		return
+	case *ast.IndexAccess:
+		if _, _, _, isMap := g.types.mapIndex(t); isMap {
+			g.generateMapAssign(t, value)
+			return
+		}
+	}
+
+	g.writeLine("    // target = value")
+	if !g.generateLocation(target) {
+		g.writeLine("    // Assignment to an unsupported target")
+		return
+	}
+	g.writeLine("    str x0, [sp, #-16]!")
+	g.generateStoredValue(value, typeName)
+	g.writeLine("    ldr x2, [sp], #16")
+	if _, isDeref := target.(*ast.DerefNode); isDeref && g.types.isStruct(typeName) {
+		for offset := 0; offset < g.types.structSize(typeName); offset += 8 {
+			g.writeLine(fmt.Sprintf("    ldr x3, [x0, #%d]", offset))
+			g.writeLine(fmt.Sprintf("    str x3, [x2, #%d]", offset))
+		}
+		return
+	}
+	g.writeLine("    str x0, [x2]")
+	if g.types.isInterface(typeName) {
+		g.writeLine("    str x1, [x2, #8]")
+	}
+}
+
+// generateOpAssign generates x op= value, x++ and x-- by lowering them to
+// plain assignments
+func (g *ARM64Generator) generateOpAssign(x ast.ASTNode, op token.Token, value ast.ASTNode, pos token.Position) {
+	hidden := func() string { return "." + g.getNewLabel() }
+	for _, stmt := range lowerOpAssign(x, op, value, pos, hidden) {
+		g.generateStatement(stmt)
+	}
+}
+
+// generateNew allocates a zero value for new(T), leaving its address in x0
+func (g *ARM64Generator) generateNew(node *ast.NewNode) {
+	typeName := constant.CanonicalType(ast.TypeName(node.Type))
+	if g.types.isStruct(typeName) {
+		g.generateZeroValue(typeName)
+		return
+	}
+	g.writeLine(fmt.Sprintf("    // new(%s)", typeName))
+	zeroing := g.types.needsZeroing(typeName)
+	if zeroing {
+		// The zero value of a string or an array is an address, which
+		// _alloc leaves in x0
+		g.generateZeroValue(typeName)
+	}
+	g.writeLine(fmt.Sprintf("    mov x16, #%d", g.types.size(typeName)))
+	g.writeLine("    bl _alloc")
+	if zeroing {
+		g.writeLine("    str x0, [x16]")
+	}
+	g.writeLine("    mov x0, x16")
+}
+
+// generateNilCheck panics if the pointer in x0 is nil
+func (g *ARM64Generator) generateNilCheck() {
+	g.writeLine("    cbz x0, _nil_panic")
+}
+
+// generateElements creates the header and the elements of a slice or an
+// array on the heap, leaving it in x0. Elements that are not given hold the
+// zero value of the element type.
+func (g *ARM64Generator) generateElements(typeName string, elements []ast.ASTNode) {
+	elementType := constant.CanonicalType(sliceElementType(typeName))
+	elementSize := g.types.size(elementType)
+	count := len(elements)
+	if isArrayType(typeName) {
+		count = arrayLength(typeName)
+	}
+
+	kind := "Slice literal"
+	if isArrayType(typeName) {
+		kind = "Array"
+	}
+	g.writeLine(fmt.Sprintf("    // %s: %s", kind, typeName))
+	g.writeLine(fmt.Sprintf("    mov x16, #%d", sliceHeaderSize))
+	g.writeLine("    bl _alloc")
+	g.writeLine("    str x16, [sp, #-16]!")
+	g.writeLine(fmt.Sprintf("    mov x16, #%d", count*elementSize))
+	g.writeLine("    bl _alloc")
+	g.writeLine("    ldr x0, [sp]")
+	g.writeLine("    str x16, [x0]")
+	g.writeLine(fmt.Sprintf("    mov x1, #%d", count))
+	g.writeLine("    str x1, [x0, #8]")
+	g.writeLine("    str x1, [x0, #16]")
+	for i := 0; i < count; i++ {
+		if i < len(elements) {
+			g.generateStoredValue(elements[i], elementType)
+		} else if g.types.needsZeroing(elementType) {
+			g.generateZeroValue(elementType)
+		} else {
+			continue
+		}
+		g.writeLine("    ldr x2, [sp]")
+		g.writeLine("    ldr x2, [x2]")
+		g.writeLine(fmt.Sprintf("    str x0, [x2, #%d]", i*elementSize))
+		if g.types.isInterface(elementType) {
+			g.writeLine(fmt.Sprintf("    str x1, [x2, #%d]", i*elementSize+8))
+		}
+	}
+	g.writeLine("    ldr x0, [sp], #16")
+}
+
+// generateMapLiteral creates a map in x0 and stores the entries of the
+// literal in it
+func (g *ARM64Generator) generateMapLiteral(node *ast.MapLiteral) {
+	g.writeLine(fmt.Sprintf("    // Map literal: %s", node.TypeName()))
+	g.writeLine(fmt.Sprintf("    mov x0, #%d", g.types.mapKeyKind(ast.TypeName(node.KeyType), node.Position())))
+	g.writeLine("    bl _map_new")
+	g.writeLine("    str x0, [sp, #-16]!")
+	for _, entry := range node.Entries {
+		g.generateExpressionAs(entry.Key, ast.TypeName(node.KeyType))
+		g.writeLine("    str x0, [sp, #-16]!")
+		g.generateStoredValue(entry.Value, ast.TypeName(node.ValueType))
+		g.writeLine("    mov x3, x1")
+		g.writeLine("    mov x2, x0")
+		g.writeLine("    ldr x1, [sp], #16")
+		g.writeLine("    ldr x0, [sp]")
+		g.writeLine("    bl _map_store")
+	}
+	g.writeLine("    ldr x0, [sp], #16")
+}
+
+// generateMapIndex looks up m[k]. The value is left in x0, with the type
+// descriptor in x1 for an interface value, and x2 is set to 1 when the key
+// is present. A missing key yields the zero value and x2 0.
+func (g *ARM64Generator) generateMapIndex(node *ast.IndexAccess) {
+	_, keyType, valueType, _ := g.types.mapIndex(node)
+	missingLabel := g.getNewLabel()
+	endLabel := g.getNewLabel()
+
+	g.writeLine("    // Map index: m[k]")
+	g.generateExpression(node.Object)
+	g.writeLine("    str x0, [sp, #-16]!")
+	g.generateExpressionAs(node.Index, keyType)
+	g.writeLine("    mov x1, x0")
+	g.writeLine("    ldr x0, [sp], #16")
+	g.writeLine("    bl _map_lookup")
+	g.writeLine("    cbz x0, " + missingLabel)
+	g.writeLine("    ldr x1, [x0, #8]")
+	g.writeLine("    ldr x0, [x0]")
+	g.writeLine("    mov x2, #1")
+	g.writeLine("    b " + endLabel)
+	g.writeLine(missingLabel + ":")
+	g.generateExpressionAs(g.types.zeroValue(valueType), valueType)
+	g.writeLine("    mov x2, #0")
+	g.writeLine(endLabel + ":")
+}
+
+// generateMapAssign generates m[k] = value
+func (g *ARM64Generator) generateMapAssign(target *ast.IndexAccess, value ast.ASTNode) {
+	_, keyType, valueType, _ := g.types.mapIndex(target)
+	g.writeLine("    // m[k] = value")
+	g.generateExpression(target.Object)
+	g.writeLine("    str x0, [sp, #-16]!")
+	g.generateExpressionAs(target.Index, keyType)
+	g.writeLine("    str x0, [sp, #-16]!")
+	g.generateStoredValue(value, valueType)
+	g.writeLine("    mov x3, x1")
+	g.writeLine("    mov x2, x0")
+	g.writeLine("    ldr x1, [sp], #16")
+	g.writeLine("    ldr x0, [sp], #16")
+	g.writeLine("    bl _map_store")
+}
+
+// generateMapBuiltin generates the built-in functions len and delete on
+// maps, reporting whether call was one of them
+func (g *ARM64Generator) generateMapBuiltin(call *ast.CallNode) bool {
+	if call.Receiver != nil || call.Callee != nil || len(call.Arguments) == 0 {
+		return false
+	}
+	mapType := g.types.exprType(call.Arguments[0])
+	if !ast.IsMapType(mapType) {
+		return false
+	}
+
+	switch {
+	case call.Function == "len" && len(call.Arguments) == 1:
+		// The count is the first word of the header; a nil map is empty
+		nilLabel := g.getNewLabel()
+		g.generateExpression(call.Arguments[0])
+		g.writeLine("    cbz x0, " + nilLabel)
+		g.writeLine("    ldr x0, [x0]")
+		g.writeLine(nilLabel + ":")
+		return true
+	case call.Function == "delete" && len(call.Arguments) == 2:
+		keyType, _ := ast.MapTypes(mapType)
+		g.generateExpression(call.Arguments[0])
+		g.writeLine("    str x0, [sp, #-16]!")
+		g.generateExpressionAs(call.Arguments[1], keyType)
+		g.writeLine("    mov x1, x0")
+		g.writeLine("    ldr x0, [sp], #16")
+		g.writeLine("    bl _map_delete")
+		return true
+	}
+	return false
+}
+
+// generateSliceBuiltin generates the built-in functions len and cap on
+// slices and len on strings, reporting whether call was one of them
+func (g *ARM64Generator) generateSliceBuiltin(call *ast.CallNode) bool {
+	if (call.Function != "len" && call.Function != "cap") || call.Receiver != nil || call.Callee != nil || len(call.Arguments) != 1 {
+		return false
+	}
+
+	switch typeName := g.types.exprType(call.Arguments[0]); {
+	case isSliceType(typeName):
+		g.generateExpression(call.Arguments[0])
+		if call.Function == "len" {
+			g.generateHeaderWord(8)
+		} else {
+			g.generateHeaderWord(16)
+		}
+		return true
+	case typeName == "string" && call.Function == "len":
+		g.generateExpression(call.Arguments[0])
+		g.generateStringLength()
+		return true
+	}
+	return false
+}
+
+// generateHeaderWord loads the length (offset 8) or the capacity (offset
+// 16) from the slice header in x0; a nil slice has neither
+func (g *ARM64Generator) generateHeaderWord(offset int) {
+	nilLabel := g.getNewLabel()
+	g.writeLine("    cbz x0, " + nilLabel)
+	g.writeLine(fmt.Sprintf("    ldr x0, [x0, #%d]", offset))
+	g.writeLine(nilLabel + ":")
+}
+
+// generateStringLength replaces the string in x0 by its length. Strings end
+// with a NUL byte.
+func (g *ARM64Generator) generateStringLength() {
+	loopLabel := g.getNewLabel()
+	endLabel := g.getNewLabel()
+	g.writeLine("    mov x1, x0")
+	g.writeLine("    mov x0, #0")
+	g.writeLine(loopLabel + ":")
+	g.writeLine("    ldrb w2, [x1, x0]")
+	g.writeLine("    cbz w2, " + endLabel)
+	g.writeLine("    add x0, x0, #1")
+	g.writeLine("    b " + loopLabel)
+	g.writeLine(endLabel + ":")
+}
+
+// generateSliceExpr generates s[low:high] and s[low:high:max], leaving the
+// result in x0. Omitted indices are 0, the length and the capacity of s.
+// The runtime checks the indices and panics when they are out of range.
+func (g *ARM64Generator) generateSliceExpr(node *ast.SliceExpr) {
+	objectType := g.types.exprType(node.Object)
+	g.writeLine("    // Slice expression: " + objectType)
+	g.generateExpression(node.Object)
+	g.writeLine("    str x0, [sp, #-16]!")
+	if node.Low != nil {
+		g.generateExpression(node.Low)
+	} else {
+		g.writeLine("    mov x0, #0")
+	}
+	g.writeLine("    str x0, [sp, #-16]!")
+
+	if objectType == "string" {
+		// A string is copied up to high unless the slice is a suffix
+		if node.High != nil {
+			g.generateExpression(node.High)
+		} else {
+			g.writeLine("    ldr x0, [sp, #16]")
+			g.generateStringLength()
+		}
+		g.writeLine("    mov x2, x0")
+		g.writeLine("    ldr x1, [sp], #16")
+		g.writeLine("    ldr x0, [sp], #16")
+		g.writeLine("    bl _slice_string")
+		return
+	}
+
+	// The stack holds max, high, low and s from the top
+	if node.High != nil {
+		g.generateExpression(node.High)
+	} else {
+		g.writeLine("    ldr x0, [sp, #16]")
+		g.generateHeaderWord(8)
+	}
+	g.writeLine("    str x0, [sp, #-16]!")
+	if node.Max != nil {
+		g.generateExpression(node.Max)
+	} else {
+		g.writeLine("    ldr x0, [sp, #32]")
+		g.generateHeaderWord(16)
+	}
+	g.writeLine("    mov x3, x0")
+	g.writeLine("    ldr x2, [sp], #16")
+	g.writeLine("    ldr x1, [sp], #16")
+	g.writeLine("    ldr x0, [sp], #16")
+	g.writeLine(fmt.Sprintf("    mov x4, #%d", g.types.size(constant.CanonicalType(sliceElementType(objectType)))))
+	g.writeLine("    bl _slice")
+}
+
+func (g *ARM64Generator) generateIndexAccess(node *ast.IndexAccess) {
+	g.writeLine("    // Index access: arr[index]")
+	g.generateExpression(node.Object)
+	g.writeLine("    str x0, [sp, #-16]!") // Store array base address
+	g.generateExpression(node.Index)
+	g.writeLine("    ldr x1, [sp], #16") // Load array base address
+	objectType := g.types.exprType(node.Object)
+	if objectType == "string" {
+		// Strings are indexed by byte
+		g.writeLine("    ldrb w0, [x1, x0]")
+		return
+	}
+	if isSliceType(objectType) {
+		// The elements start at the data word of the header
+		g.generateIndexCheck()
+		g.writeLine("    ldr x1, [x1]")
+		if elementType := sliceElementType(objectType); g.types.isInterface(elementType) {
+			g.writeLine("    add x1, x1, x0, lsl #4")
+			g.writeLine("    ldr x0, [x1]")
+			g.writeLine("    ldr x1, [x1, #8]")
+			return
+		}
+		g.writeLine("    ldr x0, [x1, x0, lsl #3]")
+		return
+	}
+	g.writeLine("    lsl x0, x0, #3") // x0 = index * 8
+	g.writeLine("    add x0, x1, x0") // x0 = base + offset
+	g.writeLine("    ldr x0, [x0]")   // Load value at address
+}
+
+// generateIndexCheck panics unless the index in x0 is within the length of
+// the slice or array header in x1. A nil slice has no elements, and a
+// negative index compares as a large unsigned one.
+func (g *ARM64Generator) generateIndexCheck() {
+	g.writeLine("    cbz x1, _index_panic")
+	g.writeLine("    ldr x2, [x1, #8]")
+	g.writeLine("    cmp x0, x2")
+	g.writeLine("    b.hs _index_panic")
+}
+
+// truncateInteger truncates x0 to a sized integer type (int32 or uint8)
+func (g *ARM64Generator) truncateInteger(typeName string) {
+	switch typeName {
+	case "int32":
+		g.writeLine("    sxtw x0, w0")
+	case "uint8":
+		g.writeLine("    and x0, x0, #0xff")
+	}
+}
+
+func (g *ARM64Generator) getNewLabel() string {
+	g.labelNum++
+	return fmt.Sprintf("L%d", g.labelNum)
+}
+
+func (g *ARM64Generator) getStringLabel(value string) string {
+	if label, exists := g.stringLiterals[value]; exists {
+		return label
+	}
+
+	label := fmt.Sprintf("str_%d", g.stringCount)
+	g.stringLiterals[value] = label
+	g.stringCount++
+	return label
+}
+
+func (g *ARM64Generator) generateStringLiterals() {
+	if len(g.stringLiterals) == 0 {
+		return
+	}
+
+	g.writeLine("")
+	g.writeLine(".section __TEXT,__cstring,cstring_literals")
+
+	for value, label := range g.stringLiterals {
+		g.writeLine(fmt.Sprintf("%s:", label))
+		g.writeLine(fmt.Sprintf("    .asciz %s", quoteAsmString(value)))
+	}
+}
+
+// typeDescriptor returns the symbol of the type descriptor of a type and
+// marks it for generation
+func (g *ARM64Generator) typeDescriptor(typeName string) string {
+	g.descriptors[typeName] = true
+	return typeSymbol(typeName)
+}
+
+// generateTypeDescriptors generates the descriptors of the types stored in
+// interface values. A descriptor holds the address of the type name and the
+// method table used for dynamic calls, with a zero entry for each method the
+// type does not have.
+func (g *ARM64Generator) generateTypeDescriptors() {
+	if len(g.descriptors) == 0 {
+		return
+	}
+
+	typeNames := make([]string, 0, len(g.descriptors))
+	for typeName := range g.descriptors {
+		typeNames = append(typeNames, typeName)
+	}
+	sort.Strings(typeNames)
+
+	g.writeLine("")
+	g.writeLine(".section __DATA,__const")
+	g.writeLine(".p2align 3")
+	for _, typeName := range typeNames {
+		g.writeLine(fmt.Sprintf("%s:", typeSymbol(typeName)))
+		g.writeLine(fmt.Sprintf("    .quad %s // %s", g.getStringLabel(typeName), typeName))
+		for i, method := range g.types.methodTable(typeName) {
+			if method == "" {
+				g.writeLine(fmt.Sprintf("    .quad 0 // %s", g.types.methodSlots[i]))
+			} else {
+				g.writeLine(fmt.Sprintf("    .quad _%s", method))
+			}
+		}
+	}
+}
+
+// generateStaticClosures generates the closures of the declared functions
+// used as values, which hold just the code address
+func (g *ARM64Generator) generateStaticClosures() {
+	if len(g.staticClosures) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(g.staticClosures))
+	for name := range g.staticClosures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	g.writeLine("")
+	g.writeLine(".section __DATA,__const")
+	g.writeLine(".p2align 3")
+	for _, name := range names {
+		g.writeLine(fmt.Sprintf("%s:", staticClosureSymbol(name)))
+		g.writeLine(fmt.Sprintf("    .quad _%s", name))
+	}
+}
+
+// generateGlobals reserves zeroed storage for the package-level variables
+func (g *ARM64Generator) generateGlobals() {
+	if len(g.types.globals) == 0 {
+		return
+	}
+
+	g.writeLine("")
+	for _, name := range g.types.globals {
+		g.writeLine(fmt.Sprintf(".zerofill __DATA,__bss,%s,%d,3", globalSymbol(name), g.types.size(g.types.globalTypes[name])))
+	}
+}
+
+// writeLine writes a line of assembly. A load or store of a local out of the
+// reach of the [x29, #-offset] form, which ends at -256, addresses it
+// through x17 instead.
+func (g *ARM64Generator) writeLine(s string) {
+	const frameOperand = ", [x29, #-"
+	if i := strings.Index(s, frameOperand); i >= 0 && strings.HasSuffix(s, "]") {
+		if offset, err := strconv.Atoi(s[i+len(frameOperand) : len(s)-1]); err == nil && offset > 256 {
+			g.output.WriteString(fmt.Sprintf("    sub x17, x29, #%d\n", offset))
+			s = s[:i] + ", [x17]"
+		}
+	}
+	g.output.WriteString(s + "\n")
+}
+
+// getFieldOffset returns a simple field offset (simplified implementation)
+func getFieldOffset(fieldName string) int {
+	switch fieldName {
+	case "x", "name", "first":
+		return 0
+	case "y", "age", "second":
+		return 8
+	case "z", "third":
+		return 16
+	default:
+		return 0
+	}
+}
+
+// Errors returns the type errors found while generating the program
+func (g *ARM64Generator) Errors() []*scanner.Error {
+	return g.types.errors
+}
+
+func (g *ARM64Generator) GenerateRuntime() string {
+	// Switch back to the text section: string literals may precede the runtime
+	runtime := `
+.section __TEXT,__text,regular,pure_instructions
+
+// Runtime function to print numbers
+// Like the other print functions, it ends with the byte at print_end
+.p2align 2
+_print_number:
     stp x29, x30, [sp, #-16]!
     mov x29, sp
     sub sp, sp, #32
@@ -405,7 +2499,20 @@ _print_number:
     // Save the number
     str x0, [x29, #-8]
     
-    // Buffer for digits (16 bytes should be enough)
+    // Print a minus sign for negative numbers
+    tbz x0, #63, convert_start
+    mov w3, #45        // ASCII '-'
+    sturb w3, [x29, #-32]
+    mov x0, #1         // stdout
+    sub x1, x29, #32   // buffer
+    mov x2, #1         // length
+    mov x16, #4        // sys_write
+    svc #0x80
+    ldr x0, [x29, #-8] // Restore the number
+    
+.p2align 2
+convert_start:
+    // Buffer for digits (19 digits at most)
     add x1, x29, #-24  // Buffer pointer
     mov x2, #0         // Digit count
     
@@ -423,8 +2530,10 @@ convert_loop:
     
     // Divide by 10
     mov x3, #10
-    udiv x4, x0, x3    // x4 = x0 / 10
+    sdiv x4, x0, x3    // x4 = x0 / 10
     msub x5, x4, x3, x0 // x5 = x0 - (x4 * 10) = x0 % 10
+    cmp x5, #0
+    cneg x5, x5, lt    // The remainder of a negative number is negative
     
     // Convert digit to ASCII and store
     add w5, w5, #48    // Convert to ASCII
@@ -463,11 +2572,12 @@ print_loop:
     
 .p2align 2
 print_newline:
-    // Write newline character directly
+    // Write the end byte, a newline or a space
     mov x16, #4        // sys_write
     mov x0, #1         // stdout
-    mov x1, sp         // Use stack for newline
-    mov w2, #10        // ASCII newline  
+    mov x1, sp         // Use stack for the end byte
+    adrp x2, print_end@PAGE
+    ldrb w2, [x2, print_end@PAGEOFF]
     strb w2, [x1]      // Store on stack
     mov x2, #1         // length
     svc #0x80
@@ -481,6 +2591,7 @@ print_newline:
 _print_string:
     stp x29, x30, [sp, #-16]!
     mov x29, sp
+    sub sp, sp, #16    // Space for the newline (keeps saved x29/x30 intact)
     
     // Calculate string length (simple strlen implementation)
     mov x1, x0         // Save string pointer
@@ -501,17 +2612,939 @@ print_str:
     // x1 already has string pointer, x2 has length
     svc #0x80
     
-    // Print newline
+    // Print the end byte, a newline or a space
     mov x16, #4        // sys_write
     mov x0, #1         // stdout
-    mov x1, sp         // Use stack for newline
-    mov w2, #10        // ASCII newline
+    mov x1, sp         // Use stack for the end byte
+    adrp x2, print_end@PAGE
+    ldrb w2, [x2, print_end@PAGEOFF]
     strb w2, [x1]      // Store on stack
     mov x2, #1         // length
     svc #0x80
     
+    add sp, sp, #16
+    ldp x29, x30, [sp], #16
+    ret
+
+// Runtime function to compare strings
+// Takes two string pointers in x0 and x1 and returns 1 in x0 if they are equal
+.p2align 2
+_string_equal:
+    mov x2, #0         // Index
+    
+.p2align 2
+streq_loop:
+    ldrb w3, [x0, x2]  // Load byte of the first string
+    ldrb w4, [x1, x2]  // Load byte of the second string
+    cmp w3, w4
+    bne streq_false
+    cbz w3, streq_true // Both strings end here
+    add x2, x2, #1
+    b streq_loop
+    
+.p2align 2
+streq_true:
+    mov x0, #1
+    ret
+    
+.p2align 2
+streq_false:
+    mov x0, #0
+    ret
+
+// Runtime function to print float64 values
+// Takes the float64 bits in x0 and prints them like Go's println: +d.dddddde+ddd
+.p2align 2
+_print_float:
+    stp x29, x30, [sp, #-16]!
+    mov x29, sp
+    sub sp, sp, #32
+    
+    mov x1, sp         // Buffer pointer
+    fmov d0, x0
+    
+    // NaN check (unordered with itself)
+    fcmp d0, d0
+    b.vs pf_nan
+    
+    // Sign
+    mov w6, #43        // ASCII '+'
+    strb w6, [x1]
+    mov x4, #0         // Decimal exponent
+    tbz x0, #63, pf_positive
+    mov w6, #45        // ASCII '-'
+    strb w6, [x1]
+    fneg d0, d0        // Absolute value
+    
+.p2align 2
+pf_positive:
+    fmov d2, #10.0
+    fmov d3, #1.0
+    
+    // v + v == v only for zero and infinity
+    fadd d5, d0, d0
+    fcmp d5, d0
+    b.ne pf_norm_high
+    fcmp d0, #0.0
+    b.eq pf_digits
+    b pf_inf
+    
+.p2align 2
+pf_norm_high:
+    // Normalize to 1 <= v < 10
+    fcmp d0, d2
+    b.lt pf_norm_low
+    fdiv d0, d0, d2
+    add x4, x4, #1
+    b pf_norm_high
+    
+.p2align 2
+pf_norm_low:
+    fcmp d0, d3
+    b.ge pf_round
+    fmul d0, d0, d2
+    sub x4, x4, #1
+    b pf_norm_low
+    
+.p2align 2
+pf_round:
+    // Round at the 7th digit: v += 5 / 10^7
+    fmov d4, #5.0
+    mov x5, #7
+pf_round_loop:
+    fdiv d4, d4, d2
+    subs x5, x5, #1
+    b.ne pf_round_loop
+    fadd d0, d0, d4
+    fcmp d0, d2
+    b.lt pf_digits
+    fdiv d0, d0, d2
+    add x4, x4, #1
+    
+.p2align 2
+pf_digits:
+    // Store 7 digits at buffer[2..8]
+    mov x5, #2
+pf_digit_loop:
+    fcvtzs x6, d0
+    scvtf d1, x6
+    fsub d0, d0, d1
+    fmul d0, d0, d2
+    add w6, w6, #48    // Convert to ASCII
+    strb w6, [x1, x5]
+    add x5, x5, #1
+    cmp x5, #9
+    b.ne pf_digit_loop
+    
+    // d.dddddd
+    ldrb w6, [x1, #2]
+    strb w6, [x1, #1]
+    mov w6, #46        // ASCII '.'
+    strb w6, [x1, #2]
+    
+    // Exponent: e+ddd
+    mov w6, #101       // ASCII 'e'
+    strb w6, [x1, #9]
+    mov w6, #43        // ASCII '+'
+    cmp x4, #0
+    b.ge pf_exponent
+    neg x4, x4
+    mov w6, #45        // ASCII '-'
+pf_exponent:
+    strb w6, [x1, #10]
+    mov x7, #10
+    udiv x8, x4, x7     // x8 = e / 10
+    msub x9, x8, x7, x4 // x9 = e % 10
+    add w9, w9, #48
+    strb w9, [x1, #13]
+    udiv x10, x8, x7    // x10 = e / 100
+    msub x9, x10, x7, x8 // x9 = (e / 10) % 10
+    add w9, w9, #48
+    strb w9, [x1, #12]
+    add w10, w10, #48
+    strb w10, [x1, #11]
+    adrp x6, print_end@PAGE
+    ldrb w6, [x6, print_end@PAGEOFF] // newline or space
+    strb w6, [x1, #14]
+    mov x2, #15        // length
+    b pf_write
+    
+.p2align 2
+pf_nan:
+    mov w6, #78        // ASCII 'N'
+    strb w6, [x1]
+    mov w6, #97        // ASCII 'a'
+    strb w6, [x1, #1]
+    mov w6, #78        // ASCII 'N'
+    strb w6, [x1, #2]
+    adrp x6, print_end@PAGE
+    ldrb w6, [x6, print_end@PAGEOFF] // newline or space
+    strb w6, [x1, #3]
+    mov x2, #4         // length
+    b pf_write
+    
+.p2align 2
+pf_inf:
+    // Sign is already in buffer[0]
+    mov w6, #73        // ASCII 'I'
+    strb w6, [x1, #1]
+    mov w6, #110       // ASCII 'n'
+    strb w6, [x1, #2]
+    mov w6, #102       // ASCII 'f'
+    strb w6, [x1, #3]
+    adrp x6, print_end@PAGE
+    ldrb w6, [x6, print_end@PAGEOFF] // newline or space
+    strb w6, [x1, #4]
+    mov x2, #5         // length
+    
+.p2align 2
+pf_write:
+    mov x16, #4        // sys_write
+    mov x0, #1         // stdout
+    svc #0x80
+    
+    add sp, sp, #32
+    ldp x29, x30, [sp], #16
+    ret
+
+// Runtime function for failed type assertions
+// A failed type assertion panics
+.p2align 2
+_type_assert_panic:
+    adrp x0, type_assert_msg@PAGE
+    add x0, x0, type_assert_msg@PAGEOFF
+    b _runtime_panic
+
+// Runtime function to allocate heap memory (ARM64 macOS)
+// Takes the size in x16 and returns the address in x16, keeping every other
+// register but x17. Memory is never freed.
+_alloc:
+    str x0, [sp, #-16]!
+    adrp x17, heap_ptr@PAGE
+    add x17, x17, heap_ptr@PAGEOFF
+    ldr x0, [x17]
+    add x16, x16, #15
+    and x16, x16, #-16 // keep blocks 16-byte aligned
+    add x16, x0, x16
+    str x16, [x17]
+    mov x16, x0
+    ldr x0, [sp], #16
+    ret
+
+// Copies the block in x0 of x9 bytes, the fields of a struct or the elements
+// of an array, to a new block returned in x0
+_copy_struct:
+    stp x29, x30, [sp, #-16]!
+    mov x16, x9
+    bl _alloc
+    mov x10, x16
+copystruct_loop:
+    cbz x9, copystruct_done
+    ldr x11, [x0], #8
+    str x11, [x10], #8
+    sub x9, x9, #8
+    b copystruct_loop
+copystruct_done:
+    mov x0, x16
+    ldp x29, x30, [sp], #16
+    ret
+
+// Runtime functions for slices (ARM64 macOS)
+// See asmgen/slices.go for the layout of slices. _slice takes the header in
+// x0, low, high and max in x1, x2 and x3 and the element size in x4, and
+// returns a header sharing the elements of the old one in x0.
+_slice:
+    stp x29, x30, [sp, #-16]!
+    mov x9, #0         // a nil slice has no capacity
+    cbz x0, slice_check
+    ldr x9, [x0, #16]
+slice_check:
+    cmp x3, x9         // unsigned, so negative indices are out of range
+    b.hi _slice_panic
+    cmp x2, x3
+    b.hi _slice_panic
+    cmp x1, x2
+    b.hi _slice_panic
+    cbz x0, slice_done // slicing a nil slice yields nil
+    mov x16, #24
+    bl _alloc
+    ldr x10, [x0]
+    madd x10, x1, x4, x10
+    str x10, [x16]     // data
+    sub x10, x2, x1
+    str x10, [x16, #8] // length
+    sub x10, x3, x1
+    str x10, [x16, #16] // capacity
+    mov x0, x16
+slice_done:
+    ldp x29, x30, [sp], #16
+    ret
+
+// _slice_string takes the string in x0 and low and high in x1 and x2, and
+// returns s[low:high] in x0. A suffix shares the bytes of the string; other
+// slices are copied to add the NUL byte.
+_slice_string:
+    stp x29, x30, [sp, #-16]!
+    mov x3, #0
+slice_string_len:
+    ldrb w9, [x0, x3]
+    cbz w9, slice_string_check
+    add x3, x3, #1
+    b slice_string_len
+slice_string_check:
+    cmp x2, x3
+    b.hi _slice_panic
+    cmp x1, x2
+    b.hi _slice_panic
+    add x9, x0, x1     // first byte
+    mov x0, x9
+    cmp x2, x3
+    b.eq slice_string_done
+    sub x2, x2, x1     // length of the result
+    add x16, x2, #1
+    bl _alloc
+    mov x3, #0
+slice_string_copy:
+    cmp x3, x2
+    b.eq slice_string_end
+    ldrb w10, [x9, x3]
+    strb w10, [x16, x3]
+    add x3, x3, #1
+    b slice_string_copy
+slice_string_end:
+    strb wzr, [x16, x3]
+    mov x0, x16
+slice_string_done:
+    ldp x29, x30, [sp], #16
+    ret
+
+// Runtime functions for maps (ARM64 macOS)
+// See asmgen/maps.go for the layout of maps. _map_new takes the key kind in
+// x0 and returns the map in x0; the others take the map in x0 and the key in
+// x1.
+_map_new:
+    stp x29, x30, [sp, #-16]!
+    mov x9, x0
+    mov x16, #40
+    bl _alloc
+    mov x0, x16
+    mov x10, #8
+    str x10, [x0, #8]  // capacity
+    str x9, [x0, #24]  // key kind
+    mov x16, #256      // 8 entries of 32 bytes
+    bl _alloc
+    str x16, [x0, #32]
+    ldp x29, x30, [sp], #16
+    ret
+
+// Hashes the key in x1 into x10
+_map_hash:
+    ldr x11, [x0, #24]
+    cmp x11, #1
+    b.eq maphash_string
+    b.hi maphash_block
+    mov x11, #0x7C15
+    movk x11, #0x7F4A, lsl #16
+    movk x11, #0xB97F, lsl #32
+    movk x11, #0x9E37, lsl #48
+    mul x10, x1, x11
+maphash_fold:
+    eor x10, x10, x10, lsr #32 // fold the high bits into the index bits
+    ret
+maphash_block:
+    mov x12, x1
+    tbz x11, #0, maphash_words
+    ldr x12, [x12]     // elements of an array
+maphash_words:
+    lsr x14, x11, #1   // size of the key
+    mov x13, #0x7C15
+    movk x13, #0x7F4A, lsl #16
+    movk x13, #0xB97F, lsl #32
+    movk x13, #0x9E37, lsl #48
+    mov x10, #0
+maphash_wordloop:
+    cbz x14, maphash_fold
+    ldr x15, [x12], #8
+    eor x10, x10, x15
+    mul x10, x10, x13
+    sub x14, x14, #8
+    b maphash_wordloop
+maphash_string:
+    mov x10, #0x2325   // FNV-1a
+    movk x10, #0x8422, lsl #16
+    movk x10, #0x9CE4, lsl #32
+    movk x10, #0xCBF2, lsl #48
+    mov x11, #0x1B3
+    movk x11, #0x100, lsl #32
+    mov x12, x1
+maphash_loop:
+    ldrb w13, [x12], #1
+    cbz w13, maphash_done
+    eor x10, x10, x13
+    mul x10, x10, x11
+    b maphash_loop
+maphash_done:
+    ret
+
+// Finds the entry of the key in x1: returns its address in x10 and 1 in
+// x11, or the address of the empty entry ending its probe sequence and 0
+_map_find:
+    stp x29, x30, [sp, #-16]!
+    bl _map_hash
+    ldr x12, [x0, #8]
+    sub x12, x12, #1   // index mask
+    and x10, x10, x12
+    ldr x14, [x0, #24]
+mapfind_loop:
+    ldr x13, [x0, #32]
+    add x13, x13, x10, lsl #5 // entry address
+    ldr x11, [x13]
+    cbz x11, mapfind_missing
+    cmp x11, #1
+    b.ne mapfind_next  // deleted entry
+    ldr x11, [x13, #8]
+    cmp x14, #1
+    b.eq mapfind_string
+    b.hi mapfind_block
+    cmp x11, x1
+    b.eq mapfind_found
+    b mapfind_next
+mapfind_block:
+    mov x9, x1
+    tbz x14, #0, mapfind_words
+    ldr x11, [x11]     // elements of arrays
+    ldr x9, [x9]
+mapfind_words:
+    lsr x15, x14, #1   // size of the key
+mapfind_wordloop:
+    cbz x15, mapfind_found
+    ldr x16, [x11], #8
+    ldr x17, [x9], #8
+    cmp x16, x17
+    b.ne mapfind_next
+    sub x15, x15, #8
+    b mapfind_wordloop
+mapfind_string:
+    mov x15, #0
+mapfind_strloop:
+    ldrb w16, [x11, x15]
+    ldrb w17, [x1, x15]
+    cmp w16, w17
+    b.ne mapfind_next
+    cbz w16, mapfind_found
+    add x15, x15, #1
+    b mapfind_strloop
+mapfind_next:
+    add x10, x10, #1
+    and x10, x10, x12
+    b mapfind_loop
+mapfind_found:
+    mov x10, x13
+    mov x11, #1
+    ldp x29, x30, [sp], #16
+    ret
+mapfind_missing:
+    mov x10, x13
+    mov x11, #0
+    ldp x29, x30, [sp], #16
+    ret
+
+// Returns the address of the value of the key in x0, or 0 when the key is
+// missing
+_map_lookup:
+    cbz x0, maplookup_done
+    stp x29, x30, [sp, #-16]!
+    bl _map_find
+    ldp x29, x30, [sp], #16
+    add x0, x10, #16
+    cbnz x11, maplookup_done
+    mov x0, #0
+maplookup_done:
+    ret
+
+// Stores the value in x2 (and its type descriptor in x3) under the key
+_map_store:
+    cbz x0, _map_nil_panic
+    stp x29, x30, [sp, #-16]!
+    bl _map_find
+    cbnz x11, mapstore_set
+    ldr x12, [x0, #16] // grow first if the table would be 3/4 full
+    add x12, x12, #1
+    lsl x12, x12, #2
+    ldr x13, [x0, #8]
+    add x13, x13, x13, lsl #1
+    cmp x12, x13
+    b.ls mapstore_insert
+    bl _map_grow
+    bl _map_find
+mapstore_insert:
+    bl _map_copy_key
+    mov x12, #1
+    str x12, [x10]
+    str x1, [x10, #8]
+    ldr x12, [x0]
+    add x12, x12, #1
+    str x12, [x0]      // count
+    ldr x12, [x0, #16]
+    add x12, x12, #1
+    str x12, [x0, #16] // used
+mapstore_set:
+    str x2, [x10, #16]
+    str x3, [x10, #24]
+    ldp x29, x30, [sp], #16
+    ret
+
+// Replaces a struct or array key in x1 with a copy of it
+_map_copy_key:
+    ldr x12, [x0, #24]
+    cmp x12, #1
+    b.ls mapcopy_done  // words and strings are not copied
+    stp x29, x30, [sp, #-16]!
+    lsr x13, x12, #1   // size of the key
+    mov x16, x13
+    tbz x12, #0, mapcopy_struct
+    add x16, x16, #24  // an array gets a header of its own
+    bl _alloc
+    ldr x14, [x1, #8]
+    str x14, [x16, #8] // length
+    str x14, [x16, #16] // capacity
+    ldr x14, [x1]
+    add x15, x16, #24
+    str x15, [x16]
+    b mapcopy_words
+mapcopy_struct:
+    bl _alloc
+    mov x14, x1
+    mov x15, x16
+mapcopy_words:
+    mov x1, x16
+mapcopy_loop:
+    cbz x13, mapcopy_end
+    ldr x12, [x14], #8
+    str x12, [x15], #8
+    sub x13, x13, #8
+    b mapcopy_loop
+mapcopy_end:
+    ldp x29, x30, [sp], #16
+mapcopy_done:
+    ret
+
+// Doubles the capacity, moving the used entries to new entries
+_map_grow:
+    stp x29, x30, [sp, #-16]!
+    stp x19, x20, [sp, #-16]!
+    str x1, [sp, #-16]!
+    ldr x19, [x0, #32] // old entries
+    ldr x20, [x0, #8]  // old capacity
+    lsl x16, x20, #6   // twice as many entries of 32 bytes
+    bl _alloc
+    str x16, [x0, #32]
+    lsl x12, x20, #1
+    str x12, [x0, #8]
+    ldr x12, [x0]
+    str x12, [x0, #16] // deleted entries are dropped
+mapgrow_loop:
+    cbz x20, mapgrow_done
+    ldr x12, [x19]
+    cmp x12, #1
+    b.ne mapgrow_next
+    ldr x1, [x19, #8]
+    bl _map_find
+    mov x12, #1
+    str x12, [x10]
+    str x1, [x10, #8]
+    ldr x12, [x19, #16]
+    str x12, [x10, #16]
+    ldr x12, [x19, #24]
+    str x12, [x10, #24]
+mapgrow_next:
+    add x19, x19, #32
+    sub x20, x20, #1
+    b mapgrow_loop
+mapgrow_done:
+    ldr x1, [sp], #16
+    ldp x19, x20, [sp], #16
+    ldp x29, x30, [sp], #16
+    ret
+
+// Deletes the entry of the key, if any
+_map_delete:
+    cbz x0, mapdelete_done
+    stp x29, x30, [sp, #-16]!
+    bl _map_find
+    ldp x29, x30, [sp], #16
+    cbz x11, mapdelete_done
+    mov x12, #2
+    str x12, [x10]
+    ldr x12, [x0]
+    sub x12, x12, #1
+    str x12, [x0]
+mapdelete_done:
+    ret
+
+// Decodes the UTF-8 encoded rune at x0 into x0 and its size into x1. An
+// invalid encoding decodes to U+FFFD with size 1.
+_decode_rune:
+    mov x2, x0
+    ldrb w0, [x2]
+    cmp x0, #0x80
+    b.lo decoderune_ascii
+    cmp x0, #0xC0
+    b.lo decoderune_invalid
+    cmp x0, #0xE0
+    b.lo decoderune_2
+    cmp x0, #0xF0
+    b.lo decoderune_3
+    cmp x0, #0xF8
+    b.lo decoderune_4
+    b decoderune_invalid
+decoderune_2:
+    and x0, x0, #0x1F
+    mov x1, #2
+    b decoderune_rest
+decoderune_3:
+    and x0, x0, #0x0F
+    mov x1, #3
+    b decoderune_rest
+decoderune_4:
+    and x0, x0, #0x07
+    mov x1, #4
+decoderune_rest:
+    mov x3, #1
+decoderune_loop:
+    ldrb w4, [x2, x3]
+    and x5, x4, #0xC0
+    cmp x5, #0x80
+    b.ne decoderune_invalid
+    and x4, x4, #0x3F
+    orr x0, x4, x0, lsl #6
+    add x3, x3, #1
+    cmp x3, x1
+    b.lo decoderune_loop
+    ret
+decoderune_ascii:
+    mov x1, #1
+    ret
+decoderune_invalid:
+    mov x0, #0xFFFD
+    mov x1, #1
+    ret
+
+// Runtime functions for defer, panic and recover (ARM64 macOS)
+// See asmgen/defer.go for the layout of defer records. _run_defers runs the
+// deferred calls of the frame in x0, the last deferred one first.
+.p2align 2
+_run_defers:
+    stp x29, x30, [sp, #-16]!
+    str x0, [sp, #-16]!
+.p2align 2
+rundefers_loop:
+    adrp x17, defer_top@PAGE
+    add x17, x17, defer_top@PAGEOFF
+    ldr x0, [x17]
+    cbz x0, rundefers_done
+    ldr x1, [sp]
+    ldr x2, [x0, #8]
+    cmp x1, x2         // a record of another frame
+    bne rundefers_done
+    ldr x1, [x0]       // unlink the record before its call
+    str x1, [x17]
+    bl _call_deferred
+    b rundefers_loop
+.p2align 2
+rundefers_done:
+    add sp, sp, #16
     ldp x29, x30, [sp], #16
     ret
+
+// Calls the deferred call of the record in x0. The deferred function
+// returns to the caller of _call_deferred.
+.p2align 2
+_call_deferred:
+    mov x16, x0
+    ldr x9, [x16, #24] // closure
+    ldp x0, x1, [x16, #40]
+    ldp x2, x3, [x16, #56]
+    ldp x4, x5, [x16, #72]
+    ldp x6, x7, [x16, #88]
+    ldr x16, [x9]
+    br x16
+
+// panic(v) with v in x0 and its type descriptor in x1. Runs the deferred
+// calls of every frame; when one of them recovers, the function that
+// deferred it returns normally through its exit code.
+.p2align 2
+_panic:
+    adrp x17, panic_state@PAGE
+    add x17, x17, panic_state@PAGEOFF
+    str x0, [x17]      // value
+    str x1, [x17, #8]  // type descriptor
+    mov x2, #1
+    str x2, [x17, #16] // panicking
+.p2align 2
+panic_loop:
+    adrp x17, defer_top@PAGE
+    add x17, x17, defer_top@PAGEOFF
+    ldr x0, [x17]
+    cbz x0, panic_exit
+    ldr x1, [x0]
+    str x1, [x17]
+    str x0, [sp, #-16]!
+    bl _call_deferred
+.p2align 2
+panic_deferred_return:
+    ldr x0, [sp], #16
+    adrp x17, panic_state@PAGE
+    add x17, x17, panic_state@PAGEOFF
+    ldr x1, [x17, #16]
+    cbnz x1, panic_loop
+    ldr x29, [x0, #8]  // frame
+    ldr x16, [x0, #16] // resume
+    br x16
+
+// recover() takes the return address of the function calling it in x0:
+// only a deferred call run by _panic returns to panic_deferred_return. It
+// returns the panic value in x0 and x1, or nil when not panicking.
+.p2align 2
+_recover:
+    adr x2, panic_deferred_return
+    cmp x0, x2
+    bne recover_nil
+    adrp x17, panic_state@PAGE
+    add x17, x17, panic_state@PAGEOFF
+    ldr x2, [x17, #16]
+    cbz x2, recover_nil
+    str xzr, [x17, #16]
+    ldr x0, [x17]
+    ldr x1, [x17, #8]
+    ret
+.p2align 2
+recover_nil:
+    mov x0, #0
+    mov x1, #0
+    ret
+
+// Nobody recovered: print the value to stderr like Go and exit with status 2
+.p2align 2
+panic_exit:
+    mov x16, #90       // sys_dup2: the print functions write to stderr
+    mov x0, #2
+    mov x1, #1
+    svc #0x80
+    mov x16, #4        // sys_write
+    mov x0, #1
+    adrp x1, panic_msg@PAGE
+    add x1, x1, panic_msg@PAGEOFF
+    mov x2, #7         // length
+    svc #0x80
+    adrp x17, panic_state@PAGE
+    add x17, x17, panic_state@PAGEOFF
+    ldr x19, [x17, #8]
+    adrp x0, panic_nil@PAGE
+    add x0, x0, panic_nil@PAGEOFF
+    cbz x19, panic_print_string
+    ldr x19, [x19]     // type name
+    mov x0, x19
+    adrp x1, panic_string_type@PAGE
+    add x1, x1, panic_string_type@PAGEOFF
+    bl _string_equal
+    cbz x0, panic_check_float
+    adrp x17, panic_state@PAGE
+    add x17, x17, panic_state@PAGEOFF
+    ldr x0, [x17]
+.p2align 2
+panic_print_string:
+    bl _print_string
+    b panic_die
+.p2align 2
+panic_check_float:
+    mov x0, x19
+    adrp x1, panic_float_type@PAGE
+    add x1, x1, panic_float_type@PAGEOFF
+    bl _string_equal
+    cbz x0, panic_check_bool
+    adrp x17, panic_state@PAGE
+    add x17, x17, panic_state@PAGEOFF
+    ldr x0, [x17]
+    bl _print_float
+    b panic_die
+.p2align 2
+panic_check_bool:
+    mov x0, x19
+    adrp x1, panic_bool_type@PAGE
+    add x1, x1, panic_bool_type@PAGEOFF
+    bl _string_equal
+    cbz x0, panic_check_int
+    adrp x17, panic_state@PAGE
+    add x17, x17, panic_state@PAGEOFF
+    ldr x2, [x17]
+    adrp x0, panic_false@PAGE
+    add x0, x0, panic_false@PAGEOFF
+    adrp x1, panic_true@PAGE
+    add x1, x1, panic_true@PAGEOFF
+    cmp x2, #0
+    csel x0, x1, x0, ne
+    b panic_print_string
+.p2align 2
+panic_check_int:
+    adrp x20, panic_int_types@PAGE
+    add x20, x20, panic_int_types@PAGEOFF
+.p2align 2
+panic_int_loop:
+    ldr x1, [x20]
+    cbz x1, panic_print_type
+    mov x0, x19
+    bl _string_equal
+    add x20, x20, #8
+    cbz x0, panic_int_loop
+    adrp x17, panic_state@PAGE
+    add x17, x17, panic_state@PAGEOFF
+    ldr x0, [x17]
+    bl _print_number
+    b panic_die
+.p2align 2
+panic_print_type:
+    // Other values print their type: (T)
+    mov x16, #4        // sys_write
+    mov x0, #1
+    adrp x1, panic_open@PAGE
+    add x1, x1, panic_open@PAGEOFF
+    mov x2, #1
+    svc #0x80
+    mov x2, #0
+.p2align 2
+panic_type_len:
+    ldrb w3, [x19, x2]
+    cbz w3, panic_type_write
+    add x2, x2, #1
+    b panic_type_len
+.p2align 2
+panic_type_write:
+    mov x16, #4        // sys_write
+    mov x0, #1
+    mov x1, x19
+    svc #0x80
+    adrp x0, panic_close@PAGE
+    add x0, x0, panic_close@PAGEOFF
+    bl _print_string
+.p2align 2
+panic_die:
+    mov x16, #1        // sys_exit
+    mov x0, #2         // exit status
+    svc #0x80
+
+// Assigning to an entry of a nil map panics
+_map_nil_panic:
+    adrp x0, map_nil_msg@PAGE
+    add x0, x0, map_nil_msg@PAGEOFF
+    b _runtime_panic
+
+// Dereferencing a nil pointer panics
+_nil_panic:
+    adrp x0, nil_deref_msg@PAGE
+    add x0, x0, nil_deref_msg@PAGEOFF
+    b _runtime_panic
+
+// Slice indices out of range panic
+_slice_panic:
+    adrp x0, slice_bounds_msg@PAGE
+    add x0, x0, slice_bounds_msg@PAGEOFF
+    b _runtime_panic
+
+// Indices out of range panic
+_index_panic:
+    adrp x0, index_msg@PAGE
+    add x0, x0, index_msg@PAGEOFF
+    b _runtime_panic
+
+// Run-time errors panic with their message as a string value, so that the
+// deferred calls run and recover() can stop them
+_runtime_panic:
+    adrp x1, _type.string@PAGE
+    add x1, x1, _type.string@PAGEOFF
+    b _panic
+
+// Integer division by zero panics
+_divide_panic:
+    adrp x0, divide_msg@PAGE
+    add x0, x0, divide_msg@PAGEOFF
+    b _runtime_panic
+
+// Shifting by a negative count panics
+_shift_panic:
+    adrp x0, shift_msg@PAGE
+    add x0, x0, shift_msg@PAGEOFF
+    b _runtime_panic
+
+.section __DATA,__data
+.p2align 3
+heap_ptr:
+    .quad heap
+defer_top:
+    .quad 0
+panic_state:
+    .quad 0, 0, 0      // value, type descriptor, panicking
+panic_int_types:
+    .quad panic_int_name, panic_int8_name, panic_int16_name, panic_int32_name, panic_int64_name
+    .quad panic_uint_name, panic_uint8_name, panic_uint16_name, panic_uint32_name, panic_uint64_name, 0
+print_end:
+    .byte 10           // ends what the print functions print
+
+.zerofill __DATA,__bss,heap,16777216,4
+
+.section __TEXT,__cstring,cstring_literals
+type_assert_msg:
+    .asciz "interface conversion: type assertion failed"
+map_nil_msg:
+    .asciz "assignment to entry in nil map"
+nil_deref_msg:
+    .asciz "runtime error: invalid memory address or nil pointer dereference"
+slice_bounds_msg:
+    .asciz "runtime error: slice bounds out of range"
+index_msg:
+    .asciz "runtime error: index out of range"
+divide_msg:
+    .asciz "runtime error: integer divide by zero"
+shift_msg:
+    .asciz "runtime error: negative shift amount"
+panic_msg:
+    .asciz "panic: "
+panic_nil:
+    .asciz "nil"
+panic_true:
+    .asciz "true"
+panic_false:
+    .asciz "false"
+panic_open:
+    .asciz "("
+panic_close:
+    .asciz ")"
+panic_string_type:
+    .asciz "string"
+panic_float_type:
+    .asciz "float64"
+panic_bool_type:
+    .asciz "bool"
+panic_int_name:
+    .asciz "int"
+panic_int8_name:
+    .asciz "int8"
+panic_int16_name:
+    .asciz "int16"
+panic_int32_name:
+    .asciz "int32"
+panic_int64_name:
+    .asciz "int64"
+panic_uint_name:
+    .asciz "uint"
+panic_uint8_name:
+    .asciz "uint8"
+panic_uint16_name:
+    .asciz "uint16"
+panic_uint32_name:
+    .asciz "uint32"
+panic_uint64_name:
+    .asciz "uint64"
 `
 	return runtime
 }
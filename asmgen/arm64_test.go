@@ -57,7 +57,14 @@ func TestARM64Generator_Generate_BinaryOperations(t *testing.T) {
 		{"addition", token.ADD, "add x0, x0, x1"},
 		{"subtraction", token.SUB, "sub x0, x0, x1"},
 		{"multiplication", token.MUL, "mul x0, x0, x1"},
-		{"division", token.QUO, "udiv x0, x0, x1"},
+		{"division", token.QUO, "sdiv x0, x0, x1"},
+		{"remainder", token.REM, "msub x0, x2, x1, x0"},
+		{"and", token.AND, "and x0, x0, x1"},
+		{"or", token.OR, "orr x0, x0, x1"},
+		{"xor", token.XOR, "eor x0, x0, x1"},
+		{"and not", token.AND_NOT, "bic x0, x0, x1"},
+		{"shift left", token.SHL, "lsl x2, x0, x1"},
+		{"shift right", token.SHR, "asr x0, x0, x1"},
 	}
 
 	for _, tt := range tests {
@@ -115,7 +122,10 @@ func TestARM64Generator_GenerateRuntime(t *testing.T) {
 	if !strings.Contains(runtime, "mov w3, #48") {
 		t.Error("Missing ASCII '0' conversion")
 	}
-	if !strings.Contains(runtime, "udiv x4, x0, x3") {
+	if !strings.Contains(runtime, "tbz x0, #63, convert_start") {
+		t.Error("Missing minus sign for negative numbers")
+	}
+	if !strings.Contains(runtime, "sdiv x4, x0, x3") {
 		t.Error("Missing division operation")
 	}
 	if !strings.Contains(runtime, "msub x5, x4, x3, x0") {
@@ -281,7 +291,7 @@ func TestARM64Generator_UntestedFeatures(t *testing.T) {
 	// Test return statement (generateReturnStatement)
 	t.Run("return_statement", func(t *testing.T) {
 		returnStmt := &ast.ReturnStatement{
-			Value: &ast.NumberNode{Value: 42},
+			Values: []ast.ASTNode{&ast.NumberNode{Value: 42}},
 		}
 		blockStmt := &ast.BlockStatement{Statements: []ast.Statement{returnStmt}}
 		funcStmt := &ast.FuncStatement{
@@ -336,7 +346,7 @@ func TestARM64Generator_UntestedFeatures(t *testing.T) {
 	// Test switch statement (generateSwitchStatement)
 	t.Run("switch_statement", func(t *testing.T) {
 		caseStmt := &ast.CaseStatement{
-			Value: &ast.NumberNode{Value: 1},
+			Values: []ast.ASTNode{&ast.NumberNode{Value: 1}},
 			Body: &ast.BlockStatement{
 				Statements: []ast.Statement{
 					&ast.ExpressionStatement{
@@ -360,9 +370,8 @@ func TestARM64Generator_UntestedFeatures(t *testing.T) {
 		}
 
 		switchStmt := &ast.SwitchStatement{
-			Value:   &ast.VariableNode{Name: "x"},
-			Cases:   []*ast.CaseStatement{caseStmt},
-			Default: defaultStmt,
+			Value: &ast.VariableNode{Name: "x"},
+			Cases: []*ast.CaseStatement{caseStmt, {Body: defaultStmt}},
 		}
 
 		// Create variable x := 1
@@ -634,9 +643,9 @@ func TestARM64Generator_GenerateStatementCoverage(t *testing.T) {
 	// Test VarStatement coverage
 	t.Run("var_statement", func(t *testing.T) {
 		varStmt := &ast.VarStatement{
-			Name:     "x",
-			TypeName: "int",
-			Value:    &ast.NumberNode{Value: 42},
+			Name:  "x",
+			Type:  ast.NewIdentType("int"),
+			Value: &ast.NumberNode{Value: 42},
 		}
 		blockStmt := &ast.BlockStatement{Statements: []ast.Statement{varStmt}}
 		funcStmt := &ast.FuncStatement{
@@ -659,8 +668,8 @@ func TestARM64Generator_GenerateStatementCoverage(t *testing.T) {
 			Value: &ast.NumberNode{Value: 10},
 		}
 		reassignStmt := &ast.ReassignStatement{
-			Name:  "x",
-			Value: &ast.NumberNode{Value: 20},
+			Target: &ast.VariableNode{Name: "x"},
+			Value:  &ast.NumberNode{Value: 20},
 		}
 		blockStmt := &ast.BlockStatement{Statements: []ast.Statement{assignStmt, reassignStmt}}
 		funcStmt := &ast.FuncStatement{
@@ -753,7 +762,7 @@ func TestARM64Generator_GenerateStatementCoverage(t *testing.T) {
 		blockStmt := &ast.BlockStatement{Statements: []ast.Statement{exprStmt}}
 		funcStmt := &ast.FuncStatement{
 			Name:       "test", // Not main
-			Parameters: []ast.Parameter{{Name: "x", Type: "int"}},
+			Parameters: []ast.Parameter{{Name: "x", Type: ast.NewIdentType("int")}},
 			Body:       blockStmt,
 		}
 		statements := []ast.Statement{funcStmt}
@@ -772,3 +781,200 @@ func TestARM64Generator_GenerateStatementCoverage(t *testing.T) {
 		}
 	})
 }
+
+func TestARM64Generator_FloatOperations(t *testing.T) {
+	tests := []struct {
+		name     string
+		operator token.Token
+		expected []string
+	}{
+		{"addition", token.ADD, []string{"fadd d0, d0, d1", "fmov x0, d0"}},
+		{"subtraction", token.SUB, []string{"fsub d0, d0, d1"}},
+		{"multiplication", token.MUL, []string{"fmul d0, d0, d1"}},
+		{"division", token.QUO, []string{"fdiv d0, d0, d1"}},
+		{"less than", token.LSS, []string{"fcmp d0, d1", "cset x0, mi"}},
+		{"greater equal", token.GEQ, []string{"fcmp d0, d1", "cset x0, ge"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewARM64Generator()
+
+			binOp := &ast.BinaryOpNode{
+				Left:     &ast.FloatNode{Value: 1.5},
+				Operator: tt.operator,
+				Right:    &ast.NumberNode{Value: 2},
+			}
+			printCall := &ast.CallNode{Function: "println", Arguments: []ast.ASTNode{binOp}}
+			funcStmt := &ast.FuncStatement{
+				Name: "main",
+				Body: &ast.BlockStatement{Statements: []ast.Statement{&ast.ExpressionStatement{Expression: printCall}}},
+			}
+
+			result := gen.Generate([]ast.Statement{funcStmt})
+
+			// 1.5 is 0x3FF8000000000000
+			if !strings.Contains(result, "movk x0, #0x3ff8, lsl #48") {
+				t.Error("Missing float64 literal load")
+			}
+			if !strings.Contains(result, "scvtf d0, x0") {
+				t.Error("Missing int to float64 conversion")
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Missing instruction: %s", expected)
+				}
+			}
+		})
+	}
+}
+
+func TestARM64Generator_FloatPrintAndConversion(t *testing.T) {
+	gen := NewARM64Generator()
+
+	// f := 2.5; println(f); println(int(f))
+	funcStmt := &ast.FuncStatement{
+		Name: "main",
+		Body: &ast.BlockStatement{Statements: []ast.Statement{
+			&ast.AssignStatement{Name: "f", Value: &ast.FloatNode{Value: 2.5}},
+			&ast.ExpressionStatement{Expression: &ast.CallNode{Function: "println", Arguments: []ast.ASTNode{&ast.VariableNode{Name: "f"}}}},
+			&ast.ExpressionStatement{Expression: &ast.CallNode{Function: "println", Arguments: []ast.ASTNode{
+				&ast.CallNode{Function: "int", Arguments: []ast.ASTNode{&ast.VariableNode{Name: "f"}}},
+			}}},
+		}},
+	}
+
+	result := gen.Generate([]ast.Statement{funcStmt})
+
+	expected := []string{"bl _print_float", "fcvtzs x0, d0", "bl _print_number"}
+	for _, instr := range expected {
+		if !strings.Contains(result, instr) {
+			t.Errorf("Missing instruction: %s", instr)
+		}
+	}
+
+	if !strings.Contains(gen.GenerateRuntime(), "_print_float:") {
+		t.Error("Missing _print_float runtime function")
+	}
+}
+
+func TestARM64Generator_RuneAndRawString(t *testing.T) {
+	gen := NewARM64Generator()
+
+	// c := rune(x) where x := 'é'; println(c); println("tab\there")
+	funcStmt := &ast.FuncStatement{
+		Name: "main",
+		Body: &ast.BlockStatement{Statements: []ast.Statement{
+			&ast.AssignStatement{Name: "x", Value: &ast.RuneNode{Value: 'é'}},
+			&ast.AssignStatement{Name: "c", Value: &ast.CallNode{Function: "rune", Arguments: []ast.ASTNode{&ast.VariableNode{Name: "x"}}}},
+			&ast.ExpressionStatement{Expression: &ast.CallNode{Function: "println", Arguments: []ast.ASTNode{&ast.VariableNode{Name: "c"}}}},
+			&ast.ExpressionStatement{Expression: &ast.CallNode{Function: "println", Arguments: []ast.ASTNode{&ast.StringNode{Value: "tab\there"}}}},
+		}},
+	}
+
+	result := gen.Generate([]ast.Statement{funcStmt})
+
+	expected := []string{
+		"mov x0, #233",
+		"sxtw x0, w0",
+		"bl _print_number",
+		`.asciz "tab\011here"`,
+		"bl _print_string",
+	}
+	for _, instr := range expected {
+		if !strings.Contains(result, instr) {
+			t.Errorf("Missing instruction: %s", instr)
+		}
+	}
+}
+
+func TestARM64Generator_StringByteIndex(t *testing.T) {
+	gen := NewARM64Generator()
+
+	// s := "héllo"; println(s[1])
+	funcStmt := &ast.FuncStatement{
+		Name: "main",
+		Body: &ast.BlockStatement{Statements: []ast.Statement{
+			&ast.AssignStatement{Name: "s", Value: &ast.StringNode{Value: "héllo"}},
+			&ast.ExpressionStatement{Expression: &ast.CallNode{Function: "println", Arguments: []ast.ASTNode{
+				&ast.IndexAccess{Object: &ast.VariableNode{Name: "s"}, Index: &ast.NumberNode{Value: 1}},
+			}}},
+		}},
+	}
+
+	result := gen.Generate([]ast.Statement{funcStmt})
+
+	if !strings.Contains(result, "ldrb w0, [x1, x0]") {
+		t.Error("Missing byte load for string index")
+	}
+	if !strings.Contains(result, "bl _print_number") {
+		t.Error("Missing print_number call")
+	}
+}
+
+func TestARM64Generator_CompoundAssignment(t *testing.T) {
+	gen := NewARM64Generator()
+
+	// var b byte = 200; b <<= 1
+	funcStmt := &ast.FuncStatement{
+		Name: "main",
+		Body: &ast.BlockStatement{Statements: []ast.Statement{
+			&ast.VarStatement{Name: "b", Type: ast.NewIdentType("byte"), Value: &ast.NumberNode{Value: 200}},
+			&ast.CompoundAssignStatement{Target: &ast.VariableNode{Name: "b"}, Operator: token.SHL_ASSIGN, Value: &ast.NumberNode{Value: 1}},
+		}},
+	}
+
+	result := gen.Generate([]ast.Statement{funcStmt})
+
+	expected := []string{
+		"ldr x0, [x29, #-8]", // load b
+		"lsl x2, x0, x1",
+		"and x0, x0, #0xff", // byte shift wraps around
+		"str x0, [x29, #-8]",
+	}
+	for _, instr := range expected {
+		if !strings.Contains(result, instr) {
+			t.Errorf("Missing instruction: %s", instr)
+		}
+	}
+}
+
+func TestARM64Generator_LogicalAndUnaryOperators(t *testing.T) {
+	gen := NewARM64Generator()
+
+	// ok := a > 0 || !done; n := -a; m := ^a; f := -1.5
+	funcStmt := &ast.FuncStatement{
+		Name: "main",
+		Body: &ast.BlockStatement{Statements: []ast.Statement{
+			&ast.AssignStatement{Name: "a", Value: &ast.NumberNode{Value: 3}},
+			&ast.AssignStatement{Name: "done", Value: &ast.BooleanNode{Value: false}},
+			&ast.AssignStatement{Name: "ok", Value: &ast.BinaryOpNode{
+				Left: &ast.BinaryOpNode{
+					Left:     &ast.VariableNode{Name: "a"},
+					Operator: token.GTR,
+					Right:    &ast.NumberNode{Value: 0},
+				},
+				Operator: token.LOR,
+				Right:    &ast.UnaryOpNode{Operator: token.NOT, Operand: &ast.VariableNode{Name: "done"}},
+			}},
+			&ast.AssignStatement{Name: "n", Value: &ast.UnaryOpNode{Operator: token.SUB, Operand: &ast.VariableNode{Name: "a"}}},
+			&ast.AssignStatement{Name: "m", Value: &ast.UnaryOpNode{Operator: token.XOR, Operand: &ast.VariableNode{Name: "a"}}},
+			&ast.AssignStatement{Name: "f", Value: &ast.UnaryOpNode{Operator: token.SUB, Operand: &ast.FloatNode{Value: 1.5}}},
+		}},
+	}
+
+	result := gen.Generate([]ast.Statement{funcStmt})
+
+	expected := []string{
+		"cbnz x0, L", // || skips the right operand when the left one is true
+		"eor x0, x0, #1",
+		"neg x0, x0",
+		"mvn x0, x0",
+		"eor x0, x0, #0x8000000000000000",
+	}
+	for _, instr := range expected {
+		if !strings.Contains(result, instr) {
+			t.Errorf("Missing instruction: %s", instr)
+		}
+	}
+}
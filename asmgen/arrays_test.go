@@ -0,0 +1,51 @@
+package asmgen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+)
+
+func TestBlockFields(t *testing.T) {
+	types := newTypeEnv()
+	types.collectFunctions([]ast.Statement{
+		&ast.TypeStatement{Name: "Point", Fields: []*ast.FieldDef{{Name: "x", Type: ast.NewIdentType("int")}}},
+		&ast.TypeStatement{Name: "Shape", Fields: []*ast.FieldDef{
+			{Name: "origin", Type: ast.NewIdentType("Point")},
+			{Name: "corners", Type: &ast.ArrayType{Len: 4, Elem: ast.NewIdentType("Point")}},
+			{Name: "next", Type: &ast.PointerType{Elem: ast.NewIdentType("Shape")}},
+			{Name: "tags", Type: &ast.SliceType{Elem: ast.NewIdentType("string")}},
+		}},
+	})
+
+	if got := strings.Join(types.blockFields("Shape", nil), ","); got != "origin,corners" {
+		t.Errorf("expected the struct and array fields, got %s", got)
+	}
+	given := map[string]ast.ASTNode{"origin": &ast.StructLiteral{TypeName: "Point"}}
+	if got := strings.Join(types.blockFields("Shape", given), ","); got != "corners" {
+		t.Errorf("expected the fields not given, got %s", got)
+	}
+}
+
+func TestSliceElementType(t *testing.T) {
+	tests := []struct {
+		typeName string
+		elem     string
+		isArray  bool
+	}{
+		{"[]int", "int", false},
+		{"[][]string", "[]string", false},
+		{"[3]int", "int", true},
+		{"[2][4]*Node", "[4]*Node", true},
+	}
+
+	for _, tt := range tests {
+		if got := sliceElementType(tt.typeName); got != tt.elem {
+			t.Errorf("sliceElementType(%q) = %q, want %q", tt.typeName, got, tt.elem)
+		}
+		if got := isArrayType(tt.typeName); got != tt.isArray {
+			t.Errorf("isArrayType(%q) = %v, want %v", tt.typeName, got, tt.isArray)
+		}
+	}
+}
@@ -2,14 +2,18 @@ package asmgen
 
 import (
 	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/scanner"
 )
 
 // ArchGenerator defines the interface for architecture-specific assembly generators
 type ArchGenerator interface {
 	Generate(statements []ast.Statement) string
 	GenerateRuntime() string
+	Errors() []*scanner.Error
 }
 
 // AsmGenerator wraps the architecture-specific generator
@@ -44,3 +48,41 @@ func (g *AsmGenerator) Generate(statements []ast.Statement) string {
 func (g *AsmGenerator) GenerateRuntime() string {
 	return g.generator.GenerateRuntime()
 }
+
+// Errors returns the type errors found by Generate, such as assigning a
+// value to an interface type it does not implement
+func (g *AsmGenerator) Errors() []*scanner.Error {
+	return g.generator.Errors()
+}
+
+// frameSizePlaceholder stands for the stack frame size of a function until
+// its body has been generated and the space its locals take is known
+const frameSizePlaceholder = "FRAME_SIZE"
+
+// frameSize returns the stack frame size for locals taking stackSize bytes:
+// at least 64 bytes, rounded up to keep the stack 16-byte aligned
+func frameSize(stackSize int) int {
+	if stackSize < 64 {
+		return 64
+	}
+	return (stackSize + 15) &^ 15
+}
+
+// patchFrameSize replaces the frame size placeholder in the code of a
+// function, which starts at offset start of output
+func patchFrameSize(output *strings.Builder, start, stackSize int) {
+	code := output.String()
+	output.Reset()
+	output.WriteString(code[:start])
+	output.WriteString(strings.ReplaceAll(code[start:], frameSizePlaceholder, strconv.Itoa(frameSize(stackSize))))
+}
+
+// endsWithReturn reports whether the last statement of a function body is a
+// return statement, so the function cannot fall off its end
+func endsWithReturn(body *ast.BlockStatement) bool {
+	if body == nil || len(body.Statements) == 0 {
+		return false
+	}
+	_, ok := body.Statements[len(body.Statements)-1].(*ast.ReturnStatement)
+	return ok
+}
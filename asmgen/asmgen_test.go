@@ -1,11 +1,14 @@
 package asmgen
 
 import (
+	"regexp"
 	"runtime"
 	"strings"
 	"testing"
 
 	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/parser"
+	"github.com/yuya-takeyama/petitgo/scanner"
 	"github.com/yuya-takeyama/petitgo/token"
 )
 
@@ -232,7 +235,7 @@ func TestX86_64Generator_CrossPlatformFeatures(t *testing.T) {
 	// Test return statement
 	t.Run("return_statement", func(t *testing.T) {
 		returnStmt := &ast.ReturnStatement{
-			Value: &ast.NumberNode{Value: 42},
+			Values: []ast.ASTNode{&ast.NumberNode{Value: 42}},
 		}
 		blockStmt := &ast.BlockStatement{Statements: []ast.Statement{returnStmt}}
 		funcStmt := &ast.FuncStatement{
@@ -278,7 +281,7 @@ func TestX86_64Generator_CrossPlatformFeatures(t *testing.T) {
 	// Test switch statement
 	t.Run("switch_statement", func(t *testing.T) {
 		caseStmt := &ast.CaseStatement{
-			Value: &ast.NumberNode{Value: 1},
+			Values: []ast.ASTNode{&ast.NumberNode{Value: 1}},
 			Body: &ast.BlockStatement{
 				Statements: []ast.Statement{
 					&ast.ExpressionStatement{
@@ -291,9 +294,8 @@ func TestX86_64Generator_CrossPlatformFeatures(t *testing.T) {
 			},
 		}
 		switchStmt := &ast.SwitchStatement{
-			Value:   &ast.VariableNode{Name: "x"},
-			Cases:   []*ast.CaseStatement{caseStmt},
-			Default: &ast.BlockStatement{Statements: []ast.Statement{}},
+			Value: &ast.VariableNode{Name: "x"},
+			Cases: []*ast.CaseStatement{caseStmt, {Body: &ast.BlockStatement{Statements: []ast.Statement{}}}},
 		}
 
 		assignStmt := &ast.AssignStatement{
@@ -355,3 +357,200 @@ func TestX86_64Generator_CrossPlatformFeatures(t *testing.T) {
 		}
 	})
 }
+
+// TestGenerate_Structure generates programs using each feature on both
+// architectures and checks the structure of the output: the program has no
+// type errors, every frame size is patched, every jump goes to a defined
+// label, and the routines the program uses are defined in the output or the
+// runtime. What the programs print is tested natively in tests/integration.
+func TestGenerate_Structure(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		routines []string          // routines used and defined
+		types    map[string]string // inferred types of variables of main, the last function generated
+	}{
+		{
+			name: "maps",
+			code: `func main() {
+	m := map[string]int{"a": 1}
+	m["b"] = 2
+	v, ok := m["a"]
+	delete(m, "a")
+	println(len(m), v, ok)
+}`,
+			routines: []string{"_map_new", "_map_store", "_map_lookup", "_map_delete"},
+			types:    map[string]string{"v": "int", "ok": "bool"},
+		},
+		{
+			name: "results",
+			code: `func divmod(a int) (int, int) { return a / 3, a % 3 }
+func pair(x int) (lo, hi int) {
+	hi = x
+	return
+}
+func main() {
+	q, r := divmod(7)
+	q, r = r, q
+	_, r = pair(8)
+	println(q, r)
+}`,
+			routines: []string{"_divmod", "_pair"},
+			types:    map[string]string{"q": "int", "r": "int"},
+		},
+		{
+			name: "methods and interfaces",
+			code: `type Shape interface { Area() int }
+type Square struct { side int }
+func (s Square) Area() int { return s.side * s.side }
+func (s *Square) Grow() { s.side++ }
+func main() {
+	sq := Square{side: 2}
+	sq.Grow()
+	var s Shape = sq
+	a := s.Area()
+	v, ok := s.(Square)
+	switch x := s.(type) {
+	case Square:
+		println(x.side)
+	}
+	println(a, v.side, ok)
+}`,
+			routines: []string{"_Square.Area", "_Square.Grow", "_type.Square", "_nil_panic", "_copy.Square"},
+			types:    map[string]string{"a": "int", "v": "Square", "ok": "bool"},
+		},
+		{
+			name: "closures and pointers",
+			code: `type Point struct { X, Y int }
+func double(n int) int { return n * 2 }
+func main() {
+	n := 1
+	inc := func(d int) { n += d }
+	inc(2)
+	f := double
+	pt := &Point{X: 1}
+	pt.Y++
+	q := new(int)
+	println(f(n), pt.Y, *q)
+}`,
+			routines: []string{"_alloc", "_double", "_nil_panic"},
+		},
+		{
+			name: "ranges, arrays and slices",
+			code: `func main() {
+	var names [2]string
+	grid := [][]int{{1}, {2, 3}}
+	for i, row := range grid {
+		println(i, len(row))
+	}
+	for _, r := range "hé" {
+		println(r)
+	}
+	for k := range map[string]int{"a": 1} {
+		println(k)
+	}
+	copied := names
+	t := grid[1][1:]
+	println(len(copied), cap(t), "abc"[:2])
+}`,
+			routines: []string{"_decode_rune", "_slice", "_slice_string", "_index_panic", "_copy.array2.string"},
+			types:    map[string]string{"grid": "[][]int", "copied": "[2]string", "t": "[]int"},
+		},
+		{
+			name: "globals and branches",
+			code: `var total = count + 1
+var count = 4
+func main() {
+outer:
+	for i := 0; ; i++ {
+		for {
+			continue outer
+		}
+		break
+	}
+	goto done
+done:
+	println(total)
+}`,
+			routines: []string{"_var.total", "_var.count"},
+		},
+		{
+			name: "defer",
+			code: `func cleanup(n int) {}
+func f() int {
+	defer cleanup(1)
+	defer func() {
+		recover()
+	}()
+	panic("boom")
+}
+func main() {
+	f()
+}`,
+			routines: []string{"_run_defers", "_panic", "_recover", "_cleanup"},
+		},
+	}
+
+	generators := []struct {
+		name string
+		new  func() ArchGenerator
+	}{
+		{"x86_64", func() ArchGenerator { return NewX86_64Generator() }},
+		{"arm64", func() ArchGenerator { return NewARM64Generator() }},
+	}
+	jump := regexp.MustCompile(`^\s+(j[a-z]+|b(\.[a-z]+)?|cbn?z \w+,)\s+(L\d+)$`)
+
+	for _, tt := range tests {
+		p := parser.NewParser(scanner.NewFileScanner("test.pg", tt.code))
+		var statements []ast.Statement
+		for stmt := p.ParseStatement(); stmt != nil; stmt = p.ParseStatement() {
+			statements = append(statements, stmt)
+		}
+		if errs := p.Errors(); len(errs) > 0 {
+			t.Fatalf("%s: unexpected parse errors: %v", tt.name, errs)
+		}
+
+		for _, g := range generators {
+			t.Run(tt.name+"/"+g.name, func(t *testing.T) {
+				gen := g.new()
+				result := gen.Generate(statements)
+				if errs := gen.Errors(); len(errs) > 0 {
+					t.Fatalf("unexpected type errors: %v", errs)
+				}
+				if strings.Contains(result, frameSizePlaceholder) {
+					t.Error("expected every frame size to be patched")
+				}
+
+				for _, line := range strings.Split(result, "\n") {
+					if m := jump.FindStringSubmatch(line); m != nil && !strings.Contains(result, "\n"+m[3]+":\n") {
+						t.Errorf("expected the target of %q to be defined", strings.TrimSpace(line))
+					}
+				}
+
+				defined := result + gen.GenerateRuntime()
+				for _, routine := range tt.routines {
+					if !strings.Contains(result, routine) {
+						t.Errorf("expected the output to use %s", routine)
+					}
+					// ARM64 reserves package-level variables with .zerofill
+					if !strings.Contains(defined, "\n"+routine+":") && !strings.Contains(defined, ","+routine+",") {
+						t.Errorf("expected %s to be defined", routine)
+					}
+				}
+
+				var types *typeEnv
+				switch gen := gen.(type) {
+				case *X86_64Generator:
+					types = &gen.types
+				case *ARM64Generator:
+					types = &gen.types
+				}
+				for name, want := range tt.types {
+					if got := types.varTypes[name]; got != want {
+						t.Errorf("expected %s to be a %s, got %q", name, want, got)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package asmgen
+
+import (
+	"strings"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/token"
+)
+
+// An assignment stores to a variable, a struct field, a slice or array
+// element, a map entry or the value a pointer points to. The operands of
+// the target are evaluated before the value, like in Go.
+//
+// A compound assignment x op= value, x++ or x-- is lowered to the plain
+// assignment x = x op value. So that the operands of x are evaluated only
+// once, they are first assigned to hidden variables, whose names start with
+// a dot and cannot clash with those of the program:
+//
+//	grid[y][x] += 1   =>   .L1 := grid[y]; .L1[x] = .L1[x] + 1
+//
+// A tuple assignment to targets that are not all variables is lowered the
+// same way: the operands of the targets and then the values are assigned to
+// hidden variables, before the targets are assigned from left to right.
+//
+//	xs[i], xs[j] = xs[j], xs[i]   =>
+//	    .L1 := xs; .L2 := i; .L3 := xs; .L4 := j; .L5 := xs[j]; .L6 := xs[i]
+//	    .L1[.L2] = .L5; .L3[.L4] = .L6
+
+// lowerOpAssign returns the statements x op= value is lowered to. hidden
+// returns the name of a new hidden variable.
+func lowerOpAssign(x ast.ASTNode, op token.Token, value ast.ASTNode, pos token.Position, hidden func() string) []ast.Statement {
+	var statements []ast.Statement
+	hoist := func(operand ast.ASTNode) ast.ASTNode {
+		switch operand.(type) {
+		case *ast.VariableNode, *ast.NumberNode, *ast.StringNode, *ast.ConstExpr:
+			return operand
+		}
+		name := hidden()
+		statements = append(statements, &ast.AssignStatement{Name: name, Value: operand, Pos: pos})
+		return &ast.VariableNode{Name: name, Pos: pos}
+	}
+
+	x = hoistOperands(x, hoist)
+	return append(statements, &ast.ReassignStatement{
+		Target: x,
+		Value:  &ast.BinaryOpNode{Left: x, Operator: op, Right: value, Pos: pos},
+		Pos:    pos,
+	})
+}
+
+// lowerTupleAssign returns the statements a tuple assignment to targets
+// that are not all variables is lowered to. hidden returns the name of a
+// new hidden variable.
+func lowerTupleAssign(s *ast.TupleAssignStatement, hidden func() string) []ast.Statement {
+	var statements []ast.Statement
+	hoist := func(operand ast.ASTNode) ast.ASTNode {
+		switch operand.(type) {
+		case *ast.NumberNode, *ast.StringNode, *ast.ConstExpr:
+			return operand
+		}
+		name := hidden()
+		statements = append(statements, &ast.AssignStatement{Name: name, Value: operand, Pos: s.Pos})
+		return &ast.VariableNode{Name: name, Pos: s.Pos}
+	}
+
+	targets := make([]ast.ASTNode, len(s.Targets))
+	for i, target := range s.Targets {
+		targets[i] = hoistOperands(target, hoist)
+	}
+	values := make([]ast.ASTNode, len(targets))
+	if len(s.Values) == len(targets) {
+		for i, value := range s.Values {
+			values[i] = hoist(value)
+		}
+	} else {
+		// The results of a call or a comma-ok expression
+		for i := range values {
+			values[i] = &ast.VariableNode{Name: hidden(), Pos: s.Pos}
+		}
+		statements = append(statements, &ast.TupleAssignStatement{Targets: values, Define: true, Values: s.Values, Pos: s.Pos})
+	}
+	for i, target := range targets {
+		if !isBlank(target) {
+			statements = append(statements, &ast.ReassignStatement{Target: target, Value: values[i], Pos: s.Pos})
+		}
+	}
+	return statements
+}
+
+// hoistOperands returns an assignment target with the operands of an index
+// expression, a field access or a dereference replaced by hoist
+func hoistOperands(x ast.ASTNode, hoist func(ast.ASTNode) ast.ASTNode) ast.ASTNode {
+	switch t := x.(type) {
+	case *ast.IndexAccess:
+		return &ast.IndexAccess{Object: hoist(t.Object), Index: hoist(t.Index), Pos: t.Pos}
+	case *ast.FieldAccessNode:
+		return &ast.FieldAccessNode{Object: hoist(t.Object), Field: t.Field, Pos: t.Pos}
+	case *ast.DerefNode:
+		return &ast.DerefNode{Operand: hoist(t.Operand), Pos: t.Pos}
+	}
+	return x
+}
+
+// isHidden reports whether a variable is a hidden variable of a lowered
+// assignment. A hidden variable holding a struct or an array stands for the
+// operand itself, so the value is not copied.
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
+
+// assignsVariables reports whether all targets of a tuple assignment are
+// variables, which are assigned without lowering
+func assignsVariables(s *ast.TupleAssignStatement) bool {
+	for _, name := range s.Names() {
+		if name == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// isBlank reports whether an assignment target is the blank identifier
+func isBlank(x ast.ASTNode) bool {
+	variable, ok := x.(*ast.VariableNode)
+	return ok && variable.Name == "_"
+}
+
+// variableTargets returns the variables of the given names as assignment
+// targets
+func variableTargets(names []string, pos token.Position) []ast.ASTNode {
+	targets := make([]ast.ASTNode, len(names))
+	for i, name := range names {
+		targets[i] = &ast.VariableNode{Name: name, Pos: pos}
+	}
+	return targets
+}
+
+// checkAssignment reports the targets of an assignment that can be told
+// from assignable ones only by their types: an element of a string, and a
+// field or an array element of a struct or array that is not addressable,
+// being a map entry, the result of a call or a composite literal. The
+// parser has checked the form of the targets.
+func (t *typeEnv) checkAssignment(stmt ast.Statement) {
+	switch s := stmt.(type) {
+	case *ast.ReassignStatement:
+		t.checkAssignable(s.Target)
+	case *ast.CompoundAssignStatement:
+		t.checkAssignable(s.Target)
+	case *ast.IncStatement:
+		t.checkAssignable(s.Target)
+	case *ast.DecStatement:
+		t.checkAssignable(s.Target)
+	case *ast.TupleAssignStatement:
+		if !s.Define {
+			for _, target := range s.Targets {
+				t.checkAssignable(target)
+			}
+		}
+	}
+}
+
+// checkAssignable reports an assignment target that is not assignable
+func (t *typeEnv) checkAssignable(target ast.ASTNode) {
+	var object ast.ASTNode
+	var what string
+	switch x := target.(type) {
+	case *ast.IndexAccess:
+		objectType := t.exprType(x.Object)
+		if objectType == "string" {
+			t.error(startPos(x), "cannot assign to element of string (strings are immutable)")
+			return
+		}
+		if !isArrayType(objectType) {
+			return
+		}
+		object, what = x.Object, "array element"
+	case *ast.FieldAccessNode:
+		if ast.IsPointerType(t.exprType(x.Object)) {
+			return
+		}
+		object, what = x.Object, "struct field "+x.Field
+	default:
+		return
+	}
+	switch unaddressable(t, object) {
+	case "map":
+		t.error(startPos(target), "cannot assign to "+what+" in map")
+	case "call":
+		t.error(startPos(target), "cannot assign to "+what+" of call result (neither addressable nor a map index expression)")
+	case "literal":
+		t.error(startPos(target), "cannot assign to "+what+" of composite literal (neither addressable nor a map index expression)")
+	}
+}
+
+// unaddressable returns what makes a struct or array value not addressable:
+// "map" for a map entry, "call" for the result of a call and "literal" for
+// a composite literal, or "" when it is addressable. A field or an element
+// of a value is addressable when the value is.
+func unaddressable(t *typeEnv, x ast.ASTNode) string {
+	switch x := x.(type) {
+	case *ast.CallNode:
+		return "call"
+	case *ast.StructLiteral, *ast.SliceLiteral:
+		return "literal"
+	case *ast.IndexAccess:
+		objectType := t.exprType(x.Object)
+		if ast.IsMapType(objectType) {
+			return "map"
+		}
+		if isArrayType(objectType) {
+			return unaddressable(t, x.Object)
+		}
+	case *ast.FieldAccessNode:
+		if !ast.IsPointerType(t.exprType(x.Object)) {
+			return unaddressable(t, x.Object)
+		}
+	}
+	return ""
+}
+
+// startPos returns the position of the first token of an assignment target
+func startPos(x ast.ASTNode) token.Position {
+	switch x := x.(type) {
+	case *ast.IndexAccess:
+		return startPos(x.Object)
+	case *ast.FieldAccessNode:
+		return startPos(x.Object)
+	}
+	return x.Position()
+}
@@ -0,0 +1,48 @@
+package asmgen
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/token"
+)
+
+func TestLowerOpAssign(t *testing.T) {
+	n := 0
+	hidden := func() string {
+		n++
+		return fmt.Sprintf(".L%d", n)
+	}
+	target := &ast.IndexAccess{
+		Object: &ast.IndexAccess{Object: &ast.VariableNode{Name: "grid"}, Index: &ast.VariableNode{Name: "y"}},
+		Index:  &ast.VariableNode{Name: "x"},
+	}
+	statements := lowerOpAssign(target, token.ADD, &ast.NumberNode{Value: 1}, token.Position{}, hidden)
+
+	if len(statements) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(statements))
+	}
+	// grid[y] is evaluated once, into a hidden variable; x is not hoisted
+	assign, ok := statements[0].(*ast.AssignStatement)
+	if !ok || assign.Name != ".L1" {
+		t.Fatalf("expected .L1 := grid[y], got %#v", statements[0])
+	}
+	reassign, ok := statements[1].(*ast.ReassignStatement)
+	if !ok {
+		t.Fatalf("expected *ast.ReassignStatement, got %T", statements[1])
+	}
+	lowered, ok := reassign.Target.(*ast.IndexAccess)
+	if !ok {
+		t.Fatalf("expected the target to be an index, got %T", reassign.Target)
+	}
+	if object, ok := lowered.Object.(*ast.VariableNode); !ok || object.Name != ".L1" {
+		t.Errorf("expected the target to index .L1, got %#v", lowered.Object)
+	}
+	if lowered.Index != target.Index {
+		t.Errorf("expected the index x to be kept, got %#v", lowered.Index)
+	}
+	if value, ok := reassign.Value.(*ast.BinaryOpNode); !ok || value.Left != lowered || value.Operator != token.ADD {
+		t.Errorf("expected the value to be .L1[x] + 1, got %#v", reassign.Value)
+	}
+}
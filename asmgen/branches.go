@@ -0,0 +1,80 @@
+package asmgen
+
+import "github.com/yuya-takeyama/petitgo/ast"
+
+// break and continue jump to the end of the innermost loop or switch, or
+// to the place where the next iteration of the innermost loop starts; with
+// a label they jump out of or on with the loop or switch with that label.
+// A goto jumps to the assembly label emitted for its label. The parser has
+// checked that all of these exist, and statements leave the stack as they
+// found it, so a jump needs nothing but a branch instruction.
+
+// branchTarget is a loop or switch that break and continue may refer to
+type branchTarget struct {
+	label         string // label of the statement, if any
+	breakLabel    string // assembly label after the statement
+	continueLabel string // assembly label of the next iteration; "" for switches
+}
+
+// branchStack tracks the targets of the branch statements in the function
+// being generated
+type branchStack struct {
+	targets []branchTarget    // enclosing loops and switches, innermost last
+	labels  map[string]string // label -> assembly label
+	next    string            // label of the loop or switch about to be generated
+}
+
+// reset forgets the labels of the previous function
+func (b *branchStack) reset() {
+	*b = branchStack{labels: make(map[string]string)}
+}
+
+// labelNext gives a label to the statement about to be generated, if it is
+// a loop or a switch
+func (b *branchStack) labelNext(s *ast.LabeledStatement) {
+	switch s.Statement.(type) {
+	case *ast.ForStatement, *ast.RangeStatement, *ast.SwitchStatement, *ast.TypeSwitchStatement:
+		b.next = s.Label
+	}
+}
+
+// push enters a loop or switch: continueLabel is "" for switches
+func (b *branchStack) push(breakLabel, continueLabel string) {
+	b.targets = append(b.targets, branchTarget{label: b.next, breakLabel: breakLabel, continueLabel: continueLabel})
+	b.next = ""
+}
+
+// pop leaves the innermost loop or switch
+func (b *branchStack) pop() {
+	b.targets = b.targets[:len(b.targets)-1]
+}
+
+// breakLabel returns where break jumps to
+func (b *branchStack) breakLabel(label string) string {
+	for i := len(b.targets) - 1; i >= 0; i-- {
+		if t := b.targets[i]; label == "" || t.label == label {
+			return t.breakLabel
+		}
+	}
+	return ""
+}
+
+// continueLabel returns where continue jumps to
+func (b *branchStack) continueLabel(label string) string {
+	for i := len(b.targets) - 1; i >= 0; i-- {
+		if t := b.targets[i]; t.continueLabel != "" && (label == "" || t.label == label) {
+			return t.continueLabel
+		}
+	}
+	return ""
+}
+
+// gotoLabel returns the assembly label of a label, creating it with
+// newLabel on first use
+func (b *branchStack) gotoLabel(label string, newLabel func() string) string {
+	if asmLabel, exists := b.labels[label]; exists {
+		return asmLabel
+	}
+	b.labels[label] = newLabel()
+	return b.labels[label]
+}
@@ -0,0 +1,39 @@
+package asmgen
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+)
+
+func TestBranchStack(t *testing.T) {
+	var b branchStack
+	b.reset()
+	b.labelNext(&ast.LabeledStatement{Label: "outer", Statement: &ast.ForStatement{}})
+	b.push("end1", "next1")
+	b.push("end2", "")
+
+	tests := []struct {
+		got, want string
+	}{
+		{b.breakLabel(""), "end2"},
+		{b.breakLabel("outer"), "end1"},
+		{b.continueLabel(""), "next1"},
+		{b.continueLabel("outer"), "next1"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("expected %s, got %s", tt.want, tt.got)
+		}
+	}
+
+	n := 0
+	newLabel := func() string {
+		n++
+		return fmt.Sprintf("L%d", n)
+	}
+	if first, again := b.gotoLabel("done", newLabel), b.gotoLabel("done", newLabel); first != again || n != 1 {
+		t.Errorf("expected one assembly label for done, got %s and %s", first, again)
+	}
+}
@@ -0,0 +1,357 @@
+package asmgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/token"
+)
+
+// A function value is the address of a closure: a heap block whose first
+// word is the address of the code, followed by the addresses of the heap
+// cells of the captured variables. A captured variable lives in such a cell
+// in the enclosing function too, so the closure and the function share it.
+// The closure of a call is passed in a context register (%r10 or x9), which
+// declared functions ignore; their function values are static closures.
+
+// closure is a function literal waiting to be generated after the function
+// that contains it
+type closure struct {
+	symbol   string
+	literal  *ast.FuncLiteral
+	captures []string // captured variables in the order of their cells in the closure
+	types    []string // types of the captured variables
+}
+
+// funcStatement returns the literal as a function declaration named after
+// its symbol
+func (c *closure) funcStatement() *ast.FuncStatement {
+	return &ast.FuncStatement{
+		Name:       c.symbol,
+		Parameters: c.literal.Parameters,
+		Results:    c.literal.Results,
+		Body:       c.literal.Body,
+		Pos:        c.literal.Pos,
+	}
+}
+
+// closureSymbol returns the symbol of the n-th function literal of a
+// function, as in main.func1
+func closureSymbol(function string, n int) string {
+	return fmt.Sprintf("%s.func%d", function, n)
+}
+
+// staticClosureSymbol returns the symbol of the static closure of a
+// declared function
+func staticClosureSymbol(function string) string {
+	return "_closure." + function
+}
+
+// funcSignature splits a function type such as func(int, string) (int, bool)
+// into its parameter and result types
+func funcSignature(typeName string) (params, results []string) {
+	end := closingParen(typeName, len("func"))
+	params = splitTypes(typeName[len("func(") : end-1])
+	rest := strings.TrimSpace(typeName[end:])
+	if strings.HasPrefix(rest, "(") {
+		return params, splitTypes(rest[1 : len(rest)-1])
+	}
+	if rest != "" {
+		results = []string{rest}
+	}
+	return params, results
+}
+
+// closingParen returns the index after the parenthesis closing the one at
+// index open
+func closingParen(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(s)
+}
+
+// splitTypes splits a comma-separated list of types, keeping the lists of
+// nested function types together
+func splitTypes(list string) []string {
+	var types []string
+	depth, start := 0, 0
+	for i := 0; i < len(list); i++ {
+		switch list[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				types = append(types, strings.TrimSpace(list[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if rest := strings.TrimSpace(list[start:]); rest != "" {
+		types = append(types, rest)
+	}
+	return types
+}
+
+// freeVariables returns the names a function literal uses without declaring
+// them, in sorted order: the variables it may capture
+func freeVariables(literal *ast.FuncLiteral) []string {
+	c := newNameCollector()
+	for _, param := range literal.Parameters {
+		c.declared[param.Name] = true
+	}
+	for _, result := range literal.Results {
+		c.declared[result.Name] = true
+	}
+	c.block(literal.Body)
+
+	var names []string
+	for name := range c.used {
+		if !c.declared[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// capturedVariables returns the names of the variables of a function body
+// that function literals in it may capture
+func capturedVariables(body *ast.BlockStatement) map[string]bool {
+	c := newNameCollector()
+	c.block(body)
+	return c.captured
+}
+
+// nameCollector collects the names declared and used by statements, the
+// names used by the function literals among them, the names whose address
+// is taken and the variables of for loops
+type nameCollector struct {
+	declared  map[string]bool
+	used      map[string]bool
+	captured  map[string]bool
+	addressed map[string]bool
+	loops     map[string]bool
+}
+
+func newNameCollector() *nameCollector {
+	return &nameCollector{
+		declared:  make(map[string]bool),
+		used:      make(map[string]bool),
+		captured:  make(map[string]bool),
+		addressed: make(map[string]bool),
+		loops:     make(map[string]bool),
+	}
+}
+
+func (c *nameCollector) block(block *ast.BlockStatement) {
+	if block == nil {
+		return
+	}
+	for _, stmt := range block.Statements {
+		c.statement(stmt)
+	}
+}
+
+func (c *nameCollector) statement(stmt ast.Statement) {
+	switch s := stmt.(type) {
+	case *ast.ExpressionStatement:
+		c.expression(s.Expression)
+	case *ast.AssignStatement:
+		c.expression(s.Value)
+		c.declared[s.Name] = true
+	case *ast.VarStatement:
+		c.expression(s.Value)
+		c.declared[s.Name] = true
+	case *ast.TupleVarStatement:
+		for _, value := range s.Values {
+			c.expression(value)
+		}
+		for _, name := range s.Names {
+			c.declared[name] = true
+		}
+	case *ast.DeclGroup:
+		for _, decl := range s.Decls {
+			c.statement(decl)
+		}
+	case *ast.ReassignStatement:
+		c.expression(s.Target)
+		c.expression(s.Value)
+	case *ast.CompoundAssignStatement:
+		c.expression(s.Target)
+		c.expression(s.Value)
+	case *ast.IncStatement:
+		c.expression(s.Target)
+	case *ast.DecStatement:
+		c.expression(s.Target)
+	case *ast.TupleAssignStatement:
+		for _, value := range s.Values {
+			c.expression(value)
+		}
+		for _, target := range s.Targets {
+			if variable, ok := target.(*ast.VariableNode); ok && s.Define {
+				c.declared[variable.Name] = true
+			} else {
+				c.expression(target)
+			}
+		}
+	case *ast.ReturnStatement:
+		for _, value := range s.Values {
+			c.expression(value)
+		}
+	case *ast.IfStatement:
+		c.expression(s.Condition)
+		c.block(s.ThenBlock)
+		c.block(s.ElseBlock)
+	case *ast.ForStatement:
+		if s.Init != nil {
+			c.statement(s.Init)
+		}
+		for _, name := range loopVariables(s.Init) {
+			c.loops[name] = true
+		}
+		c.expression(s.Condition)
+		if s.Update != nil {
+			c.statement(s.Update)
+		}
+		c.block(s.Body)
+	case *ast.RangeStatement:
+		c.expression(s.Expression)
+		for _, name := range []string{s.Key, s.Value} {
+			if name == "" {
+				continue
+			}
+			if s.Define {
+				c.declared[name] = true
+			} else {
+				c.used[name] = true
+			}
+		}
+		c.block(s.Body)
+	case *ast.SwitchStatement:
+		if s.Init != nil {
+			c.statement(s.Init)
+		}
+		c.expression(s.Value)
+		for _, clause := range s.Cases {
+			for _, value := range clause.Values {
+				c.expression(value)
+			}
+			c.block(clause.Body)
+		}
+	case *ast.TypeSwitchStatement:
+		if s.Init != nil {
+			c.statement(s.Init)
+		}
+		c.expression(s.Value)
+		if s.Binding != "" {
+			c.declared[s.Binding] = true
+		}
+		for _, clause := range s.Cases {
+			c.block(clause.Body)
+		}
+	case *ast.BlockStatement:
+		c.block(s)
+	case *ast.LabeledStatement:
+		c.statement(s.Statement)
+	case *ast.DeferStatement:
+		c.expression(s.Call)
+	}
+}
+
+func (c *nameCollector) expression(expr ast.ASTNode) {
+	switch e := expr.(type) {
+	case *ast.VariableNode:
+		c.used[e.Name] = true
+	case *ast.BinaryOpNode:
+		c.expression(e.Left)
+		c.expression(e.Right)
+	case *ast.UnaryOpNode:
+		if v, ok := e.Operand.(*ast.VariableNode); ok && e.Operator == token.AND {
+			c.addressed[v.Name] = true
+		}
+		c.expression(e.Operand)
+	case *ast.DerefNode:
+		c.expression(e.Operand)
+	case *ast.CallNode:
+		if e.Function != "" && e.Receiver == nil {
+			// A variable holding a function value may be called by name
+			c.used[e.Function] = true
+		}
+		c.expression(e.Receiver)
+		c.expression(e.Callee)
+		for _, arg := range e.Arguments {
+			c.expression(arg)
+		}
+	case *ast.FieldAccessNode:
+		c.expression(e.Object)
+	case *ast.IndexAccess:
+		c.expression(e.Object)
+		c.expression(e.Index)
+	case *ast.SliceExpr:
+		c.expression(e.Object)
+		c.expression(e.Low)
+		c.expression(e.High)
+		c.expression(e.Max)
+	case *ast.StructLiteral:
+		for _, value := range e.Fields {
+			c.expression(value)
+		}
+		for _, value := range e.Values {
+			c.expression(value)
+		}
+	case *ast.SliceLiteral:
+		for _, element := range e.Elements {
+			c.expression(element)
+		}
+	case *ast.ArrayLiteral:
+		for _, element := range e.Elements {
+			c.expression(element)
+		}
+	case *ast.MapLiteral:
+		for _, entry := range e.Entries {
+			c.expression(entry.Key)
+			c.expression(entry.Value)
+		}
+	case *ast.TypeAssertNode:
+		c.expression(e.Expression)
+	case *ast.FuncLiteral:
+		// A nested literal uses the variables it captures
+		for _, name := range freeVariables(e) {
+			c.used[name] = true
+			c.captured[name] = true
+		}
+	}
+}
+
+// loopVariables returns the variables declared by the init statement of a
+// for loop. Each iteration has variables of its own, so a closure capturing
+// them in one iteration does not see the updates of the next.
+func loopVariables(init ast.Statement) []string {
+	switch init := init.(type) {
+	case *ast.AssignStatement:
+		return []string{init.Name}
+	case *ast.TupleAssignStatement:
+		var names []string
+		for _, name := range init.Names() {
+			if init.Define && name != "" && name != "_" {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
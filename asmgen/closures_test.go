@@ -0,0 +1,50 @@
+package asmgen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/token"
+)
+
+func TestFuncSignature(t *testing.T) {
+	tests := []struct {
+		typeName string
+		params   []string
+		results  []string
+	}{
+		{"func()", nil, nil},
+		{"func(int, string) bool", []string{"int", "string"}, []string{"bool"}},
+		{"func(func(int) int) (int, error)", []string{"func(int) int"}, []string{"int", "error"}},
+		{"func() func(int) int", nil, []string{"func(int) int"}},
+	}
+	for _, tt := range tests {
+		params, results := funcSignature(tt.typeName)
+		if strings.Join(params, ";") != strings.Join(tt.params, ";") || strings.Join(results, ";") != strings.Join(tt.results, ";") {
+			t.Errorf("funcSignature(%s) = %v, %v, want %v, %v", tt.typeName, params, results, tt.params, tt.results)
+		}
+	}
+}
+
+func TestFreeVariables(t *testing.T) {
+	// func(a int) { b := a; c = b + d; func() { e++ }() }
+	literal := &ast.FuncLiteral{
+		Parameters: []ast.Parameter{{Name: "a", Type: ast.NewIdentType("int")}},
+		Body: &ast.BlockStatement{Statements: []ast.Statement{
+			&ast.AssignStatement{Name: "b", Value: &ast.VariableNode{Name: "a"}},
+			&ast.ReassignStatement{Target: &ast.VariableNode{Name: "c"}, Value: &ast.BinaryOpNode{
+				Left: &ast.VariableNode{Name: "b"}, Operator: token.ADD, Right: &ast.VariableNode{Name: "d"},
+			}},
+			&ast.ExpressionStatement{Expression: &ast.CallNode{Callee: &ast.FuncLiteral{
+				Body: &ast.BlockStatement{Statements: []ast.Statement{&ast.IncStatement{Target: &ast.VariableNode{Name: "e"}}}},
+			}}},
+		}},
+	}
+	if got := strings.Join(freeVariables(literal), ","); got != "c,d,e" {
+		t.Errorf("expected free variables c,d,e, got %s", got)
+	}
+	if captured := capturedVariables(literal.Body); len(captured) != 1 || !captured["e"] {
+		t.Errorf("expected only e to be captured, got %v", captured)
+	}
+}
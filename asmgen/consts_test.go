@@ -0,0 +1,29 @@
+package asmgen
+
+import (
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/constant"
+)
+
+func TestConstType(t *testing.T) {
+	types := newTypeEnv()
+	typed, _ := constant.Convert(constant.MakeInt64(1), "uint8")
+
+	tests := []struct {
+		v        constant.Value
+		expected string
+	}{
+		{constant.MakeInt64(1), "int"},
+		{constant.MakeRune('a'), "int32"},
+		{constant.MakeFloat64(1), "float64"},
+		{constant.MakeString("s"), "string"},
+		{typed, "uint8"},
+	}
+	for _, tt := range tests {
+		if got := types.exprType(&ast.ConstExpr{Value: tt.v}); got != tt.expected {
+			t.Errorf("%s (%s): expected type %s, got %s", tt.v, tt.v.Type, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package asmgen
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/constant"
+)
+
+// Structs and arrays are heap blocks, and their values are the addresses of
+// the blocks. To give them value semantics, a struct or an array is copied
+// whenever it is stored in a variable, a field, an element or a map entry,
+// returned, or passed to a function, which copies its parameters. A new
+// value, such as a composite literal or the result of a call, is stored as
+// it is.
+//
+// Each type is copied by a routine of its own, generated once for the
+// program: it takes the value in %rax (x0 on ARM64) and returns the copy
+// there, copying the struct and array fields and elements in turn.
+
+// isValueBlock reports whether values of a type are heap blocks copied on
+// assignment: structs and arrays
+func (t *typeEnv) isValueBlock(typeName string) bool {
+	return t.isStruct(typeName) || isArrayType(typeName)
+}
+
+// isNewValue reports whether an expression yields a value of its own, which
+// needs no copy when it is stored
+func isNewValue(expr ast.ASTNode) bool {
+	switch expr := expr.(type) {
+	case *ast.StructLiteral, *ast.ArrayLiteral:
+		return true
+	case *ast.CallNode:
+		return !isConversion(expr)
+	}
+	return false
+}
+
+// storedCopyType returns the type of the block to copy when expr is stored
+// as a value of a type, or "" when there is nothing to copy. A struct or an
+// array stored in an interface value is copied too.
+func (t *typeEnv) storedCopyType(expr ast.ASTNode, typeName string) string {
+	typeName = constant.CanonicalType(typeName)
+	if t.isInterface(typeName) {
+		typeName = t.exprType(expr)
+	}
+	if !t.isValueBlock(typeName) || isNewValue(expr) {
+		return ""
+	}
+	return typeName
+}
+
+// copySymbol returns the symbol of the routine copying values of a type.
+// The other characters of type names that symbols cannot hold, as in
+// [2]func(int) or [2]interface{}, become dots.
+func copySymbol(typeName string) string {
+	symbol := strings.NewReplacer("*", "ptr.", "[", "array", "]", ".", " ", "").Replace(typeName)
+	return "_copy." + strings.Map(func(r rune) rune {
+		if r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '.'
+	}, symbol)
+}
+
+// pendingCopies returns the types in copies whose routines are not in done
+// yet, sorted
+func pendingCopies(copies, done map[string]bool) []string {
+	var typeNames []string
+	for typeName := range copies {
+		if !done[typeName] {
+			typeNames = append(typeNames, typeName)
+		}
+	}
+	sort.Strings(typeNames)
+	return typeNames
+}
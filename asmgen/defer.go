@@ -0,0 +1,114 @@
+package asmgen
+
+import "github.com/yuya-takeyama/petitgo/ast"
+
+// A defer statement pushes a defer record onto the chain of the runtime,
+// whose head is defer_top. The records of all frames form one stack, the
+// innermost frame's on top, and each record is a heap block of words:
+//
+//	next, frame, resume, closure, code, arguments...
+//
+// frame is the frame pointer of the function that deferred the call and
+// resume the address of its exit code. The deferred call is a call of the
+// closure with the argument words in the argument registers; a method
+// called through an interface gets a closure of its own in the code word.
+// Built-in functions have no code to call, so their deferred calls are
+// calls of a function literal calling the built-in function with hidden
+// variables holding the evaluated arguments.
+//
+// A function with defer statements keeps its results in variables, and a
+// return statement stores the results and jumps to the exit code, which
+// runs the deferred calls of the frame with _run_defers and then returns
+// the results. panic(v) calls _panic, which runs the deferred calls of all
+// frames from the innermost one on. recover() called by one of them stops
+// the panic: _panic then resumes the exit code of the frame that deferred
+// the call, which first resets the stack pointer of the frame. A panic that
+// nobody recovers prints its value and exits with status 2, like in Go.
+
+// deferRecordWords is the number of words before the arguments in a defer
+// record
+const deferRecordWords = 5
+
+// deferFrame is the state of the function being generated for its defer
+// statements
+type deferFrame struct {
+	exitLabel string   // label of the exit code; "" without defer statements
+	results   []string // variables holding the results
+}
+
+// containsDefer reports whether a function body has defer statements,
+// leaving aside those of function literals, which are functions of their own
+func containsDefer(block *ast.BlockStatement) bool {
+	if block == nil {
+		return false
+	}
+	for _, stmt := range block.Statements {
+		if statementContainsDefer(stmt) {
+			return true
+		}
+	}
+	return false
+}
+
+func statementContainsDefer(stmt ast.Statement) bool {
+	switch s := stmt.(type) {
+	case *ast.DeferStatement:
+		return true
+	case *ast.BlockStatement:
+		return containsDefer(s)
+	case *ast.IfStatement:
+		return containsDefer(s.ThenBlock) || containsDefer(s.ElseBlock)
+	case *ast.ForStatement:
+		return containsDefer(s.Body)
+	case *ast.RangeStatement:
+		return containsDefer(s.Body)
+	case *ast.SwitchStatement:
+		for _, clause := range s.Cases {
+			if containsDefer(clause.Body) {
+				return true
+			}
+		}
+	case *ast.TypeSwitchStatement:
+		for _, clause := range s.Cases {
+			if containsDefer(clause.Body) {
+				return true
+			}
+		}
+	case *ast.LabeledStatement:
+		return statementContainsDefer(s.Statement)
+	}
+	return false
+}
+
+// isBuiltinCall reports whether a call calls a built-in function such as
+// println or panic, which has no code of its own
+func (t *typeEnv) isBuiltinCall(call *ast.CallNode) bool {
+	if call.Receiver != nil || call.Callee != nil {
+		return false
+	}
+	_, declared := t.paramTypes[t.callSymbol(call)]
+	return !declared
+}
+
+// isRecover reports whether a call is a call of the built-in recover
+func (t *typeEnv) isRecover(call *ast.CallNode) bool {
+	return call.Function == "recover" && len(call.Arguments) == 0 && t.isBuiltinCall(call)
+}
+
+// deferredBuiltin returns the function literal deferred in place of a call
+// of a built-in function, which calls it with the hidden variables holding
+// its arguments
+func deferredBuiltin(call *ast.CallNode, hidden []string) *ast.FuncLiteral {
+	args := make([]ast.ASTNode, len(hidden))
+	for i, name := range hidden {
+		args[i] = &ast.VariableNode{Name: name, Pos: call.Pos}
+	}
+	body := &ast.BlockStatement{
+		Statements: []ast.Statement{&ast.ExpressionStatement{
+			Expression: &ast.CallNode{Function: call.Function, Arguments: args, Pos: call.Pos},
+			Pos:        call.Pos,
+		}},
+		Pos: call.Pos,
+	}
+	return &ast.FuncLiteral{Body: body, Pos: call.Pos}
+}
@@ -0,0 +1,208 @@
+package asmgen
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/constant"
+)
+
+// A package-level variable lives in the data section, where it starts as
+// zero bytes; main assigns the initial values before its body runs. A
+// variable is initialized after the variables its initializer refers to,
+// and otherwise in declaration order. A function using package-level variables keeps their
+// addresses in stack slots like the heap cells of captured variables, so
+// they are loaded, stored, captured and pointed to the same way.
+
+// globalSymbol returns the symbol of the storage of a package-level variable
+func globalSymbol(name string) string {
+	return "_var." + name
+}
+
+// collectGlobals records the package-level variables with their types and
+// the declarations initializing them
+func (t *typeEnv) collectGlobals(statements []ast.Statement) {
+	for _, stmt := range t.initializationOrder(declarations(statements)) {
+		switch s := stmt.(type) {
+		case *ast.VarStatement:
+			typeName := ast.TypeName(s.Type)
+			if typeName == "" {
+				typeName = t.exprType(s.Value)
+			}
+			t.declareGlobal(s.Name, typeName)
+		case *ast.TupleVarStatement:
+			assign := &ast.TupleAssignStatement{Targets: variableTargets(s.Names, s.Pos), Define: true, Values: s.Values, Pos: s.Pos}
+			for i, name := range s.Names {
+				typeName := ast.TypeName(s.Type)
+				if typeName == "" {
+					typeName = t.tupleValueType(assign, i)
+				}
+				t.declareGlobal(name, typeName)
+			}
+		default:
+			continue
+		}
+		t.globalDecls = append(t.globalDecls, stmt)
+	}
+}
+
+// initializationOrder returns the declarations of package-level variables
+// among decls in the order they are initialized: each variable comes after
+// the variables its initializer refers to, directly or through the
+// functions it calls, and otherwise the first in declaration order comes
+// first. A variable referring to itself is an initialization cycle.
+func (t *typeEnv) initializationOrder(decls []ast.Statement) []ast.Statement {
+	funcs := make(map[string]*ast.FuncStatement)
+	var vars []ast.Statement
+	for _, decl := range decls {
+		switch s := decl.(type) {
+		case *ast.FuncStatement:
+			if s.Receiver == nil {
+				funcs[s.Name] = s
+			}
+		case *ast.VarStatement, *ast.TupleVarStatement:
+			vars = append(vars, decl)
+		}
+	}
+
+	// The declaration declaring each variable, and the names each
+	// initializer refers to
+	declaring := make(map[string]ast.Statement)
+	refs := make(map[ast.Statement][]string)
+	for _, decl := range vars {
+		for _, name := range declaredNames(decl) {
+			declaring[name] = decl
+		}
+		refs[decl] = references(initializers(decl), funcs)
+	}
+
+	initialized := make(map[ast.Statement]bool)
+	ready := func(decl ast.Statement) (string, bool) {
+		for _, name := range refs[decl] {
+			if dep, exists := declaring[name]; exists && !initialized[dep] {
+				return name, false
+			}
+		}
+		return "", true
+	}
+
+	var order []ast.Statement
+	for len(order) < len(vars) {
+		next := -1
+		for i, decl := range vars {
+			if _, ok := ready(decl); !initialized[decl] && ok {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			// Every variable left is in a cycle; report the first one and
+			// go on in declaration order
+			for i, decl := range vars {
+				if !initialized[decl] {
+					variable, _ := ready(decl)
+					if declaring[variable] == decl {
+						variable = "itself"
+					}
+					t.error(decl.Position(), fmt.Sprintf("initialization cycle: %s refers to %s", declaredNames(decl)[0], variable))
+					next = i
+					break
+				}
+			}
+		}
+		initialized[vars[next]] = true
+		order = append(order, vars[next])
+	}
+	return order
+}
+
+// declaredNames returns the variables declared by a declaration of
+// package-level variables
+func declaredNames(decl ast.Statement) []string {
+	switch s := decl.(type) {
+	case *ast.VarStatement:
+		return []string{s.Name}
+	case *ast.TupleVarStatement:
+		return s.Names
+	}
+	return nil
+}
+
+// initializers returns the initial values of a declaration of package-level
+// variables
+func initializers(decl ast.Statement) []ast.ASTNode {
+	switch s := decl.(type) {
+	case *ast.VarStatement:
+		if s.Value != nil {
+			return []ast.ASTNode{s.Value}
+		}
+	case *ast.TupleVarStatement:
+		return s.Values
+	}
+	return nil
+}
+
+// references returns the names values refer to without declaring them,
+// also through the bodies of the functions among funcs they refer to, in
+// sorted order
+func references(values []ast.ASTNode, funcs map[string]*ast.FuncStatement) []string {
+	found := make(map[string]bool)
+	var visit func(c *nameCollector)
+	visit = func(c *nameCollector) {
+		for name := range c.used {
+			if c.declared[name] || found[name] {
+				continue
+			}
+			found[name] = true
+			if funcStmt, exists := funcs[name]; exists {
+				body := newNameCollector()
+				for _, param := range append(funcStmt.Parameters, funcStmt.Results...) {
+					body.declared[param.Name] = true
+				}
+				body.block(funcStmt.Body)
+				visit(body)
+			}
+		}
+	}
+	c := newNameCollector()
+	for _, value := range values {
+		c.expression(value)
+	}
+	visit(c)
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// declareGlobal records a package-level variable. Its type is also declared
+// for the initializers of the variables after it.
+func (t *typeEnv) declareGlobal(name, typeName string) {
+	if name == "_" {
+		return
+	}
+	t.globals = append(t.globals, name)
+	t.globalTypes[name] = constant.CanonicalType(typeName)
+	t.declare(name, typeName)
+}
+
+// usedGlobals returns the package-level variables a function uses, in
+// declaration order. main uses all of them, as it initializes them.
+func (t *typeEnv) usedGlobals(funcStmt *ast.FuncStatement) []string {
+	if funcStmt.Name == "main" && funcStmt.Receiver == nil {
+		return t.globals
+	}
+	c := newNameCollector()
+	c.block(funcStmt.Body)
+	var names []string
+	for _, name := range t.globals {
+		if c.used[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
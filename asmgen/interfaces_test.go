@@ -0,0 +1,68 @@
+package asmgen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+)
+
+func TestMethodTable(t *testing.T) {
+	types := newTypeEnv()
+	types.collectFunctions([]ast.Statement{
+		&ast.InterfaceStatement{Name: "Shape", Methods: []*ast.MethodDef{{Name: "Area"}, {Name: "Scale"}}},
+		&ast.FuncStatement{Name: "Area", Receiver: &ast.Parameter{Name: "c", Type: ast.NewIdentType("Circle")}},
+		&ast.FuncStatement{Name: "Scale", Receiver: &ast.Parameter{Name: "c", Type: &ast.PointerType{Elem: ast.NewIdentType("Circle")}}},
+	})
+
+	tests := []struct {
+		typeName string
+		expected []string
+	}{
+		// the method set of T holds only the value receiver methods
+		{"Circle", []string{"Circle.Area", ""}},
+		{"*Circle", []string{"Circle.Area", "Circle.Scale"}},
+		{"int", []string{"", ""}},
+	}
+	for _, tt := range tests {
+		got := types.methodTable(tt.typeName)
+		if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("methodTable(%s) = %v, want %v", tt.typeName, got, tt.expected)
+		}
+	}
+	if got := types.methodOffset("Scale"); got != 16 {
+		t.Errorf("expected Scale in the second slot, got offset %d", got)
+	}
+}
+
+func TestMissingMethod(t *testing.T) {
+	intResult := []ast.Parameter{{Type: ast.NewIdentType("int")}}
+	types := newTypeEnv()
+	types.collectFunctions([]ast.Statement{
+		&ast.InterfaceStatement{Name: "Shape", Methods: []*ast.MethodDef{{Name: "Area", Results: intResult}, {Name: "Scale"}}},
+		&ast.InterfaceStatement{Name: "Sized", Methods: []*ast.MethodDef{{Name: "Size"}}, Embedded: []string{"Shape"}},
+		&ast.FuncStatement{Name: "Area", Receiver: &ast.Parameter{Name: "c", Type: ast.NewIdentType("Circle")}, Results: intResult},
+		&ast.FuncStatement{Name: "Scale", Receiver: &ast.Parameter{Name: "c", Type: &ast.PointerType{Elem: ast.NewIdentType("Circle")}}},
+		&ast.FuncStatement{Name: "Area", Receiver: &ast.Parameter{Name: "s", Type: ast.NewIdentType("Square")}},
+	})
+
+	tests := []struct {
+		typeName string
+		iface    string
+		expected string
+	}{
+		{"*Circle", "Shape", ""},
+		{"Circle", "Shape", "method Scale has pointer receiver"},
+		{"Square", "Shape", "wrong type for method Area"},
+		{"int", "Shape", "missing method Area"},
+		{"Sized", "Shape", ""},
+		{"*Circle", "Sized", "missing method Size"},
+		{"Shape", "Sized", "missing method Size"},
+		{"int", "any", ""},
+	}
+	for _, tt := range tests {
+		if got := types.missingMethod(tt.typeName, tt.iface); got != tt.expected {
+			t.Errorf("missingMethod(%s, %s) = %q, want %q", tt.typeName, tt.iface, got, tt.expected)
+		}
+	}
+}
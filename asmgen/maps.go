@@ -0,0 +1,88 @@
+package asmgen
+
+import (
+	"fmt"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/constant"
+	"github.com/yuya-takeyama/petitgo/token"
+)
+
+// A map is the address of a header on the heap, and nil is 0:
+//
+//	count, capacity, used, key kind, entries
+//
+// The entries form an open-addressing hash table with linear probing and a
+// power of two capacity. Each entry takes four words: its state (0 empty,
+// 1 used, 2 deleted), the key and the two words of the value, the second of
+// which holds the type descriptor of an interface value. used counts the
+// used and deleted entries; the table doubles before it is three quarters
+// full. String keys are hashed and compared by their bytes, struct and array
+// keys by the words of their fields or elements, and all other keys by their
+// single word. A struct or array key is copied when it is stored, so later
+// changes to the key do not move its entry.
+
+// Key kinds of the map header. The kind of a struct or array key is its
+// size in bytes shifted left by one, with the low bit set for an array,
+// whose elements are found through its header.
+const (
+	mapWordKey   = 0
+	mapStringKey = 1
+)
+
+// mapKeyKind returns the key kind of maps with keys of a type, reporting an
+// error at pos when the keys cannot be compared word by word
+func (t *typeEnv) mapKeyKind(keyType string, pos token.Position) int {
+	switch {
+	case keyType == "string":
+		return mapStringKey
+	case t.isStruct(keyType):
+		for _, field := range t.structs[keyType] {
+			if !t.isWordKey(ast.TypeName(field.Type)) {
+				t.error(pos, fmt.Sprintf("invalid map key type %s: field %s of type %s is not supported", keyType, field.Name, ast.TypeName(field.Type)))
+				return mapWordKey
+			}
+		}
+		return t.structSize(keyType) << 1
+	case isArrayType(keyType):
+		if !t.isWordKey(sliceElementType(keyType)) {
+			t.error(pos, fmt.Sprintf("invalid map key type %s: elements of type %s are not supported", keyType, sliceElementType(keyType)))
+			return mapWordKey
+		}
+		return arrayLength(keyType)*8<<1 | 1
+	case t.isInterface(keyType):
+		t.error(pos, fmt.Sprintf("invalid map key type %s: interface keys are not supported", keyType))
+	}
+	return mapWordKey
+}
+
+// isWordKey reports whether values of a type are equal exactly when their
+// single words are, so they can be compared as part of a struct or array key
+func (t *typeEnv) isWordKey(typeName string) bool {
+	return constant.CanonicalType(typeName) != "string" && !t.isStruct(typeName) &&
+		!isSliceType(typeName) && !t.isInterface(typeName) && !ast.IsMapType(typeName)
+}
+
+// mapIndex returns the index expression x[k] when x is a map, along with the
+// key and value types of the map
+func (t *typeEnv) mapIndex(expr ast.ASTNode) (index *ast.IndexAccess, keyType, valueType string, ok bool) {
+	index, ok = expr.(*ast.IndexAccess)
+	if !ok {
+		return nil, "", "", false
+	}
+	mapType := t.exprType(index.Object)
+	if !ast.IsMapType(mapType) {
+		return nil, "", "", false
+	}
+	keyType, valueType = ast.MapTypes(mapType)
+	return index, keyType, valueType, true
+}
+
+// commaOkMapIndex returns the index expression of v, ok := m[k]
+func (t *typeEnv) commaOkMapIndex(s *ast.TupleAssignStatement) (*ast.IndexAccess, bool) {
+	if len(s.Values) != 1 || len(s.Targets) != 2 {
+		return nil, false
+	}
+	index, _, _, ok := t.mapIndex(s.Values[0])
+	return index, ok
+}
@@ -0,0 +1,46 @@
+package asmgen
+
+import (
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/token"
+)
+
+func TestMapKeyKind(t *testing.T) {
+	field := func(name, typeName string) *ast.FieldDef {
+		return &ast.FieldDef{Name: name, Type: ast.NewIdentType(typeName)}
+	}
+	types := newTypeEnv()
+	types.collectFunctions([]ast.Statement{
+		&ast.TypeStatement{Name: "Point", Fields: []*ast.FieldDef{field("x", "int"), field("y", "int")}},
+		&ast.TypeStatement{Name: "Named", Fields: []*ast.FieldDef{field("name", "string")}},
+	})
+
+	tests := []struct {
+		keyType string
+		keyKind int
+		err     string
+	}{
+		{"string", mapStringKey, ""},
+		{"int", mapWordKey, ""},
+		{"Point", 16 << 1, ""},
+		{"[3]int", 24<<1 | 1, ""},
+		{"Named", mapWordKey, "invalid map key type Named: field name of type string is not supported"},
+		{"[2]string", mapWordKey, "invalid map key type [2]string: elements of type string are not supported"},
+		{"any", mapWordKey, "invalid map key type any: interface keys are not supported"},
+	}
+	for _, tt := range tests {
+		types.errors = nil
+		if got := types.mapKeyKind(tt.keyType, token.Position{}); got != tt.keyKind {
+			t.Errorf("mapKeyKind(%s) = %d, want %d", tt.keyType, got, tt.keyKind)
+		}
+		err := ""
+		if len(types.errors) > 0 {
+			err = types.errors[0].Message
+		}
+		if err != tt.err {
+			t.Errorf("mapKeyKind(%s) reported %q, want %q", tt.keyType, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package asmgen
+
+import (
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+)
+
+func TestCallSymbol(t *testing.T) {
+	types := newTypeEnv()
+	types.declare("p", "*Person")
+	tests := []struct {
+		call     *ast.CallNode
+		expected string
+	}{
+		{&ast.CallNode{Function: "greet"}, "greet"},
+		{&ast.CallNode{Function: "Greet", Receiver: &ast.VariableNode{Name: "p"}}, "Person.Greet"},
+		{&ast.CallNode{Function: "Greet", Receiver: &ast.StructLiteral{TypeName: "Robot"}}, "Robot.Greet"},
+	}
+
+	for _, tt := range tests {
+		if got := types.callSymbol(tt.call); got != tt.expected {
+			t.Errorf("callSymbol(%s) = %q, want %q", tt.call.Function, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package asmgen
+
+import (
+	"strings"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+)
+
+// A pointer is the address of the value it points to, and nil is 0. A
+// variable whose address is taken escapes to a heap cell, as a captured
+// variable does, so that the pointer outlives the function. main never
+// returns, so its variables stay on the stack and are addressed there. A
+// struct value is already the address of its heap block, so a pointer to a
+// struct is that address too and p.field works alike on pointers and
+// values.
+
+// pointerElementType returns the type a pointer type points to
+func pointerElementType(typeName string) string {
+	return strings.TrimPrefix(typeName, "*")
+}
+
+// escapingVariables returns the variables of a function that live in heap
+// cells: those captured by function literals and, unless the function is
+// main, those whose address is taken. The variables of a for loop get new
+// cells in every iteration, so in main too their addresses are taken from
+// cells.
+func escapingVariables(funcStmt *ast.FuncStatement) map[string]bool {
+	c := newNameCollector()
+	c.block(funcStmt.Body)
+	main := funcStmt.Name == "main" && funcStmt.Receiver == nil
+	for name := range c.addressed {
+		if !main || c.loops[name] {
+			c.captured[name] = true
+		}
+	}
+	return c.captured
+}
@@ -0,0 +1,40 @@
+package asmgen
+
+import (
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+)
+
+func TestFieldOffset(t *testing.T) {
+	types := newTypeEnv()
+	types.collectFunctions([]ast.Statement{
+		&ast.InterfaceStatement{Name: "Shape"},
+		&ast.TypeStatement{Name: "Item", Fields: []*ast.FieldDef{
+			{Name: "name", Type: ast.NewIdentType("string")},
+			{Name: "shape", Type: ast.NewIdentType("Shape")},
+			{Name: "count", Type: ast.NewIdentType("int")},
+		}},
+	})
+
+	tests := []struct {
+		typeName string
+		field    string
+		expected int
+	}{
+		{"Item", "name", 0},
+		// an interface field takes two words
+		{"Item", "shape", 8},
+		{"*Item", "count", 24},
+		// fields of unknown structs fall back to offsets by name
+		{"Unknown", "age", 8},
+	}
+	for _, tt := range tests {
+		if got := types.fieldOffset(tt.typeName, tt.field); got != tt.expected {
+			t.Errorf("fieldOffset(%s, %s) = %d, want %d", tt.typeName, tt.field, got, tt.expected)
+		}
+	}
+	if got := types.structSize("Item"); got != 32 {
+		t.Errorf("expected Item to take 32 bytes, got %d", got)
+	}
+}
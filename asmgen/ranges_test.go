@@ -0,0 +1,34 @@
+package asmgen
+
+import "testing"
+
+func TestARM64Generator_FarLocals(t *testing.T) {
+	gen := NewARM64Generator()
+	gen.writeLine("    str x0, [x29, #-256]")
+	gen.writeLine("    ldr x1, [x29, #-264]")
+
+	expected := "    str x0, [x29, #-256]\n    sub x17, x29, #264\n    ldr x1, [x17]\n"
+	if got := gen.output.String(); got != expected {
+		t.Errorf("expected locals beyond -256 to be addressed through x17:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestRangeTypes(t *testing.T) {
+	tests := []struct {
+		typeName  string
+		keyType   string
+		valueType string
+	}{
+		{"[]string", "int", "string"},
+		{"[]byte", "int", "uint8"},
+		{"string", "int", "int32"},
+		{"map[string][]int", "string", "[]int"},
+		{"int", "int", ""},
+	}
+	for _, tt := range tests {
+		keyType, valueType := rangeTypes(tt.typeName)
+		if keyType != tt.keyType || valueType != tt.valueType {
+			t.Errorf("rangeTypes(%s) = %s, %s, want %s, %s", tt.typeName, keyType, valueType, tt.keyType, tt.valueType)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package asmgen
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/constant"
+)
+
+// A slice is the address of a header on the heap, and nil is 0:
+//
+//	data, length, capacity
+//
+// A header is never modified once created, so copying the address copies
+// the slice. The elements are stored one after another from data, each
+// taking the stack size of the element type.
+//
+// An array [N]T is laid out like a slice of length and capacity N, so
+// indexing, len and range work on both. Like structs, arrays are copied on
+// assignment (see copies.go).
+
+// sliceHeaderSize is the size of the header of a slice
+const sliceHeaderSize = 24
+
+// isSliceType reports whether typeName names a slice or an array type
+func isSliceType(typeName string) bool {
+	return strings.HasPrefix(typeName, "[")
+}
+
+// isArrayType reports whether typeName names an array type such as [3]int
+func isArrayType(typeName string) bool {
+	return isSliceType(typeName) && !strings.HasPrefix(typeName, "[]")
+}
+
+// sliceElementType returns the element type of a slice or an array type
+func sliceElementType(typeName string) string {
+	return typeName[strings.Index(typeName, "]")+1:]
+}
+
+// arrayLength returns the length of an array type
+func arrayLength(typeName string) int {
+	n, _ := strconv.Atoi(typeName[len("["):strings.Index(typeName, "]")])
+	return n
+}
+
+// needsZeroing reports whether the zero value of a type must be stored
+// explicitly in zeroed heap memory: the zero string is the address of an
+// empty string, and zero structs and arrays are blocks of their own
+func (t *typeEnv) needsZeroing(typeName string) bool {
+	return constant.CanonicalType(typeName) == "string" || t.isStruct(typeName) || isArrayType(typeName)
+}
+
+// rangeTypes returns the types of the key and value of a range loop over a
+// value of a type: the index and element of a slice, the byte offset and
+// rune of a string, the key and value of a map, or an integer counting up
+// to n, which has no value
+func rangeTypes(typeName string) (keyType, valueType string) {
+	switch {
+	case isSliceType(typeName):
+		return "int", constant.CanonicalType(sliceElementType(typeName))
+	case typeName == "string":
+		return "int", "int32"
+	case ast.IsMapType(typeName):
+		keyType, valueType = ast.MapTypes(typeName)
+		return constant.CanonicalType(keyType), constant.CanonicalType(valueType)
+	}
+	return "int", ""
+}
+
+// isIterationVariable reports whether a range loop binds a name to its key
+// or value: the name is neither omitted nor the blank identifier
+func isIterationVariable(name string) bool {
+	return name != "" && name != "_"
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/token"
 )
 
 // Test switch statement code generation for ARM64
@@ -21,23 +22,23 @@ func TestGenerateSwitchStatementARM64(t *testing.T) {
 				Value: &ast.VariableNode{Name: "x"},
 				Cases: []*ast.CaseStatement{
 					{
-						Value: &ast.NumberNode{Value: 1},
+						Values: []ast.ASTNode{&ast.NumberNode{Value: 1}},
 						Body: &ast.BlockStatement{
 							Statements: []ast.Statement{
 								&ast.ReassignStatement{
-									Name:  "result",
-									Value: &ast.NumberNode{Value: 10},
+									Target: &ast.VariableNode{Name: "result"},
+									Value:  &ast.NumberNode{Value: 10},
 								},
 							},
 						},
 					},
 					{
-						Value: &ast.NumberNode{Value: 2},
+						Values: []ast.ASTNode{&ast.NumberNode{Value: 2}},
 						Body: &ast.BlockStatement{
 							Statements: []ast.Statement{
 								&ast.ReassignStatement{
-									Name:  "result",
-									Value: &ast.NumberNode{Value: 20},
+									Target: &ast.VariableNode{Name: "result"},
+									Value:  &ast.NumberNode{Value: 20},
 								},
 							},
 						},
@@ -64,22 +65,24 @@ func TestGenerateSwitchStatementARM64(t *testing.T) {
 				Value: &ast.VariableNode{Name: "x"},
 				Cases: []*ast.CaseStatement{
 					{
-						Value: &ast.NumberNode{Value: 1},
+						Values: []ast.ASTNode{&ast.NumberNode{Value: 1}},
 						Body: &ast.BlockStatement{
 							Statements: []ast.Statement{
 								&ast.ReassignStatement{
-									Name:  "result",
-									Value: &ast.NumberNode{Value: 10},
+									Target: &ast.VariableNode{Name: "result"},
+									Value:  &ast.NumberNode{Value: 10},
 								},
 							},
 						},
 					},
-				},
-				Default: &ast.BlockStatement{
-					Statements: []ast.Statement{
-						&ast.ReassignStatement{
-							Name:  "result",
-							Value: &ast.NumberNode{Value: 99},
+					{
+						Body: &ast.BlockStatement{
+							Statements: []ast.Statement{
+								&ast.ReassignStatement{
+									Target: &ast.VariableNode{Name: "result"},
+									Value:  &ast.NumberNode{Value: 99},
+								},
+							},
 						},
 					},
 				},
@@ -97,12 +100,12 @@ func TestGenerateSwitchStatementARM64(t *testing.T) {
 				Value: &ast.VariableNode{Name: "name"},
 				Cases: []*ast.CaseStatement{
 					{
-						Value: &ast.StringNode{Value: "alice"},
+						Values: []ast.ASTNode{&ast.StringNode{Value: "alice"}},
 						Body: &ast.BlockStatement{
 							Statements: []ast.Statement{
 								&ast.ReassignStatement{
-									Name:  "result",
-									Value: &ast.NumberNode{Value: 1},
+									Target: &ast.VariableNode{Name: "result"},
+									Value:  &ast.NumberNode{Value: 1},
 								},
 							},
 						},
@@ -160,23 +163,23 @@ func TestGenerateSwitchStatementX86_64(t *testing.T) {
 				Value: &ast.VariableNode{Name: "x"},
 				Cases: []*ast.CaseStatement{
 					{
-						Value: &ast.NumberNode{Value: 1},
+						Values: []ast.ASTNode{&ast.NumberNode{Value: 1}},
 						Body: &ast.BlockStatement{
 							Statements: []ast.Statement{
 								&ast.ReassignStatement{
-									Name:  "result",
-									Value: &ast.NumberNode{Value: 10},
+									Target: &ast.VariableNode{Name: "result"},
+									Value:  &ast.NumberNode{Value: 10},
 								},
 							},
 						},
 					},
 					{
-						Value: &ast.NumberNode{Value: 2},
+						Values: []ast.ASTNode{&ast.NumberNode{Value: 2}},
 						Body: &ast.BlockStatement{
 							Statements: []ast.Statement{
 								&ast.ReassignStatement{
-									Name:  "result",
-									Value: &ast.NumberNode{Value: 20},
+									Target: &ast.VariableNode{Name: "result"},
+									Value:  &ast.NumberNode{Value: 20},
 								},
 							},
 						},
@@ -203,22 +206,24 @@ func TestGenerateSwitchStatementX86_64(t *testing.T) {
 				Value: &ast.VariableNode{Name: "x"},
 				Cases: []*ast.CaseStatement{
 					{
-						Value: &ast.NumberNode{Value: 1},
+						Values: []ast.ASTNode{&ast.NumberNode{Value: 1}},
 						Body: &ast.BlockStatement{
 							Statements: []ast.Statement{
 								&ast.ReassignStatement{
-									Name:  "result",
-									Value: &ast.NumberNode{Value: 10},
+									Target: &ast.VariableNode{Name: "result"},
+									Value:  &ast.NumberNode{Value: 10},
 								},
 							},
 						},
 					},
-				},
-				Default: &ast.BlockStatement{
-					Statements: []ast.Statement{
-						&ast.ReassignStatement{
-							Name:  "result",
-							Value: &ast.NumberNode{Value: 99},
+					{
+						Body: &ast.BlockStatement{
+							Statements: []ast.Statement{
+								&ast.ReassignStatement{
+									Target: &ast.VariableNode{Name: "result"},
+									Value:  &ast.NumberNode{Value: 99},
+								},
+							},
 						},
 					},
 				},
@@ -265,12 +270,15 @@ func TestGenerateSwitchEdgeCases(t *testing.T) {
 	t.Run("switch with empty cases", func(t *testing.T) {
 		stmt := &ast.SwitchStatement{
 			Value: &ast.VariableNode{Name: "x"},
-			Cases: []*ast.CaseStatement{},
-			Default: &ast.BlockStatement{
-				Statements: []ast.Statement{
-					&ast.ReassignStatement{
-						Name:  "result",
-						Value: &ast.NumberNode{Value: 0},
+			Cases: []*ast.CaseStatement{
+				{
+					Body: &ast.BlockStatement{
+						Statements: []ast.Statement{
+							&ast.ReassignStatement{
+								Target: &ast.VariableNode{Name: "result"},
+								Value:  &ast.NumberNode{Value: 0},
+							},
+						},
 					},
 				},
 			},
@@ -302,18 +310,17 @@ func TestGenerateSwitchEdgeCases(t *testing.T) {
 			Value: &ast.VariableNode{Name: "x"},
 			Cases: []*ast.CaseStatement{
 				{
-					Value: &ast.NumberNode{Value: 1},
+					Values: []ast.ASTNode{&ast.NumberNode{Value: 1}},
 					Body: &ast.BlockStatement{
 						Statements: []ast.Statement{
 							&ast.ReassignStatement{
-								Name:  "result",
-								Value: &ast.NumberNode{Value: 10},
+								Target: &ast.VariableNode{Name: "result"},
+								Value:  &ast.NumberNode{Value: 10},
 							},
 						},
 					},
 				},
 			},
-			Default: nil,
 		}
 
 		// ARM64
@@ -339,3 +346,139 @@ func TestGenerateSwitchEdgeCases(t *testing.T) {
 		}
 	})
 }
+
+// generalSwitchProgram builds
+//
+//	func main() {
+//		name := "bob"
+//		switch n := 2.5; {
+//		case n > 2:
+//			println(1)
+//			fallthrough
+//		default:
+//			println(2)
+//		}
+//		switch name {
+//		case "alice", "bob":
+//			println(name)
+//		}
+//	}
+func generalSwitchProgram() []ast.Statement {
+	println := func(arg ast.ASTNode) ast.Statement {
+		return &ast.ExpressionStatement{Expression: &ast.CallNode{Function: "println", Arguments: []ast.ASTNode{arg}}}
+	}
+	return []ast.Statement{&ast.FuncStatement{
+		Name: "main",
+		Body: &ast.BlockStatement{Statements: []ast.Statement{
+			&ast.AssignStatement{Name: "name", Value: &ast.StringNode{Value: "bob"}},
+			&ast.SwitchStatement{
+				Init: &ast.AssignStatement{Name: "n", Value: &ast.FloatNode{Value: 2.5}},
+				Cases: []*ast.CaseStatement{
+					{
+						Values: []ast.ASTNode{&ast.BinaryOpNode{Left: &ast.VariableNode{Name: "n"}, Operator: token.GTR, Right: &ast.NumberNode{Value: 2}}},
+						Body: &ast.BlockStatement{Statements: []ast.Statement{
+							println(&ast.NumberNode{Value: 1}),
+							&ast.FallthroughStatement{},
+						}},
+					},
+					{
+						Body: &ast.BlockStatement{Statements: []ast.Statement{println(&ast.NumberNode{Value: 2})}},
+					},
+				},
+			},
+			&ast.SwitchStatement{
+				Value: &ast.VariableNode{Name: "name"},
+				Cases: []*ast.CaseStatement{
+					{
+						Values: []ast.ASTNode{&ast.StringNode{Value: "alice"}, &ast.StringNode{Value: "bob"}},
+						Body:   &ast.BlockStatement{Statements: []ast.Statement{println(&ast.VariableNode{Name: "name"})}},
+					},
+				},
+			},
+		}},
+	}}
+}
+
+func TestGenerateGeneralSwitchX86_64(t *testing.T) {
+	gen := NewX86_64Generator()
+	result := gen.Generate(generalSwitchProgram())
+
+	expected := []string{
+		// the init statement runs first, then the tagless switch compares with true
+		"# n := value\n    movabsq $4612811918334230528, %rax # 2.5\n    movq %rax, -16(%rbp)\n    # switch expression\n    movq $1, %rax\n    movq %rax, -24(%rbp)",
+		// fallthrough continues into the default body without a jump
+		"    # case 0 body\n    movq $1, %rax\n    # Print number in %rax\n    call _print_number\nL3:\n    # default case",
+		// each value of a case list is compared by content
+		"    # case 0 comparison\n    leaq str_1(%rip), %rax\n    movq %rax, %rbx\n    movq -32(%rbp), %rax\n    call _string_equal\n    testq %rax, %rax\n    jnz L5",
+		"    # case 0 comparison\n    leaq str_0(%rip), %rax",
+	}
+	for _, want := range expected {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected output to contain:\n%s\nOutput:\n%s", want, result)
+		}
+	}
+	if !strings.Contains(gen.GenerateRuntime(), "_string_equal:") {
+		t.Errorf("expected runtime to define _string_equal")
+	}
+}
+
+func TestGenerateGeneralSwitchARM64(t *testing.T) {
+	gen := NewARM64Generator()
+	result := gen.Generate(generalSwitchProgram())
+
+	expected := []string{
+		"    // switch expression\n    mov x0, #1\n    str x0, [x29, #-24]",
+		"    // case 0 body\n    mov x0, #1\n    // Print number in x0\n    bl _print_number\nL3:\n    // default case",
+		"    mov x1, x0\n    ldr x0, [x29, #-32]\n    bl _string_equal\n    cbnz x0, L5",
+	}
+	for _, want := range expected {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected output to contain:\n%s\nOutput:\n%s", want, result)
+		}
+	}
+	if !strings.Contains(gen.GenerateRuntime(), "_string_equal:") {
+		t.Errorf("expected runtime to define _string_equal")
+	}
+}
+
+func TestGenerateSwitchFloatAndStringEquality(t *testing.T) {
+	// switch f { case 2: } converts the case value and compares as float64
+	stmt := &ast.SwitchStatement{
+		Value: &ast.VariableNode{Name: "f"},
+		Cases: []*ast.CaseStatement{
+			{Values: []ast.ASTNode{&ast.NumberNode{Value: 2}}, Body: &ast.BlockStatement{}},
+		},
+	}
+	genX86 := NewX86_64Generator()
+	genX86.variables = map[string]int{"f": 8}
+	genX86.types.declare("f", "float64")
+	genX86.generateSwitchStatement(stmt)
+	for _, want := range []string{"cvtsi2sdq %rax, %xmm0", "ucomisd %xmm1, %xmm0", "setnp %bl"} {
+		if !strings.Contains(genX86.output.String(), want) {
+			t.Errorf("x86_64: expected %q in output:\n%s", want, genX86.output.String())
+		}
+	}
+
+	genARM := NewARM64Generator()
+	genARM.variables = map[string]int{"f": 8}
+	genARM.types.declare("f", "float64")
+	genARM.generateSwitchStatement(stmt)
+	for _, want := range []string{"scvtf d0, x0", "fcmp d0, d1", "cset x0, eq"} {
+		if !strings.Contains(genARM.output.String(), want) {
+			t.Errorf("ARM64: expected %q in output:\n%s", want, genARM.output.String())
+		}
+	}
+
+	// a != b on strings negates the content comparison
+	neq := &ast.BinaryOpNode{Left: &ast.StringNode{Value: "a"}, Operator: token.NEQ, Right: &ast.StringNode{Value: "b"}}
+	genX86 = NewX86_64Generator()
+	genX86.generateExpression(neq)
+	if !strings.Contains(genX86.output.String(), "call _string_equal\n    xorq $1, %rax") {
+		t.Errorf("x86_64: expected negated string comparison:\n%s", genX86.output.String())
+	}
+	genARM = NewARM64Generator()
+	genARM.generateExpression(neq)
+	if !strings.Contains(genARM.output.String(), "bl _string_equal\n    eor x0, x0, #1") {
+		t.Errorf("ARM64: expected negated string comparison:\n%s", genARM.output.String())
+	}
+}
@@ -0,0 +1,821 @@
+package asmgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/constant"
+	"github.com/yuya-takeyama/petitgo/scanner"
+	"github.com/yuya-takeyama/petitgo/token"
+)
+
+// typeEnv tracks static types of variables and function results so that
+// generators can choose instructions for non-int values
+type typeEnv struct {
+	varTypes    map[string]string   // variable name -> type name
+	resultTypes map[string][]string // function name -> result type names
+	paramTypes  map[string][]string // function name -> parameter type names
+	results     []ast.Parameter     // results of the function being generated
+
+	interfaces  map[string][]string        // interface name -> method names
+	methods     map[string]map[string]bool // type name -> method name -> has a pointer receiver
+	methodSlots []string                   // method names of all interfaces in method table order
+
+	constants map[string]constant.Value  // package-level constant name -> value
+	structs   map[string][]*ast.FieldDef // struct name -> fields
+
+	globals     []string          // package-level variables in declaration order
+	globalTypes map[string]string // package-level variable name -> type
+	globalDecls []ast.Statement   // declarations of the package-level variables
+
+	errors []*scanner.Error // type errors found while generating
+}
+
+func newTypeEnv() typeEnv {
+	return typeEnv{
+		varTypes:    make(map[string]string),
+		resultTypes: make(map[string][]string),
+		paramTypes:  make(map[string][]string),
+		interfaces:  make(map[string][]string),
+		methods:     make(map[string]map[string]bool),
+		constants:   make(map[string]constant.Value),
+		structs:     make(map[string][]*ast.FieldDef),
+		globalTypes: make(map[string]string),
+	}
+}
+
+// collectFunctions records the signatures of all top-level functions, the
+// methods of interface types, the fields of struct types, the package-level
+// constants and the package-level variables. The parser replaces constants
+// with their values, except in functions declared before the constant.
+func (t *typeEnv) collectFunctions(statements []ast.Statement) {
+	interfaces := make(map[string]*ast.InterfaceStatement)
+	for _, stmt := range declarations(statements) {
+		switch s := stmt.(type) {
+		case *ast.TypeStatement:
+			t.structs[s.Name] = s.Fields
+		case *ast.ConstStatement:
+			for _, spec := range s.Specs {
+				for i, name := range spec.Names {
+					t.constants[name] = spec.Constants[i]
+				}
+			}
+		case *ast.FuncStatement:
+			t.declareFunction(funcSymbol(s), funcParameters(s), s.Results)
+			if s.Receiver != nil {
+				if t.methods[s.ReceiverType()] == nil {
+					t.methods[s.ReceiverType()] = make(map[string]bool)
+				}
+				t.methods[s.ReceiverType()][s.Name] = strings.HasPrefix(ast.TypeName(s.Receiver.Type), "*")
+			}
+		case *ast.InterfaceStatement:
+			interfaces[s.Name] = s
+		}
+	}
+
+	// A method of an interface is called with the data word of the
+	// interface value as its receiver. An interface also has the methods of
+	// the interfaces it embeds.
+	slots := make(map[string]bool)
+	lookup := func(name string) (*ast.InterfaceStatement, bool) {
+		iface, exists := interfaces[name]
+		return iface, exists
+	}
+	for name, iface := range interfaces {
+		methods := iface.MethodSet(lookup)
+		names := make([]string, len(methods))
+		for i, method := range methods {
+			names[i] = method.Name
+			slots[method.Name] = true
+			receiver := ast.Parameter{}
+			t.declareFunction(methodSymbol(name, method.Name),
+				append([]ast.Parameter{receiver}, method.Parameters...), method.Results)
+		}
+		t.interfaces[name] = names
+	}
+
+	t.methodSlots = t.methodSlots[:0]
+	for name := range slots {
+		t.methodSlots = append(t.methodSlots, name)
+	}
+	sort.Strings(t.methodSlots)
+
+	t.collectGlobals(statements)
+}
+
+// declareFunction records the parameter and result types of a function
+func (t *typeEnv) declareFunction(symbol string, parameters, results []ast.Parameter) {
+	resultTypes := make([]string, len(results))
+	for i, result := range results {
+		resultTypes[i] = constant.CanonicalType(ast.TypeName(result.Type))
+	}
+	t.resultTypes[symbol] = resultTypes
+	params := make([]string, len(parameters))
+	for i, param := range parameters {
+		params[i] = constant.CanonicalType(ast.TypeName(param.Type))
+	}
+	t.paramTypes[symbol] = params
+}
+
+// enterFunction resets the variable types for a new function body
+func (t *typeEnv) enterFunction(funcStmt *ast.FuncStatement) {
+	t.varTypes = make(map[string]string)
+	t.results = funcStmt.Results
+	for _, param := range funcParameters(funcStmt) {
+		t.varTypes[param.Name] = constant.CanonicalType(ast.TypeName(param.Type))
+	}
+}
+
+// resultType returns the type of the i-th result of the function being generated
+func (t *typeEnv) resultType(i int) string {
+	if i < len(t.results) {
+		return constant.CanonicalType(ast.TypeName(t.results[i].Type))
+	}
+	return ""
+}
+
+// callResultType returns the type of the i-th result of a called function
+func (t *typeEnv) callResultType(function string, i int) string {
+	if results, exists := t.resultTypes[function]; exists && i < len(results) {
+		return results[i]
+	}
+	return ""
+}
+
+// funcSymbol returns the assembly symbol of a function or method, without
+// the leading underscore. Methods are mangled as Type.Method, which cannot
+// clash with a function name.
+func funcSymbol(funcStmt *ast.FuncStatement) string {
+	if funcStmt.Receiver != nil {
+		return methodSymbol(funcStmt.ReceiverType(), funcStmt.Name)
+	}
+	return funcStmt.Name
+}
+
+// methodSymbol returns the mangled symbol of a method
+func methodSymbol(typeName, method string) string {
+	return typeName + "." + method
+}
+
+// funcParameters returns the parameters of a function. The receiver of a
+// method is passed as its first parameter.
+func funcParameters(funcStmt *ast.FuncStatement) []ast.Parameter {
+	if funcStmt.Receiver == nil {
+		return funcStmt.Parameters
+	}
+	return append([]ast.Parameter{*funcStmt.Receiver}, funcStmt.Parameters...)
+}
+
+// callSymbol returns the symbol called by a call expression; a method is
+// resolved through the static type of its receiver. A call of a function
+// value has no symbol and returns the function type instead, whose
+// signature is recorded like that of a function.
+func (t *typeEnv) callSymbol(call *ast.CallNode) string {
+	if field := t.fieldCall(call); field != call {
+		return t.callSymbol(field)
+	}
+	if call.Receiver != nil {
+		return methodSymbol(strings.TrimPrefix(t.exprType(call.Receiver), "*"), call.Function)
+	}
+	typeName := t.varTypes[call.Function]
+	if call.Callee != nil {
+		typeName = t.exprType(call.Callee)
+	}
+	if !ast.IsFuncType(typeName) {
+		return call.Function
+	}
+	if _, exists := t.paramTypes[typeName]; !exists {
+		params, results := funcSignature(typeName)
+		t.paramTypes[typeName] = params
+		t.resultTypes[typeName] = results
+	}
+	return typeName
+}
+
+// fieldCall returns the call of the function value held by the field f
+// when x.f(...) calls no method of the struct x, and the call itself
+// otherwise. A call of a struct that has neither a method nor a field f is
+// an error.
+func (t *typeEnv) fieldCall(call *ast.CallNode) *ast.CallNode {
+	if call.Receiver == nil {
+		return call
+	}
+	typeName := strings.TrimPrefix(t.exprType(call.Receiver), "*")
+	if _, exists := t.methods[typeName][call.Function]; exists || !t.isStruct(typeName) {
+		return call
+	}
+	field := t.field(typeName, call.Function)
+	if field == nil {
+		t.error(startPos(call.Receiver), fmt.Sprintf("%s undefined (type %s has no field or method %s)", selectorName(call), typeName, call.Function))
+		return call
+	}
+	if fieldType := constant.CanonicalType(ast.TypeName(field.Type)); !ast.IsFuncType(fieldType) {
+		t.error(startPos(call.Receiver), fmt.Sprintf("invalid operation: cannot call non-function %s (variable of type %s)", selectorName(call), fieldType))
+		return call
+	}
+	return &ast.CallNode{
+		Callee:    &ast.FieldAccessNode{Object: call.Receiver, Field: call.Function, Pos: call.Pos},
+		Arguments: call.Arguments,
+		Pos:       call.Pos,
+	}
+}
+
+// selectorName returns x.f for a method call x.f(...) on a variable x, and
+// f otherwise
+func selectorName(call *ast.CallNode) string {
+	if v, ok := call.Receiver.(*ast.VariableNode); ok {
+		return v.Name + "." + call.Function
+	}
+	return call.Function
+}
+
+// callee returns the expression holding the function value called by a
+// call of a function value
+func callee(call *ast.CallNode) ast.ASTNode {
+	if call.Callee != nil {
+		return call.Callee
+	}
+	return &ast.VariableNode{Name: call.Function, Pos: call.Pos}
+}
+
+// funcType returns the function type of a declared function
+func (t *typeEnv) funcType(function string) (string, bool) {
+	params, exists := t.paramTypes[function]
+	if !exists {
+		return "", false
+	}
+	return ast.FuncTypeName(params, t.resultTypes[function]), true
+}
+
+// callArguments returns the arguments of a call, with the receiver of a
+// method call first
+func callArguments(call *ast.CallNode) []ast.ASTNode {
+	if call.Receiver == nil {
+		return call.Arguments
+	}
+	return append([]ast.ASTNode{call.Receiver}, call.Arguments...)
+}
+
+// declare records the type of a variable
+func (t *typeEnv) declare(name, typeName string) {
+	t.varTypes[name] = constant.CanonicalType(typeName)
+}
+
+// paramType returns the type of the i-th parameter of a function
+func (t *typeEnv) paramType(function string, i int) string {
+	if params, exists := t.paramTypes[function]; exists && i < len(params) {
+		return params[i]
+	}
+	return ""
+}
+
+// exprType returns the static type of an expression ("int" when unknown)
+func (t *typeEnv) exprType(expr ast.ASTNode) string {
+	switch e := expr.(type) {
+	case *ast.FloatNode:
+		return "float64"
+	case *ast.RuneNode:
+		return "int32"
+	case *ast.StringNode:
+		return "string"
+	case *ast.BooleanNode:
+		return "bool"
+	case *ast.ConstExpr:
+		return constant.DefaultType(e.Value)
+	case *ast.VariableNode:
+		if typeName, exists := t.varTypes[e.Name]; exists && typeName != "" {
+			return typeName
+		}
+		if v, isConstant := t.constants[e.Name]; isConstant {
+			return constant.DefaultType(v)
+		}
+		if typeName, isFunction := t.funcType(e.Name); isFunction {
+			return typeName
+		}
+	case *ast.FuncLiteral:
+		return e.TypeName()
+	case *ast.MapLiteral:
+		return e.TypeName()
+	case *ast.SliceLiteral:
+		return e.TypeName()
+	case *ast.ArrayLiteral:
+		return e.TypeName()
+	case *ast.BinaryOpNode:
+		switch e.Operator {
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ, token.LAND, token.LOR:
+			return "bool"
+		case token.SHL, token.SHR:
+			// A shift has the type of its left operand
+			return t.exprType(e.Left)
+		}
+		leftType := t.exprType(e.Left)
+		rightType := t.exprType(e.Right)
+		if leftType == "float64" || rightType == "float64" {
+			return "float64"
+		}
+		// An int operand acts like an untyped constant; so does a rune
+		// mixed with a byte, as in s[i] - '0'
+		if leftType == "uint8" || rightType == "uint8" {
+			return "uint8"
+		}
+		if leftType == "int32" || rightType == "int32" {
+			return "int32"
+		}
+		return leftType
+	case *ast.UnaryOpNode:
+		switch e.Operator {
+		case token.NOT:
+			return "bool"
+		case token.AND:
+			return "*" + t.exprType(e.Operand)
+		}
+		return t.exprType(e.Operand)
+	case *ast.DerefNode:
+		return strings.TrimPrefix(t.exprType(e.Operand), "*")
+	case *ast.NewNode:
+		return "*" + constant.CanonicalType(ast.TypeName(e.Type))
+	case *ast.FieldAccessNode:
+		if field := t.field(t.exprType(e.Object), e.Field); field != nil {
+			return constant.CanonicalType(ast.TypeName(field.Type))
+		}
+	case *ast.CallNode:
+		if isConversion(e) {
+			return constant.CanonicalType(e.Function)
+		}
+		if t.isRecover(e) {
+			return "interface{}"
+		}
+		if results := t.resultTypes[t.callSymbol(e)]; len(results) == 1 && results[0] != "" {
+			return results[0]
+		}
+	case *ast.StructLiteral:
+		return e.TypeName
+	case *ast.TypeAssertNode:
+		return constant.CanonicalType(e.Type)
+	case *ast.IndexAccess:
+		// Indexing a string yields a byte, indexing a slice its element type
+		// and indexing a map its value type
+		objectType := t.exprType(e.Object)
+		if objectType == "string" {
+			return "uint8"
+		}
+		if isSliceType(objectType) {
+			return constant.CanonicalType(sliceElementType(objectType))
+		}
+		if ast.IsMapType(objectType) {
+			_, valueType := ast.MapTypes(objectType)
+			return constant.CanonicalType(valueType)
+		}
+	case *ast.SliceExpr:
+		// Slicing a string yields a string, and slicing a slice or an array
+		// a slice of its element type
+		objectType := t.exprType(e.Object)
+		if isSliceType(objectType) {
+			return "[]" + sliceElementType(objectType)
+		}
+		return objectType
+	}
+	return "int"
+}
+
+// field returns the field of a struct type, or of the struct type a
+// pointer type points to. It returns nil when the struct is unknown.
+func (t *typeEnv) field(typeName, name string) *ast.FieldDef {
+	for _, field := range t.structs[strings.TrimPrefix(typeName, "*")] {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// fieldOffset returns the offset of a field in the heap block of a struct.
+// Fields are laid out in declaration order, an interface field taking two
+// words; the offsets of fields of unknown structs are guessed from their
+// names.
+func (t *typeEnv) fieldOffset(typeName, name string) int {
+	fields, exists := t.structs[strings.TrimPrefix(typeName, "*")]
+	if !exists {
+		return getFieldOffset(name)
+	}
+	offset := 0
+	for _, field := range fields {
+		if field.Name == name {
+			return offset
+		}
+		offset += t.size(ast.TypeName(field.Type))
+	}
+	return 0
+}
+
+// structSize returns the size of the heap block of a struct type
+func (t *typeEnv) structSize(typeName string) int {
+	size := 0
+	for _, field := range t.structs[typeName] {
+		size += t.size(ast.TypeName(field.Type))
+	}
+	return size
+}
+
+// blockFields returns the names of the fields of a struct not among given
+// whose zero value is a heap block, a struct or an array, which a new struct
+// needs as its block starts with nil in them
+func (t *typeEnv) blockFields(typeName string, given map[string]ast.ASTNode) []string {
+	var names []string
+	for _, field := range t.structs[typeName] {
+		fieldType := constant.CanonicalType(ast.TypeName(field.Type))
+		if _, exists := given[field.Name]; !exists && (t.isStruct(fieldType) || isArrayType(fieldType)) {
+			names = append(names, field.Name)
+		}
+	}
+	return names
+}
+
+// literalFields returns the names of the fields given by a struct literal,
+// in declaration order for a known struct and sorted otherwise, and their
+// values by name. Values given in field order must be those of all fields.
+func (t *typeEnv) literalFields(node *ast.StructLiteral) ([]string, map[string]ast.ASTNode) {
+	var names []string
+	if fields, exists := t.structs[node.TypeName]; exists {
+		all := make([]string, len(fields))
+		for i, field := range fields {
+			all[i] = field.Name
+		}
+		switch {
+		case node.Values == nil:
+		case len(node.Values) < len(fields):
+			t.error(node.Pos, "too few values in struct literal of type "+node.TypeName)
+		case len(node.Values) > len(fields):
+			t.error(node.Values[len(fields)].Position(), "too many values in struct literal of type "+node.TypeName)
+		}
+		values := node.FieldValues(all)
+		for _, name := range all {
+			if _, given := values[name]; given {
+				names = append(names, name)
+			}
+		}
+		return names, values
+	}
+	for name := range node.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, node.Fields
+}
+
+// isStruct reports whether typeName names a struct type
+func (t *typeEnv) isStruct(typeName string) bool {
+	_, exists := t.structs[typeName]
+	return exists
+}
+
+// isInterface reports whether typeName names an interface type
+func (t *typeEnv) isInterface(typeName string) bool {
+	if typeName == "any" || typeName == "interface{}" {
+		return true
+	}
+	_, exists := t.interfaces[typeName]
+	return exists
+}
+
+// error records a type error at pos
+func (t *typeEnv) error(pos token.Position, message string) {
+	// A lowered statement is checked again, with the same error
+	for _, err := range t.errors {
+		if err.Pos == pos && err.Message == message {
+			return
+		}
+	}
+	t.errors = append(t.errors, &scanner.Error{Pos: pos, Message: message})
+}
+
+// checkImplements reports an error when the static type of expr does not
+// implement the interface iface, so a value of it cannot be assigned to iface
+func (t *typeEnv) checkImplements(expr ast.ASTNode, iface string) {
+	if t.isNil(expr) {
+		return
+	}
+	typeName := t.exprType(expr)
+	if reason := t.missingMethod(typeName, iface); reason != "" {
+		t.error(expr.Position(), typeName+" does not implement "+iface+" ("+reason+")")
+	}
+}
+
+// checkSingleValues reports an error for each call among several args that
+// returns several results, as each argument takes a single value; the
+// results of a sole argument are spread over the parameters instead
+func (t *typeEnv) checkSingleValues(args []ast.ASTNode) {
+	if len(args) == 1 {
+		return
+	}
+	for _, arg := range args {
+		if call, ok := arg.(*ast.CallNode); ok && len(t.resultTypes[t.callSymbol(call)]) > 1 {
+			t.error(arg.Position(), "multiple-value "+call.Function+"(...) in single-value context")
+		}
+	}
+}
+
+// spreadArguments returns, for the arguments of f(g()) where g returns
+// several results, the statement assigning the results to hidden variables
+// and the variables, which are passed as the arguments of f. hidden returns
+// the name of a new hidden variable.
+func (t *typeEnv) spreadArguments(args []ast.ASTNode, hidden func() string) (*ast.TupleAssignStatement, []ast.ASTNode, bool) {
+	if len(args) != 1 || !t.isMultiValueCall(args[0]) {
+		return nil, nil, false
+	}
+	pos := args[0].Position()
+	results := make([]ast.ASTNode, len(t.resultTypes[t.callSymbol(args[0].(*ast.CallNode))]))
+	for i := range results {
+		results[i] = &ast.VariableNode{Name: hidden(), Pos: pos}
+	}
+	return &ast.TupleAssignStatement{Targets: results, Define: true, Values: args, Pos: pos}, results, true
+}
+
+// missingMethod explains why the method set of typeName does not contain
+// every method of the interface iface, or returns "" when it does. The
+// method set of an interface type holds the methods of the interface.
+func (t *typeEnv) missingMethod(typeName, iface string) string {
+	baseType := strings.TrimPrefix(typeName, "*")
+	for _, method := range t.interfaces[iface] {
+		var symbol string
+		if t.isInterface(typeName) {
+			if !hasMethod(t.interfaces[typeName], method) {
+				return "missing method " + method
+			}
+			symbol = methodSymbol(typeName, method)
+		} else {
+			pointer, exists := t.methods[baseType][method]
+			if !exists {
+				return "missing method " + method
+			}
+			if pointer && baseType == typeName {
+				return "method " + method + " has pointer receiver"
+			}
+			symbol = methodSymbol(baseType, method)
+		}
+		if !t.sameSignature(symbol, methodSymbol(iface, method)) {
+			return "wrong type for method " + method
+		}
+	}
+	return ""
+}
+
+// hasMethod reports whether a list of method names contains name
+func hasMethod(methods []string, name string) bool {
+	for _, method := range methods {
+		if method == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sameSignature reports whether two methods take parameters and return
+// results of the same types, ignoring their receivers
+func (t *typeEnv) sameSignature(a, b string) bool {
+	paramsA, paramsB := t.paramTypes[a], t.paramTypes[b]
+	resultsA, resultsB := t.resultTypes[a], t.resultTypes[b]
+	if len(paramsA) != len(paramsB) || len(resultsA) != len(resultsB) {
+		return false
+	}
+	for i := 1; i < len(paramsA); i++ {
+		if paramsA[i] != paramsB[i] {
+			return false
+		}
+	}
+	for i := range resultsA {
+		if resultsA[i] != resultsB[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// words returns the number of machine words holding a value of a type.
+// Interface values take two: the data word and a type descriptor pointer.
+func (t *typeEnv) words(typeName string) int {
+	if t.isInterface(typeName) {
+		return 2
+	}
+	return 1
+}
+
+// size returns the stack size of a value of a type
+func (t *typeEnv) size(typeName string) int {
+	return 8 * t.words(typeName)
+}
+
+// isDynamicCall reports whether a call is a method call on an interface
+// value, dispatched through the method table of its type descriptor
+func (t *typeEnv) isDynamicCall(call *ast.CallNode) bool {
+	return call.Receiver != nil && t.isInterface(t.exprType(call.Receiver))
+}
+
+// methodOffset returns the offset of a method in the method table of a type
+// descriptor, which starts with the address of the type name
+func (t *typeEnv) methodOffset(method string) int {
+	return 8 * (1 + sort.SearchStrings(t.methodSlots, method))
+}
+
+// methodTable returns the method symbols in the method table of a type, in
+// slot order. A slot is empty when the method is not in the method set:
+// that of T holds the methods with value receivers, and that of *T also the
+// ones with pointer receivers.
+func (t *typeEnv) methodTable(typeName string) []string {
+	baseType := strings.TrimPrefix(typeName, "*")
+	table := make([]string, len(t.methodSlots))
+	for i, method := range t.methodSlots {
+		if pointer, exists := t.methods[baseType][method]; exists && (!pointer || baseType != typeName) {
+			table[i] = methodSymbol(baseType, method)
+		}
+	}
+	return table
+}
+
+// typeSymbol returns the symbol of the type descriptor of a type
+func typeSymbol(typeName string) string {
+	return "_type." + strings.NewReplacer("*", "ptr.", "[]", "slice.").Replace(typeName)
+}
+
+// isNil reports whether expr is the predeclared nil
+func (t *typeEnv) isNil(expr ast.ASTNode) bool {
+	v, ok := expr.(*ast.VariableNode)
+	if !ok || v.Name != "nil" {
+		return false
+	}
+	_, declared := t.varTypes[v.Name]
+	return !declared
+}
+
+// nilComparison returns the interface operand of x == nil or x != nil
+func (t *typeEnv) nilComparison(e *ast.BinaryOpNode) (ast.ASTNode, bool) {
+	if e.Operator != token.EQL && e.Operator != token.NEQ {
+		return nil, false
+	}
+	switch {
+	case t.isNil(e.Right) && t.isInterface(t.exprType(e.Left)):
+		return e.Left, true
+	case t.isNil(e.Left) && t.isInterface(t.exprType(e.Right)):
+		return e.Right, true
+	}
+	return nil, false
+}
+
+// commaOkAssertion returns the type assertion of v, ok := x.(T)
+func commaOkAssertion(s *ast.TupleAssignStatement) (*ast.TypeAssertNode, bool) {
+	if len(s.Values) != 1 || len(s.Targets) != 2 {
+		return nil, false
+	}
+	assert, ok := s.Values[0].(*ast.TypeAssertNode)
+	return assert, ok
+}
+
+// isFloat reports whether an expression has type float64
+func (t *typeEnv) isFloat(expr ast.ASTNode) bool {
+	return t.exprType(expr) == "float64"
+}
+
+// isConversion reports whether a call is a conversion between numeric types
+func isConversion(call *ast.CallNode) bool {
+	if call.Receiver != nil {
+		return false
+	}
+	switch call.Function {
+	case "float64", "int", "int32", "rune", "uint8", "byte":
+		return len(call.Arguments) == 1
+	}
+	return false
+}
+
+// isStringComparison reports whether e compares two strings with == or !=
+func (t *typeEnv) isStringComparison(e *ast.BinaryOpNode) bool {
+	if e.Operator != token.EQL && e.Operator != token.NEQ {
+		return false
+	}
+	return t.exprType(e.Left) == "string" && t.exprType(e.Right) == "string"
+}
+
+// isMultiValueCall reports whether expr calls a function returning more than
+// one result
+func (t *typeEnv) isMultiValueCall(expr ast.ASTNode) bool {
+	call, ok := expr.(*ast.CallNode)
+	return ok && len(t.resultTypes[t.callSymbol(call)]) > 1
+}
+
+// returnValues returns the values returned by a return statement; a bare
+// return in a function with named results returns those variables
+func (t *typeEnv) returnValues(stmt *ast.ReturnStatement) []ast.ASTNode {
+	if len(stmt.Values) > 0 || len(t.results) == 0 || t.results[0].Name == "" {
+		return stmt.Values
+	}
+	values := make([]ast.ASTNode, len(t.results))
+	for i, result := range t.results {
+		values[i] = &ast.VariableNode{Name: result.Name, Pos: stmt.Pos}
+	}
+	return values
+}
+
+// tupleValueType returns the type of the i-th value assigned by a tuple
+// assignment, taken from the called function when a single call supplies
+// all values, or from the type assertion of v, ok := x.(T)
+func (t *typeEnv) tupleValueType(s *ast.TupleAssignStatement, i int) string {
+	if assert, ok := commaOkAssertion(s); ok {
+		if i == 0 {
+			return constant.CanonicalType(assert.Type)
+		}
+		return "bool"
+	}
+	if index, ok := t.commaOkMapIndex(s); ok {
+		if i == 0 {
+			return t.exprType(index)
+		}
+		return "bool"
+	}
+	if len(s.Values) == 1 {
+		if call, ok := s.Values[0].(*ast.CallNode); ok {
+			return t.callResultType(t.callSymbol(call), i)
+		}
+	}
+	if i < len(s.Values) {
+		return t.exprType(s.Values[i])
+	}
+	return ""
+}
+
+// assignOperator returns the operator of a tuple assignment for comments
+func assignOperator(s *ast.TupleAssignStatement) string {
+	if s.Define {
+		return ":="
+	}
+	return "="
+}
+
+// declarations returns the top-level statements with the declarations of
+// groups such as type ( ... ) in place of the groups
+func declarations(statements []ast.Statement) []ast.Statement {
+	var decls []ast.Statement
+	for _, stmt := range statements {
+		if group, ok := stmt.(*ast.DeclGroup); ok {
+			decls = append(decls, group.Decls...)
+		} else {
+			decls = append(decls, stmt)
+		}
+	}
+	return decls
+}
+
+// zeroValue returns a literal holding the zero value of a type, used to
+// initialize named results and variables. The zero value of an interface
+// type is nil; structs are handled by the generators, which allocate them.
+func (t *typeEnv) zeroValue(typeName string) ast.ASTNode {
+	if t.isInterface(typeName) {
+		return &ast.VariableNode{Name: "nil"}
+	}
+	switch constant.CanonicalType(typeName) {
+	case "float64":
+		return &ast.FloatNode{Value: 0}
+	case "string":
+		return &ast.StringNode{Value: ""}
+	case "bool":
+		return &ast.BooleanNode{Value: false}
+	}
+	return &ast.NumberNode{Value: 0}
+}
+
+// constLiteral returns a literal holding the value of a constant, which is
+// generated in place of the constant
+func constLiteral(v constant.Value) ast.ASTNode {
+	switch v.Kind() {
+	case constant.Bool:
+		return &ast.BooleanNode{Value: v.BoolVal()}
+	case constant.String:
+		return &ast.StringNode{Value: v.StringVal()}
+	case constant.Float:
+		return &ast.FloatNode{Value: v.Float64Val()}
+	}
+	n, _ := v.Int64Val()
+	return &ast.NumberNode{Value: int(n)}
+}
+
+// quoteAsmString quotes a string for an .asciz directive. Quotes, backslashes
+// and bytes outside printable ASCII are written as escape sequences.
+func quoteAsmString(value string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		ch := value[i]
+		switch {
+		case ch == '"' || ch == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(ch)
+		case ch < ' ' || ch > '~':
+			b.WriteString(fmt.Sprintf("\\%03o", ch))
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -2,9 +2,13 @@ package asmgen
 
 import (
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 
 	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/constant"
+	"github.com/yuya-takeyama/petitgo/scanner"
 	"github.com/yuya-takeyama/petitgo/token"
 )
 
@@ -16,6 +20,18 @@ type X86_64Generator struct {
 	stackSize      int
 	stringLiterals map[string]string // string value -> label name
 	stringCount    int
+	types          typeEnv
+	descriptors    map[string]bool // types whose descriptors are referenced
+	copies         map[string]bool // types whose copy routines are referenced
+
+	function       string          // symbol of the function being generated
+	literals       int             // function literals seen in the function
+	captured       map[string]bool // variables of the function captured by closures
+	cells          map[int]bool    // stack offsets holding the addresses of heap cells
+	closures       []*closure      // function literals waiting to be generated
+	staticClosures map[string]bool // declared functions used as values
+	branches       branchStack     // targets of break, continue and goto
+	defers         deferFrame      // exit code and results of a function with defer statements
 }
 
 // NewX86_64Generator creates a new x86_64 assembly generator
@@ -25,6 +41,10 @@ func NewX86_64Generator() *X86_64Generator {
 		stackSize:      0,
 		stringLiterals: make(map[string]string),
 		stringCount:    0,
+		types:          newTypeEnv(),
+		descriptors:    make(map[string]bool),
+		copies:         make(map[string]bool),
+		staticClosures: make(map[string]bool),
 	}
 }
 
@@ -37,57 +57,155 @@ func (g *X86_64Generator) Generate(statements []ast.Statement) string {
 	g.writeLine(".globl _start")
 	g.writeLine("")
 
-	// Generate all functions first
+	g.types.collectFunctions(statements)
+
+	// Generate all functions first, each followed by its function literals
 	for _, stmt := range statements {
 		if funcStmt, ok := stmt.(*ast.FuncStatement); ok {
-			g.generateFunction(funcStmt)
+			g.generateFunction(funcStmt, nil)
+			for len(g.closures) > 0 {
+				c := g.closures[0]
+				g.closures = g.closures[1:]
+				g.generateFunction(c.funcStatement(), c)
+			}
 		}
 	}
+	g.generateCopyRoutines()
 
-	// Generate string literals in data section
+	// Generate type descriptors, static closures and string literals in
+	// data section; run-time errors panic with string values
+	g.typeDescriptor("string")
+	g.generateTypeDescriptors()
+	g.generateStaticClosures()
 	g.generateStringLiterals()
+	g.generateGlobals()
 
 	return g.output.String()
 }
 
-func (g *X86_64Generator) generateFunction(funcStmt *ast.FuncStatement) {
+// generateFunction generates a declared function, or the function of a
+// literal when c is not nil
+func (g *X86_64Generator) generateFunction(funcStmt *ast.FuncStatement, c *closure) {
 	// Reset variables for each function
 	g.variables = make(map[string]int)
 	g.stackSize = 0
+	g.types.enterFunction(funcStmt)
+	g.function = funcSymbol(funcStmt)
+	g.literals = 0
+	g.captured = escapingVariables(funcStmt)
+	g.cells = make(map[int]bool)
+	g.branches.reset()
+	start := g.output.Len()
 
 	// Linux uses _start as entry point instead of main
-	if funcStmt.Name == "main" {
+	if funcStmt.Name == "main" && funcStmt.Receiver == nil {
 		g.writeLine("_start:")
 	} else {
-		g.writeLine(fmt.Sprintf("_%s:", funcStmt.Name))
+		g.writeLine(fmt.Sprintf("_%s:", funcSymbol(funcStmt)))
 	}
 
 	g.writeLine("    # Function prologue")
 	g.writeLine("    pushq %rbp")      // Save base pointer
 	g.writeLine("    movq %rsp, %rbp") // Set base pointer
-	g.writeLine("    subq $64, %rsp")  // Reserve 64 bytes for local variables
+	// Reserve space for local variables; the size is patched in later
+	g.writeLine("    subq $" + frameSizePlaceholder + ", %rsp")
+
+	// The captured variables of a closure are the heap cells listed in the
+	// closure passed in %r10
+	if c != nil {
+		for i, name := range c.captures {
+			offset := g.allocateCell(name, c.types[i])
+			g.writeLine(fmt.Sprintf("    # Captured: %s", name))
+			g.writeLine(fmt.Sprintf("    movq %d(%%r10), %%rax", 8*(i+1)))
+			g.writeLine(fmt.Sprintf("    movq %%rax, -%d(%%rbp)", offset))
+		}
+	}
+
+	// Package-level variables are used through their addresses, unless a
+	// closure captured them already
+	for _, name := range g.types.usedGlobals(funcStmt) {
+		if _, captured := g.variables[name]; captured {
+			continue
+		}
+		offset := g.allocateCell(name, g.types.globalTypes[name])
+		g.writeLine(fmt.Sprintf("    # Package-level variable: %s", name))
+		g.writeLine(fmt.Sprintf("    leaq %s(%%rip), %%rax", globalSymbol(name)))
+		g.writeLine(fmt.Sprintf("    movq %%rax, -%d(%%rbp)", offset))
+	}
 
-	// For functions with parameters, handle the first parameter in %rdi (Linux calling convention)
-	if len(funcStmt.Parameters) > 0 {
+	// Parameters arrive in x86ArgumentRegisters (Linux calling convention);
+	// the receiver of a method comes first and interface values take two
+	register := 0
+	for _, param := range funcParameters(funcStmt) {
+		words := g.types.words(ast.TypeName(param.Type))
+		if register+words > len(x86ArgumentRegisters) {
+			break
+		}
 		// Store parameter on stack
-		g.stackSize += 8
-		g.variables[funcStmt.Parameters[0].Name] = g.stackSize
-		g.writeLine(fmt.Sprintf("    # Parameter: %s", funcStmt.Parameters[0].Name))
-		g.writeLine(fmt.Sprintf("    movq %%rdi, -%d(%%rbp)", g.stackSize))
+		offset := g.allocate(param.Name, ast.TypeName(param.Type))
+		g.writeLine(fmt.Sprintf("    # Parameter: %s", param.Name))
+		g.writeLine(fmt.Sprintf("    movq %s, %s", x86ArgumentRegisters[register], g.operand(offset, 0)))
+		if words == 2 {
+			g.writeLine(fmt.Sprintf("    movq %s, %s", x86ArgumentRegisters[register+1], g.operand(offset, 1)))
+		}
+		register += words
+	}
+
+	// A struct or an array passed by value, including a value receiver, is
+	// copied so the function cannot change the caller's value
+	for _, param := range funcParameters(funcStmt) {
+		typeName := constant.CanonicalType(ast.TypeName(param.Type))
+		if offset, exists := g.variables[param.Name]; exists && g.types.isValueBlock(typeName) {
+			g.writeLine(fmt.Sprintf("    # Copy of %s", param.Name))
+			g.writeLine(fmt.Sprintf("    movq %s, %%rax", g.operand(offset, 0)))
+			g.copyValue(typeName)
+			g.writeLine(fmt.Sprintf("    movq %%rax, %s", g.operand(offset, 0)))
+		}
+	}
+
+	if funcStmt.Name == "main" && funcStmt.Receiver == nil {
+		g.initializeGlobals()
+	}
+
+	// Named results are local variables starting at their zero values
+	if funcStmt.HasNamedResults() {
+		for _, result := range funcStmt.Results {
+			offset := g.allocate(result.Name, ast.TypeName(result.Type))
+			g.writeLine(fmt.Sprintf("    # Named result: %s", result.Name))
+			g.generateExpressionAs(g.types.zeroValue(ast.TypeName(result.Type)), ast.TypeName(result.Type))
+			g.storeValue(offset, ast.TypeName(result.Type))
+		}
+	}
+
+	g.defers = deferFrame{}
+	if containsDefer(funcStmt.Body) {
+		g.enterDeferFrame(funcStmt)
 	}
 
 	// Generate function body
 	g.generateBlock(funcStmt.Body)
 
 	// Function epilogue only for main (other functions use explicit return)
-	if funcStmt.Name == "main" {
+	if g.defers.exitLabel != "" {
+		// Every way out of the function goes through the exit code
+		if !endsWithReturn(funcStmt.Body) {
+			g.writeLine("    jmp " + g.defers.exitLabel)
+		}
+		g.generateDeferExit(funcStmt.Name == "main")
+	} else if funcStmt.Name == "main" {
 		g.writeLine("    # Exit")
-		g.writeLine("    addq $64, %rsp") // Restore stack space
+		g.writeLine("    addq $" + frameSizePlaceholder + ", %rsp")
 		g.writeLine("    popq %rbp")      // Restore base pointer
 		g.writeLine("    movq $60, %rax") // sys_exit
 		g.writeLine("    movq $0, %rdi")  // exit status
 		g.writeLine("    syscall")        // system call
+	} else if !endsWithReturn(funcStmt.Body) {
+		// Return at the end of a function without a final return statement
+		g.generateReturnStatement(&ast.ReturnStatement{})
 	}
+
+	// The frame holds every local allocated in the body
+	patchFrameSize(&g.output, start, g.stackSize)
 	g.writeLine("")
 }
 
@@ -98,11 +216,15 @@ func (g *X86_64Generator) generateBlock(block *ast.BlockStatement) {
 }
 
 func (g *X86_64Generator) generateStatement(stmt ast.Statement) {
+	g.types.checkAssignment(stmt)
 	switch s := stmt.(type) {
 	case *ast.ExpressionStatement:
 		if callNode, ok := s.Expression.(*ast.CallNode); ok {
-			if callNode.Function == "println" && len(callNode.Arguments) > 0 {
-				g.generatePrintln(callNode.Arguments[0])
+			if callNode.Function == "println" && callNode.Receiver == nil {
+				g.generatePrintln(callNode.Arguments)
+			} else {
+				// Function and method calls run for their side effects
+				g.generateFunctionCall(callNode)
 			}
 		}
 	case *ast.AssignStatement:
@@ -110,67 +232,245 @@ func (g *X86_64Generator) generateStatement(stmt ast.Statement) {
 		if offset, exists := g.variables[s.Name]; exists {
 			// Variable reassignment
 			g.writeLine(fmt.Sprintf("    # %s = value (reassignment)", s.Name))
-			g.generateExpression(s.Value)
-			g.writeLine(fmt.Sprintf("    movq %%rax, -%d(%%rbp)", offset))
+			g.generateStoredValue(s.Value, g.types.varTypes[s.Name])
+			g.storeValue(offset, g.types.varTypes[s.Name])
 		} else {
 			// New variable assignment
-			g.stackSize += 8
-			g.variables[s.Name] = g.stackSize
+			typeName := g.types.exprType(s.Value)
+			offset := g.allocate(s.Name, typeName)
 			g.writeLine(fmt.Sprintf("    # %s := value", s.Name))
-			g.generateExpression(s.Value)
-			g.writeLine(fmt.Sprintf("    movq %%rax, -%d(%%rbp)", g.stackSize))
+			if isHidden(s.Name) {
+				g.generateExpression(s.Value)
+			} else {
+				g.generateStoredValue(s.Value, typeName)
+			}
+			g.storeValue(offset, typeName)
 		}
 	case *ast.VarStatement:
-		g.stackSize += 8
-		g.variables[s.Name] = g.stackSize
-		g.writeLine(fmt.Sprintf("    # var %s", s.Name))
-		g.generateExpression(s.Value)
-		g.writeLine(fmt.Sprintf("    movq %%rax, -%d(%%rbp)", g.stackSize))
+		g.generateVarStatement(s)
+	case *ast.TupleVarStatement:
+		g.generateTupleVar(s)
+	case *ast.DeclGroup:
+		for _, decl := range s.Decls {
+			g.generateStatement(decl)
+		}
 	case *ast.IfStatement:
 		g.generateIfStatement(s)
 	case *ast.ForStatement:
 		g.generateForStatement(s)
+	case *ast.RangeStatement:
+		g.generateRangeStatement(s)
 	case *ast.ReturnStatement:
 		g.generateReturnStatement(s)
 	case *ast.ReassignStatement:
-		// Variable reassignment
-		if offset, exists := g.variables[s.Name]; exists {
-			g.writeLine(fmt.Sprintf("    # %s = value", s.Name))
-			g.generateExpression(s.Value)
-			g.writeLine(fmt.Sprintf("    movq %%rax, -%d(%%rbp)", offset))
-		}
+		g.generateAssign(s.Target, s.Value)
+	case *ast.TupleAssignStatement:
+		g.generateTupleAssign(s)
+	case *ast.CompoundAssignStatement:
+		g.generateOpAssign(s.Target, s.Operator.BinaryOperator(), s.Value, s.Pos)
+	case *ast.IncStatement:
+		// x++ is generated as x += 1
+		g.generateOpAssign(s.Target, token.ADD, &ast.NumberNode{Value: 1}, s.Pos)
+	case *ast.DecStatement:
+		g.generateOpAssign(s.Target, token.SUB, &ast.NumberNode{Value: 1}, s.Pos)
 	case *ast.SwitchStatement:
 		g.generateSwitchStatement(s)
+	case *ast.TypeSwitchStatement:
+		g.generateTypeSwitchStatement(s)
+	case *ast.LabeledStatement:
+		g.writeLine(g.branches.gotoLabel(s.Label, g.getNewLabel) + ":")
+		g.branches.labelNext(s)
+		g.generateStatement(s.Statement)
+	case *ast.BreakStatement:
+		g.writeLine("    jmp " + g.branches.breakLabel(s.Label))
+	case *ast.ContinueStatement:
+		g.writeLine("    jmp " + g.branches.continueLabel(s.Label))
+	case *ast.GotoStatement:
+		g.writeLine("    jmp " + g.branches.gotoLabel(s.Label, g.getNewLabel))
+	case *ast.DeferStatement:
+		g.generateDeferStatement(s)
+	}
+}
+
+// allocate reserves a stack slot for a new variable of a type and returns
+// its offset. An interface value keeps its data word at the offset and its
+// type descriptor in the word above. A variable captured by a closure gets a
+// new heap cell instead, whose address is kept in the slot.
+func (g *X86_64Generator) allocate(name, typeName string) int {
+	if g.captured[name] {
+		offset := g.allocateCell(name, typeName)
+		g.writeLine(fmt.Sprintf("    movq $%d, %%r11", g.types.size(typeName)))
+		g.writeLine("    call _alloc")
+		g.writeLine(fmt.Sprintf("    movq %%r11, -%d(%%rbp)", offset))
+		return offset
 	}
+	g.stackSize += g.types.size(typeName)
+	g.variables[name] = g.stackSize
+	g.types.declare(name, typeName)
+	return g.stackSize
 }
 
-func (g *X86_64Generator) generatePrintln(arg ast.ASTNode) {
-	g.generateExpression(arg)
+// allocateCell reserves a stack slot for the address of the heap cell of a
+// variable and returns its offset
+func (g *X86_64Generator) allocateCell(name, typeName string) int {
+	g.stackSize += 8
+	g.variables[name] = g.stackSize
+	g.types.declare(name, typeName)
+	g.cells[g.stackSize] = true
+	return g.stackSize
+}
 
-	// Check argument type to determine print function
-	switch arg.(type) {
-	case *ast.StringNode:
-		g.writeLine("    # Print string in %rax")
+// operand returns the memory operand of a word of the variable at a stack
+// offset: 0 for the data word and 1 for the type descriptor of an interface
+// value. The address of a heap cell is loaded into %r11 first.
+func (g *X86_64Generator) operand(offset, word int) string {
+	if g.cells[offset] {
+		g.writeLine(fmt.Sprintf("    movq -%d(%%rbp), %%r11", offset))
+		if word == 0 {
+			return "(%r11)"
+		}
+		return fmt.Sprintf("%d(%%r11)", 8*word)
+	}
+	return fmt.Sprintf("-%d(%%rbp)", offset-8*word)
+}
+
+// storeValue stores the value in %rax in the variable at a stack offset,
+// along with the type descriptor in %rdx for an interface value
+func (g *X86_64Generator) storeValue(offset int, typeName string) {
+	g.writeLine("    movq %rax, " + g.operand(offset, 0))
+	if g.types.isInterface(typeName) {
+		g.writeLine("    movq %rdx, " + g.operand(offset, 1))
+	}
+}
+
+// loadValue loads the variable at a stack offset into %rax, along with the
+// type descriptor into %rdx for an interface value
+func (g *X86_64Generator) loadValue(offset int, typeName string) {
+	g.writeLine(fmt.Sprintf("    movq %s, %%rax", g.operand(offset, 0)))
+	if g.types.isInterface(typeName) {
+		g.writeLine(fmt.Sprintf("    movq %s, %%rdx", g.operand(offset, 1)))
+	}
+}
+
+// generatePrintln prints the arguments of println separated by spaces and
+// followed by a newline; the results of a sole call returning several
+// results are printed like arguments. The print routines end what they
+// print with the byte at print_end, a newline unless it is set to a space
+// for an argument before the last.
+func (g *X86_64Generator) generatePrintln(args []ast.ASTNode) {
+	hidden := func() string { return "." + g.getNewLabel() }
+	if assign, results, ok := g.types.spreadArguments(args, hidden); ok {
+		g.generateStatement(assign)
+		args = results
+	}
+	g.types.checkSingleValues(args)
+	if len(args) == 0 {
+		g.writeLine(fmt.Sprintf("    leaq %s(%%rip), %%rax", g.getStringLabel("")))
 		g.writeLine("    call _print_string")
-	default:
-		g.writeLine("    # Print number in %rax")
-		g.writeLine("    call _print_number")
+		return
+	}
+
+	// Every argument is evaluated before any is printed
+	if len(args) == 1 {
+		g.generateExpression(args[0])
+	} else {
+		for _, arg := range args {
+			g.generateExpression(arg)
+			g.writeLine("    pushq %rax")
+		}
+	}
+	for i, arg := range args {
+		separated := i < len(args)-1
+		if len(args) > 1 {
+			g.writeLine(fmt.Sprintf("    movq %d(%%rsp), %%rax", 8*(len(args)-1-i)))
+		}
+		if separated {
+			g.writeLine("    movb $32, print_end(%rip) # ASCII space")
+		}
+
+		// Check argument type to determine print function
+		switch g.types.exprType(arg) {
+		case "string":
+			g.writeLine("    # Print string in %rax")
+			g.writeLine("    call _print_string")
+		case "float64":
+			g.writeLine("    # Print float64 bits in %rax")
+			g.writeLine("    call _print_float")
+		default:
+			g.writeLine("    # Print number in %rax")
+			g.writeLine("    call _print_number")
+		}
+		if separated {
+			g.writeLine("    movb $10, print_end(%rip) # ASCII newline")
+		}
+	}
+	if len(args) > 1 {
+		g.writeLine(fmt.Sprintf("    addq $%d, %%rsp", 8*len(args)))
 	}
 }
 
 func (g *X86_64Generator) generateExpression(expr ast.ASTNode) {
 	switch e := expr.(type) {
 	case *ast.NumberNode:
+		if e.Value < math.MinInt32 || e.Value > math.MaxInt32 {
+			// Immediate does not fit in 32 bits
+			g.writeLine(fmt.Sprintf("    movabsq $%d, %%rax", e.Value))
+		} else {
+			g.writeLine(fmt.Sprintf("    movq $%d, %%rax", e.Value))
+		}
+	case *ast.RuneNode:
 		g.writeLine(fmt.Sprintf("    movq $%d, %%rax", e.Value))
+	case *ast.BooleanNode:
+		if e.Value {
+			g.writeLine("    movq $1, %rax")
+		} else {
+			g.writeLine("    movq $0, %rax")
+		}
+	case *ast.FloatNode:
+		// float64 values are kept as raw bits in %rax
+		g.writeLine(fmt.Sprintf("    movabsq $%d, %%rax # %v", int64(math.Float64bits(e.Value)), e.Value))
+	case *ast.ConstExpr:
+		g.generateExpression(constLiteral(e.Value))
 	case *ast.VariableNode:
 		if offset, exists := g.variables[e.Name]; exists {
-			g.writeLine(fmt.Sprintf("    movq -%d(%%rbp), %%rax", offset))
+			g.loadValue(offset, g.types.varTypes[e.Name])
+		} else if v, isConstant := g.types.constants[e.Name]; isConstant {
+			g.generateExpression(constLiteral(v))
+		} else if _, isFunction := g.types.funcType(e.Name); isFunction {
+			// A declared function used as a value
+			g.staticClosures[e.Name] = true
+			g.writeLine(fmt.Sprintf("    leaq %s(%%rip), %%rax", staticClosureSymbol(e.Name)))
+		} else if e.Name == "nil" {
+			g.writeLine("    movq $0, %rax")
 		}
+	case *ast.FuncLiteral:
+		g.generateFuncLiteral(e)
 	case *ast.StringNode:
 		// Get or create string label
 		label := g.getStringLabel(e.Value)
 		g.writeLine(fmt.Sprintf("    leaq %s(%%rip), %%rax", label))
 	case *ast.BinaryOpNode:
+		if e.Operator == token.LAND || e.Operator == token.LOR {
+			g.generateLogicalOp(e)
+			return
+		}
+		if g.types.isFloat(e.Left) || g.types.isFloat(e.Right) {
+			g.generateFloatBinaryOp(e)
+			return
+		}
+		if operand, ok := g.types.nilComparison(e); ok {
+			// An interface is nil when it has no type descriptor
+			g.generateExpression(operand)
+			g.writeLine("    testq %rdx, %rdx")
+			if e.Operator == token.EQL {
+				g.writeLine("    sete %al")
+			} else {
+				g.writeLine("    setne %al")
+			}
+			g.writeLine("    movzbq %al, %rax")
+			return
+		}
+
 		// Left operand
 		g.generateExpression(e.Left)
 		g.writeLine("    pushq %rax")
@@ -180,6 +480,15 @@ func (g *X86_64Generator) generateExpression(expr ast.ASTNode) {
 		g.writeLine("    movq %rax, %rbx")
 		g.writeLine("    popq %rax")
 
+		// Strings are compared by content
+		if g.types.isStringComparison(e) {
+			g.generateEqual("string")
+			if e.Operator == token.NEQ {
+				g.writeLine("    xorq $1, %rax")
+			}
+			return
+		}
+
 		// Operation
 		switch e.Operator {
 		case token.ADD:
@@ -189,8 +498,43 @@ func (g *X86_64Generator) generateExpression(expr ast.ASTNode) {
 		case token.MUL:
 			g.writeLine("    imulq %rbx, %rax")
 		case token.QUO:
+			g.writeLine("    testq %rbx, %rbx")
+			g.writeLine("    jz _divide_panic")
 			g.writeLine("    cqo")        // Sign extend %rax to %rdx:%rax
 			g.writeLine("    idivq %rbx") // Divide %rdx:%rax by %rbx
+		case token.REM:
+			g.writeLine("    testq %rbx, %rbx")
+			g.writeLine("    jz _divide_panic")
+			g.writeLine("    cqo")
+			g.writeLine("    idivq %rbx")
+			g.writeLine("    movq %rdx, %rax") // Remainder is left in %rdx
+		case token.AND:
+			g.writeLine("    andq %rbx, %rax")
+		case token.OR:
+			g.writeLine("    orq %rbx, %rax")
+		case token.XOR:
+			g.writeLine("    xorq %rbx, %rax")
+		case token.AND_NOT:
+			g.writeLine("    notq %rbx")
+			g.writeLine("    andq %rbx, %rax")
+		case token.SHL:
+			// shlq only uses the low 6 bits of the count; counts of 64 or
+			// more shift out all bits, and negative ones panic
+			g.writeLine("    testq %rbx, %rbx")
+			g.writeLine("    js _shift_panic")
+			g.writeLine("    movq %rbx, %rcx")
+			g.writeLine("    shlq %cl, %rax")
+			g.writeLine("    xorl %edx, %edx")
+			g.writeLine("    cmpq $64, %rbx")
+			g.writeLine("    cmovaeq %rdx, %rax")
+		case token.SHR:
+			// Counts of 64 or more are clamped to 63 to fill with the sign bit
+			g.writeLine("    testq %rbx, %rbx")
+			g.writeLine("    js _shift_panic")
+			g.writeLine("    movq $63, %rcx")
+			g.writeLine("    cmpq %rcx, %rbx")
+			g.writeLine("    cmovbq %rbx, %rcx")
+			g.writeLine("    sarq %cl, %rax")
 		case token.EQL:
 			g.writeLine("    cmpq %rbx, %rax")
 			g.writeLine("    sete %al")
@@ -216,15 +560,172 @@ func (g *X86_64Generator) generateExpression(expr ast.ASTNode) {
 			g.writeLine("    setge %al")
 			g.writeLine("    movzbq %al, %rax")
 		}
+
+		// rune and byte arithmetic wraps around
+		g.truncateInteger(g.types.exprType(e))
+	case *ast.UnaryOpNode:
+		if e.Operator == token.AND {
+			g.generateAddress(e.Operand)
+			return
+		}
+		g.generateExpression(e.Operand)
+		switch e.Operator {
+		case token.SUB:
+			if g.types.isFloat(e.Operand) {
+				g.writeLine("    btcq $63, %rax") // Flip the sign bit
+			} else {
+				g.writeLine("    negq %rax")
+			}
+		case token.NOT:
+			g.writeLine("    xorq $1, %rax")
+		case token.XOR:
+			g.writeLine("    notq %rax")
+		}
+		g.truncateInteger(g.types.exprType(e))
 	case *ast.CallNode:
 		g.generateFunctionCall(e)
+	case *ast.DerefNode:
+		g.generateDeref(e)
+	case *ast.NewNode:
+		g.generateNew(e)
 	case *ast.FieldAccessNode:
 		g.generateFieldAccess(e)
+	case *ast.StructLiteral:
+		g.generateStructLiteral(e)
 	case *ast.SliceLiteral:
-		g.generateSliceLiteral(e)
+		g.generateElements(e.TypeName(), e.Elements)
+	case *ast.ArrayLiteral:
+		g.generateElements(e.TypeName(), e.Elements)
+	case *ast.MapLiteral:
+		g.generateMapLiteral(e)
 	case *ast.IndexAccess:
+		if _, _, _, isMap := g.types.mapIndex(e); isMap {
+			g.generateMapIndex(e)
+			return
+		}
 		g.generateIndexAccess(e)
+	case *ast.SliceExpr:
+		g.generateSliceExpr(e)
+	case *ast.TypeAssertNode:
+		g.generateTypeAssert(e, false)
+	}
+}
+
+// generateExpressionAs generates an expression and converts an int result
+// to float64 when the destination type requires it. A value assigned to an
+// interface type becomes an interface value.
+func (g *X86_64Generator) generateExpressionAs(expr ast.ASTNode, typeName string) {
+	if g.types.isInterface(typeName) {
+		g.types.checkImplements(expr, typeName)
+		g.generateInterfaceValue(expr)
+		return
+	}
+	g.generateExpression(expr)
+	if typeName == "float64" && !g.types.isFloat(expr) {
+		g.writeLine("    cvtsi2sdq %rax, %xmm0")
+		g.writeLine("    movq %xmm0, %rax")
+	}
+}
+
+// generateInterfaceValue generates an interface value: the data word in
+// %rax and the address of the type descriptor of the dynamic type in %rdx.
+// nil leaves zero in both.
+func (g *X86_64Generator) generateInterfaceValue(expr ast.ASTNode) {
+	switch typeName := g.types.exprType(expr); {
+	case g.types.isNil(expr):
+		g.writeLine("    movq $0, %rax")
+		g.writeLine("    movq $0, %rdx")
+	case g.types.isInterface(typeName):
+		// Interface values share the type descriptor of their dynamic type
+		g.generateExpression(expr)
+	default:
+		g.generateExpression(expr)
+		g.writeLine(fmt.Sprintf("    leaq %s(%%rip), %%rdx # %s", g.typeDescriptor(typeName), typeName))
+	}
+}
+
+// generateLogicalOp generates && and || with short-circuit evaluation: the
+// right operand is skipped when the left one decides the result
+func (g *X86_64Generator) generateLogicalOp(e *ast.BinaryOpNode) {
+	endLabel := g.getNewLabel()
+
+	g.generateExpression(e.Left)
+	g.writeLine("    testq %rax, %rax")
+	if e.Operator == token.LAND {
+		g.writeLine("    jz " + endLabel) // false && x is false
+	} else {
+		g.writeLine("    jnz " + endLabel) // true || x is true
+	}
+
+	g.generateExpression(e.Right)
+	g.writeLine("    testq %rax, %rax")
+
+	g.writeLine(endLabel + ":")
+	g.writeLine("    setne %al")
+	g.writeLine("    movzbq %al, %rax")
+}
+
+// generateFloatBinaryOp generates SSE code for float64 arithmetic and comparison.
+// Operands are loaded into %xmm0 (left) and %xmm1 (right); int operands are converted.
+func (g *X86_64Generator) generateFloatBinaryOp(e *ast.BinaryOpNode) {
+	// Left operand
+	g.generateExpressionAs(e.Left, "float64")
+	g.writeLine("    pushq %rax")
+
+	// Right operand
+	g.generateExpressionAs(e.Right, "float64")
+	g.writeLine("    movq %rax, %xmm1")
+	g.writeLine("    popq %rax")
+	g.writeLine("    movq %rax, %xmm0")
+
+	// Operation
+	switch e.Operator {
+	case token.ADD:
+		g.writeLine("    addsd %xmm1, %xmm0")
+	case token.SUB:
+		g.writeLine("    subsd %xmm1, %xmm0")
+	case token.MUL:
+		g.writeLine("    mulsd %xmm1, %xmm0")
+	case token.QUO:
+		g.writeLine("    divsd %xmm1, %xmm0")
+	case token.EQL:
+		// Unordered (NaN) operands set PF and compare unequal
+		g.writeLine("    ucomisd %xmm1, %xmm0")
+		g.writeLine("    sete %al")
+		g.writeLine("    setnp %bl")
+		g.writeLine("    andb %bl, %al")
+		g.writeLine("    movzbq %al, %rax")
+		return
+	case token.NEQ:
+		g.writeLine("    ucomisd %xmm1, %xmm0")
+		g.writeLine("    setne %al")
+		g.writeLine("    setp %bl")
+		g.writeLine("    orb %bl, %al")
+		g.writeLine("    movzbq %al, %rax")
+		return
+	case token.LSS:
+		// a < b is evaluated as b > a so that NaN yields false
+		g.writeLine("    ucomisd %xmm0, %xmm1")
+		g.writeLine("    seta %al")
+		g.writeLine("    movzbq %al, %rax")
+		return
+	case token.LEQ:
+		g.writeLine("    ucomisd %xmm0, %xmm1")
+		g.writeLine("    setae %al")
+		g.writeLine("    movzbq %al, %rax")
+		return
+	case token.GTR:
+		g.writeLine("    ucomisd %xmm1, %xmm0")
+		g.writeLine("    seta %al")
+		g.writeLine("    movzbq %al, %rax")
+		return
+	case token.GEQ:
+		g.writeLine("    ucomisd %xmm1, %xmm0")
+		g.writeLine("    setae %al")
+		g.writeLine("    movzbq %al, %rax")
+		return
 	}
+	g.writeLine("    movq %xmm0, %rax")
 }
 
 func (g *X86_64Generator) generateIfStatement(stmt *ast.IfStatement) {
@@ -247,145 +748,1713 @@ func (g *X86_64Generator) generateIfStatement(stmt *ast.IfStatement) {
 	}
 }
 
+// generateForStatement generates a for loop. continue jumps to the update
+// statement.
 func (g *X86_64Generator) generateForStatement(stmt *ast.ForStatement) {
+	if stmt.Init != nil {
+		g.generateStatement(stmt.Init)
+	}
+
 	startLabel := g.getNewLabel()
 	endLabel := g.getNewLabel()
+	continueLabel := g.getNewLabel()
 
 	g.writeLine(startLabel + ":")
-	g.generateExpression(stmt.Condition)
-	g.writeLine("    testq %rax, %rax")
-	g.writeLine("    jz " + endLabel)
+	if stmt.Condition != nil {
+		g.generateExpression(stmt.Condition)
+		g.writeLine("    testq %rax, %rax")
+		g.writeLine("    jz " + endLabel)
+	}
 
+	g.branches.push(endLabel, continueLabel)
 	g.generateBlock(stmt.Body)
+	g.branches.pop()
 
+	g.writeLine(continueLabel + ":")
+	g.renewCells(loopVariables(stmt.Init))
+	if stmt.Update != nil {
+		g.generateStatement(stmt.Update)
+	}
 	g.writeLine("    jmp " + startLabel)
 	g.writeLine(endLabel + ":")
 }
 
-func (g *X86_64Generator) generateReturnStatement(stmt *ast.ReturnStatement) {
-	if stmt.Value != nil {
-		g.generateExpression(stmt.Value)
+// renewCells moves the captured variables among names to new heap cells,
+// which start with their current values
+func (g *X86_64Generator) renewCells(names []string) {
+	for _, name := range names {
+		if !g.captured[name] {
+			continue
+		}
+		offset := g.variables[name]
+		size := g.types.size(g.types.varTypes[name])
+		g.writeLine(fmt.Sprintf("    movq $%d, %%r11", size))
+		g.writeLine("    call _alloc")
+		g.writeLine(fmt.Sprintf("    movq -%d(%%rbp), %%rax", offset))
+		for word := 0; word < size; word += 8 {
+			g.writeLine(fmt.Sprintf("    movq %d(%%rax), %%rcx", word))
+			g.writeLine(fmt.Sprintf("    movq %%rcx, %d(%%r11)", word))
+		}
+		g.writeLine(fmt.Sprintf("    movq %%r11, -%d(%%rbp)", offset))
 	}
-	g.writeLine("    addq $64, %rsp") // Restore stack space
-	g.writeLine("    popq %rbp")      // Restore base pointer
-	g.writeLine("    ret")            // Return
 }
 
-func (g *X86_64Generator) generateSwitchStatement(stmt *ast.SwitchStatement) {
+// generateRangeStatement lowers a range loop into an index loop. The range
+// expression is kept in a hidden local along with the index, and the key and
+// value are stored in the iteration variables at the start of each
+// iteration. With := the variables get slots of their own, visible only in
+// the body, and a variable captured by a closure gets a new heap cell in
+// every iteration.
+func (g *X86_64Generator) generateRangeStatement(stmt *ast.RangeStatement) {
+	rangeType := g.types.exprType(stmt.Expression)
+	keyType, valueType := rangeTypes(rangeType)
+	startLabel := g.getNewLabel()
+	nextLabel := g.getNewLabel()
 	endLabel := g.getNewLabel()
 
-	// Generate the switch value and store it
-	g.writeLine("    # switch expression")
-	g.generateExpression(stmt.Value)
-	g.writeLine("    pushq %rax") // Store switch value on stack
+	// Hidden locals: the range expression, the index and a scratch word
+	g.writeLine(fmt.Sprintf("    # for range %s", rangeType))
+	g.generateExpression(stmt.Expression)
+	if isArrayType(rangeType) && isIterationVariable(stmt.Value) && !isNewValue(stmt.Expression) {
+		// The values come from a copy of the array
+		g.copyValue(rangeType)
+	}
+	g.stackSize += 24
+	rangeOffset := g.stackSize
+	indexOffset := rangeOffset - 8
+	scratchOffset := rangeOffset - 16
+	g.writeLine(fmt.Sprintf("    movq %%rax, -%d(%%rbp)", rangeOffset))
+	g.writeLine(fmt.Sprintf("    movq $0, -%d(%%rbp)", indexOffset))
 
-	// Generate case comparisons and labels
-	caseLabels := make([]string, len(stmt.Cases))
-	for i := range stmt.Cases {
-		caseLabels[i] = g.getNewLabel()
+	// The iteration variables are only visible inside the body
+	restore := g.shadowRangeVariables(stmt)
+
+	g.writeLine(startLabel + ":")
+	switch {
+	case isSliceType(rangeType):
+		g.writeLine(fmt.Sprintf("    movq -%d(%%rbp), %%rbx", rangeOffset))
+		g.writeLine("    testq %rbx, %rbx")
+		g.writeLine("    jz " + endLabel)
+		g.writeLine(fmt.Sprintf("    movq -%d(%%rbp), %%rax", indexOffset))
+		g.writeLine("    cmpq 8(%rbx), %rax")
+		g.writeLine("    jge " + endLabel)
+		if isIterationVariable(stmt.Value) {
+			g.writeLine("    movq (%rbx), %rbx")
+			if g.types.isInterface(valueType) {
+				g.writeLine("    salq $4, %rax")
+				g.writeLine("    addq %rbx, %rax")
+				g.writeLine("    movq 8(%rax), %rdx")
+				g.writeLine("    movq (%rax), %rax")
+			} else {
+				g.writeLine("    movq (%rbx,%rax,8), %rax")
+			}
+			g.bindRangeVariable(stmt, stmt.Value, valueType)
+		}
+	case rangeType == "string":
+		// Each iteration decodes the rune at the index and moves the index
+		// past it
+		g.writeLine(fmt.Sprintf("    movq -%d(%%rbp), %%rdi", rangeOffset))
+		g.writeLine(fmt.Sprintf("    addq -%d(%%rbp), %%rdi", indexOffset))
+		g.writeLine("    cmpb $0, (%rdi)")
+		g.writeLine("    je " + endLabel)
+		g.writeLine("    call _decode_rune")
+		g.writeLine(fmt.Sprintf("    addq -%d(%%rbp), %%rdx", indexOffset))
+		g.writeLine(fmt.Sprintf("    movq %%rdx, -%d(%%rbp)", scratchOffset))
+		g.bindRangeVariable(stmt, stmt.Value, valueType)
+	case ast.IsMapType(rangeType):
+		// The index walks the entries, skipping the ones not in use
+		g.writeLine(fmt.Sprintf("    movq -%d(%%rbp), %%rbx", rangeOffset))
+		g.writeLine("    testq %rbx, %rbx")
+		g.writeLine("    jz " + endLabel)
+		g.writeLine(fmt.Sprintf("    movq -%d(%%rbp), %%rax", indexOffset))
+		g.writeLine("    cmpq 8(%rbx), %rax")
+		g.writeLine("    jge " + endLabel)
+		g.writeLine("    salq $5, %rax")
+		g.writeLine("    addq 32(%rbx), %rax")
+		g.writeLine("    cmpq $1, (%rax)")
+		g.writeLine("    jne " + nextLabel)
+		g.writeLine(fmt.Sprintf("    movq %%rax, -%d(%%rbp)", scratchOffset))
+		if isIterationVariable(stmt.Value) {
+			g.writeLine(fmt.Sprintf("    movq -%d(%%rbp), %%rcx", scratchOffset))
+			g.writeLine("    movq 16(%rcx), %rax")
+			g.writeLine("    movq 24(%rcx), %rdx")
+			g.bindRangeVariable(stmt, stmt.Value, valueType)
+		}
+		if isIterationVariable(stmt.Key) {
+			g.writeLine(fmt.Sprintf("    movq -%d(%%rbp), %%rcx", scratchOffset))
+			g.writeLine("    movq 8(%rcx), %rax")
+			g.bindRangeVariable(stmt, stmt.Key, keyType)
+		}
+	default:
+		// for i := range n counts from 0 to n-1
+		g.writeLine(fmt.Sprintf("    movq -%d(%%rbp), %%rax", indexOffset))
+		g.writeLine(fmt.Sprintf("    cmpq -%d(%%rbp), %%rax", rangeOffset))
+		g.writeLine("    jge " + endLabel)
+	}
+	if !ast.IsMapType(rangeType) && isIterationVariable(stmt.Key) {
+		// The key is the index itself
+		g.writeLine(fmt.Sprintf("    movq -%d(%%rbp), %%rax", indexOffset))
+		g.bindRangeVariable(stmt, stmt.Key, keyType)
+	}
+
+	g.branches.push(endLabel, nextLabel)
+	g.generateBlock(stmt.Body)
+	g.branches.pop()
+
+	g.writeLine(nextLabel + ":")
+	if rangeType == "string" {
+		g.writeLine(fmt.Sprintf("    movq -%d(%%rbp), %%rax", scratchOffset))
+		g.writeLine(fmt.Sprintf("    movq %%rax, -%d(%%rbp)", indexOffset))
+	} else {
+		g.writeLine(fmt.Sprintf("    incq -%d(%%rbp)", indexOffset))
 	}
+	g.writeLine("    jmp " + startLabel)
+	g.writeLine(endLabel + ":")
+	restore()
+}
 
-	defaultLabel := g.getNewLabel()
-	if stmt.Default != nil {
-		defaultLabel = g.getNewLabel()
+// bindRangeVariable stores the value in %rax (and %rdx) in an iteration
+// variable of a range loop. With := the variable gets a new slot.
+func (g *X86_64Generator) bindRangeVariable(stmt *ast.RangeStatement, name, typeName string) {
+	if !isIterationVariable(name) {
+		return
+	}
+	if g.types.isValueBlock(typeName) {
+		g.copyValue(typeName)
 	}
+	if stmt.Define {
+		g.storeValue(g.allocate(name, typeName), typeName)
+		return
+	}
+	if offset, exists := g.variables[name]; exists {
+		g.storeValue(offset, g.types.varTypes[name])
+	}
+}
 
-	// Compare each case value
-	for i, caseStmt := range stmt.Cases {
-		g.writeLine(fmt.Sprintf("    # case %d comparison", i))
-		g.generateExpression(caseStmt.Value)
-		g.writeLine("    movq (%rsp), %rbx") // Load switch value from stack
-		g.writeLine("    cmpq %rax, %rbx")
-		g.writeLine(fmt.Sprintf("    je %s", caseLabels[i]))
+// shadowRangeVariables returns a function restoring the variables that the
+// iteration variables declared by a range loop shadow
+func (g *X86_64Generator) shadowRangeVariables(stmt *ast.RangeStatement) (restore func()) {
+	if !stmt.Define {
+		return func() {}
+	}
+	offsets := make(map[string]int)
+	types := make(map[string]string)
+	for _, name := range []string{stmt.Key, stmt.Value} {
+		if offset, exists := g.variables[name]; exists {
+			offsets[name] = offset
+			types[name] = g.types.varTypes[name]
+		}
+	}
+	return func() {
+		for _, name := range []string{stmt.Key, stmt.Value} {
+			if offset, shadowed := offsets[name]; shadowed {
+				g.variables[name] = offset
+				g.types.varTypes[name] = types[name]
+			} else {
+				delete(g.variables, name)
+				delete(g.types.varTypes, name)
+			}
+		}
 	}
+}
+
+// x86ArgumentRegisters pass the arguments of a call in order
+var x86ArgumentRegisters = []string{"%rdi", "%rsi", "%rdx", "%rcx", "%r8", "%r9"}
 
-	// If no case matched, go to default or end
-	if stmt.Default != nil {
-		g.writeLine(fmt.Sprintf("    jmp %s", defaultLabel))
+// x86ResultRegisters hold the results of a call in order, so a function
+// returns at most len(x86ResultRegisters) values. Callers store them into
+// their stack slots right after the call.
+var x86ResultRegisters = []string{"%rax", "%rdx", "%rcx", "%rsi", "%rdi", "%r8", "%r9"}
+
+// generateTypeAssert generates a type assertion x.(T). The result is left in
+// %rax, with the type descriptor in %rdx when T is an interface type, and
+// %rcx is set to 1 when the assertion holds. A failed assertion panics,
+// unless commaOk is set for v, ok := x.(T); then the result is the zero
+// value of T and %rcx is 0.
+func (g *X86_64Generator) generateTypeAssert(e *ast.TypeAssertNode, commaOk bool) {
+	okLabel := g.getNewLabel()
+	endLabel := g.getNewLabel()
+
+	g.generateExpression(e.Expression)
+	g.writeLine(fmt.Sprintf("    # Type assertion: .(%s)", e.Type))
+	g.generateTypeTest(e.Type, okLabel)
+	if commaOk {
+		g.generateExpressionAs(g.types.zeroValue(e.Type), e.Type)
+		g.writeLine("    movq $0, %rcx")
+		g.writeLine("    jmp " + endLabel)
 	} else {
-		g.writeLine(fmt.Sprintf("    jmp %s", endLabel))
+		g.writeLine("    call _type_assert_panic")
 	}
+	g.writeLine(okLabel + ":")
+	g.writeLine("    movq $1, %rcx")
+	g.writeLine(endLabel + ":")
+}
 
-	// Generate case bodies
-	for i, caseStmt := range stmt.Cases {
+// generateTypeTest jumps to label when the type descriptor in %rdx matches
+// typeName: nil matches a nil interface, a concrete type its own
+// descriptor, and an interface type every descriptor whose method table has
+// all of its methods. It falls through otherwise.
+func (g *X86_64Generator) generateTypeTest(typeName, label string) {
+	switch {
+	case typeName == "nil":
+		g.writeLine("    testq %rdx, %rdx")
+		g.writeLine("    jz " + label)
+	case g.types.isInterface(typeName):
+		nextLabel := g.getNewLabel()
+		g.writeLine("    testq %rdx, %rdx")
+		g.writeLine("    jz " + nextLabel)
+		for _, method := range g.types.interfaces[typeName] {
+			g.writeLine(fmt.Sprintf("    cmpq $0, %d(%%rdx) # %s", g.types.methodOffset(method), method))
+			g.writeLine("    je " + nextLabel)
+		}
+		g.writeLine("    jmp " + label)
+		g.writeLine(nextLabel + ":")
+	default:
+		g.writeLine(fmt.Sprintf("    leaq %s(%%rip), %%rcx", g.typeDescriptor(constant.CanonicalType(typeName))))
+		g.writeLine("    cmpq %rcx, %rdx")
+		g.writeLine("    je " + label)
+	}
+}
+
+// generateTypeSwitchStatement generates a type switch. The interface value
+// is kept in a hidden local and its type descriptor is tested against the
+// types of each case in source order. The bound variable gets a slot of its
+// own in every clause.
+func (g *X86_64Generator) generateTypeSwitchStatement(stmt *ast.TypeSwitchStatement) {
+	if stmt.Init != nil {
+		g.generateStatement(stmt.Init)
+	}
+
+	endLabel := g.getNewLabel()
+
+	g.writeLine("    # type switch expression")
+	valueType := g.types.exprType(stmt.Value)
+	g.generateInterfaceValue(stmt.Value)
+	g.stackSize += 16
+	valueOffset := g.stackSize
+	g.storeValue(valueOffset, "any")
+
+	caseLabels := make([]string, len(stmt.Cases))
+	for i := range stmt.Cases {
+		caseLabels[i] = g.getNewLabel()
+	}
+
+	// If no case matches, go to the default clause or the end
+	defaultLabel := endLabel
+	for i, clause := range stmt.Cases {
+		if clause.IsDefault() {
+			defaultLabel = caseLabels[i]
+			continue
+		}
+		g.writeLine(fmt.Sprintf("    # case %d: %s", i, strings.Join(clause.Types, ", ")))
+		g.writeLine(fmt.Sprintf("    movq -%d(%%rbp), %%rdx", valueOffset-8))
+		for _, typeName := range clause.Types {
+			g.generateTypeTest(typeName, caseLabels[i])
+		}
+	}
+	g.writeLine(fmt.Sprintf("    jmp %s", defaultLabel))
+
+	// The bound variable is only visible inside the clauses
+	previousOffset, shadowed := g.variables[stmt.Binding]
+	previousType := g.types.varTypes[stmt.Binding]
+
+	g.branches.push(endLabel, "")
+	for i, clause := range stmt.Cases {
 		g.writeLine(fmt.Sprintf("%s:", caseLabels[i]))
-		g.writeLine(fmt.Sprintf("    # case %d body", i))
-		g.generateBlock(caseStmt.Body)
+		if clause.IsDefault() {
+			g.writeLine("    # default case")
+		} else {
+			g.writeLine(fmt.Sprintf("    # case %d body", i))
+		}
+		if stmt.Binding != "" {
+			// The variable has the type of the case in clauses listing a
+			// single type, and the type of x in the others
+			bindingType := valueType
+			if len(clause.Types) == 1 && clause.Types[0] != "nil" {
+				bindingType = constant.CanonicalType(clause.Types[0])
+			}
+			offset := g.allocate(stmt.Binding, bindingType)
+			g.writeLine(fmt.Sprintf("    movq -%d(%%rbp), %%rax", valueOffset))
+			g.writeLine(fmt.Sprintf("    movq -%d(%%rbp), %%rdx", valueOffset-8))
+			g.storeValue(offset, bindingType)
+		}
+		g.generateBlock(clause.Body)
 		g.writeLine(fmt.Sprintf("    jmp %s", endLabel)) // break to end
 	}
+	g.branches.pop()
 
-	// Generate default case if exists
-	if stmt.Default != nil {
-		g.writeLine(fmt.Sprintf("%s:", defaultLabel))
-		g.writeLine("    # default case")
-		g.generateBlock(stmt.Default)
+	if stmt.Binding != "" {
+		if shadowed {
+			g.variables[stmt.Binding] = previousOffset
+			g.types.varTypes[stmt.Binding] = previousType
+		} else {
+			delete(g.variables, stmt.Binding)
+			delete(g.types.varTypes, stmt.Binding)
+		}
 	}
 
 	// End label
 	g.writeLine(fmt.Sprintf("%s:", endLabel))
-	g.writeLine("    addq $8, %rsp") // Restore stack (remove stored switch value)
 }
 
-func (g *X86_64Generator) generateFunctionCall(call *ast.CallNode) {
-	if len(call.Arguments) > 0 {
-		g.generateExpression(call.Arguments[0])
-		g.writeLine("    movq %rax, %rdi") // First argument goes to %rdi
+func (g *X86_64Generator) generateReturnStatement(stmt *ast.ReturnStatement) {
+	if g.defers.exitLabel != "" {
+		g.generateDeferReturn(stmt)
+		return
 	}
-	g.writeLine(fmt.Sprintf("    call _%s", call.Function))
-}
 
-func (g *X86_64Generator) generateFieldAccess(node *ast.FieldAccessNode) {
-	g.writeLine("    # Field access: obj.field")
-	g.generateExpression(node.Object)
-	fieldOffset := getFieldOffset(node.Field)
-	g.writeLine(fmt.Sprintf("    # Access field '%s' at offset %d", node.Field, fieldOffset))
-	g.writeLine(fmt.Sprintf("    movq %d(%%rax), %%rax", fieldOffset))
+	values := g.types.returnValues(stmt)
+	switch {
+	case len(values) == 1 && g.types.isMultiValueCall(values[0]):
+		// return f() passes the result registers of f through
+		g.generateExpression(values[0])
+	case len(values) == 1:
+		g.generateStoredValue(values[0], g.types.resultType(0))
+	case len(values) > 1:
+		// Evaluate every result before filling the result registers
+		if len(values) > len(x86ResultRegisters) {
+			values = values[:len(x86ResultRegisters)]
+		}
+		for i, value := range values {
+			g.generateStoredValue(value, g.types.resultType(i))
+			g.writeLine("    pushq %rax")
+		}
+		for i := len(values) - 1; i >= 0; i-- {
+			g.writeLine("    popq " + x86ResultRegisters[i])
+		}
+	}
+	g.writeLine("    addq $" + frameSizePlaceholder + ", %rsp")
+	g.writeLine("    popq %rbp") // Restore base pointer
+	g.writeLine("    ret")       // Return
 }
 
-func (g *X86_64Generator) generateSliceLiteral(node *ast.SliceLiteral) {
-	g.writeLine("    # Slice literal creation")
-	elementCount := len(node.Elements)
-	g.writeLine(fmt.Sprintf("    movq $%d, %%rax", elementCount))
+// enterDeferFrame prepares a function with defer statements: its results
+// are kept in its named results or in hidden variables starting at their
+// zero values, which a recovered panic returns
+func (g *X86_64Generator) enterDeferFrame(funcStmt *ast.FuncStatement) {
+	g.defers.exitLabel = g.getNewLabel()
+	for _, result := range funcStmt.Results {
+		name := result.Name
+		if !funcStmt.HasNamedResults() {
+			name = "." + g.getNewLabel()
+			offset := g.allocate(name, ast.TypeName(result.Type))
+			g.writeLine(fmt.Sprintf("    # Result: %s", name))
+			g.generateExpressionAs(g.types.zeroValue(ast.TypeName(result.Type)), ast.TypeName(result.Type))
+			g.storeValue(offset, ast.TypeName(result.Type))
+		}
+		g.defers.results = append(g.defers.results, name)
+	}
 }
 
-func (g *X86_64Generator) generateIndexAccess(node *ast.IndexAccess) {
-	g.writeLine("    # Index access: arr[index]")
-	g.generateExpression(node.Object)
-	g.writeLine("    pushq %rax") // Store array base address
-	g.generateExpression(node.Index)
-	g.writeLine("    popq %rbx")         // Load array base address
-	g.writeLine("    salq $3, %rax")     // %rax = index * 8
-	g.writeLine("    addq %rbx, %rax")   // %rax = base + offset
-	g.writeLine("    movq (%rax), %rax") // Load value at address
-}
+// generateDeferReturn generates a return statement of a function with defer
+// statements, which stores the results and jumps to the exit code
+func (g *X86_64Generator) generateDeferReturn(stmt *ast.ReturnStatement) {
+	results := g.defers.results
+	if len(stmt.Values) == 1 && g.types.isMultiValueCall(stmt.Values[0]) {
+		// return f() stores the result registers of f, one word each
+		g.generateExpression(stmt.Values[0])
+		if len(results) > len(x86ResultRegisters) {
+			results = results[:len(x86ResultRegisters)]
+		}
+		for i := range results {
+			g.writeLine("    pushq " + x86ResultRegisters[i])
+		}
+		for i := len(results) - 1; i >= 0; i-- {
+			g.writeLine("    popq %rax")
+			g.storeValue(g.variables[results[i]], g.types.resultType(i))
+		}
+		g.writeLine("    jmp " + g.defers.exitLabel)
+		return
+	}
 
-func (g *X86_64Generator) getNewLabel() string {
-	g.labelNum++
-	return fmt.Sprintf("L%d", g.labelNum)
+	// Evaluate every result before storing any, as in return b, a. A bare
+	// return leaves the named results as they are.
+	values := stmt.Values
+	if len(values) > len(results) {
+		values = values[:len(results)]
+	}
+	for i, value := range values {
+		typeName := g.types.resultType(i)
+		g.generateStoredValue(value, typeName)
+		g.writeLine("    pushq %rax")
+		if g.types.isInterface(typeName) {
+			g.writeLine("    pushq %rdx")
+		}
+	}
+	for i := len(values) - 1; i >= 0; i-- {
+		typeName := g.types.resultType(i)
+		if g.types.isInterface(typeName) {
+			g.writeLine("    popq %rdx")
+		}
+		g.writeLine("    popq %rax")
+		g.storeValue(g.variables[results[i]], typeName)
+	}
+	g.writeLine("    jmp " + g.defers.exitLabel)
 }
 
-func (g *X86_64Generator) getStringLabel(value string) string {
-	if label, exists := g.stringLiterals[value]; exists {
-		return label
+// generateDeferExit generates the exit code of a function with defer
+// statements: it runs the deferred calls and returns the results, or exits
+// for main
+func (g *X86_64Generator) generateDeferExit(main bool) {
+	g.writeLine(g.defers.exitLabel + ":")
+	g.writeLine("    # Exit code: a recovered panic resumes here")
+	g.writeLine("    leaq -" + frameSizePlaceholder + "(%rbp), %rsp")
+	g.writeLine("    movq %rbp, %rdi")
+	g.writeLine("    call _run_defers")
+
+	if main {
+		g.writeLine("    # Exit")
+		g.writeLine("    addq $" + frameSizePlaceholder + ", %rsp")
+		g.writeLine("    popq %rbp")
+		g.writeLine("    movq $60, %rax") // sys_exit
+		g.writeLine("    movq $0, %rdi")  // exit status
+		g.writeLine("    syscall")
+		return
 	}
 
-	label := fmt.Sprintf("str_%d", g.stringCount)
-	g.stringLiterals[value] = label
-	g.stringCount++
-	return label
+	results := g.defers.results
+	switch {
+	case len(results) == 1:
+		g.loadValue(g.variables[results[0]], g.types.resultType(0))
+	case len(results) > 1:
+		if len(results) > len(x86ResultRegisters) {
+			results = results[:len(x86ResultRegisters)]
+		}
+		for i, name := range results {
+			g.loadValue(g.variables[name], g.types.resultType(i))
+			g.writeLine("    pushq %rax")
+		}
+		for i := len(results) - 1; i >= 0; i-- {
+			g.writeLine("    popq " + x86ResultRegisters[i])
+		}
+	}
+	g.writeLine("    addq $" + frameSizePlaceholder + ", %rsp")
+	g.writeLine("    popq %rbp")
+	g.writeLine("    ret")
 }
 
-func (g *X86_64Generator) generateStringLiterals() {
-	if len(g.stringLiterals) == 0 {
+// generateDeferStatement evaluates the function value and the arguments of
+// a deferred call into a defer record and pushes it onto the defer chain
+func (g *X86_64Generator) generateDeferStatement(s *ast.DeferStatement) {
+	call := s.Call
+	if g.types.isRecover(call) {
+		// A deferred recover() is not called by a deferred function, so
+		// it never recovers
+		g.writeLine("    # defer recover(): nothing to do")
 		return
 	}
+	if g.types.isBuiltinCall(call) {
+		hidden := make([]string, len(call.Arguments))
+		for i, arg := range call.Arguments {
+			hidden[i] = "." + g.getNewLabel()
+			g.captured[hidden[i]] = true
+			g.generateStatement(&ast.AssignStatement{Name: hidden[i], Value: arg, Pos: s.Pos})
+		}
+		call = &ast.CallNode{Callee: deferredBuiltin(call, hidden), Pos: call.Pos}
+	}
 
-	g.writeLine("")
-	g.writeLine(".section .rodata")
+	call = g.types.fieldCall(call)
+	symbol := g.types.callSymbol(call)
+	args := callArguments(call)
+	words := 0
+	for i := range args {
+		n := g.types.words(g.types.paramType(symbol, i))
+		if words+n > len(x86ArgumentRegisters) {
+			args = args[:i]
+			break
+		}
+		words += n
+	}
+
+	g.writeLine(fmt.Sprintf("    # defer %s", symbol))
+	dynamic := g.types.isDynamicCall(call)
+	switch {
+	case dynamic:
+		// The method of the receiver's type descriptor is the code of the
+		// closure, and its data word the receiver
+		g.generateExpression(args[0])
+		g.writeLine("    testq %rdx, %rdx")
+		g.writeLine("    jz _nil_panic")
+		g.writeLine(fmt.Sprintf("    movq %d(%%rdx), %%rcx", g.types.methodOffset(call.Function)))
+		g.writeLine("    pushq %rcx")
+		g.writeLine("    pushq %rax")
+		g.pushArguments(symbol, args, 1)
+	case ast.IsFuncType(symbol):
+		g.generateExpression(callee(call))
+		g.writeLine("    pushq %rax")
+		g.pushArguments(symbol, args, 0)
+	default:
+		g.staticClosures[symbol] = true
+		g.writeLine(fmt.Sprintf("    leaq %s(%%rip), %%rax", staticClosureSymbol(symbol)))
+		g.writeLine("    pushq %rax")
+		g.pushArguments(symbol, args, 0)
+	}
+
+	g.writeLine(fmt.Sprintf("    movq $%d, %%r11", 8*(deferRecordWords+len(x86ArgumentRegisters))))
+	g.writeLine("    call _alloc")
+	for i := words - 1; i >= 0; i-- {
+		g.writeLine("    popq %rax")
+		g.writeLine(fmt.Sprintf("    movq %%rax, %d(%%r11)", 8*(deferRecordWords+i)))
+	}
+	g.writeLine("    popq %rax")
+	if dynamic {
+		g.writeLine("    movq %rax, 32(%r11) # code")
+		g.writeLine("    leaq 32(%r11), %rax")
+	}
+	g.writeLine("    movq %rax, 24(%r11) # closure")
+	g.writeLine("    movq %rbp, 8(%r11)  # frame")
+	g.writeLine(fmt.Sprintf("    leaq %s(%%rip), %%rax", g.defers.exitLabel))
+	g.writeLine("    movq %rax, 16(%r11) # resume")
+	g.writeLine("    movq defer_top(%rip), %rax")
+	g.writeLine("    movq %rax, (%r11)   # next")
+	g.writeLine("    movq %r11, defer_top(%rip)")
+}
+
+// generatePanicBuiltin generates panic(v) and recover(), and reports
+// whether call is one of them
+func (g *X86_64Generator) generatePanicBuiltin(call *ast.CallNode) bool {
+	switch {
+	case call.Function == "panic" && len(call.Arguments) == 1 && g.types.isBuiltinCall(call):
+		g.writeLine("    # panic(v)")
+		g.generateInterfaceValue(call.Arguments[0])
+		g.writeLine("    call _panic")
+	case g.types.isRecover(call):
+		// _recover tells a deferred call run by _panic by its return address
+		g.writeLine("    # recover()")
+		g.writeLine("    movq 8(%rbp), %rdi")
+		g.writeLine("    call _recover")
+	default:
+		return false
+	}
+	return true
+}
+
+// generateVarStatement declares a variable, initialized with its value or
+// with the zero value of its type
+func (g *X86_64Generator) generateVarStatement(s *ast.VarStatement) {
+	typeName := ast.TypeName(s.Type)
+	if typeName == "" {
+		typeName = g.types.exprType(s.Value)
+	}
+	offset := g.allocate(s.Name, typeName)
+	g.writeLine(fmt.Sprintf("    # var %s", s.Name))
+	if s.Value == nil {
+		g.generateZeroValue(typeName)
+	} else {
+		g.generateStoredValue(s.Value, typeName)
+	}
+	g.storeValue(offset, typeName)
+}
+
+// initializeGlobals assigns the package-level variables their values, or
+// the zero values that are not zero bytes, in declaration order
+func (g *X86_64Generator) initializeGlobals() {
+	for _, decl := range g.types.globalDecls {
+		switch s := decl.(type) {
+		case *ast.VarStatement:
+			g.initializeGlobal(s.Name, s.Value)
+		case *ast.TupleVarStatement:
+			if s.Values != nil {
+				g.generateTupleAssign(&ast.TupleAssignStatement{Targets: variableTargets(s.Names, s.Pos), Values: s.Values, Pos: s.Pos})
+				continue
+			}
+			for _, name := range s.Names {
+				g.initializeGlobal(name, nil)
+			}
+		}
+	}
+}
+
+// initializeGlobal assigns a package-level variable its value, or its zero
+// value when value is nil
+func (g *X86_64Generator) initializeGlobal(name string, value ast.ASTNode) {
+	offset, exists := g.variables[name]
+	if !exists {
+		// The blank identifier only evaluates the value
+		if value != nil {
+			g.generateExpression(value)
+		}
+		return
+	}
+	typeName := g.types.globalTypes[name]
+	g.writeLine(fmt.Sprintf("    # var %s", name))
+	switch {
+	case value != nil:
+		g.generateStoredValue(value, typeName)
+	case g.types.needsZeroing(typeName):
+		g.generateZeroValue(typeName)
+	default:
+		return
+	}
+	g.storeValue(offset, typeName)
+}
+
+// generateTupleVar declares several variables at once. Their values are
+// assigned like those of a tuple assignment, so the results of a call and
+// the comma-ok forms work the same way.
+func (g *X86_64Generator) generateTupleVar(s *ast.TupleVarStatement) {
+	if s.Values == nil {
+		for _, name := range s.Names {
+			if name != "_" {
+				g.generateVarStatement(&ast.VarStatement{Name: name, Type: s.Type, Pos: s.Pos})
+			}
+		}
+		return
+	}
+	assign := &ast.TupleAssignStatement{Targets: variableTargets(s.Names, s.Pos), Define: true, Values: s.Values, Pos: s.Pos}
+	for i, name := range s.Names {
+		if name == "_" {
+			continue
+		}
+		typeName := ast.TypeName(s.Type)
+		if typeName == "" {
+			typeName = g.types.tupleValueType(assign, i)
+		}
+		g.allocate(name, typeName)
+	}
+	g.generateTupleAssign(assign)
+}
+
+// generateZeroValue leaves the zero value of a type in %rax (and %rdx). The
+// zero value of a struct is a new struct on the heap with all fields zero,
+// and that of an array a new array of zero elements.
+func (g *X86_64Generator) generateZeroValue(typeName string) {
+	if isArrayType(typeName) {
+		g.generateElements(typeName, nil)
+		return
+	}
+	if !g.types.isStruct(typeName) {
+		g.generateExpressionAs(g.types.zeroValue(typeName), typeName)
+		return
+	}
+	g.writeLine(fmt.Sprintf("    # Zero value: %s", typeName))
+	g.writeLine(fmt.Sprintf("    movq $%d, %%r11", g.types.structSize(typeName)))
+	g.writeLine("    call _alloc")
+	g.writeLine("    movq %r11, %rax")
+	if fields := g.types.blockFields(typeName, nil); len(fields) > 0 {
+		g.writeLine("    pushq %rax")
+		g.generateZeroFields(typeName, fields)
+		g.writeLine("    popq %rax")
+	}
+}
+
+// generateZeroFields stores the zero values of fields of a struct in its
+// block on top of the stack
+func (g *X86_64Generator) generateZeroFields(typeName string, fields []string) {
+	for _, name := range fields {
+		g.generateZeroValue(constant.CanonicalType(ast.TypeName(g.types.field(typeName, name).Type)))
+		g.writeLine("    movq (%rsp), %r11")
+		g.writeLine(fmt.Sprintf("    movq %%rax, %d(%%r11) # %s", g.types.fieldOffset(typeName, name), name))
+	}
+}
+
+// generateStoredValue generates a value stored as a value of a type, in
+// %rax (and %rdx), copying a struct or an array that is not new
+func (g *X86_64Generator) generateStoredValue(expr ast.ASTNode, typeName string) {
+	g.generateExpressionAs(expr, typeName)
+	if copyType := g.types.storedCopyType(expr, typeName); copyType != "" {
+		g.copyValue(copyType)
+	}
+}
+
+// copyValue replaces the struct or array in %rax by a copy of it
+func (g *X86_64Generator) copyValue(typeName string) {
+	g.copies[typeName] = true
+	g.writeLine("    call " + copySymbol(typeName))
+}
+
+// generateCopyRoutines generates the routines copying the types in
+// g.copies, and those of their fields and elements
+func (g *X86_64Generator) generateCopyRoutines() {
+	done := make(map[string]bool)
+	for typeNames := pendingCopies(g.copies, done); len(typeNames) > 0; typeNames = pendingCopies(g.copies, done) {
+		for _, typeName := range typeNames {
+			done[typeName] = true
+			g.generateCopyRoutine(typeName)
+		}
+	}
+}
+
+// generateCopyRoutine generates the routine copying the struct or array in
+// %rax to a new block returned in %rax. It changes %rcx, %r9, %r10 and %r11.
+func (g *X86_64Generator) generateCopyRoutine(typeName string) {
+	g.writeLine("")
+	g.writeLine(copySymbol(typeName) + ":")
+	if !isArrayType(typeName) {
+		g.writeLine(fmt.Sprintf("    movq $%d, %%r9", g.types.structSize(typeName)))
+		g.writeLine("    call _copy_struct")
+		if fields := g.types.blockFields(typeName, nil); len(fields) > 0 {
+			g.writeLine("    pushq %rax")
+			for _, name := range fields {
+				offset := g.types.fieldOffset(typeName, name)
+				g.writeLine("    movq (%rsp), %rax")
+				g.writeLine(fmt.Sprintf("    movq %d(%%rax), %%rax # %s", offset, name))
+				g.copyValue(constant.CanonicalType(ast.TypeName(g.types.field(typeName, name).Type)))
+				g.writeLine("    movq (%rsp), %r11")
+				g.writeLine(fmt.Sprintf("    movq %%rax, %d(%%r11)", offset))
+			}
+			g.writeLine("    popq %rax")
+		}
+		g.writeLine("    ret")
+		return
+	}
+
+	// An array gets a new header and a copy of its elements
+	elementType := constant.CanonicalType(sliceElementType(typeName))
+	length := arrayLength(typeName)
+	g.writeLine("    movq (%rax), %rax")
+	g.writeLine(fmt.Sprintf("    movq $%d, %%r9", length*g.types.size(elementType)))
+	g.writeLine("    call _copy_struct")
+	g.writeLine(fmt.Sprintf("    movq $%d, %%r11", sliceHeaderSize))
+	g.writeLine("    call _alloc")
+	g.writeLine("    movq %rax, (%r11)")
+	g.writeLine(fmt.Sprintf("    movq $%d, 8(%%r11)", length))
+	g.writeLine(fmt.Sprintf("    movq $%d, 16(%%r11)", length))
+	g.writeLine("    movq %r11, %rax")
+	if g.types.isValueBlock(elementType) {
+		// The header and the index of the element being copied are kept on
+		// the stack
+		loopLabel := g.getNewLabel()
+		doneLabel := g.getNewLabel()
+		g.writeLine("    pushq %rax")
+		g.writeLine("    pushq $0")
+		g.writeLine(loopLabel + ":")
+		g.writeLine("    movq (%rsp), %rcx")
+		g.writeLine(fmt.Sprintf("    cmpq $%d, %%rcx", length))
+		g.writeLine("    jge " + doneLabel)
+		g.writeLine("    movq 8(%rsp), %rax")
+		g.writeLine("    movq (%rax), %rax")
+		g.writeLine("    movq (%rax,%rcx,8), %rax")
+		g.copyValue(elementType)
+		g.writeLine("    movq (%rsp), %rcx")
+		g.writeLine("    movq 8(%rsp), %r11")
+		g.writeLine("    movq (%r11), %r11")
+		g.writeLine("    movq %rax, (%r11,%rcx,8)")
+		g.writeLine("    incq (%rsp)")
+		g.writeLine("    jmp " + loopLabel)
+		g.writeLine(doneLabel + ":")
+		g.writeLine("    addq $8, %rsp")
+		g.writeLine("    popq %rax")
+	}
+	g.writeLine("    ret")
+}
+
+// generateTupleAssign assigns several values at once. The results of a call
+// are stored straight from the result registers; other values are all
+// pushed before any variable is stored, so a, b = b, a swaps them. Targets
+// that are not all variables are assigned through hidden variables.
+func (g *X86_64Generator) generateTupleAssign(s *ast.TupleAssignStatement) {
+	if !assignsVariables(s) {
+		hidden := func() string { return "." + g.getNewLabel() }
+		for _, stmt := range lowerTupleAssign(s, hidden) {
+			g.generateStatement(stmt)
+		}
+		return
+	}
+	names := s.Names()
+	g.writeLine(fmt.Sprintf("    # %s %s values", strings.Join(names, ", "), assignOperator(s)))
+	if assert, ok := commaOkAssertion(s); ok {
+		// v, ok := x.(T) leaves the result in %rax (and %rdx) and ok in %rcx
+		g.generateTypeAssert(assert, true)
+		if offset, ok := g.tupleSlot(s, 0); ok {
+			g.storeValue(offset, g.types.varTypes[names[0]])
+		}
+		if offset, ok := g.tupleSlot(s, 1); ok {
+			g.writeLine("    movq %rcx, " + g.operand(offset, 0))
+		}
+		return
+	}
+	if index, ok := g.types.commaOkMapIndex(s); ok {
+		// v, ok := m[k] leaves the value in %rax (and %rdx) and ok in %rcx
+		g.generateMapIndex(index)
+		if offset, ok := g.tupleSlot(s, 0); ok {
+			g.storeValue(offset, g.types.varTypes[names[0]])
+		}
+		if offset, ok := g.tupleSlot(s, 1); ok {
+			g.writeLine("    movq %rcx, " + g.operand(offset, 0))
+		}
+		return
+	}
+	if len(s.Values) == 1 {
+		g.generateExpression(s.Values[0])
+		for i, name := range names {
+			if offset, ok := g.tupleSlot(s, i); ok && i < len(x86ResultRegisters) {
+				g.writeLine(fmt.Sprintf("    movq %s, %s", x86ResultRegisters[i], g.operand(offset, 0)))
+			} else if ok {
+				g.writeLine(fmt.Sprintf("    # %s: no result register", name))
+			}
+		}
+		return
+	}
+
+	for i, value := range s.Values {
+		g.generateStoredValue(value, g.types.varTypes[names[i]])
+		g.writeLine("    pushq %rax")
+	}
+	for i := len(names) - 1; i >= 0; i-- {
+		g.writeLine("    popq %rax")
+		if offset, ok := g.tupleSlot(s, i); ok {
+			g.writeLine("    movq %rax, " + g.operand(offset, 0))
+		}
+	}
+}
+
+// tupleSlot returns the stack offset of the i-th variable of a tuple
+// assignment, allocating it when := declares a new variable. It reports
+// false for the blank identifier and unknown variables.
+func (g *X86_64Generator) tupleSlot(s *ast.TupleAssignStatement, i int) (int, bool) {
+	name := s.Names()[i]
+	if name == "_" {
+		return 0, false
+	}
+	if offset, exists := g.variables[name]; exists {
+		return offset, true
+	}
+	if !s.Define {
+		return 0, false
+	}
+	return g.allocate(name, g.types.tupleValueType(s, i)), true
+}
+
+func (g *X86_64Generator) generateSwitchStatement(stmt *ast.SwitchStatement) {
+	if stmt.Init != nil {
+		g.generateStatement(stmt.Init)
+	}
+
+	endLabel := g.getNewLabel()
+
+	// Generate the switch value and store it in a hidden local, so that
+	// returns from the case bodies leave the stack balanced. A tagless
+	// switch compares the cases with true.
+	valueType := "bool"
+	g.writeLine("    # switch expression")
+	if stmt.Value != nil {
+		valueType = g.types.exprType(stmt.Value)
+		g.generateExpression(stmt.Value)
+	} else {
+		g.writeLine("    movq $1, %rax")
+	}
+	g.stackSize += 8
+	valueOffset := g.stackSize
+	g.writeLine(fmt.Sprintf("    movq %%rax, -%d(%%rbp)", valueOffset))
+
+	// Generate case comparisons and labels
+	caseLabels := make([]string, len(stmt.Cases))
+	for i := range stmt.Cases {
+		caseLabels[i] = g.getNewLabel()
+	}
+
+	// If no case matches, go to the default clause or the end
+	defaultLabel := endLabel
+
+	// Compare each case value in source order
+	for i, caseStmt := range stmt.Cases {
+		if caseStmt.IsDefault() {
+			defaultLabel = caseLabels[i]
+			continue
+		}
+		for _, value := range caseStmt.Values {
+			g.writeLine(fmt.Sprintf("    # case %d comparison", i))
+			g.generateExpressionAs(value, valueType)
+			switch valueType {
+			case "string", "float64":
+				g.writeLine("    movq %rax, %rbx")
+				g.writeLine(fmt.Sprintf("    movq -%d(%%rbp), %%rax", valueOffset))
+				g.generateEqual(valueType)
+				g.writeLine("    testq %rax, %rax")
+				g.writeLine(fmt.Sprintf("    jnz %s", caseLabels[i]))
+			default:
+				g.writeLine(fmt.Sprintf("    movq -%d(%%rbp), %%rbx", valueOffset)) // Load switch value
+				g.writeLine("    cmpq %rax, %rbx")
+				g.writeLine(fmt.Sprintf("    je %s", caseLabels[i]))
+			}
+		}
+	}
+	g.writeLine(fmt.Sprintf("    jmp %s", defaultLabel))
+
+	// Generate case bodies in source order, so that fallthrough just
+	// continues into the next body
+	g.branches.push(endLabel, "")
+	for i, caseStmt := range stmt.Cases {
+		g.writeLine(fmt.Sprintf("%s:", caseLabels[i]))
+		if caseStmt.IsDefault() {
+			g.writeLine("    # default case")
+		} else {
+			g.writeLine(fmt.Sprintf("    # case %d body", i))
+		}
+		g.generateBlock(caseStmt.Body)
+		if !caseStmt.Fallthrough() {
+			g.writeLine(fmt.Sprintf("    jmp %s", endLabel)) // break to end
+		}
+	}
+	g.branches.pop()
+
+	// End label
+	g.writeLine(fmt.Sprintf("%s:", endLabel))
+}
+
+// generateEqual compares the values of type typeName in %rax and %rbx and
+// leaves 1 in %rax if they are equal, 0 otherwise
+func (g *X86_64Generator) generateEqual(typeName string) {
+	switch typeName {
+	case "string":
+		g.writeLine("    call _string_equal")
+	case "float64":
+		// Unordered (NaN) operands set PF and compare unequal
+		g.writeLine("    movq %rax, %xmm0")
+		g.writeLine("    movq %rbx, %xmm1")
+		g.writeLine("    ucomisd %xmm1, %xmm0")
+		g.writeLine("    sete %al")
+		g.writeLine("    setnp %bl")
+		g.writeLine("    andb %bl, %al")
+		g.writeLine("    movzbq %al, %rax")
+	default:
+		g.writeLine("    cmpq %rbx, %rax")
+		g.writeLine("    sete %al")
+		g.writeLine("    movzbq %al, %rax")
+	}
+}
+
+func (g *X86_64Generator) generateFunctionCall(call *ast.CallNode) {
+	// f(g()) passes the results of g as the arguments of f
+	if !isConversion(call) && !g.types.isBuiltinCall(call) {
+		hidden := func() string { return "." + g.getNewLabel() }
+		if assign, results, ok := g.types.spreadArguments(call.Arguments, hidden); ok {
+			g.generateStatement(assign)
+			spread := *call
+			spread.Arguments = results
+			call = &spread
+		}
+	}
+	g.types.checkSingleValues(call.Arguments)
+
+	// Conversions between int, int32 (rune) and float64
+	if isConversion(call) {
+		arg := call.Arguments[0]
+		g.generateExpression(arg)
+		if call.Function == "float64" {
+			if !g.types.isFloat(arg) {
+				g.writeLine("    cvtsi2sdq %rax, %xmm0")
+				g.writeLine("    movq %xmm0, %rax")
+			}
+			return
+		}
+		if g.types.isFloat(arg) {
+			g.writeLine("    movq %rax, %xmm0")
+			g.writeLine("    cvttsd2si %xmm0, %rax") // Truncate toward zero
+		}
+		g.truncateInteger(constant.CanonicalType(call.Function))
+		return
+	}
+
+	if g.generateMapBuiltin(call) || g.generateSliceBuiltin(call) || g.generatePanicBuiltin(call) {
+		return
+	}
+
+	call = g.types.fieldCall(call)
+	symbol := g.types.callSymbol(call)
+	args := callArguments(call)
+
+	// Interface values take two argument registers
+	words := 0
+	for i := range args {
+		n := g.types.words(g.types.paramType(symbol, i))
+		if words+n > len(x86ArgumentRegisters) {
+			args = args[:i]
+			break
+		}
+		words += n
+	}
+
+	if g.types.isDynamicCall(call) {
+		// The method is looked up in the method table of the receiver's
+		// type descriptor, and its data word becomes the receiver. Calling
+		// a method of a nil interface value panics.
+		g.writeLine(fmt.Sprintf("    # Dynamic call: %s", symbol))
+		g.generateExpression(args[0])
+		g.writeLine("    testq %rdx, %rdx")
+		g.writeLine("    jz _nil_panic")
+		g.writeLine("    pushq %rdx")
+		g.writeLine("    pushq %rax")
+		g.pushArguments(symbol, args, 1)
+		g.popArguments(words)
+		g.writeLine("    popq %r11")
+		g.writeLine(fmt.Sprintf("    call *%d(%%r11)", g.types.methodOffset(call.Function)))
+		return
+	}
+
+	if ast.IsFuncType(symbol) {
+		// The closure goes to %r10 and its first word is the code address
+		g.writeLine(fmt.Sprintf("    # Closure call: %s", symbol))
+		g.generateExpression(callee(call))
+		g.writeLine("    pushq %rax")
+		g.pushArguments(symbol, args, 0)
+		g.popArguments(words)
+		g.writeLine("    popq %r10")
+		g.writeLine("    call *(%r10)")
+		return
+	}
+
+	switch {
+	case words == 1:
+		g.generateExpressionAs(args[0], g.types.paramType(symbol, 0))
+		g.writeLine("    movq %rax, %rdi") // First argument goes to %rdi
+	case words > 1:
+		// Evaluate every argument before filling the argument registers
+		g.pushArguments(symbol, args, 0)
+		g.popArguments(words)
+	}
+	g.writeLine(fmt.Sprintf("    call _%s", symbol))
+}
+
+// pushArguments evaluates the arguments of a call from index start on and
+// pushes their words: the data word and then the type descriptor of an
+// interface value
+func (g *X86_64Generator) pushArguments(symbol string, args []ast.ASTNode, start int) {
+	for i := start; i < len(args); i++ {
+		paramType := g.types.paramType(symbol, i)
+		g.generateExpressionAs(args[i], paramType)
+		g.writeLine("    pushq %rax")
+		if g.types.isInterface(paramType) {
+			g.writeLine("    pushq %rdx")
+		}
+	}
+}
+
+// popArguments pops the pushed words of the arguments into the argument registers
+func (g *X86_64Generator) popArguments(words int) {
+	for i := words - 1; i >= 0; i-- {
+		g.writeLine("    popq " + x86ArgumentRegisters[i])
+	}
+}
+
+// generateFuncLiteral creates the closure of a function literal in %rax: a
+// heap block holding the code address followed by the heap cells of the
+// captured variables. The function itself is generated later.
+func (g *X86_64Generator) generateFuncLiteral(lit *ast.FuncLiteral) {
+	g.literals++
+	c := &closure{symbol: closureSymbol(g.function, g.literals), literal: lit}
+	for _, name := range freeVariables(lit) {
+		if _, exists := g.variables[name]; exists {
+			c.captures = append(c.captures, name)
+			c.types = append(c.types, g.types.varTypes[name])
+		}
+	}
+	g.closures = append(g.closures, c)
+
+	g.writeLine(fmt.Sprintf("    # Closure: %s", c.symbol))
+	g.writeLine(fmt.Sprintf("    movq $%d, %%r11", 8*(len(c.captures)+1)))
+	g.writeLine("    call _alloc")
+	g.writeLine(fmt.Sprintf("    leaq _%s(%%rip), %%rax", c.symbol))
+	g.writeLine("    movq %rax, (%r11)")
+	for i, name := range c.captures {
+		g.writeLine(fmt.Sprintf("    movq -%d(%%rbp), %%rax # %s", g.variables[name], name))
+		g.writeLine(fmt.Sprintf("    movq %%rax, %d(%%r11)", 8*(i+1)))
+	}
+	g.writeLine("    movq %r11, %rax")
+}
+
+func (g *X86_64Generator) generateFieldAccess(node *ast.FieldAccessNode) {
+	g.writeLine("    # Field access: obj.field")
+	g.generateExpression(node.Object)
+	objectType := g.types.exprType(node.Object)
+	if ast.IsPointerType(objectType) {
+		g.generateNilCheck()
+	}
+	fieldOffset := g.types.fieldOffset(objectType, node.Field)
+	g.writeLine(fmt.Sprintf("    # Access field '%s' at offset %d", node.Field, fieldOffset))
+	if g.types.isInterface(g.types.exprType(node)) {
+		g.writeLine(fmt.Sprintf("    movq %d(%%rax), %%rdx", fieldOffset+8))
+	}
+	g.writeLine(fmt.Sprintf("    movq %d(%%rax), %%rax", fieldOffset))
+}
+
+// generateStructLiteral creates a struct on the heap, leaving its address
+// in %rax. Fields without a value keep the zero bytes of the new block.
+func (g *X86_64Generator) generateStructLiteral(node *ast.StructLiteral) {
+	size := g.types.structSize(node.TypeName)
+	if !g.types.isStruct(node.TypeName) {
+		size = 8 * len(node.Fields)
+	}
+	g.writeLine(fmt.Sprintf("    # Struct literal: %s", node.TypeName))
+	g.writeLine(fmt.Sprintf("    movq $%d, %%r11", size))
+	g.writeLine("    call _alloc")
+	g.writeLine("    pushq %r11")
+	names, values := g.types.literalFields(node)
+	for _, name := range names {
+		fieldType := ""
+		if field := g.types.field(node.TypeName, name); field != nil {
+			fieldType = constant.CanonicalType(ast.TypeName(field.Type))
+		}
+		offset := g.types.fieldOffset(node.TypeName, name)
+		g.generateStoredValue(values[name], fieldType)
+		g.writeLine("    movq (%rsp), %r11")
+		g.writeLine(fmt.Sprintf("    movq %%rax, %d(%%r11) # %s", offset, name))
+		if g.types.isInterface(fieldType) {
+			g.writeLine(fmt.Sprintf("    movq %%rdx, %d(%%r11)", offset+8))
+		}
+	}
+	g.generateZeroFields(node.TypeName, g.types.blockFields(node.TypeName, values))
+	g.writeLine("    popq %rax")
+}
+
+// generateAddress leaves the address of an operand of & in %rax: the heap
+// cell or stack slot of a variable, a field or an element in its heap
+// block, or a new heap cell holding any other value. The address of a
+// struct is its value.
+func (g *X86_64Generator) generateAddress(x ast.ASTNode) {
+	typeName := g.types.exprType(x)
+	if g.types.isStruct(typeName) {
+		g.generateExpression(x)
+		return
+	}
+	if g.generateLocation(x) {
+		return
+	}
+	g.writeLine(fmt.Sprintf("    # Address of a new %s", typeName))
+	g.generateExpression(x)
+	g.writeLine(fmt.Sprintf("    movq $%d, %%r11", g.types.size(typeName)))
+	g.writeLine("    call _alloc")
+	g.writeLine("    movq %rax, (%r11)")
+	if g.types.isInterface(typeName) {
+		g.writeLine("    movq %rdx, 8(%r11)")
+	}
+	g.writeLine("    movq %r11, %rax")
+}
+
+// generateLocation leaves the address of the storage of x in %rax: the
+// heap cell or stack slot of a variable, a field or an element in its heap
+// block, or the value a pointer points to. It reports whether x has such
+// storage.
+func (g *X86_64Generator) generateLocation(x ast.ASTNode) bool {
+	switch x := x.(type) {
+	case *ast.VariableNode:
+		if offset, exists := g.variables[x.Name]; exists {
+			g.writeLine(fmt.Sprintf("    # Address of %s", x.Name))
+			if g.cells[offset] {
+				g.writeLine(fmt.Sprintf("    movq -%d(%%rbp), %%rax", offset))
+			} else {
+				g.writeLine(fmt.Sprintf("    leaq -%d(%%rbp), %%rax", offset))
+			}
+			return true
+		}
+	case *ast.FieldAccessNode:
+		objectType := g.types.exprType(x.Object)
+		g.writeLine(fmt.Sprintf("    # Address of field '%s'", x.Field))
+		g.generateExpression(x.Object)
+		g.generateNilCheck()
+		g.writeLine(fmt.Sprintf("    addq $%d, %%rax", g.types.fieldOffset(objectType, x.Field)))
+		return true
+	case *ast.IndexAccess:
+		if objectType := g.types.exprType(x.Object); isSliceType(objectType) {
+			g.writeLine("    # Address of slice element")
+			g.generateExpression(x.Object)
+			g.writeLine("    pushq %rax")
+			g.generateExpression(x.Index)
+			g.writeLine("    popq %rbx")
+			g.generateIndexCheck()
+			g.writeLine(fmt.Sprintf("    imulq $%d, %%rax", g.types.size(sliceElementType(objectType))))
+			g.writeLine("    addq (%rbx), %rax")
+			return true
+		}
+	case *ast.DerefNode:
+		// &*p is p, once p is known not to be nil
+		g.generateExpression(x.Operand)
+		g.generateNilCheck()
+		return true
+	}
+	return false
+}
+
+// generateDeref loads the value a pointer points to into %rax (and %rdx)
+func (g *X86_64Generator) generateDeref(node *ast.DerefNode) {
+	elementType := pointerElementType(g.types.exprType(node.Operand))
+	g.writeLine("    # Dereference: *p")
+	g.generateExpression(node.Operand)
+	g.generateNilCheck()
+	if g.types.isStruct(elementType) {
+		return
+	}
+	if g.types.isInterface(elementType) {
+		g.writeLine("    movq 8(%rax), %rdx")
+	}
+	g.writeLine("    movq (%rax), %rax")
+}
+
+// generateAssign generates target = value (see asmgen/assign.go). The
+// value is stored at the location of the target, except that a variable
+// gets its value like at its declaration and a map entry is stored by the
+// runtime. Assigning a struct through a pointer, *p = value, copies its
+// fields into the block p points to.
+func (g *X86_64Generator) generateAssign(target, value ast.ASTNode) {
+	typeName := g.types.exprType(target)
+	switch t := target.(type) {
+	case *ast.VariableNode:
+		if offset, exists := g.variables[t.Name]; exists {
+			g.writeLine(fmt.Sprintf("    # %s = value", t.Name))
+			g.generateStoredValue(value, g.types.varTypes[t.Name])
+			g.storeValue(offset, g.types.varTypes[t.Name])
+		}
+		return
+	case *ast.IndexAccess:
+		if _, _, _, isMap := g.types.mapIndex(t); isMap {
+			g.generateMapAssign(t, value)
+			return
+		}
+	}
+
+	g.writeLine("    # target = value")
+	if !g.generateLocation(target) {
+		g.writeLine("    # Assignment to an unsupported target")
+		return
+	}
+	g.writeLine("    pushq %rax")
+	g.generateStoredValue(value, typeName)
+	g.writeLine("    popq %r11")
+	if _, isDeref := target.(*ast.DerefNode); isDeref && g.types.isStruct(typeName) {
+		for offset := 0; offset < g.types.structSize(typeName); offset += 8 {
+			g.writeLine(fmt.Sprintf("    movq %d(%%rax), %%rcx", offset))
+			g.writeLine(fmt.Sprintf("    movq %%rcx, %d(%%r11)", offset))
+		}
+		return
+	}
+	g.writeLine("    movq %rax, (%r11)")
+	if g.types.isInterface(typeName) {
+		g.writeLine("    movq %rdx, 8(%r11)")
+	}
+}
+
+// generateOpAssign generates x op= value, x++ and x-- by lowering them to
+// plain assignments
+func (g *X86_64Generator) generateOpAssign(x ast.ASTNode, op token.Token, value ast.ASTNode, pos token.Position) {
+	hidden := func() string { return "." + g.getNewLabel() }
+	for _, stmt := range lowerOpAssign(x, op, value, pos, hidden) {
+		g.generateStatement(stmt)
+	}
+}
+
+// generateNew allocates a zero value for new(T), leaving its address in %rax
+func (g *X86_64Generator) generateNew(node *ast.NewNode) {
+	typeName := constant.CanonicalType(ast.TypeName(node.Type))
+	if g.types.isStruct(typeName) {
+		g.generateZeroValue(typeName)
+		return
+	}
+	g.writeLine(fmt.Sprintf("    # new(%s)", typeName))
+	zeroing := g.types.needsZeroing(typeName)
+	if zeroing {
+		// The zero value of a string or an array is an address, which
+		// _alloc leaves in %rax
+		g.generateZeroValue(typeName)
+	}
+	g.writeLine(fmt.Sprintf("    movq $%d, %%r11", g.types.size(typeName)))
+	g.writeLine("    call _alloc")
+	if zeroing {
+		g.writeLine("    movq %rax, (%r11)")
+	}
+	g.writeLine("    movq %r11, %rax")
+}
+
+// generateNilCheck panics if the pointer in %rax is nil
+func (g *X86_64Generator) generateNilCheck() {
+	g.writeLine("    testq %rax, %rax")
+	g.writeLine("    jz _nil_panic")
+}
+
+// generateElements creates the header and the elements of a slice or an
+// array on the heap, leaving it in %rax. Elements that are not given hold
+// the zero value of the element type.
+func (g *X86_64Generator) generateElements(typeName string, elements []ast.ASTNode) {
+	elementType := constant.CanonicalType(sliceElementType(typeName))
+	elementSize := g.types.size(elementType)
+	count := len(elements)
+	if isArrayType(typeName) {
+		count = arrayLength(typeName)
+	}
+
+	kind := "Slice literal"
+	if isArrayType(typeName) {
+		kind = "Array"
+	}
+	g.writeLine(fmt.Sprintf("    # %s: %s", kind, typeName))
+	g.writeLine(fmt.Sprintf("    movq $%d, %%r11", sliceHeaderSize))
+	g.writeLine("    call _alloc")
+	g.writeLine("    pushq %r11")
+	g.writeLine(fmt.Sprintf("    movq $%d, %%r11", count*elementSize))
+	g.writeLine("    call _alloc")
+	g.writeLine("    movq (%rsp), %rax")
+	g.writeLine("    movq %r11, (%rax)")
+	g.writeLine(fmt.Sprintf("    movq $%d, 8(%%rax)", count))
+	g.writeLine(fmt.Sprintf("    movq $%d, 16(%%rax)", count))
+	for i := 0; i < count; i++ {
+		if i < len(elements) {
+			g.generateStoredValue(elements[i], elementType)
+		} else if g.types.needsZeroing(elementType) {
+			g.generateZeroValue(elementType)
+		} else {
+			continue
+		}
+		g.writeLine("    movq (%rsp), %rbx")
+		g.writeLine("    movq (%rbx), %rbx")
+		g.writeLine(fmt.Sprintf("    movq %%rax, %d(%%rbx)", i*elementSize))
+		if g.types.isInterface(elementType) {
+			g.writeLine(fmt.Sprintf("    movq %%rdx, %d(%%rbx)", i*elementSize+8))
+		}
+	}
+	g.writeLine("    popq %rax")
+}
+
+// generateMapLiteral creates a map in %rax and stores the entries of the
+// literal in it
+func (g *X86_64Generator) generateMapLiteral(node *ast.MapLiteral) {
+	g.writeLine(fmt.Sprintf("    # Map literal: %s", node.TypeName()))
+	g.writeLine(fmt.Sprintf("    movq $%d, %%rdi", g.types.mapKeyKind(ast.TypeName(node.KeyType), node.Position())))
+	g.writeLine("    call _map_new")
+	g.writeLine("    pushq %rax")
+	for _, entry := range node.Entries {
+		g.generateExpressionAs(entry.Key, ast.TypeName(node.KeyType))
+		g.writeLine("    pushq %rax")
+		g.generateStoredValue(entry.Value, ast.TypeName(node.ValueType))
+		g.writeLine("    movq %rdx, %rcx")
+		g.writeLine("    movq %rax, %rdx")
+		g.writeLine("    popq %rsi")
+		g.writeLine("    movq (%rsp), %rdi")
+		g.writeLine("    call _map_store")
+	}
+	g.writeLine("    popq %rax")
+}
+
+// generateMapIndex looks up m[k]. The value is left in %rax, with the type
+// descriptor in %rdx for an interface value, and %rcx is set to 1 when the
+// key is present. A missing key yields the zero value and %rcx 0.
+func (g *X86_64Generator) generateMapIndex(node *ast.IndexAccess) {
+	_, keyType, valueType, _ := g.types.mapIndex(node)
+	missingLabel := g.getNewLabel()
+	endLabel := g.getNewLabel()
+
+	g.writeLine("    # Map index: m[k]")
+	g.generateExpression(node.Object)
+	g.writeLine("    pushq %rax")
+	g.generateExpressionAs(node.Index, keyType)
+	g.writeLine("    movq %rax, %rsi")
+	g.writeLine("    popq %rdi")
+	g.writeLine("    call _map_lookup")
+	g.writeLine("    testq %rax, %rax")
+	g.writeLine("    jz " + missingLabel)
+	g.writeLine("    movq 8(%rax), %rdx")
+	g.writeLine("    movq (%rax), %rax")
+	g.writeLine("    movq $1, %rcx")
+	g.writeLine("    jmp " + endLabel)
+	g.writeLine(missingLabel + ":")
+	g.generateExpressionAs(g.types.zeroValue(valueType), valueType)
+	g.writeLine("    movq $0, %rcx")
+	g.writeLine(endLabel + ":")
+}
+
+// generateMapAssign generates m[k] = value
+func (g *X86_64Generator) generateMapAssign(target *ast.IndexAccess, value ast.ASTNode) {
+	_, keyType, valueType, _ := g.types.mapIndex(target)
+	g.writeLine("    # m[k] = value")
+	g.generateExpression(target.Object)
+	g.writeLine("    pushq %rax")
+	g.generateExpressionAs(target.Index, keyType)
+	g.writeLine("    pushq %rax")
+	g.generateStoredValue(value, valueType)
+	g.writeLine("    movq %rdx, %rcx")
+	g.writeLine("    movq %rax, %rdx")
+	g.writeLine("    popq %rsi")
+	g.writeLine("    popq %rdi")
+	g.writeLine("    call _map_store")
+}
+
+// generateMapBuiltin generates the built-in functions len and delete on
+// maps, reporting whether call was one of them
+func (g *X86_64Generator) generateMapBuiltin(call *ast.CallNode) bool {
+	if call.Receiver != nil || call.Callee != nil || len(call.Arguments) == 0 {
+		return false
+	}
+	mapType := g.types.exprType(call.Arguments[0])
+	if !ast.IsMapType(mapType) {
+		return false
+	}
+
+	switch {
+	case call.Function == "len" && len(call.Arguments) == 1:
+		// The count is the first word of the header; a nil map is empty
+		nilLabel := g.getNewLabel()
+		g.generateExpression(call.Arguments[0])
+		g.writeLine("    testq %rax, %rax")
+		g.writeLine("    jz " + nilLabel)
+		g.writeLine("    movq (%rax), %rax")
+		g.writeLine(nilLabel + ":")
+		return true
+	case call.Function == "delete" && len(call.Arguments) == 2:
+		keyType, _ := ast.MapTypes(mapType)
+		g.generateExpression(call.Arguments[0])
+		g.writeLine("    pushq %rax")
+		g.generateExpressionAs(call.Arguments[1], keyType)
+		g.writeLine("    movq %rax, %rsi")
+		g.writeLine("    popq %rdi")
+		g.writeLine("    call _map_delete")
+		return true
+	}
+	return false
+}
+
+// generateSliceBuiltin generates the built-in functions len and cap on
+// slices and len on strings, reporting whether call was one of them
+func (g *X86_64Generator) generateSliceBuiltin(call *ast.CallNode) bool {
+	if (call.Function != "len" && call.Function != "cap") || call.Receiver != nil || call.Callee != nil || len(call.Arguments) != 1 {
+		return false
+	}
+
+	switch typeName := g.types.exprType(call.Arguments[0]); {
+	case isSliceType(typeName):
+		g.generateExpression(call.Arguments[0])
+		if call.Function == "len" {
+			g.generateHeaderWord(8)
+		} else {
+			g.generateHeaderWord(16)
+		}
+		return true
+	case typeName == "string" && call.Function == "len":
+		g.generateExpression(call.Arguments[0])
+		g.generateStringLength()
+		return true
+	}
+	return false
+}
+
+// generateHeaderWord loads the length (offset 8) or the capacity (offset
+// 16) from the slice header in %rax; a nil slice has neither
+func (g *X86_64Generator) generateHeaderWord(offset int) {
+	nilLabel := g.getNewLabel()
+	g.writeLine("    testq %rax, %rax")
+	g.writeLine("    jz " + nilLabel)
+	g.writeLine(fmt.Sprintf("    movq %d(%%rax), %%rax", offset))
+	g.writeLine(nilLabel + ":")
+}
+
+// generateStringLength replaces the string in %rax by its length. Strings
+// end with a NUL byte.
+func (g *X86_64Generator) generateStringLength() {
+	loopLabel := g.getNewLabel()
+	endLabel := g.getNewLabel()
+	g.writeLine("    movq %rax, %rbx")
+	g.writeLine("    movq $0, %rax")
+	g.writeLine(loopLabel + ":")
+	g.writeLine("    cmpb $0, (%rbx,%rax,1)")
+	g.writeLine("    je " + endLabel)
+	g.writeLine("    incq %rax")
+	g.writeLine("    jmp " + loopLabel)
+	g.writeLine(endLabel + ":")
+}
+
+// generateSliceExpr generates s[low:high] and s[low:high:max], leaving the
+// result in %rax. Omitted indices are 0, the length and the capacity of s.
+// The runtime checks the indices and panics when they are out of range.
+func (g *X86_64Generator) generateSliceExpr(node *ast.SliceExpr) {
+	objectType := g.types.exprType(node.Object)
+	g.writeLine("    # Slice expression: " + objectType)
+	g.generateExpression(node.Object)
+	g.writeLine("    pushq %rax")
+	if node.Low != nil {
+		g.generateExpression(node.Low)
+	} else {
+		g.writeLine("    movq $0, %rax")
+	}
+	g.writeLine("    pushq %rax")
+
+	if objectType == "string" {
+		// A string is copied up to high unless the slice is a suffix
+		if node.High != nil {
+			g.generateExpression(node.High)
+		} else {
+			g.writeLine("    movq 8(%rsp), %rax")
+			g.generateStringLength()
+		}
+		g.writeLine("    movq %rax, %rdx")
+		g.writeLine("    popq %rsi")
+		g.writeLine("    popq %rdi")
+		g.writeLine("    call _slice_string")
+		return
+	}
+
+	// The stack holds max, high, low and s from the top
+	if node.High != nil {
+		g.generateExpression(node.High)
+	} else {
+		g.writeLine("    movq 8(%rsp), %rax")
+		g.generateHeaderWord(8)
+	}
+	g.writeLine("    pushq %rax")
+	if node.Max != nil {
+		g.generateExpression(node.Max)
+	} else {
+		g.writeLine("    movq 16(%rsp), %rax")
+		g.generateHeaderWord(16)
+	}
+	g.writeLine("    movq %rax, %rcx")
+	g.writeLine("    popq %rdx")
+	g.writeLine("    popq %rsi")
+	g.writeLine("    popq %rdi")
+	g.writeLine(fmt.Sprintf("    movq $%d, %%r8", g.types.size(constant.CanonicalType(sliceElementType(objectType)))))
+	g.writeLine("    call _slice")
+}
+
+func (g *X86_64Generator) generateIndexAccess(node *ast.IndexAccess) {
+	g.writeLine("    # Index access: arr[index]")
+	g.generateExpression(node.Object)
+	g.writeLine("    pushq %rax") // Store array base address
+	g.generateExpression(node.Index)
+	g.writeLine("    popq %rbx") // Load array base address
+	objectType := g.types.exprType(node.Object)
+	if objectType == "string" {
+		// Strings are indexed by byte
+		g.writeLine("    movzbq (%rbx,%rax,1), %rax")
+		return
+	}
+	if isSliceType(objectType) {
+		// The elements start at the data word of the header
+		g.generateIndexCheck()
+		g.writeLine("    movq (%rbx), %rbx")
+		if elementType := sliceElementType(objectType); g.types.isInterface(elementType) {
+			g.writeLine("    salq $4, %rax")
+			g.writeLine("    addq %rbx, %rax")
+			g.writeLine("    movq 8(%rax), %rdx")
+			g.writeLine("    movq (%rax), %rax")
+			return
+		}
+		g.writeLine("    movq (%rbx,%rax,8), %rax")
+		return
+	}
+	g.writeLine("    salq $3, %rax")     // %rax = index * 8
+	g.writeLine("    addq %rbx, %rax")   // %rax = base + offset
+	g.writeLine("    movq (%rax), %rax") // Load value at address
+}
+
+// generateIndexCheck panics unless the index in %rax is within the length
+// of the slice or array header in %rbx. A nil slice has no elements, and a
+// negative index compares as a large unsigned one.
+func (g *X86_64Generator) generateIndexCheck() {
+	g.writeLine("    testq %rbx, %rbx")
+	g.writeLine("    jz _index_panic")
+	g.writeLine("    cmpq 8(%rbx), %rax")
+	g.writeLine("    jae _index_panic")
+}
+
+// truncateInteger truncates %rax to a sized integer type (int32 or uint8)
+func (g *X86_64Generator) truncateInteger(typeName string) {
+	switch typeName {
+	case "int32":
+		g.writeLine("    movslq %eax, %rax")
+	case "uint8":
+		g.writeLine("    movzbq %al, %rax")
+	}
+}
+
+func (g *X86_64Generator) getNewLabel() string {
+	g.labelNum++
+	return fmt.Sprintf("L%d", g.labelNum)
+}
+
+func (g *X86_64Generator) getStringLabel(value string) string {
+	if label, exists := g.stringLiterals[value]; exists {
+		return label
+	}
+
+	label := fmt.Sprintf("str_%d", g.stringCount)
+	g.stringLiterals[value] = label
+	g.stringCount++
+	return label
+}
+
+func (g *X86_64Generator) generateStringLiterals() {
+	if len(g.stringLiterals) == 0 {
+		return
+	}
+
+	g.writeLine("")
+	g.writeLine(".section .rodata")
 
 	for value, label := range g.stringLiterals {
 		g.writeLine(fmt.Sprintf("%s:", label))
-		g.writeLine(fmt.Sprintf("    .asciz \"%s\"", value))
+		g.writeLine(fmt.Sprintf("    .asciz %s", quoteAsmString(value)))
+	}
+}
+
+// typeDescriptor returns the symbol of the type descriptor of a type and
+// marks it for generation
+func (g *X86_64Generator) typeDescriptor(typeName string) string {
+	g.descriptors[typeName] = true
+	return typeSymbol(typeName)
+}
+
+// generateTypeDescriptors generates the descriptors of the types stored in
+// interface values. A descriptor holds the address of the type name and the
+// method table used for dynamic calls, with a zero entry for each method the
+// type does not have.
+func (g *X86_64Generator) generateTypeDescriptors() {
+	if len(g.descriptors) == 0 {
+		return
+	}
+
+	typeNames := make([]string, 0, len(g.descriptors))
+	for typeName := range g.descriptors {
+		typeNames = append(typeNames, typeName)
+	}
+	sort.Strings(typeNames)
+
+	g.writeLine("")
+	g.writeLine(".section .rodata")
+	g.writeLine(".p2align 3")
+	for _, typeName := range typeNames {
+		g.writeLine(fmt.Sprintf("%s:", typeSymbol(typeName)))
+		g.writeLine(fmt.Sprintf("    .quad %s # %s", g.getStringLabel(typeName), typeName))
+		for i, method := range g.types.methodTable(typeName) {
+			if method == "" {
+				g.writeLine(fmt.Sprintf("    .quad 0 # %s", g.types.methodSlots[i]))
+			} else {
+				g.writeLine(fmt.Sprintf("    .quad _%s", method))
+			}
+		}
+	}
+}
+
+// generateStaticClosures generates the closures of the declared functions
+// used as values, which hold just the code address
+func (g *X86_64Generator) generateStaticClosures() {
+	if len(g.staticClosures) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(g.staticClosures))
+	for name := range g.staticClosures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	g.writeLine("")
+	g.writeLine(".section .rodata")
+	g.writeLine(".p2align 3")
+	for _, name := range names {
+		g.writeLine(fmt.Sprintf("%s:", staticClosureSymbol(name)))
+		g.writeLine(fmt.Sprintf("    .quad _%s", name))
+	}
+}
+
+// generateGlobals reserves zeroed storage for the package-level variables
+func (g *X86_64Generator) generateGlobals() {
+	if len(g.types.globals) == 0 {
+		return
+	}
+
+	g.writeLine("")
+	g.writeLine(".section .bss")
+	g.writeLine(".p2align 3")
+	for _, name := range g.types.globals {
+		g.writeLine(fmt.Sprintf("%s:", globalSymbol(name)))
+		g.writeLine(fmt.Sprintf("    .zero %d", g.types.size(g.types.globalTypes[name])))
 	}
 }
 
@@ -393,9 +2462,18 @@ func (g *X86_64Generator) writeLine(s string) {
 	g.output.WriteString(s + "\n")
 }
 
+// Errors returns the type errors found while generating the program
+func (g *X86_64Generator) Errors() []*scanner.Error {
+	return g.types.errors
+}
+
 func (g *X86_64Generator) GenerateRuntime() string {
+	// Switch back to the text section: string literals may precede the runtime
 	runtime := `
+.section .text
+
 # Runtime function to print numbers (x86_64 Linux)
+# Like the other print functions, it ends with the byte at print_end
 _print_number:
     pushq %rbp
     movq %rsp, %rbp
@@ -404,7 +2482,19 @@ _print_number:
     # Save the number
     movq %rax, -8(%rbp)
     
-    # Buffer for digits (16 bytes should be enough)
+    # Print a minus sign for negative numbers
+    testq %rax, %rax
+    jns convert_start
+    movb $45, -32(%rbp)   # ASCII '-'
+    movq $1, %rax         # sys_write
+    movq $1, %rdi         # stdout
+    leaq -32(%rbp), %rsi  # buffer
+    movq $1, %rdx         # length
+    syscall
+    movq -8(%rbp), %rax   # Restore the number
+    
+convert_start:
+    # Buffer for digits (19 digits at most)
     leaq -24(%rbp), %rsi  # Buffer pointer
     movq $0, %rcx         # Digit count
     
@@ -424,6 +2514,11 @@ convert_loop:
     cqo                   # Sign extend %rax to %rdx:%rax
     idivq %rbx            # %rax = quotient, %rdx = remainder
     
+    # The remainder of a negative number is negative
+    movq %rdx, %rbx
+    negq %rbx
+    cmovgq %rbx, %rdx
+    
     # Convert digit to ASCII and store
     addq $48, %rdx        # Convert to ASCII
     movb %dl, (%rsi,%rcx,1) # Store digit
@@ -461,11 +2556,12 @@ print_loop:
     jnz print_loop
     
 print_newline:
-    # Write newline character
+    # Write the end byte, a newline or a space
     movq $1, %rax         # sys_write
     movq $1, %rdi         # stdout
     leaq -25(%rbp), %rsi  # buffer
-    movb $10, (%rsi)      # ASCII newline  
+    movb print_end(%rip), %dl
+    movb %dl, (%rsi)
     movq $1, %rdx         # length
     syscall
     
@@ -473,10 +2569,33 @@ print_newline:
     popq %rbp
     ret
 
+# Runtime function to compare strings (x86_64 Linux)
+# Takes two string pointers in %rax and %rbx and returns 1 in %rax if they are equal
+_string_equal:
+    movq $0, %rcx         # Index
+    
+streq_loop:
+    movb (%rax,%rcx), %dl # Load byte of the first string
+    cmpb (%rbx,%rcx), %dl # Compare with the second string
+    jne streq_false
+    testb %dl, %dl        # Both strings end here
+    jz streq_true
+    incq %rcx
+    jmp streq_loop
+    
+streq_true:
+    movq $1, %rax
+    ret
+    
+streq_false:
+    movq $0, %rax
+    ret
+
 # Runtime function to print strings (x86_64 Linux)
 _print_string:
     pushq %rbp
     movq %rsp, %rbp
+    subq $16, %rsp        # Space for the newline (keeps saved %rbp intact)
     
     # Calculate string length (simple strlen implementation)
     movq %rax, %rsi       # Save string pointer
@@ -497,16 +2616,836 @@ print_str:
     movq %rcx, %rdx       # length
     syscall
     
-    # Print newline
+    # Print the end byte, a newline or a space
     movq $1, %rax         # sys_write
     movq $1, %rdi         # stdout
-    movq %rsp, %rsi       # Use stack for newline
-    movb $10, (%rsi)      # ASCII newline
+    movq %rsp, %rsi       # Use stack for the end byte
+    movb print_end(%rip), %dl
+    movb %dl, (%rsi)
     movq $1, %rdx         # length
     syscall
     
+    addq $16, %rsp
     popq %rbp
     ret
+
+# Runtime function to print float64 values (x86_64 Linux)
+# Takes the float64 bits in %rax and prints them like Go's println: +d.dddddde+ddd
+_print_float:
+    pushq %rbp
+    movq %rsp, %rbp
+    subq $32, %rsp
+    
+    leaq -32(%rbp), %rsi  # Buffer pointer
+    movq %rax, %xmm0
+    
+    # NaN check (unordered with itself)
+    ucomisd %xmm0, %xmm0
+    jp pf_nan
+    
+    # Sign
+    movb $43, (%rsi)      # ASCII '+'
+    movq $0, %rcx         # Decimal exponent
+    btrq $63, %rax        # Clear sign bit
+    jnc pf_positive
+    movb $45, (%rsi)      # ASCII '-'
+    movq %rax, %xmm0      # Absolute value
+    
+pf_positive:
+    movabsq $4621819117588971520, %rdx # 10.0
+    movq %rdx, %xmm2
+    movabsq $4607182418800017408, %rdx # 1.0
+    movq %rdx, %xmm3
+    
+    # v + v == v only for zero and infinity
+    movapd %xmm0, %xmm5
+    addsd %xmm0, %xmm5
+    ucomisd %xmm0, %xmm5
+    jne pf_norm_high
+    testq %rax, %rax
+    jz pf_digits
+    jmp pf_inf
+    
+pf_norm_high:
+    # Normalize to 1 <= v < 10
+    ucomisd %xmm2, %xmm0
+    jb pf_norm_low
+    divsd %xmm2, %xmm0
+    incq %rcx
+    jmp pf_norm_high
+    
+pf_norm_low:
+    ucomisd %xmm3, %xmm0
+    jae pf_round
+    mulsd %xmm2, %xmm0
+    decq %rcx
+    jmp pf_norm_low
+    
+pf_round:
+    # Round at the 7th digit: v += 5 / 10^7
+    movabsq $4617315517961601024, %rdx # 5.0
+    movq %rdx, %xmm4
+    movq $7, %rdx
+pf_round_loop:
+    divsd %xmm2, %xmm4
+    decq %rdx
+    jnz pf_round_loop
+    addsd %xmm4, %xmm0
+    ucomisd %xmm2, %xmm0
+    jb pf_digits
+    divsd %xmm2, %xmm0
+    incq %rcx
+    
+pf_digits:
+    # Store 7 digits at buffer[2..8]
+    movq $2, %rdx
+pf_digit_loop:
+    cvttsd2si %xmm0, %rax
+    cvtsi2sdq %rax, %xmm1
+    subsd %xmm1, %xmm0
+    mulsd %xmm2, %xmm0
+    addq $48, %rax        # Convert to ASCII
+    movb %al, (%rsi,%rdx,1)
+    incq %rdx
+    cmpq $9, %rdx
+    jne pf_digit_loop
+    
+    # d.dddddd
+    movb 2(%rsi), %al
+    movb %al, 1(%rsi)
+    movb $46, 2(%rsi)     # ASCII '.'
+    
+    # Exponent: e+ddd
+    movb $101, 9(%rsi)    # ASCII 'e'
+    movb $43, 10(%rsi)    # ASCII '+'
+    testq %rcx, %rcx
+    jns pf_exponent
+    negq %rcx
+    movb $45, 10(%rsi)    # ASCII '-'
+pf_exponent:
+    movq %rcx, %rax
+    movq $10, %r8
+    xorq %rdx, %rdx
+    divq %r8              # %rax = e / 10, %rdx = e % 10
+    addq $48, %rdx
+    movb %dl, 13(%rsi)
+    xorq %rdx, %rdx
+    divq %r8              # %rax = e / 100, %rdx = (e / 10) % 10
+    addq $48, %rdx
+    movb %dl, 12(%rsi)
+    addq $48, %rax
+    movb %al, 11(%rsi)
+    movb print_end(%rip), %dl
+    movb %dl, 14(%rsi)    # newline or space
+    movq $15, %rdx        # length
+    jmp pf_write
+    
+pf_nan:
+    movb $78, (%rsi)      # ASCII 'N'
+    movb $97, 1(%rsi)     # ASCII 'a'
+    movb $78, 2(%rsi)     # ASCII 'N'
+    movb print_end(%rip), %dl
+    movb %dl, 3(%rsi)     # newline or space
+    movq $4, %rdx         # length
+    jmp pf_write
+    
+pf_inf:
+    # Sign is already in buffer[0]
+    movb $73, 1(%rsi)     # ASCII 'I'
+    movb $110, 2(%rsi)    # ASCII 'n'
+    movb $102, 3(%rsi)    # ASCII 'f'
+    movb print_end(%rip), %dl
+    movb %dl, 4(%rsi)     # newline or space
+    movq $5, %rdx         # length
+    
+pf_write:
+    movq $1, %rax         # sys_write
+    movq $1, %rdi         # stdout
+    syscall
+    
+    addq $32, %rsp
+    popq %rbp
+    ret
+
+# Runtime function for failed type assertions (x86_64 Linux)
+# A failed type assertion panics
+_type_assert_panic:
+    leaq type_assert_msg(%rip), %rax
+    jmp _runtime_panic
+
+# Runtime function to allocate heap memory (x86_64 Linux)
+# Takes the size in %r11 and returns the address in %r11, keeping every
+# other register. Memory is never freed.
+_alloc:
+    pushq %rax
+    movq heap_ptr(%rip), %rax
+    addq $15, %r11
+    andq $-16, %r11       # keep blocks 16-byte aligned
+    addq %rax, %r11
+    movq %r11, heap_ptr(%rip)
+    movq %rax, %r11
+    popq %rax
+    ret
+
+# Copies the block in %rax of %r9 bytes, the fields of a struct or the
+# elements of an array, to a new block returned in %rax
+_copy_struct:
+    movq %r9, %r11
+    call _alloc
+    movq %r11, %r10
+copystruct_loop:
+    testq %r9, %r9
+    jz copystruct_done
+    movq (%rax), %rcx
+    movq %rcx, (%r10)
+    addq $8, %rax
+    addq $8, %r10
+    subq $8, %r9
+    jmp copystruct_loop
+copystruct_done:
+    movq %r11, %rax
+    ret
+
+# Runtime functions for slices (x86_64 Linux)
+# See asmgen/slices.go for the layout of slices. _slice takes the header in
+# %rdi, low, high and max in %rsi, %rdx and %rcx and the element size in %r8,
+# and returns a header sharing the elements of the old one in %rax.
+_slice:
+    movq $0, %r9          # a nil slice has no capacity
+    testq %rdi, %rdi
+    jz slice_check
+    movq 16(%rdi), %r9
+slice_check:
+    cmpq %r9, %rcx        # unsigned, so negative indices are out of range
+    ja _slice_panic
+    cmpq %rcx, %rdx
+    ja _slice_panic
+    cmpq %rdx, %rsi
+    ja _slice_panic
+    movq $0, %rax         # slicing a nil slice yields nil
+    testq %rdi, %rdi
+    jz slice_done
+    movq $24, %r11
+    call _alloc
+    movq %rsi, %rax
+    imulq %r8, %rax
+    addq (%rdi), %rax
+    movq %rax, (%r11)     # data
+    subq %rsi, %rdx
+    movq %rdx, 8(%r11)    # length
+    subq %rsi, %rcx
+    movq %rcx, 16(%r11)   # capacity
+    movq %r11, %rax
+slice_done:
+    ret
+
+# _slice_string takes the string in %rdi and low and high in %rsi and %rdx,
+# and returns s[low:high] in %rax. A suffix shares the bytes of the string;
+# other slices are copied to add the NUL byte.
+_slice_string:
+    movq $0, %rcx
+slice_string_len:
+    cmpb $0, (%rdi,%rcx,1)
+    je slice_string_check
+    incq %rcx
+    jmp slice_string_len
+slice_string_check:
+    cmpq %rcx, %rdx
+    ja _slice_panic
+    cmpq %rdx, %rsi
+    ja _slice_panic
+    addq %rsi, %rdi       # first byte
+    cmpq %rcx, %rdx
+    je slice_string_suffix
+    subq %rsi, %rdx       # length of the result
+    movq %rdx, %r11
+    incq %r11
+    call _alloc
+    movq $0, %rcx
+slice_string_copy:
+    cmpq %rdx, %rcx
+    je slice_string_end
+    movb (%rdi,%rcx,1), %al
+    movb %al, (%r11,%rcx,1)
+    incq %rcx
+    jmp slice_string_copy
+slice_string_end:
+    movb $0, (%r11,%rcx,1)
+    movq %r11, %rax
+    ret
+slice_string_suffix:
+    movq %rdi, %rax
+    ret
+
+# Runtime functions for maps (x86_64 Linux)
+# See asmgen/maps.go for the layout of maps. _map_new takes the key kind in
+# %rdi and returns the map in %rax; the others take the map in %rdi and the
+# key in %rsi.
+_map_new:
+    movq $40, %r11
+    call _alloc
+    movq $8, 8(%r11)      # capacity
+    movq %rdi, 24(%r11)   # key kind
+    movq %r11, %rax
+    movq $256, %r11       # 8 entries of 32 bytes
+    call _alloc
+    movq %r11, 32(%rax)
+    ret
+
+# Hashes the key in %rsi into %rax
+_map_hash:
+    movq 24(%rdi), %rcx
+    cmpq $1, %rcx
+    je maphash_string
+    ja maphash_block
+    movabsq $0x9E3779B97F4A7C15, %rax
+    imulq %rsi, %rax
+maphash_fold:
+    movq %rax, %rcx
+    shrq $32, %rcx
+    xorq %rcx, %rax       # fold the high bits into the index bits
+    ret
+maphash_block:
+    movq %rsi, %rdx
+    testq $1, %rcx
+    jz maphash_words
+    movq (%rdx), %rdx     # elements of an array
+maphash_words:
+    shrq $1, %rcx         # size of the key
+    movq $0, %rax
+    movabsq $0x9E3779B97F4A7C15, %r8
+maphash_wordloop:
+    testq %rcx, %rcx
+    jz maphash_fold
+    xorq (%rdx), %rax
+    imulq %r8, %rax
+    addq $8, %rdx
+    subq $8, %rcx
+    jmp maphash_wordloop
+maphash_string:
+    movabsq $0xCBF29CE484222325, %rax # FNV-1a
+    movabsq $0x100000001B3, %rcx
+    movq %rsi, %rdx
+maphash_loop:
+    movzbq (%rdx), %r8
+    testq %r8, %r8
+    jz maphash_done
+    xorq %r8, %rax
+    imulq %rcx, %rax
+    incq %rdx
+    jmp maphash_loop
+maphash_done:
+    ret
+
+# Finds the entry of the key in %rsi: returns its address in %rax and 1 in
+# %rdx, or the address of the empty entry ending its probe sequence and 0
+_map_find:
+    call _map_hash
+    movq 8(%rdi), %r9
+    decq %r9              # index mask
+    andq %r9, %rax
+mapfind_loop:
+    movq %rax, %r10
+    shlq $5, %r10
+    addq 32(%rdi), %r10   # entry address
+    movq (%r10), %r8
+    testq %r8, %r8
+    jz mapfind_missing
+    cmpq $1, %r8
+    jne mapfind_next      # deleted entry
+    movq 8(%r10), %r8
+    movq 24(%rdi), %rcx
+    cmpq $1, %rcx
+    je mapfind_string
+    ja mapfind_block
+    cmpq %rsi, %r8
+    je mapfind_found
+    jmp mapfind_next
+mapfind_block:
+    movq %rsi, %r11
+    testq $1, %rcx
+    jz mapfind_words
+    movq (%r8), %r8       # elements of arrays
+    movq (%r11), %r11
+mapfind_words:
+    shrq $1, %rcx         # size of the key
+mapfind_wordloop:
+    testq %rcx, %rcx
+    jz mapfind_found
+    movq (%r8), %rdx
+    cmpq (%r11), %rdx
+    jne mapfind_next
+    addq $8, %r8
+    addq $8, %r11
+    subq $8, %rcx
+    jmp mapfind_wordloop
+mapfind_string:
+    movq $0, %rcx
+mapfind_strloop:
+    movb (%r8,%rcx), %dl
+    cmpb (%rsi,%rcx), %dl
+    jne mapfind_next
+    testb %dl, %dl
+    jz mapfind_found
+    incq %rcx
+    jmp mapfind_strloop
+mapfind_next:
+    incq %rax
+    andq %r9, %rax
+    jmp mapfind_loop
+mapfind_found:
+    movq %r10, %rax
+    movq $1, %rdx
+    ret
+mapfind_missing:
+    movq %r10, %rax
+    movq $0, %rdx
+    ret
+
+# Returns the address of the value of the key in %rax, or 0 when the key is
+# missing
+_map_lookup:
+    testq %rdi, %rdi
+    jz maplookup_missing
+    call _map_find
+    testq %rdx, %rdx
+    jz maplookup_missing
+    addq $16, %rax
+    ret
+maplookup_missing:
+    movq $0, %rax
+    ret
+
+# Stores the value in %rdx (and its type descriptor in %rcx) under the key
+_map_store:
+    testq %rdi, %rdi
+    jz _map_nil_panic
+    pushq %rdx
+    pushq %rcx
+    call _map_find
+    testq %rdx, %rdx
+    jnz mapstore_set
+    movq 16(%rdi), %r8    # grow first if the table would be 3/4 full
+    incq %r8
+    shlq $2, %r8
+    movq 8(%rdi), %r9
+    leaq (%r9,%r9,2), %r9
+    cmpq %r9, %r8
+    jbe mapstore_insert
+    call _map_grow
+    call _map_find
+mapstore_insert:
+    call _map_copy_key
+    movq $1, (%rax)
+    movq %rsi, 8(%rax)
+    incq (%rdi)           # count
+    incq 16(%rdi)         # used
+mapstore_set:
+    popq %rcx
+    popq %rdx
+    movq %rdx, 16(%rax)
+    movq %rcx, 24(%rax)
+    ret
+
+# Replaces a struct or array key in %rsi with a copy of it
+_map_copy_key:
+    movq 24(%rdi), %rcx
+    cmpq $1, %rcx
+    jbe mapcopy_done      # words and strings are not copied
+    movq %rcx, %r9
+    shrq $1, %r9          # size of the key
+    movq %r9, %r11
+    testq $1, %rcx
+    jz mapcopy_struct
+    addq $24, %r11        # an array gets a header of its own
+    call _alloc
+    movq 8(%rsi), %r8
+    movq %r8, 8(%r11)     # length
+    movq %r8, 16(%r11)    # capacity
+    movq (%rsi), %r8
+    leaq 24(%r11), %r10
+    movq %r10, (%r11)
+    jmp mapcopy_words
+mapcopy_struct:
+    call _alloc
+    movq %rsi, %r8
+    movq %r11, %r10
+mapcopy_words:
+    movq %r11, %rsi
+mapcopy_loop:
+    testq %r9, %r9
+    jz mapcopy_done
+    movq (%r8), %rcx
+    movq %rcx, (%r10)
+    addq $8, %r8
+    addq $8, %r10
+    subq $8, %r9
+    jmp mapcopy_loop
+mapcopy_done:
+    ret
+
+# Doubles the capacity, moving the used entries to new entries
+_map_grow:
+    pushq %rsi
+    pushq %rbx
+    pushq %r12
+    movq 32(%rdi), %rbx   # old entries
+    movq 8(%rdi), %r12    # old capacity
+    movq %r12, %r11
+    shlq $6, %r11         # twice as many entries of 32 bytes
+    call _alloc
+    movq %r11, 32(%rdi)
+    shlq $1, 8(%rdi)
+    movq (%rdi), %rax
+    movq %rax, 16(%rdi)   # deleted entries are dropped
+mapgrow_loop:
+    testq %r12, %r12
+    jz mapgrow_done
+    cmpq $1, (%rbx)
+    jne mapgrow_next
+    movq 8(%rbx), %rsi
+    call _map_find
+    movq $1, (%rax)
+    movq %rsi, 8(%rax)
+    movq 16(%rbx), %rcx
+    movq %rcx, 16(%rax)
+    movq 24(%rbx), %rcx
+    movq %rcx, 24(%rax)
+mapgrow_next:
+    addq $32, %rbx
+    decq %r12
+    jmp mapgrow_loop
+mapgrow_done:
+    popq %r12
+    popq %rbx
+    popq %rsi
+    ret
+
+# Deletes the entry of the key, if any
+_map_delete:
+    testq %rdi, %rdi
+    jz mapdelete_done
+    call _map_find
+    testq %rdx, %rdx
+    jz mapdelete_done
+    movq $2, (%rax)
+    decq (%rdi)
+mapdelete_done:
+    ret
+
+# Decodes the UTF-8 encoded rune at %rdi into %rax and its size into %rdx.
+# An invalid encoding decodes to U+FFFD with size 1.
+_decode_rune:
+    movzbq (%rdi), %rax
+    cmpq $0x80, %rax
+    jb decoderune_ascii
+    cmpq $0xC0, %rax
+    jb decoderune_invalid
+    cmpq $0xE0, %rax
+    jb decoderune_2
+    cmpq $0xF0, %rax
+    jb decoderune_3
+    cmpq $0xF8, %rax
+    jb decoderune_4
+    jmp decoderune_invalid
+decoderune_2:
+    andq $0x1F, %rax
+    movq $2, %rdx
+    jmp decoderune_rest
+decoderune_3:
+    andq $0x0F, %rax
+    movq $3, %rdx
+    jmp decoderune_rest
+decoderune_4:
+    andq $0x07, %rax
+    movq $4, %rdx
+decoderune_rest:
+    movq $1, %rsi
+decoderune_loop:
+    movzbq (%rdi,%rsi,1), %r8
+    movq %r8, %r9
+    andq $0xC0, %r9
+    cmpq $0x80, %r9
+    jne decoderune_invalid
+    shlq $6, %rax
+    andq $0x3F, %r8
+    orq %r8, %rax
+    incq %rsi
+    cmpq %rdx, %rsi
+    jb decoderune_loop
+    ret
+decoderune_ascii:
+    movq $1, %rdx
+    ret
+decoderune_invalid:
+    movq $0xFFFD, %rax
+    movq $1, %rdx
+    ret
+
+# Runtime functions for defer, panic and recover (x86_64 Linux)
+# See asmgen/defer.go for the layout of defer records. _run_defers runs the
+# deferred calls of the frame in %rdi, the last deferred one first.
+_run_defers:
+    pushq %rdi
+    pushq %rdi            # keep the stack 16-byte aligned
+rundefers_loop:
+    movq defer_top(%rip), %rax
+    testq %rax, %rax
+    jz rundefers_done
+    movq (%rsp), %rdi
+    cmpq %rdi, 8(%rax)    # a record of another frame
+    jne rundefers_done
+    movq (%rax), %rcx     # unlink the record before its call
+    movq %rcx, defer_top(%rip)
+    call _call_deferred
+    jmp rundefers_loop
+rundefers_done:
+    addq $16, %rsp
+    ret
+
+# Calls the deferred call of the record in %rax. The deferred function
+# returns to the caller of _call_deferred.
+_call_deferred:
+    movq 24(%rax), %r10   # closure
+    movq 40(%rax), %rdi
+    movq 48(%rax), %rsi
+    movq 56(%rax), %rdx
+    movq 64(%rax), %rcx
+    movq 72(%rax), %r8
+    movq 80(%rax), %r9
+    jmp *(%r10)
+
+# panic(v) with v in %rax and its type descriptor in %rdx. Runs the deferred
+# calls of every frame; when one of them recovers, the function that
+# deferred it returns normally through its exit code.
+_panic:
+    movq %rax, panic_state(%rip)    # value
+    movq %rdx, panic_state+8(%rip)  # type descriptor
+    movq $1, panic_state+16(%rip)   # panicking
+panic_loop:
+    movq defer_top(%rip), %rax
+    testq %rax, %rax
+    jz panic_exit
+    movq (%rax), %rcx
+    movq %rcx, defer_top(%rip)
+    pushq %rax
+    call _call_deferred
+panic_deferred_return:
+    popq %rax
+    cmpq $0, panic_state+16(%rip)
+    jne panic_loop
+    movq 8(%rax), %rbp    # frame
+    jmp *16(%rax)         # resume
+
+# recover() takes the return address of the function calling it in %rdi:
+# only a deferred call run by _panic returns to panic_deferred_return. It
+# returns the panic value in %rax and %rdx, or nil when not panicking.
+_recover:
+    movq $0, %rax
+    movq $0, %rdx
+    leaq panic_deferred_return(%rip), %rcx
+    cmpq %rcx, %rdi
+    jne recover_done
+    cmpq $0, panic_state+16(%rip)
+    je recover_done
+    movq $0, panic_state+16(%rip)
+    movq panic_state(%rip), %rax
+    movq panic_state+8(%rip), %rdx
+recover_done:
+    ret
+
+# Nobody recovered: print the value to stderr like Go and exit with status 2
+panic_exit:
+    movq $33, %rax        # sys_dup2: the print functions write to stderr
+    movq $2, %rdi
+    movq $1, %rsi
+    syscall
+    movq $1, %rax         # sys_write
+    movq $1, %rdi
+    leaq panic_msg(%rip), %rsi
+    movq $7, %rdx         # length
+    syscall
+    movq panic_state+8(%rip), %r12
+    leaq panic_nil(%rip), %rax
+    testq %r12, %r12
+    jz panic_print_string
+    movq (%r12), %r12     # type name
+    movq %r12, %rax
+    leaq panic_string_type(%rip), %rbx
+    call _string_equal
+    testq %rax, %rax
+    jz panic_check_float
+    movq panic_state(%rip), %rax
+panic_print_string:
+    call _print_string
+    jmp panic_die
+panic_check_float:
+    movq %r12, %rax
+    leaq panic_float_type(%rip), %rbx
+    call _string_equal
+    testq %rax, %rax
+    jz panic_check_bool
+    movq panic_state(%rip), %rax
+    call _print_float
+    jmp panic_die
+panic_check_bool:
+    movq %r12, %rax
+    leaq panic_bool_type(%rip), %rbx
+    call _string_equal
+    testq %rax, %rax
+    jz panic_check_int
+    leaq panic_false(%rip), %rax
+    leaq panic_true(%rip), %rcx
+    cmpq $0, panic_state(%rip)
+    cmovneq %rcx, %rax
+    jmp panic_print_string
+panic_check_int:
+    leaq panic_int_types(%rip), %r13
+panic_int_loop:
+    movq (%r13), %rbx
+    testq %rbx, %rbx
+    jz panic_print_type
+    movq %r12, %rax
+    call _string_equal
+    addq $8, %r13
+    testq %rax, %rax
+    jz panic_int_loop
+    movq panic_state(%rip), %rax
+    call _print_number
+    jmp panic_die
+panic_print_type:
+    # Other values print their type: (T)
+    movq $1, %rax         # sys_write
+    movq $1, %rdi
+    leaq panic_open(%rip), %rsi
+    movq $1, %rdx
+    syscall
+    movq $0, %rdx
+panic_type_len:
+    cmpb $0, (%r12,%rdx,1)
+    je panic_type_write
+    incq %rdx
+    jmp panic_type_len
+panic_type_write:
+    movq $1, %rax         # sys_write
+    movq $1, %rdi
+    movq %r12, %rsi
+    syscall
+    leaq panic_close(%rip), %rax
+    call _print_string
+panic_die:
+    movq $60, %rax        # sys_exit
+    movq $2, %rdi         # exit status
+    syscall
+
+# Assigning to an entry of a nil map panics
+_map_nil_panic:
+    leaq map_nil_msg(%rip), %rax
+    jmp _runtime_panic
+
+# Dereferencing a nil pointer panics
+_nil_panic:
+    leaq nil_deref_msg(%rip), %rax
+    jmp _runtime_panic
+
+# Slice indices out of range panic
+_slice_panic:
+    leaq slice_bounds_msg(%rip), %rax
+    jmp _runtime_panic
+
+# Indices out of range panic
+_index_panic:
+    leaq index_msg(%rip), %rax
+    jmp _runtime_panic
+
+# Run-time errors panic with their message as a string value, so that the
+# deferred calls run and recover() can stop them
+_runtime_panic:
+    leaq _type.string(%rip), %rdx
+    jmp _panic
+
+# Integer division by zero panics
+_divide_panic:
+    leaq divide_msg(%rip), %rax
+    jmp _runtime_panic
+
+# Shifting by a negative count panics
+_shift_panic:
+    leaq shift_msg(%rip), %rax
+    jmp _runtime_panic
+
+.section .data
+heap_ptr:
+    .quad heap
+defer_top:
+    .quad 0
+panic_state:
+    .quad 0, 0, 0         # value, type descriptor, panicking
+print_end:
+    .byte 10              # ends what the print functions print
+
+.section .bss
+.p2align 4
+heap:
+    .zero 16777216
+
+.section .rodata
+type_assert_msg:
+    .asciz "interface conversion: type assertion failed"
+map_nil_msg:
+    .asciz "assignment to entry in nil map"
+nil_deref_msg:
+    .asciz "runtime error: invalid memory address or nil pointer dereference"
+slice_bounds_msg:
+    .asciz "runtime error: slice bounds out of range"
+index_msg:
+    .asciz "runtime error: index out of range"
+divide_msg:
+    .asciz "runtime error: integer divide by zero"
+shift_msg:
+    .asciz "runtime error: negative shift amount"
+panic_msg:
+    .ascii "panic: "
+panic_nil:
+    .asciz "nil"
+panic_true:
+    .asciz "true"
+panic_false:
+    .asciz "false"
+panic_open:
+    .ascii "("
+panic_close:
+    .asciz ")"
+panic_string_type:
+    .asciz "string"
+panic_float_type:
+    .asciz "float64"
+panic_bool_type:
+    .asciz "bool"
+panic_int_name:
+    .asciz "int"
+panic_int8_name:
+    .asciz "int8"
+panic_int16_name:
+    .asciz "int16"
+panic_int32_name:
+    .asciz "int32"
+panic_int64_name:
+    .asciz "int64"
+panic_uint_name:
+    .asciz "uint"
+panic_uint8_name:
+    .asciz "uint8"
+panic_uint16_name:
+    .asciz "uint16"
+panic_uint32_name:
+    .asciz "uint32"
+panic_uint64_name:
+    .asciz "uint64"
+.p2align 3
+panic_int_types:
+    .quad panic_int_name, panic_int8_name, panic_int16_name, panic_int32_name, panic_int64_name
+    .quad panic_uint_name, panic_uint8_name, panic_uint16_name, panic_uint32_name, panic_uint64_name, 0
 `
 	return runtime
 }
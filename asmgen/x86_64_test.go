@@ -58,6 +58,13 @@ func TestX86_64Generator_Generate_BinaryOperations(t *testing.T) {
 		{"subtraction", token.SUB, "subq %rbx, %rax"},
 		{"multiplication", token.MUL, "imulq %rbx, %rax"},
 		{"division", token.QUO, "idivq %rbx"},
+		{"remainder", token.REM, "movq %rdx, %rax"},
+		{"and", token.AND, "andq %rbx, %rax"},
+		{"or", token.OR, "orq %rbx, %rax"},
+		{"xor", token.XOR, "xorq %rbx, %rax"},
+		{"and not", token.AND_NOT, "notq %rbx"},
+		{"shift left", token.SHL, "shlq %cl, %rax"},
+		{"shift right", token.SHR, "sarq %cl, %rax"},
 	}
 
 	for _, tt := range tests {
@@ -115,6 +122,9 @@ func TestX86_64Generator_GenerateRuntime(t *testing.T) {
 	if !strings.Contains(runtime, "movb $48, (%rsi)") {
 		t.Error("Missing ASCII '0' conversion")
 	}
+	if !strings.Contains(runtime, "movb $45, -32(%rbp)") {
+		t.Error("Missing minus sign for negative numbers")
+	}
 	if !strings.Contains(runtime, "idivq %rbx") {
 		t.Error("Missing division operation")
 	}
@@ -194,7 +204,7 @@ func TestX86_64Generator_ComprehensiveFeatures(t *testing.T) {
 			Right:    &ast.NumberNode{Value: 10},
 		}
 		update := &ast.IncStatement{
-			Name: "i",
+			Target: &ast.VariableNode{Name: "i"},
 		}
 		printCall := &ast.CallNode{
 			Function:  "println",
@@ -248,7 +258,7 @@ func TestX86_64Generator_ComprehensiveFeatures(t *testing.T) {
 	// Test return statement
 	t.Run("return_statement", func(t *testing.T) {
 		returnStmt := &ast.ReturnStatement{
-			Value: &ast.NumberNode{Value: 42},
+			Values: []ast.ASTNode{&ast.NumberNode{Value: 42}},
 		}
 		blockStmt := &ast.BlockStatement{Statements: []ast.Statement{returnStmt}}
 		funcStmt := &ast.FuncStatement{
@@ -274,8 +284,8 @@ func TestX86_64Generator_ComprehensiveFeatures(t *testing.T) {
 			Value: &ast.NumberNode{Value: 10},
 		}
 		reassignStmt := &ast.ReassignStatement{
-			Name:  "x",
-			Value: &ast.NumberNode{Value: 20},
+			Target: &ast.VariableNode{Name: "x"},
+			Value:  &ast.NumberNode{Value: 20},
 		}
 		blockStmt := &ast.BlockStatement{Statements: []ast.Statement{assignStmt, reassignStmt}}
 		funcStmt := &ast.FuncStatement{
@@ -346,7 +356,7 @@ func TestX86_64Generator_SwitchStatement(t *testing.T) {
 
 	// Create: switch x { case 1: println("one"); default: println("other") }
 	caseStmt := &ast.CaseStatement{
-		Value: &ast.NumberNode{Value: 1},
+		Values: []ast.ASTNode{&ast.NumberNode{Value: 1}},
 		Body: &ast.BlockStatement{
 			Statements: []ast.Statement{
 				&ast.ExpressionStatement{
@@ -370,9 +380,8 @@ func TestX86_64Generator_SwitchStatement(t *testing.T) {
 	}
 
 	switchStmt := &ast.SwitchStatement{
-		Value:   &ast.VariableNode{Name: "x"},
-		Cases:   []*ast.CaseStatement{caseStmt},
-		Default: defaultStmt,
+		Value: &ast.VariableNode{Name: "x"},
+		Cases: []*ast.CaseStatement{caseStmt, {Body: defaultStmt}},
 	}
 
 	// Create variable x := 1
@@ -549,9 +558,9 @@ func TestX86_64Generator_CoverageCompleter(t *testing.T) {
 	t.Run("statement_coverage", func(t *testing.T) {
 		// Test VarStatement
 		varStmt := &ast.VarStatement{
-			Name:     "x",
-			TypeName: "int",
-			Value:    &ast.NumberNode{Value: 42},
+			Name:  "x",
+			Type:  ast.NewIdentType("int"),
+			Value: &ast.NumberNode{Value: 42},
 		}
 		blockStmt := &ast.BlockStatement{Statements: []ast.Statement{varStmt}}
 		funcStmt := &ast.FuncStatement{
@@ -577,7 +586,7 @@ func TestX86_64Generator_CoverageCompleter(t *testing.T) {
 		blockStmt := &ast.BlockStatement{Statements: []ast.Statement{exprStmt}}
 		funcStmt := &ast.FuncStatement{
 			Name:       "test", // Not main
-			Parameters: []ast.Parameter{{Name: "x", Type: "int"}},
+			Parameters: []ast.Parameter{{Name: "x", Type: ast.NewIdentType("int")}},
 			Body:       blockStmt,
 		}
 		statements := []ast.Statement{funcStmt}
@@ -588,3 +597,235 @@ func TestX86_64Generator_CoverageCompleter(t *testing.T) {
 		}
 	})
 }
+
+func TestX86_64Generator_FloatOperations(t *testing.T) {
+	tests := []struct {
+		name     string
+		operator token.Token
+		expected []string
+	}{
+		{"addition", token.ADD, []string{"addsd %xmm1, %xmm0", "movq %xmm0, %rax"}},
+		{"subtraction", token.SUB, []string{"subsd %xmm1, %xmm0"}},
+		{"multiplication", token.MUL, []string{"mulsd %xmm1, %xmm0"}},
+		{"division", token.QUO, []string{"divsd %xmm1, %xmm0"}},
+		{"less than", token.LSS, []string{"ucomisd %xmm0, %xmm1", "seta %al"}},
+		{"equal", token.EQL, []string{"ucomisd %xmm1, %xmm0", "setnp %bl"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewX86_64Generator()
+
+			binOp := &ast.BinaryOpNode{
+				Left:     &ast.FloatNode{Value: 1.5},
+				Operator: tt.operator,
+				Right:    &ast.NumberNode{Value: 2},
+			}
+			printCall := &ast.CallNode{Function: "println", Arguments: []ast.ASTNode{binOp}}
+			funcStmt := &ast.FuncStatement{
+				Name: "main",
+				Body: &ast.BlockStatement{Statements: []ast.Statement{&ast.ExpressionStatement{Expression: printCall}}},
+			}
+
+			result := gen.Generate([]ast.Statement{funcStmt})
+
+			// 1.5 is loaded as raw bits and the int operand is converted
+			if !strings.Contains(result, "movabsq $4609434218613702656, %rax") {
+				t.Error("Missing float64 literal load")
+			}
+			if !strings.Contains(result, "cvtsi2sdq %rax, %xmm0") {
+				t.Error("Missing int to float64 conversion")
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Missing instruction: %s", expected)
+				}
+			}
+		})
+	}
+}
+
+func TestX86_64Generator_FloatVariablesAndPrint(t *testing.T) {
+	gen := NewX86_64Generator()
+
+	// func half(x float64) float64 { return x / 2 }
+	halfFunc := &ast.FuncStatement{
+		Name:       "half",
+		Parameters: []ast.Parameter{{Name: "x", Type: ast.NewIdentType("float64")}},
+		Results:    []ast.Parameter{{Type: ast.NewIdentType("float64")}},
+		Body: &ast.BlockStatement{Statements: []ast.Statement{
+			&ast.ReturnStatement{Values: []ast.ASTNode{&ast.BinaryOpNode{
+				Left:     &ast.VariableNode{Name: "x"},
+				Operator: token.QUO,
+				Right:    &ast.NumberNode{Value: 2},
+			}}},
+		}},
+	}
+	// func main() { println(half(3)); println(int(half(3))) }
+	call := &ast.CallNode{Function: "half", Arguments: []ast.ASTNode{&ast.NumberNode{Value: 3}}}
+	mainFunc := &ast.FuncStatement{
+		Name: "main",
+		Body: &ast.BlockStatement{Statements: []ast.Statement{
+			&ast.ExpressionStatement{Expression: &ast.CallNode{Function: "println", Arguments: []ast.ASTNode{call}}},
+			&ast.ExpressionStatement{Expression: &ast.CallNode{Function: "println", Arguments: []ast.ASTNode{
+				&ast.CallNode{Function: "int", Arguments: []ast.ASTNode{call}},
+			}}},
+		}},
+	}
+
+	result := gen.Generate([]ast.Statement{halfFunc, mainFunc})
+
+	expected := []string{
+		"divsd %xmm1, %xmm0",
+		"call _half",
+		"call _print_float",
+		"cvttsd2si %xmm0, %rax",
+		"call _print_number",
+	}
+	for _, instr := range expected {
+		if !strings.Contains(result, instr) {
+			t.Errorf("Missing instruction: %s", instr)
+		}
+	}
+
+	if !strings.Contains(gen.GenerateRuntime(), "_print_float:") {
+		t.Error("Missing _print_float runtime function")
+	}
+}
+
+func TestX86_64Generator_RuneAndRawString(t *testing.T) {
+	gen := NewX86_64Generator()
+
+	// c := 'a' + 1; println(c); println(`a "b"\n` + newline)
+	funcStmt := &ast.FuncStatement{
+		Name: "main",
+		Body: &ast.BlockStatement{Statements: []ast.Statement{
+			&ast.AssignStatement{Name: "c", Value: &ast.BinaryOpNode{
+				Left:     &ast.RuneNode{Value: 'a'},
+				Operator: token.ADD,
+				Right:    &ast.NumberNode{Value: 1},
+			}},
+			&ast.ExpressionStatement{Expression: &ast.CallNode{Function: "println", Arguments: []ast.ASTNode{&ast.VariableNode{Name: "c"}}}},
+			&ast.ExpressionStatement{Expression: &ast.CallNode{Function: "println", Arguments: []ast.ASTNode{&ast.StringNode{Value: "a \"b\"\\n\nc"}}}},
+		}},
+	}
+
+	result := gen.Generate([]ast.Statement{funcStmt})
+
+	expected := []string{
+		"movq $97, %rax",
+		"movslq %eax, %rax", // int32 arithmetic wraps around
+		"call _print_number",
+		`.asciz "a \"b\"\\n\012c"`,
+		"call _print_string",
+	}
+	for _, instr := range expected {
+		if !strings.Contains(result, instr) {
+			t.Errorf("Missing instruction: %s", instr)
+		}
+	}
+}
+
+func TestX86_64Generator_StringByteIndex(t *testing.T) {
+	gen := NewX86_64Generator()
+
+	// s := "héllo"; println(s[1] - 'a')
+	funcStmt := &ast.FuncStatement{
+		Name: "main",
+		Body: &ast.BlockStatement{Statements: []ast.Statement{
+			&ast.AssignStatement{Name: "s", Value: &ast.StringNode{Value: "héllo"}},
+			&ast.ExpressionStatement{Expression: &ast.CallNode{Function: "println", Arguments: []ast.ASTNode{
+				&ast.BinaryOpNode{
+					Left:     &ast.IndexAccess{Object: &ast.VariableNode{Name: "s"}, Index: &ast.NumberNode{Value: 1}},
+					Operator: token.SUB,
+					Right:    &ast.RuneNode{Value: 'a'},
+				},
+			}}},
+		}},
+	}
+
+	result := gen.Generate([]ast.Statement{funcStmt})
+
+	expected := []string{
+		"movzbq (%rbx,%rax,1), %rax", // load a single byte
+		"movzbq %al, %rax",           // byte arithmetic wraps around
+		`.asciz "h\303\251llo"`,
+	}
+	for _, instr := range expected {
+		if !strings.Contains(result, instr) {
+			t.Errorf("Missing instruction: %s", instr)
+		}
+	}
+	if strings.Contains(result, "salq $3, %rax") {
+		t.Error("String index should not be scaled by 8")
+	}
+}
+
+func TestX86_64Generator_CompoundAssignment(t *testing.T) {
+	gen := NewX86_64Generator()
+
+	// var b byte = 200; b <<= 1
+	funcStmt := &ast.FuncStatement{
+		Name: "main",
+		Body: &ast.BlockStatement{Statements: []ast.Statement{
+			&ast.VarStatement{Name: "b", Type: ast.NewIdentType("byte"), Value: &ast.NumberNode{Value: 200}},
+			&ast.CompoundAssignStatement{Target: &ast.VariableNode{Name: "b"}, Operator: token.SHL_ASSIGN, Value: &ast.NumberNode{Value: 1}},
+		}},
+	}
+
+	result := gen.Generate([]ast.Statement{funcStmt})
+
+	expected := []string{
+		"movq -8(%rbp), %rax", // load b
+		"shlq %cl, %rax",
+		"movzbq %al, %rax", // byte shift wraps around
+		"movq %rax, -8(%rbp)",
+	}
+	for _, instr := range expected {
+		if !strings.Contains(result, instr) {
+			t.Errorf("Missing instruction: %s", instr)
+		}
+	}
+}
+
+func TestX86_64Generator_LogicalAndUnaryOperators(t *testing.T) {
+	gen := NewX86_64Generator()
+
+	// ok := -a < 0 && !done; m := ^a; f := -1.5
+	funcStmt := &ast.FuncStatement{
+		Name: "main",
+		Body: &ast.BlockStatement{Statements: []ast.Statement{
+			&ast.AssignStatement{Name: "a", Value: &ast.NumberNode{Value: 3}},
+			&ast.AssignStatement{Name: "done", Value: &ast.BooleanNode{Value: false}},
+			&ast.AssignStatement{Name: "ok", Value: &ast.BinaryOpNode{
+				Left: &ast.BinaryOpNode{
+					Left:     &ast.UnaryOpNode{Operator: token.SUB, Operand: &ast.VariableNode{Name: "a"}},
+					Operator: token.LSS,
+					Right:    &ast.NumberNode{Value: 0},
+				},
+				Operator: token.LAND,
+				Right:    &ast.UnaryOpNode{Operator: token.NOT, Operand: &ast.VariableNode{Name: "done"}},
+			}},
+			&ast.AssignStatement{Name: "m", Value: &ast.UnaryOpNode{Operator: token.XOR, Operand: &ast.VariableNode{Name: "a"}}},
+			&ast.AssignStatement{Name: "f", Value: &ast.UnaryOpNode{Operator: token.SUB, Operand: &ast.FloatNode{Value: 1.5}}},
+		}},
+	}
+
+	result := gen.Generate([]ast.Statement{funcStmt})
+
+	expected := []string{
+		"negq %rax",
+		"jz L", // && skips the right operand when the left one is false
+		"xorq $1, %rax",
+		"notq %rax",
+		"btcq $63, %rax",
+	}
+	for _, instr := range expected {
+		if !strings.Contains(result, instr) {
+			t.Errorf("Missing instruction: %s", instr)
+		}
+	}
+	if got := gen.types.exprType(&ast.UnaryOpNode{Operator: token.SUB, Operand: &ast.FloatNode{Value: 1}}); got != "float64" {
+		t.Errorf("expected float64 for negated float, got %s", got)
+	}
+}
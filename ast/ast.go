@@ -3,61 +3,123 @@ package ast
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
+	"github.com/yuya-takeyama/petitgo/constant"
 	"github.com/yuya-takeyama/petitgo/token"
 )
 
 // ASTNode interface for all AST nodes
 type ASTNode interface {
 	String() string
+	Position() token.Position
 }
 
 // NumberNode represents a numeric literal
 type NumberNode struct {
-	Value int
+	Value   int
+	Literal string // source text, whose value may not fit in an int
+	Pos     token.Position
 }
 
 func (n *NumberNode) String() string {
 	return "NumberNode"
 }
 
+func (n *NumberNode) Position() token.Position {
+	return n.Pos
+}
+
 func (n *NumberNode) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, withLiteral(n.Literal, map[string]interface{}{
 		"type":  "NumberNode",
 		"value": n.Value,
-	})
+	})))
+}
+
+// FloatNode represents a floating-point literal
+type FloatNode struct {
+	Value   float64
+	Literal string // source text, whose exact value float64 may only approximate
+	Pos     token.Position
+}
+
+func (n *FloatNode) String() string {
+	return "FloatNode"
+}
+
+func (n *FloatNode) Position() token.Position {
+	return n.Pos
+}
+
+func (n *FloatNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, withLiteral(n.Literal, map[string]interface{}{
+		"type":  "FloatNode",
+		"value": n.Value,
+	})))
+}
+
+// RuneNode represents a rune literal such as 'a'
+type RuneNode struct {
+	Value rune
+	Pos   token.Position
+}
+
+func (n *RuneNode) String() string {
+	return "RuneNode"
+}
+
+func (n *RuneNode) Position() token.Position {
+	return n.Pos
+}
+
+func (n *RuneNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":  "RuneNode",
+		"value": n.Value,
+	}))
 }
 
 // BooleanNode represents a boolean literal
 type BooleanNode struct {
 	Value bool
+	Pos   token.Position
 }
 
 func (n *BooleanNode) String() string {
 	return "BooleanNode"
 }
 
+func (n *BooleanNode) Position() token.Position {
+	return n.Pos
+}
+
 func (n *BooleanNode) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type":  "BooleanNode",
 		"value": n.Value,
-	})
+	}))
 }
 
 // StringNode represents a string literal
 type StringNode struct {
 	Value string
+	Pos   token.Position
 }
 
 func (n *StringNode) String() string {
 	return "StringNode"
 }
 
+func (n *StringNode) Position() token.Position {
+	return n.Pos
+}
+
 func (n *StringNode) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type":  "StringNode",
 		"value": n.Value,
-	})
+	}))
 }
 
 // BinaryOpNode represents a binary operation
@@ -65,307 +127,792 @@ type BinaryOpNode struct {
 	Left     ASTNode
 	Operator token.Token
 	Right    ASTNode
+	Pos      token.Position
 }
 
 func (n *BinaryOpNode) String() string {
 	return "BinaryOpNode"
 }
 
+func (n *BinaryOpNode) Position() token.Position {
+	return n.Pos
+}
+
 func (n *BinaryOpNode) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type":     "BinaryOpNode",
 		"left":     n.Left,
 		"operator": tokenToString(n.Operator),
 		"right":    n.Right,
-	})
+	}))
+}
+
+// UnaryOpNode represents a unary operation (-x, +x, !x, ^x) or taking the
+// address of an operand (&x, &p.field, &T{...})
+type UnaryOpNode struct {
+	Operator token.Token
+	Operand  ASTNode
+	Pos      token.Position
+}
+
+func (n *UnaryOpNode) String() string {
+	return "UnaryOpNode"
+}
+
+func (n *UnaryOpNode) Position() token.Position {
+	return n.Pos
+}
+
+func (n *UnaryOpNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":     "UnaryOpNode",
+		"operator": tokenToString(n.Operator),
+		"operand":  n.Operand,
+	}))
+}
+
+// DerefNode represents the value a pointer points to (*p)
+type DerefNode struct {
+	Operand ASTNode
+	Pos     token.Position
+}
+
+func (n *DerefNode) String() string {
+	return "DerefNode"
+}
+
+func (n *DerefNode) Position() token.Position {
+	return n.Pos
+}
+
+func (n *DerefNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":    "DerefNode",
+		"operand": n.Operand,
+	}))
+}
+
+// NewNode represents a call of the built-in new, which allocates a zero
+// value of a type and returns a pointer to it (new(T))
+type NewNode struct {
+	Type Type
+	Pos  token.Position
+}
+
+func (n *NewNode) String() string {
+	return "NewNode"
+}
+
+func (n *NewNode) Position() token.Position {
+	return n.Pos
+}
+
+func (n *NewNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":    "NewNode",
+		"newType": n.Type,
+	}))
 }
 
 // VariableNode represents a variable reference
 type VariableNode struct {
 	Name string
+	Pos  token.Position
 }
 
 func (n *VariableNode) String() string {
 	return "VariableNode"
 }
 
+func (n *VariableNode) Position() token.Position {
+	return n.Pos
+}
+
 func (n *VariableNode) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type": "VariableNode",
 		"name": n.Name,
-	})
+	}))
+}
+
+// ConstExpr represents a constant expression that the parser has evaluated:
+// a use of a declared constant or of iota, or an expression built from them.
+// Expression is the expression as written and Value its exact value.
+type ConstExpr struct {
+	Expression ASTNode
+	Value      constant.Value
+	Pos        token.Position
+}
+
+func (n *ConstExpr) String() string {
+	return "ConstExpr"
+}
+
+func (n *ConstExpr) Position() token.Position {
+	return n.Pos
+}
+
+func (n *ConstExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":       "ConstExpr",
+		"expression": n.Expression,
+		"value":      constantJSON(n.Value),
+	}))
+}
+
+// constantJSON describes a constant value and its type for JSON output
+func constantJSON(v constant.Value) map[string]interface{} {
+	return map[string]interface{}{
+		"type":  v.Type,
+		"value": v.String(),
+	}
 }
 
 // FieldAccessNode represents field access (obj.field)
 type FieldAccessNode struct {
 	Object ASTNode
 	Field  string
+	Pos    token.Position
 }
 
 func (n *FieldAccessNode) String() string {
 	return "FieldAccessNode"
 }
 
+func (n *FieldAccessNode) Position() token.Position {
+	return n.Pos
+}
+
 func (n *FieldAccessNode) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type":   "FieldAccessNode",
 		"object": n.Object,
 		"field":  n.Field,
-	})
+	}))
 }
 
 // CallNode represents a function call (function(args...))
 type CallNode struct {
 	Function  string
+	Receiver  ASTNode // receiver of a method call recv.Function(...); nil for function calls
+	Callee    ASTNode // called expression other than a name, as in f(1)(2); Function is "" then
 	Arguments []ASTNode
+	Pos       token.Position
 }
 
 func (n *CallNode) String() string {
 	return "CallNode"
 }
 
+func (n *CallNode) Position() token.Position {
+	return n.Pos
+}
+
 func (n *CallNode) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	fields := map[string]interface{}{
 		"type":      "CallNode",
 		"function":  n.Function,
+		"receiver":  n.Receiver,
 		"arguments": n.Arguments,
-	})
+	}
+	if n.Callee != nil {
+		fields["callee"] = n.Callee
+	}
+	return json.Marshal(withPos(n.Pos, fields))
 }
 
 // Statement interface for all statement nodes
 type Statement interface {
 	String() string
+	Position() token.Position
 }
 
-// VarStatement represents a variable declaration (var x int = 42)
+// VarStatement represents a variable declaration (var x int = 42). Type
+// is nil when the type is inferred from the value, and Value is nil when
+// the variable starts as the zero value of its type.
 type VarStatement struct {
-	Name     string
-	TypeName string
-	Value    ASTNode
+	Name  string
+	Type  Type
+	Value ASTNode
+	Pos   token.Position
 }
 
 func (n *VarStatement) String() string {
 	return "VarStatement"
 }
 
+func (n *VarStatement) Position() token.Position {
+	return n.Pos
+}
+
 func (n *VarStatement) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"type":     "VarStatement",
-		"name":     n.Name,
-		"typeName": n.TypeName,
-		"value":    n.Value,
-	})
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":    "VarStatement",
+		"name":    n.Name,
+		"varType": n.Type,
+		"value":   n.Value,
+	}))
+}
+
+// TupleVarStatement represents a variable declaration of several names
+// (var a, b = 1, 2, var q, r int or var q, r = divmod(7, 2)). Type is nil
+// when the types are inferred from the values, and Values is nil when the
+// variables start as zero values.
+type TupleVarStatement struct {
+	Names  []string // "_" discards the value
+	Type   Type
+	Values []ASTNode
+	Pos    token.Position
+}
+
+func (n *TupleVarStatement) String() string {
+	return "TupleVarStatement"
+}
+
+func (n *TupleVarStatement) Position() token.Position {
+	return n.Pos
+}
+
+func (n *TupleVarStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":    "TupleVarStatement",
+		"names":   n.Names,
+		"varType": n.Type,
+		"values":  n.Values,
+	}))
+}
+
+// DeclGroup represents a parenthesized group of declarations introduced by
+// a single keyword: var ( x int; y = 2 ), import ( "fmt"; "os" ) or
+// type ( ... ). Each entry of Decls is the declaration it would be on its
+// own line.
+type DeclGroup struct {
+	Keyword string // "var", "import" or "type"
+	Decls   []Statement
+	Pos     token.Position
+}
+
+func (n *DeclGroup) String() string {
+	return "DeclGroup"
+}
+
+func (n *DeclGroup) Position() token.Position {
+	return n.Pos
+}
+
+func (n *DeclGroup) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":    "DeclGroup",
+		"keyword": n.Keyword,
+		"decls":   n.Decls,
+	}))
+}
+
+// ConstStatement represents a constant declaration, either a single spec
+// (const x = 1) or a parenthesized group (const ( a = iota; b ))
+type ConstStatement struct {
+	Specs []*ConstSpec
+	Pos   token.Position
+}
+
+func (n *ConstStatement) String() string {
+	return "ConstStatement"
+}
+
+func (n *ConstStatement) Position() token.Position {
+	return n.Pos
+}
+
+func (n *ConstStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":  "ConstStatement",
+		"specs": n.Specs,
+	}))
+}
+
+// ConstSpec represents one line of a constant declaration. Values is nil
+// when the line repeats the expressions of the previous one, as in the
+// second line of const ( A = iota; B ). Constants holds the value of each
+// name, evaluated by the parser with the iota of the line.
+type ConstSpec struct {
+	Names     []string
+	Type      Type // nil for untyped constants
+	Values    []ASTNode
+	Iota      int
+	Constants []constant.Value
+	Pos       token.Position
+}
+
+func (s *ConstSpec) MarshalJSON() ([]byte, error) {
+	constants := make([]map[string]interface{}, len(s.Constants))
+	for i, v := range s.Constants {
+		constants[i] = constantJSON(v)
+	}
+	return json.Marshal(withPos(s.Pos, map[string]interface{}{
+		"names":     s.Names,
+		"type":      s.Type,
+		"values":    s.Values,
+		"iota":      s.Iota,
+		"constants": constants,
+	}))
 }
 
 // AssignStatement represents an assignment (x := 42 or x = 42)
 type AssignStatement struct {
 	Name  string
 	Value ASTNode
+	Pos   token.Position
 }
 
 func (n *AssignStatement) String() string {
 	return "AssignStatement"
 }
 
+func (n *AssignStatement) Position() token.Position {
+	return n.Pos
+}
+
 func (n *AssignStatement) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type":  "AssignStatement",
 		"name":  n.Name,
 		"value": n.Value,
-	})
+	}))
 }
 
-// ReassignStatement represents a variable reassignment (x = 42)
+// ReassignStatement represents an assignment to an existing variable or
+// to an addressable expression: x = 42, xs[i] = v, p.age = 3, *p = v.
+// Target is a VariableNode, an IndexAccess (including map entries), a
+// FieldAccessNode or a DerefNode.
 type ReassignStatement struct {
-	Name  string
-	Value ASTNode
+	Target ASTNode
+	Value  ASTNode
+	Pos    token.Position
 }
 
 func (n *ReassignStatement) String() string {
 	return "ReassignStatement"
 }
 
+func (n *ReassignStatement) Position() token.Position {
+	return n.Pos
+}
+
 func (n *ReassignStatement) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"type":  "ReassignStatement",
-		"name":  n.Name,
-		"value": n.Value,
-	})
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":   "ReassignStatement",
+		"target": n.Target,
+		"value":  n.Value,
+	}))
+}
+
+// TupleAssignStatement represents an assignment of several values at once
+// (a, b := 1, 2 or xs[i], xs[j] = xs[j], xs[i]). A single value on the
+// right-hand side is a call returning multiple results. The targets are
+// assignable like that of a ReassignStatement; those of := are variables.
+type TupleAssignStatement struct {
+	Targets []ASTNode // the variable "_" discards the value
+	Define  bool      // true for :=
+	Values  []ASTNode
+	Pos     token.Position
+}
+
+// Names returns the names of the variables assigned to, with "" for the
+// targets that are not variables
+func (n *TupleAssignStatement) Names() []string {
+	names := make([]string, len(n.Targets))
+	for i, target := range n.Targets {
+		if variable, ok := target.(*VariableNode); ok {
+			names[i] = variable.Name
+		}
+	}
+	return names
+}
+
+func (n *TupleAssignStatement) String() string {
+	return "TupleAssignStatement"
+}
+
+func (n *TupleAssignStatement) Position() token.Position {
+	return n.Pos
 }
 
-// CompoundAssignStatement represents compound assignment (x += y, x -= y, etc.)
+func (n *TupleAssignStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":    "TupleAssignStatement",
+		"targets": n.Targets,
+		"define":  n.Define,
+		"values":  n.Values,
+	}))
+}
+
+// CompoundAssignStatement represents compound assignment (x += y,
+// grid[y][x] -= 1, etc.). Target is assignable like that of a
+// ReassignStatement.
 type CompoundAssignStatement struct {
-	Name     string
+	Target   ASTNode
 	Operator token.Token // ADD_ASSIGN, SUB_ASSIGN, etc.
 	Value    ASTNode
+	Pos      token.Position
 }
 
 func (n *CompoundAssignStatement) String() string {
 	return "CompoundAssignStatement"
 }
 
+func (n *CompoundAssignStatement) Position() token.Position {
+	return n.Pos
+}
+
 func (n *CompoundAssignStatement) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type":     "CompoundAssignStatement",
-		"name":     n.Name,
+		"target":   n.Target,
 		"operator": tokenToString(n.Operator),
 		"value":    n.Value,
-	})
+	}))
 }
 
-// IncStatement represents an increment statement (x++)
+// IncStatement represents an increment statement (x++, p.age++)
 type IncStatement struct {
-	Name string
+	Target ASTNode
+	Pos    token.Position
 }
 
 func (n *IncStatement) String() string {
 	return "IncStatement"
 }
 
+func (n *IncStatement) Position() token.Position {
+	return n.Pos
+}
+
 func (n *IncStatement) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"type": "IncStatement",
-		"name": n.Name,
-	})
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":   "IncStatement",
+		"target": n.Target,
+	}))
 }
 
-// DecStatement represents a decrement statement (x--)
+// DecStatement represents a decrement statement (x--, xs[i]--)
 type DecStatement struct {
-	Name string
+	Target ASTNode
+	Pos    token.Position
 }
 
 func (n *DecStatement) String() string {
 	return "DecStatement"
 }
 
+func (n *DecStatement) Position() token.Position {
+	return n.Pos
+}
+
 func (n *DecStatement) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"type": "DecStatement",
-		"name": n.Name,
-	})
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":   "DecStatement",
+		"target": n.Target,
+	}))
 }
 
-// SwitchStatement represents a switch statement
+// SwitchStatement represents a switch statement. Value is nil for a tagless
+// switch, which selects the first case whose value is true. Cases holds the
+// clauses in source order, including the default clause.
 type SwitchStatement struct {
-	Value   ASTNode
-	Cases   []*CaseStatement
-	Default *BlockStatement
+	Init  Statement // optional init statement
+	Value ASTNode
+	Cases []*CaseStatement
+	Pos   token.Position
 }
 
 func (n *SwitchStatement) String() string {
 	return "SwitchStatement"
 }
 
+func (n *SwitchStatement) Position() token.Position {
+	return n.Pos
+}
+
 func (n *SwitchStatement) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"type":    "SwitchStatement",
-		"value":   n.Value,
-		"cases":   n.Cases,
-		"default": n.Default,
-	})
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":  "SwitchStatement",
+		"init":  n.Init,
+		"value": n.Value,
+		"cases": n.Cases,
+	}))
 }
 
-// CaseStatement represents a case in a switch
+// CaseStatement represents a case clause in a switch. Values is nil for the
+// default clause.
 type CaseStatement struct {
-	Value ASTNode
-	Body  *BlockStatement
+	Values []ASTNode
+	Body   *BlockStatement
+	Pos    token.Position
+}
+
+// IsDefault reports whether the clause is the default clause
+func (n *CaseStatement) IsDefault() bool {
+	return n.Values == nil
+}
+
+// Fallthrough reports whether the clause ends with a fallthrough statement
+func (n *CaseStatement) Fallthrough() bool {
+	if n.Body == nil || len(n.Body.Statements) == 0 {
+		return false
+	}
+	_, ok := n.Body.Statements[len(n.Body.Statements)-1].(*FallthroughStatement)
+	return ok
 }
 
 func (n *CaseStatement) String() string {
 	return "CaseStatement"
 }
 
+func (n *CaseStatement) Position() token.Position {
+	return n.Pos
+}
+
 func (n *CaseStatement) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"type":  "CaseStatement",
-		"value": n.Value,
-		"body":  n.Body,
-	})
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":    "CaseStatement",
+		"values":  n.Values,
+		"default": n.IsDefault(),
+		"body":    n.Body,
+	}))
+}
+
+// FallthroughStatement represents a fallthrough statement at the end of a
+// case clause
+type FallthroughStatement struct {
+	Pos token.Position
+}
+
+func (n *FallthroughStatement) String() string {
+	return "FallthroughStatement"
+}
+
+func (n *FallthroughStatement) Position() token.Position {
+	return n.Pos
+}
+
+func (n *FallthroughStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type": "FallthroughStatement",
+	}))
+}
+
+// TypeSwitchStatement represents a type switch
+// (switch v := x.(type) { case int: ... case Shape, nil: ... })
+type TypeSwitchStatement struct {
+	Init    Statement // optional init statement; nil when absent
+	Binding string    // variable bound in each clause; "" when absent
+	Value   ASTNode   // the interface value x of x.(type)
+	Cases   []*TypeCaseClause
+	Pos     token.Position
+}
+
+func (n *TypeSwitchStatement) String() string {
+	return "TypeSwitchStatement"
+}
+
+func (n *TypeSwitchStatement) Position() token.Position {
+	return n.Pos
+}
+
+func (n *TypeSwitchStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":    "TypeSwitchStatement",
+		"init":    n.Init,
+		"binding": n.Binding,
+		"value":   n.Value,
+		"cases":   n.Cases,
+	}))
+}
+
+// TypeCaseClause represents one clause of a type switch
+type TypeCaseClause struct {
+	Types []string // nil for the default clause; "nil" matches a nil interface
+	Body  *BlockStatement
+	Pos   token.Position
+}
+
+func (n *TypeCaseClause) String() string {
+	return "TypeCaseClause"
+}
+
+func (n *TypeCaseClause) Position() token.Position {
+	return n.Pos
+}
+
+// IsDefault reports whether the clause is the default clause
+func (n *TypeCaseClause) IsDefault() bool {
+	return n.Types == nil
+}
+
+func (n *TypeCaseClause) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":    "TypeCaseClause",
+		"types":   n.Types,
+		"default": n.IsDefault(),
+		"body":    n.Body,
+	}))
 }
 
 // TypeStatement represents a type definition (type Name struct {...})
 type TypeStatement struct {
 	Name   string
 	Fields []*FieldDef
+	Pos    token.Position
 }
 
 func (n *TypeStatement) String() string {
 	return "TypeStatement"
 }
 
+func (n *TypeStatement) Position() token.Position {
+	return n.Pos
+}
+
 func (n *TypeStatement) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type":   "TypeStatement",
 		"name":   n.Name,
 		"fields": n.Fields,
-	})
+	}))
 }
 
 // FieldDef represents a field definition in struct
 type FieldDef struct {
 	Name string
-	Type string
+	Type Type
+	Pos  token.Position
+}
+
+func (f *FieldDef) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(f.Pos, map[string]interface{}{
+		"name": f.Name,
+		"type": f.Type,
+	}))
 }
 
-// ArrayLiteral represents an array literal [3]int{1, 2, 3}
+// ArrayLiteral represents an array literal [3]int{1, 2, 3}. The Size of
+// [...]int{1, 2, 3} is the number of its elements.
 type ArrayLiteral struct {
-	ElementType string
+	ElementType Type
 	Size        int
 	Elements    []ASTNode
+	Pos         token.Position
 }
 
 func (n *ArrayLiteral) String() string {
 	return "ArrayLiteral"
 }
 
+func (n *ArrayLiteral) Position() token.Position {
+	return n.Pos
+}
+
 func (n *ArrayLiteral) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type":        "ArrayLiteral",
 		"elementType": n.ElementType,
 		"size":        n.Size,
 		"elements":    n.Elements,
-	})
+	}))
 }
 
-// InterfaceStatement represents interface definition
+// TypeName returns the array type of the literal, such as [3]int
+func (n *ArrayLiteral) TypeName() string {
+	return (&ArrayType{Len: n.Size, Elem: n.ElementType}).TypeName()
+}
+
+// InterfaceStatement represents interface definition (type Name interface {...})
 type InterfaceStatement struct {
-	Name    string
-	Methods []*MethodDef
+	Name     string
+	Methods  []*MethodDef
+	Embedded []string // names of the embedded interfaces
+	Pos      token.Position
 }
 
 func (n *InterfaceStatement) String() string {
 	return "InterfaceStatement"
 }
 
+func (n *InterfaceStatement) Position() token.Position {
+	return n.Pos
+}
+
 func (n *InterfaceStatement) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"type":    "InterfaceStatement",
-		"name":    n.Name,
-		"methods": n.Methods,
-	})
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":     "InterfaceStatement",
+		"name":     n.Name,
+		"methods":  n.Methods,
+		"embedded": n.Embedded,
+	}))
+}
+
+// MethodSet returns the methods of the interface together with those of
+// the interfaces it embeds, which lookup finds by name. A method declared
+// more than once is listed once, and unknown or cyclic embedded
+// interfaces add no methods.
+func (n *InterfaceStatement) MethodSet(lookup func(name string) (*InterfaceStatement, bool)) []*MethodDef {
+	var methods []*MethodDef
+	seen := make(map[string]bool)
+	visited := make(map[string]bool)
+	var collect func(iface *InterfaceStatement)
+	collect = func(iface *InterfaceStatement) {
+		visited[iface.Name] = true
+		for _, method := range iface.Methods {
+			if !seen[method.Name] {
+				seen[method.Name] = true
+				methods = append(methods, method)
+			}
+		}
+		for _, name := range iface.Embedded {
+			if embedded, exists := lookup(name); exists && !visited[name] {
+				collect(embedded)
+			}
+		}
+	}
+	collect(n)
+	return methods
 }
 
 // MethodDef represents method definition in interface
 type MethodDef struct {
 	Name       string
-	Parameters []*Parameter
-	ReturnType string
+	Parameters []Parameter
+	Results    []Parameter
+	Pos        token.Position
+}
+
+func (m *MethodDef) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(m.Pos, map[string]interface{}{
+		"name":       m.Name,
+		"parameters": m.Parameters,
+		"results":    m.Results,
+	}))
 }
 
 // BlockStatement represents a block of statements ({ ... })
 type BlockStatement struct {
 	Statements []Statement
+	Pos        token.Position
 }
 
 func (n *BlockStatement) String() string {
 	return "BlockStatement"
 }
 
+func (n *BlockStatement) Position() token.Position {
+	return n.Pos
+}
+
 func (n *BlockStatement) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type":       "BlockStatement",
 		"statements": n.Statements,
-	})
+	}))
 }
 
 // IfStatement represents an if statement (if condition { block } else { block })
@@ -373,19 +920,24 @@ type IfStatement struct {
 	Condition ASTNode
 	ThenBlock *BlockStatement
 	ElseBlock *BlockStatement // nil if no else
+	Pos       token.Position
 }
 
 func (n *IfStatement) String() string {
 	return "IfStatement"
 }
 
+func (n *IfStatement) Position() token.Position {
+	return n.Pos
+}
+
 func (n *IfStatement) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type":      "IfStatement",
 		"condition": n.Condition,
 		"thenBlock": n.ThenBlock,
 		"elseBlock": n.ElseBlock,
-	})
+	}))
 }
 
 // ForStatement represents a for loop (for init; condition; update { block })
@@ -394,266 +946,633 @@ type ForStatement struct {
 	Condition ASTNode   // nil for infinite loops
 	Update    Statement // nil for condition-only for loops
 	Body      *BlockStatement
+	Pos       token.Position
 }
 
 func (n *ForStatement) String() string {
 	return "ForStatement"
 }
 
+func (n *ForStatement) Position() token.Position {
+	return n.Pos
+}
+
 func (n *ForStatement) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type":      "ForStatement",
 		"init":      n.Init,
 		"condition": n.Condition,
 		"update":    n.Update,
 		"body":      n.Body,
-	})
+	}))
+}
+
+// RangeStatement represents a for statement with a range clause:
+// for key, value := range Expression { ... }
+type RangeStatement struct {
+	Key        string // "" when omitted
+	Value      string // "" when omitted
+	Define     bool   // := declares new variables for each iteration, = assigns existing ones
+	Expression ASTNode
+	Body       *BlockStatement
+	Pos        token.Position
+}
+
+func (n *RangeStatement) String() string {
+	return "RangeStatement"
+}
+
+func (n *RangeStatement) Position() token.Position {
+	return n.Pos
+}
+
+func (n *RangeStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":       "RangeStatement",
+		"key":        n.Key,
+		"value":      n.Value,
+		"define":     n.Define,
+		"expression": n.Expression,
+		"body":       n.Body,
+	}))
 }
 
-// BreakStatement represents a break statement
-type BreakStatement struct{}
+// BreakStatement represents a break statement. Label is empty unless it
+// breaks out of the labeled statement: break outer.
+type BreakStatement struct {
+	Label string
+	Pos   token.Position
+}
 
 func (n *BreakStatement) String() string {
 	return "BreakStatement"
 }
 
+func (n *BreakStatement) Position() token.Position {
+	return n.Pos
+}
+
 func (n *BreakStatement) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"type": "BreakStatement",
-	})
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":  "BreakStatement",
+		"label": n.Label,
+	}))
 }
 
-// ContinueStatement represents a continue statement
-type ContinueStatement struct{}
+// ContinueStatement represents a continue statement. Label is empty unless
+// it continues the labeled loop: continue outer.
+type ContinueStatement struct {
+	Label string
+	Pos   token.Position
+}
 
 func (n *ContinueStatement) String() string {
 	return "ContinueStatement"
 }
 
+func (n *ContinueStatement) Position() token.Position {
+	return n.Pos
+}
+
 func (n *ContinueStatement) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"type": "ContinueStatement",
-	})
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":  "ContinueStatement",
+		"label": n.Label,
+	}))
+}
+
+// GotoStatement represents a goto statement: goto Label
+type GotoStatement struct {
+	Label string
+	Pos   token.Position
+}
+
+func (n *GotoStatement) String() string {
+	return "GotoStatement"
+}
+
+func (n *GotoStatement) Position() token.Position {
+	return n.Pos
+}
+
+func (n *GotoStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":  "GotoStatement",
+		"label": n.Label,
+	}))
+}
+
+// DeferStatement represents a defer statement: defer f(x). The function
+// value and the arguments are evaluated at the defer statement, and the call
+// runs when the surrounding function returns.
+type DeferStatement struct {
+	Call *CallNode
+	Pos  token.Position
+}
+
+func (n *DeferStatement) String() string {
+	return "DeferStatement"
+}
+
+func (n *DeferStatement) Position() token.Position {
+	return n.Pos
+}
+
+func (n *DeferStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type": "DeferStatement",
+		"call": n.Call,
+	}))
+}
+
+// LabeledStatement represents a statement with a label: outer: for { ... }.
+// A label right before the closing brace of a block labels an
+// EmptyStatement.
+type LabeledStatement struct {
+	Label     string
+	Statement Statement
+	Pos       token.Position
+}
+
+func (n *LabeledStatement) String() string {
+	return "LabeledStatement"
+}
+
+func (n *LabeledStatement) Position() token.Position {
+	return n.Pos
+}
+
+func (n *LabeledStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":      "LabeledStatement",
+		"label":     n.Label,
+		"statement": n.Statement,
+	}))
+}
+
+// EmptyStatement represents the empty statement a label may be attached to
+type EmptyStatement struct {
+	Pos token.Position
+}
+
+func (n *EmptyStatement) String() string {
+	return "EmptyStatement"
+}
+
+func (n *EmptyStatement) Position() token.Position {
+	return n.Pos
+}
+
+func (n *EmptyStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type": "EmptyStatement",
+	}))
 }
 
 // ExpressionStatement represents an expression as a statement
 type ExpressionStatement struct {
 	Expression ASTNode
+	Pos        token.Position
 }
 
 func (n *ExpressionStatement) String() string {
 	return "ExpressionStatement"
 }
 
+func (n *ExpressionStatement) Position() token.Position {
+	return n.Pos
+}
+
 func (n *ExpressionStatement) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type":       "ExpressionStatement",
 		"expression": n.Expression,
-	})
+	}))
 }
 
 // Parameter represents a function parameter
 type Parameter struct {
 	Name string
-	Type string
+	Type Type
+	Pos  token.Position
 }
 
 func (p *Parameter) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(p.Pos, map[string]interface{}{
 		"name": p.Name,
 		"type": p.Type,
-	})
+	}))
 }
 
-// FuncStatement represents a function definition
+// FuncStatement represents a function or method definition
 type FuncStatement struct {
 	Name       string
+	Receiver   *Parameter // nil for functions; Type is a PointerType for a pointer receiver
 	Parameters []Parameter
-	ReturnType string
+	Results    []Parameter // Name is empty for unnamed results
 	Body       *BlockStatement
+	Pos        token.Position
 }
 
 func (n *FuncStatement) String() string {
 	return "FuncStatement"
 }
 
+func (n *FuncStatement) Position() token.Position {
+	return n.Pos
+}
+
 func (n *FuncStatement) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type":       "FuncStatement",
 		"name":       n.Name,
+		"receiver":   n.Receiver,
+		"parameters": n.Parameters,
+		"results":    n.Results,
+		"body":       n.Body,
+	}))
+}
+
+// ReceiverType returns the name of the receiver base type of a method
+// ("Person" for both (p Person) and (p *Person)), or "" for a function
+func (n *FuncStatement) ReceiverType() string {
+	if n.Receiver == nil {
+		return ""
+	}
+	return strings.TrimPrefix(TypeName(n.Receiver.Type), "*")
+}
+
+// HasNamedResults reports whether the results of the function are named
+func (n *FuncStatement) HasNamedResults() bool {
+	return len(n.Results) > 0 && n.Results[0].Name != ""
+}
+
+// FuncLiteral represents a function literal (func(x int) int { ... }).
+// It is a closure over the variables of the enclosing function.
+type FuncLiteral struct {
+	Parameters []Parameter
+	Results    []Parameter // Name is empty for unnamed results
+	Body       *BlockStatement
+	Pos        token.Position
+}
+
+func (n *FuncLiteral) String() string {
+	return "FuncLiteral"
+}
+
+func (n *FuncLiteral) Position() token.Position {
+	return n.Pos
+}
+
+func (n *FuncLiteral) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":       "FuncLiteral",
 		"parameters": n.Parameters,
-		"returnType": n.ReturnType,
+		"results":    n.Results,
 		"body":       n.Body,
-	})
+	}))
+}
+
+// TypeName returns the function type of the literal, such as func(int) int
+func (n *FuncLiteral) TypeName() string {
+	return FuncTypeName(ParameterTypes(n.Parameters), ParameterTypes(n.Results))
+}
+
+// ParameterTypes returns the names of the types of a parameter or result list
+func ParameterTypes(params []Parameter) []string {
+	types := make([]string, len(params))
+	for i, param := range params {
+		types[i] = TypeName(param.Type)
+	}
+	return types
 }
 
 // ReturnStatement represents a return statement
 type ReturnStatement struct {
-	Value ASTNode // nil for empty return
+	Values []ASTNode // empty for a bare return
+	Pos    token.Position
 }
 
 func (n *ReturnStatement) String() string {
 	return "ReturnStatement"
 }
 
+func (n *ReturnStatement) Position() token.Position {
+	return n.Pos
+}
+
 func (n *ReturnStatement) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"type":  "ReturnStatement",
-		"value": n.Value,
-	})
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":   "ReturnStatement",
+		"values": n.Values,
+	}))
 }
 
 // StructField represents a field in a struct definition
 type StructField struct {
 	Name string
 	Type string
+	Pos  token.Position
 }
 
 func (sf *StructField) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(sf.Pos, map[string]interface{}{
 		"name": sf.Name,
 		"type": sf.Type,
-	})
+	}))
 }
 
 // StructDefinition represents a struct type definition (type Person struct { ... })
 type StructDefinition struct {
 	Name   string
 	Fields []StructField
+	Pos    token.Position
 }
 
 func (n *StructDefinition) String() string {
 	return "StructDefinition"
 }
 
+func (n *StructDefinition) Position() token.Position {
+	return n.Pos
+}
+
 func (n *StructDefinition) statement() {}
 
 func (n *StructDefinition) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type":   "StructDefinition",
 		"name":   n.Name,
 		"fields": n.Fields,
-	})
+	}))
 }
 
 // StructLiteral represents a struct literal (Person{Name: "Alice", Age: 25})
 type StructLiteral struct {
 	TypeName string
 	Fields   map[string]ASTNode // field name -> value expression
+	Values   []ASTNode          // values in field order, for Point{1, 2}
+	Pos      token.Position
+}
+
+// FieldValues returns the value expressions of the literal by field name.
+// Values given in field order are matched with names, the field names of
+// the struct type in declaration order; extra values are dropped.
+func (n *StructLiteral) FieldValues(names []string) map[string]ASTNode {
+	if n.Values == nil {
+		return n.Fields
+	}
+	fields := make(map[string]ASTNode, len(n.Values))
+	for i, value := range n.Values {
+		if i < len(names) {
+			fields[names[i]] = value
+		}
+	}
+	return fields
 }
 
 func (n *StructLiteral) String() string {
 	return "StructLiteral"
 }
 
+func (n *StructLiteral) Position() token.Position {
+	return n.Pos
+}
+
 func (n *StructLiteral) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type":     "StructLiteral",
 		"typeName": n.TypeName,
 		"fields":   n.Fields,
-	})
+		"values":   n.Values,
+	}))
 }
 
 // FieldAccess represents field access (person.Name)
 type FieldAccess struct {
 	Object ASTNode // the struct instance
 	Field  string  // field name
+	Pos    token.Position
 }
 
 func (n *FieldAccess) String() string {
 	return "FieldAccess"
 }
 
+func (n *FieldAccess) Position() token.Position {
+	return n.Pos
+}
+
 func (n *FieldAccess) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type":   "FieldAccess",
 		"object": n.Object,
 		"field":  n.Field,
-	})
-}
-
-// SliceType represents a slice type ([]int, []string, etc.)
-type SliceType struct {
-	ElementType string
-}
-
-func (st *SliceType) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"type":        "SliceType",
-		"elementType": st.ElementType,
-	})
+	}))
 }
 
 // SliceLiteral represents a slice literal ([]int{1, 2, 3})
 type SliceLiteral struct {
-	ElementType string
+	ElementType Type
 	Elements    []ASTNode
+	Pos         token.Position
 }
 
 func (n *SliceLiteral) String() string {
 	return "SliceLiteral"
 }
 
+func (n *SliceLiteral) Position() token.Position {
+	return n.Pos
+}
+
 func (n *SliceLiteral) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type":        "SliceLiteral",
 		"elementType": n.ElementType,
 		"elements":    n.Elements,
-	})
+	}))
+}
+
+// TypeName returns the slice type of the literal, such as []int
+func (n *SliceLiteral) TypeName() string {
+	return "[]" + TypeName(n.ElementType)
+}
+
+// MapLiteral represents a map literal (map[string]int{"a": 1})
+type MapLiteral struct {
+	KeyType   Type
+	ValueType Type
+	Entries   []*KeyValue
+	Pos       token.Position
+}
+
+func (n *MapLiteral) String() string {
+	return "MapLiteral"
+}
+
+func (n *MapLiteral) Position() token.Position {
+	return n.Pos
+}
+
+func (n *MapLiteral) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":      "MapLiteral",
+		"keyType":   n.KeyType,
+		"valueType": n.ValueType,
+		"entries":   n.Entries,
+	}))
+}
+
+// TypeName returns the map type of the literal, such as map[string]int
+func (n *MapLiteral) TypeName() string {
+	return MapTypeName(TypeName(n.KeyType), TypeName(n.ValueType))
+}
+
+// KeyValue is a key: value element of a map literal
+type KeyValue struct {
+	Key   ASTNode `json:"key"`
+	Value ASTNode `json:"value"`
 }
 
 // IndexAccess represents slice/array indexing (slice[0])
 type IndexAccess struct {
 	Object ASTNode // the slice/array
 	Index  ASTNode // index expression
+	Pos    token.Position
 }
 
 func (n *IndexAccess) String() string {
 	return "IndexAccess"
 }
 
+func (n *IndexAccess) Position() token.Position {
+	return n.Pos
+}
+
 func (n *IndexAccess) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type":   "IndexAccess",
 		"object": n.Object,
 		"index":  n.Index,
-	})
+	}))
+}
+
+// SliceExpr represents a slice expression s[Low:High] or, with Slice3,
+// the full slice expression s[Low:High:Max]. Omitted indices are nil.
+type SliceExpr struct {
+	Object ASTNode // the slice, array or string
+	Low    ASTNode
+	High   ASTNode
+	Max    ASTNode
+	Slice3 bool // true for s[Low:High:Max]
+	Pos    token.Position
+}
+
+func (n *SliceExpr) String() string {
+	return "SliceExpr"
+}
+
+func (n *SliceExpr) Position() token.Position {
+	return n.Pos
+}
+
+func (n *SliceExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":   "SliceExpr",
+		"object": n.Object,
+		"low":    n.Low,
+		"high":   n.High,
+		"max":    n.Max,
+		"slice3": n.Slice3,
+	}))
+}
+
+// TypeAssertNode represents a type assertion (x.(T)). Type is empty for the
+// x.(type) guard of a type switch.
+type TypeAssertNode struct {
+	Expression ASTNode
+	Type       string
+	Pos        token.Position
+}
+
+func (n *TypeAssertNode) String() string {
+	return "TypeAssertNode"
+}
+
+func (n *TypeAssertNode) Position() token.Position {
+	return n.Pos
+}
+
+func (n *TypeAssertNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
+		"type":       "TypeAssertNode",
+		"expression": n.Expression,
+		"assertType": n.Type,
+	}))
 }
 
 // PackageStatement represents a package declaration (package main)
 type PackageStatement struct {
 	Name string // package name
+	Pos  token.Position
 }
 
 func (n *PackageStatement) String() string {
 	return "PackageStatement"
 }
 
+func (n *PackageStatement) Position() token.Position {
+	return n.Pos
+}
+
 func (n *PackageStatement) statement() {}
 
 func (n *PackageStatement) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type": "PackageStatement",
 		"name": n.Name,
-	})
+	}))
 }
 
 // ImportStatement represents an import declaration (import "fmt")
 type ImportStatement struct {
 	Path string // import path like "fmt", "os"
+	Pos  token.Position
 }
 
 func (n *ImportStatement) String() string {
 	return "ImportStatement"
 }
 
+func (n *ImportStatement) Position() token.Position {
+	return n.Pos
+}
+
 func (n *ImportStatement) statement() {}
 
 func (n *ImportStatement) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	return json.Marshal(withPos(n.Pos, map[string]interface{}{
 		"type": "ImportStatement",
 		"path": n.Path,
-	})
+	}))
+}
+
+// withPos adds the source position to a node's JSON fields when it is known
+func withPos(pos token.Position, fields map[string]interface{}) map[string]interface{} {
+	if pos.IsValid() {
+		fields["pos"] = pos
+	}
+	return fields
+}
+
+// withLiteral adds the source text of a number literal to its JSON
+// representation. Literals built by later stages have none.
+func withLiteral(literal string, m map[string]interface{}) map[string]interface{} {
+	if literal != "" {
+		m["literal"] = literal
+	}
+	return m
 }
 
 // tokenToString converts a token to its string representation
@@ -667,6 +1586,20 @@ func tokenToString(tok token.Token) string {
 		return "*"
 	case token.QUO:
 		return "/"
+	case token.REM:
+		return "%"
+	case token.AND:
+		return "&"
+	case token.OR:
+		return "|"
+	case token.XOR:
+		return "^"
+	case token.SHL:
+		return "<<"
+	case token.SHR:
+		return ">>"
+	case token.AND_NOT:
+		return "&^"
 	case token.EQL:
 		return "=="
 	case token.NEQ:
@@ -693,6 +1626,20 @@ func tokenToString(tok token.Token) string {
 		return "*="
 	case token.QUO_ASSIGN:
 		return "/="
+	case token.REM_ASSIGN:
+		return "%="
+	case token.AND_ASSIGN:
+		return "&="
+	case token.OR_ASSIGN:
+		return "|="
+	case token.XOR_ASSIGN:
+		return "^="
+	case token.SHL_ASSIGN:
+		return "<<="
+	case token.SHR_ASSIGN:
+		return ">>="
+	case token.AND_NOT_ASSIGN:
+		return "&^="
 	default:
 		return fmt.Sprintf("TOKEN_%d", int(tok))
 	}
@@ -2,8 +2,10 @@ package ast
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
+	"github.com/yuya-takeyama/petitgo/constant"
 	"github.com/yuya-takeyama/petitgo/token"
 )
 
@@ -24,14 +26,14 @@ func TestMarshalJSON(t *testing.T) {
 		{
 			name: "VarStatement",
 			node: &VarStatement{
-				Name:     "x",
-				TypeName: "int",
-				Value:    &NumberNode{Value: 42},
+				Name:  "x",
+				Type:  NewIdentType("int"),
+				Value: &NumberNode{Value: 42},
 			},
 			want: map[string]interface{}{
-				"type":     "VarStatement",
-				"name":     "x",
-				"typeName": "int",
+				"type":    "VarStatement",
+				"name":    "x",
+				"varType": identJSON("int"),
 				"value": map[string]interface{}{
 					"type":  "NumberNode",
 					"value": float64(42),
@@ -58,28 +60,146 @@ func TestMarshalJSON(t *testing.T) {
 			node: &FuncStatement{
 				Name: "add",
 				Parameters: []Parameter{
-					{Name: "a", Type: "int"},
-					{Name: "b", Type: "int"},
+					{Name: "a", Type: NewIdentType("int")},
+					{Name: "b", Type: NewIdentType("int")},
+				},
+				Results: []Parameter{
+					{Type: NewIdentType("int")},
 				},
-				ReturnType: "int",
 				Body: &BlockStatement{
 					Statements: []Statement{},
 				},
 			},
 			want: map[string]interface{}{
-				"type": "FuncStatement",
-				"name": "add",
+				"type":     "FuncStatement",
+				"name":     "add",
+				"receiver": nil,
 				"parameters": []interface{}{
-					map[string]interface{}{"name": "a", "type": "int"},
-					map[string]interface{}{"name": "b", "type": "int"},
+					map[string]interface{}{"name": "a", "type": identJSON("int")},
+					map[string]interface{}{"name": "b", "type": identJSON("int")},
+				},
+				"results": []interface{}{
+					map[string]interface{}{"name": "", "type": identJSON("int")},
 				},
-				"returnType": "int",
 				"body": map[string]interface{}{
 					"type":       "BlockStatement",
 					"statements": []interface{}{},
 				},
 			},
 		},
+		{
+			name: "method with pointer receiver",
+			node: &FuncStatement{
+				Name:     "Rename",
+				Receiver: &Parameter{Name: "p", Type: &PointerType{Elem: NewIdentType("Person")}},
+				Body:     &BlockStatement{Statements: []Statement{}},
+			},
+			want: map[string]interface{}{
+				"type":       "FuncStatement",
+				"name":       "Rename",
+				"receiver":   map[string]interface{}{"name": "p", "type": map[string]interface{}{"type": "PointerType", "elem": identJSON("Person")}},
+				"parameters": nil,
+				"results":    nil,
+				"body": map[string]interface{}{
+					"type":       "BlockStatement",
+					"statements": []interface{}{},
+				},
+			},
+		},
+		{
+			name: "method call",
+			node: &CallNode{Function: "Greet", Receiver: &VariableNode{Name: "p"}, Arguments: []ASTNode{}},
+			want: map[string]interface{}{
+				"type":      "CallNode",
+				"function":  "Greet",
+				"receiver":  map[string]interface{}{"type": "VariableNode", "name": "p"},
+				"arguments": []interface{}{},
+			},
+		},
+		{
+			name: "call of a call",
+			node: &CallNode{Callee: &CallNode{Function: "f", Arguments: []ASTNode{}}, Arguments: []ASTNode{}},
+			want: map[string]interface{}{
+				"type":     "CallNode",
+				"function": "",
+				"receiver": nil,
+				"callee": map[string]interface{}{
+					"type":      "CallNode",
+					"function":  "f",
+					"receiver":  nil,
+					"arguments": []interface{}{},
+				},
+				"arguments": []interface{}{},
+			},
+		},
+		{
+			name: "FuncLiteral",
+			node: &FuncLiteral{
+				Parameters: []Parameter{{Name: "x", Type: NewIdentType("int")}},
+				Results:    []Parameter{{Type: NewIdentType("int")}},
+				Body:       &BlockStatement{Statements: []Statement{}},
+			},
+			want: map[string]interface{}{
+				"type": "FuncLiteral",
+				"parameters": []interface{}{
+					map[string]interface{}{"name": "x", "type": identJSON("int")},
+				},
+				"results": []interface{}{
+					map[string]interface{}{"name": "", "type": identJSON("int")},
+				},
+				"body": map[string]interface{}{
+					"type":       "BlockStatement",
+					"statements": []interface{}{},
+				},
+			},
+		},
+		{
+			name: "MapLiteral",
+			node: &MapLiteral{
+				KeyType:   NewIdentType("string"),
+				ValueType: NewIdentType("int"),
+				Entries:   []*KeyValue{{Key: &StringNode{Value: "a"}, Value: &NumberNode{Value: 1}}},
+			},
+			want: map[string]interface{}{
+				"type":      "MapLiteral",
+				"keyType":   identJSON("string"),
+				"valueType": identJSON("int"),
+				"entries": []interface{}{
+					map[string]interface{}{
+						"key":   map[string]interface{}{"type": "StringNode", "value": "a"},
+						"value": map[string]interface{}{"type": "NumberNode", "value": float64(1)},
+					},
+				},
+			},
+		},
+		{
+			name: "ConstStatement",
+			node: &ConstStatement{Specs: []*ConstSpec{{
+				Names:     []string{"A"},
+				Values:    []ASTNode{&ConstExpr{Expression: &VariableNode{Name: "iota"}, Value: constant.MakeInt64(0)}},
+				Constants: []constant.Value{constant.MakeInt64(0)},
+			}}},
+			want: map[string]interface{}{
+				"type": "ConstStatement",
+				"specs": []interface{}{
+					map[string]interface{}{
+						"names": []interface{}{"A"},
+						"type":  nil,
+						"iota":  float64(0),
+						"values": []interface{}{
+							map[string]interface{}{
+								"type":       "ConstExpr",
+								"expression": map[string]interface{}{"type": "VariableNode", "name": "iota"},
+								"value":      map[string]interface{}{"type": "untyped int", "value": "0"},
+							},
+						},
+						"constants": []interface{}{
+							map[string]interface{}{"type": "untyped int", "value": "0"},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "PackageStatement",
 			node: &PackageStatement{Name: "main"},
@@ -96,6 +216,47 @@ func TestMarshalJSON(t *testing.T) {
 				"path": "fmt",
 			},
 		},
+		{
+			name: "TupleVarStatement",
+			node: &TupleVarStatement{Names: []string{"q", "r"}, Type: NewIdentType("int")},
+			want: map[string]interface{}{
+				"type":    "TupleVarStatement",
+				"names":   []interface{}{"q", "r"},
+				"varType": identJSON("int"),
+				"values":  nil,
+			},
+		},
+		{
+			name: "DeclGroup",
+			node: &DeclGroup{Keyword: "import", Decls: []Statement{&ImportStatement{Path: "fmt"}}},
+			want: map[string]interface{}{
+				"type":    "DeclGroup",
+				"keyword": "import",
+				"decls": []interface{}{
+					map[string]interface{}{"type": "ImportStatement", "path": "fmt"},
+				},
+			},
+		},
+		{
+			name: "ReassignStatement",
+			node: &ReassignStatement{Target: &DerefNode{Operand: &VariableNode{Name: "p"}}, Value: &NumberNode{Value: 1}},
+			want: map[string]interface{}{
+				"type": "ReassignStatement",
+				"target": map[string]interface{}{
+					"type":    "DerefNode",
+					"operand": map[string]interface{}{"type": "VariableNode", "name": "p"},
+				},
+				"value": map[string]interface{}{"type": "NumberNode", "value": float64(1)},
+			},
+		},
+		{
+			name: "NewNode",
+			node: &NewNode{Type: NewIdentType("Point")},
+			want: map[string]interface{}{
+				"type":    "NewNode",
+				"newType": identJSON("Point"),
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -155,6 +316,36 @@ func TestBinaryOpNodeMarshalJSON(t *testing.T) {
 	}
 }
 
+func TestUnaryOpNodeMarshalJSON(t *testing.T) {
+	node := &UnaryOpNode{
+		Operator: token.NOT,
+		Operand:  &VariableNode{Name: "done"},
+	}
+
+	jsonData, err := json.Marshal(node)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(jsonData, &got); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"type":     "UnaryOpNode",
+		"operator": "!",
+		"operand": map[string]interface{}{
+			"type": "VariableNode",
+			"name": "done",
+		},
+	}
+
+	if !deepEqual(got, want) {
+		t.Errorf("UnaryOpNode MarshalJSON() = %v, want %v", got, want)
+	}
+}
+
 // Simple deep equality check for maps
 func deepEqual(a, b map[string]interface{}) bool {
 	if len(a) != len(b) {
@@ -204,32 +395,44 @@ func TestStringMethods(t *testing.T) {
 		{"BooleanNode", &BooleanNode{Value: true}, "BooleanNode"},
 		{"VariableNode", &VariableNode{Name: "x"}, "VariableNode"},
 		{"BinaryOpNode", &BinaryOpNode{}, "BinaryOpNode"},
+		{"UnaryOpNode", &UnaryOpNode{}, "UnaryOpNode"},
 		{"CallNode", &CallNode{Function: "test"}, "CallNode"},
 		{"VarStatement", &VarStatement{Name: "x"}, "VarStatement"},
 		{"AssignStatement", &AssignStatement{Name: "x"}, "AssignStatement"},
-		{"ReassignStatement", &ReassignStatement{Name: "x"}, "ReassignStatement"},
-		{"CompoundAssignStatement", &CompoundAssignStatement{Name: "x"}, "CompoundAssignStatement"},
-		{"IncStatement", &IncStatement{Name: "x"}, "IncStatement"},
-		{"DecStatement", &DecStatement{Name: "x"}, "DecStatement"},
+		{"ReassignStatement", &ReassignStatement{Target: &VariableNode{Name: "x"}}, "ReassignStatement"},
+		{"TupleAssignStatement", &TupleAssignStatement{Targets: []ASTNode{&VariableNode{Name: "a"}, &VariableNode{Name: "b"}}}, "TupleAssignStatement"},
+		{"CompoundAssignStatement", &CompoundAssignStatement{Target: &VariableNode{Name: "x"}}, "CompoundAssignStatement"},
+		{"IncStatement", &IncStatement{Target: &VariableNode{Name: "x"}}, "IncStatement"},
+		{"DecStatement", &DecStatement{Target: &VariableNode{Name: "x"}}, "DecStatement"},
 		{"SwitchStatement", &SwitchStatement{}, "SwitchStatement"},
 		{"CaseStatement", &CaseStatement{}, "CaseStatement"},
+		{"FallthroughStatement", &FallthroughStatement{}, "FallthroughStatement"},
 		{"IfStatement", &IfStatement{}, "IfStatement"},
 		{"ForStatement", &ForStatement{}, "ForStatement"},
 		{"BreakStatement", &BreakStatement{}, "BreakStatement"},
 		{"ContinueStatement", &ContinueStatement{}, "ContinueStatement"},
+		{"GotoStatement", &GotoStatement{Label: "done"}, "GotoStatement"},
+		{"DeferStatement", &DeferStatement{Call: &CallNode{Function: "f"}}, "DeferStatement"},
+		{"LabeledStatement", &LabeledStatement{Label: "outer"}, "LabeledStatement"},
+		{"EmptyStatement", &EmptyStatement{}, "EmptyStatement"},
 		{"BlockStatement", &BlockStatement{}, "BlockStatement"},
 		{"ExpressionStatement", &ExpressionStatement{}, "ExpressionStatement"},
 		{"FuncStatement", &FuncStatement{Name: "test"}, "FuncStatement"},
 		{"ReturnStatement", &ReturnStatement{}, "ReturnStatement"},
 		{"StructLiteral", &StructLiteral{TypeName: "Person"}, "StructLiteral"},
-		{"SliceLiteral", &SliceLiteral{ElementType: "int"}, "SliceLiteral"},
+		{"SliceLiteral", &SliceLiteral{ElementType: NewIdentType("int")}, "SliceLiteral"},
 		{"FieldAccessNode", &FieldAccessNode{Field: "name"}, "FieldAccessNode"},
 		{"IndexAccess", &IndexAccess{}, "IndexAccess"},
+		{"SliceExpr", &SliceExpr{}, "SliceExpr"},
 		{"StructDefinition", &StructDefinition{Name: "Person"}, "StructDefinition"},
 		{"TypeStatement", &TypeStatement{Name: "Person"}, "TypeStatement"},
 		{"PackageStatement", &PackageStatement{Name: "main"}, "PackageStatement"},
 		{"ImportStatement", &ImportStatement{Path: "fmt"}, "ImportStatement"},
-		{"ArrayLiteral", &ArrayLiteral{ElementType: "int"}, "ArrayLiteral"},
+		{"TupleVarStatement", &TupleVarStatement{Names: []string{"a", "b"}}, "TupleVarStatement"},
+		{"DeclGroup", &DeclGroup{Keyword: "var"}, "DeclGroup"},
+		{"DerefNode", &DerefNode{}, "DerefNode"},
+		{"NewNode", &NewNode{Type: NewIdentType("int")}, "NewNode"},
+		{"ArrayLiteral", &ArrayLiteral{ElementType: NewIdentType("int")}, "ArrayLiteral"},
 	}
 
 	for _, tt := range tests {
@@ -242,12 +445,98 @@ func TestStringMethods(t *testing.T) {
 	}
 }
 
+func TestFuncStatementReceiverType(t *testing.T) {
+	tests := []struct {
+		receiver *Parameter
+		expected string
+	}{
+		{nil, ""},
+		{&Parameter{Name: "p", Type: NewIdentType("Person")}, "Person"},
+		{&Parameter{Name: "p", Type: &PointerType{Elem: NewIdentType("Person")}}, "Person"},
+	}
+
+	for _, tt := range tests {
+		funcStmt := &FuncStatement{Name: "Greet", Receiver: tt.receiver}
+		if got := funcStmt.ReceiverType(); got != tt.expected {
+			t.Errorf("ReceiverType() = %q, want %q", got, tt.expected)
+		}
+	}
+}
+
+func TestFuncTypeName(t *testing.T) {
+	tests := []struct {
+		params   []string
+		results  []string
+		expected string
+	}{
+		{nil, nil, "func()"},
+		{[]string{"int", "string"}, []string{"bool"}, "func(int, string) bool"},
+		{[]string{"func(int) int"}, []string{"int", "error"}, "func(func(int) int) (int, error)"},
+	}
+
+	for _, tt := range tests {
+		if got := FuncTypeName(tt.params, tt.results); got != tt.expected {
+			t.Errorf("FuncTypeName(%v, %v) = %q, want %q", tt.params, tt.results, got, tt.expected)
+		}
+	}
+}
+
+func TestMapTypes(t *testing.T) {
+	tests := []struct {
+		typeName  string
+		keyType   string
+		valueType string
+	}{
+		{"map[string]int", "string", "int"},
+		{"map[int]map[string]bool", "int", "map[string]bool"},
+		{"map[[2]int][]string", "[2]int", "[]string"},
+	}
+	for _, tt := range tests {
+		if !IsMapType(tt.typeName) {
+			t.Errorf("expected %s to be a map type", tt.typeName)
+		}
+		keyType, valueType := MapTypes(tt.typeName)
+		if keyType != tt.keyType || valueType != tt.valueType {
+			t.Errorf("MapTypes(%s) = %s, %s, want %s, %s", tt.typeName, keyType, valueType, tt.keyType, tt.valueType)
+		}
+	}
+	if IsMapType("[]int") {
+		t.Error("expected []int not to be a map type")
+	}
+}
+
+func TestInterfaceMethodSet(t *testing.T) {
+	interfaces := map[string]*InterfaceStatement{
+		"Stringer": {Name: "Stringer", Methods: []*MethodDef{{Name: "String"}}},
+		"Named":    {Name: "Named", Methods: []*MethodDef{{Name: "Name"}, {Name: "String"}}, Embedded: []string{"Stringer", "Loop", "Unknown"}},
+		"Loop":     {Name: "Loop", Methods: []*MethodDef{{Name: "Next"}}, Embedded: []string{"Named"}},
+	}
+	lookup := func(name string) (*InterfaceStatement, bool) {
+		iface, exists := interfaces[name]
+		return iface, exists
+	}
+
+	var names []string
+	for _, method := range interfaces["Named"].MethodSet(lookup) {
+		names = append(names, method.Name)
+	}
+	if strings.Join(names, " ") != "Name String Next" {
+		t.Errorf("expected methods Name String Next, got %v", names)
+	}
+}
+
+// identJSON returns the JSON of the type named name
+func identJSON(name string) map[string]interface{} {
+	return map[string]interface{}{"type": "IdentType", "name": name}
+}
+
 // Test Statement interface compliance
 func TestStatementInterface(t *testing.T) {
 	statements := []Statement{
 		&VarStatement{},
 		&AssignStatement{},
 		&ReassignStatement{},
+		&TupleAssignStatement{},
 		&CompoundAssignStatement{},
 		&IncStatement{},
 		&DecStatement{},
@@ -256,6 +545,10 @@ func TestStatementInterface(t *testing.T) {
 		&ForStatement{},
 		&BreakStatement{},
 		&ContinueStatement{},
+		&GotoStatement{},
+		&DeferStatement{},
+		&LabeledStatement{},
+		&EmptyStatement{},
 		&BlockStatement{},
 		&ExpressionStatement{},
 		&FuncStatement{},
@@ -264,6 +557,8 @@ func TestStatementInterface(t *testing.T) {
 		&TypeStatement{},
 		&PackageStatement{},
 		&ImportStatement{},
+		&TupleVarStatement{},
+		&DeclGroup{},
 	}
 
 	// Just ensure all statements implement the Statement interface
@@ -285,25 +580,37 @@ func TestRemainingMarshalJSON(t *testing.T) {
 		{"VariableNode", &VariableNode{Name: "x"}},
 		{"BinaryOpNode", &BinaryOpNode{Left: &NumberNode{Value: 1}, Operator: token.ADD, Right: &NumberNode{Value: 2}}},
 		{"CallNode", &CallNode{Function: "test", Arguments: []ASTNode{}}},
-		{"ReassignStatement", &ReassignStatement{Name: "x", Value: &NumberNode{Value: 1}}},
-		{"CompoundAssignStatement", &CompoundAssignStatement{Name: "x", Operator: token.ADD, Value: &NumberNode{Value: 1}}},
-		{"IncStatement", &IncStatement{Name: "x"}},
-		{"DecStatement", &DecStatement{Name: "x"}},
+		{"ReassignStatement", &ReassignStatement{Target: &VariableNode{Name: "x"}, Value: &NumberNode{Value: 1}}},
+		{"CompoundAssignStatement", &CompoundAssignStatement{Target: &FieldAccessNode{Object: &VariableNode{Name: "p"}, Field: "age"}, Operator: token.ADD, Value: &NumberNode{Value: 1}}},
+		{"IncStatement", &IncStatement{Target: &VariableNode{Name: "x"}}},
+		{"DecStatement", &DecStatement{Target: &IndexAccess{Object: &VariableNode{Name: "xs"}, Index: &NumberNode{Value: 0}}}},
 		{"SwitchStatement", &SwitchStatement{Value: &VariableNode{Name: "x"}, Cases: []*CaseStatement{}}},
-		{"CaseStatement", &CaseStatement{Value: &NumberNode{Value: 1}, Body: &BlockStatement{}}},
+		{"CaseStatement", &CaseStatement{Values: []ASTNode{&NumberNode{Value: 1}}, Body: &BlockStatement{}}},
+		{"FallthroughStatement", &FallthroughStatement{}},
+		{"TypeSwitchStatement", &TypeSwitchStatement{Binding: "v", Value: &VariableNode{Name: "x"}, Cases: []*TypeCaseClause{}}},
+		{"TypeCaseClause", &TypeCaseClause{Types: []string{"int", "nil"}, Body: &BlockStatement{}}},
+		{"TypeAssertNode", &TypeAssertNode{Expression: &VariableNode{Name: "x"}, Type: "int"}},
+		{"InterfaceStatement", &InterfaceStatement{Name: "Shape", Methods: []*MethodDef{{Name: "Area", Results: []Parameter{{Type: NewIdentType("int")}}}}}},
 		{"IfStatement", &IfStatement{Condition: &BooleanNode{Value: true}, ThenBlock: &BlockStatement{}}},
 		{"ForStatement", &ForStatement{Condition: &BooleanNode{Value: true}, Body: &BlockStatement{}}},
+		{"RangeStatement", &RangeStatement{Key: "i", Value: "v", Define: true, Expression: &VariableNode{Name: "xs"}, Body: &BlockStatement{}}},
 		{"BreakStatement", &BreakStatement{}},
-		{"ContinueStatement", &ContinueStatement{}},
+		{"ContinueStatement", &ContinueStatement{Label: "outer"}},
+		{"GotoStatement", &GotoStatement{Label: "done"}},
+		{"DeferStatement", &DeferStatement{Call: &CallNode{Function: "unlock"}}},
+		{"LabeledStatement", &LabeledStatement{Label: "done", Statement: &EmptyStatement{}}},
 		{"ExpressionStatement", &ExpressionStatement{Expression: &NumberNode{Value: 1}}},
-		{"ReturnStatement", &ReturnStatement{Value: &NumberNode{Value: 1}}},
+		{"ReturnStatement", &ReturnStatement{Values: []ASTNode{&NumberNode{Value: 1}}}},
+		{"TupleAssignStatement", &TupleAssignStatement{Targets: []ASTNode{&VariableNode{Name: "a"}, &VariableNode{Name: "_"}}, Define: true, Values: []ASTNode{&CallNode{Function: "f"}}}},
 		{"StructLiteral", &StructLiteral{TypeName: "Person", Fields: map[string]ASTNode{}}},
-		{"SliceLiteral", &SliceLiteral{ElementType: "int", Elements: []ASTNode{}}},
+		{"SliceLiteral", &SliceLiteral{ElementType: NewIdentType("int"), Elements: []ASTNode{}}},
 		{"FieldAccessNode", &FieldAccessNode{Object: &VariableNode{Name: "x"}, Field: "name"}},
 		{"IndexAccess", &IndexAccess{Object: &VariableNode{Name: "arr"}, Index: &NumberNode{Value: 0}}},
+		{"SliceExpr", &SliceExpr{Object: &VariableNode{Name: "s"}, Low: &NumberNode{Value: 1}}},
+		{"ReassignStatement", &ReassignStatement{Target: &IndexAccess{Object: &VariableNode{Name: "m"}, Index: &StringNode{Value: "a"}}, Value: &NumberNode{Value: 1}}},
 		{"StructDefinition", &StructDefinition{Name: "Person", Fields: []StructField{}}},
 		{"TypeStatement", &TypeStatement{Name: "Person", Fields: []*FieldDef{}}},
-		{"ArrayLiteral", &ArrayLiteral{ElementType: "int", Size: 10, Elements: []ASTNode{}}},
+		{"ArrayLiteral", &ArrayLiteral{ElementType: NewIdentType("int"), Size: 10, Elements: []ASTNode{}}},
 	}
 
 	for _, tt := range tests {
@@ -348,6 +655,9 @@ func TestTokenToString(t *testing.T) {
 		{"SUB_ASSIGN", token.SUB_ASSIGN, "-="},
 		{"MUL_ASSIGN", token.MUL_ASSIGN, "*="},
 		{"QUO_ASSIGN", token.QUO_ASSIGN, "/="},
+		{"REM", token.REM, "%"},
+		{"AND_NOT", token.AND_NOT, "&^"},
+		{"SHL_ASSIGN", token.SHL_ASSIGN, "<<="},
 		{"LAND", token.LAND, "&&"},
 		{"LOR", token.LOR, "||"},
 		{"NOT", token.NOT, "!"},
@@ -372,11 +682,13 @@ func TestMissingASTNodes(t *testing.T) {
 			Value: &VariableNode{Name: "x"},
 			Cases: []*CaseStatement{
 				{
-					Value: &NumberNode{Value: 1},
-					Body:  &BlockStatement{Statements: []Statement{}},
+					Values: []ASTNode{&NumberNode{Value: 1}},
+					Body:   &BlockStatement{Statements: []Statement{}},
+				},
+				{
+					Body: &BlockStatement{Statements: []Statement{}},
 				},
 			},
-			Default: &BlockStatement{Statements: []Statement{}},
 		}
 
 		// Test String method
@@ -398,8 +710,8 @@ func TestMissingASTNodes(t *testing.T) {
 	// Test CaseStatement String and MarshalJSON
 	t.Run("CaseStatement", func(t *testing.T) {
 		caseStmt := &CaseStatement{
-			Value: &NumberNode{Value: 1},
-			Body:  &BlockStatement{Statements: []Statement{}},
+			Values: []ASTNode{&NumberNode{Value: 1}},
+			Body:   &BlockStatement{Statements: []Statement{}},
 		}
 
 		// Test String method
@@ -418,6 +730,30 @@ func TestMissingASTNodes(t *testing.T) {
 		}
 	})
 
+	// Test default and fallthrough clauses
+	t.Run("CaseStatement default and fallthrough", func(t *testing.T) {
+		caseStmt := &CaseStatement{
+			Values: []ASTNode{&NumberNode{Value: 1}},
+			Body:   &BlockStatement{Statements: []Statement{&FallthroughStatement{}}},
+		}
+		defaultStmt := &CaseStatement{Body: &BlockStatement{}}
+
+		if caseStmt.IsDefault() || !caseStmt.Fallthrough() {
+			t.Errorf("expected a case clause ending with fallthrough")
+		}
+		if !defaultStmt.IsDefault() || defaultStmt.Fallthrough() {
+			t.Errorf("expected a default clause without fallthrough")
+		}
+
+		data, err := json.Marshal(defaultStmt)
+		if err != nil {
+			t.Fatalf("CaseStatement.MarshalJSON() error: %v", err)
+		}
+		if !strings.Contains(string(data), `"default":true`) {
+			t.Errorf("expected default clause in JSON, got %s", data)
+		}
+	})
+
 	// Test ExpressionStatement MarshalJSON
 	t.Run("ExpressionStatement", func(t *testing.T) {
 		exprStmt := &ExpressionStatement{
@@ -516,7 +852,7 @@ func TestMissingASTNodes(t *testing.T) {
 	// Test SliceType MarshalJSON (line 580)
 	t.Run("SliceType", func(t *testing.T) {
 		sliceType := &SliceType{
-			ElementType: "int",
+			Elem: NewIdentType("int"),
 		}
 
 		// Test MarshalJSON method
@@ -529,3 +865,79 @@ func TestMissingASTNodes(t *testing.T) {
 		}
 	})
 }
+
+func TestMarshalJSONPosition(t *testing.T) {
+	node := &VariableNode{
+		Name: "x",
+		Pos:  token.Position{Filename: "main.pg", Offset: 4, Line: 2, Column: 3},
+	}
+
+	jsonData, err := json.Marshal(node)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(jsonData, &got); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"type": "VariableNode",
+		"name": "x",
+		"pos": map[string]interface{}{
+			"filename": "main.pg",
+			"offset":   float64(4),
+			"line":     float64(2),
+			"column":   float64(3),
+		},
+	}
+
+	if !deepEqual(got, want) {
+		t.Errorf("VariableNode MarshalJSON() = %v, want %v", got, want)
+	}
+}
+
+func TestTypeName(t *testing.T) {
+	point := &StructType{Fields: []*FieldDef{{Name: "X", Type: NewIdentType("int")}, {Name: "Y", Type: NewIdentType("int")}}}
+	tests := []struct {
+		t        Type
+		expected string
+	}{
+		{nil, ""},
+		{NewIdentType("Person"), "Person"},
+		{&SliceType{Elem: &SliceType{Elem: NewIdentType("int")}}, "[][]int"},
+		{&SliceType{Elem: &PointerType{Elem: NewIdentType("Node")}}, "[]*Node"},
+		{&ArrayType{Len: 3, Elem: NewIdentType("string")}, "[3]string"},
+		{&ArrayType{Len: -1, Elem: NewIdentType("int")}, "[...]int"},
+		{&MapType{Key: NewIdentType("string"), Value: &SliceType{Elem: NewIdentType("int")}}, "map[string][]int"},
+		{&FuncType{Params: []Type{NewIdentType("int")}, Results: []Type{&SliceType{Elem: NewIdentType("int")}, NewIdentType("error")}}, "func(int) ([]int, error)"},
+		{point, "struct{X int; Y int}"},
+		{&InterfaceType{}, "interface{}"},
+	}
+
+	for _, tt := range tests {
+		if got := TypeName(tt.t); got != tt.expected {
+			t.Errorf("TypeName(%v) = %q, want %q", tt.t, got, tt.expected)
+		}
+	}
+}
+
+func TestTypeMarshalJSON(t *testing.T) {
+	node := &SliceType{Elem: &PointerType{Elem: NewIdentType("Node")}}
+	data, err := json.Marshal(node)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"type": "SliceType",
+		"elem": map[string]interface{}{"type": "PointerType", "elem": identJSON("Node")},
+	}
+	if !deepEqual(got, want) {
+		t.Errorf("MarshalJSON() = %v, want %v", got, want)
+	}
+}
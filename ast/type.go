@@ -0,0 +1,305 @@
+package ast
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/yuya-takeyama/petitgo/token"
+)
+
+// Type is a type expression written in a declaration, a literal, a
+// parameter or a result, such as int, []*Node or map[string][]int.
+// TypeName spells the type the way Go does; later stages name types by
+// that spelling.
+type Type interface {
+	ASTNode
+	TypeName() string
+}
+
+// TypeName returns the name of a type, or "" when the type is omitted
+func TypeName(t Type) string {
+	if t == nil {
+		return ""
+	}
+	return t.TypeName()
+}
+
+// IdentType is a type named by an identifier: int, string, Person
+type IdentType struct {
+	Name string
+	Pos  token.Position
+}
+
+// NewIdentType returns the type named name
+func NewIdentType(name string) *IdentType {
+	return &IdentType{Name: name}
+}
+
+func (t *IdentType) String() string {
+	return "IdentType"
+}
+
+func (t *IdentType) Position() token.Position {
+	return t.Pos
+}
+
+func (t *IdentType) TypeName() string {
+	return t.Name
+}
+
+func (t *IdentType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(t.Pos, map[string]interface{}{
+		"type": "IdentType",
+		"name": t.Name,
+	}))
+}
+
+// SliceType is a slice type []Elem
+type SliceType struct {
+	Elem Type
+	Pos  token.Position
+}
+
+func (t *SliceType) String() string {
+	return "SliceType"
+}
+
+func (t *SliceType) Position() token.Position {
+	return t.Pos
+}
+
+func (t *SliceType) TypeName() string {
+	return "[]" + TypeName(t.Elem)
+}
+
+func (t *SliceType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(t.Pos, map[string]interface{}{
+		"type": "SliceType",
+		"elem": t.Elem,
+	}))
+}
+
+// ArrayType is an array type [Len]Elem. Len is -1 for the [...]Elem of an
+// array literal until its elements are counted.
+type ArrayType struct {
+	Len  int
+	Elem Type
+	Pos  token.Position
+}
+
+func (t *ArrayType) String() string {
+	return "ArrayType"
+}
+
+func (t *ArrayType) Position() token.Position {
+	return t.Pos
+}
+
+func (t *ArrayType) TypeName() string {
+	if t.Len < 0 {
+		return "[...]" + TypeName(t.Elem)
+	}
+	return "[" + strconv.Itoa(t.Len) + "]" + TypeName(t.Elem)
+}
+
+func (t *ArrayType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(t.Pos, map[string]interface{}{
+		"type": "ArrayType",
+		"len":  t.Len,
+		"elem": t.Elem,
+	}))
+}
+
+// MapType is a map type map[Key]Value
+type MapType struct {
+	Key   Type
+	Value Type
+	Pos   token.Position
+}
+
+func (t *MapType) String() string {
+	return "MapType"
+}
+
+func (t *MapType) Position() token.Position {
+	return t.Pos
+}
+
+func (t *MapType) TypeName() string {
+	return MapTypeName(TypeName(t.Key), TypeName(t.Value))
+}
+
+func (t *MapType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(t.Pos, map[string]interface{}{
+		"type":  "MapType",
+		"key":   t.Key,
+		"value": t.Value,
+	}))
+}
+
+// PointerType is a pointer type *Elem
+type PointerType struct {
+	Elem Type
+	Pos  token.Position
+}
+
+func (t *PointerType) String() string {
+	return "PointerType"
+}
+
+func (t *PointerType) Position() token.Position {
+	return t.Pos
+}
+
+func (t *PointerType) TypeName() string {
+	return "*" + TypeName(t.Elem)
+}
+
+func (t *PointerType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(t.Pos, map[string]interface{}{
+		"type": "PointerType",
+		"elem": t.Elem,
+	}))
+}
+
+// FuncType is a function type func(Params) Results. Parameter names are
+// not part of the type.
+type FuncType struct {
+	Params  []Type
+	Results []Type
+	Pos     token.Position
+}
+
+func (t *FuncType) String() string {
+	return "FuncType"
+}
+
+func (t *FuncType) Position() token.Position {
+	return t.Pos
+}
+
+func (t *FuncType) TypeName() string {
+	return FuncTypeName(typeNames(t.Params), typeNames(t.Results))
+}
+
+func (t *FuncType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(t.Pos, map[string]interface{}{
+		"type":    "FuncType",
+		"params":  t.Params,
+		"results": t.Results,
+	}))
+}
+
+// StructType is a struct type struct { Fields }
+type StructType struct {
+	Fields []*FieldDef
+	Pos    token.Position
+}
+
+func (t *StructType) String() string {
+	return "StructType"
+}
+
+func (t *StructType) Position() token.Position {
+	return t.Pos
+}
+
+func (t *StructType) TypeName() string {
+	fields := make([]string, len(t.Fields))
+	for i, field := range t.Fields {
+		fields[i] = field.Name + " " + TypeName(field.Type)
+	}
+	return "struct{" + strings.Join(fields, "; ") + "}"
+}
+
+func (t *StructType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(t.Pos, map[string]interface{}{
+		"type":   "StructType",
+		"fields": t.Fields,
+	}))
+}
+
+// InterfaceType is the empty interface type interface{}. Interfaces with
+// methods are declared by InterfaceStatement.
+type InterfaceType struct {
+	Pos token.Position
+}
+
+func (t *InterfaceType) String() string {
+	return "InterfaceType"
+}
+
+func (t *InterfaceType) Position() token.Position {
+	return t.Pos
+}
+
+func (t *InterfaceType) TypeName() string {
+	return "interface{}"
+}
+
+func (t *InterfaceType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withPos(t.Pos, map[string]interface{}{
+		"type": "InterfaceType",
+	}))
+}
+
+// FuncTypeName returns the name of the function type with the given
+// parameter and result types: func(int, string) (int, bool)
+func FuncTypeName(params, results []string) string {
+	name := "func(" + strings.Join(params, ", ") + ")"
+	switch len(results) {
+	case 0:
+		return name
+	case 1:
+		return name + " " + results[0]
+	}
+	return name + " (" + strings.Join(results, ", ") + ")"
+}
+
+// MapTypeName returns the name of the map type with the given key and
+// value types
+func MapTypeName(keyType, valueType string) string {
+	return "map[" + keyType + "]" + valueType
+}
+
+// IsMapType reports whether typeName names a map type
+func IsMapType(typeName string) bool {
+	return strings.HasPrefix(typeName, "map[")
+}
+
+// MapTypes splits a map type such as map[string][]int into its key and
+// value types
+func MapTypes(typeName string) (keyType, valueType string) {
+	depth := 0
+	for i := len("map"); i < len(typeName); i++ {
+		switch typeName[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return typeName[len("map["):i], typeName[i+1:]
+			}
+		}
+	}
+	return "", ""
+}
+
+// IsPointerType reports whether typeName names a pointer type
+func IsPointerType(typeName string) bool {
+	return strings.HasPrefix(typeName, "*")
+}
+
+// IsFuncType reports whether typeName names a function type
+func IsFuncType(typeName string) bool {
+	return strings.HasPrefix(typeName, "func(")
+}
+
+func typeNames(types []Type) []string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = TypeName(t)
+	}
+	return names
+}
@@ -0,0 +1,590 @@
+// Package constant implements the values of constant expressions. Untyped
+// constants are exact: integers have arbitrary precision and floats are
+// rational numbers, so 1 << 100 >> 98 is 4 and 0.1 + 0.2 == 0.3 holds. A constant only
+// has to fit its type once it becomes typed, either by a declaration such
+// as const x uint8 = 1 or by being used where a value is needed.
+package constant
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/yuya-takeyama/petitgo/token"
+)
+
+// Kind is the kind of a constant value
+type Kind int
+
+const (
+	Unknown Kind = iota
+	Bool
+	String
+	Int
+	Float
+)
+
+// The types of untyped constants. Typed constants carry the name of their
+// type instead: int, int32, uint8, float64, string or bool.
+const (
+	UntypedBool   = "untyped bool"
+	UntypedInt    = "untyped int"
+	UntypedRune   = "untyped rune"
+	UntypedFloat  = "untyped float"
+	UntypedString = "untyped string"
+)
+
+// floatPrec is the precision in bits used to print float constants too
+// large for float64
+const floatPrec = 512
+
+// maxExponent limits the exponent of float literals, whose exact values
+// take memory in proportion to it
+const maxExponent = 10000
+
+// maxShift limits the shift count so that 1 << n cannot exhaust memory
+const maxShift = 10000
+
+// Value is the value of a constant expression together with its type
+type Value struct {
+	Type string
+
+	kind Kind
+	b    bool
+	s    string
+	i    *big.Int
+	f    *big.Rat
+}
+
+// MakeBool returns an untyped boolean constant
+func MakeBool(b bool) Value {
+	return Value{Type: UntypedBool, kind: Bool, b: b}
+}
+
+// MakeString returns an untyped string constant
+func MakeString(s string) Value {
+	return Value{Type: UntypedString, kind: String, s: s}
+}
+
+// MakeInt64 returns an untyped integer constant
+func MakeInt64(n int64) Value {
+	return Value{Type: UntypedInt, kind: Int, i: big.NewInt(n)}
+}
+
+// MakeRune returns an untyped rune constant
+func MakeRune(r rune) Value {
+	return Value{Type: UntypedRune, kind: Int, i: big.NewInt(int64(r))}
+}
+
+// MakeFloat64 returns an untyped floating-point constant
+func MakeFloat64(f float64) Value {
+	return Value{Type: UntypedFloat, kind: Float, f: new(big.Rat).SetFloat64(f)}
+}
+
+// MakeFromLiteral returns the untyped constant of an integer literal, for
+// tok token.INT, or of a floating-point literal, for tok token.FLOAT, with
+// its exact value. It fails when lit is not a valid literal.
+func MakeFromLiteral(lit string, tok token.Token) (Value, error) {
+	switch tok {
+	case token.INT:
+		if n, ok := new(big.Int).SetString(lit, 0); ok {
+			return Value{Type: UntypedInt, kind: Int, i: n}, nil
+		}
+	case token.FLOAT:
+		if tooLarge(lit) {
+			return Value{}, fmt.Errorf("floating-point constant %s is too large", lit)
+		}
+		if f, ok := new(big.Rat).SetString(strings.ReplaceAll(lit, "_", "")); ok {
+			return Value{Type: UntypedFloat, kind: Float, f: f}, nil
+		}
+	}
+	return Value{}, fmt.Errorf("invalid literal %s", lit)
+}
+
+// tooLarge reports whether the exponent of a float literal exceeds
+// maxExponent
+func tooLarge(lit string) bool {
+	markers := "eE"
+	if strings.HasPrefix(lit, "0x") || strings.HasPrefix(lit, "0X") {
+		markers = "pP"
+	}
+	i := strings.LastIndexAny(lit, markers)
+	if i < 0 {
+		return false
+	}
+	exponent, err := strconv.Atoi(lit[i+1:])
+	return err != nil || exponent > maxExponent || exponent < -maxExponent
+}
+
+// Kind returns the kind of v
+func (v Value) Kind() Kind {
+	return v.kind
+}
+
+// IsUntyped reports whether v is an untyped constant
+func (v Value) IsUntyped() bool {
+	return isUntyped(v.Type)
+}
+
+func isUntyped(typeName string) bool {
+	switch typeName {
+	case UntypedBool, UntypedInt, UntypedRune, UntypedFloat, UntypedString:
+		return true
+	}
+	return false
+}
+
+// BoolVal returns the value of a boolean constant
+func (v Value) BoolVal() bool {
+	return v.b
+}
+
+// StringVal returns the value of a string constant
+func (v Value) StringVal() string {
+	return v.s
+}
+
+// Int64Val returns the value of an integer constant. ok is false if it does
+// not fit in an int64.
+func (v Value) Int64Val() (n int64, ok bool) {
+	if v.kind != Int {
+		return 0, false
+	}
+	return v.i.Int64(), v.i.IsInt64()
+}
+
+// Float64Val returns the nearest float64 to a numeric constant
+func (v Value) Float64Val() float64 {
+	switch v.kind {
+	case Int:
+		f, _ := new(big.Float).SetInt(v.i).Float64()
+		return f
+	case Float:
+		f, _ := v.f.Float64()
+		return f
+	}
+	return 0
+}
+
+// String formats v the way it is written in source: 42, 2.5, "abc", true
+func (v Value) String() string {
+	switch v.kind {
+	case Bool:
+		return strconv.FormatBool(v.b)
+	case String:
+		return strconv.Quote(v.s)
+	case Int:
+		return v.i.String()
+	case Float:
+		if f, _ := v.f.Float64(); !math.IsInf(f, 0) {
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		return new(big.Float).SetPrec(floatPrec).SetRat(v.f).Text('g', 10)
+	}
+	return "unknown"
+}
+
+// DefaultType returns the type a constant gets where a value is needed
+// without a type given: int for untyped integers, rune (int32) for untyped
+// runes, float64, string and bool, or the type of a typed constant
+func DefaultType(v Value) string {
+	switch v.Type {
+	case UntypedBool:
+		return "bool"
+	case UntypedInt:
+		return "int"
+	case UntypedRune:
+		return "int32"
+	case UntypedFloat:
+		return "float64"
+	case UntypedString:
+		return "string"
+	}
+	return v.Type
+}
+
+// Convert returns v converted to the type typeName. It fails when v does not
+// have a matching kind, or when its value is not representable in the type.
+func Convert(v Value, typeName string) (Value, error) {
+	typeName = CanonicalType(typeName)
+	if v.Type == typeName {
+		return v, nil
+	}
+	return convert(v, typeName)
+}
+
+func convert(v Value, typeName string) (Value, error) {
+	switch typeName {
+	case "int", "int32", "uint8":
+		var n *big.Int
+		switch v.kind {
+		case Int:
+			n = v.i
+		case Float:
+			if !v.f.IsInt() {
+				return Value{}, fmt.Errorf("constant %s truncated to integer", v)
+			}
+			n = new(big.Int).Set(v.f.Num())
+		default:
+			return Value{}, cannotConvert(v, typeName)
+		}
+		if !fitsInteger(n, typeName) {
+			return Value{}, fmt.Errorf("constant %s overflows %s", n, typeName)
+		}
+		return Value{Type: typeName, kind: Int, i: n}, nil
+	case "float64":
+		var f *big.Rat
+		switch v.kind {
+		case Int:
+			f = new(big.Rat).SetInt(v.i)
+		case Float:
+			f = v.f
+		default:
+			return Value{}, cannotConvert(v, typeName)
+		}
+		rounded, _ := f.Float64()
+		if math.IsInf(rounded, 0) {
+			return Value{}, fmt.Errorf("constant %s overflows float64", v)
+		}
+		return Value{Type: typeName, kind: Float, f: new(big.Rat).SetFloat64(rounded)}, nil
+	case "string":
+		if v.kind != String {
+			return Value{}, cannotConvert(v, typeName)
+		}
+		return Value{Type: typeName, kind: String, s: v.s}, nil
+	case "bool":
+		if v.kind != Bool {
+			return Value{}, cannotConvert(v, typeName)
+		}
+		return Value{Type: typeName, kind: Bool, b: v.b}, nil
+	}
+	return Value{}, fmt.Errorf("invalid constant type %s", typeName)
+}
+
+// IsConstType reports whether constants of type typeName can be declared
+func IsConstType(typeName string) bool {
+	switch CanonicalType(typeName) {
+	case "int", "int32", "uint8", "float64", "string", "bool":
+		return true
+	}
+	return false
+}
+
+func cannotConvert(v Value, typeName string) error {
+	return fmt.Errorf("cannot convert %s (%s constant) to type %s", v, v.Type, typeName)
+}
+
+// CanonicalType resolves the type aliases rune and byte to the types they denote
+func CanonicalType(typeName string) string {
+	switch typeName {
+	case "rune":
+		return "int32"
+	case "byte":
+		return "uint8"
+	}
+	return typeName
+}
+
+// fitsInteger reports whether n is representable in an integer type
+func fitsInteger(n *big.Int, typeName string) bool {
+	switch typeName {
+	case "int32":
+		return n.IsInt64() && n.Int64() >= math.MinInt32 && n.Int64() <= math.MaxInt32
+	case "uint8":
+		return n.IsInt64() && n.Int64() >= 0 && n.Int64() <= math.MaxUint8
+	}
+	return n.IsInt64()
+}
+
+// rank orders the kinds of untyped numeric constants: an operation on an
+// untyped int and an untyped float yields an untyped float
+func rank(typeName string) int {
+	switch typeName {
+	case UntypedInt:
+		return 1
+	case UntypedRune:
+		return 2
+	case UntypedFloat:
+		return 3
+	}
+	return 0
+}
+
+// operandType returns the type of a binary operation on x and y and
+// converts both operands to it. A typed operand gives its type to an
+// untyped one; two untyped numbers take the later kind of int, rune, float.
+func operandType(x, y Value) (Value, Value, string, error) {
+	switch {
+	case !x.IsUntyped() && !y.IsUntyped():
+		if x.Type != y.Type {
+			return x, y, "", fmt.Errorf("invalid operation: mismatched types %s and %s", x.Type, y.Type)
+		}
+		return x, y, x.Type, nil
+	case !x.IsUntyped():
+		y, err := Convert(y, x.Type)
+		return x, y, x.Type, err
+	case !y.IsUntyped():
+		x, err := Convert(x, y.Type)
+		return x, y, y.Type, err
+	}
+	if rank(x.Type) == 0 || rank(y.Type) == 0 {
+		if x.kind != y.kind {
+			return x, y, "", fmt.Errorf("invalid operation: mismatched types %s and %s", x.Type, y.Type)
+		}
+		return x, y, x.Type, nil
+	}
+	if rank(x.Type) < rank(y.Type) {
+		return x, y, y.Type, nil
+	}
+	return x, y, x.Type, nil
+}
+
+// toFloat returns the value of a numeric constant as a big.Rat
+func (v Value) toFloat() *big.Rat {
+	if v.kind == Int {
+		return new(big.Rat).SetInt(v.i)
+	}
+	return v.f
+}
+
+// BinaryOp returns the value of x op y
+func BinaryOp(x Value, op token.Token, y Value) (Value, error) {
+	if op == token.SHL || op == token.SHR {
+		return shift(x, op, y)
+	}
+
+	x, y, typeName, err := operandType(x, y)
+	if err != nil {
+		return Value{}, err
+	}
+
+	switch op {
+	case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+		return compare(x, op, y)
+	}
+
+	var result Value
+	switch {
+	case x.kind == Bool && y.kind == Bool:
+		switch op {
+		case token.LAND:
+			result = Value{kind: Bool, b: x.b && y.b}
+		case token.LOR:
+			result = Value{kind: Bool, b: x.b || y.b}
+		}
+	case x.kind == String && y.kind == String:
+		if op == token.ADD {
+			result = Value{kind: String, s: x.s + y.s}
+		}
+	case typeName == UntypedFloat || typeName == "float64":
+		a, b := x.toFloat(), y.toFloat()
+		f := new(big.Rat)
+		switch op {
+		case token.ADD:
+			result = Value{kind: Float, f: f.Add(a, b)}
+		case token.SUB:
+			result = Value{kind: Float, f: f.Sub(a, b)}
+		case token.MUL:
+			result = Value{kind: Float, f: f.Mul(a, b)}
+		case token.QUO:
+			if b.Sign() == 0 {
+				return Value{}, fmt.Errorf("invalid operation: division by zero")
+			}
+			result = Value{kind: Float, f: f.Quo(a, b)}
+		}
+	case x.kind == Int && y.kind == Int:
+		a, b := x.i, y.i
+		n := new(big.Int)
+		switch op {
+		case token.ADD:
+			n.Add(a, b)
+		case token.SUB:
+			n.Sub(a, b)
+		case token.MUL:
+			n.Mul(a, b)
+		case token.QUO, token.REM:
+			if b.Sign() == 0 {
+				return Value{}, fmt.Errorf("invalid operation: division by zero")
+			}
+			// Go の除算は 0 方向への切り捨て
+			if op == token.QUO {
+				n.Quo(a, b)
+			} else {
+				n.Rem(a, b)
+			}
+		case token.AND:
+			n.And(a, b)
+		case token.OR:
+			n.Or(a, b)
+		case token.XOR:
+			n.Xor(a, b)
+		case token.AND_NOT:
+			n.AndNot(a, b)
+		default:
+			n = nil
+		}
+		if n != nil {
+			result = Value{kind: Int, i: n}
+		}
+	}
+	if result.kind == Unknown {
+		return Value{}, fmt.Errorf("invalid operation: operator %s not defined on %s (%s constant)", operatorText(op), x, x.Type)
+	}
+
+	result.Type = typeName
+	return representable(result)
+}
+
+// shift returns the value of x << y or x >> y. The result has the type of x;
+// an untyped float with an integral value is shifted as an untyped int.
+func shift(x Value, op token.Token, y Value) (Value, error) {
+	var count *big.Int
+	switch {
+	case y.kind == Int:
+		count = y.i
+	case y.kind == Float && y.f.IsInt():
+		count = y.f.Num()
+	default:
+		return Value{}, fmt.Errorf("invalid shift count %s", y)
+	}
+	if count.Sign() < 0 {
+		return Value{}, fmt.Errorf("invalid negative shift count %s", y)
+	}
+	if !count.IsInt64() || count.Int64() > maxShift {
+		return Value{}, fmt.Errorf("invalid shift count %s (too large)", y)
+	}
+
+	typeName := x.Type
+	if x.kind == Float && x.IsUntyped() && x.f.IsInt() {
+		n := new(big.Int).Set(x.f.Num())
+		x, typeName = Value{kind: Int, i: n}, UntypedInt
+	}
+	if x.kind != Int {
+		return Value{}, fmt.Errorf("invalid operation: shifted operand %s must be integer", x)
+	}
+
+	n := new(big.Int)
+	if op == token.SHL {
+		n.Lsh(x.i, uint(count.Int64()))
+	} else {
+		n.Rsh(x.i, uint(count.Int64()))
+	}
+	return representable(Value{Type: typeName, kind: Int, i: n})
+}
+
+// compare returns the untyped boolean result of comparing x and y, which
+// already have the same type
+func compare(x Value, op token.Token, y Value) (Value, error) {
+	var c int
+	switch {
+	case x.kind == Bool:
+		if op != token.EQL && op != token.NEQ {
+			return Value{}, fmt.Errorf("invalid operation: operator %s not defined on %s (%s constant)", operatorText(op), x, x.Type)
+		}
+		if x.b != y.b {
+			c = 1
+		}
+	case x.kind == String:
+		switch {
+		case x.s < y.s:
+			c = -1
+		case x.s > y.s:
+			c = 1
+		}
+	case x.kind == Int && y.kind == Int:
+		c = x.i.Cmp(y.i)
+	default:
+		c = x.toFloat().Cmp(y.toFloat())
+	}
+
+	var b bool
+	switch op {
+	case token.EQL:
+		b = c == 0
+	case token.NEQ:
+		b = c != 0
+	case token.LSS:
+		b = c < 0
+	case token.LEQ:
+		b = c <= 0
+	case token.GTR:
+		b = c > 0
+	case token.GEQ:
+		b = c >= 0
+	}
+	return MakeBool(b), nil
+}
+
+// UnaryOp returns the value of op x for the prefix operators +, -, ^ and !
+func UnaryOp(op token.Token, x Value) (Value, error) {
+	result := Value{Type: x.Type, kind: x.kind}
+	switch {
+	case op == token.NOT && x.kind == Bool:
+		result.b = !x.b
+	case op == token.ADD && (x.kind == Int || x.kind == Float):
+		return x, nil
+	case op == token.SUB && x.kind == Int:
+		result.i = new(big.Int).Neg(x.i)
+	case op == token.SUB && x.kind == Float:
+		result.f = new(big.Rat).Neg(x.f)
+	case op == token.XOR && x.kind == Int:
+		result.i = new(big.Int).Not(x.i)
+		// 符号なし型の ^x は型の幅のビットだけを反転する
+		if x.Type == "uint8" {
+			result.i.And(result.i, big.NewInt(math.MaxUint8))
+		}
+	default:
+		return Value{}, fmt.Errorf("invalid operation: operator %s not defined on %s (%s constant)", operatorText(op), x, x.Type)
+	}
+	return representable(result)
+}
+
+// representable checks that a typed result fits its type. Typed float64
+// results are rounded to float64 precision.
+func representable(v Value) (Value, error) {
+	if v.IsUntyped() {
+		return v, nil
+	}
+	return convert(v, v.Type)
+}
+
+// operatorText returns the source text of an operator token
+func operatorText(op token.Token) string {
+	switch op {
+	case token.ADD:
+		return "+"
+	case token.SUB:
+		return "-"
+	case token.MUL:
+		return "*"
+	case token.QUO:
+		return "/"
+	case token.REM:
+		return "%"
+	case token.AND:
+		return "&"
+	case token.OR:
+		return "|"
+	case token.XOR:
+		return "^"
+	case token.AND_NOT:
+		return "&^"
+	case token.LAND:
+		return "&&"
+	case token.LOR:
+		return "||"
+	case token.NOT:
+		return "!"
+	case token.LSS:
+		return "<"
+	case token.LEQ:
+		return "<="
+	case token.GTR:
+		return ">"
+	case token.GEQ:
+		return ">="
+	}
+	return "?"
+}
@@ -0,0 +1,202 @@
+package constant
+
+import (
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/token"
+)
+
+func TestBinaryOp(t *testing.T) {
+	big, _ := BinaryOp(MakeInt64(1), token.SHL, MakeInt64(100))
+
+	tests := []struct {
+		x        Value
+		op       token.Token
+		y        Value
+		expected string
+		typeName string
+	}{
+		{MakeInt64(7), token.QUO, MakeInt64(2), "3", UntypedInt},
+		{MakeInt64(-7), token.REM, MakeInt64(2), "-1", UntypedInt},
+		{MakeInt64(7), token.QUO, MakeFloat64(2), "3.5", UntypedFloat},
+		{MakeRune('a'), token.ADD, MakeInt64(1), "98", UntypedRune},
+		{big, token.SHR, MakeInt64(98), "4", UntypedInt},
+		{big, token.SUB, big, "0", UntypedInt},
+		{MakeString("ab"), token.ADD, MakeString("c"), `"abc"`, UntypedString},
+		{MakeInt64(1), token.LSS, MakeFloat64(1.5), "true", UntypedBool},
+		{MakeBool(true), token.LAND, MakeBool(false), "false", UntypedBool},
+		{MakeInt64(12), token.AND_NOT, MakeInt64(4), "8", UntypedInt},
+		{MakeFloat64(2), token.SHL, MakeInt64(3), "16", UntypedInt},
+	}
+
+	for _, tt := range tests {
+		v, err := BinaryOp(tt.x, tt.op, tt.y)
+		if err != nil {
+			t.Fatalf("%s op %s: unexpected error %v", tt.x, tt.y, err)
+		}
+		if v.String() != tt.expected || v.Type != tt.typeName {
+			t.Errorf("%s op %s: expected %s (%s), got %s (%s)", tt.x, tt.y, tt.expected, tt.typeName, v, v.Type)
+		}
+	}
+}
+
+func TestMakeFromLiteral(t *testing.T) {
+	literal := func(lit string, tok token.Token) Value {
+		v, err := MakeFromLiteral(lit, tok)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", lit, err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		x        Value
+		op       token.Token
+		y        Value
+		expected string
+	}{
+		// 0.1 + 0.2 == 0.3 holds for exact constants
+		{literal("0.1", token.FLOAT), token.ADD, literal("0.2", token.FLOAT), "0.3"},
+		{literal("100000000000000000000", token.INT), token.QUO, literal("1e10", token.FLOAT), "1e+10"},
+		{literal("0x_FF", token.INT), token.ADD, literal("0b1", token.INT), "256"},
+		{literal("0x1p-2", token.FLOAT), token.MUL, literal("4", token.INT), "1"},
+		{literal("1e400", token.FLOAT), token.QUO, literal("1e399", token.FLOAT), "10"},
+	}
+	for _, tt := range tests {
+		v, err := BinaryOp(tt.x, tt.op, tt.y)
+		if err != nil {
+			t.Fatalf("%s op %s: unexpected error %v", tt.x, tt.y, err)
+		}
+		if v.String() != tt.expected {
+			t.Errorf("%s op %s: expected %s, got %s", tt.x, tt.y, tt.expected, v)
+		}
+	}
+
+	sum, _ := BinaryOp(literal("0.1", token.FLOAT), token.ADD, literal("0.2", token.FLOAT))
+	if equal, _ := BinaryOp(sum, token.EQL, literal("0.3", token.FLOAT)); !equal.BoolVal() {
+		t.Error("expected 0.1 + 0.2 == 0.3")
+	}
+	if _, err := MakeFromLiteral("1e100000", token.FLOAT); err == nil {
+		t.Error("expected an error for a too large exponent")
+	}
+}
+
+func TestBinaryOp_Typed(t *testing.T) {
+	maxByte, _ := Convert(MakeInt64(255), "uint8")
+	one, _ := Convert(MakeInt64(1), "int32")
+
+	if _, err := BinaryOp(maxByte, token.ADD, MakeInt64(1)); err == nil || err.Error() != "constant 256 overflows uint8" {
+		t.Errorf("expected overflow of uint8, got %v", err)
+	}
+	if _, err := BinaryOp(one, token.SHL, MakeInt64(31)); err == nil || err.Error() != "constant 2147483648 overflows int32" {
+		t.Errorf("expected overflow of int32, got %v", err)
+	}
+	if _, err := BinaryOp(maxByte, token.ADD, one); err == nil || err.Error() != "invalid operation: mismatched types uint8 and int32" {
+		t.Errorf("expected mismatched types, got %v", err)
+	}
+
+	v, err := BinaryOp(one, token.MUL, MakeInt64(3))
+	if err != nil || v.String() != "3" || v.Type != "int32" {
+		t.Errorf("expected 3 of type int32, got %s (%s), %v", v, v.Type, err)
+	}
+}
+
+func TestBinaryOp_Errors(t *testing.T) {
+	tests := []struct {
+		x        Value
+		op       token.Token
+		y        Value
+		expected string
+	}{
+		{MakeInt64(1), token.QUO, MakeInt64(0), "invalid operation: division by zero"},
+		{MakeFloat64(1), token.QUO, MakeFloat64(0), "invalid operation: division by zero"},
+		{MakeString("a"), token.ADD, MakeInt64(1), "invalid operation: mismatched types untyped string and untyped int"},
+		{MakeString("a"), token.SUB, MakeString("b"), `invalid operation: operator - not defined on "a" (untyped string constant)`},
+		{MakeFloat64(1.5), token.SHL, MakeInt64(1), "invalid operation: shifted operand 1.5 must be integer"},
+		{MakeInt64(1), token.SHL, MakeInt64(-1), "invalid negative shift count -1"},
+		{MakeInt64(1), token.SHL, MakeInt64(100000), "invalid shift count 100000 (too large)"},
+	}
+
+	for _, tt := range tests {
+		_, err := BinaryOp(tt.x, tt.op, tt.y)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s op %s: expected error %q, got %v", tt.x, tt.y, tt.expected, err)
+		}
+	}
+}
+
+func TestUnaryOp(t *testing.T) {
+	b, _ := Convert(MakeInt64(5), "uint8")
+
+	tests := []struct {
+		op       token.Token
+		x        Value
+		expected string
+	}{
+		{token.SUB, MakeInt64(5), "-5"},
+		{token.XOR, MakeInt64(5), "-6"},
+		{token.XOR, b, "250"},
+		{token.NOT, MakeBool(false), "true"},
+		{token.SUB, MakeFloat64(2.5), "-2.5"},
+	}
+
+	for _, tt := range tests {
+		v, err := UnaryOp(tt.op, tt.x)
+		if err != nil || v.String() != tt.expected {
+			t.Errorf("op %s: expected %s, got %s, %v", tt.x, tt.expected, v, err)
+		}
+	}
+
+	if _, err := UnaryOp(token.SUB, b); err == nil || err.Error() != "constant -5 overflows uint8" {
+		t.Errorf("expected overflow of uint8, got %v", err)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		v        Value
+		typeName string
+		expected string
+	}{
+		{MakeInt64(255), "byte", ""},
+		{MakeInt64(256), "uint8", "constant 256 overflows uint8"},
+		{MakeInt64(-1), "uint8", "constant -1 overflows uint8"},
+		{MakeFloat64(2.0), "int", ""},
+		{MakeFloat64(2.5), "int", "constant 2.5 truncated to integer"},
+		{MakeRune('x'), "float64", ""},
+		{MakeString("s"), "int", `cannot convert "s" (untyped string constant) to type int`},
+		{MakeBool(true), "string", "cannot convert true (untyped bool constant) to type string"},
+	}
+
+	for _, tt := range tests {
+		_, err := Convert(tt.v, tt.typeName)
+		if tt.expected == "" && err != nil {
+			t.Errorf("%s to %s: unexpected error %v", tt.v, tt.typeName, err)
+		}
+		if tt.expected != "" && (err == nil || err.Error() != tt.expected) {
+			t.Errorf("%s to %s: expected error %q, got %v", tt.v, tt.typeName, tt.expected, err)
+		}
+	}
+}
+
+func TestDefaultType(t *testing.T) {
+	typed, _ := Convert(MakeInt64(1), "uint8")
+
+	tests := []struct {
+		v        Value
+		expected string
+	}{
+		{MakeInt64(1), "int"},
+		{MakeRune('a'), "int32"},
+		{MakeFloat64(1), "float64"},
+		{MakeString(""), "string"},
+		{MakeBool(true), "bool"},
+		{typed, "uint8"},
+	}
+
+	for _, tt := range tests {
+		if got := DefaultType(tt.v); got != tt.expected {
+			t.Errorf("%s (%s): expected default type %s, got %s", tt.v, tt.v.Type, tt.expected, got)
+		}
+	}
+}
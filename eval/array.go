@@ -0,0 +1,74 @@
+package eval
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+)
+
+// ArrayValue represents an array [N]T. Unlike a slice, an array is a value:
+// copies made by copyValue do not share its elements.
+type ArrayValue struct {
+	ElementType string
+	Elements    []Value
+}
+
+func (v *ArrayValue) Type() string {
+	return "[" + strconv.Itoa(len(v.Elements)) + "]" + v.ElementType
+}
+func (v *ArrayValue) String() string {
+	return fmt.Sprintf("[%d elements]", len(v.Elements))
+}
+func (v *ArrayValue) IsTruthy() bool { return true }
+
+// isArrayType reports whether typeName names an array type such as [3]int
+func isArrayType(typeName string) bool {
+	return strings.HasPrefix(typeName, "[") && !strings.HasPrefix(typeName, "[]")
+}
+
+// arrayType splits an array type such as [3][]int into its length and
+// element type
+func arrayType(typeName string) (length int, elementType string) {
+	end := strings.Index(typeName, "]")
+	if end < 0 {
+		return 0, ""
+	}
+	length, _ = strconv.Atoi(typeName[len("["):end])
+	return length, typeName[end+1:]
+}
+
+// zeroArray returns an array whose elements are all zero values
+func zeroArray(typeName string, env *Environment) *ArrayValue {
+	length, elementType := arrayType(typeName)
+	elements := make([]Value, length)
+	for i := range elements {
+		elements[i] = zeroValueOf(elementType, env)
+	}
+	return &ArrayValue{ElementType: elementType, Elements: elements}
+}
+
+// evalArrayLiteral evaluates array literals. Elements that are not given
+// are zero values.
+func evalArrayLiteral(node *ast.ArrayLiteral, env *Environment) Value {
+	array := zeroArray(node.TypeName(), env)
+	for i, elem := range node.Elements {
+		if i < len(array.Elements) {
+			array.Elements[i] = evalElement(elem, array.ElementType, env)
+		}
+	}
+	return array
+}
+
+// elementsOf returns the elements and the element type of a slice or an
+// array. ok is false for other values.
+func elementsOf(value Value) (elements []Value, elementType string, ok bool) {
+	switch v := value.(type) {
+	case *SliceValue:
+		return v.Elements, v.ElementType, true
+	case *ArrayValue:
+		return v.Elements, v.ElementType, true
+	}
+	return nil, "", false
+}
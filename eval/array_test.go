@@ -0,0 +1,95 @@
+package eval
+
+import "testing"
+
+func TestArray_Values(t *testing.T) {
+	env := evalProgram(t, `var zeros [3]int
+var names [2]string
+primes := [...]int{2, 3, 5, 7}
+partial := [4]int{1, 2}
+copied := primes
+copied[0] = 100
+first := primes[0]
+length := len(partial)
+sum := 0
+for i, p := range primes {
+	sum += i * p
+}
+grid := [2][2]int{{1, 2}, {3, 4}}
+corner := grid[1][1]
+same := [2]int{1, 2} == [2]int{1, 2}`)
+
+	expected := map[string]struct {
+		value    string
+		typeName string
+	}{
+		"zeros":  {"[3 elements]", "[3]int"},
+		"names":  {"[2 elements]", "[2]string"},
+		"primes": {"[4 elements]", "[4]int"},
+		"first":  {"2", "int"},
+		"length": {"4", "int"},
+		"sum":    {"34", "int"},
+		"corner": {"4", "int"},
+		"same":   {"true", "bool"},
+	}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("%s not set", name)
+		}
+		if value.String() != want.value || value.Type() != want.typeName {
+			t.Errorf("%s: expected %s of type %s, got %s of type %s", name, want.value, want.typeName, value.String(), value.Type())
+		}
+	}
+
+	partial, _ := env.Get("partial")
+	if last := partial.(*ArrayValue).Elements[3]; last.String() != "0" {
+		t.Errorf("expected missing elements to be zero, got %s", last)
+	}
+}
+
+func TestCompositeLiterals_NestedTypes(t *testing.T) {
+	env := evalProgram(t, `type Person struct {
+	Name string
+	Tags []string
+}
+type Node struct {
+	Value int
+}
+func names(people []Person) []string {
+	out := []string{}
+	for _, p := range people {
+		out = append(out, p.Name)
+	}
+	return out
+}
+people := []Person{{Name: "ann", Tags: []string{"a", "b"}}, Person{Name: "bob"}}
+listed := names(people)
+tags := len(people[0].Tags)
+grid := [][]int{{1, 2}, []int{3}}
+nodes := []*Node{{Value: 1}, &Node{Value: 2}}
+index := map[string][]int{"a": {1, 2, 3}}
+count := len(index["a"])
+second := nodes[1].Value`)
+
+	expected := map[string]struct {
+		value    string
+		typeName string
+	}{
+		"listed": {"[2 elements]", "[]string"},
+		"tags":   {"2", "int"},
+		"grid":   {"[2 elements]", "[][]int"},
+		"nodes":  {"[2 elements]", "[]*Node"},
+		"count":  {"3", "int"},
+		"second": {"2", "int"},
+	}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("%s not set", name)
+		}
+		if value.String() != want.value || value.Type() != want.typeName {
+			t.Errorf("%s: expected %s of type %s, got %s of type %s", name, want.value, want.typeName, value.String(), value.Type())
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package eval
+
+import (
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/token"
+)
+
+// assignTarget returns a pointer to the location an assignment to target
+// stores to: a variable, a struct field, a slice or array element, a map
+// entry or the value a pointer points to. Map entries are not addressable,
+// so they get a location of their own. It returns nil when there is nothing
+// to assign to, such as an undeclared variable, an element out of range or
+// a field of a struct in a map; like other run-time errors of the
+// evaluator, the assignment is ignored.
+func assignTarget(target ast.ASTNode, env *Environment) *PointerValue {
+	switch x := target.(type) {
+	case *ast.VariableNode:
+		if _, exists := env.Get(x.Name); !exists {
+			return nil
+		}
+	case *ast.IndexAccess:
+		owner := assignTarget(x.Object, env)
+		index := EvalValueWithEnvironment(x.Index, env)
+		if owner == nil {
+			return nil
+		}
+		obj := owner.Load()
+		if m, ok := obj.(*MapValue); ok {
+			key := convertValue(index, m.KeyType, env)
+			return &PointerValue{ElementType: m.ValueType, target: mapEntryLocation{m: m, key: key, env: env}}
+		}
+		if _, isArray := obj.(*ArrayValue); isArray && inMap(owner) {
+			return nil
+		}
+		elements, elementType, ok := elementsOf(derefValue(obj))
+		i, isInt := toInt(index)
+		if !ok || !isInt || i < 0 || i >= len(elements) {
+			return nil
+		}
+		return &PointerValue{ElementType: elementType, target: cellLocation{cell: &elements[i]}}
+	case *ast.FieldAccessNode:
+		owner := assignTarget(x.Object, env)
+		if owner == nil {
+			return nil
+		}
+		obj := owner.Load()
+		if _, isStruct := obj.(*StructValue); isStruct && inMap(owner) {
+			return nil
+		}
+		if object, ok := derefValue(obj).(*StructValue); ok {
+			if value, exists := object.Fields[x.Field]; exists {
+				return &PointerValue{ElementType: value.Type(), target: fieldLocation{object: object, field: x.Field}}
+			}
+		}
+	}
+	return evalAddress(target, env)
+}
+
+// inMap reports whether a location is a map entry, whose fields and
+// elements are not addressable
+func inMap(location *PointerValue) bool {
+	_, ok := location.target.(mapEntryLocation)
+	return ok
+}
+
+// evalAssign evaluates x = v. Like in Go, the operands of x are evaluated
+// before v.
+func evalAssign(s *ast.ReassignStatement, env *Environment) {
+	target := assignTarget(s.Target, env)
+	value := EvalValueWithEnvironment(s.Value, env)
+	if target != nil {
+		target.Store(copyValue(convertValue(value, target.ElementType, env)))
+	}
+}
+
+// evalTupleAssign evaluates a, b := x, y and a, b = x, y. Like in Go, the
+// operands of the targets are evaluated first, then all values, before any
+// target is assigned, so a, b = b, a and xs[i], xs[j] = xs[j], xs[i] swap.
+func evalTupleAssign(s *ast.TupleAssignStatement, env *Environment) {
+	// := declares the variables that are new in this scope
+	names := s.Names()
+	declares := make([]bool, len(s.Targets))
+	targets := make([]*PointerValue, len(s.Targets))
+	for i, target := range s.Targets {
+		if names[i] == "_" {
+			continue
+		}
+		if _, local := env.variables[names[i]]; s.Define && !local {
+			declares[i] = true
+			continue
+		}
+		targets[i] = assignTarget(target, env)
+	}
+	values := evalTupleValues(s.Values, len(s.Targets), env)
+	for i := 0; i < len(s.Targets) && i < len(values); i++ {
+		if declares[i] {
+			env.Define(names[i], values[i])
+		} else if targets[i] != nil {
+			targets[i].Store(copyValue(convertValue(values[i], targets[i].ElementType, env)))
+		}
+	}
+}
+
+// evalOpAssign evaluates x op= v, x++ and x--, which evaluate the operands
+// of x only once
+func evalOpAssign(x ast.ASTNode, op token.Token, operand ast.ASTNode, env *Environment) {
+	target := assignTarget(x, env)
+	value := EvalValueWithEnvironment(operand, env)
+	if target == nil {
+		return
+	}
+	old := target.Load()
+	target.Store(convertValue(binaryOp(old, value, op), old.Type(), env))
+}
@@ -0,0 +1,160 @@
+package eval
+
+import "testing"
+
+func TestAssign_Targets(t *testing.T) {
+	env := evalProgram(t, `type Point struct {
+	X int
+	Y int
+}
+type Line struct {
+	From Point
+	To   Point
+}
+xs := []int{1, 2, 3}
+xs[0] = 10
+xs[1] += 5
+xs[2]++
+first := xs[0]
+second := xs[1]
+third := xs[2]
+grid := [][]int{{1, 2}, {3, 4}}
+grid[1][0] *= 10
+cell := grid[1][0]
+var arr [3]int
+arr[2] = 7
+arr[2]--
+last := arr[2]
+p := Point{X: 1, Y: 2}
+p.X = 5
+p.Y <<= 2
+px := p.X
+py := p.Y
+var l Line
+l.To.X = 3
+l.To.X++
+toX := l.To.X
+q := &p
+q.X -= 1
+pxAgain := p.X
+n := 1
+ptr := &n
+*ptr = 6
+*ptr *= 7
+deref := n
+ps := []Point{Point{X: 1, Y: 1}}
+ps[0].Y = 9
+elemY := ps[0].Y
+m := map[string]int{"a": 1}
+m["a"] += 2
+m["b"]++
+ma := m["a"]
+mb := m["b"]
+words := []string{"go"}
+words[0] += "pher"
+word := words[0]`)
+
+	expected := map[string]string{
+		"first":   "10",
+		"second":  "7",
+		"third":   "4",
+		"cell":    "30",
+		"last":    "6",
+		"px":      "5",
+		"py":      "8",
+		"toX":     "4",
+		"pxAgain": "4",
+		"deref":   "42",
+		"elemY":   "9",
+		"ma":      "3",
+		"mb":      "1",
+		"word":    "gopher",
+	}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("%s not set", name)
+		}
+		if value.String() != want {
+			t.Errorf("%s: expected %s, got %s", name, want, value.String())
+		}
+	}
+}
+
+func TestAssign_OperandsEvaluatedOnce(t *testing.T) {
+	env := evalProgram(t, `calls := 0
+next := func() int {
+	calls++
+	return calls - 1
+}
+xs := []int{10, 20}
+xs[next()] += 1
+xs[next()]++
+a := xs[0]
+b := xs[1]`)
+
+	expected := map[string]string{"calls": "2", "a": "11", "b": "21"}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("%s not set", name)
+		}
+		if value.String() != want {
+			t.Errorf("%s: expected %s, got %s", name, want, value.String())
+		}
+	}
+}
+
+func TestAssign_MapEntryFields(t *testing.T) {
+	// Fields of a struct in a map are not addressable, so assignments to
+	// them are ignored
+	env := evalProgram(t, `type Point struct {
+	X int
+}
+m := map[string]Point{"a": Point{X: 1}}
+m["a"].X = 5
+m["a"].X++
+x := m["a"].X
+ptrs := map[string]*Point{"a": &Point{X: 1}}
+ptrs["a"].X = 5
+px := ptrs["a"].X`)
+
+	expected := map[string]string{"x": "1", "px": "5"}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("%s not set", name)
+		}
+		if value.String() != want {
+			t.Errorf("%s: expected %s, got %s", name, want, value.String())
+		}
+	}
+}
+
+func TestAssign_CompositeLiteralsCopyStructs(t *testing.T) {
+	env := evalProgram(t, `type Point struct {
+	X int
+}
+type Line struct {
+	From Point
+}
+p := Point{X: 1}
+l := Line{From: p}
+ps := []Point{p}
+m := map[int]Point{0: p}
+p.X = 2
+fromX := l.From.X
+elemX := ps[0].X
+entryX := m[0].X`)
+
+	expected := map[string]string{"fromX": "1", "elemX": "1", "entryX": "1"}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("%s not set", name)
+		}
+		if value.String() != want {
+			t.Errorf("%s: expected %s, got %s", name, want, value.String())
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package eval
+
+import "github.com/yuya-takeyama/petitgo/ast"
+
+// break, continue and goto unwind the evaluation with a ControlFlowException
+// up to the statement they branch to: the loop or switch with their label,
+// or the innermost one, and for goto the statement list declaring the label.
+// The parser has checked that this statement exists.
+
+// evalForStatement evaluates a for loop. label is the label of the loop, if
+// any. The variables declared by the init statement are local to the loop,
+// and each iteration has copies of its own, so closures created in the body
+// capture the values of their iteration.
+func evalForStatement(s *ast.ForStatement, label string, env *Environment) {
+	scope := env.enclosed()
+	if s.Init != nil {
+		EvalStatement(s.Init, scope)
+	}
+	var declared []string
+	for name := range scope.variables {
+		declared = append(declared, name)
+	}
+
+	for {
+		// condition check with type-aware evaluation
+		if s.Condition != nil {
+			condition := EvalValueWithEnvironment(s.Condition, scope)
+			if !condition.IsTruthy() {
+				break
+			}
+		}
+
+		// body execution
+		if !evalLoopBody(s.Body, label, scope) {
+			break
+		}
+
+		// The next iteration starts with the values of this one
+		next := env.enclosed()
+		for _, name := range declared {
+			next.variables[name] = scope.variables[name]
+		}
+		scope = next
+
+		// execute update statement if present
+		if s.Update != nil {
+			EvalStatement(s.Update, scope)
+		}
+	}
+}
+
+// evalLoopBody runs the body of a loop once and reports whether the loop
+// goes on. break ends the loop and continue goes on with the next iteration.
+func evalLoopBody(body *ast.BlockStatement, label string, env *Environment) (next bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			branch, ok := r.(*ControlFlowException)
+			if !ok || branch.Type == "goto" || (branch.Label != "" && branch.Label != label) {
+				// Re-panic for other exceptions and outer statements
+				panic(r)
+			}
+			next = branch.Type == "continue"
+		}
+	}()
+
+	EvalBlockStatement(body, env)
+	return true
+}
+
+// evalBreakable evaluates a switch statement, which break ends
+func evalBreakable(label string, eval func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			if branch, ok := r.(*ControlFlowException); ok && branch.Type == "break" && (branch.Label == "" || branch.Label == label) {
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	eval()
+}
+
+// evalLabeledStatement evaluates the statement of a labeled statement. A
+// loop or switch gets the label, so that break and continue with the label
+// apply to it.
+func evalLabeledStatement(s *ast.LabeledStatement, env *Environment) {
+	switch stmt := s.Statement.(type) {
+	case *ast.ForStatement:
+		evalForStatement(stmt, s.Label, env)
+	case *ast.RangeStatement:
+		evalRangeStatement(stmt, s.Label, env)
+	case *ast.SwitchStatement:
+		evalBreakable(s.Label, func() { evalSwitchStatement(stmt, env) })
+	case *ast.TypeSwitchStatement:
+		evalBreakable(s.Label, func() { evalTypeSwitchStatement(stmt, env) })
+	default:
+		EvalStatement(s.Statement, env)
+	}
+}
+
+// evalStatements evaluates a statement list. A goto to one of the labels of
+// the list goes on with the labeled statement.
+func evalStatements(list []ast.Statement, env *Environment) {
+	var labels map[string]int
+	for i, stmt := range list {
+		if labeled, ok := stmt.(*ast.LabeledStatement); ok {
+			if labels == nil {
+				labels = make(map[string]int)
+			}
+			labels[labeled.Label] = i
+		}
+	}
+
+	if labels == nil {
+		for _, stmt := range list {
+			EvalStatement(stmt, env)
+		}
+		return
+	}
+	for i := 0; i < len(list); {
+		i = evalStatementsFrom(list, i, labels, env)
+	}
+}
+
+// evalStatementsFrom evaluates the statements of a list from the i-th one.
+// It returns the end of the list, or the index of the statement labeled with
+// the label a goto jumped to.
+func evalStatementsFrom(list []ast.Statement, i int, labels map[string]int, env *Environment) (next int) {
+	defer func() {
+		if r := recover(); r != nil {
+			if branch, ok := r.(*ControlFlowException); ok && branch.Type == "goto" {
+				if target, exists := labels[branch.Label]; exists {
+					next = target
+					return
+				}
+			}
+			panic(r)
+		}
+	}()
+
+	for ; i < len(list); i++ {
+		EvalStatement(list[i], env)
+	}
+	return len(list)
+}
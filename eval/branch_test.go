@@ -0,0 +1,94 @@
+package eval
+
+import "testing"
+
+func TestBranch_BreakAndContinue(t *testing.T) {
+	env := evalProgram(t, `sum := 0
+for i := 0; i < 10; i++ {
+	if i%2 == 0 {
+		continue
+	}
+	if i > 7 {
+		break
+	}
+	sum += i
+}
+found := -1
+grid := [][]int{{1, 2}, {3, 4}}
+outer:
+for i, row := range grid {
+	for j, v := range row {
+		if v == 3 {
+			found = i*10 + j
+			break outer
+		}
+	}
+}
+count := 0
+rows:
+for i := 0; i < 3; i++ {
+	for j := 0; j < 3; j++ {
+		if j == 1 {
+			continue rows
+		}
+		count++
+	}
+}
+skipped := 0
+for k := 0; k < 5; k++ {
+	switch k {
+	case 2:
+		continue
+	case 4:
+		break
+	}
+	skipped += k
+}
+reached := 0
+sw:
+switch {
+case true:
+	for {
+		break sw
+	}
+	reached = 1
+}`)
+
+	expected := map[string]string{
+		"sum":     "16",
+		"found":   "10",
+		"count":   "3",
+		"skipped": "8",
+		"reached": "0",
+	}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("%s not set", name)
+		}
+		if value.String() != want {
+			t.Errorf("%s: expected %s, got %s", name, want, value.String())
+		}
+	}
+}
+
+func TestBranch_Goto(t *testing.T) {
+	env := evalProgram(t, `func countdown(n int) int {
+	steps := 0
+loop:
+	if n > 0 {
+		n--
+		steps++
+		goto loop
+	}
+	goto done
+	steps = -1
+done:
+	return steps
+}
+steps := countdown(4)`)
+
+	if steps, _ := env.Get("steps"); steps.String() != "4" {
+		t.Errorf("expected 4 steps, got %s", steps.String())
+	}
+}
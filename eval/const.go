@@ -0,0 +1,35 @@
+package eval
+
+import (
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/constant"
+)
+
+// evalConstStatement defines the constants of a declaration, whose values
+// the parser has already evaluated
+func evalConstStatement(s *ast.ConstStatement, env *Environment) {
+	for _, spec := range s.Specs {
+		for i, name := range spec.Names {
+			if name != "_" && i < len(spec.Constants) {
+				env.Define(name, constantValue(spec.Constants[i]))
+			}
+		}
+	}
+}
+
+// constantValue converts a constant to a value of its type, or of its
+// default type for an untyped constant
+func constantValue(v constant.Value) Value {
+	switch v.Kind() {
+	case constant.Bool:
+		return &BoolValue{Value: v.BoolVal()}
+	case constant.String:
+		return &StringValue{Value: v.StringVal()}
+	case constant.Float:
+		return &FloatValue{Value: v.Float64Val()}
+	case constant.Int:
+		n, _ := v.Int64Val()
+		return newInteger(constant.DefaultType(v), int(n))
+	}
+	return &IntValue{Value: 0}
+}
@@ -0,0 +1,81 @@
+package eval
+
+import (
+	"testing"
+)
+
+func TestConst_Declarations(t *testing.T) {
+	env := evalProgram(t, `const Big = 1 << 100
+const (
+	Sunday = iota
+	Monday
+	Tuesday
+)
+const (
+	_  = iota
+	KB = 1 << (10 * iota)
+	MB
+)
+const Pi = 3.5
+const Greeting = "hello, " + "world"
+const Letter = 'a' + 1
+const Small int32 = 7
+const Mask byte = ^byte(0) >> 4
+small := Big >> 98
+day := Tuesday
+mb := MB / KB
+area := Pi * 2
+greeting := Greeting
+letter := Letter
+product := Small * 3
+mask := Mask
+half := float64(Monday) / 2`)
+
+	expected := map[string]struct {
+		value    string
+		typeName string
+	}{
+		"small":    {"4", "int"},
+		"day":      {"2", "int"},
+		"mb":       {"1024", "int"},
+		"area":     {"7", "float64"},
+		"greeting": {"hello, world", "string"},
+		"letter":   {"98", "int32"},
+		"product":  {"21", "int32"},
+		"mask":     {"15", "uint8"},
+		"half":     {"0.5", "float64"},
+		"Tuesday":  {"2", "int"},
+		"Pi":       {"3.5", "float64"},
+	}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("%s not set", name)
+		}
+		if value.String() != want.value || value.Type() != want.typeName {
+			t.Errorf("%s: expected %s of type %s, got %s of type %s", name, want.value, want.typeName, value.String(), value.Type())
+		}
+	}
+}
+
+func TestConst_Scopes(t *testing.T) {
+	env := evalProgram(t, `const N = 10
+func shadowed(N int) int {
+	return N * 2
+}
+func local() int {
+	const N = 3
+	return N
+}
+a := shadowed(4)
+b := local()
+c := N`)
+
+	expected := map[string]string{"a": "8", "b": "3", "c": "10"}
+	for name, want := range expected {
+		value, _ := env.Get(name)
+		if value == nil || value.String() != want {
+			t.Errorf("%s: expected %s, got %v", name, want, value)
+		}
+	}
+}
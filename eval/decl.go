@@ -0,0 +1,118 @@
+package eval
+
+import "github.com/yuya-takeyama/petitgo/ast"
+
+// A program declares its functions, types and constants before anything
+// runs, and its package-level variables are initialized in order, except
+// that a variable referred to before its declaration is initialized first:
+// var a = b + 1 and var b = 2 give a the value 3, also when b is only used
+// by a function a's initializer calls.
+
+// initialization tracks the package-level variables of a program
+type initialization struct {
+	pending map[string]ast.Statement // variable -> its declaration
+	done    map[ast.Statement]bool   // declarations evaluated already
+	active  []string                 // variables being initialized, the innermost last
+}
+
+// EvalProgram evaluates the top-level statements of a program in env. main
+// is not called.
+func EvalProgram(statements []ast.Statement, env *Environment) {
+	env.init = &initialization{pending: make(map[string]ast.Statement), done: make(map[ast.Statement]bool)}
+	defer func() { env.init = nil }()
+
+	var rest []ast.Statement
+	for _, stmt := range statements {
+		switch s := stmt.(type) {
+		case *ast.FuncStatement, *ast.StructDefinition, *ast.TypeStatement, *ast.InterfaceStatement,
+			*ast.ConstStatement, *ast.PackageStatement, *ast.ImportStatement:
+			EvalStatement(stmt, env)
+			continue
+		case *ast.DeclGroup:
+			if s.Keyword != "var" {
+				EvalStatement(stmt, env)
+				continue
+			}
+		}
+		for _, decl := range variableDeclarations(stmt) {
+			for _, name := range declaredNames(decl) {
+				if name != "_" {
+					env.init.pending[name] = decl
+				}
+			}
+		}
+		rest = append(rest, stmt)
+	}
+
+	for _, stmt := range rest {
+		decls := variableDeclarations(stmt)
+		if decls == nil {
+			EvalStatement(stmt, env)
+		}
+		for _, decl := range decls {
+			env.initialize(decl)
+		}
+	}
+}
+
+// initialize evaluates a declaration of package-level variables unless it
+// is evaluated already
+func (env *Environment) initialize(decl ast.Statement) {
+	if env.init.done[decl] {
+		return
+	}
+	env.init.done[decl] = true
+	names := declaredNames(decl)
+	env.init.active = append(env.init.active, names...)
+	EvalStatement(decl, env)
+	env.init.active = env.init.active[:len(env.init.active)-len(names)]
+}
+
+// initializePending initializes a package-level variable referred to before
+// its declaration and reports whether there is one. Referring to a variable
+// during its own initialization is an initialization cycle.
+func (env *Environment) initializePending(name string) bool {
+	if env.init == nil {
+		return false
+	}
+	for _, active := range env.init.active {
+		if active == name {
+			referring := env.init.active[len(env.init.active)-1]
+			if referring == name {
+				name = "itself"
+			}
+			panic(&PanicException{Value: &StringValue{Value: "initialization cycle: " + referring + " refers to " + name}})
+		}
+	}
+	decl, exists := env.init.pending[name]
+	if !exists || env.init.done[decl] {
+		return false
+	}
+	env.initialize(decl)
+	return true
+}
+
+// variableDeclarations returns the declarations of variables a top-level
+// statement consists of, or nil for other statements
+func variableDeclarations(stmt ast.Statement) []ast.Statement {
+	switch s := stmt.(type) {
+	case *ast.VarStatement, *ast.TupleVarStatement:
+		return []ast.Statement{stmt}
+	case *ast.DeclGroup:
+		if s.Keyword == "var" {
+			return s.Decls
+		}
+	}
+	return nil
+}
+
+// declaredNames returns the variables a variable declaration declares
+func declaredNames(decl ast.Statement) []string {
+	switch s := decl.(type) {
+	case *ast.VarStatement:
+		return []string{s.Name}
+	case *ast.TupleVarStatement:
+		return s.Names
+	}
+	return nil
+}
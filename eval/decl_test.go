@@ -0,0 +1,177 @@
+package eval
+
+import (
+	"testing"
+)
+
+func TestDecl_ZeroValues(t *testing.T) {
+	env := evalProgram(t, `type Point struct {
+	X int
+	Y float64
+}
+type Line struct {
+	From Point
+	Tags []string
+}
+type Shape interface {
+	Area() int
+}
+var i int
+var f float64
+var s string
+var b bool
+var r rune
+var xs []int
+var m map[string]int
+var p Point
+var l Line
+var sh Shape
+var fn func() int
+isNilSlice := xs == nil
+isNilMap := m == nil
+isNilShape := sh == nil
+isNilFunc := fn == nil
+isEmptyLiteral := []int{} == nil
+xs = append(xs, 1)
+grown := len(xs)`)
+
+	expected := map[string]struct {
+		value    string
+		typeName string
+	}{
+		"i":              {"0", "int"},
+		"f":              {"0", "float64"},
+		"s":              {"", "string"},
+		"b":              {"false", "bool"},
+		"r":              {"0", "int32"},
+		"xs":             {"[1 elements]", "[]int"},
+		"m":              {"map[]", "map[string]int"},
+		"p":              {"Point{...}", "Point"},
+		"sh":             {"<nil>", "Shape"},
+		"isNilSlice":     {"true", "bool"},
+		"isNilMap":       {"true", "bool"},
+		"isNilShape":     {"true", "bool"},
+		"isNilFunc":      {"true", "bool"},
+		"isEmptyLiteral": {"false", "bool"},
+		"grown":          {"1", "int"},
+	}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("%s not set", name)
+		}
+		if value.String() != want.value || value.Type() != want.typeName {
+			t.Errorf("%s: expected %s of type %s, got %s of type %s", name, want.value, want.typeName, value.String(), value.Type())
+		}
+	}
+
+	// struct fields start as zero values, nested structs included
+	l, _ := env.Get("l")
+	from := l.(*StructValue).Fields["From"].(*StructValue)
+	if from.Fields["X"].String() != "0" || from.Fields["Y"].Type() != "float64" {
+		t.Errorf("expected a zero Point, got %v", from.Fields)
+	}
+	if tags := l.(*StructValue).Fields["Tags"].(*SliceValue); !tags.IsNil() || tags.ElementType != "string" {
+		t.Errorf("expected a nil []string, got %v", tags)
+	}
+}
+
+func TestDecl_Groups(t *testing.T) {
+	env := evalProgram(t, `import (
+	"fmt"
+	"os"
+)
+func divmod(a int, b int) (int, int) { return a / b, a % b }
+var (
+	count int
+	name  = "petit"
+	a, b  = 1, "two"
+	c, d  float64 = 1, 2.5
+	q, r  = divmod(17, 5)
+	u, v  int
+)
+var m = map[string]int{"k": 7}
+var found, ok = m["k"]
+var _, missing = m["x"]`)
+
+	expected := map[string]struct {
+		value    string
+		typeName string
+	}{
+		"count":   {"0", "int"},
+		"name":    {"petit", "string"},
+		"a":       {"1", "int"},
+		"b":       {"two", "string"},
+		"c":       {"1", "float64"},
+		"d":       {"2.5", "float64"},
+		"q":       {"3", "int"},
+		"r":       {"2", "int"},
+		"u":       {"0", "int"},
+		"v":       {"0", "int"},
+		"found":   {"7", "int"},
+		"ok":      {"true", "bool"},
+		"missing": {"false", "bool"},
+	}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("%s not set", name)
+		}
+		if value.String() != want.value || value.Type() != want.typeName {
+			t.Errorf("%s: expected %s of type %s, got %s of type %s", name, want.value, want.typeName, value.String(), value.Type())
+		}
+	}
+	if _, exists := env.Get("_"); exists {
+		t.Errorf("expected _ not to be declared")
+	}
+	if imports := env.GetImports(); len(imports) != 2 || imports[0] != "fmt" || imports[1] != "os" {
+		t.Errorf("expected imports fmt and os, got %v", imports)
+	}
+}
+
+func TestDecl_InitializationOrder(t *testing.T) {
+	env := evalProgram(t, `var a = b + 1
+var b = 2
+var c = total()
+var (
+	x, y = d, 5
+	d    = "d"
+)
+
+func total() int {
+	return a + b
+}`)
+
+	for name, want := range map[string]string{"a": "3", "b": "2", "c": "5", "x": "d", "y": "5"} {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("%s not set", name)
+		}
+		if value.String() != want {
+			t.Errorf("%s: expected %s, got %s", name, want, value.String())
+		}
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var p = q\nvar q = p + 1", "initialization cycle: q refers to p"},
+		{"var r = f()\nfunc f() int { return r }", "initialization cycle: r refers to itself"},
+	}
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				exception, ok := recover().(*PanicException)
+				if !ok {
+					t.Errorf("%q: expected a *PanicException", tt.input)
+					return
+				}
+				if value := exception.Value.String(); value != tt.expected {
+					t.Errorf("%q: expected panic %q, got %q", tt.input, tt.expected, value)
+				}
+			}()
+			evalProgram(t, tt.input)
+		}()
+	}
+}
@@ -0,0 +1,162 @@
+package eval
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+)
+
+// A function call evaluates its body in a frame collecting the calls its
+// defer statements defer. They run last-in first-out when the body ends,
+// whether by a return statement, by falling off its end or by a panic. A
+// panic is a PanicException unwinding the evaluation: the frames it passes
+// record it while running their deferred calls, and a deferred function
+// calling recover() stops it, so that its frame returns normally.
+
+// frame is the call of a user-defined function
+type frame struct {
+	defers []func() // deferred calls, in the order of the defer statements
+
+	// panic is the panic unwinding the frame, nil if none or recovered
+	panic *PanicException
+	// deferring reports whether the deferred calls of the frame are running
+	deferring bool
+	// deferredBy is the frame that deferred the call of this frame, whose
+	// panic recover() stops; nil unless the call is a deferred call
+	deferredBy *frame
+}
+
+// newFrame creates the frame of a call made in env
+func newFrame(env *Environment) *frame {
+	f := &frame{}
+	if env.frame != nil && env.frame.deferring {
+		f.deferredBy = env.frame
+	}
+	return f
+}
+
+// catch evaluates fn in the frame. It returns the ReturnException of a
+// return statement ending fn, and records a panic in the frame, replacing
+// the panic fn was called during.
+func (f *frame) catch(fn func()) (returned *ReturnException) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch ex := r.(type) {
+			case *ReturnException:
+				returned = ex
+			case *PanicException:
+				f.panic = ex
+			default:
+				// Re-panic for other exceptions
+				panic(r)
+			}
+		}
+	}()
+
+	fn()
+	return nil
+}
+
+// runDefers runs the deferred calls of the frame, the last deferred one
+// first. A panic left afterwards goes on unwinding the caller.
+func (f *frame) runDefers() {
+	f.deferring = true
+	for len(f.defers) > 0 {
+		call := f.defers[len(f.defers)-1]
+		f.defers = f.defers[:len(f.defers)-1]
+		f.catch(call)
+	}
+	f.deferring = false
+
+	if f.panic != nil {
+		panic(f.panic)
+	}
+}
+
+// evalDeferStatement evaluates the function value and the arguments of a
+// deferred call, and defers the call to the end of the function. Outside
+// functions there is nothing to defer the call to, and it runs at once.
+func evalDeferStatement(s *ast.DeferStatement, env *Environment) {
+	call := s.Call
+
+	// The evaluated values are kept in hidden variables of a scope of their
+	// own, which the deferred call refers to
+	scope := env.enclosed()
+	hidden := func(value Value) ast.ASTNode {
+		name := "." + strconv.Itoa(len(scope.variables))
+		scope.Define(name, value)
+		return &ast.VariableNode{Name: name, Pos: call.Pos}
+	}
+
+	deferred := &ast.CallNode{Function: call.Function, Pos: call.Pos}
+	switch {
+	case call.Receiver != nil:
+		deferred.Receiver = hidden(deferredReceiver(call, env))
+	case call.Callee != nil:
+		deferred.Callee = hidden(EvalValueWithEnvironment(call.Callee, env))
+	default:
+		// A variable holding a function is called with its current value
+		if value, exists := env.Get(call.Function); exists {
+			if _, ok := value.(*FunctionValue); ok {
+				deferred.Function = ""
+				deferred.Callee = hidden(value)
+			}
+		}
+	}
+	for _, value := range evalValueList(call.Arguments, env) {
+		deferred.Arguments = append(deferred.Arguments, hidden(value))
+	}
+
+	run := func() { evalCallWithTypes(deferred, scope) }
+	if env.frame == nil {
+		run()
+		return
+	}
+	env.frame.defers = append(env.frame.defers, run)
+}
+
+// deferredReceiver evaluates the receiver of a deferred method call. A
+// method with a pointer receiver gets the address of an addressable
+// receiver, and a value receiver is copied, as at the call.
+func deferredReceiver(call *ast.CallNode, env *Environment) Value {
+	receiver := EvalValueWithEnvironment(call.Receiver, env)
+	x := call.Receiver
+	if iface, ok := receiver.(*InterfaceValue); ok {
+		if iface.Value == nil {
+			panic(&PanicException{Value: &StringValue{Value: nilDereference}})
+		}
+		receiver, x = iface.Value, nil
+	}
+	method, exists := env.GetMethod(strings.TrimPrefix(receiver.Type(), "*"), call.Function)
+	if !exists {
+		return receiver
+	}
+	receiver = methodReceiver(method, receiver, x, env)
+	if method.Receiver != nil && !ast.IsPointerType(ast.TypeName(method.Receiver.Type)) {
+		receiver = copyValue(receiver)
+	}
+	return receiver
+}
+
+// evalPanic evaluates the built-in panic(v)
+func evalPanic(node *ast.CallNode, env *Environment) {
+	var value Value = &NilValue{}
+	if len(node.Arguments) > 0 {
+		value = EvalValueWithEnvironment(node.Arguments[0], env)
+	}
+	panic(&PanicException{Value: value})
+}
+
+// evalRecover evaluates the built-in recover(). Called by a deferred
+// function while its frame is panicking, it stops the panic and returns its
+// value as an interface{} value; it returns nil otherwise.
+func evalRecover(env *Environment) Value {
+	if env.frame == nil || env.frame.deferredBy == nil || env.frame.deferredBy.panic == nil {
+		return &InterfaceValue{Interface: "interface{}"}
+	}
+	panicking := env.frame.deferredBy
+	value := panicking.panic.Value
+	panicking.panic = nil
+	return convertValue(value, "interface{}", env)
+}
@@ -0,0 +1,215 @@
+package eval
+
+import (
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/parser"
+	"github.com/yuya-takeyama/petitgo/scanner"
+)
+
+func TestDefer_Order(t *testing.T) {
+	env := evalProgram(t, `type Counter struct {
+	n int
+}
+
+func (c *Counter) Inc() {
+	c.n++
+}
+
+func order() (log []int) {
+	for i := 0; i < 3; i++ {
+		defer func(n int) {
+			log = append(log, n)
+		}(i)
+	}
+	log = append(log, 10)
+	return log
+}
+
+func doubled() (n int) {
+	defer func() {
+		n = n * 2
+	}()
+	return 21
+}
+
+func arguments() (result int) {
+	x := 1
+	record := func(v int) {
+		result = v
+	}
+	defer record(x)
+	record = nil
+	x = 2
+	return x
+}
+
+func counted() int {
+	c := Counter{}
+	func() {
+		defer c.Inc()
+		defer c.Inc()
+	}()
+	return c.n
+}
+
+log := order()
+trace := log[0]*1000 + log[1]*100 + log[2]*10 + log[3]
+n := doubled()
+argument := arguments()
+counter := counted()`)
+
+	expected := map[string]string{
+		"trace":    "10210",
+		"n":        "42",
+		"argument": "1",
+		"counter":  "2",
+	}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("%s not set", name)
+		}
+		if value.String() != want {
+			t.Errorf("%s: expected %s, got %s", name, want, value.String())
+		}
+	}
+}
+
+func TestDefer_Recover(t *testing.T) {
+	env := evalProgram(t, `func divide(a int, b int) (q int, err string) {
+	defer func() {
+		r := recover()
+		if r != nil {
+			err = "recovered: " + r.(string)
+		}
+	}()
+	return a / divisor(b), ""
+}
+
+func divisor(b int) int {
+	if b == 0 {
+		panic("division by zero")
+	}
+	return b
+}
+
+func nilDeref() (msg string) {
+	defer func() {
+		msg = recover().(string)
+	}()
+	var p *int
+	*p = 1
+	return "unreachable"
+}
+
+func unrecovered() int {
+	defer func() {
+		helper()
+	}()
+	defer recover()
+	panic("not recovered")
+}
+
+func helper() {
+	recover()
+}
+
+func outer() (msg string) {
+	defer func() {
+		msg = recover().(string)
+	}()
+	unrecovered()
+	return "unreachable"
+}
+
+func zero() int {
+	defer func() {
+		recover()
+	}()
+	panic(1)
+}
+
+func asserted() (n int) {
+	defer func() {
+		r := recover()
+		n = r.(int)
+	}()
+	panic(41)
+}
+
+func replaced() (msg string) {
+	defer func() {
+		r := recover()
+		msg = r.(string)
+	}()
+	defer func() {
+		panic("second")
+	}()
+	panic("first")
+}
+
+q, err := divide(7, 0)
+q2, err2 := divide(7, 2)
+msg := nilDeref()
+msg2 := outer()
+z := zero()
+n := asserted()
+second := replaced()
+none := recover()`)
+
+	expected := map[string]string{
+		"q":      "0",
+		"err":    "recovered: division by zero",
+		"q2":     "3",
+		"err2":   "",
+		"msg":    nilDereference,
+		"msg2":   "not recovered",
+		"z":      "0",
+		"n":      "41",
+		"second": "second",
+		"none":   "<nil>",
+	}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("%s not set", name)
+		}
+		if value.String() != want {
+			t.Errorf("%s: expected %q, got %q", name, want, value.String())
+		}
+	}
+}
+
+func TestDefer_Panic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"panic(\"boom\")", "boom"},
+		{"func f() {\n\tdefer println(\"cleanup\")\n\tpanic(42)\n}\nf()", "42"},
+		// a panic in a deferred call replaces the panic being recovered from
+		{"func f() {\n\tdefer func() {\n\t\tpanic(\"second\")\n\t}()\n\tpanic(\"first\")\n}\nf()", "second"},
+	}
+
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				exception, ok := recover().(*PanicException)
+				if !ok {
+					t.Errorf("%q: expected a *PanicException", tt.input)
+					return
+				}
+				if value := exception.Value.String(); value != tt.expected {
+					t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, value)
+				}
+			}()
+
+			p := parser.NewParser(scanner.NewScanner(tt.input))
+			env := NewEnvironment()
+			for stmt := p.ParseStatement(); stmt != nil; stmt = p.ParseStatement() {
+				EvalStatement(stmt, env)
+			}
+		}()
+	}
+}
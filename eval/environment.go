@@ -2,40 +2,100 @@ package eval
 
 import "github.com/yuya-takeyama/petitgo/ast"
 
-// Function represents a user-defined function
+// Function represents a user-defined function or method
 type Function struct {
 	Name       string
+	Receiver   *ast.Parameter // nil for functions
 	Parameters []ast.Parameter
-	ReturnType string
+	Results    []ast.Parameter
 	Body       *ast.BlockStatement
+	Env        *Environment // environment the function was declared or the literal created in, whose variables it shares
+}
+
+// Signature returns the function type of a function, such as func(int) int
+func (f *Function) Signature() string {
+	return ast.FuncTypeName(ast.ParameterTypes(f.Parameters), ast.ParameterTypes(f.Results))
 }
 
 // Environment は変数と関数を管理する
 type Environment struct {
-	variables map[string]Value
-	functions map[string]*Function
-	structs   map[string]*ast.StructDefinition
-	pkg       string   // current package name
-	imports   []string // imported packages
+	variables  map[string]Value
+	functions  map[string]*Function
+	methods    map[string]map[string]*Function // type name -> method name -> method
+	structs    map[string]*ast.StructDefinition
+	interfaces map[string]*ast.InterfaceStatement
+	pkg        string   // current package name
+	imports    []string // imported packages
+
+	// outer is the environment a closure was created in, whose variables
+	// the closure shares; nil otherwise
+	outer *Environment
+
+	// frame is the call of the function being evaluated; nil outside
+	// functions
+	frame *frame
+
+	// init tracks the package-level variables while a program is evaluated
+	init *initialization
 }
 
 func NewEnvironment() *Environment {
 	return &Environment{
-		variables: make(map[string]Value),
-		functions: make(map[string]*Function),
-		structs:   make(map[string]*ast.StructDefinition),
-		pkg:       "main", // default package
-		imports:   make([]string, 0),
+		variables:  make(map[string]Value),
+		functions:  make(map[string]*Function),
+		methods:    make(map[string]map[string]*Function),
+		structs:    make(map[string]*ast.StructDefinition),
+		interfaces: make(map[string]*ast.InterfaceStatement),
+		pkg:        "main", // default package
+		imports:    make([]string, 0),
 	}
 }
 
+// enclosed creates the scope of a block inside env: variables declared in it
+// are local to the block, while other names resolve in env
+func (env *Environment) enclosed() *Environment {
+	inner := NewEnvironment()
+	inner.functions = env.functions
+	inner.methods = env.methods
+	inner.structs = env.structs
+	inner.interfaces = env.interfaces
+	inner.pkg = env.pkg
+	inner.imports = env.imports
+	inner.outer = env
+	inner.frame = env.frame
+	return inner
+}
+
+// Set assigns to a variable, in the outer environment of a closure when the
+// variable was captured from there. An unknown variable is declared here.
 func (env *Environment) Set(name string, value Value) {
+	for e := env; e != nil; e = e.outer {
+		if _, exists := e.variables[name]; exists || e.initializePending(name) {
+			e.variables[name] = value
+			return
+		}
+	}
 	env.variables[name] = value
 }
 
+// Define declares a variable in this environment, shadowing any variable of
+// the same name captured by a closure
+func (env *Environment) Define(name string, value Value) {
+	env.variables[name] = value
+}
+
+// Get looks a variable up. A package-level variable referred to before its
+// declaration is initialized first.
 func (env *Environment) Get(name string) (Value, bool) {
-	value, exists := env.variables[name]
-	return value, exists
+	for e := env; e != nil; e = e.outer {
+		if value, exists := e.variables[name]; exists {
+			return value, true
+		}
+		if e.initializePending(name) {
+			return e.variables[name], true
+		}
+	}
+	return nil, false
 }
 
 // SetInt is a helper function for backward compatibility
@@ -45,7 +105,7 @@ func (env *Environment) SetInt(name string, value int) {
 
 // GetInt is a helper function for backward compatibility
 func (env *Environment) GetInt(name string) (int, bool) {
-	if value, exists := env.variables[name]; exists {
+	if value, exists := env.Get(name); exists {
 		if intVal, ok := value.(*IntValue); ok {
 			return intVal.Value, true
 		}
@@ -62,6 +122,20 @@ func (env *Environment) GetFunction(name string) (*Function, bool) {
 	return function, exists
 }
 
+// SetMethod adds a method to the method table of a type
+func (env *Environment) SetMethod(typeName string, method *Function) {
+	if env.methods[typeName] == nil {
+		env.methods[typeName] = make(map[string]*Function)
+	}
+	env.methods[typeName][method.Name] = method
+}
+
+// GetMethod looks up a method in the method table of a type
+func (env *Environment) GetMethod(typeName, name string) (*Function, bool) {
+	method, exists := env.methods[typeName][name]
+	return method, exists
+}
+
 func (env *Environment) SetStruct(name string, definition *ast.StructDefinition) {
 	env.structs[name] = definition
 }
@@ -71,6 +145,17 @@ func (env *Environment) GetStruct(name string) (*ast.StructDefinition, bool) {
 	return definition, exists
 }
 
+// SetInterface registers an interface type
+func (env *Environment) SetInterface(name string, definition *ast.InterfaceStatement) {
+	env.interfaces[name] = definition
+}
+
+// GetInterface looks up an interface type
+func (env *Environment) GetInterface(name string) (*ast.InterfaceStatement, bool) {
+	definition, exists := env.interfaces[name]
+	return definition, exists
+}
+
 // SetPackage sets the current package name
 func (env *Environment) SetPackage(name string) {
 	env.pkg = name
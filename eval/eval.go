@@ -2,19 +2,29 @@ package eval
 
 import (
 	"os"
+	"strings"
 
 	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/constant"
 	"github.com/yuya-takeyama/petitgo/token"
 )
 
-// ControlFlowException for break and continue
+// ControlFlowException for break, continue and goto
 type ControlFlowException struct {
-	Type string // "break" or "continue"
+	Type  string // "break", "continue" or "goto"
+	Label string // the label of the statement to branch to; "" for the innermost one
+}
+
+// PanicException is raised by run-time errors such as dereferencing a nil
+// pointer, and unwinds the evaluation like a panic in Go
+type PanicException struct {
+	Value Value // the value the program panicked with
 }
 
 // ReturnException for return statements
 type ReturnException struct {
-	Value int
+	Value  int   // integer result, kept for the int-based evaluator
+	Result Value // typed result, a *TupleValue for multiple results and nil for a bare return
 }
 
 // printInt converts an integer to string and outputs it (without fmt package)
@@ -59,17 +69,39 @@ func EvalValueWithEnvironment(node ast.ASTNode, env *Environment) Value {
 	switch n := node.(type) {
 	case *ast.NumberNode:
 		return &IntValue{Value: n.Value}
+	case *ast.FloatNode:
+		return &FloatValue{Value: n.Value}
+	case *ast.RuneNode:
+		return &RuneValue{Value: n.Value}
 	case *ast.BooleanNode:
 		return &BoolValue{Value: n.Value}
 	case *ast.StringNode:
 		return &StringValue{Value: n.Value}
+	case *ast.ConstExpr:
+		return constantValue(n.Value)
 	case *ast.VariableNode:
 		if value, exists := env.Get(n.Name); exists {
 			return value
 		}
+		if n.Name == "nil" {
+			return &NilValue{}
+		}
+		// A declared function used as a value
+		if function, exists := env.GetFunction(n.Name); exists {
+			return &FunctionValue{Signature: function.Signature(), Function: function}
+		}
 		return &IntValue{Value: 0}
 	case *ast.BinaryOpNode:
 		return evalBinaryOpWithTypes(n, env)
+	case *ast.UnaryOpNode:
+		if n.Operator == token.AND {
+			return evalAddress(n.Operand, env)
+		}
+		return evalUnaryOp(n, env)
+	case *ast.DerefNode:
+		return evalDeref(n, env)
+	case *ast.NewNode:
+		return newPointer(ast.TypeName(n.Type), zeroValueOf(ast.TypeName(n.Type), env))
 	case *ast.CallNode:
 		return evalCallWithTypes(n, env)
 	case *ast.StructLiteral:
@@ -78,8 +110,19 @@ func EvalValueWithEnvironment(node ast.ASTNode, env *Environment) Value {
 		return evalFieldAccess(n, env)
 	case *ast.SliceLiteral:
 		return evalSliceLiteral(n, env)
+	case *ast.ArrayLiteral:
+		return evalArrayLiteral(n, env)
+	case *ast.MapLiteral:
+		return evalMapLiteral(n, env)
 	case *ast.IndexAccess:
 		return evalIndexAccess(n, env)
+	case *ast.SliceExpr:
+		return evalSliceExpr(n, env)
+	case *ast.TypeAssertNode:
+		value, _ := evalTypeAssert(n, env, false)
+		return value
+	case *ast.FuncLiteral:
+		return evalFuncLiteral(n, env)
 	}
 
 	// Default case: convert old evaluation to IntValue
@@ -90,23 +133,73 @@ func EvalValueWithEnvironment(node ast.ASTNode, env *Environment) Value {
 // evalBinaryOpWithTypes evaluates binary operations with proper type checking
 func evalBinaryOpWithTypes(node *ast.BinaryOpNode, env *Environment) Value {
 	left := EvalValueWithEnvironment(node.Left, env)
+
+	// && and || evaluate the right operand only when the left one does not
+	// decide the result
+	switch node.Operator {
+	case token.LAND:
+		if !left.IsTruthy() {
+			return &BoolValue{Value: false}
+		}
+		return &BoolValue{Value: EvalValueWithEnvironment(node.Right, env).IsTruthy()}
+	case token.LOR:
+		if left.IsTruthy() {
+			return &BoolValue{Value: true}
+		}
+		return &BoolValue{Value: EvalValueWithEnvironment(node.Right, env).IsTruthy()}
+	}
+
 	right := EvalValueWithEnvironment(node.Right, env)
+	return binaryOp(left, right, node.Operator)
+}
+
+// evalUnaryOp evaluates -x, +x, !x and ^x
+func evalUnaryOp(node *ast.UnaryOpNode, env *Environment) Value {
+	operand := EvalValueWithEnvironment(node.Operand, env)
 
-	// Type checking and operation dispatch
 	switch node.Operator {
+	case token.NOT:
+		return &BoolValue{Value: !operand.IsTruthy()}
+	case token.ADD:
+		return operand
+	case token.SUB:
+		if floatVal, ok := operand.(*FloatValue); ok {
+			return &FloatValue{Value: -floatVal.Value}
+		}
+		// rune and byte negation wraps around
+		if value, ok := toInt(operand); ok {
+			return newInteger(operand.Type(), -value)
+		}
+	case token.XOR:
+		// bitwise complement
+		if value, ok := toInt(operand); ok {
+			return newInteger(operand.Type(), ^value)
+		}
+	}
+
+	// Type error: return 0 for now
+	return &IntValue{Value: 0}
+}
+
+// binaryOp applies a binary operator to two values
+func binaryOp(left, right Value, op token.Token) Value {
+	// Type checking and operation dispatch
+	switch op {
 	case token.ADD:
 		return addValues(left, right)
-	case token.SUB, token.MUL, token.QUO:
-		return arithmeticOp(left, right, node.Operator)
+	case token.SUB, token.MUL, token.QUO, token.REM, token.AND, token.OR, token.XOR, token.AND_NOT:
+		return arithmeticOp(left, right, op)
+	case token.SHL, token.SHR:
+		return shiftOp(left, right, op)
 	case token.EQL, token.NEQ, token.LSS, token.GTR, token.LEQ, token.GEQ:
-		return compareValues(left, right, node.Operator)
+		return compareValues(left, right, op)
 	}
 
 	// Default: return false for unsupported operations
 	return &BoolValue{Value: false}
 }
 
-// addValues handles addition with type checking (int + int, string + string)
+// addValues handles addition with type checking (int + int, float64 + float64, string + string)
 func addValues(left, right Value) Value {
 	leftInt, leftIsInt := left.(*IntValue)
 	rightInt, rightIsInt := right.(*IntValue)
@@ -116,6 +209,16 @@ func addValues(left, right Value) Value {
 		return &IntValue{Value: leftInt.Value + rightInt.Value}
 	}
 
+	// float64 + float64 (an int operand is converted like an untyped constant)
+	if leftFloat, rightFloat, ok := floatOperands(left, right); ok {
+		return &FloatValue{Value: leftFloat + rightFloat}
+	}
+
+	// rune + rune, byte + byte (an int operand is converted like an untyped constant)
+	if leftInt, rightInt, typeName, ok := sizedOperands(left, right); ok {
+		return newInteger(typeName, leftInt+rightInt)
+	}
+
 	leftStr, leftIsStr := left.(*StringValue)
 	rightStr, rightIsStr := right.(*StringValue)
 
@@ -128,8 +231,24 @@ func addValues(left, right Value) Value {
 	return &StringValue{Value: left.String() + right.String()}
 }
 
-// arithmeticOp handles arithmetic operations (only for int types)
+// Messages of the panics raised by integer operations
+const (
+	divideByZero  = "runtime error: integer divide by zero"
+	negativeShift = "runtime error: negative shift amount"
+)
+
+// arithmeticOp handles arithmetic operations (int, rune, byte and float64 types)
 func arithmeticOp(left, right Value, op token.Token) Value {
+	if leftFloat, rightFloat, ok := floatOperands(left, right); ok {
+		return floatArithmeticOp(leftFloat, rightFloat, op)
+	}
+
+	// rune and byte operations are computed as int and wrapped around
+	if leftInt, rightInt, typeName, ok := sizedOperands(left, right); ok {
+		result := arithmeticOp(&IntValue{Value: leftInt}, &IntValue{Value: rightInt}, op)
+		return newInteger(typeName, result.(*IntValue).Value)
+	}
+
 	leftInt, leftIsInt := left.(*IntValue)
 	rightInt, rightIsInt := right.(*IntValue)
 
@@ -145,18 +264,149 @@ func arithmeticOp(left, right Value, op token.Token) Value {
 		return &IntValue{Value: leftInt.Value * rightInt.Value}
 	case token.QUO:
 		if rightInt.Value == 0 {
-			// Division by zero: return 0 for now
-			return &IntValue{Value: 0}
+			panic(&PanicException{Value: &StringValue{Value: divideByZero}})
 		}
 		return &IntValue{Value: leftInt.Value / rightInt.Value}
+	case token.REM:
+		if rightInt.Value == 0 {
+			panic(&PanicException{Value: &StringValue{Value: divideByZero}})
+		}
+		return &IntValue{Value: leftInt.Value % rightInt.Value}
+	case token.AND:
+		return &IntValue{Value: leftInt.Value & rightInt.Value}
+	case token.OR:
+		return &IntValue{Value: leftInt.Value | rightInt.Value}
+	case token.XOR:
+		return &IntValue{Value: leftInt.Value ^ rightInt.Value}
+	case token.AND_NOT:
+		return &IntValue{Value: leftInt.Value &^ rightInt.Value}
 	}
 
 	return &IntValue{Value: 0}
 }
 
+// shiftOp handles << and >>. The result has the type of the left operand;
+// a shift count of the operand size or more shifts out all bits, and a
+// negative count panics.
+func shiftOp(left, right Value, op token.Token) Value {
+	value, leftOk := toInt(left)
+	count, rightOk := toInt(right)
+	if !leftOk || !rightOk {
+		// Type error: return 0 for now
+		return &IntValue{Value: 0}
+	}
+	if count < 0 {
+		panic(&PanicException{Value: &StringValue{Value: negativeShift}})
+	}
+
+	result := value >> count
+	if op == token.SHL {
+		result = value << count
+	}
+	return newInteger(left.Type(), result)
+}
+
+// floatOperands returns both operands as float64 if at least one of them is
+// a float64 and the other one is numeric
+func floatOperands(left, right Value) (float64, float64, bool) {
+	_, leftIsFloat := left.(*FloatValue)
+	_, rightIsFloat := right.(*FloatValue)
+	if !leftIsFloat && !rightIsFloat {
+		return 0, 0, false
+	}
+	leftFloat, leftOk := toFloat(left)
+	rightFloat, rightOk := toFloat(right)
+	return leftFloat, rightFloat, leftOk && rightOk
+}
+
+// toFloat converts a numeric value to float64
+func toFloat(value Value) (float64, bool) {
+	switch v := value.(type) {
+	case *FloatValue:
+		return v.Value, true
+	case *IntValue:
+		return float64(v.Value), true
+	case *RuneValue:
+		return float64(v.Value), true
+	case *ByteValue:
+		return float64(v.Value), true
+	}
+	return 0, false
+}
+
+// sizedOperands returns both operands as int together with the result type
+// if at least one of them is a sized integer (rune or byte) and the other one
+// is an integer. An int operand acts like an untyped constant; so does a rune
+// mixed with a byte, as in s[i] - '0'.
+func sizedOperands(left, right Value) (int, int, string, bool) {
+	leftInt, leftOk := toInt(left)
+	rightInt, rightOk := toInt(right)
+	if !leftOk || !rightOk {
+		return 0, 0, "", false
+	}
+
+	_, leftIsByte := left.(*ByteValue)
+	_, rightIsByte := right.(*ByteValue)
+	if leftIsByte || rightIsByte {
+		return leftInt, rightInt, "uint8", true
+	}
+
+	_, leftIsRune := left.(*RuneValue)
+	_, rightIsRune := right.(*RuneValue)
+	if leftIsRune || rightIsRune {
+		return leftInt, rightInt, "int32", true
+	}
+	return 0, 0, "", false
+}
+
+// toInt converts an integer value (int, rune or byte) to int
+func toInt(value Value) (int, bool) {
+	switch v := value.(type) {
+	case *IntValue:
+		return v.Value, true
+	case *RuneValue:
+		return int(v.Value), true
+	case *ByteValue:
+		return int(v.Value), true
+	}
+	return 0, false
+}
+
+// floatArithmeticOp handles arithmetic operations on float64 operands.
+// Division by zero follows IEEE 754 and yields ±Inf or NaN.
+func floatArithmeticOp(left, right float64, op token.Token) Value {
+	switch op {
+	case token.ADD:
+		return &FloatValue{Value: left + right}
+	case token.SUB:
+		return &FloatValue{Value: left - right}
+	case token.MUL:
+		return &FloatValue{Value: left * right}
+	case token.QUO:
+		return &FloatValue{Value: left / right}
+	}
+
+	return &FloatValue{Value: 0}
+}
+
 // compareValues handles comparison operations
 func compareValues(left, right Value, op token.Token) Value {
-	// For now, only support int comparisons
+	if leftFloat, rightFloat, ok := floatOperands(left, right); ok {
+		return compareFloats(leftFloat, rightFloat, op)
+	}
+
+	// Values of different types are never equal
+	switch op {
+	case token.EQL:
+		return &BoolValue{Value: valuesEqual(left, right)}
+	case token.NEQ:
+		return &BoolValue{Value: !valuesEqual(left, right)}
+	}
+
+	if leftInt, rightInt, _, ok := sizedOperands(left, right); ok {
+		return compareInts(leftInt, rightInt, op)
+	}
+
 	leftInt, leftIsInt := left.(*IntValue)
 	rightInt, rightIsInt := right.(*IntValue)
 
@@ -170,6 +420,95 @@ func compareValues(left, right Value, op token.Token) Value {
 	return compareInts(leftInt.Value, rightInt.Value, op)
 }
 
+// valuesEqual reports whether two values are equal as Go's == defines it.
+// Values of different types are not equal, except that an int operand is
+// converted like an untyped constant.
+func valuesEqual(left, right Value) bool {
+	// Interface values compare their dynamic values
+	left, right = dynamicValue(left), dynamicValue(right)
+
+	if leftFloat, rightFloat, ok := floatOperands(left, right); ok {
+		return leftFloat == rightFloat
+	}
+	if leftInt, rightInt, _, ok := sizedOperands(left, right); ok {
+		return leftInt == rightInt
+	}
+
+	switch l := left.(type) {
+	case *IntValue:
+		if r, ok := right.(*IntValue); ok {
+			return l.Value == r.Value
+		}
+	case *StringValue:
+		if r, ok := right.(*StringValue); ok {
+			return l.Value == r.Value
+		}
+	case *BoolValue:
+		if r, ok := right.(*BoolValue); ok {
+			return l.Value == r.Value
+		}
+	case *NilValue:
+		switch r := right.(type) {
+		case *FunctionValue:
+			return r.Function == nil
+		case *MapValue:
+			return r.IsNil()
+		case *SliceValue:
+			return r.IsNil()
+		case *PointerValue:
+			return r.IsNil()
+		}
+		_, ok := right.(*NilValue)
+		return ok
+	case *FunctionValue:
+		// Function values can only be compared with nil
+		_, ok := right.(*NilValue)
+		return ok && l.Function == nil
+	case *MapValue:
+		// Maps can only be compared with nil
+		_, ok := right.(*NilValue)
+		return ok && l.IsNil()
+	case *SliceValue:
+		// Slices can only be compared with nil
+		_, ok := right.(*NilValue)
+		return ok && l.IsNil()
+	case *PointerValue:
+		// Pointers are equal if they point to the same location
+		switch r := right.(type) {
+		case *NilValue:
+			return l.IsNil()
+		case *PointerValue:
+			return l.target == r.target
+		}
+		return false
+	case *StructValue:
+		// Structs are equal if all their fields are equal
+		r, ok := right.(*StructValue)
+		if !ok || l.TypeName != r.TypeName || len(l.Fields) != len(r.Fields) {
+			return false
+		}
+		for name, value := range l.Fields {
+			if other, exists := r.Fields[name]; !exists || !valuesEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case *ArrayValue:
+		// Arrays are equal if all their elements are equal
+		r, ok := right.(*ArrayValue)
+		if !ok || l.Type() != r.Type() {
+			return false
+		}
+		for i, value := range l.Elements {
+			if !valuesEqual(value, r.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
 // compareInts compares two integers
 func compareInts(left, right int, op token.Token) Value {
 	switch op {
@@ -189,6 +528,25 @@ func compareInts(left, right int, op token.Token) Value {
 	return &BoolValue{Value: false}
 }
 
+// compareFloats compares two float64 values
+func compareFloats(left, right float64, op token.Token) Value {
+	switch op {
+	case token.EQL:
+		return &BoolValue{Value: left == right}
+	case token.NEQ:
+		return &BoolValue{Value: left != right}
+	case token.LSS:
+		return &BoolValue{Value: left < right}
+	case token.GTR:
+		return &BoolValue{Value: left > right}
+	case token.LEQ:
+		return &BoolValue{Value: left <= right}
+	case token.GEQ:
+		return &BoolValue{Value: left >= right}
+	}
+	return &BoolValue{Value: false}
+}
+
 // compareStrings compares two strings
 func compareStrings(left, right string, op token.Token) Value {
 	switch op {
@@ -210,6 +568,52 @@ func compareStrings(left, right string, op token.Token) Value {
 
 // evalCallWithTypes evaluates function calls with proper type system
 func evalCallWithTypes(node *ast.CallNode, env *Environment) Value {
+	// Method call: the method is looked up in the method table of the
+	// receiver's type
+	if node.Receiver != nil {
+		receiver := EvalValueWithEnvironment(node.Receiver, env)
+		x := node.Receiver
+		if iface, ok := receiver.(*InterfaceValue); ok {
+			// Dynamic dispatch on the value stored in the interface
+			if iface.Value == nil {
+				panic(&PanicException{Value: &StringValue{Value: nilDereference}})
+			}
+			receiver = iface.Value
+			x = nil
+		}
+		// The methods of T can be called on a *T and the other way round
+		typeName := strings.TrimPrefix(receiver.Type(), "*")
+		if method, exists := env.GetMethod(typeName, node.Function); exists {
+			return callUserFunction(method, methodReceiver(method, receiver, x, env), node.Arguments, env)
+		}
+		// Without a method, x.f(...) calls the function held by the field f
+		if object, ok := derefValue(receiver).(*StructValue); ok {
+			if field, ok := object.Fields[node.Function].(*FunctionValue); ok {
+				return callFunctionValue(field, node.Arguments, env)
+			}
+		}
+		panic(&PanicException{Value: &StringValue{Value: selectorName(node) + " undefined (type " + typeName + " has no field or method " + node.Function + ")"}})
+	}
+
+	// Call of a function value: f(1)(2), func() { ... }() or a variable
+	// holding a function, which shadows a function of the same name
+	if node.Callee != nil {
+		return callFunctionValue(EvalValueWithEnvironment(node.Callee, env), node.Arguments, env)
+	}
+	if value, exists := env.Get(node.Function); exists {
+		if _, ok := value.(*FunctionValue); ok {
+			return callFunctionValue(value, node.Arguments, env)
+		}
+	}
+
+	// Built-in functions: panic and recover
+	if node.Function == "panic" {
+		evalPanic(node, env)
+	}
+	if node.Function == "recover" && len(node.Arguments) == 0 {
+		return evalRecover(env)
+	}
+
 	// Built-in function: print
 	if node.Function == "print" && len(node.Arguments) > 0 {
 		value := EvalValueWithEnvironment(node.Arguments[0], env)
@@ -224,22 +628,41 @@ func evalCallWithTypes(node *ast.CallNode, env *Environment) Value {
 		switch v := value.(type) {
 		case *SliceValue:
 			return &IntValue{Value: len(v.Elements)}
+		case *ArrayValue:
+			return &IntValue{Value: len(v.Elements)}
 		case *StringValue:
 			return &IntValue{Value: len(v.Value)}
+		case *MapValue:
+			return &IntValue{Value: v.Len()}
 		default:
 			return &IntValue{Value: 0}
 		}
 	}
 
+	// Built-in function: cap
+	if node.Function == "cap" && len(node.Arguments) == 1 {
+		if elements, _, ok := elementsOf(EvalValueWithEnvironment(node.Arguments[0], env)); ok {
+			return &IntValue{Value: cap(elements)}
+		}
+		return &IntValue{Value: 0}
+	}
+
+	// Built-in function: delete
+	if node.Function == "delete" && len(node.Arguments) == 2 {
+		if m, ok := EvalValueWithEnvironment(node.Arguments[0], env).(*MapValue); ok {
+			m.Delete(convertValue(EvalValueWithEnvironment(node.Arguments[1], env), m.KeyType, env))
+		}
+		return &IntValue{Value: 0}
+	}
+
 	// Built-in function: append
 	if node.Function == "append" && len(node.Arguments) >= 2 {
 		sliceVal := EvalValueWithEnvironment(node.Arguments[0], env)
 		if slice, ok := sliceVal.(*SliceValue); ok {
-			// Create new slice with appended elements
-			newElements := make([]Value, len(slice.Elements))
-			copy(newElements, slice.Elements)
-
-			// Append all remaining arguments
+			// Like Go, append stores into the spare capacity of the slice
+			// when there is enough, sharing it with other slices of the
+			// same elements, and copies the elements otherwise
+			newElements := slice.Elements
 			for i := 1; i < len(node.Arguments); i++ {
 				elem := EvalValueWithEnvironment(node.Arguments[i], env)
 				newElements = append(newElements, elem)
@@ -253,19 +676,59 @@ func evalCallWithTypes(node *ast.CallNode, env *Environment) Value {
 		return sliceVal // Return original if not a slice
 	}
 
-	// User-defined function: for now, fallback to old implementation
+	// Built-in conversions: float64(x), int(x), rune(x), byte(x), string(r)
+	if node.Function == "float64" && len(node.Arguments) == 1 {
+		value := EvalValueWithEnvironment(node.Arguments[0], env)
+		if f, ok := toFloat(value); ok {
+			return &FloatValue{Value: f}
+		}
+		return &FloatValue{Value: 0}
+	}
+	if isIntegerType(node.Function) && len(node.Arguments) == 1 {
+		value := EvalValueWithEnvironment(node.Arguments[0], env)
+		n, ok := toInt(value)
+		if f, isFloat := value.(*FloatValue); isFloat {
+			n, ok = int(f.Value), true
+		}
+		if !ok {
+			n = 0
+		}
+		return newInteger(constant.CanonicalType(node.Function), n)
+	}
+	if node.Function == "string" && len(node.Arguments) == 1 {
+		value := EvalValueWithEnvironment(node.Arguments[0], env)
+		if n, ok := toInt(value); ok {
+			return &StringValue{Value: string(rune(n))}
+		}
+		if str, ok := value.(*StringValue); ok {
+			return str
+		}
+		return &StringValue{Value: ""}
+	}
+
+	// User-defined function
 	if function, exists := env.GetFunction(node.Function); exists {
-		result := callUserFunction(function, node.Arguments, env)
-		return &IntValue{Value: result}
+		return callUserFunction(function, nil, node.Arguments, env)
 	}
 
 	return &IntValue{Value: 0}
 }
 
+// selectorName returns x.f for a method call x.f(...) on a variable x, and
+// f otherwise
+func selectorName(node *ast.CallNode) string {
+	if v, ok := node.Receiver.(*ast.VariableNode); ok {
+		return v.Name + "." + node.Function
+	}
+	return node.Function
+}
+
 func EvalWithEnvironment(node ast.ASTNode, env *Environment) int {
 	switch n := node.(type) {
 	case *ast.NumberNode:
 		return n.Value
+	case *ast.RuneNode:
+		return int(n.Value)
 	case *ast.BooleanNode:
 		if n.Value {
 			return 1 // true = 1
@@ -275,6 +738,12 @@ func EvalWithEnvironment(node ast.ASTNode, env *Environment) int {
 		// For now, return string length as evaluation result
 		// In the future, we'll need proper type system
 		return len(n.Value)
+	case *ast.ConstExpr:
+		if n.Value.Kind() == constant.Bool {
+			return EvalWithEnvironment(&ast.BooleanNode{Value: n.Value.BoolVal()}, env)
+		}
+		value, _ := toInt(constantValue(n.Value))
+		return value
 	case *ast.VariableNode:
 		if value, exists := env.GetInt(n.Name); exists {
 			return value
@@ -332,9 +801,21 @@ func EvalWithEnvironment(node ast.ASTNode, env *Environment) int {
 			return value
 		}
 
-		// User-defined function
-		if function, exists := env.GetFunction(n.Function); exists {
-			return callUserFunction(function, n.Arguments, env)
+		// Method calls and calls of function values
+		if n.Receiver != nil || n.Callee != nil {
+			if intVal, ok := evalCallWithTypes(n, env).(*IntValue); ok {
+				return intVal.Value
+			}
+			return 0
+		}
+
+		// User-defined function or a variable holding a function
+		_, isVariable := env.Get(n.Function)
+		if _, exists := env.GetFunction(n.Function); exists || isVariable {
+			if intVal, ok := evalCallWithTypes(n, env).(*IntValue); ok {
+				return intVal.Value
+			}
+			return 0
 		}
 	}
 
@@ -345,125 +826,54 @@ func EvalWithEnvironment(node ast.ASTNode, env *Environment) int {
 func EvalStatement(stmt ast.Statement, env *Environment) {
 	switch s := stmt.(type) {
 	case *ast.VarStatement:
+		// A variable without a value starts as the zero value of its type
+		if s.Value == nil {
+			env.Define(s.Name, zeroValueOf(ast.TypeName(s.Type), env))
+			break
+		}
+
 		// Use type-aware evaluation
 		value := EvalValueWithEnvironment(s.Value, env)
 
 		// Type checking: verify that the value matches the declared type
-		expectedType := s.TypeName
-		actualType := value.Type()
-
-		if expectedType != actualType {
-			// Type mismatch - for now, we'll create a zero value of the expected type
-			// In a more sophisticated implementation, this would be a compile-time error
-			switch expectedType {
-			case "int":
-				value = &IntValue{Value: 0}
-			case "string":
-				value = &StringValue{Value: ""}
-			case "bool":
-				value = &BoolValue{Value: false}
-			default:
-				// Unknown type, keep original value
-			}
+		// Type mismatch - for now, we'll create a zero value of the expected type
+		// In a more sophisticated implementation, this would be a compile-time error
+		value = convertValue(value, ast.TypeName(s.Type), env)
+
+		env.Define(s.Name, copyValue(value))
+	case *ast.TupleVarStatement:
+		evalTupleVarStatement(s, env)
+	case *ast.DeclGroup:
+		for _, decl := range s.Decls {
+			EvalStatement(decl, env)
 		}
-
-		env.Set(s.Name, value)
 	case *ast.AssignStatement:
 		// Use type-aware evaluation with type inference
 		value := EvalValueWithEnvironment(s.Value, env)
 
-		// Check if variable already exists
-		if existingValue, exists := env.Get(s.Name); exists {
+		// Check if variable already exists in this scope; a variable captured
+		// by a closure is shadowed instead
+		if existingValue, exists := env.variables[s.Name]; exists {
 			// Variable exists - check type compatibility
-			existingType := existingValue.Type()
-			newType := value.Type()
-
-			if existingType != newType {
-				// Type mismatch - use zero value of existing type for type safety
-				// This maintains Go's type safety principles
-				switch existingType {
-				case "int":
-					value = &IntValue{Value: 0}
-				case "string":
-					value = &StringValue{Value: ""}
-				case "bool":
-					value = &BoolValue{Value: false}
-				default:
-					// Unknown type, keep new value (fallback)
-				}
-			}
+			// Type mismatch - use zero value of existing type for type safety
+			// This maintains Go's type safety principles
+			value = convertValue(value, existingValue.Type(), env)
 		}
 		// If variable doesn't exist, infer type from value (type inference)
 
-		env.Set(s.Name, value)
+		env.Define(s.Name, copyValue(value))
 	case *ast.ReassignStatement:
-		// Variable reassignment - must exist already
-		value := EvalValueWithEnvironment(s.Value, env)
-
-		// Check if variable exists
-		if existingValue, exists := env.Get(s.Name); exists {
-			// Variable exists - check type compatibility
-			existingType := existingValue.Type()
-			newType := value.Type()
-
-			if existingType != newType {
-				// Type mismatch - use zero value of existing type for type safety
-				switch existingType {
-				case "int":
-					value = &IntValue{Value: 0}
-				case "string":
-					value = &StringValue{Value: ""}
-				case "bool":
-					value = &BoolValue{Value: false}
-				default:
-					// Unknown type, keep new value (fallback)
-				}
-			}
-
-			env.Set(s.Name, value)
-		} else {
-			// Variable doesn't exist - this would be a compile error in real Go
-			// For now, we'll just ignore it
-		}
+		// The value is converted to the type of the variable, field or
+		// element assigned to
+		evalAssign(s, env)
+	case *ast.TupleAssignStatement:
+		evalTupleAssign(s, env)
 	case *ast.IncStatement:
-		// Increment statement - variable must exist
-		if existingValue, exists := env.Get(s.Name); exists {
-			if intVal, ok := existingValue.(*IntValue); ok {
-				env.Set(s.Name, &IntValue{Value: intVal.Value + 1})
-			}
-		}
+		evalOpAssign(s.Target, token.ADD, &ast.NumberNode{Value: 1}, env)
 	case *ast.DecStatement:
-		// Decrement statement - variable must exist
-		if existingValue, exists := env.Get(s.Name); exists {
-			if intVal, ok := existingValue.(*IntValue); ok {
-				env.Set(s.Name, &IntValue{Value: intVal.Value - 1})
-			}
-		}
+		evalOpAssign(s.Target, token.SUB, &ast.NumberNode{Value: 1}, env)
 	case *ast.CompoundAssignStatement:
-		// Compound assignment - variable must exist
-		if existingValue, exists := env.Get(s.Name); exists {
-			if intVal, ok := existingValue.(*IntValue); ok {
-				newValue := EvalValueWithEnvironment(s.Value, env)
-				if newIntVal, ok := newValue.(*IntValue); ok {
-					var result int
-					switch s.Operator {
-					case token.ADD_ASSIGN:
-						result = intVal.Value + newIntVal.Value
-					case token.SUB_ASSIGN:
-						result = intVal.Value - newIntVal.Value
-					case token.MUL_ASSIGN:
-						result = intVal.Value * newIntVal.Value
-					case token.QUO_ASSIGN:
-						if newIntVal.Value != 0 {
-							result = intVal.Value / newIntVal.Value
-						} else {
-							result = 0 // Handle division by zero
-						}
-					}
-					env.Set(s.Name, &IntValue{Value: result})
-				}
-			}
-		}
+		evalOpAssign(s.Target, s.Operator.BinaryOperator(), s.Value, env)
 	case *ast.ExpressionStatement:
 		// Use type-aware evaluation for expressions
 		EvalValueWithEnvironment(s.Expression, env)
@@ -476,78 +886,73 @@ func EvalStatement(stmt ast.Statement, env *Environment) {
 			EvalBlockStatement(s.ElseBlock, env)
 		}
 	case *ast.ForStatement:
-		// Execute init statement if present
-		if s.Init != nil {
-			EvalStatement(s.Init, env)
-		}
-
-		for {
-			// condition check with type-aware evaluation
-			if s.Condition != nil {
-				condition := EvalValueWithEnvironment(s.Condition, env)
-				if !condition.IsTruthy() {
-					break
-				}
-			}
-
-			// body execution
-			EvalBlockStatement(s.Body, env)
-
-			// execute update statement if present
-			if s.Update != nil {
-				EvalStatement(s.Update, env)
-			}
-		}
+		evalForStatement(s, "", env)
+	case *ast.ConstStatement:
+		evalConstStatement(s, env)
+	case *ast.RangeStatement:
+		evalRangeStatement(s, "", env)
 	case *ast.SwitchStatement:
-		// Evaluate the switch value
-		switchValue := EvalValueWithEnvironment(s.Value, env)
-
-		// Try to match each case
-		matched := false
-		for _, caseStmt := range s.Cases {
-			caseValue := EvalValueWithEnvironment(caseStmt.Value, env)
-			// Simple equality check - could be enhanced for type-aware comparison
-			if switchValue.String() == caseValue.String() {
-				EvalBlockStatement(caseStmt.Body, env)
-				matched = true
-				break // Go switch has implicit break
-			}
-		}
-
-		// If no case matched, execute default block
-		if !matched && s.Default != nil {
-			EvalBlockStatement(s.Default, env)
-		}
+		evalBreakable("", func() { evalSwitchStatement(s, env) })
+	case *ast.TypeSwitchStatement:
+		evalBreakable("", func() { evalTypeSwitchStatement(s, env) })
 	case *ast.BlockStatement:
 		EvalBlockStatement(s, env)
+	case *ast.LabeledStatement:
+		evalLabeledStatement(s, env)
 	case *ast.BreakStatement:
-		// TODO: implement proper break handling
+		panic(&ControlFlowException{Type: "break", Label: s.Label})
 	case *ast.ContinueStatement:
-		// TODO: implement proper continue handling
+		panic(&ControlFlowException{Type: "continue", Label: s.Label})
+	case *ast.GotoStatement:
+		panic(&ControlFlowException{Type: "goto", Label: s.Label})
 	case *ast.FuncStatement:
 		// Register function in environment
 		function := &Function{
 			Name:       s.Name,
+			Receiver:   s.Receiver,
 			Parameters: s.Parameters,
-			ReturnType: s.ReturnType,
+			Results:    s.Results,
 			Body:       s.Body,
+			Env:        env,
 		}
-		env.SetFunction(s.Name, function)
+		if s.Receiver != nil {
+			// Methods go to the method table of the receiver type
+			env.SetMethod(s.ReceiverType(), function)
+		} else {
+			env.SetFunction(s.Name, function)
+		}
+	case *ast.DeferStatement:
+		evalDeferStatement(s, env)
 	case *ast.ReturnStatement:
 		// Handle return statement with exception (type-aware)
-		var value Value = &IntValue{Value: 0}
-		if s.Value != nil {
-			value = EvalValueWithEnvironment(s.Value, env)
+		var value Value
+		switch len(s.Values) {
+		case 0:
+			// A bare return; the caller collects named results
+			panic(&ReturnException{Value: 0})
+		case 1:
+			value = EvalValueWithEnvironment(s.Values[0], env)
+		default:
+			value = &TupleValue{Values: evalValueList(s.Values, env)}
 		}
-		// For now, still use int for return values (backward compatibility)
+		// The int result is kept for the int-based evaluator
 		if intVal, ok := value.(*IntValue); ok {
-			panic(&ReturnException{Value: intVal.Value})
+			panic(&ReturnException{Value: intVal.Value, Result: value})
 		} else {
-			panic(&ReturnException{Value: 0})
+			panic(&ReturnException{Value: 0, Result: value})
 		}
 	case *ast.StructDefinition:
 		// Register struct definition in environment
 		env.SetStruct(s.Name, s)
+	case *ast.TypeStatement:
+		// type T struct { ... } registers the same definition
+		fields := make([]ast.StructField, len(s.Fields))
+		for i, field := range s.Fields {
+			fields[i] = ast.StructField{Name: field.Name, Type: ast.TypeName(field.Type), Pos: field.Pos}
+		}
+		env.SetStruct(s.Name, &ast.StructDefinition{Name: s.Name, Fields: fields, Pos: s.Pos})
+	case *ast.InterfaceStatement:
+		env.SetInterface(s.Name, s)
 	case *ast.PackageStatement:
 		// Handle package declaration
 		// For now, just store the package name in environment
@@ -559,16 +964,62 @@ func EvalStatement(stmt ast.Statement, env *Environment) {
 	}
 }
 
+// evalSwitchStatement runs the first clause whose case matches, or the default
+// clause if none does. Cases are tried in source order; a tagless switch
+// matches the first true case. fallthrough continues with the next clause.
+func evalSwitchStatement(s *ast.SwitchStatement, env *Environment) {
+	if s.Init != nil {
+		EvalStatement(s.Init, env)
+	}
+
+	var switchValue Value = &BoolValue{Value: true}
+	if s.Value != nil {
+		switchValue = EvalValueWithEnvironment(s.Value, env)
+	}
+
+	selected := -1
+	for i, clause := range s.Cases {
+		for _, value := range clause.Values {
+			if valuesEqual(switchValue, EvalValueWithEnvironment(value, env)) {
+				selected = i
+				break
+			}
+		}
+		if selected >= 0 {
+			break
+		}
+	}
+
+	// If no case matched, execute the default clause
+	if selected < 0 {
+		for i, clause := range s.Cases {
+			if clause.IsDefault() {
+				selected = i
+			}
+		}
+	}
+	if selected < 0 {
+		return
+	}
+
+	// Go switch has implicit break unless the clause ends with fallthrough
+	for i := selected; i < len(s.Cases); i++ {
+		EvalBlockStatement(s.Cases[i].Body, env)
+		if !s.Cases[i].Fallthrough() {
+			break
+		}
+	}
+}
+
 func EvalBlockStatement(block *ast.BlockStatement, env *Environment) {
 	if block != nil && block.Statements != nil {
-		for _, stmt := range block.Statements {
-			EvalStatement(stmt, env)
-		}
+		evalStatements(block.Statements, env)
 	}
 }
 
-// callUserFunction calls a user-defined function with arguments
-func callUserFunction(function *Function, args []ast.ASTNode, env *Environment) int {
+// callUserFunction calls a user-defined function with arguments. receiver
+// is the receiver of a method call and nil for function calls.
+func callUserFunction(function *Function, receiver Value, args []ast.ASTNode, env *Environment) Value {
 	// Create new scope for function execution
 	localEnv := NewEnvironment()
 
@@ -576,67 +1027,210 @@ func callUserFunction(function *Function, args []ast.ASTNode, env *Environment)
 	for name, fn := range env.functions {
 		localEnv.SetFunction(name, fn)
 	}
+	// Methods and struct types are shared with the caller
+	localEnv.methods = env.methods
+	localEnv.structs = env.structs
+	localEnv.interfaces = env.interfaces
+	// A closure shares the variables of the environment it was created in
+	localEnv.outer = function.Env
+	localEnv.frame = newFrame(env)
+
+	// Bind the receiver: a value receiver works on a copy, while a pointer
+	// receiver shares the caller's struct
+	if function.Receiver != nil && function.Receiver.Name != "" {
+		if !ast.IsPointerType(ast.TypeName(function.Receiver.Type)) {
+			receiver = copyValue(receiver)
+		}
+		localEnv.Define(function.Receiver.Name, receiver)
+	}
 
-	// Bind arguments to parameters (type-aware with type checking)
+	// Bind arguments to parameters (type-aware with type checking); the
+	// results of a sole call returning several results are the arguments
+	values := evalValueList(args, env)
 	for i, param := range function.Parameters {
-		if i < len(args) {
-			value := EvalValueWithEnvironment(args[i], env)
+		if i < len(values) {
+			value := values[i]
 
 			// Type checking: verify argument type matches parameter type
-			expectedType := param.Type
-			actualType := value.Type()
-
-			if expectedType != actualType {
-				// Type mismatch - create zero value of expected type
-				switch expectedType {
-				case "int":
-					value = &IntValue{Value: 0}
-				case "string":
-					value = &StringValue{Value: ""}
-				case "bool":
-					value = &BoolValue{Value: false}
-				default:
-					// Unknown type, keep original value
-				}
-			}
+			// Type mismatch - create zero value of expected type
+			value = convertValue(value, ast.TypeName(param.Type), env)
 
-			localEnv.Set(param.Name, value)
+			localEnv.Define(param.Name, copyValue(value))
 		} else {
 			// Missing argument - set zero value of parameter type
-			switch param.Type {
-			case "int":
-				localEnv.Set(param.Name, &IntValue{Value: 0})
-			case "string":
-				localEnv.Set(param.Name, &StringValue{Value: ""})
-			case "bool":
-				localEnv.Set(param.Name, &BoolValue{Value: false})
-			default:
-				localEnv.Set(param.Name, &IntValue{Value: 0})
-			}
+			localEnv.Define(param.Name, zeroValueOf(ast.TypeName(param.Type), env))
+		}
+	}
+
+	// Named results start as zero values
+	named := len(function.Results) > 0 && function.Results[0].Name != ""
+	if named {
+		for _, result := range function.Results {
+			localEnv.Define(result.Name, zeroValueOf(ast.TypeName(result.Type), env))
 		}
 	}
 
 	// Execute function body with return handling
-	returnValue := 0
+	var returnValue Value = &IntValue{Value: 0}
 	if function.Body != nil {
-		func() {
-			defer func() {
-				if r := recover(); r != nil {
-					if returnEx, ok := r.(*ReturnException); ok {
-						// Capture return value
-						returnValue = returnEx.Value
-					} else {
-						// Re-panic for other exceptions
-						panic(r)
-					}
-				}
-			}()
+		returnEx := localEnv.frame.catch(func() { EvalBlockStatement(function.Body, localEnv) })
+		switch {
+		case named:
+			// return x sets the named results before the deferred calls,
+			// which may change them
+			if returnEx != nil && returnEx.Result != nil {
+				setNamedResults(function, returnEx.Result, localEnv)
+			}
+		case returnEx != nil:
+			// Capture return value
+			returnValue = returnEx.Result
+			if returnValue == nil {
+				returnValue = &IntValue{Value: returnEx.Value}
+			}
+		case len(function.Results) > 0:
+			// A function recovering from a panic returns zero values
+			returnValue = zeroResults(function, env)
+		}
+
+		localEnv.frame.runDefers()
+		if named {
+			returnValue = namedResults(function, localEnv)
+		}
+	}
+
+	return convertResults(returnValue, function.Results, env)
+}
 
-			EvalBlockStatement(function.Body, localEnv)
-		}()
+// evalFuncLiteral creates a closure: the function of the literal captures
+// the environment it is evaluated in
+func evalFuncLiteral(node *ast.FuncLiteral, env *Environment) Value {
+	function := &Function{
+		Name:       "func",
+		Parameters: node.Parameters,
+		Results:    node.Results,
+		Body:       node.Body,
+		Env:        env,
 	}
+	return &FunctionValue{Signature: node.TypeName(), Function: function}
+}
+
+// callFunctionValue calls the function held by a function value. Calling a
+// nil function value yields 0.
+func callFunctionValue(value Value, args []ast.ASTNode, env *Environment) Value {
+	if fn, ok := value.(*FunctionValue); ok && fn.Function != nil {
+		return callUserFunction(fn.Function, nil, args, env)
+	}
+	return &IntValue{Value: 0}
+}
+
+// namedResults collects the current values of the named results of a function
+func namedResults(function *Function, env *Environment) Value {
+	values := make([]Value, len(function.Results))
+	for i, result := range function.Results {
+		values[i], _ = env.Get(result.Name)
+	}
+	if len(values) == 1 {
+		return values[0]
+	}
+	return &TupleValue{Values: values}
+}
 
-	return returnValue
+// setNamedResults assigns the values of a return statement to the named
+// results of a function
+func setNamedResults(function *Function, value Value, env *Environment) {
+	values := []Value{value}
+	if tuple, ok := value.(*TupleValue); ok {
+		values = tuple.Values
+	}
+	for i, result := range function.Results {
+		if i < len(values) {
+			env.Set(result.Name, convertValue(values[i], ast.TypeName(result.Type), env))
+		}
+	}
+}
+
+// zeroResults returns the zero values of the results of a function
+func zeroResults(function *Function, env *Environment) Value {
+	values := make([]Value, len(function.Results))
+	for i, result := range function.Results {
+		values[i] = zeroValueOf(ast.TypeName(result.Type), env)
+	}
+	if len(values) == 1 {
+		return values[0]
+	}
+	return &TupleValue{Values: values}
+}
+
+// convertResults converts returned values to the declared result types
+func convertResults(value Value, results []ast.Parameter, env *Environment) Value {
+	if tuple, ok := value.(*TupleValue); ok && len(tuple.Values) == len(results) {
+		values := make([]Value, len(results))
+		for i, result := range results {
+			values[i] = convertValue(tuple.Values[i], ast.TypeName(result.Type), env)
+		}
+		return &TupleValue{Values: values}
+	}
+	if len(results) == 1 {
+		return convertValue(value, ast.TypeName(results[0].Type), env)
+	}
+	return value
+}
+
+// evalValueList evaluates a list of expressions from left to right. A single
+// call returning multiple results is expanded into its results.
+func evalValueList(exprs []ast.ASTNode, env *Environment) []Value {
+	if len(exprs) == 1 {
+		value := EvalValueWithEnvironment(exprs[0], env)
+		if tuple, ok := value.(*TupleValue); ok {
+			return tuple.Values
+		}
+		return []Value{value}
+	}
+	values := make([]Value, len(exprs))
+	for i, expr := range exprs {
+		values[i] = EvalValueWithEnvironment(expr, env)
+	}
+	return values
+}
+
+// evalTupleValues evaluates the values of an assignment to n variables,
+// including the comma-ok forms v, ok := x.(T) and v, ok := m[k]
+func evalTupleValues(exprs []ast.ASTNode, n int, env *Environment) []Value {
+	if len(exprs) == 1 && n == 2 {
+		switch e := exprs[0].(type) {
+		case *ast.TypeAssertNode:
+			value, matched := evalTypeAssert(e, env, true)
+			return []Value{value, &BoolValue{Value: matched}}
+		case *ast.IndexAccess:
+			if m, isMap := EvalValueWithEnvironment(e.Object, env).(*MapValue); isMap {
+				value, found := evalMapIndex(m, EvalValueWithEnvironment(e.Index, env), env)
+				return []Value{value, &BoolValue{Value: found}}
+			}
+		}
+	}
+	return evalValueList(exprs, env)
+}
+
+// evalTupleVarStatement declares several variables at once. All values are
+// evaluated before any variable is declared; without values the variables
+// start as zero values.
+func evalTupleVarStatement(s *ast.TupleVarStatement, env *Environment) {
+	var values []Value
+	if s.Values != nil {
+		values = evalTupleValues(s.Values, len(s.Names), env)
+	}
+	for i, name := range s.Names {
+		if name == "_" {
+			continue
+		}
+		if i >= len(values) {
+			env.Define(name, zeroValueOf(ast.TypeName(s.Type), env))
+		} else if s.Type != nil {
+			env.Define(name, convertValue(values[i], ast.TypeName(s.Type), env))
+		} else {
+			env.Define(name, values[i])
+		}
+	}
 }
 
 // evalStructLiteral evaluates struct literal expressions
@@ -651,28 +1245,30 @@ func evalStructLiteral(node *ast.StructLiteral, env *Environment) Value {
 		}
 	}
 
-	// Evaluate field values
+	// Evaluate field values; values without field names are those of the
+	// fields in declaration order. Struct and array values are copied.
 	fields := make(map[string]Value)
 	for fieldName, fieldExpr := range node.Fields {
-		value := EvalValueWithEnvironment(fieldExpr, env)
+		value := copyValue(EvalValueWithEnvironment(fieldExpr, env))
 		fields[fieldName] = value
 	}
+	for i, valueExpr := range node.Values {
+		value := copyValue(EvalValueWithEnvironment(valueExpr, env))
+		if i < len(structDef.Fields) {
+			fields[structDef.Fields[i].Name] = value
+		}
+	}
 
-	// Initialize missing fields with zero values
+	// Values of interface fields are stored as interface values, and
+	// missing fields are initialized with zero values
 	for _, field := range structDef.Fields {
-		if _, exists := fields[field.Name]; !exists {
-			// Set default zero values based on type
-			switch field.Type {
-			case "int":
-				fields[field.Name] = &IntValue{Value: 0}
-			case "string":
-				fields[field.Name] = &StringValue{Value: ""}
-			case "bool":
-				fields[field.Name] = &BoolValue{Value: false}
-			default:
-				// Unknown type: set to int 0
-				fields[field.Name] = &IntValue{Value: 0}
+		if value, exists := fields[field.Name]; exists {
+			if _, isInterface := interfaceMethods(field.Type, env); isInterface {
+				fields[field.Name] = convertValue(value, field.Type, env)
 			}
+		} else {
+			// Set default zero values based on type
+			fields[field.Name] = zeroValueOf(field.Type, env)
 		}
 	}
 
@@ -684,7 +1280,8 @@ func evalStructLiteral(node *ast.StructLiteral, env *Environment) Value {
 
 // evalFieldAccess evaluates field access expressions (obj.field)
 func evalFieldAccess(node *ast.FieldAccessNode, env *Environment) Value {
-	obj := EvalValueWithEnvironment(node.Object, env)
+	// p.field is (*p).field for a pointer p
+	obj := derefValue(EvalValueWithEnvironment(node.Object, env))
 
 	// Check if object is a struct
 	structVal, ok := obj.(*StructValue)
@@ -704,27 +1301,55 @@ func evalFieldAccess(node *ast.FieldAccessNode, env *Environment) Value {
 
 // evalSliceLiteral evaluates slice literal expressions
 func evalSliceLiteral(node *ast.SliceLiteral, env *Environment) Value {
-	var elements []Value
+	// A literal is never a nil slice, even without elements
+	elementType := ast.TypeName(node.ElementType)
+	elements := make([]Value, 0, len(node.Elements))
 
 	// Evaluate each element
 	for _, elem := range node.Elements {
-		value := EvalValueWithEnvironment(elem, env)
-		elements = append(elements, value)
+		elements = append(elements, evalElement(elem, elementType, env))
 	}
 
 	return &SliceValue{
-		ElementType: node.ElementType,
+		ElementType: elementType,
 		Elements:    elements,
 	}
 }
 
+// evalElement evaluates an element of a slice or array literal. Struct and
+// array values are copied, and values of an interface element type are
+// stored as interface values.
+func evalElement(elem ast.ASTNode, elementType string, env *Environment) Value {
+	value := copyValue(EvalValueWithEnvironment(elem, env))
+	if _, isInterface := interfaceMethods(elementType, env); isInterface {
+		value = convertValue(value, elementType, env)
+	}
+	return value
+}
+
 // evalIndexAccess evaluates index access expressions (slice[index])
 func evalIndexAccess(node *ast.IndexAccess, env *Environment) Value {
 	obj := EvalValueWithEnvironment(node.Object, env)
 	index := EvalValueWithEnvironment(node.Index, env)
 
-	// Check if object is a slice
-	sliceVal, ok := obj.(*SliceValue)
+	// Indexing a map yields the zero value for a missing key
+	if m, ok := obj.(*MapValue); ok {
+		value, _ := evalMapIndex(m, index, env)
+		return value
+	}
+
+	// Indexing a string yields the byte at that offset
+	if strVal, ok := obj.(*StringValue); ok {
+		i, ok := toInt(index)
+		if !ok || i < 0 || i >= len(strVal.Value) {
+			// Invalid index or out of bounds: return zero value
+			return &ByteValue{Value: 0}
+		}
+		return &ByteValue{Value: strVal.Value[i]}
+	}
+
+	// Check if object is a slice or an array
+	elements, _, ok := elementsOf(obj)
 	if !ok {
 		// Not a slice: return zero value
 		return &IntValue{Value: 0}
@@ -738,10 +1363,10 @@ func evalIndexAccess(node *ast.IndexAccess, env *Environment) Value {
 	}
 
 	// Bounds checking
-	if indexVal.Value < 0 || indexVal.Value >= len(sliceVal.Elements) {
+	if indexVal.Value < 0 || indexVal.Value >= len(elements) {
 		// Out of bounds: return zero value
 		return &IntValue{Value: 0}
 	}
 
-	return sliceVal.Elements[indexVal.Value]
+	return elements[indexVal.Value]
 }
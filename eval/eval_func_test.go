@@ -3,6 +3,7 @@ package eval
 import (
 	"testing"
 
+	"github.com/yuya-takeyama/petitgo/ast"
 	"github.com/yuya-takeyama/petitgo/parser"
 	"github.com/yuya-takeyama/petitgo/scanner"
 )
@@ -98,3 +99,259 @@ func TestEval_FunctionRecursion(t *testing.T) {
 		t.Errorf("recursive function call result wrong. expected=0, got=%d", result)
 	}
 }
+
+// evalProgram evaluates every statement of a program in a new environment
+func evalProgram(t *testing.T, input string) *Environment {
+	t.Helper()
+	p := parser.NewParser(scanner.NewScanner(input))
+	var statements []ast.Statement
+	for {
+		stmt := p.ParseStatement()
+		if stmt == nil {
+			break
+		}
+		statements = append(statements, stmt)
+	}
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("unexpected parse errors: %v", errs)
+	}
+	env := NewEnvironment()
+	EvalProgram(statements, env)
+	return env
+}
+
+func TestEval_MultipleReturnValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]string
+	}{
+		{
+			name: "unnamed results",
+			input: `func divmod(a int, b int) (int, int) { return a / b, a % b }
+q, r := divmod(17, 5)`,
+			expected: map[string]string{"q": "3", "r": "2"},
+		},
+		{
+			name: "comma ok idiom",
+			input: `func lookup(k string) (string, bool) {
+	if k == "a" {
+		return "apple", true
+	}
+	return "", false
+}
+v, ok := lookup("a")
+w, found := lookup("b")`,
+			expected: map[string]string{"v": "apple", "ok": "true", "w": "", "found": "false"},
+		},
+		{
+			name: "named results with bare return",
+			input: `func split(sum int) (x, y int) {
+	x = sum * 4 / 9
+	y = sum - x
+	return
+}
+a, b := split(17)`,
+			expected: map[string]string{"a": "7", "b": "10"},
+		},
+		{
+			name: "named results start at zero values",
+			input: `func zero() (n int, s string, f float64) { return }
+n, s, f := zero()`,
+			expected: map[string]string{"n": "0", "s": "", "f": "0"},
+		},
+		{
+			name: "results are converted to the declared types",
+			input: `func pair() (float64, int) { return 1, 2 }
+f, i := pair()`,
+			expected: map[string]string{"f": "1", "i": "2"},
+		},
+		{
+			name: "returning a multi-value call",
+			input: `func two() (int, int) { return 1, 2 }
+func forward() (int, int) { return two() }
+a, b := forward()`,
+			expected: map[string]string{"a": "1", "b": "2"},
+		},
+		{
+			name: "results passed as arguments",
+			input: `func divmod(a int, b int) (int, int) { return a / b, a % b }
+func add(a int, b int) int { return a + b }
+sum := add(divmod(9, 4))`,
+			expected: map[string]string{"sum": "3"},
+		},
+		{
+			name: "blank identifier discards a result",
+			input: `func two() (int, int) { return 1, 2 }
+_, b := two()`,
+			expected: map[string]string{"b": "2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := evalProgram(t, tt.input)
+			for name, want := range tt.expected {
+				value, exists := env.Get(name)
+				if !exists {
+					t.Fatalf("variable %s not set", name)
+				}
+				if value.String() != want {
+					t.Errorf("%s: expected %q, got %q", name, want, value.String())
+				}
+			}
+			if _, exists := env.Get("_"); exists {
+				t.Error("blank identifier must not be assigned")
+			}
+		})
+	}
+}
+
+func TestEval_TupleAssignment(t *testing.T) {
+	env := evalProgram(t, `func divmod(a int, b int) (int, int) { return a / b, a % b }
+a, b := 1, 2
+a, b = b, a
+x := 1.5
+x, y := 2, "s"
+type Point struct {
+	x int
+	y int
+}
+p := Point{x: 3, y: 4}
+p.x, p.y = p.y, p.x
+xs := []int{5, 6, 7}
+i := 0
+i, xs[i] = 2, 9
+xs[1], xs[2] = xs[2], xs[1]
+m := map[string]int{}
+ptr := &a
+m["k"], *ptr = divmod(7, 2)
+px := p.x
+first := xs[0]
+second := xs[1]
+third := xs[2]
+mk := m["k"]`)
+
+	expected := map[string]string{"a": "1", "b": "1", "x": "2", "y": "s", "px": "4", "i": "2", "first": "9", "second": "7", "third": "6", "mk": "3"}
+	for name, want := range expected {
+		value, _ := env.Get(name)
+		if value == nil || value.String() != want {
+			t.Errorf("%s: expected %q, got %v", name, want, value)
+		}
+	}
+
+	// Redeclaring with := keeps the type of an existing variable
+	if x, _ := env.Get("x"); x.Type() != "float64" {
+		t.Errorf("x: expected float64, got %s", x.Type())
+	}
+}
+
+func TestEval_TupleValue(t *testing.T) {
+	tuple := &TupleValue{Values: []Value{&IntValue{Value: 1}, &StringValue{Value: "a"}, &BoolValue{Value: true}}}
+	if tuple.Type() != "(int, string, bool)" {
+		t.Errorf("expected type (int, string, bool), got %s", tuple.Type())
+	}
+	if tuple.String() != "1 a true" {
+		t.Errorf("expected string %q, got %q", "1 a true", tuple.String())
+	}
+}
+
+func TestEval_Closures(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]string
+	}{
+		{
+			name: "captured variables are shared by reference",
+			input: `func counter() func() int {
+	n := 0
+	return func() int {
+		n++
+		return n
+	}
+}
+next := counter()
+a := next()
+b := next()
+c := counter()()`,
+			expected: map[string]string{"a": "1", "b": "2", "c": "1"},
+		},
+		{
+			name: "closures see later assignments",
+			input: `x := 1
+get := func() int { return x }
+x = 5
+y := get()
+set := func(v int) { x = v }
+set(7)`,
+			expected: map[string]string{"y": "5", "x": "7"},
+		},
+		{
+			name: "parameters and := shadow captured variables",
+			input: `x := 1
+f := func(x int) int { return x * 2 }
+g := func() int {
+	x := 10
+	return x
+}
+a := f(3)
+b := g()`,
+			expected: map[string]string{"a": "6", "b": "10", "x": "1"},
+		},
+		{
+			name: "function values and function-typed parameters",
+			input: `func double(n int) int { return n * 2 }
+func apply(f func(int) int, v int) int { return f(v) }
+var g func(int) int = double
+a := apply(g, 4)
+b := apply(func(n int) int { return n + 1 }, 4)
+c := func(a, b int) int { return a * b }(6, 7)`,
+			expected: map[string]string{"a": "8", "b": "5", "c": "42"},
+		},
+		{
+			name: "nil function values",
+			input: `var f func() = nil
+isNil := f == nil
+f = func() {}
+set := f != nil`,
+			expected: map[string]string{"isNil": "true", "set": "true"},
+		},
+		{
+			name: "each iteration of a for loop has its own variables",
+			input: `fs := []func() int{nil, nil, nil}
+for i := 0; i < 3; i++ {
+	fs[i] = func() int { return i }
+}
+ps := []*int{nil, nil}
+for j, k := 0, 10; j < 2; j, k = j+1, k+1 {
+	ps[j] = &k
+}
+a := fs[0]()
+b := fs[2]()
+c := *ps[0]
+d := *ps[1]`,
+			expected: map[string]string{"a": "0", "b": "2", "c": "10", "d": "11"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := evalProgram(t, tt.input)
+			for name, want := range tt.expected {
+				value, exists := env.Get(name)
+				if !exists {
+					t.Fatalf("variable %s not set", name)
+				}
+				if value.String() != want {
+					t.Errorf("%s: expected %q, got %q", name, want, value.String())
+				}
+			}
+		})
+	}
+
+	env := evalProgram(t, `f := func(a int, s string) (int, bool) { return a, true }`)
+	if f, _ := env.Get("f"); f.Type() != "func(int, string) (int, bool)" {
+		t.Errorf("f: expected type func(int, string) (int, bool), got %s", f.Type())
+	}
+}
@@ -0,0 +1,258 @@
+package eval
+
+import (
+	"strings"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/constant"
+)
+
+// interfaceMethods returns the methods of an interface type, including
+// those of the interfaces it embeds. ok is false when typeName does not name
+// an interface type.
+func interfaceMethods(typeName string, env *Environment) (methods []*ast.MethodDef, ok bool) {
+	switch typeName {
+	case "any", "interface{}":
+		return nil, true
+	}
+	definition, exists := env.GetInterface(typeName)
+	if !exists {
+		return nil, false
+	}
+	return definition.MethodSet(env.GetInterface), true
+}
+
+// implements reports whether the method set of typeName contains every
+// method of an interface
+func implements(typeName string, methods []*ast.MethodDef, env *Environment) bool {
+	return missingMethod(typeName, methods, env) == ""
+}
+
+// missingMethod explains why the method set of typeName does not contain
+// every method of an interface, or returns "" when it does. The method set
+// of T holds the methods with value receivers, while that of *T also holds
+// the ones with pointer receivers.
+func missingMethod(typeName string, methods []*ast.MethodDef, env *Environment) string {
+	baseType := strings.TrimPrefix(typeName, "*")
+	if dynamicMethods, isInterface := interfaceMethods(typeName, env); isInterface {
+		// An interface value has the methods of its interface type
+		for _, spec := range methods {
+			if method := findMethod(dynamicMethods, spec.Name); method == nil {
+				return "missing method " + spec.Name
+			} else if !sameTypes(method.Parameters, spec.Parameters) || !sameTypes(method.Results, spec.Results) {
+				return "wrong type for method " + spec.Name
+			}
+		}
+		return ""
+	}
+	for _, spec := range methods {
+		method, exists := env.GetMethod(baseType, spec.Name)
+		if !exists {
+			return "missing method " + spec.Name
+		}
+		if !sameTypes(method.Parameters, spec.Parameters) || !sameTypes(method.Results, spec.Results) {
+			return "wrong type for method " + spec.Name
+		}
+		if baseType == typeName && ast.IsPointerType(ast.TypeName(method.Receiver.Type)) {
+			return "method " + spec.Name + " has pointer receiver"
+		}
+	}
+	return ""
+}
+
+// findMethod returns the method of an interface with the given name
+func findMethod(methods []*ast.MethodDef, name string) *ast.MethodDef {
+	for _, method := range methods {
+		if method.Name == name {
+			return method
+		}
+	}
+	return nil
+}
+
+// sameTypes reports whether two parameter lists have identical types
+func sameTypes(a, b []ast.Parameter) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if constant.CanonicalType(ast.TypeName(a[i].Type)) != constant.CanonicalType(ast.TypeName(b[i].Type)) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasType reports whether a dynamic value matches a type in a type assertion
+// or a type switch case: its type is typeName, or it implements typeName when
+// that is an interface type. Only NilValue matches "nil".
+func hasType(value Value, typeName string, env *Environment) bool {
+	if _, isNil := value.(*NilValue); isNil || typeName == "nil" {
+		return isNil && typeName == "nil"
+	}
+	if methods, isInterface := interfaceMethods(typeName, env); isInterface {
+		return implements(value.Type(), methods, env)
+	}
+	return value.Type() == constant.CanonicalType(typeName)
+}
+
+// convertValue adapts value to the declared type typeName. Values assigned
+// to an interface type are stored in an interface value; a value whose type
+// does not implement the interface cannot be assigned, and panics like a
+// failed conversion. Other types are handled by coerceValue.
+func convertValue(value Value, typeName string, env *Environment) Value {
+	if ast.IsFuncType(typeName) {
+		// nil and functions of other types yield a nil function value
+		if fn, ok := value.(*FunctionValue); ok && fn.Signature == typeName {
+			return value
+		}
+		return &FunctionValue{Signature: typeName}
+	}
+	if ast.IsPointerType(typeName) {
+		// nil and pointers to other types yield a nil pointer
+		if pointer, ok := value.(*PointerValue); ok && pointer.Type() == typeName {
+			return value
+		}
+		return &PointerValue{ElementType: typeName[len("*"):]}
+	}
+	if ast.IsMapType(typeName) {
+		// nil and maps of other types yield a nil map
+		if m, ok := value.(*MapValue); ok && m.Type() == typeName {
+			return value
+		}
+		keyType, valueType := ast.MapTypes(typeName)
+		return &MapValue{KeyType: keyType, ValueType: valueType}
+	}
+	methods, isInterface := interfaceMethods(typeName, env)
+	if !isInterface {
+		return coerceValue(value, typeName)
+	}
+	if iface, ok := value.(*InterfaceValue); ok && iface.Value == nil {
+		return &InterfaceValue{Interface: typeName}
+	}
+	if _, isNil := value.(*NilValue); isNil {
+		return &InterfaceValue{Interface: typeName}
+	}
+	if reason := missingMethod(value.Type(), methods, env); reason != "" {
+		panic(&PanicException{Value: &StringValue{Value: value.Type() + " does not implement " + typeName + " (" + reason + ")"}})
+	}
+	return &InterfaceValue{Interface: typeName, Value: dynamicValue(value)}
+}
+
+// zeroValueOf returns the zero value of a type, including nil interfaces,
+// nil function values, nil maps, nil slices, nil pointers and structs whose
+// fields are all zero values
+func zeroValueOf(typeName string, env *Environment) Value {
+	if ast.IsFuncType(typeName) {
+		return &FunctionValue{Signature: typeName}
+	}
+	if ast.IsMapType(typeName) {
+		keyType, valueType := ast.MapTypes(typeName)
+		return &MapValue{KeyType: keyType, ValueType: valueType}
+	}
+	if strings.HasPrefix(typeName, "[]") {
+		return &SliceValue{ElementType: typeName[len("[]"):]}
+	}
+	if ast.IsPointerType(typeName) {
+		return &PointerValue{ElementType: typeName[len("*"):]}
+	}
+	if isArrayType(typeName) {
+		return zeroArray(typeName, env)
+	}
+	if structDef, isStruct := env.GetStruct(typeName); isStruct {
+		fields := make(map[string]Value, len(structDef.Fields))
+		for _, field := range structDef.Fields {
+			fields[field.Name] = zeroValueOf(field.Type, env)
+		}
+		return &StructValue{TypeName: typeName, Fields: fields}
+	}
+	if _, isInterface := interfaceMethods(typeName, env); isInterface {
+		return &InterfaceValue{Interface: typeName}
+	}
+	return zeroValue(typeName)
+}
+
+// evalTypeAssert evaluates a type assertion x.(T). ok reports whether the
+// dynamic value of x has type T; when it does not, the result is the zero
+// value of T, and a type assertion used as a single value panics.
+func evalTypeAssert(node *ast.TypeAssertNode, env *Environment, commaOk bool) (value Value, ok bool) {
+	x := EvalValueWithEnvironment(node.Expression, env)
+	dynamic := dynamicValue(x)
+	if _, isInterface := x.(*InterfaceValue); isInterface && hasType(dynamic, node.Type, env) {
+		if _, isNil := dynamic.(*NilValue); !isNil {
+			return convertValue(dynamic, node.Type, env), true
+		}
+	}
+	if !commaOk {
+		panic(&PanicException{Value: &StringValue{Value: assertionError(x, dynamic, node.Type, env)}})
+	}
+	return zeroValueOf(node.Type, env), false
+}
+
+// assertionError returns the message of the panic raised by a failed type
+// assertion x.(T), where dynamic is the dynamic value of x
+func assertionError(x, dynamic Value, typeName string, env *Environment) string {
+	if _, isNil := dynamic.(*NilValue); isNil {
+		return "interface conversion: interface is nil, not " + typeName
+	}
+	if methods, isInterface := interfaceMethods(typeName, env); isInterface {
+		return "interface conversion: " + dynamic.Type() + " is not " + typeName + ": " + missingMethod(dynamic.Type(), methods, env)
+	}
+	return "interface conversion: " + x.Type() + " is " + dynamic.Type() + ", not " + typeName
+}
+
+// evalTypeSwitchStatement runs the first clause listing a type that the
+// dynamic value of x has, or the default clause if none does. The bound
+// variable has the listed type in clauses with a single type and holds x
+// itself in the others; it is only visible inside the clause.
+func evalTypeSwitchStatement(s *ast.TypeSwitchStatement, env *Environment) {
+	if s.Init != nil {
+		EvalStatement(s.Init, env)
+	}
+
+	x := EvalValueWithEnvironment(s.Value, env)
+	dynamic := dynamicValue(x)
+
+	var selected *ast.TypeCaseClause
+	for _, clause := range s.Cases {
+		for _, typeName := range clause.Types {
+			if hasType(dynamic, typeName, env) {
+				selected = clause
+				break
+			}
+		}
+		if selected != nil {
+			break
+		}
+	}
+
+	// If no case matched, execute the default clause
+	if selected == nil {
+		for _, clause := range s.Cases {
+			if clause.IsDefault() {
+				selected = clause
+			}
+		}
+	}
+	if selected == nil {
+		return
+	}
+
+	if s.Binding != "" {
+		bound := x
+		if len(selected.Types) == 1 && selected.Types[0] != "nil" {
+			bound = convertValue(dynamic, selected.Types[0], env)
+		}
+		previous, existed := env.variables[s.Binding]
+		env.Define(s.Binding, bound)
+		defer func() {
+			if existed {
+				env.Define(s.Binding, previous)
+			} else {
+				delete(env.variables, s.Binding)
+			}
+		}()
+	}
+
+	EvalBlockStatement(selected.Body, env)
+}
@@ -0,0 +1,209 @@
+package eval
+
+import (
+	"testing"
+)
+
+const shapesProgram = `type Shape interface {
+	Area() int
+	Scale(f int) int
+}
+
+type Square struct {
+	side int
+}
+
+func (s Square) Area() int { return s.side * s.side }
+func (s Square) Scale(f int) int { return s.side * f }
+
+type Circle struct {
+	r int
+}
+
+func (c Circle) Area() int { return 3 * c.r * c.r }
+func (c *Circle) Scale(f int) int { return c.r * f }
+
+func describe(s Shape) int { return s.Area() + s.Scale(10) }
+`
+
+func TestInterface_DynamicDispatch(t *testing.T) {
+	env := evalProgram(t, shapesProgram+`
+var s Shape = Square{side: 4}
+area := s.Area()
+total := describe(s)
+var c Shape = &Circle{r: 1}
+scaled := c.Scale(3)
+var a any = 42
+var e interface{} = nil
+empty := e == nil
+full := a != nil`)
+
+	expected := map[string]string{
+		"area":   "16",
+		"total":  "56",
+		"scaled": "3",
+		"empty":  "true",
+		"full":   "true",
+		"a":      "42",
+	}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("variable %s not set", name)
+		}
+		if value.String() != want {
+			t.Errorf("%s: expected %q, got %q", name, want, value.String())
+		}
+	}
+
+	if s, _ := env.Get("s"); s.Type() != "Shape" {
+		t.Errorf("s: expected type Shape, got %s", s.Type())
+	}
+}
+
+func TestInterface_TypeAssertion(t *testing.T) {
+	env := evalProgram(t, shapesProgram+`
+var s Shape = Square{side: 4}
+sq := s.(Square)
+side := sq.side
+var a any = 42
+n, ok := a.(int)
+str, isString := a.(string)
+shape, isShape := a.(Shape)
+back, isAny := s.(any)`)
+
+	expected := map[string]string{
+		"side":     "4",
+		"n":        "42",
+		"ok":       "true",
+		"str":      "",
+		"isString": "false",
+		"shape":    "<nil>",
+		"isShape":  "false",
+		"isAny":    "true",
+	}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("variable %s not set", name)
+		}
+		if value.String() != want {
+			t.Errorf("%s: expected %q, got %q", name, want, value.String())
+		}
+	}
+
+	if back, _ := env.Get("back"); back.Type() != "any" {
+		t.Errorf("back: expected type any, got %s", back.Type())
+	}
+}
+
+func TestInterface_Embedded(t *testing.T) {
+	env := evalProgram(t, `type Stringer interface {
+	String() string
+}
+
+type Named interface {
+	Stringer
+	Name() string
+}
+
+type Gopher struct {
+	name string
+}
+
+func (g Gopher) String() string { return "gopher " + g.name }
+func (g Gopher) Name() string { return g.name }
+
+var n Named = Gopher{name: "go"}
+var s Stringer = n
+str := s.String()
+name := n.Name()`)
+
+	expected := map[string]string{
+		"str":  "gopher go",
+		"name": "go",
+	}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("variable %s not set", name)
+		}
+		if value.String() != want {
+			t.Errorf("%s: expected %q, got %q", name, want, value.String())
+		}
+	}
+}
+
+func TestInterface_Panics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var c Shape = Circle{r: 1}", "Circle does not implement Shape (method Scale has pointer receiver)"},
+		{"var a any = 1\nvar s Shape = a", "any does not implement Shape (missing method Area)"},
+		{"var s Shape\ns.Area()", "runtime error: invalid memory address or nil pointer dereference"},
+		{"var a any = 42\ns := a.(string)", "interface conversion: any is int, not string"},
+		{"var a any = 42\ns := a.(Shape)", "interface conversion: int is not Shape: missing method Area"},
+		{"var a any\nn := a.(int)", "interface conversion: interface is nil, not int"},
+	}
+
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				exception, ok := recover().(*PanicException)
+				if !ok {
+					t.Errorf("%q: expected a *PanicException", tt.input)
+					return
+				}
+				if value := exception.Value.String(); value != tt.expected {
+					t.Errorf("%q: expected panic %q, got %q", tt.input, tt.expected, value)
+				}
+			}()
+
+			evalProgram(t, shapesProgram+tt.input)
+		}()
+	}
+}
+
+func TestInterface_TypeSwitch(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"int", "42", "43"},
+		{"string", `"go"`, "go!"},
+		{"interface", "Square{side: 2}", "4"},
+		{"nil", "nil", "nil"},
+		{"default", "true", "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := evalProgram(t, shapesProgram+`
+var a any = `+tt.value+`
+v := "outer"
+var result any = nil
+switch v := a.(type) {
+case int:
+	result = v + 1
+case string:
+	result = v + "!"
+case Shape:
+	result = v.Area()
+case nil:
+	result = "nil"
+default:
+	result = "other"
+}`)
+
+			result, _ := env.Get("result")
+			if result.String() != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result.String())
+			}
+			if v, _ := env.Get("v"); v.String() != "outer" {
+				t.Errorf("the binding must not leak out of the switch, got v = %q", v.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package eval
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+)
+
+// hashKey returns a string that identifies a comparable value as a map key:
+// two keys have the same hash exactly when they are equal. Keys of an
+// interface type are hashed by their dynamic values, so 1 and "1" differ.
+func hashKey(key Value) string {
+	key = dynamicValue(key)
+	switch k := key.(type) {
+	case *IntValue:
+		return "int:" + strconv.Itoa(k.Value)
+	case *FloatValue:
+		if k.Value == 0 {
+			// +0 and -0 are equal
+			return "float64:0"
+		}
+		return "float64:" + strconv.FormatUint(math.Float64bits(k.Value), 16)
+	case *StringValue:
+		return "string:" + strconv.Quote(k.Value)
+	case *StructValue:
+		// Structs are equal if all their fields are equal
+		names := make([]string, 0, len(k.Fields))
+		for name := range k.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fields := make([]string, len(names))
+		for i, name := range names {
+			fields[i] = name + "=" + hashKey(k.Fields[name])
+		}
+		return k.TypeName + "{" + strings.Join(fields, ",") + "}"
+	case *ArrayValue:
+		// Arrays are equal if all their elements are equal
+		elements := make([]string, len(k.Elements))
+		for i, element := range k.Elements {
+			elements[i] = hashKey(element)
+		}
+		return k.Type() + "{" + strings.Join(elements, ",") + "}"
+	case *PointerValue:
+		// Pointers are equal if they point to the same location
+		return k.Type() + ":" + pointerKey(k.target)
+	}
+	return key.Type() + ":" + key.String()
+}
+
+// pointerKey returns a string that identifies the location a pointer points
+// to, or "nil" for a nil pointer
+func pointerKey(target location) string {
+	switch l := target.(type) {
+	case variableLocation:
+		return fmt.Sprintf("var(%p,%s)", l.env, l.name)
+	case fieldLocation:
+		return fmt.Sprintf("field(%p,%s)", l.object, l.field)
+	case cellLocation:
+		return fmt.Sprintf("cell(%p)", l.cell)
+	}
+	return "nil"
+}
+
+// lessValue orders map keys for printing: numbers by value and anything else
+// by its string form
+func lessValue(a, b Value) bool {
+	if x, y, ok := floatOperands(a, b); ok {
+		return x < y
+	}
+	if x, xOk := toInt(a); xOk {
+		if y, yOk := toInt(b); yOk {
+			return x < y
+		}
+	}
+	return a.String() < b.String()
+}
+
+// evalMapLiteral evaluates map literal expressions. Keys and values are
+// converted to the key and value types of the map, and struct and array
+// values are copied.
+func evalMapLiteral(node *ast.MapLiteral, env *Environment) Value {
+	m := NewMapValue(ast.TypeName(node.KeyType), ast.TypeName(node.ValueType))
+	for _, entry := range node.Entries {
+		key := convertValue(EvalValueWithEnvironment(entry.Key, env), m.KeyType, env)
+		value := copyValue(convertValue(EvalValueWithEnvironment(entry.Value, env), m.ValueType, env))
+		m.Set(key, value)
+	}
+	return m
+}
+
+// evalMapIndex looks up m[k]. ok reports whether the key is present; when
+// it is not, the result is the zero value of the value type.
+func evalMapIndex(m *MapValue, index Value, env *Environment) (value Value, ok bool) {
+	if value, exists := m.Get(convertValue(index, m.KeyType, env)); exists {
+		return value, true
+	}
+	return zeroValueOf(m.ValueType, env), false
+}
+
+// nilMapAssignment is the message of the panic raised by assigning to an
+// entry of a nil map
+const nilMapAssignment = "assignment to entry in nil map"
+
+// mapEntryLocation is the entry of a map for a key, which is assigned to
+// but cannot be pointed to. Assigning to an entry of a nil map panics.
+type mapEntryLocation struct {
+	m   *MapValue
+	key Value
+	env *Environment // for the zero value of a missing entry
+}
+
+func (l mapEntryLocation) load() Value {
+	value, _ := evalMapIndex(l.m, l.key, l.env)
+	return value
+}
+func (l mapEntryLocation) store(value Value) {
+	if !l.m.Set(l.key, value) {
+		panic(&PanicException{Value: &StringValue{Value: nilMapAssignment}})
+	}
+}
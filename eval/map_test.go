@@ -0,0 +1,134 @@
+package eval
+
+import (
+	"testing"
+)
+
+func TestMap_Operations(t *testing.T) {
+	env := evalProgram(t, `m := map[string]int{"a": 1, "b": 2}
+m["c"] = 3
+m["a"] = m["a"] + 10
+a := m["a"]
+missing := m["z"]
+size := len(m)
+v, ok := m["b"]
+w, found := m["z"]
+delete(m, "b")
+delete(m, "z")
+afterDelete := len(m)
+_, stillThere := m["b"]
+alias := m
+alias["d"] = 4
+shared := m["d"]
+var empty map[string]int = nil
+emptyLen := len(empty)
+fromNil := empty["x"]
+isNil := empty == nil`)
+
+	expected := map[string]string{
+		"a":           "11",
+		"missing":     "0",
+		"size":        "3",
+		"v":           "2",
+		"ok":          "true",
+		"w":           "0",
+		"found":       "false",
+		"afterDelete": "2",
+		"stillThere":  "false",
+		"shared":      "4", // maps are references
+		"emptyLen":    "0",
+		"fromNil":     "0",
+		"isNil":       "true",
+		"m":           "map[a:11 c:3 d:4]",
+	}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("variable %s not set", name)
+		}
+		if value.String() != want {
+			t.Errorf("%s: expected %q, got %q", name, want, value.String())
+		}
+	}
+
+	if m, _ := env.Get("m"); m.Type() != "map[string]int" {
+		t.Errorf("m: expected type map[string]int, got %s", m.Type())
+	}
+}
+
+func TestMap_Keys(t *testing.T) {
+	env := evalProgram(t, `type Point struct {
+	x int
+	y int
+}
+byPoint := map[Point]string{Point{x: 1, y: 2}: "a"}
+p := byPoint[Point{x: 1, y: 2}]
+q := byPoint[Point{x: 2, y: 1}]
+var k any = 1
+byAny := map[any]int{k: 10, "1": 20}
+one := byAny[1]
+str := byAny["1"]
+nested := map[string]map[int]bool{"x": map[int]bool{3: true}}
+deep := nested["x"][3]
+byPair := map[[2]int]int{[2]int{1, 2}: 12, [2]int{2, 1}: 21}
+pair := byPair[[2]int{2, 1}]
+a := 1
+b := 1
+c := new(int)
+byPtr := map[*int]int{&a: 1, &b: 2, c: 3, nil: 4}
+pa := byPtr[&a]
+pb := byPtr[&b]
+pc := byPtr[c]
+pn := byPtr[nil]
+pd := byPtr[new(int)]
+byInt := map[int]string{3: "c", 1: "a", 2: "b"}`)
+
+	expected := map[string]string{
+		"p":     "a",
+		"q":     "",
+		"one":   "10",
+		"str":   "20",
+		"deep":  "true",
+		"pair":  "21",
+		"pa":    "1", // pointers to equal values differ
+		"pb":    "2",
+		"pc":    "3",
+		"pn":    "4",
+		"pd":    "0",
+		"byInt": "map[1:a 2:b 3:c]", // printed in key order
+	}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("variable %s not set", name)
+		}
+		if value.String() != want {
+			t.Errorf("%s: expected %q, got %q", name, want, value.String())
+		}
+	}
+}
+
+func TestMap_NilMapAssignment(t *testing.T) {
+	inputs := []string{
+		"var m map[string]int\nm[\"a\"] = 1",
+		"var m map[string]int\nm[\"a\"]++",
+		"var m map[string]int\nm[\"a\"] += 2",
+	}
+
+	for _, input := range inputs {
+		func() {
+			defer func() {
+				exception, ok := recover().(*PanicException)
+				if !ok {
+					t.Errorf("%q: expected a *PanicException", input)
+					return
+				}
+				if value := exception.Value.String(); value != nilMapAssignment {
+					t.Errorf("%q: expected panic %q, got %q", input, nilMapAssignment, value)
+				}
+			}()
+
+			evalProgram(t, input)
+		}()
+	}
+}
@@ -0,0 +1,161 @@
+package eval
+
+import "github.com/yuya-takeyama/petitgo/ast"
+
+// nilDereference is the message of the panic raised by dereferencing a nil
+// pointer or calling a method of a nil interface value
+const nilDereference = "runtime error: invalid memory address or nil pointer dereference"
+
+// PointerValue represents a pointer to a variable, a struct field, a slice
+// element or a value allocated by new(T) or &T{...}. A nil pointer has no
+// target.
+type PointerValue struct {
+	ElementType string
+	target      location
+}
+
+func (v *PointerValue) Type() string { return "*" + v.ElementType }
+func (v *PointerValue) String() string {
+	if v.target == nil {
+		return "<nil>"
+	}
+	return "&" + v.target.load().String()
+}
+func (v *PointerValue) IsTruthy() bool { return v.target != nil }
+
+// IsNil reports whether v is a nil pointer
+func (v *PointerValue) IsNil() bool { return v.target == nil }
+
+// Load returns the value v points to, panicking if v is nil
+func (v *PointerValue) Load() Value {
+	if v.target == nil {
+		panic(&PanicException{Value: &StringValue{Value: nilDereference}})
+	}
+	return v.target.load()
+}
+
+// Store replaces the value v points to, panicking if v is nil
+func (v *PointerValue) Store(value Value) {
+	if v.target == nil {
+		panic(&PanicException{Value: &StringValue{Value: nilDereference}})
+	}
+	v.target.store(value)
+}
+
+// location is a place holding a value that a pointer can point to. Two
+// pointers are equal when their locations are.
+type location interface {
+	load() Value
+	store(value Value)
+}
+
+// variableLocation is a variable of an environment
+type variableLocation struct {
+	env  *Environment
+	name string
+}
+
+func (l variableLocation) load() Value       { return l.env.variables[l.name] }
+func (l variableLocation) store(value Value) { l.env.variables[l.name] = value }
+
+// fieldLocation is a field of a struct
+type fieldLocation struct {
+	object *StructValue
+	field  string
+}
+
+func (l fieldLocation) load() Value       { return l.object.Fields[l.field] }
+func (l fieldLocation) store(value Value) { l.object.Fields[l.field] = value }
+
+// cellLocation is an element of a slice or a value that has no name, such
+// as the one allocated by new(T)
+type cellLocation struct {
+	cell *Value
+}
+
+func (l cellLocation) load() Value       { return *l.cell }
+func (l cellLocation) store(value Value) { *l.cell = value }
+
+// newPointer returns a pointer to a new location holding value
+func newPointer(elementType string, value Value) *PointerValue {
+	return &PointerValue{ElementType: elementType, target: cellLocation{cell: &value}}
+}
+
+// evalAddress evaluates &x. The address of a variable, a field or a slice
+// element points to it; other operands, such as composite literals, are
+// stored in a new location.
+func evalAddress(x ast.ASTNode, env *Environment) *PointerValue {
+	switch x := x.(type) {
+	case *ast.VariableNode:
+		for e := env; e != nil; e = e.outer {
+			if value, exists := e.variables[x.Name]; exists {
+				return &PointerValue{ElementType: value.Type(), target: variableLocation{env: e, name: x.Name}}
+			}
+		}
+	case *ast.FieldAccessNode:
+		if object, ok := derefValue(EvalValueWithEnvironment(x.Object, env)).(*StructValue); ok {
+			if value, exists := object.Fields[x.Field]; exists {
+				return &PointerValue{ElementType: value.Type(), target: fieldLocation{object: object, field: x.Field}}
+			}
+		}
+	case *ast.IndexAccess:
+		if elements, elementType, ok := elementsOf(EvalValueWithEnvironment(x.Object, env)); ok {
+			if i, ok := toInt(EvalValueWithEnvironment(x.Index, env)); ok && i >= 0 && i < len(elements) {
+				return &PointerValue{ElementType: elementType, target: cellLocation{cell: &elements[i]}}
+			}
+		}
+	case *ast.DerefNode:
+		if pointer, ok := EvalValueWithEnvironment(x.Operand, env).(*PointerValue); ok {
+			// &*p is p itself, after checking that p is not nil
+			pointer.Load()
+			return pointer
+		}
+	}
+	value := EvalValueWithEnvironment(x, env)
+	return newPointer(value.Type(), value)
+}
+
+// evalDeref evaluates *p
+func evalDeref(node *ast.DerefNode, env *Environment) Value {
+	switch p := EvalValueWithEnvironment(node.Operand, env).(type) {
+	case *PointerValue:
+		return p.Load()
+	case *NilValue:
+		panic(&PanicException{Value: &StringValue{Value: nilDereference}})
+	}
+	return &IntValue{Value: 0}
+}
+
+// derefValue returns the value a pointer points to, so that p.field and
+// method calls work on pointers to structs; other values are returned as
+// they are
+func derefValue(value Value) Value {
+	if p, ok := value.(*PointerValue); ok {
+		return p.Load()
+	}
+	return value
+}
+
+// methodReceiver adapts the receiver of a method call to the receiver of
+// the method: a method with a pointer receiver gets the address of the
+// receiver expression x, and one with a value receiver gets the value a
+// pointer points to. x is nil when the receiver is the dynamic value of an
+// interface, which is not addressable.
+func methodReceiver(method *Function, receiver Value, x ast.ASTNode, env *Environment) Value {
+	if method.Receiver == nil {
+		return receiver
+	}
+	pointer, isPointer := receiver.(*PointerValue)
+	wantsPointer := ast.IsPointerType(ast.TypeName(method.Receiver.Type))
+	switch {
+	case wantsPointer && !isPointer:
+		switch x.(type) {
+		case *ast.VariableNode, *ast.FieldAccessNode, *ast.IndexAccess, *ast.DerefNode:
+			return evalAddress(x, env)
+		}
+		return newPointer(receiver.Type(), receiver)
+	case !wantsPointer && isPointer:
+		return pointer.Load()
+	}
+	return receiver
+}
@@ -0,0 +1,113 @@
+package eval
+
+import (
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/parser"
+	"github.com/yuya-takeyama/petitgo/scanner"
+)
+
+func TestPointer_Basics(t *testing.T) {
+	env := evalProgram(t, `type Node struct {
+	Value int
+	Next  *Node
+}
+type Counter struct {
+	n int
+}
+func (c *Counter) Inc() { *c = Counter{n: c.n + 1} }
+func (c Counter) Get() int { return c.n }
+func set(p *int, v int) { *p = v }
+func push(head *Node, v int) *Node { return &Node{Value: v, Next: head} }
+x := 1
+p := &x
+set(p, 42)
+deref := *p
+pp := &p
+**pp = 9
+same := p == &x
+var list *Node
+empty := list == nil
+for i := range 3 {
+	list = push(list, i)
+}
+sum := 0
+for n := list; n != nil; n = n.Next {
+	sum = sum*10 + n.Value
+}
+q := new(int)
+zero := *q
+*q = 7
+c := &Counter{}
+c.Inc()
+c.Inc()
+fromPointer := c.Get()
+v := Counter{n: 3}
+v.Inc()
+fromValue := v.Get()
+xs := []int{1, 2, 3}
+e := &xs[1]
+*e = 20
+second := xs[1]
+f := &v.n
+*f = 100
+field := v.n`)
+
+	expected := map[string]struct {
+		value    string
+		typeName string
+	}{
+		"x":           {"9", "int"},
+		"deref":       {"42", "int"},
+		"p":           {"&9", "*int"},
+		"same":        {"true", "bool"},
+		"list":        {"&Node{...}", "*Node"},
+		"empty":       {"true", "bool"},
+		"sum":         {"210", "int"},
+		"zero":        {"0", "int"},
+		"q":           {"&7", "*int"},
+		"c":           {"&Counter{...}", "*Counter"},
+		"fromPointer": {"2", "int"},
+		"fromValue":   {"4", "int"},
+		"second":      {"20", "int"},
+		"field":       {"100", "int"},
+	}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("%s not set", name)
+		}
+		if value.String() != want.value || value.Type() != want.typeName {
+			t.Errorf("%s: expected %s of type %s, got %s of type %s", name, want.value, want.typeName, value.String(), value.Type())
+		}
+	}
+}
+
+func TestPointer_NilDereference(t *testing.T) {
+	tests := []string{
+		"var p *int\nx := *p",
+		"var p *int\n*p = 1",
+		"type Node struct { Value int }\nvar n *Node\nx := n.Value",
+	}
+
+	for _, input := range tests {
+		func() {
+			defer func() {
+				exception, ok := recover().(*PanicException)
+				if !ok {
+					t.Errorf("%q: expected a *PanicException", input)
+					return
+				}
+				if msg := exception.Value.String(); msg != nilDereference {
+					t.Errorf("%q: expected %q, got %q", input, nilDereference, msg)
+				}
+			}()
+
+			p := parser.NewParser(scanner.NewScanner(input))
+			env := NewEnvironment()
+			for stmt := p.ParseStatement(); stmt != nil; stmt = p.ParseStatement() {
+				EvalStatement(stmt, env)
+			}
+		}()
+	}
+}
@@ -0,0 +1,77 @@
+package eval
+
+import "github.com/yuya-takeyama/petitgo/ast"
+
+// evalRangeStatement runs the body of a range loop once for each element of
+// a slice, rune of a string, entry of a map or integer from 0 to n-1. The
+// range expression is evaluated once. With := each iteration declares its
+// own variables, so closures created in the body capture the values of their
+// iteration. label is the label of the loop, if any.
+func evalRangeStatement(s *ast.RangeStatement, label string, env *Environment) {
+	// iterate runs the body for an element and reports whether the loop goes on
+	iterate := func(key, value Value) bool {
+		scope := env.enclosed()
+		assignRangeVariable(s, s.Key, key, scope)
+		if s.Value != "" {
+			assignRangeVariable(s, s.Value, copyValue(value), scope)
+		}
+		return evalLoopBody(s.Body, label, scope)
+	}
+
+	switch x := EvalValueWithEnvironment(s.Expression, env).(type) {
+	case *SliceValue:
+		elements := x.Elements
+		for i := range elements {
+			if !iterate(&IntValue{Value: i}, elements[i]) {
+				return
+			}
+		}
+	case *ArrayValue:
+		// The loop ranges over a copy of the array
+		elements := copyValue(x).(*ArrayValue).Elements
+		for i := range elements {
+			if !iterate(&IntValue{Value: i}, elements[i]) {
+				return
+			}
+		}
+	case *StringValue:
+		// Strings are iterated by rune; the key is the byte offset of the rune
+		for i, r := range x.Value {
+			if !iterate(&IntValue{Value: i}, &RuneValue{Value: r}) {
+				return
+			}
+		}
+	case *MapValue:
+		for _, key := range x.Keys() {
+			value, exists := x.Get(key)
+			if !exists {
+				// Entries deleted during the iteration are not produced
+				continue
+			}
+			if !iterate(key, value) {
+				return
+			}
+		}
+	case *IntValue:
+		for i := 0; i < x.Value; i++ {
+			if !iterate(&IntValue{Value: i}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// assignRangeVariable binds an iteration variable: := declares it in the
+// scope of the iteration, while = assigns the existing variable
+func assignRangeVariable(s *ast.RangeStatement, name string, value Value, scope *Environment) {
+	if name == "" || name == "_" {
+		return
+	}
+	if s.Define {
+		scope.Define(name, value)
+		return
+	}
+	if existingValue, exists := scope.Get(name); exists {
+		scope.Set(name, convertValue(value, existingValue.Type(), scope))
+	}
+}
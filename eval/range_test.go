@@ -0,0 +1,110 @@
+package eval
+
+import (
+	"testing"
+)
+
+func TestRange_Collections(t *testing.T) {
+	env := evalProgram(t, `weighted := 0
+for i, v := range []int{10, 20, 30} {
+	weighted += i * v
+}
+offsets := 0
+runes := 0
+for i, r := range "aé😀" {
+	offsets = offsets*10 + i
+	runes += int(r)
+}
+m := map[string]int{"x": 1, "y": 2, "z": 3}
+keys := ""
+total := 0
+for k, v := range m {
+	keys += k
+	total += v
+}
+count := 0
+for i := range 5 {
+	count += i
+}
+times := 0
+for range 3 {
+	times++
+}
+var empty []int = nil
+for range empty {
+	times = -1
+}
+last := -1
+for last = range []int{7, 8, 9} {
+}`)
+
+	expected := map[string]string{
+		"weighted": "80",
+		"offsets":  "13", // a is at 0, é at 1 and 😀 at 3
+		"runes":    "128842",
+		"keys":     "xyz",
+		"total":    "6",
+		"count":    "10",
+		"times":    "3",
+		"last":     "2",
+	}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("variable %s not set", name)
+		}
+		if value.String() != want {
+			t.Errorf("%s: expected %q, got %q", name, want, value.String())
+		}
+	}
+}
+
+func TestRange_IterationVariables(t *testing.T) {
+	env := evalProgram(t, `var first func() int = nil
+for i := range 3 {
+	if i == 0 {
+		first = func() int { return i }
+	}
+}
+captured := first()
+x := 100
+for x := range 2 {
+	x += 10
+}
+m := map[string]int{"a": 1, "b": 2}
+visited := 0
+for k := range m {
+	delete(m, "b")
+	visited++
+}
+type Point struct {
+	x int
+}
+points := []Point{Point{x: 1}}
+for _, p := range points {
+	inner := p.x
+	p = Point{x: 5}
+}
+kept := points[0].x`)
+
+	expected := map[string]string{
+		"captured": "0", // each iteration has its own i
+		"x":        "100",
+		"visited":  "1", // entries deleted during the iteration are not produced
+		"kept":     "1",
+	}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("variable %s not set", name)
+		}
+		if value.String() != want {
+			t.Errorf("%s: expected %q, got %q", name, want, value.String())
+		}
+	}
+	for _, name := range []string{"i", "k", "p", "inner"} {
+		if _, exists := env.Get(name); exists {
+			t.Errorf("%s must not leak out of the loop", name)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package eval
+
+import (
+	"fmt"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+)
+
+// evalSliceExpr evaluates slice expressions s[low:high] and s[low:high:max].
+// Slicing a slice or an array shares its elements, so assignments through
+// the result are visible through the operand and append reuses the spare
+// capacity up to max. Slicing a string yields the bytes from low to high.
+func evalSliceExpr(node *ast.SliceExpr, env *Environment) Value {
+	obj := derefValue(EvalValueWithEnvironment(node.Object, env))
+	low := evalSliceIndex(node.Low, 0, env)
+
+	if str, ok := obj.(*StringValue); ok {
+		high := evalSliceIndex(node.High, len(str.Value), env)
+		checkSliceBounds(low, high, high, len(str.Value), false, "length")
+		return &StringValue{Value: str.Value[low:high]}
+	}
+
+	elements, elementType, ok := elementsOf(obj)
+	if !ok {
+		// Not sliceable: return zero value
+		return &IntValue{Value: 0}
+	}
+	high := evalSliceIndex(node.High, len(elements), env)
+	max := evalSliceIndex(node.Max, cap(elements), env)
+	checkSliceBounds(low, high, max, cap(elements), node.Slice3, "capacity")
+	return &SliceValue{ElementType: elementType, Elements: elements[low:high:max]}
+}
+
+// evalSliceIndex evaluates an index of a slice expression, which is
+// omitted when x is nil
+func evalSliceIndex(x ast.ASTNode, omitted int, env *Environment) int {
+	if x == nil {
+		return omitted
+	}
+	n, _ := toInt(EvalValueWithEnvironment(x, env))
+	return n
+}
+
+// checkSliceBounds panics like Go's runtime unless
+// 0 <= low <= high <= max <= capacity
+func checkSliceBounds(low, high, max, capacity int, slice3 bool, limit string) {
+	var bounds string
+	switch {
+	case slice3 && (max < 0 || max > capacity):
+		bounds = fmt.Sprintf("[::%d] with %s %d", max, limit, capacity)
+	case slice3 && (high < 0 || high > max):
+		bounds = fmt.Sprintf("[:%d:%d]", high, max)
+	case slice3 && (low < 0 || low > high):
+		bounds = fmt.Sprintf("[%d:%d:]", low, high)
+	case !slice3 && (high < 0 || high > capacity):
+		bounds = fmt.Sprintf("[:%d] with %s %d", high, limit, capacity)
+	case !slice3 && low < 0:
+		bounds = fmt.Sprintf("[%d:]", low)
+	case !slice3 && low > high:
+		bounds = fmt.Sprintf("[%d:%d]", low, high)
+	default:
+		return
+	}
+	panic(&PanicException{Value: &StringValue{Value: "runtime error: slice bounds out of range " + bounds}})
+}
@@ -140,6 +140,10 @@ func TestSlice_LenFunction(t *testing.T) {
 		{`nums := []int{1, 2, 3}`, `len(nums)`, 3},
 		{`empty := []int{}`, `len(empty)`, 0},
 		{`str := "hello"`, `len(str)`, 5},
+		{`str := "héllo"`, `len(str)`, 6},
+		{`str := "日本"`, `len(str)`, 6},
+		{"str := `\\u00e9`", `len(str)`, 6},
+		{`str := "\u00e9\x41"`, `len(str)`, 3},
 		{`big := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}`, `len(big)`, 10},
 	}
 
@@ -300,3 +304,126 @@ func TestSlice_ComplexOperations(t *testing.T) {
 		}
 	}
 }
+
+func TestString_ByteIndexing(t *testing.T) {
+	tests := []struct {
+		expr         string
+		expectedType string
+		expected     string
+	}{
+		{`str[0]`, "uint8", "104"},
+		{`str[1]`, "uint8", "195"},
+		{`str[2]`, "uint8", "169"},
+		{`str[5]`, "uint8", "111"},
+		{`str[6]`, "uint8", "0"},
+		{`str[0] - 'a'`, "uint8", "7"},
+		{`str[0] == 'h'`, "bool", "true"},
+		{`byte(300)`, "uint8", "44"},
+		{`str[5] + 200`, "uint8", "55"},
+		{`string(str[0])`, "string", "h"},
+	}
+
+	for _, tt := range tests {
+		env := NewEnvironment()
+		EvalStatement(parser.NewParser(scanner.NewScanner(`str := "héllo"`)).ParseStatement(), env)
+
+		expr := parser.NewParser(scanner.NewScanner(tt.expr)).ParseExpression()
+		result := EvalValueWithEnvironment(expr, env)
+
+		if result.Type() != tt.expectedType {
+			t.Errorf("Input: %s, expected type %s, got %s", tt.expr, tt.expectedType, result.Type())
+		}
+		if result.String() != tt.expected {
+			t.Errorf("Input: %s, expected %s, got %s", tt.expr, tt.expected, result.String())
+		}
+	}
+}
+
+func TestSlice_SliceExpressions(t *testing.T) {
+	env := evalProgram(t, `s := []int{1, 2, 3, 4, 5}
+mid := s[1:3]
+mid[0] = 20
+second := s[1]
+midCap := cap(mid)
+grown := append(mid, 30)
+fourth := s[3]
+capped := s[1:2:2]
+copied := append(capped, 99)
+copied[0] = 7
+stillSecond := s[1]
+cappedCap := cap(capped)
+var arr [4]int
+tail := arr[1:]
+tail[0] = 5
+arrSecond := arr[1]
+str := "hello, world"
+world := str[7:]
+hello := str[:5]
+lo := str[3:5]
+all := str[:]
+var none []int
+emptyLen := len(none[0:0])
+grownLen := len(grown)`)
+
+	expected := map[string]string{
+		"second":      "20",
+		"midCap":      "4",
+		"fourth":      "30",
+		"stillSecond": "20",
+		"cappedCap":   "1",
+		"arrSecond":   "5",
+		"world":       "world",
+		"hello":       "hello",
+		"lo":          "lo",
+		"all":         "hello, world",
+		"emptyLen":    "0",
+		"grownLen":    "3",
+	}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("%s not set", name)
+		}
+		if value.String() != want {
+			t.Errorf("%s: expected %s, got %s", name, want, value.String())
+		}
+	}
+
+	if tail, _ := env.Get("tail"); tail.Type() != "[]int" {
+		t.Errorf("expected slicing an array to yield []int, got %s", tail.Type())
+	}
+}
+
+func TestSlice_SliceBoundsOutOfRange(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"s := []int{1, 2, 3}\nx := s[:5]", "runtime error: slice bounds out of range [:5] with capacity 3"},
+		{"s := []int{1, 2, 3}\nx := s[2:1]", "runtime error: slice bounds out of range [2:1]"},
+		{"s := []int{1, 2, 3}\nx := s[0:1:4]", "runtime error: slice bounds out of range [::4] with capacity 3"},
+		{"s := []int{1, 2, 3}\nx := s[0:3:2]", "runtime error: slice bounds out of range [:3:2]"},
+		{"s := \"abc\"\nx := s[1:4]", "runtime error: slice bounds out of range [:4] with length 3"},
+	}
+
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				exception, ok := recover().(*PanicException)
+				if !ok {
+					t.Errorf("%q: expected a *PanicException", tt.input)
+					return
+				}
+				if msg := exception.Value.String(); msg != tt.expected {
+					t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, msg)
+				}
+			}()
+
+			p := parser.NewParser(scanner.NewScanner(tt.input))
+			env := NewEnvironment()
+			for stmt := p.ParseStatement(); stmt != nil; stmt = p.ParseStatement() {
+				EvalStatement(stmt, env)
+			}
+		}()
+	}
+}
@@ -234,3 +234,125 @@ func TestStruct_NestedAccess(t *testing.T) {
 		t.Errorf("Expected 30 (10+20), got %d", intVal.Value)
 	}
 }
+
+func TestStruct_Methods(t *testing.T) {
+	env := evalProgram(t, `type Person struct {
+	Name string
+	Age  int
+}
+
+func (p Person) Greet() string { return "Hello, " + p.Name }
+func (p Person) IsOlder(other Person) bool { return p.Age > other.Age }
+func (p *Person) Birthday() int { return p.Age + 1 }
+func (Person) Kind() string { return "person" }
+func Greet() string { return "function" }
+
+func NewPerson(name string, age int) Person { return Person{Name: name, Age: age} }
+
+alice := NewPerson("Alice", 30)
+bob := Person{Name: "Bob", Age: 25}
+greeting := alice.Greet()
+older := alice.IsOlder(bob)
+next := bob.Birthday()
+kind := NewPerson("Carol", 1).Kind()
+plain := Greet()`)
+
+	expected := map[string]string{
+		"greeting": "Hello, Alice",
+		"older":    "true",
+		"next":     "26",
+		"kind":     "person",
+		"plain":    "function",
+	}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("variable %s not set", name)
+		}
+		if value.String() != want {
+			t.Errorf("%s: expected %q, got %q", name, want, value.String())
+		}
+	}
+
+	if _, exists := env.GetMethod("Person", "Birthday"); !exists {
+		t.Error("pointer receiver method should be in the method table of Person")
+	}
+	if _, exists := env.GetFunction("Kind"); exists {
+		t.Error("methods must not be registered as functions")
+	}
+}
+
+func TestStruct_FunctionFields(t *testing.T) {
+	env := evalProgram(t, `type Grid struct {
+	Fn func(int) int
+	n  int
+}
+
+var g Grid
+g.Fn = func(x int) int { return x * 2 }
+p := &g
+h := Grid{Fn: func(x int) int { return x + 1 }, n: 3}
+a := g.Fn(5)
+b := p.Fn(6)
+c := h.Fn(h.n)`)
+
+	for name, want := range map[string]string{"a": "10", "b": "12", "c": "4"} {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("variable %s not set", name)
+		}
+		if value.String() != want {
+			t.Errorf("%s: expected %q, got %q", name, want, value.String())
+		}
+	}
+
+	defer func() {
+		exception, ok := recover().(*PanicException)
+		if !ok {
+			t.Fatal("expected a *PanicException for a missing method")
+		}
+		if value, want := exception.Value.String(), "g.Size undefined (type Grid has no field or method Size)"; value != want {
+			t.Errorf("expected panic %q, got %q", want, value)
+		}
+	}()
+	evalProgram(t, "type Grid struct {\n\tn int\n}\n\nvar g Grid\ng.Size()")
+}
+
+func TestStruct_CopyValue(t *testing.T) {
+	inner := &StructValue{TypeName: "Point", Fields: map[string]Value{"X": &IntValue{Value: 1}}}
+	outer := &StructValue{TypeName: "Line", Fields: map[string]Value{"Start": inner}}
+
+	copied := copyValue(outer).(*StructValue)
+	copied.Fields["Start"].(*StructValue).Fields["X"] = &IntValue{Value: 2}
+
+	if inner.Fields["X"].String() != "1" {
+		t.Errorf("copy shares nested struct fields with the original")
+	}
+	if copyValue(inner.Fields["X"]) != inner.Fields["X"] {
+		t.Errorf("non-struct values are returned as they are")
+	}
+}
+
+func TestStruct_PositionalLiteral(t *testing.T) {
+	env := evalProgram(t, `type Point struct {
+	x, y int
+	name string
+}
+p := Point{1, 2, "a"}
+points := []Point{{3, 4, "b"}}
+x := p.x
+y := p.y
+name := p.name
+last := points[0].y`)
+
+	expected := map[string]string{"x": "1", "y": "2", "name": "a", "last": "4"}
+	for name, want := range expected {
+		value, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("variable %s not set", name)
+		}
+		if value.String() != want {
+			t.Errorf("%s: expected %q, got %q", name, want, value.String())
+		}
+	}
+}
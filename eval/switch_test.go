@@ -208,3 +208,161 @@ default:
 		})
 	}
 }
+
+// Test the general switch forms
+func TestEvalSwitchForms(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		setupX   int
+		expected string
+	}{
+		{
+			name: "case list",
+			input: `switch x {
+case 1, 2, 3:
+	result = "small"
+case 4, 5:
+	result = "medium"
+}`,
+			setupX:   5,
+			expected: "medium",
+		},
+		{
+			name: "tag expression",
+			input: `switch x % 3 {
+case 0:
+	result = "fizz"
+case 1:
+	result = "one"
+}`,
+			setupX:   9,
+			expected: "fizz",
+		},
+		{
+			name: "init statement",
+			input: `switch y := x * 2; y {
+case 8:
+	result = "eight"
+default:
+	result = "other"
+}`,
+			setupX:   4,
+			expected: "eight",
+		},
+		{
+			name: "tagless switch selects the first true case",
+			input: `switch {
+case x < 0:
+	result = "negative"
+case x < 10:
+	result = "small"
+case x < 100:
+	result = "large"
+}`,
+			setupX:   5,
+			expected: "small",
+		},
+		{
+			name: "fallthrough",
+			input: `switch x {
+case 1:
+	result = result + "a"
+	fallthrough
+case 2:
+	result = result + "b"
+	fallthrough
+default:
+	result = result + "c"
+case 3:
+	result = result + "d"
+}`,
+			setupX:   1,
+			expected: "abc",
+		},
+		{
+			name: "default in the middle runs only when no case matches",
+			input: `switch x {
+default:
+	result = "other"
+case 1:
+	result = "one"
+}`,
+			setupX:   1,
+			expected: "one",
+		},
+		{
+			name: "fallthrough from default",
+			input: `switch x {
+default:
+	result = "other"
+	fallthrough
+case 1:
+	result = result + " one"
+}`,
+			setupX:   7,
+			expected: "other one",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := NewEnvironment()
+			env.Set("x", &IntValue{Value: tt.setupX})
+			env.Set("result", &StringValue{Value: ""})
+
+			p := parser.NewParser(scanner.NewScanner(tt.input))
+			stmt := p.ParseStatement()
+			if errs := p.Errors(); len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+
+			EvalStatement(stmt, env)
+
+			resultVal, _ := env.Get("result")
+			if resultVal.String() != tt.expected {
+				t.Errorf("expected '%s', got '%s'", tt.expected, resultVal.String())
+			}
+		})
+	}
+}
+
+// Test that switch and == compare values with their types
+func TestEvalSwitchTypeAwareEquality(t *testing.T) {
+	env := NewEnvironment()
+	env.Set("s", &StringValue{Value: "1"})
+	env.Set("f", &FloatValue{Value: 2})
+	env.Set("r", &RuneValue{Value: 'a'})
+	env.Set("result", &StringValue{Value: ""})
+
+	input := `switch s {
+case 1:
+	result = "int"
+case "1":
+	result = "string"
+}`
+	EvalStatement(parser.NewParser(scanner.NewScanner(input)).ParseStatement(), env)
+	if resultVal, _ := env.Get("result"); resultVal.String() != "string" {
+		t.Errorf("expected string case to match, got '%s'", resultVal.String())
+	}
+
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`s == 1`, false},
+		{`s != 1`, true},
+		{`s == "1"`, true},
+		{`f == 2`, true},
+		{`r == 97`, true},
+		{`r == 'a'`, true},
+		{`true == 1`, false},
+	}
+	for _, tt := range tests {
+		expr := parser.NewParser(scanner.NewScanner(tt.input)).ParseExpression()
+		result, ok := EvalValueWithEnvironment(expr, env).(*BoolValue)
+		if !ok || result.Value != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.input, tt.expected, result)
+		}
+	}
+}
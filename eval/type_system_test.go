@@ -197,3 +197,319 @@ func TestTypeSystem_ArithmeticTypeErrors(t *testing.T) {
 		t.Errorf("Expected 0 for type error, got %d", intVal.Value)
 	}
 }
+
+func TestTypeSystem_FloatArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+		str      string
+	}{
+		{"1.5 + 2.25", 3.75, "3.75"},
+		{"0.1 + 0.2", 0.3, "0.3"}, // constant expressions are exact
+		{"10.0 - 2.5", 7.5, "7.5"},
+		{"2.5 * 4", 10, "10"},
+		{"1 / 4.0", 0.25, "0.25"},
+		{"1e21 * 10", 1e22, "1e+22"},
+		{"float64(3) / 2", 1.5, "1.5"},
+	}
+
+	for _, tt := range tests {
+		s := scanner.NewScanner(tt.input)
+		p := parser.NewParser(s)
+		expr := p.ParseExpression()
+
+		result := EvalValue(expr)
+
+		floatVal, ok := result.(*FloatValue)
+		if !ok {
+			t.Fatalf("Input: %s, expected FloatValue, got %T", tt.input, result)
+		}
+
+		if floatVal.Value != tt.expected {
+			t.Errorf("Input: %s, expected: %v, got: %v", tt.input, tt.expected, floatVal.Value)
+		}
+
+		if floatVal.Type() != "float64" {
+			t.Errorf("Input: %s, expected type 'float64', got '%s'", tt.input, floatVal.Type())
+		}
+
+		if floatVal.String() != tt.str {
+			t.Errorf("Input: %s, expected String() '%s', got '%s'", tt.input, tt.str, floatVal.String())
+		}
+	}
+}
+
+func TestTypeSystem_FloatComparisons(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"1.5 < 2.5", true},
+		{"2.5 <= 2.5", true},
+		{"3.0 > 4", false},
+		{"2 >= 1.5", true},
+		{"0.5 == 0.5", true},
+		{"0.5 != 0.25", true},
+	}
+
+	for _, tt := range tests {
+		s := scanner.NewScanner(tt.input)
+		p := parser.NewParser(s)
+		expr := p.ParseExpression()
+
+		result := EvalValue(expr)
+
+		boolVal, ok := result.(*BoolValue)
+		if !ok {
+			t.Fatalf("Input: %s, expected BoolValue, got %T", tt.input, result)
+		}
+
+		if boolVal.Value != tt.expected {
+			t.Errorf("Input: %s, expected: %v, got: %v", tt.input, tt.expected, boolVal.Value)
+		}
+	}
+}
+
+func TestTypeSystem_FloatVariables(t *testing.T) {
+	env := NewEnvironment()
+	input := "var f float64 = 2\ng := f / 4\nn := int(7.9)"
+
+	p := parser.NewParser(scanner.NewScanner(input))
+	for {
+		stmt := p.ParseStatement()
+		if stmt == nil {
+			break
+		}
+		EvalStatement(stmt, env)
+	}
+
+	if f, _ := env.Get("f"); f.String() != "2" || f.Type() != "float64" {
+		t.Errorf("expected f to be float64 2, got %s %s", f.Type(), f.String())
+	}
+	if g, _ := env.Get("g"); g.String() != "0.5" || g.Type() != "float64" {
+		t.Errorf("expected g to be float64 0.5, got %s %s", g.Type(), g.String())
+	}
+	if n, _ := env.Get("n"); n.String() != "7" || n.Type() != "int" {
+		t.Errorf("expected n to be int 7, got %s %s", n.Type(), n.String())
+	}
+}
+
+func TestTypeSystem_RuneValues(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedType string
+		expected     string
+	}{
+		{"'a'", "int32", "97"},
+		{"'a' + 1", "int32", "98"},
+		{"'z' - 'a'", "int32", "25"},
+		{`'\x41'`, "int32", "65"},
+		{"rune(2147483647) + 1", "int32", "-2147483648"},
+		{"int('0')", "int", "48"},
+		{"'b' > 'a'", "bool", "true"},
+		{"'a' == 97", "bool", "true"},
+		{"string('é')", "string", "é"},
+		{"string('a' + 1)", "string", "b"},
+		{"`raw\\n`", "string", `raw\n`},
+	}
+
+	for _, tt := range tests {
+		s := scanner.NewScanner(tt.input)
+		p := parser.NewParser(s)
+		expr := p.ParseExpression()
+
+		result := EvalValue(expr)
+
+		if result.Type() != tt.expectedType {
+			t.Errorf("Input: %s, expected type: %s, got: %s", tt.input, tt.expectedType, result.Type())
+		}
+		if result.String() != tt.expected {
+			t.Errorf("Input: %s, expected: %s, got: %s", tt.input, tt.expected, result.String())
+		}
+	}
+}
+
+func TestTypeSystem_RuneVariables(t *testing.T) {
+	env := NewEnvironment()
+	input := "var r rune = 65\nc := r + 2"
+
+	p := parser.NewParser(scanner.NewScanner(input))
+	for {
+		stmt := p.ParseStatement()
+		if stmt == nil {
+			break
+		}
+		EvalStatement(stmt, env)
+	}
+
+	expected := map[string]string{"r": "65", "c": "67"}
+	for name, value := range expected {
+		v, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("variable %s not found", name)
+		}
+		if v.Type() != "int32" || v.String() != value {
+			t.Errorf("expected %s to be int32 %s, got %s %s", name, value, v.Type(), v.String())
+		}
+	}
+}
+
+func TestTypeSystem_IntegerOperators(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedType string
+		expected     string
+	}{
+		{"17 % 5", "int", "2"},
+		{"(0 - 17) % 5", "int", "-2"},
+		{"12 & 10", "int", "8"},
+		{"12 | 10", "int", "14"},
+		{"12 ^ 10", "int", "6"},
+		{"12 &^ 10", "int", "4"},
+		{"1 << 10", "int", "1024"},
+		{"1024 >> 3", "int", "128"},
+		{"(0 - 16) >> 2", "int", "-4"},
+		{"1 << 64", "int", "0"},
+		{"(0 - 1) >> 100", "int", "-1"},
+		{"1 << 2 + 1", "int", "5"},
+		{"2 + 3 % 2", "int", "3"},
+		{"6 & 3 | 8", "int", "10"},
+		{"byte(200) << 1", "uint8", "144"},
+		{"'a' | 32", "int32", "97"},
+		{"'A' ^ ' '", "int32", "97"},
+		{"1 << byte(3)", "int", "8"},
+	}
+
+	for _, tt := range tests {
+		s := scanner.NewScanner(tt.input)
+		p := parser.NewParser(s)
+		expr := p.ParseExpression()
+
+		result := EvalValue(expr)
+
+		if result.Type() != tt.expectedType {
+			t.Errorf("Input: %s, expected type: %s, got: %s", tt.input, tt.expectedType, result.Type())
+		}
+		if result.String() != tt.expected {
+			t.Errorf("Input: %s, expected: %s, got: %s", tt.input, tt.expected, result.String())
+		}
+	}
+}
+
+func TestTypeSystem_IntegerOperatorPanics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"7 / (1 - 1)", divideByZero},
+		{"7 % (1 - 1)", divideByZero},
+		{"byte(7) / byte(0)", divideByZero},
+		{"1 << (0 - 1)", negativeShift},
+		{"8 >> (0 - 2)", negativeShift},
+	}
+
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				exception, ok := recover().(*PanicException)
+				if !ok {
+					t.Errorf("Input: %s, expected a *PanicException", tt.input)
+					return
+				}
+				if value := exception.Value.String(); value != tt.expected {
+					t.Errorf("Input: %s, expected panic %q, got %q", tt.input, tt.expected, value)
+				}
+			}()
+
+			EvalValue(parser.NewParser(scanner.NewScanner(tt.input)).ParseExpression())
+		}()
+	}
+}
+
+func TestTypeSystem_CompoundAssignment(t *testing.T) {
+	env := NewEnvironment()
+	input := `h := 5381
+h <<= 5
+h ^= 99
+h %= 1000
+mask := 255
+mask &^= 15
+mask |= 1
+mask >>= 1
+f := 1.5
+f *= 2.0
+s := "ab"
+s += "c"
+var b byte = 250
+b += 10`
+
+	p := parser.NewParser(scanner.NewScanner(input))
+	for {
+		stmt := p.ParseStatement()
+		if stmt == nil {
+			break
+		}
+		EvalStatement(stmt, env)
+	}
+
+	expected := map[string]string{
+		"h":    "227",
+		"mask": "120",
+		"f":    "3",
+		"s":    "abc",
+		"b":    "4",
+	}
+	for name, value := range expected {
+		v, exists := env.Get(name)
+		if !exists {
+			t.Fatalf("variable %s not found", name)
+		}
+		if v.String() != value {
+			t.Errorf("expected %s to be %s, got %s", name, value, v.String())
+		}
+	}
+}
+
+func TestTypeSystem_LogicalAndUnaryOperators(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedType string
+		expected     string
+	}{
+		{"-5", "int", "-5"},
+		{"-5 + 3", "int", "-2"},
+		{"2 * -3", "int", "-6"},
+		{"- -4", "int", "4"},
+		{"+7", "int", "7"},
+		{"-2.5", "float64", "-2.5"},
+		{"^0", "int", "-1"},
+		{"^byte(200)", "uint8", "55"},
+		{"-byte(1)", "uint8", "255"},
+		{"-'a'", "int32", "-97"},
+		{"!true", "bool", "false"},
+		{"!(1 > 2)", "bool", "true"},
+		{"true && false", "bool", "false"},
+		{"true && true", "bool", "true"},
+		{"false || true", "bool", "true"},
+		{"false || false", "bool", "false"},
+		{"1 < 2 && 2 < 3", "bool", "true"},
+		{"1 > 2 || 2 > 3 || 3 > 2", "bool", "true"},
+		{"true || false && false", "bool", "true"},
+		{"!false && 3 > -1", "bool", "true"},
+	}
+
+	for _, tt := range tests {
+		s := scanner.NewScanner(tt.input)
+		p := parser.NewParser(s)
+		expr := p.ParseExpression()
+
+		result := EvalValue(expr)
+
+		if result.Type() != tt.expectedType {
+			t.Errorf("Input: %s, expected type: %s, got: %s", tt.input, tt.expectedType, result.Type())
+		}
+		if result.String() != tt.expected {
+			t.Errorf("Input: %s, expected: %s, got: %s", tt.input, tt.expected, result.String())
+		}
+	}
+}
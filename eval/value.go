@@ -1,6 +1,13 @@
 package eval
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yuya-takeyama/petitgo/constant"
+)
 
 // Value represents a value with a specific type in petitgo
 type Value interface {
@@ -18,6 +25,33 @@ func (v *IntValue) Type() string   { return "int" }
 func (v *IntValue) String() string { return fmt.Sprintf("%d", v.Value) }
 func (v *IntValue) IsTruthy() bool { return v.Value != 0 }
 
+// FloatValue represents a float64 value
+type FloatValue struct {
+	Value float64
+}
+
+func (v *FloatValue) Type() string   { return "float64" }
+func (v *FloatValue) String() string { return strconv.FormatFloat(v.Value, 'g', -1, 64) }
+func (v *FloatValue) IsTruthy() bool { return v.Value != 0 }
+
+// RuneValue represents a rune value (an alias for int32)
+type RuneValue struct {
+	Value rune
+}
+
+func (v *RuneValue) Type() string   { return "int32" }
+func (v *RuneValue) String() string { return fmt.Sprintf("%d", v.Value) }
+func (v *RuneValue) IsTruthy() bool { return v.Value != 0 }
+
+// ByteValue represents a byte value (an alias for uint8)
+type ByteValue struct {
+	Value byte
+}
+
+func (v *ByteValue) Type() string   { return "uint8" }
+func (v *ByteValue) String() string { return fmt.Sprintf("%d", v.Value) }
+func (v *ByteValue) IsTruthy() bool { return v.Value != 0 }
+
 // StringValue represents a string value
 type StringValue struct {
 	Value string
@@ -66,3 +100,251 @@ func (v *SliceValue) String() string {
 	return fmt.Sprintf("[%d elements]", len(v.Elements))
 }
 func (v *SliceValue) IsTruthy() bool { return len(v.Elements) > 0 }
+
+// IsNil reports whether v is a nil slice
+func (v *SliceValue) IsNil() bool { return v.Elements == nil }
+
+// MapValue represents a map. Maps are reference values: copies of a
+// MapValue share its entries. A nil map has no entries and cannot be
+// assigned to. Entries keep their insertion order, so that iteration is
+// deterministic.
+type MapValue struct {
+	KeyType   string
+	ValueType string
+	index     map[string]int // hash key -> position in keys and values; nil for a nil map
+	keys      []Value
+	values    []Value
+}
+
+// NewMapValue creates an empty map
+func NewMapValue(keyType, valueType string) *MapValue {
+	return &MapValue{KeyType: keyType, ValueType: valueType, index: make(map[string]int)}
+}
+
+func (v *MapValue) Type() string { return "map[" + v.KeyType + "]" + v.ValueType }
+func (v *MapValue) String() string {
+	// Entries are printed sorted by key, as fmt does
+	order := make([]int, len(v.keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return lessValue(v.keys[order[i]], v.keys[order[j]]) })
+
+	entries := make([]string, len(order))
+	for i, position := range order {
+		entries[i] = v.keys[position].String() + ":" + v.values[position].String()
+	}
+	return "map[" + strings.Join(entries, " ") + "]"
+}
+func (v *MapValue) IsTruthy() bool { return v.index != nil }
+
+// IsNil reports whether v is a nil map
+func (v *MapValue) IsNil() bool { return v.index == nil }
+
+// Len returns the number of entries
+func (v *MapValue) Len() int { return len(v.keys) }
+
+// Get returns the value stored under key
+func (v *MapValue) Get(key Value) (Value, bool) {
+	position, exists := v.index[hashKey(key)]
+	if !exists {
+		return nil, false
+	}
+	return v.values[position], true
+}
+
+// Set stores a value under key. It reports false for a nil map.
+func (v *MapValue) Set(key, value Value) bool {
+	if v.index == nil {
+		return false
+	}
+	hash := hashKey(key)
+	if position, exists := v.index[hash]; exists {
+		v.values[position] = value
+		return true
+	}
+	v.index[hash] = len(v.keys)
+	v.keys = append(v.keys, key)
+	v.values = append(v.values, value)
+	return true
+}
+
+// Delete removes the entry of key, if any
+func (v *MapValue) Delete(key Value) {
+	hash := hashKey(key)
+	position, exists := v.index[hash]
+	if !exists {
+		return
+	}
+	delete(v.index, hash)
+	v.keys = append(v.keys[:position], v.keys[position+1:]...)
+	v.values = append(v.values[:position], v.values[position+1:]...)
+	for i := position; i < len(v.keys); i++ {
+		v.index[hashKey(v.keys[i])] = i
+	}
+}
+
+// Keys returns the keys in insertion order
+func (v *MapValue) Keys() []Value {
+	return append([]Value(nil), v.keys...)
+}
+
+// TupleValue holds the results of a call returning multiple values
+type TupleValue struct {
+	Values []Value
+}
+
+func (v *TupleValue) Type() string {
+	types := make([]string, len(v.Values))
+	for i, value := range v.Values {
+		types[i] = value.Type()
+	}
+	return "(" + strings.Join(types, ", ") + ")"
+}
+func (v *TupleValue) String() string {
+	// Results are printed separated by spaces, as println(f()) does
+	strs := make([]string, len(v.Values))
+	for i, value := range v.Values {
+		strs[i] = value.String()
+	}
+	return strings.Join(strs, " ")
+}
+func (v *TupleValue) IsTruthy() bool { return len(v.Values) > 0 }
+
+// InterfaceValue represents a value of an interface type: the dynamic value
+// stored in it together with the interface it is stored as
+type InterfaceValue struct {
+	Interface string
+	Value     Value // nil for a nil interface
+}
+
+func (v *InterfaceValue) Type() string { return v.Interface }
+func (v *InterfaceValue) String() string {
+	if v.Value == nil {
+		return "<nil>"
+	}
+	return v.Value.String()
+}
+func (v *InterfaceValue) IsTruthy() bool { return v.Value != nil }
+
+// FunctionValue represents a value of a function type: a declared function
+// or a closure created by a function literal
+type FunctionValue struct {
+	Signature string    // function type, such as func(int) int
+	Function  *Function // nil for a nil function value
+}
+
+func (v *FunctionValue) Type() string { return v.Signature }
+func (v *FunctionValue) String() string {
+	if v.Function == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%p", v.Function)
+}
+func (v *FunctionValue) IsTruthy() bool { return v.Function != nil }
+
+// NilValue represents the predeclared nil
+type NilValue struct{}
+
+func (v *NilValue) Type() string   { return "nil" }
+func (v *NilValue) String() string { return "<nil>" }
+func (v *NilValue) IsTruthy() bool { return false }
+
+// dynamicValue returns the value stored in an interface value, NilValue for
+// a nil interface, and any other value as it is
+func dynamicValue(value Value) Value {
+	if iface, ok := value.(*InterfaceValue); ok {
+		if iface.Value == nil {
+			return &NilValue{}
+		}
+		return iface.Value
+	}
+	return value
+}
+
+// copyValue returns a copy of a value with value semantics: struct fields and
+// array elements are copied so that changes to the copy do not affect the
+// original
+func copyValue(value Value) Value {
+	if array, ok := value.(*ArrayValue); ok {
+		elements := make([]Value, len(array.Elements))
+		for i, element := range array.Elements {
+			elements[i] = copyValue(element)
+		}
+		return &ArrayValue{ElementType: array.ElementType, Elements: elements}
+	}
+	structVal, ok := value.(*StructValue)
+	if !ok {
+		return value
+	}
+	fields := make(map[string]Value, len(structVal.Fields))
+	for name, field := range structVal.Fields {
+		fields[name] = copyValue(field)
+	}
+	return &StructValue{TypeName: structVal.TypeName, Fields: fields}
+}
+
+// isIntegerType reports whether typeName names one of the supported integer types
+func isIntegerType(typeName string) bool {
+	switch constant.CanonicalType(typeName) {
+	case "int", "int32", "uint8":
+		return true
+	}
+	return false
+}
+
+// newInteger creates an integer value of the given type, wrapping n
+// around if it does not fit
+func newInteger(typeName string, n int) Value {
+	switch typeName {
+	case "int32":
+		return &RuneValue{Value: rune(n)}
+	case "uint8":
+		return &ByteValue{Value: byte(n)}
+	}
+	return &IntValue{Value: n}
+}
+
+// zeroValue returns the zero value for a type name (int 0 for unknown types)
+func zeroValue(typeName string) Value {
+	switch constant.CanonicalType(typeName) {
+	case "int":
+		return &IntValue{Value: 0}
+	case "int32":
+		return &RuneValue{Value: 0}
+	case "uint8":
+		return &ByteValue{Value: 0}
+	case "float64":
+		return &FloatValue{Value: 0}
+	case "string":
+		return &StringValue{Value: ""}
+	case "bool":
+		return &BoolValue{Value: false}
+	default:
+		return &IntValue{Value: 0}
+	}
+}
+
+// coerceValue adapts value to the declared type typeName. An int is
+// converted to float64, rune or byte the way an untyped constant would be;
+// any other mismatch yields the zero value of a known type, while values of
+// unknown types are kept as they are.
+func coerceValue(value Value, typeName string) Value {
+	typeName = constant.CanonicalType(typeName)
+	if value.Type() == typeName {
+		return value
+	}
+	if intVal, isInt := value.(*IntValue); isInt {
+		switch typeName {
+		case "float64":
+			return &FloatValue{Value: float64(intVal.Value)}
+		case "int32", "uint8":
+			return newInteger(typeName, intVal.Value)
+		}
+	}
+	switch typeName {
+	case "int", "int32", "uint8", "float64", "string", "bool":
+		return zeroValue(typeName)
+	}
+	return value
+}
@@ -107,12 +107,10 @@ func buildFile(filename string) {
 	}
 
 	// Parse the petitgo code
-	statements := parseProgram(string(content))
+	statements := mustParseProgram(filename, string(content))
 
 	// Generate ARM64 assembly directly (no more Go codegen)
-	generator := asmgen.NewAsmGenerator()
-	assembly := generator.Generate(statements)
-	runtimeCode := generator.GenerateRuntime()
+	assembly, runtimeCode := mustGenerate(statements)
 
 	// Write assembly to temporary file
 	asmFile := "/tmp/petitgo_temp.s"
@@ -179,12 +177,10 @@ func runFile(filename string) {
 		os.Exit(1)
 	}
 
-	statements := parseProgram(string(content))
+	statements := mustParseProgram(filename, string(content))
 
 	// Generate ARM64 assembly directly
-	generator := asmgen.NewAsmGenerator()
-	assembly := generator.Generate(statements)
-	runtimeCode := generator.GenerateRuntime()
+	assembly, runtimeCode := mustGenerate(statements)
 
 	// Create temporary files properly
 	tempDir := os.TempDir()
@@ -272,9 +268,10 @@ func runFile(filename string) {
 	}
 }
 
-// parseProgram parses a petitgo program and returns statements
-func parseProgram(source string) []ast.Statement {
-	s := scanner.NewScanner(source)
+// parseProgram parses a petitgo program and returns statements along with
+// any lexical or syntax errors found in it
+func parseProgram(filename, source string) ([]ast.Statement, []*scanner.Error) {
+	s := scanner.NewFileScanner(filename, source)
 	p := parser.NewParser(s)
 
 	var statements []ast.Statement
@@ -287,9 +284,36 @@ func parseProgram(source string) []ast.Statement {
 		statements = append(statements, stmt)
 	}
 
+	return statements, p.Errors()
+}
+
+// mustParseProgram parses a petitgo program, and prints the errors and exits
+// if it is not valid
+func mustParseProgram(filename, source string) []ast.Statement {
+	statements, errors := parseProgram(filename, source)
+	if len(errors) > 0 {
+		for _, err := range errors {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
 	return statements
 }
 
+// mustGenerate generates the assembly of a program and the runtime it
+// calls, and prints the type errors and exits if the program is not valid
+func mustGenerate(statements []ast.Statement) (assembly, runtime string) {
+	generator := asmgen.NewAsmGenerator()
+	assembly = generator.Generate(statements)
+	if errors := generator.Errors(); len(errors) > 0 {
+		for _, err := range errors {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+	return assembly, generator.GenerateRuntime()
+}
+
 // astFile parses a petitgo file and outputs the AST as JSON
 func astFile(filename string) {
 	// Read the petitgo source file
@@ -300,7 +324,7 @@ func astFile(filename string) {
 	}
 
 	// Parse the petitgo code
-	statements := parseProgram(string(content))
+	statements := mustParseProgram(filename, string(content))
 
 	// Convert AST to JSON using MarshalJSON methods
 	program := map[string]interface{}{
@@ -328,12 +352,10 @@ func asmFile(filename string) {
 	}
 
 	// Parse the petitgo code
-	statements := parseProgram(string(content))
+	statements := mustParseProgram(filename, string(content))
 
 	// Generate ARM64 assembly
-	generator := asmgen.NewAsmGenerator()
-	assembly := generator.Generate(statements)
-	runtime := generator.GenerateRuntime()
+	assembly, runtime := mustGenerate(statements)
 
 	fmt.Print(assembly)
 	fmt.Print(runtime)
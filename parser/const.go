@@ -0,0 +1,372 @@
+package parser
+
+import (
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/constant"
+	"github.com/yuya-takeyama/petitgo/token"
+)
+
+// parseConstStatement parses constant declarations: const x = 1,
+// const a, b int = 1, 2 or a group const ( A = iota; B; C )
+func (p *Parser) parseConstStatement() ast.Statement {
+	stmt := &ast.ConstStatement{Pos: p.currentToken.Pos}
+
+	// const
+	p.nextToken()
+
+	if p.currentToken.Type != token.LPAREN {
+		if spec := p.parseConstSpec(0, nil); spec != nil {
+			stmt.Specs = append(stmt.Specs, spec)
+		}
+		return stmt
+	}
+
+	// (
+	p.nextToken()
+
+	// 値を省略した行は直前の式を iota を変えて繰り返す
+	var last *ast.ConstSpec
+	for iota := 0; ; iota++ {
+		p.skipSemicolons()
+		if p.currentToken.Type == token.RPAREN || p.currentToken.Type == token.EOF {
+			break
+		}
+		spec := p.parseConstSpec(iota, last)
+		if spec == nil {
+			p.skipSpec()
+			continue
+		}
+		stmt.Specs = append(stmt.Specs, spec)
+		if spec.Values != nil {
+			last = spec
+		}
+
+		// 各行はセミコロンか ')' で終わる
+		if p.currentToken.Type != token.SEMICOLON && p.currentToken.Type != token.RPAREN {
+			p.errorExpected("';' or ')' after constant declaration")
+			p.skipSpec()
+		}
+	}
+
+	// )
+	p.expect(token.RPAREN, "')' at end of constant declaration")
+
+	return stmt
+}
+
+// parseConstSpec parses one line of a constant declaration with the given
+// value of iota. A line without values repeats the type and the
+// expressions of last, the previous line with values. It returns nil after
+// reporting an error.
+func (p *Parser) parseConstSpec(iota int, last *ast.ConstSpec) *ast.ConstSpec {
+	spec := &ast.ConstSpec{Iota: iota, Pos: p.currentToken.Pos}
+
+	// 定数名のリスト
+	for {
+		name := p.expectIdent("constant name")
+		if name == "" {
+			return nil
+		}
+		spec.Names = append(spec.Names, name)
+		if p.currentToken.Type != token.COMMA {
+			break
+		}
+		p.nextToken() // ',' を消費
+	}
+
+	// 型 (省略可能)
+	errorCount := len(p.errors)
+	if p.isTypeStart() {
+		spec.Type = p.parseType("constant type")
+		if spec.Type != nil && !constant.IsConstType(spec.Type.TypeName()) {
+			p.error(spec.Pos, "invalid constant type "+spec.Type.TypeName())
+		}
+	}
+
+	var values []ast.ASTNode
+	if p.currentToken.Type == token.ASSIGN && p.currentToken.Literal == "=" {
+		p.nextToken() // '=' を消費
+		p.iota = iota
+		values = p.parseExpressionList()
+		p.iota = -1
+		spec.Values = values
+	} else if last != nil && spec.Type == nil {
+		spec.Type = last.Type
+		values = last.Values
+	} else {
+		p.error(spec.Pos, "missing init expr for const declaration")
+		return nil
+	}
+
+	// 型や式のエラーは報告済み
+	if len(p.errors) > errorCount {
+		return nil
+	}
+
+	switch {
+	case len(values) < len(spec.Names):
+		p.error(spec.Pos, "missing init expr for const declaration")
+		return nil
+	case len(values) > len(spec.Names):
+		p.error(values[len(spec.Names)].Position(), "extra init expr")
+		return nil
+	}
+
+	for _, value := range values {
+		v, ok := p.constantOf(value, spec)
+		if !ok {
+			return nil
+		}
+		spec.Constants = append(spec.Constants, v)
+	}
+
+	// 定数のスコープは宣言の後から始まる
+	for i, name := range spec.Names {
+		p.declareConst(name, spec.Constants[i], spec.Pos)
+	}
+	return spec
+}
+
+// constantOf returns the value of a constant for the expression value of
+// a spec, converted to the type of the spec. The expressions repeated from
+// the previous line are evaluated again with the iota of this line.
+func (p *Parser) constantOf(value ast.ASTNode, spec *ast.ConstSpec) (constant.Value, bool) {
+	var v constant.Value
+	if spec.Values == nil {
+		var err error
+		if v, err = p.evalConst(value, spec.Iota); err != nil {
+			p.error(spec.Pos, err.Error())
+			return v, false
+		}
+	} else {
+		var ok bool
+		if v, ok = p.constOf(value); !ok {
+			p.error(value.Position(), "const initializer is not a constant")
+			return v, false
+		}
+	}
+
+	if spec.Type != nil {
+		var err error
+		if v, err = constant.Convert(v, spec.Type.TypeName()); err != nil {
+			pos := value.Position()
+			if spec.Values == nil {
+				pos = spec.Pos
+			}
+			p.error(pos, err.Error())
+			return v, false
+		}
+	}
+	return v, true
+}
+
+// evalConst evaluates a constant expression again with another value of
+// iota. Named constants keep the values they were declared with.
+func (p *Parser) evalConst(x ast.ASTNode, iota int) (constant.Value, error) {
+	switch x := x.(type) {
+	case *ast.ConstExpr:
+		if ident, ok := x.Expression.(*ast.VariableNode); ok {
+			if ident.Name == "iota" {
+				return constant.MakeInt64(int64(iota)), nil
+			}
+			return x.Value, nil
+		}
+		return p.evalConst(x.Expression, iota)
+	case *ast.BinaryOpNode:
+		left, err := p.evalConst(x.Left, iota)
+		if err != nil {
+			return left, err
+		}
+		right, err := p.evalConst(x.Right, iota)
+		if err != nil {
+			return right, err
+		}
+		return constant.BinaryOp(left, x.Operator, right)
+	case *ast.UnaryOpNode:
+		operand, err := p.evalConst(x.Operand, iota)
+		if err != nil {
+			return operand, err
+		}
+		return constant.UnaryOp(x.Operator, operand)
+	case *ast.CallNode:
+		if isConversion(x) {
+			arg, err := p.evalConst(x.Arguments[0], iota)
+			if err != nil {
+				return arg, err
+			}
+			return constant.Convert(arg, x.Function)
+		}
+	}
+	v, _ := p.constOf(x)
+	return v, nil
+}
+
+// constOf returns the value of a constant expression: a literal or a
+// ConstExpr. ok is false for other expressions.
+func (p *Parser) constOf(x ast.ASTNode) (v constant.Value, ok bool) {
+	switch x := x.(type) {
+	case *ast.ConstExpr:
+		return x.Value, true
+	case *ast.NumberNode:
+		if x.Literal == "" {
+			return constant.MakeInt64(int64(x.Value)), true
+		}
+		v, err := constant.MakeFromLiteral(x.Literal, token.INT)
+		return v, err == nil
+	case *ast.FloatNode:
+		// 指数が大きすぎるリテラルは報告済み
+		if x.Literal == "" {
+			return constant.MakeFloat64(x.Value), true
+		}
+		v, err := constant.MakeFromLiteral(x.Literal, token.FLOAT)
+		return v, err == nil
+	case *ast.RuneNode:
+		return constant.MakeRune(x.Value), true
+	case *ast.StringNode:
+		return constant.MakeString(x.Value), true
+	case *ast.BooleanNode:
+		return constant.MakeBool(x.Value), true
+	}
+	return v, false
+}
+
+// fold replaces a constant expression with a ConstExpr holding its exact
+// value, so that later stages need not know about constants nor evaluate
+// 1 << 62 * 2 / 4 with the overflows of int
+func (p *Parser) fold(x ast.ASTNode, v constant.Value) ast.ASTNode {
+	return &ast.ConstExpr{Expression: x, Value: v, Pos: x.Position()}
+}
+
+// foldBinary evaluates a binary operation on two constants, reporting
+// errors such as division by zero at the operator
+func (p *Parser) foldBinary(x *ast.BinaryOpNode, opPos token.Position) ast.ASTNode {
+	left, leftOk := p.constOf(x.Left)
+	right, rightOk := p.constOf(x.Right)
+	if !leftOk || !rightOk {
+		return x
+	}
+	v, err := constant.BinaryOp(left, x.Operator, right)
+	if err != nil {
+		p.error(opPos, err.Error())
+		return x
+	}
+	return p.fold(x, v)
+}
+
+// foldUnary evaluates a unary operation on a constant
+func (p *Parser) foldUnary(x *ast.UnaryOpNode) ast.ASTNode {
+	operand, ok := p.constOf(x.Operand)
+	if !ok {
+		return x
+	}
+	v, err := constant.UnaryOp(x.Operator, operand)
+	if err != nil {
+		p.error(x.Pos, err.Error())
+		return x
+	}
+	return p.fold(x, v)
+}
+
+// foldConversion evaluates a conversion of a constant such as uint8(300),
+// reporting values that do not fit the type
+func (p *Parser) foldConversion(call *ast.CallNode) ast.ASTNode {
+	if !isConversion(call) {
+		return call
+	}
+	arg, ok := p.constOf(call.Arguments[0])
+	// string(65) のような整数から文字列への変換は実行時に任せる
+	if !ok || call.Function == "string" && arg.Kind() != constant.String {
+		return call
+	}
+	v, err := constant.Convert(arg, call.Function)
+	if err != nil {
+		p.error(call.Arguments[0].Position(), err.Error())
+		return call
+	}
+	return p.fold(call, v)
+}
+
+// isConversion reports whether a call converts its argument to a basic type
+func isConversion(call *ast.CallNode) bool {
+	if call.Receiver != nil || call.Callee != nil || len(call.Arguments) != 1 {
+		return false
+	}
+	return constant.IsConstType(call.Function)
+}
+
+// convertConstant converts a constant to the type it is given: typeName, or
+// the default type of an untyped constant when typeName is "". A constant
+// of another type is replaced with a ConstExpr holding the converted value,
+// so that var i int = 3.0 stores the int 3, and one that does not fit the
+// type is reported. Other expressions are returned as they are.
+func (p *Parser) convertConstant(x ast.ASTNode, typeName string) ast.ASTNode {
+	v, ok := p.constOf(x)
+	if !ok {
+		return x
+	}
+	if typeName == "" {
+		typeName = constant.DefaultType(v)
+	} else if !constant.IsConstType(typeName) {
+		return x
+	}
+	converted, err := constant.Convert(v, typeName)
+	if err != nil {
+		p.error(x.Position(), err.Error())
+		return x
+	}
+	// 既定の型のままなら式はそのまま使える
+	if constant.DefaultType(v) == constant.CanonicalType(typeName) {
+		return x
+	}
+	if c, ok := x.(*ast.ConstExpr); ok {
+		x = c.Expression
+	}
+	return &ast.ConstExpr{Expression: x, Value: converted, Pos: x.Position()}
+}
+
+// openScope starts a new scope for constants and returns a function that
+// ends it
+func (p *Parser) openScope() (close func()) {
+	p.constScopes = append(p.constScopes, map[string]*constant.Value{})
+	return func() { p.constScopes = p.constScopes[:len(p.constScopes)-1] }
+}
+
+// lookupConst returns the value of the constant a name refers to, or nil
+// if it does not refer to a constant
+func (p *Parser) lookupConst(name string) *constant.Value {
+	for i := len(p.constScopes) - 1; i >= 0; i-- {
+		if v, exists := p.constScopes[i][name]; exists {
+			return v
+		}
+	}
+	return nil
+}
+
+// declareConst declares a constant in the current scope
+func (p *Parser) declareConst(name string, v constant.Value, pos token.Position) {
+	if name == "_" {
+		return
+	}
+	scope := p.constScopes[len(p.constScopes)-1]
+	if scope[name] != nil {
+		p.error(pos, name+" redeclared in this block")
+	}
+	scope[name] = &v
+}
+
+// declareVar records a variable declared in the current scope, which hides
+// a constant of the same name in an outer scope
+func (p *Parser) declareVar(name string) {
+	if p.lookupConst(name) != nil {
+		p.constScopes[len(p.constScopes)-1][name] = nil
+	}
+}
+
+// declareParameters declares the parameters and named results of a function
+func (p *Parser) declareParameters(parameters, results []ast.Parameter) {
+	for _, param := range append(append([]ast.Parameter{}, parameters...), results...) {
+		if param.Name != "" {
+			p.declareVar(param.Name)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/token"
+)
+
+// parseDeclGroup parses a parenthesized group of declarations after the
+// keyword at pos, parsing each line with parseSpec: var ( x int; y = 2 ),
+// import ( "fmt"; "os" ) or type ( ... )
+func (p *Parser) parseDeclGroup(keyword string, pos token.Position, parseSpec func(pos token.Position) ast.Statement) ast.Statement {
+	group := &ast.DeclGroup{Keyword: keyword, Pos: pos}
+
+	// (
+	p.nextToken()
+
+	for {
+		p.skipSemicolons()
+		if p.currentToken.Type == token.RPAREN || p.currentToken.Type == token.EOF {
+			break
+		}
+		errorCount := len(p.errors)
+		group.Decls = append(group.Decls, parseSpec(p.currentToken.Pos))
+
+		// 各行はセミコロンか ')' で終わる。エラーなら次の行まで読み飛ばす
+		if len(p.errors) > errorCount {
+			p.skipSpec()
+		} else if p.currentToken.Type != token.SEMICOLON && p.currentToken.Type != token.RPAREN {
+			p.errorExpected("';' or ')' after " + keyword + " declaration")
+			p.skipSpec()
+		}
+	}
+
+	// )
+	p.expect(token.RPAREN, "')' at end of "+keyword+" declaration")
+
+	return group
+}
+
+// skipSpec skips the rest of a line of a declaration group after an error
+func (p *Parser) skipSpec() {
+	for p.currentToken.Type != token.SEMICOLON && p.currentToken.Type != token.RPAREN &&
+		p.currentToken.Type != token.EOF {
+		p.nextToken()
+	}
+}
+
+// parseVarStatement parses variable declarations: var x int, var x = 1,
+// var a, b int = 1, 2 or a group var ( x int; y = 2 )
+func (p *Parser) parseVarStatement() ast.Statement {
+	pos := p.currentToken.Pos
+
+	// var
+	p.nextToken()
+
+	if p.currentToken.Type == token.LPAREN {
+		return p.parseDeclGroup("var", pos, p.parseVarSpec)
+	}
+	return p.parseVarSpec(pos)
+}
+
+// parseVarSpec parses one line of a variable declaration. The type, the
+// values or both may be given; a single name yields a VarStatement and
+// several names a TupleVarStatement.
+func (p *Parser) parseVarSpec(pos token.Position) ast.Statement {
+	// 変数名のリスト
+	namePos := p.currentToken.Pos
+	var names []string
+	for {
+		name := p.expectIdent("variable name")
+		if name == "" {
+			return &ast.VarStatement{Pos: pos}
+		}
+		names = append(names, name)
+		if p.currentToken.Type != token.COMMA {
+			break
+		}
+		p.nextToken() // ',' を消費
+	}
+
+	// 型 (値があれば省略可能)
+	var t ast.Type
+	if p.isTypeStart() {
+		t = p.parseType("type name")
+	}
+
+	// = 式のリスト (型があれば省略可能で、ゼロ値になる)
+	var values []ast.ASTNode
+	if p.currentToken.Type == token.ASSIGN && p.currentToken.Literal == "=" {
+		p.nextToken()
+		values = p.parseExpressionList()
+		if len(values) != len(names) && (len(values) > 1 || !isMultiValued(values[0], len(names))) {
+			p.error(namePos, "assignment mismatch: "+countOf(len(names), "variable")+" but "+countOf(len(values), "value"))
+		} else if len(values) == len(names) {
+			for i, value := range values {
+				values[i] = p.convertConstant(value, ast.TypeName(t))
+			}
+		}
+	} else if t == nil {
+		p.errorExpected("type or '=' after variable name")
+	}
+
+	// 変数のスコープは宣言の後から始まる
+	for _, name := range names {
+		p.declareVar(name)
+	}
+
+	if len(names) > 1 {
+		return &ast.TupleVarStatement{
+			Names:  names,
+			Type:   t,
+			Values: values,
+			Pos:    pos,
+		}
+	}
+	var value ast.ASTNode
+	if len(values) > 0 {
+		value = values[0]
+	}
+	return &ast.VarStatement{
+		Name:  names[0],
+		Type:  t,
+		Value: value,
+		Pos:   pos,
+	}
+}
@@ -0,0 +1,302 @@
+package parser
+
+import (
+	"strconv"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/token"
+)
+
+// parseLabeledStatement parses a statement with a label: outer: for { ... }
+func (p *Parser) parseLabeledStatement() ast.Statement {
+	stmt := &ast.LabeledStatement{Label: p.currentToken.Literal, Pos: p.currentToken.Pos}
+
+	// ラベルと ':' を消費
+	p.nextToken()
+	p.nextToken()
+
+	// '}' の直前や ';' の前のラベルは空文に付く
+	switch p.currentToken.Type {
+	case token.RBRACE, token.SEMICOLON, token.EOF:
+		stmt.Statement = &ast.EmptyStatement{Pos: p.currentToken.Pos}
+	default:
+		stmt.Statement = p.parseStatement()
+	}
+	return stmt
+}
+
+// parseBranchLabel parses the optional label after break and continue
+func (p *Parser) parseBranchLabel() string {
+	if p.currentToken.Type != token.IDENT {
+		return ""
+	}
+	label := p.currentToken.Literal
+	p.nextToken()
+	return label
+}
+
+func (p *Parser) parseGotoStatement() ast.Statement {
+	pos := p.currentToken.Pos
+
+	// goto
+	p.nextToken()
+	return &ast.GotoStatement{Label: p.expectIdent("label"), Pos: pos}
+}
+
+// branchChecker checks the labels and the break, continue and goto
+// statements of a function body. Labels belong to the function: each is
+// declared once and must be used. break and continue with a label refer to
+// an enclosing labeled statement, and a goto may neither jump into a block
+// nor over a variable declaration of the block of its label.
+type branchChecker struct {
+	p      *Parser
+	labels map[string]*ast.LabeledStatement
+	order  []*ast.LabeledStatement // labels in source order
+	used   map[string]bool
+}
+
+// labelBlock is a block of statements being checked
+type labelBlock struct {
+	parent *labelBlock
+	// labeled is the labeled statement the block is the body of, if any
+	labeled *ast.LabeledStatement
+	// labels are the labels declared so far in the block
+	labels map[string]bool
+	// canBreak and canContinue report whether the block is inside a
+	// statement a break or a continue without label applies to
+	canBreak, canContinue bool
+}
+
+// checkBranches checks the branch statements of a function body
+func (p *Parser) checkBranches(body []ast.Statement) {
+	c := &branchChecker{
+		p:      p,
+		labels: make(map[string]*ast.LabeledStatement),
+		used:   make(map[string]bool),
+	}
+	c.collect(body)
+
+	// Forward jumps left over at the function level have no label in an
+	// enclosing block
+	for _, jump := range c.block(nil, nil, body, false, false) {
+		if _, exists := c.labels[jump.Label]; exists {
+			p.error(jump.Pos, "goto "+jump.Label+" jumps into block")
+			c.used[jump.Label] = true
+		} else {
+			p.error(jump.Pos, "label "+jump.Label+" not declared")
+		}
+	}
+
+	for _, stmt := range c.order {
+		if !c.used[stmt.Label] {
+			p.error(stmt.Pos, "label "+stmt.Label+" declared and not used")
+		}
+	}
+}
+
+// collect records the labels of a statement list and the blocks nested in it
+func (c *branchChecker) collect(list []ast.Statement) {
+	for _, stmt := range list {
+		for stmt != nil {
+			labeled, ok := stmt.(*ast.LabeledStatement)
+			if !ok {
+				break
+			}
+			if labeled.Label != "_" {
+				if _, exists := c.labels[labeled.Label]; exists {
+					c.p.error(labeled.Pos, "label "+labeled.Label+" already declared")
+				} else {
+					c.labels[labeled.Label] = labeled
+					c.order = append(c.order, labeled)
+				}
+			}
+			stmt = labeled.Statement
+		}
+		for _, block := range nestedBlocks(stmt) {
+			c.collect(block.Statements)
+		}
+	}
+}
+
+// nestedBlocks returns the blocks directly nested in a statement
+func nestedBlocks(stmt ast.Statement) []*ast.BlockStatement {
+	var blocks []*ast.BlockStatement
+	switch s := stmt.(type) {
+	case *ast.BlockStatement:
+		blocks = append(blocks, s)
+	case *ast.IfStatement:
+		blocks = append(blocks, s.ThenBlock, s.ElseBlock)
+	case *ast.ForStatement:
+		blocks = append(blocks, s.Body)
+	case *ast.RangeStatement:
+		blocks = append(blocks, s.Body)
+	case *ast.SwitchStatement:
+		for _, clause := range s.Cases {
+			blocks = append(blocks, clause.Body)
+		}
+	case *ast.TypeSwitchStatement:
+		for _, clause := range s.Cases {
+			blocks = append(blocks, clause.Body)
+		}
+	}
+	// ブロックが省略された文 (構文エラーの後など) を除く
+	n := 0
+	for _, block := range blocks {
+		if block != nil {
+			blocks[n] = block
+			n++
+		}
+	}
+	return blocks[:n]
+}
+
+// block checks a statement list and returns its forward gotos whose labels
+// are not declared in it
+func (c *branchChecker) block(parent *labelBlock, labeled *ast.LabeledStatement, list []ast.Statement, canBreak, canContinue bool) []*ast.GotoStatement {
+	b := &labelBlock{parent: parent, labeled: labeled, labels: make(map[string]bool), canBreak: canBreak, canContinue: canContinue}
+
+	// forward are the gotos waiting for their labels, and overDecl those of
+	// them that were pending at the last variable declaration
+	var forward, overDecl []*ast.GotoStatement
+	declLine := 0
+	declare := func(pos token.Position) {
+		declLine = pos.Line
+		overDecl = append([]*ast.GotoStatement(nil), forward...)
+	}
+	nested := func(labeled *ast.LabeledStatement, block *ast.BlockStatement, canBreak, canContinue bool) {
+		if block != nil {
+			forward = append(forward, c.block(b, labeled, block.Statements, canBreak, canContinue)...)
+		}
+	}
+
+	var check func(stmt ast.Statement, labeled *ast.LabeledStatement)
+	check = func(stmt ast.Statement, labeled *ast.LabeledStatement) {
+		switch s := stmt.(type) {
+		case *ast.VarStatement, *ast.TupleVarStatement, *ast.AssignStatement:
+			declare(s.Position())
+		case *ast.TupleAssignStatement:
+			if s.Define {
+				declare(s.Pos)
+			}
+		case *ast.DeclGroup:
+			if s.Keyword == "var" && len(s.Decls) > 0 {
+				declare(s.Pos)
+			}
+		case *ast.LabeledStatement:
+			if s.Label != "_" {
+				b.labels[s.Label] = true
+				var pending []*ast.GotoStatement
+				for _, jump := range forward {
+					if jump.Label != s.Label {
+						pending = append(pending, jump)
+						continue
+					}
+					c.used[s.Label] = true
+					if containsJump(overDecl, jump) {
+						c.p.error(jump.Pos, "goto "+jump.Label+" jumps over variable declaration at line "+strconv.Itoa(declLine))
+					}
+				}
+				forward = pending
+			}
+			check(s.Statement, s)
+		case *ast.BreakStatement:
+			if s.Label == "" {
+				if !b.canBreak {
+					c.p.error(s.Pos, "break is not in a loop, switch, or select")
+				}
+				return
+			}
+			c.branch(b, s.Label, s.Pos, "break")
+		case *ast.ContinueStatement:
+			if s.Label == "" {
+				if !b.canContinue {
+					c.p.error(s.Pos, "continue is not in a loop")
+				}
+				return
+			}
+			c.branch(b, s.Label, s.Pos, "continue")
+		case *ast.GotoStatement:
+			if b.declares(s.Label) {
+				// 後方へのジャンプ
+				c.used[s.Label] = true
+				return
+			}
+			forward = append(forward, s)
+		case *ast.BlockStatement:
+			nested(labeled, s, b.canBreak, b.canContinue)
+		case *ast.IfStatement:
+			nested(labeled, s.ThenBlock, b.canBreak, b.canContinue)
+			nested(nil, s.ElseBlock, b.canBreak, b.canContinue)
+		case *ast.ForStatement:
+			nested(labeled, s.Body, true, true)
+		case *ast.RangeStatement:
+			nested(labeled, s.Body, true, true)
+		case *ast.SwitchStatement:
+			for _, clause := range s.Cases {
+				nested(labeled, clause.Body, true, b.canContinue)
+			}
+		case *ast.TypeSwitchStatement:
+			for _, clause := range s.Cases {
+				nested(labeled, clause.Body, true, b.canContinue)
+			}
+		}
+	}
+	for _, stmt := range list {
+		check(stmt, nil)
+	}
+	return forward
+}
+
+// branch checks break and continue with a label, which must be the label of
+// an enclosing for statement, or for break of an enclosing switch
+func (c *branchChecker) branch(b *labelBlock, label string, pos token.Position, keyword string) {
+	if _, exists := c.labels[label]; !exists {
+		c.p.error(pos, "label "+label+" not declared")
+		return
+	}
+	c.used[label] = true
+
+	valid := false
+	if target := b.enclosing(label); target != nil {
+		switch target.Statement.(type) {
+		case *ast.ForStatement, *ast.RangeStatement:
+			valid = true
+		case *ast.SwitchStatement, *ast.TypeSwitchStatement:
+			valid = keyword == "break"
+		}
+	}
+	if !valid {
+		c.p.error(pos, "invalid "+keyword+" label "+label)
+	}
+}
+
+// declares reports whether a label is declared before in the block or an
+// enclosing one, which a goto jumps back to
+func (b *labelBlock) declares(label string) bool {
+	for ; b != nil; b = b.parent {
+		if b.labels[label] {
+			return true
+		}
+	}
+	return false
+}
+
+// enclosing returns the labeled statement with the label whose body
+// encloses the block, or nil
+func (b *labelBlock) enclosing(label string) *ast.LabeledStatement {
+	for ; b != nil; b = b.parent {
+		if b.labeled != nil && b.labeled.Label == label {
+			return b.labeled
+		}
+	}
+	return nil
+}
+
+func containsJump(jumps []*ast.GotoStatement, jump *ast.GotoStatement) bool {
+	for _, j := range jumps {
+		if j == jump {
+			return true
+		}
+	}
+	return false
+}
@@ -1,63 +1,275 @@
 package parser
 
 import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
 	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/constant"
 	"github.com/yuya-takeyama/petitgo/scanner"
 	"github.com/yuya-takeyama/petitgo/token"
 )
 
+type (
+	// prefixParseFn parses an expression starting with the current token
+	prefixParseFn func() ast.ASTNode
+	// postfixParseFn parses the operator at the current token applied to x
+	postfixParseFn func(x ast.ASTNode) ast.ASTNode
+)
+
 // Parser struct for parsing tokens into AST
 type Parser struct {
 	scanner      *scanner.Scanner
 	currentToken token.TokenInfo
+	peekToken    token.TokenInfo
+	errors       []*scanner.Error
+
+	prefixParseFns  map[token.Token]prefixParseFn
+	postfixParseFns map[token.Token]postfixParseFn
+
+	// noStructLiteral is set while parsing if and for headers, where an
+	// identifier followed by '{' is followed by the block, not a struct literal
+	noStructLiteral bool
+
+	// inSwitchHeader is set while parsing a switch header, the only place
+	// where the x.(type) guard of a type switch may appear
+	inSwitchHeader bool
+
+	// inForHeader is set while parsing the first statement of a for header,
+	// the only place where a range clause may appear
+	inForHeader bool
+
+	// blockDepth counts the enclosing blocks. Inside a block, a statement
+	// starting with func is a function literal rather than a declaration.
+	blockDepth int
+
+	// stmtDepth counts the statements being parsed, so that the branches
+	// of a statement outside functions are checked once it is complete
+	stmtDepth int
+
+	// constScopes maps the names declared in each enclosing scope to their
+	// constant values, innermost last. A nil entry is a variable hiding a
+	// constant of an outer scope.
+	constScopes []map[string]*constant.Value
+
+	// iota is the value of iota in the constant declaration being parsed,
+	// or -1 outside constant declarations
+	iota int
 }
 
 func NewParser(s *scanner.Scanner) *Parser {
-	p := &Parser{scanner: s}
+	p := &Parser{
+		scanner:         s,
+		prefixParseFns:  make(map[token.Token]prefixParseFn),
+		postfixParseFns: make(map[token.Token]postfixParseFn),
+		constScopes:     []map[string]*constant.Value{{}},
+		iota:            -1,
+	}
+
+	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
+	p.registerPrefix(token.IMAG, p.parseImaginaryLiteral)
+	p.registerPrefix(token.CHAR, p.parseRuneLiteral)
+	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.TRUE, p.parseBooleanLiteral)
+	p.registerPrefix(token.FALSE, p.parseBooleanLiteral)
+	p.registerPrefix(token.IDENT, p.parseIdentifier)
+	p.registerPrefix(token.LPAREN, p.parseParenExpr)
+	p.registerPrefix(token.LBRACK, p.parseArrayOrSliceLiteral)
+	p.registerPrefix(token.FUNC, p.parseFuncLiteral)
+	p.registerPrefix(token.MAP, p.parseMapLiteral)
+	p.registerPrefix(token.RANGE, p.parseRangeExpr)
+	p.registerPrefix(token.AND, p.parseAddressExpr)
+	p.registerPrefix(token.MUL, p.parseDerefExpr)
+	for _, op := range []token.Token{token.SUB, token.ADD, token.NOT, token.XOR} {
+		p.registerPrefix(op, p.parsePrefixExpr)
+	}
+
+	p.registerPostfix(token.PERIOD, p.parseSelector)
+	p.registerPostfix(token.LBRACK, p.parseIndexExpr)
+	p.registerPostfix(token.LPAREN, p.parseCallExpr)
+	p.registerPostfix(token.LBRACE, p.parseCompositeLiteral)
+
+	p.nextToken()
 	p.nextToken()
 	return p
 }
 
+// setNoStructLiteral changes whether struct literals are disabled and returns
+// a function that restores the previous setting
+func (p *Parser) setNoStructLiteral(disabled bool) (restore func()) {
+	old := p.noStructLiteral
+	p.noStructLiteral = disabled
+	return func() { p.noStructLiteral = old }
+}
+
+// Errors returns the lexical and syntax errors found so far, sorted by position
+func (p *Parser) Errors() []*scanner.Error {
+	errors := append(append([]*scanner.Error{}, p.scanner.Errors()...), p.errors...)
+	sort.SliceStable(errors, func(i, j int) bool {
+		return errors[i].Pos.Offset < errors[j].Pos.Offset
+	})
+	return errors
+}
+
+// error records a syntax error at pos. Only the first error at a position is
+// kept, since the parser may reach the same bad token more than once.
+func (p *Parser) error(pos token.Position, message string) {
+	if n := len(p.errors); n > 0 && p.errors[n-1].Pos.Offset == pos.Offset {
+		return
+	}
+	p.errors = append(p.errors, &scanner.Error{Pos: pos, Message: message})
+}
+
+// errorExpected records an error at the current token saying what was
+// expected instead. Illegal tokens are already reported by the scanner.
+func (p *Parser) errorExpected(what string) {
+	if p.currentToken.Type == token.ILLEGAL {
+		return
+	}
+	p.error(p.currentToken.Pos, "unexpected "+tokenText(p.currentToken)+", expected "+what)
+}
+
+// expect consumes the current token if it is tok, and records an error
+// otherwise
+func (p *Parser) expect(tok token.Token, what string) bool {
+	if p.currentToken.Type != tok {
+		p.errorExpected(what)
+		return false
+	}
+	p.nextToken()
+	return true
+}
+
+// expectIdent returns the literal of the current identifier and consumes it,
+// or records an error and returns "" if the current token is not one
+func (p *Parser) expectIdent(what string) string {
+	if p.currentToken.Type != token.IDENT {
+		p.errorExpected(what)
+		return ""
+	}
+	name := p.currentToken.Literal
+	p.nextToken()
+	return name
+}
+
+// synchronize skips tokens after a syntax error until the start of the next
+// statement, so that one mistake produces one error. Blocks are skipped as
+// a whole, and the closing brace of the enclosing block is kept.
+func (p *Parser) synchronize(start int) {
+	// 少なくとも 1 トークンは読み進める
+	if p.currentToken.Pos.Offset == start && p.currentToken.Type != token.EOF {
+		p.nextToken()
+	}
+
+	depth := 0
+	for {
+		switch p.currentToken.Type {
+		case token.EOF:
+			return
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			if depth == 0 {
+				return
+			}
+			depth--
+			if depth == 0 {
+				p.nextToken()
+				if p.currentToken.Type == token.SEMICOLON {
+					p.nextToken()
+				}
+				return
+			}
+		case token.SEMICOLON:
+			if depth == 0 {
+				p.nextToken()
+				return
+			}
+		case token.IF, token.FOR, token.SWITCH, token.TYPE, token.CONST, token.FUNC, token.RETURN,
+			token.BREAK, token.CONTINUE, token.FALLTHROUGH, token.GOTO, token.DEFER, token.PACKAGE, token.IMPORT,
+			token.CASE, token.DEFAULT:
+			if depth == 0 {
+				return
+			}
+		}
+		p.nextToken()
+	}
+}
+
 func (p *Parser) nextToken() {
+	p.currentToken = p.peekToken
 	for {
-		p.currentToken = p.scanner.NextToken()
+		p.peekToken = p.scanner.NextToken()
 		// Skip comments
-		if p.currentToken.Type != token.COMMENT {
+		if p.peekToken.Type != token.COMMENT {
 			break
 		}
 	}
 }
 
+// skipSemicolons consumes empty statements
+func (p *Parser) skipSemicolons() {
+	for p.currentToken.Type == token.SEMICOLON {
+		p.nextToken()
+	}
+}
+
+// ParseStatement parses one statement together with its terminator.
+// A statement ends with a semicolon (explicit or inserted at a newline),
+// or right before a closing brace or the end of input.
 func (p *Parser) ParseStatement() ast.Statement {
+	p.skipSemicolons()
+
 	// Check for EOF first
 	if p.currentToken.Type == token.EOF {
 		return nil
 	}
 
+	start := p.currentToken.Pos.Offset
+	errorCount := len(p.errors)
+	p.stmtDepth++
+	stmt := p.parseStatement()
+	p.stmtDepth--
+
+	recover := len(p.errors) > errorCount
+	switch p.currentToken.Type {
+	case token.SEMICOLON:
+		if !recover {
+			p.nextToken()
+		}
+	case token.RBRACE, token.EOF:
+		// '}' や EOF の直前ではセミコロンを省略できる
+	default:
+		p.errorExpected("end of statement")
+		recover = true
+	}
+
+	// エラーの後は次の文まで読み飛ばす
+	if recover {
+		p.synchronize(start)
+	}
+
+	// 関数の外の文 (REPL の入力) はそれぞれを関数本体のように検査する
+	if _, isFunc := stmt.(*ast.FuncStatement); p.stmtDepth == 0 && stmt != nil && !isFunc {
+		p.checkBranches([]ast.Statement{stmt})
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseStatement() ast.Statement {
 	switch p.currentToken.Type {
 	case token.IDENT:
 		if p.currentToken.Literal == "var" {
 			return p.parseVarStatement()
 		}
-		// Check what follows the identifier
-		nextScanner := scanner.NewScanner(p.scanner.Input()[p.scanner.Position():])
-		nextToken := nextScanner.NextToken()
-
-		if nextToken.Type == token.ASSIGN {
-			if nextToken.Literal == ":=" {
-				return p.parseAssignStatement()
-			} else {
-				return p.parseReassignStatement()
-			}
-		} else if nextToken.Type == token.INC {
-			return p.parseIncStatement()
-		} else if nextToken.Type == token.DEC {
-			return p.parseDecStatement()
-		} else if nextToken.Type == token.ADD_ASSIGN || nextToken.Type == token.SUB_ASSIGN ||
-			nextToken.Type == token.MUL_ASSIGN || nextToken.Type == token.QUO_ASSIGN {
-			return p.parseCompoundAssignStatement()
+		if p.peekToken.Type == token.COLON {
+			return p.parseLabeledStatement()
 		}
-		return p.parseExpressionStatement()
+		return p.parseSimpleStatement()
 	case token.IF:
 		return p.parseIfStatement()
 	case token.FOR:
@@ -66,11 +278,23 @@ func (p *Parser) ParseStatement() ast.Statement {
 		return p.parseSwitchStatement()
 	case token.TYPE:
 		return p.parseTypeStatement()
+	case token.CONST:
+		return p.parseConstStatement()
 	case token.BREAK:
 		return p.parseBreakStatement()
 	case token.CONTINUE:
 		return p.parseContinueStatement()
+	case token.FALLTHROUGH:
+		return p.parseFallthroughStatement()
+	case token.GOTO:
+		return p.parseGotoStatement()
+	case token.DEFER:
+		return p.parseDeferStatement()
 	case token.FUNC:
+		if p.blockDepth > 0 {
+			// 関数の中では関数リテラルの式文: func() { ... }()
+			return p.parseExpressionStatement()
+		}
 		return p.parseFuncStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
@@ -86,32 +310,19 @@ func (p *Parser) ParseStatement() ast.Statement {
 	}
 }
 
-func (p *Parser) parseVarStatement() ast.Statement {
-	// var
-	p.nextToken()
-
-	// 変数名
-	name := p.currentToken.Literal
-	p.nextToken()
-
-	// 型名
-	typeName := p.currentToken.Literal
-	p.nextToken()
-
-	// =
-	p.nextToken()
-
-	// 式
-	value := p.ParseExpression()
-
-	return &ast.VarStatement{
-		Name:     name,
-		TypeName: typeName,
-		Value:    value,
+// parseSimpleStatement parses the statements allowed in for clauses:
+// assignments, increments/decrements and expression statements
+func (p *Parser) parseSimpleStatement() ast.Statement {
+	// 変数の宣言は識別子で始まる
+	if p.currentToken.Type == token.IDENT && p.peekToken.Type == token.ASSIGN && p.peekToken.Literal == ":=" {
+		return p.parseAssignStatement()
 	}
+	return p.parseExpressionStatement()
 }
 
 func (p *Parser) parseAssignStatement() ast.Statement {
+	pos := p.currentToken.Pos
+
 	// 変数名
 	name := p.currentToken.Literal
 	p.nextToken()
@@ -120,18 +331,19 @@ func (p *Parser) parseAssignStatement() ast.Statement {
 	p.nextToken()
 
 	// 式
-	value := p.ParseExpression()
+	value := p.convertConstant(p.ParseExpression(), "")
+	p.declareVar(name)
 
 	return &ast.AssignStatement{
 		Name:  name,
 		Value: value,
+		Pos:   pos,
 	}
 }
 
-func (p *Parser) parseReassignStatement() ast.Statement {
-	// 変数名
-	name := p.currentToken.Literal
-	p.nextToken()
+// parseReassignStatement parses the rest of target = value
+func (p *Parser) parseReassignStatement(target ast.ASTNode, pos token.Position) ast.Statement {
+	p.checkAssignable(target)
 
 	// =
 	p.nextToken()
@@ -140,41 +352,147 @@ func (p *Parser) parseReassignStatement() ast.Statement {
 	value := p.ParseExpression()
 
 	return &ast.ReassignStatement{
-		Name:  name,
-		Value: value,
+		Target: target,
+		Value:  value,
+		Pos:    pos,
 	}
 }
 
-func (p *Parser) parseIncStatement() ast.Statement {
-	// 変数名
-	name := p.currentToken.Literal
-	p.nextToken()
+// checkAssignable reports an assignment to something other than a
+// variable, a field, an element, a map entry or a value through a pointer,
+// such as a constant or the result of a call
+func (p *Parser) checkAssignable(x ast.ASTNode) {
+	switch x := x.(type) {
+	case nil:
+		// 式のエラーは報告済み
+	case *ast.VariableNode, *ast.IndexAccess, *ast.FieldAccessNode, *ast.DerefNode:
+	case *ast.ConstExpr:
+		name := "value"
+		if ident, ok := x.Expression.(*ast.VariableNode); ok {
+			name = ident.Name
+		}
+		p.error(x.Pos, "cannot assign to "+name+" (neither addressable nor a map index expression)")
+	default:
+		p.error(x.Position(), "cannot assign to value (neither addressable nor a map index expression)")
+	}
+}
+
+// parseTupleAssignStatement parses the rest of an assignment of several
+// values, whose first target has been parsed: a, b := 1, 2 or
+// xs[i], xs[j] = xs[j], xs[i]
+func (p *Parser) parseTupleAssignStatement(first ast.ASTNode, pos token.Position) ast.Statement {
+	// 代入先のリスト
+	targets := []ast.ASTNode{first}
+	positions := []token.Position{pos}
+	for p.currentToken.Type == token.COMMA {
+		p.nextToken() // ',' を消費
+		positions = append(positions, p.currentToken.Pos)
+		targets = append(targets, p.ParseExpression())
+	}
+
+	// := or =
+	define := p.currentToken.Literal == ":="
+	if !p.expect(token.ASSIGN, "':=' or '=' after assignment targets") {
+		return &ast.TupleAssignStatement{Targets: targets, Pos: pos}
+	}
+
+	// 式のリスト (右辺が一つなら複数の値を返す呼び出しか comma-ok 形式)
+	values := p.parseExpressionList()
+	if len(values) != len(targets) && (len(values) > 1 || !isMultiValued(values[0], len(targets))) {
+		p.error(pos, "assignment mismatch: "+countOf(len(targets), "variable")+" but "+countOf(len(values), "value"))
+	}
+	for i, target := range targets {
+		if define {
+			targets[i] = p.declareTarget(target, positions[i])
+		} else {
+			p.checkAssignable(target)
+		}
+	}
+	if define && len(values) == len(targets) {
+		for i, value := range values {
+			values[i] = p.convertConstant(value, "")
+		}
+	}
+
+	return &ast.TupleAssignStatement{
+		Targets: targets,
+		Define:  define,
+		Values:  values,
+		Pos:     pos,
+	}
+}
+
+// declareTarget declares the variable a target of := at pos names. A
+// constant of an outer scope is shadowed by the new variable.
+func (p *Parser) declareTarget(target ast.ASTNode, pos token.Position) ast.ASTNode {
+	if x, ok := target.(*ast.ConstExpr); ok {
+		target = x.Expression
+	}
+	variable, ok := target.(*ast.VariableNode)
+	if !ok {
+		if target != nil {
+			p.error(pos, "non-name on left side of :=")
+		}
+		return target
+	}
+	p.declareVar(variable.Name)
+	return variable
+}
+
+// isMultiValued reports whether x alone can be assigned to n variables:
+// calls of functions with several results, comma-ok type assertions,
+// comma-ok index expressions on maps and range clauses
+func isMultiValued(x ast.ASTNode, n int) bool {
+	switch x := x.(type) {
+	case *ast.CallNode:
+		return true
+	case *ast.UnaryOpNode:
+		return x.Operator == token.RANGE
+	case *ast.TypeAssertNode:
+		return x.Type != "" && n == 2
+	case *ast.IndexAccess:
+		return n == 2
+	}
+	return false
+}
+
+// countOf formats a count with a noun: "1 value", "2 values"
+func countOf(n int, noun string) string {
+	if n != 1 {
+		noun += "s"
+	}
+	return strconv.Itoa(n) + " " + noun
+}
+
+// parseIncStatement parses the rest of target++
+func (p *Parser) parseIncStatement(target ast.ASTNode, pos token.Position) ast.Statement {
+	p.checkAssignable(target)
 
 	// ++
 	p.nextToken()
 
 	return &ast.IncStatement{
-		Name: name,
+		Target: target,
+		Pos:    pos,
 	}
 }
 
-func (p *Parser) parseDecStatement() ast.Statement {
-	// 変数名
-	name := p.currentToken.Literal
-	p.nextToken()
+// parseDecStatement parses the rest of target--
+func (p *Parser) parseDecStatement(target ast.ASTNode, pos token.Position) ast.Statement {
+	p.checkAssignable(target)
 
 	// --
 	p.nextToken()
 
 	return &ast.DecStatement{
-		Name: name,
+		Target: target,
+		Pos:    pos,
 	}
 }
 
-func (p *Parser) parseCompoundAssignStatement() ast.Statement {
-	// 変数名
-	name := p.currentToken.Literal
-	p.nextToken()
+// parseCompoundAssignStatement parses the rest of target op= value
+func (p *Parser) parseCompoundAssignStatement(target ast.ASTNode, pos token.Position) ast.Statement {
+	p.checkAssignable(target)
 
 	// compound operator (+=, -=, etc.)
 	operator := p.currentToken.Type
@@ -184,268 +502,703 @@ func (p *Parser) parseCompoundAssignStatement() ast.Statement {
 	value := p.ParseExpression()
 
 	return &ast.CompoundAssignStatement{
-		Name:     name,
+		Target:   target,
 		Operator: operator,
 		Value:    value,
+		Pos:      pos,
 	}
 }
 
+// parseSwitchStatement parses switch statements:
+// switch [init;] [value] { case x, y: ... default: ... }
 func (p *Parser) parseSwitchStatement() ast.Statement {
-	// switch
-	p.nextToken()
+	pos := p.currentToken.Pos
+	defer p.openScope()()
 
-	// value to switch on - use simple identifier for now to avoid ParseExpression issues
-	if p.currentToken.Type != token.IDENT {
-		return &ast.SwitchStatement{}
-	}
-	value := &ast.VariableNode{Name: p.currentToken.Literal}
+	// switch
 	p.nextToken()
 
-	// {
+	// ヘッダー内の '{' は switch 本体の開始なので struct literal として扱わない
+	restore := p.setNoStructLiteral(true)
+	p.inSwitchHeader = true
+	var init, first ast.Statement
+	var value ast.ASTNode
 	if p.currentToken.Type != token.LBRACE {
-		return &ast.SwitchStatement{}
-	}
-	p.nextToken()
-
-	var cases []*ast.CaseStatement
-	var defaultCase *ast.BlockStatement
+		if p.currentToken.Type != token.SEMICOLON {
+			first = p.parseSimpleStatement()
+		}
 
-	// Parse cases
-	for p.currentToken.Type != token.RBRACE && p.currentToken.Type != token.EOF {
-		if p.currentToken.Type == token.CASE {
-			// case value:
+		// switch init; value { ... }
+		if p.currentToken.Type == token.SEMICOLON {
 			p.nextToken()
-			caseValue := p.ParseExpression()
-
-			// :
-			if p.currentToken.Type == token.COLON {
-				p.nextToken()
+			init, first = first, nil
+			if p.currentToken.Type != token.LBRACE {
+				first = p.parseSimpleStatement()
 			}
+		}
+	}
+	p.inSwitchHeader = false
+	restore()
 
-			// Parse statements until next case/default/}
-			var statements []ast.Statement
-			for p.currentToken.Type != token.CASE &&
-				p.currentToken.Type != token.DEFAULT &&
-				p.currentToken.Type != token.RBRACE &&
-				p.currentToken.Type != token.EOF {
-				if stmt := p.ParseStatement(); stmt != nil {
-					statements = append(statements, stmt)
-				} else {
-					// If ParseStatement returns nil, advance token to prevent infinite loop
-					p.nextToken()
-				}
-			}
+	// switch [v :=] x.(type) { ... }
+	if binding, x, ok := typeSwitchGuard(first); ok {
+		return p.parseTypeSwitchBody(init, binding, x, pos)
+	}
 
-			cases = append(cases, &ast.CaseStatement{
-				Value: caseValue,
-				Body:  &ast.BlockStatement{Statements: statements},
-			})
-		} else if p.currentToken.Type == token.DEFAULT {
-			// default:
-			p.nextToken()
+	if first != nil {
+		if exprStmt, ok := first.(*ast.ExpressionStatement); ok {
+			value = exprStmt.Expression
+		} else {
+			p.error(first.Position(), "switch value must be an expression")
+		}
+	}
 
-			// :
-			if p.currentToken.Type == token.COLON {
-				p.nextToken()
-			}
+	// {
+	if !p.expect(token.LBRACE, "'{' after switch header") {
+		return &ast.SwitchStatement{Init: init, Value: value, Pos: pos}
+	}
 
-			// Parse statements until }
-			var statements []ast.Statement
-			for p.currentToken.Type != token.RBRACE && p.currentToken.Type != token.EOF {
-				if stmt := p.ParseStatement(); stmt != nil {
-					statements = append(statements, stmt)
-				} else {
-					// If ParseStatement returns nil, advance token to prevent infinite loop
-					p.nextToken()
-				}
-			}
+	var cases []*ast.CaseStatement
+	var defaultCase *ast.CaseStatement
 
-			defaultCase = &ast.BlockStatement{Statements: statements}
-		} else {
-			p.nextToken()
+	// Parse case clauses
+	for p.skipSemicolons(); p.currentToken.Type != token.RBRACE && p.currentToken.Type != token.EOF; p.skipSemicolons() {
+		clause := p.parseCaseClause()
+		if clause == nil {
+			continue
+		}
+		if clause.IsDefault() {
+			if defaultCase != nil {
+				p.error(clause.Pos, "multiple defaults in switch")
+			}
+			defaultCase = clause
 		}
+		cases = append(cases, clause)
 	}
 
-	// }
-	if p.currentToken.Type == token.RBRACE {
-		p.nextToken()
+	// 最後の節からは fallthrough できない
+	if n := len(cases); n > 0 && cases[n-1].Fallthrough() {
+		statements := cases[n-1].Body.Statements
+		p.error(statements[len(statements)-1].Position(), "cannot fallthrough final case in switch")
 	}
 
+	// }
+	p.expect(token.RBRACE, "'}' at end of switch")
+
 	return &ast.SwitchStatement{
-		Value:   value,
-		Cases:   cases,
-		Default: defaultCase,
+		Init:  init,
+		Value: value,
+		Cases: cases,
+		Pos:   pos,
 	}
 }
 
-func (p *Parser) parseTypeStatement() ast.Statement {
-	// type
-	p.nextToken()
+// parseCaseClause parses a case or default clause of a switch statement.
+// It returns nil after reporting a token that does not start a clause.
+func (p *Parser) parseCaseClause() *ast.CaseStatement {
+	pos := p.currentToken.Pos
 
-	// type name
-	if p.currentToken.Type != token.IDENT {
-		return &ast.TypeStatement{}
+	var values []ast.ASTNode
+	switch p.currentToken.Type {
+	case token.CASE:
+		// case x, y:
+		p.nextToken()
+		values = p.parseExpressionList()
+		p.expect(token.COLON, "':' after case value")
+	case token.DEFAULT:
+		// default:
+		p.nextToken()
+		p.expect(token.COLON, "':' after default")
+	default:
+		// 次の節まで読み飛ばす
+		p.errorExpected("case or default")
+		for !p.atClauseEnd() {
+			p.nextToken()
+		}
+		return nil
 	}
-	typeName := p.currentToken.Literal
-	p.nextToken()
 
-	// struct
-	if p.currentToken.Literal != "struct" {
-		return &ast.TypeStatement{}
+	// Parse statements until next case/default/}
+	defer p.openScope()()
+	var statements []ast.Statement
+	for p.skipSemicolons(); !p.atClauseEnd(); p.skipSemicolons() {
+		statements = append(statements, p.ParseStatement())
 	}
-	p.nextToken()
+	p.checkFallthrough(statements, true)
 
-	// {
-	if p.currentToken.Type != token.LBRACE {
-		return &ast.TypeStatement{}
+	return &ast.CaseStatement{
+		Values: values,
+		Body:   &ast.BlockStatement{Statements: statements, Pos: pos},
+		Pos:    pos,
 	}
-	p.nextToken()
+}
 
-	var fields []*ast.FieldDef
+// typeSwitchGuard returns the bound variable and the operand x of a type
+// switch guard x.(type) or v := x.(type). ok is false for other statements.
+func typeSwitchGuard(stmt ast.Statement) (binding string, x ast.ASTNode, ok bool) {
+	switch stmt := stmt.(type) {
+	case *ast.ExpressionStatement:
+		if assert, isAssert := stmt.Expression.(*ast.TypeAssertNode); isAssert && assert.Type == "" {
+			return "", assert.Expression, true
+		}
+	case *ast.AssignStatement:
+		if assert, isAssert := stmt.Value.(*ast.TypeAssertNode); isAssert && assert.Type == "" {
+			return stmt.Name, assert.Expression, true
+		}
+	}
+	return "", nil, false
+}
 
-	// Parse fields
-	for p.currentToken.Type != token.RBRACE && p.currentToken.Type != token.EOF {
-		if p.currentToken.Type == token.IDENT {
-			fieldName := p.currentToken.Literal
-			p.nextToken()
+// parseTypeSwitchBody parses the clauses of a type switch after its header
+func (p *Parser) parseTypeSwitchBody(init ast.Statement, binding string, x ast.ASTNode, pos token.Position) ast.Statement {
+	stmt := &ast.TypeSwitchStatement{Init: init, Binding: binding, Value: x, Pos: pos}
 
-			if p.currentToken.Type == token.IDENT {
-				fieldType := p.currentToken.Literal
-				p.nextToken()
+	// {
+	if !p.expect(token.LBRACE, "'{' after switch header") {
+		return stmt
+	}
 
-				fields = append(fields, &ast.FieldDef{
-					Name: fieldName,
-					Type: fieldType,
-				})
+	var defaultCase *ast.TypeCaseClause
+	for p.skipSemicolons(); p.currentToken.Type != token.RBRACE && p.currentToken.Type != token.EOF; p.skipSemicolons() {
+		clause := p.parseTypeCaseClause()
+		if clause == nil {
+			continue
+		}
+		if clause.IsDefault() {
+			if defaultCase != nil {
+				p.error(clause.Pos, "multiple defaults in switch")
 			}
-		} else {
-			p.nextToken()
+			defaultCase = clause
 		}
+		stmt.Cases = append(stmt.Cases, clause)
 	}
 
 	// }
-	if p.currentToken.Type == token.RBRACE {
-		p.nextToken()
-	}
+	p.expect(token.RBRACE, "'}' at end of switch")
 
-	return &ast.TypeStatement{
-		Name:   typeName,
-		Fields: fields,
-	}
+	return stmt
 }
 
-func (p *Parser) parseIfStatement() ast.Statement {
-	// if
-	p.nextToken()
+// parseTypeCaseClause parses a case or default clause of a type switch.
+// It returns nil after reporting a token that does not start a clause.
+func (p *Parser) parseTypeCaseClause() *ast.TypeCaseClause {
+	pos := p.currentToken.Pos
 
-	// condition - special handling for if statement context
-	condition := p.parseIfCondition()
+	var types []string
+	switch p.currentToken.Type {
+	case token.CASE:
+		// case int, Shape, nil:
+		p.nextToken()
+		types = []string{ast.TypeName(p.parseType("type"))}
+		for p.currentToken.Type == token.COMMA {
+			p.nextToken() // ',' を消費
+			types = append(types, ast.TypeName(p.parseType("type")))
+		}
+		p.expect(token.COLON, "':' after case type")
+	case token.DEFAULT:
+		// default:
+		p.nextToken()
+		p.expect(token.COLON, "':' after default")
+	default:
+		// 次の節まで読み飛ばす
+		p.errorExpected("case or default")
+		for !p.atClauseEnd() {
+			p.nextToken()
+		}
+		return nil
+	}
 
-	// {
-	if p.currentToken.Type != token.LBRACE {
-		// エラー: とりあえずダミーを返す（conditionは設定する）
-		return &ast.IfStatement{Condition: condition}
+	defer p.openScope()()
+	var statements []ast.Statement
+	for p.skipSemicolons(); !p.atClauseEnd(); p.skipSemicolons() {
+		statements = append(statements, p.ParseStatement())
 	}
+	// 型 switch では fallthrough できない
+	p.checkFallthrough(statements, false)
 
-	// then block
-	thenBlock := p.parseBlockStatement()
+	return &ast.TypeCaseClause{
+		Types: types,
+		Body:  &ast.BlockStatement{Statements: statements, Pos: pos},
+		Pos:   pos,
+	}
+}
 
-	var elseBlock *ast.BlockStatement = nil
+// atClauseEnd reports whether the current token ends a case clause
+func (p *Parser) atClauseEnd() bool {
+	switch p.currentToken.Type {
+	case token.CASE, token.DEFAULT, token.RBRACE, token.EOF:
+		return true
+	}
+	return false
+}
 
-	// else があるかチェック
-	if p.currentToken.Type == token.ELSE {
-		p.nextToken()
-		if p.currentToken.Type == token.LBRACE {
-			elseBlock = p.parseBlockStatement()
+// checkFallthrough reports fallthrough statements in a statement list. Only
+// the last statement of a case clause may be a fallthrough statement.
+func (p *Parser) checkFallthrough(statements []ast.Statement, inCaseClause bool) {
+	for i, stmt := range statements {
+		if _, ok := stmt.(*ast.FallthroughStatement); ok && (!inCaseClause || i != len(statements)-1) {
+			p.error(stmt.Position(), "fallthrough statement out of place")
 		}
 	}
+}
 
-	return &ast.IfStatement{
-		Condition: condition,
-		ThenBlock: thenBlock,
-		ElseBlock: elseBlock,
+// parseExpressionList parses one or more comma separated expressions
+func (p *Parser) parseExpressionList() []ast.ASTNode {
+	list := []ast.ASTNode{p.ParseExpression()}
+	for p.currentToken.Type == token.COMMA {
+		p.nextToken() // ',' を消費
+		list = append(list, p.ParseExpression())
 	}
+	return list
 }
 
-// parseIfCondition parses conditions in if statements (prevents struct literal confusion)
-func (p *Parser) parseIfCondition() ast.ASTNode {
-	// For if conditions, we need to be careful about struct literal vs variable references
-	// If we see IDENT followed by LBRACE, treat it as a variable, not a struct literal
-	if p.currentToken.Type == token.IDENT {
-		name := p.currentToken.Literal
-		p.nextToken()
+// parseTypeStatement parses type declarations: type T struct { ... },
+// type T interface { ... } or a group type ( A struct { ... }; B ... )
+func (p *Parser) parseTypeStatement() ast.Statement {
+	pos := p.currentToken.Pos
 
-		// If the next token is LBRACE, this is definitely a variable reference
-		// because the LBRACE belongs to the if statement block
-		if p.currentToken.Type == token.LBRACE {
-			return &ast.VariableNode{Name: name}
+	// type
+	p.nextToken()
+
+	if p.currentToken.Type == token.LPAREN {
+		return p.parseDeclGroup("type", pos, p.parseTypeSpec)
+	}
+	return p.parseTypeSpec(pos)
+}
+
+// parseTypeSpec parses the name and the definition of one type
+func (p *Parser) parseTypeSpec(pos token.Position) ast.Statement {
+	// type name
+	typeName := p.expectIdent("type name")
+	if typeName == "" {
+		return &ast.TypeStatement{Pos: pos}
+	}
+
+	switch p.currentToken.Type {
+	case token.STRUCT:
+		structType := p.parseStructType()
+		if structType == nil {
+			return &ast.TypeStatement{Name: typeName, Pos: pos}
+		}
+		return &ast.TypeStatement{
+			Name:   typeName,
+			Fields: structType.Fields,
+			Pos:    pos,
 		}
+	case token.INTERFACE:
+		return p.parseInterfaceType(typeName, pos)
+	}
+	p.errorExpected("struct or interface")
+	return &ast.TypeStatement{Name: typeName, Pos: pos}
+}
+
+// parseInterfaceType parses the method set of an interface type declaration:
+// type Shape interface { Area() int; Scale(factor int) }. An interface name
+// in place of a method embeds the methods of that interface.
+func (p *Parser) parseInterfaceType(typeName string, pos token.Position) ast.Statement {
+	// interface
+	p.nextToken()
+
+	// {
+	if !p.expect(token.LBRACE, "'{' after interface") {
+		return &ast.InterfaceStatement{Name: typeName, Pos: pos}
+	}
+
+	var methods []*ast.MethodDef
+	var embedded []string
+	for p.skipSemicolons(); p.currentToken.Type != token.RBRACE && p.currentToken.Type != token.EOF; p.skipSemicolons() {
+		methodPos := p.currentToken.Pos
+		methodName := p.expectIdent("method name")
+		if methodName != "" && (p.currentToken.Type == token.SEMICOLON || p.currentToken.Type == token.RBRACE) {
+			// 埋め込まれたインターフェース
+			embedded = append(embedded, methodName)
+		} else if methodName != "" && p.expect(token.LPAREN, "'(' after method name") {
+			parameters, _ := p.parseParameterList("parameter")
+			methods = append(methods, &ast.MethodDef{
+				Name:       methodName,
+				Parameters: parameters,
+				Results:    p.parseResults(),
+				Pos:        methodPos,
+			})
+		}
+
+		// メソッドはセミコロンか '}' で終わる。エラーなら次のメソッドまで読み飛ばす
+		if p.currentToken.Type != token.SEMICOLON && p.currentToken.Type != token.RBRACE {
+			p.errorExpected("';' or '}' after method")
+			for p.currentToken.Type != token.SEMICOLON && p.currentToken.Type != token.RBRACE &&
+				p.currentToken.Type != token.EOF {
+				p.nextToken()
+			}
+		}
+	}
+
+	// }
+	p.expect(token.RBRACE, "'}' at end of interface")
+
+	return &ast.InterfaceStatement{
+		Name:     typeName,
+		Methods:  methods,
+		Embedded: embedded,
+		Pos:      pos,
+	}
+}
+
+// parseType parses a type: a type name T, *T, []T, [N]T, map[K]V, a
+// function type such as func(int) string, struct { ... } or interface{}.
+// It returns nil after reporting an error.
+func (p *Parser) parseType(what string) ast.Type {
+	pos := p.currentToken.Pos
+	switch p.currentToken.Type {
+	case token.FUNC:
+		p.nextToken() // 'func' を消費
+		if !p.expect(token.LPAREN, "'(' after func") {
+			return nil
+		}
+		params, ok := p.parseParameterList("parameter")
+		if !ok {
+			return nil
+		}
+		return &ast.FuncType{Params: parameterTypes(params), Results: parameterTypes(p.parseResults()), Pos: pos}
+	case token.MUL:
+		p.nextToken() // '*' を消費
+		elem := p.parseType(what)
+		if elem == nil {
+			return nil
+		}
+		return &ast.PointerType{Elem: elem, Pos: pos}
+	case token.LBRACK:
+		return p.parseArrayOrSliceType(what, false)
+	case token.MAP:
+		if t := p.parseMapType(); t != nil {
+			return t
+		}
+		return nil
+	case token.STRUCT:
+		if t := p.parseStructType(); t != nil {
+			return t
+		}
+		return nil
+	case token.INTERFACE:
+		// 空インターフェースのみ
+		p.nextToken()
+		if !p.expect(token.LBRACE, "'{' after interface") || !p.expect(token.RBRACE, "'}' in empty interface type") {
+			return nil
+		}
+		return &ast.InterfaceType{Pos: pos}
+	}
+
+	name := p.expectIdent(what)
+	if name == "" {
+		return nil
+	}
+	return &ast.IdentType{Name: name, Pos: pos}
+}
+
+// parseArrayOrSliceType parses a slice type []T or an array type [N]T.
+// The length [...] of an array whose elements are counted is allowed only
+// in a composite literal.
+func (p *Parser) parseArrayOrSliceType(what string, inLiteral bool) ast.Type {
+	pos := p.currentToken.Pos
+	p.nextToken() // '[' を消費
+
+	length := -1
+	switch p.currentToken.Type {
+	case token.RBRACK:
+		p.nextToken() // ']' を消費
+		elem := p.parseType(what)
+		if elem == nil {
+			return nil
+		}
+		return &ast.SliceType{Elem: elem, Pos: pos}
+	case token.ELLIPSIS:
+		if !inLiteral {
+			p.error(p.currentToken.Pos, "invalid use of [...] array (outside a composite literal)")
+		}
+		p.nextToken() // '...' を消費
+	default:
+		restore := p.setNoStructLiteral(false)
+		length = p.arrayLength(p.ParseExpression())
+		restore()
+	}
+
+	if !p.expect(token.RBRACK, "']' after array length") {
+		return nil
+	}
+	elem := p.parseType(what)
+	if elem == nil {
+		return nil
+	}
+	return &ast.ArrayType{Len: length, Elem: elem, Pos: pos}
+}
 
-		// If not LBRACE, parse as normal expression (might be comparison, etc.)
-		// We need to "put back" the IDENT token and parse normally
-		// For now, handle simple cases
-		if p.currentToken.Type == token.GTR || p.currentToken.Type == token.LSS ||
-			p.currentToken.Type == token.EQL || p.currentToken.Type == token.NEQ ||
-			p.currentToken.Type == token.LEQ || p.currentToken.Type == token.GEQ {
-			// Binary comparison: IDENT op EXPR
-			operator := p.currentToken.Type
+// arrayLength returns the length of an array type, which must be a
+// non-negative integer constant. It returns 0 after reporting an error.
+func (p *Parser) arrayLength(x ast.ASTNode) int {
+	v, ok := p.constOf(x)
+	if !ok {
+		p.error(x.Position(), "array length must be constant")
+		return 0
+	}
+	if n, err := constant.Convert(v, "int"); err == nil {
+		if length, ok := n.Int64Val(); ok && length >= 0 {
+			return int(length)
+		}
+	}
+	p.error(x.Position(), "invalid array length "+v.String())
+	return 0
+}
+
+// parseMapType parses a map type map[K]V. It returns nil after reporting
+// an error.
+func (p *Parser) parseMapType() *ast.MapType {
+	pos := p.currentToken.Pos
+	p.nextToken() // 'map' を消費
+	if !p.expect(token.LBRACK, "'[' after map") {
+		return nil
+	}
+	keyType := p.parseType("map key type")
+	if keyType == nil || !p.expect(token.RBRACK, "']' after map key type") {
+		return nil
+	}
+	valueType := p.parseType("map value type")
+	if valueType == nil {
+		return nil
+	}
+	return &ast.MapType{Key: keyType, Value: valueType, Pos: pos}
+}
+
+// parseStructType parses the fields of a struct type: struct { Name string;
+// Age int }. It returns nil when the '{' is missing.
+func (p *Parser) parseStructType() *ast.StructType {
+	pos := p.currentToken.Pos
+	p.nextToken() // 'struct' を消費
+
+	// {
+	if !p.expect(token.LBRACE, "'{' after struct") {
+		return nil
+	}
+
+	var fields []*ast.FieldDef
+
+	// Parse fields
+	for p.skipSemicolons(); p.currentToken.Type != token.RBRACE && p.currentToken.Type != token.EOF; p.skipSemicolons() {
+		if p.currentToken.Type != token.IDENT {
+			p.errorExpected("field name")
 			p.nextToken()
-			right := p.parseTerm()
-			return &ast.BinaryOpNode{
-				Left:     &ast.VariableNode{Name: name},
-				Operator: operator,
-				Right:    right,
+			continue
+		}
+		// 同じ型のフィールドはまとめて宣言できる: x, y int
+		names := []*ast.FieldDef{{Name: p.currentToken.Literal, Pos: p.currentToken.Pos}}
+		p.nextToken()
+		for p.currentToken.Type == token.COMMA {
+			p.nextToken() // ',' を消費
+			fieldPos := p.currentToken.Pos
+			fieldName := p.expectIdent("field name")
+			if fieldName == "" {
+				break
+			}
+			names = append(names, &ast.FieldDef{Name: fieldName, Pos: fieldPos})
+		}
+
+		if fieldType := p.parseType("field type"); fieldType != nil {
+			for _, field := range names {
+				field.Type = fieldType
+				fields = append(fields, field)
+			}
+		}
+
+		// フィールドはセミコロンか '}' で終わる。エラーなら次のフィールドまで読み飛ばす
+		if p.currentToken.Type != token.SEMICOLON && p.currentToken.Type != token.RBRACE {
+			p.errorExpected("';' or '}' after field")
+			for p.currentToken.Type != token.SEMICOLON && p.currentToken.Type != token.RBRACE &&
+				p.currentToken.Type != token.EOF {
+				p.nextToken()
 			}
 		}
+	}
+
+	// }
+	p.expect(token.RBRACE, "'}' at end of struct")
+
+	return &ast.StructType{Fields: fields, Pos: pos}
+}
+
+// parameterTypes returns the types of parameters, dropping their names
+func parameterTypes(params []ast.Parameter) []ast.Type {
+	types := make([]ast.Type, len(params))
+	for i, param := range params {
+		types[i] = param.Type
+	}
+	return types
+}
+
+// isTypeStart reports whether the current token can start a type
+func (p *Parser) isTypeStart() bool {
+	switch p.currentToken.Type {
+	case token.IDENT, token.MUL, token.LBRACK, token.INTERFACE, token.FUNC, token.MAP, token.STRUCT:
+		return true
+	}
+	return false
+}
+
+func (p *Parser) parseIfStatement() ast.Statement {
+	pos := p.currentToken.Pos
+	defer p.openScope()()
+
+	// if
+	p.nextToken()
+
+	// condition - special handling for if statement context
+	condition := p.parseIfCondition()
+
+	// {
+	if p.currentToken.Type != token.LBRACE {
+		p.errorExpected("'{' after if condition")
+		return &ast.IfStatement{Condition: condition, Pos: pos}
+	}
+
+	// then block
+	thenBlock := p.parseBlockStatement()
+
+	var elseBlock *ast.BlockStatement = nil
+
+	// else があるかチェック
+	if p.currentToken.Type == token.ELSE {
+		p.nextToken()
+		switch p.currentToken.Type {
+		case token.LBRACE:
+			elseBlock = p.parseBlockStatement()
+		case token.IF:
+			// else if は if 文だけを含むブロックとして扱う
+			elsePos := p.currentToken.Pos
+			elseBlock = &ast.BlockStatement{Statements: []ast.Statement{p.parseIfStatement()}, Pos: elsePos}
+		default:
+			p.errorExpected("if statement or block after else")
+		}
+	}
 
-		// Just a variable reference
-		return &ast.VariableNode{Name: name}
+	return &ast.IfStatement{
+		Condition: condition,
+		ThenBlock: thenBlock,
+		ElseBlock: elseBlock,
+		Pos:       pos,
 	}
+}
 
-	// For other cases, use normal expression parsing
-	// This handles cases like "1 > 0", "x + y > 5", etc.
+// parseIfCondition parses conditions in if statements (prevents struct literal confusion)
+func (p *Parser) parseIfCondition() ast.ASTNode {
+	// if x {} の '{' はブロックの開始なので struct literal として扱わない
+	defer p.setNoStructLiteral(true)()
 	return p.ParseExpression()
 }
 
 func (p *Parser) parseForStatement() ast.Statement {
+	pos := p.currentToken.Pos
+	defer p.openScope()()
+
 	// for
 	p.nextToken()
 
+	// ヘッダー内の '{' はブロックの開始なので struct literal として扱わない
+	defer p.setNoStructLiteral(true)()
+
 	// for文の形式を判定
-	// 1. for condition { ... } (condition-only)
-	// 2. for init; condition; update { ... } (full form)
+	// 1. for { ... } (infinite)
+	// 2. for condition { ... } (condition-only)
+	// 3. for init; condition; update { ... } (full form)
+	// 4. for key, value := range x { ... } (range clause)
+	if p.currentToken.Type == token.LBRACE {
+		return &ast.ForStatement{Body: p.parseBlockStatement(), Pos: pos}
+	}
 
-	// Check if this is full form (has semicolons)
-	// We need to look ahead to see if there are semicolons
-	if p.hasForLoopSemicolons() {
-		return p.parseFullForStatement()
-	} else {
-		return p.parseConditionOnlyForStatement()
+	var first ast.Statement
+	if p.currentToken.Type != token.SEMICOLON {
+		p.inForHeader = true
+		first = p.parseSimpleStatement()
+		p.inForHeader = false
 	}
-}
 
-func (p *Parser) hasForLoopSemicolons() bool {
-	// Simple check: scan ahead for semicolons before opening brace
-	scanner := p.scanner
-	pos := scanner.Position()
-	input := scanner.Input()
+	if stmt, ok := p.rangeClause(first); ok {
+		stmt.Pos = pos
+		return p.parseRangeBody(stmt)
+	}
 
-	for pos < len(input) && input[pos] != '{' && input[pos] != '\n' {
-		if input[pos] == ';' {
-			return true
+	if p.currentToken.Type == token.SEMICOLON {
+		return p.parseFullForStatement(first, pos)
+	}
+	return p.parseConditionOnlyForStatement(first, pos)
+}
+
+// rangeClause converts the statement of a range clause, such as
+// k, v := range x, into a range statement. ok is false for other statements.
+func (p *Parser) rangeClause(stmt ast.Statement) (rangeStmt *ast.RangeStatement, ok bool) {
+	switch stmt := stmt.(type) {
+	case *ast.ExpressionStatement:
+		if x, isRange := rangeOperand(stmt.Expression); isRange {
+			return &ast.RangeStatement{Expression: x}, true
+		}
+	case *ast.AssignStatement:
+		if x, isRange := rangeOperand(stmt.Value); isRange {
+			return &ast.RangeStatement{Key: stmt.Name, Define: true, Expression: x}, true
+		}
+	case *ast.ReassignStatement:
+		key, isVariable := stmt.Target.(*ast.VariableNode)
+		if x, isRange := rangeOperand(stmt.Value); isRange && isVariable {
+			return &ast.RangeStatement{Key: key.Name, Expression: x}, true
+		}
+	case *ast.TupleAssignStatement:
+		if len(stmt.Values) != 1 {
+			return nil, false
+		}
+		x, isRange := rangeOperand(stmt.Values[0])
+		if !isRange {
+			return nil, false
 		}
-		pos++
+		names := stmt.Names()
+		if len(names) > 2 {
+			p.error(stmt.Pos, "range clause permits at most two iteration variables")
+		}
+		for i, name := range names[:2] {
+			if name == "" {
+				p.error(stmt.Targets[i].Position(), "range clause permits only variables as iteration variables")
+			}
+		}
+		return &ast.RangeStatement{Key: names[0], Value: names[1], Define: stmt.Define, Expression: x}, true
 	}
-	return false
+	return nil, false
 }
 
-func (p *Parser) parseConditionOnlyForStatement() ast.Statement {
+// rangeOperand returns x of the expression range x
+func rangeOperand(expr ast.ASTNode) (x ast.ASTNode, ok bool) {
+	if unary, isUnary := expr.(*ast.UnaryOpNode); isUnary && unary.Operator == token.RANGE {
+		return unary.Operand, true
+	}
+	return nil, false
+}
+
+// parseRangeBody parses the body of a for statement with a range clause
+func (p *Parser) parseRangeBody(stmt *ast.RangeStatement) ast.Statement {
+	// {
+	if p.currentToken.Type != token.LBRACE {
+		p.errorExpected("'{' after for clause")
+		return stmt
+	}
+
+	stmt.Body = p.parseBlockStatement()
+	return stmt
+}
+
+func (p *Parser) parseConditionOnlyForStatement(first ast.Statement, pos token.Position) ast.Statement {
 	// for condition { ... }
-	condition := p.ParseExpression()
+	var condition ast.ASTNode
+	if exprStmt, ok := first.(*ast.ExpressionStatement); ok {
+		condition = exprStmt.Expression
+	}
 
 	// {
 	if p.currentToken.Type != token.LBRACE {
-		return &ast.ForStatement{}
+		p.errorExpected("'{' after for clause")
+		return &ast.ForStatement{Condition: condition, Pos: pos}
 	}
 
 	body := p.parseBlockStatement()
@@ -455,22 +1208,15 @@ func (p *Parser) parseConditionOnlyForStatement() ast.Statement {
 		Condition: condition,
 		Update:    nil,
 		Body:      body,
+		Pos:       pos,
 	}
 }
 
-func (p *Parser) parseFullForStatement() ast.Statement {
+func (p *Parser) parseFullForStatement(init ast.Statement, pos token.Position) ast.Statement {
 	// for init; condition; update { ... }
 
-	// init statement
-	var init ast.Statement
-	if p.currentToken.Type != token.SEMICOLON {
-		init = p.ParseStatement()
-	}
-
-	// skip semicolon
-	if p.currentToken.Type == token.SEMICOLON {
-		p.nextToken()
-	}
+	// skip semicolon after init
+	p.nextToken()
 
 	// condition
 	var condition ast.ASTNode
@@ -486,12 +1232,13 @@ func (p *Parser) parseFullForStatement() ast.Statement {
 	// update statement
 	var update ast.Statement
 	if p.currentToken.Type != token.LBRACE {
-		update = p.ParseStatement()
+		update = p.parseSimpleStatement()
 	}
 
 	// {
 	if p.currentToken.Type != token.LBRACE {
-		return &ast.ForStatement{}
+		p.errorExpected("'{' after for clause")
+		return &ast.ForStatement{Init: init, Condition: condition, Update: update, Pos: pos}
 	}
 
 	body := p.parseBlockStatement()
@@ -501,405 +1248,973 @@ func (p *Parser) parseFullForStatement() ast.Statement {
 		Condition: condition,
 		Update:    update,
 		Body:      body,
+		Pos:       pos,
 	}
 }
 
 func (p *Parser) parseBreakStatement() ast.Statement {
-	// break
+	pos := p.currentToken.Pos
+
+	// break [label]
 	p.nextToken()
-	return &ast.BreakStatement{}
+	return &ast.BreakStatement{Label: p.parseBranchLabel(), Pos: pos}
 }
 
 func (p *Parser) parseContinueStatement() ast.Statement {
-	// continue
+	pos := p.currentToken.Pos
+
+	// continue [label]
 	p.nextToken()
-	return &ast.ContinueStatement{}
+	return &ast.ContinueStatement{Label: p.parseBranchLabel(), Pos: pos}
+}
+
+func (p *Parser) parseFallthroughStatement() ast.Statement {
+	pos := p.currentToken.Pos
+
+	// fallthrough
+	p.nextToken()
+	return &ast.FallthroughStatement{Pos: pos}
 }
 
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
+	pos := p.currentToken.Pos
+
 	// {
 	p.nextToken()
 
+	defer p.setNoStructLiteral(false)()
+	defer p.openScope()()
+	p.blockDepth++
+	defer func() { p.blockDepth-- }()
+
 	statements := []ast.Statement{}
 
-	for p.currentToken.Type != token.RBRACE && p.currentToken.Type != token.EOF {
+	for p.skipSemicolons(); p.currentToken.Type != token.RBRACE && p.currentToken.Type != token.EOF; p.skipSemicolons() {
 		stmt := p.ParseStatement()
 		statements = append(statements, stmt)
 	}
+	p.checkFallthrough(statements, false)
 
 	// }
-	if p.currentToken.Type == token.RBRACE {
-		p.nextToken()
-	}
+	p.expect(token.RBRACE, "'}' at end of block")
 
-	return &ast.BlockStatement{Statements: statements}
+	return &ast.BlockStatement{Statements: statements, Pos: pos}
 }
 
+// parseExpressionStatement parses expression statements and the
+// statements that assign to an expression: x = v, xs[i] += v, p.age++,
+// *p = v
 func (p *Parser) parseExpressionStatement() ast.Statement {
+	pos := p.currentToken.Pos
 	expression := p.ParseExpression()
-	return &ast.ExpressionStatement{Expression: expression}
+
+	// 式の後の演算子で代入文かどうかが決まる
+	switch p.currentToken.Type {
+	case token.COMMA:
+		return p.parseTupleAssignStatement(expression, pos)
+	case token.ASSIGN:
+		if p.currentToken.Literal == "=" {
+			return p.parseReassignStatement(expression, pos)
+		}
+	case token.INC:
+		return p.parseIncStatement(expression, pos)
+	case token.DEC:
+		return p.parseDecStatement(expression, pos)
+	case token.ADD_ASSIGN, token.SUB_ASSIGN, token.MUL_ASSIGN, token.QUO_ASSIGN, token.REM_ASSIGN,
+		token.AND_ASSIGN, token.OR_ASSIGN, token.XOR_ASSIGN, token.SHL_ASSIGN, token.SHR_ASSIGN,
+		token.AND_NOT_ASSIGN:
+		return p.parseCompoundAssignStatement(expression, pos)
+	}
+	return &ast.ExpressionStatement{Expression: expression, Pos: pos}
 }
 
+// ParseExpression parses an expression
 func (p *Parser) ParseExpression() ast.ASTNode {
-	return p.parseComparison()
+	return p.parseBinaryExpr(token.LowestPrec + 1)
 }
 
-// 比較演算子の解析 (==, !=, <, >, <=, >=)
-func (p *Parser) parseComparison() ast.ASTNode {
-	left := p.parseTerm()
+// parseBinaryExpr parses binary operations whose operators have precedence
+// prec1 or higher. 優先順位は token.Precedence の表で決まるので、新しい
+// 二項演算子は表に追加するだけでよい
+func (p *Parser) parseBinaryExpr(prec1 int) ast.ASTNode {
+	left := p.parseUnaryExpr()
 
-	for p.currentToken.Type == token.EQL || p.currentToken.Type == token.NEQ ||
-		p.currentToken.Type == token.LSS || p.currentToken.Type == token.GTR ||
-		p.currentToken.Type == token.LEQ || p.currentToken.Type == token.GEQ {
+	for {
 		operator := p.currentToken.Type
+		prec := operator.Precedence()
+		if prec < prec1 {
+			return left
+		}
+		opPos := p.currentToken.Pos
 		p.nextToken()
-		right := p.parseTerm()
-		left = &ast.BinaryOpNode{
+
+		// 同じ優先順位の演算子は左結合
+		right := p.parseBinaryExpr(prec + 1)
+		left = p.foldBinary(&ast.BinaryOpNode{
 			Left:     left,
 			Operator: operator,
 			Right:    right,
+			Pos:      left.Position(),
+		}, opPos)
+	}
+}
+
+// parseUnaryExpr parses an operand with its prefix and postfix operators
+func (p *Parser) parseUnaryExpr() ast.ASTNode {
+	prefix, ok := p.prefixParseFns[p.currentToken.Type]
+	if !ok {
+		return p.parseBadExpr()
+	}
+	x := prefix()
+
+	for {
+		postfix, ok := p.postfixParseFns[p.currentToken.Type]
+		if !ok {
+			return x
+		}
+		// 識別子の後の '{' だけが struct literal になる
+		if p.currentToken.Type == token.LBRACE && !p.isStructLiteralType(x) {
+			return x
 		}
+		x = postfix(x)
 	}
+}
 
-	return left
+// registerPrefix registers the function parsing expressions that start with tok
+func (p *Parser) registerPrefix(tok token.Token, fn prefixParseFn) {
+	p.prefixParseFns[tok] = fn
 }
 
-func (p *Parser) parseTerm() ast.ASTNode {
-	left := p.parseMultiplyDivide()
+// registerPostfix registers the function parsing the operator tok applied to
+// the expression before it
+func (p *Parser) registerPostfix(tok token.Token, fn postfixParseFn) {
+	p.postfixParseFns[tok] = fn
+}
 
-	for p.currentToken.Type == token.ADD || p.currentToken.Type == token.SUB {
-		operator := p.currentToken.Type
-		p.nextToken()
-		right := p.parseMultiplyDivide()
-		left = &ast.BinaryOpNode{
-			Left:     left,
-			Operator: operator,
-			Right:    right,
+// 単項演算子の解析 (-, +, !, ^)
+func (p *Parser) parsePrefixExpr() ast.ASTNode {
+	pos := p.currentToken.Pos
+	operator := p.currentToken.Type
+	p.nextToken()
+
+	return p.foldUnary(&ast.UnaryOpNode{
+		Operator: operator,
+		Operand:  p.parseUnaryExpr(),
+		Pos:      pos,
+	})
+}
+
+// parseAddressExpr parses &x. The operand must be addressable: a variable,
+// a field, an element or the value a pointer points to; the address of a
+// composite literal such as &Point{1, 2} points to a new value.
+func (p *Parser) parseAddressExpr() ast.ASTNode {
+	pos := p.currentToken.Pos
+	p.nextToken() // '&' を消費
+
+	errorCount := len(p.errors)
+	operand := p.parseUnaryExpr()
+	switch operand.(type) {
+	case *ast.VariableNode, *ast.FieldAccessNode, *ast.IndexAccess, *ast.DerefNode,
+		*ast.StructLiteral, *ast.SliceLiteral, *ast.ArrayLiteral, *ast.MapLiteral:
+	default:
+		// 式のエラーは報告済み
+		if len(p.errors) == errorCount {
+			p.error(pos, "invalid operation: cannot take address of "+p.operandText(operand))
 		}
 	}
 
-	return left
+	return &ast.UnaryOpNode{
+		Operator: token.AND,
+		Operand:  operand,
+		Pos:      pos,
+	}
 }
 
-func (p *Parser) parseMultiplyDivide() ast.ASTNode {
-	left := p.parseFactor()
+// parseDerefExpr parses *p, the value the pointer p points to
+func (p *Parser) parseDerefExpr() ast.ASTNode {
+	pos := p.currentToken.Pos
+	p.nextToken() // '*' を消費
 
-	for p.currentToken.Type == token.MUL || p.currentToken.Type == token.QUO {
-		operator := p.currentToken.Type
-		p.nextToken()
-		right := p.parseFactor()
-		left = &ast.BinaryOpNode{
-			Left:     left,
-			Operator: operator,
-			Right:    right,
-		}
+	errorCount := len(p.errors)
+	operand := p.parseUnaryExpr()
+	if _, ok := p.constOf(operand); ok && len(p.errors) == errorCount {
+		p.error(pos, "invalid operation: cannot indirect "+p.operandText(operand))
 	}
 
-	return left
+	return &ast.DerefNode{
+		Operand: operand,
+		Pos:     pos,
+	}
 }
 
-func (p *Parser) parseFactor() ast.ASTNode {
-	if p.currentToken.Type == token.INT {
-		value := 0
-		literal := p.currentToken.Literal
+// operandText describes an operand that cannot be used with an operator:
+// a constant by its value and type, anything else as a value
+func (p *Parser) operandText(x ast.ASTNode) string {
+	if v, ok := p.constOf(x); ok {
+		return v.String() + " (" + v.Type + " constant)"
+	}
+	return "value"
+}
 
-		// 手動で文字列を数値に変換（strconv なしで）
-		for i := 0; i < len(literal); i++ {
-			digit := int(literal[i] - '0')
-			value = value*10 + digit
-		}
+// parseRangeExpr parses range x in the header of a for statement. It is
+// kept as a unary expression until the header is turned into a range
+// statement.
+func (p *Parser) parseRangeExpr() ast.ASTNode {
+	pos := p.currentToken.Pos
+	if !p.inForHeader {
+		p.error(pos, "range clause outside for statement")
+	}
+	p.nextToken()
 
-		p.nextToken()
-		return &ast.NumberNode{Value: value}
+	return &ast.UnaryOpNode{
+		Operator: token.RANGE,
+		Operand:  p.ParseExpression(),
+		Pos:      pos,
 	}
+}
 
-	if p.currentToken.Type == token.TRUE {
-		p.nextToken()
-		return &ast.BooleanNode{Value: true}
+// parseIntegerLiteral parses an integer literal. Its value may exceed int:
+// constant expressions use its exact value, and the value only has to fit
+// once it is given a type.
+func (p *Parser) parseIntegerLiteral() ast.ASTNode {
+	pos := p.currentToken.Pos
+	literal := p.currentToken.Literal
+	value, _ := parseIntLiteral(literal)
+
+	p.nextToken()
+	return &ast.NumberNode{Value: value, Literal: literal, Pos: pos}
+}
+
+// parseFloatLiteral parses a floating-point literal, keeping its text for
+// its exact value
+func (p *Parser) parseFloatLiteral() ast.ASTNode {
+	pos := p.currentToken.Pos
+	literal := p.currentToken.Literal
+	if _, err := constant.MakeFromLiteral(literal, token.FLOAT); err != nil {
+		p.error(pos, err.Error())
 	}
+	value, _ := strconv.ParseFloat(strings.ReplaceAll(literal, "_", ""), 64)
 
-	if p.currentToken.Type == token.FALSE {
-		p.nextToken()
-		return &ast.BooleanNode{Value: false}
+	p.nextToken()
+	return &ast.FloatNode{Value: value, Literal: literal, Pos: pos}
+}
+
+func (p *Parser) parseImaginaryLiteral() ast.ASTNode {
+	pos := p.currentToken.Pos
+	p.error(pos, "complex numbers are not supported: "+p.currentToken.Literal)
+	p.nextToken()
+	return &ast.NumberNode{Value: 0, Pos: pos}
+}
+
+func (p *Parser) parseRuneLiteral() ast.ASTNode {
+	pos := p.currentToken.Pos
+	// スキャナーがデコード済みの文字を UTF-8 で返す
+	value, _ := utf8.DecodeRuneInString(p.currentToken.Literal)
+	p.nextToken()
+	return &ast.RuneNode{Value: value, Pos: pos}
+}
+
+func (p *Parser) parseStringLiteral() ast.ASTNode {
+	pos := p.currentToken.Pos
+	value := p.currentToken.Literal
+	p.nextToken()
+	return &ast.StringNode{Value: value, Pos: pos}
+}
+
+func (p *Parser) parseBooleanLiteral() ast.ASTNode {
+	pos := p.currentToken.Pos
+	value := p.currentToken.Type == token.TRUE
+	p.nextToken()
+	return &ast.BooleanNode{Value: value, Pos: pos}
+}
+
+func (p *Parser) parseIdentifier() ast.ASTNode {
+	pos := p.currentToken.Pos
+	name := p.currentToken.Literal
+	p.nextToken()
+
+	// 定数と iota はその値に置き換える
+	ident := &ast.VariableNode{Name: name, Pos: pos}
+	if value := p.lookupConst(name); value != nil {
+		return &ast.ConstExpr{Expression: ident, Value: *value, Pos: pos}
 	}
+	if name == "iota" && p.iota >= 0 {
+		return &ast.ConstExpr{Expression: ident, Value: constant.MakeInt64(int64(p.iota)), Pos: pos}
+	}
+	return ident
+}
 
-	if p.currentToken.Type == token.STRING {
-		value := p.currentToken.Literal
-		p.nextToken()
-		return &ast.StringNode{Value: value}
+// parseParenExpr parses a parenthesized expression: (x + y)
+func (p *Parser) parseParenExpr() ast.ASTNode {
+	p.nextToken() // '(' を消費
+
+	// 括弧の中では struct literal が使える
+	restore := p.setNoStructLiteral(false)
+	expr := p.ParseExpression()
+	restore()
+
+	p.expect(token.RPAREN, "')'") // ')' を消費
+	return expr
+}
+
+// parseArrayOrSliceLiteral parses slice and array literals from their
+// type: []int{1, 2}, [3]int{1, 2} or [...]string{"a", "b"}
+func (p *Parser) parseArrayOrSliceLiteral() ast.ASTNode {
+	pos := p.currentToken.Pos
+	t := p.parseArrayOrSliceType("element type", true)
+	if t != nil && p.currentToken.Type != token.LBRACE {
+		p.errorExpected("'{' after " + t.TypeName())
+	}
+	if t == nil || p.currentToken.Type != token.LBRACE {
+		// エラーケース: とりあえず 0 を返す
+		return &ast.NumberNode{Value: 0, Pos: pos}
 	}
+	return p.parseLiteralOf(t, pos)
+}
+
+// parseBadExpr reports a token that cannot start an expression
+func (p *Parser) parseBadExpr() ast.ASTNode {
+	pos := p.currentToken.Pos
 
-	if p.currentToken.Type == token.IDENT {
-		name := p.currentToken.Literal
+	// 不正なトークンはスキャナーがエラーとして記録済みなので読み飛ばす
+	if p.currentToken.Type == token.ILLEGAL {
 		p.nextToken()
+	} else {
+		p.errorExpected("expression")
+	}
 
-		// 関数呼び出しかチェック
-		if p.currentToken.Type == token.LPAREN {
-			p.nextToken() // '(' を消費
+	// エラーケース: とりあえず 0 を返す
+	return &ast.NumberNode{Value: 0, Pos: pos}
+}
 
-			arguments := []ast.ASTNode{}
+// parseSelector parses field access: x.field
+func (p *Parser) parseSelector(x ast.ASTNode) ast.ASTNode {
+	pos := p.currentToken.Pos
+	p.nextToken() // '.' を消費
 
-			// 引数をパース
-			for p.currentToken.Type != token.RPAREN && p.currentToken.Type != token.EOF {
-				arg := p.ParseExpression()
-				arguments = append(arguments, arg)
+	// 型アサーション: x.(T)
+	if p.currentToken.Type == token.LPAREN {
+		return p.parseTypeAssertion(x, pos)
+	}
 
-				// カンマをスキップ
-				if p.currentToken.Type == token.COMMA {
-					p.nextToken()
-				}
-			}
+	fieldName := p.expectIdent("field name")
+	if fieldName == "" {
+		return x
+	}
 
-			if p.currentToken.Type == token.RPAREN {
-				p.nextToken() // ')' を消費
-			}
+	return &ast.FieldAccessNode{
+		Object: x,
+		Field:  fieldName,
+		Pos:    pos,
+	}
+}
 
-			return &ast.CallNode{Function: name, Arguments: arguments}
-		}
+// parseTypeAssertion parses type assertions x.(T) and the guard x.(type)
+// of a type switch after the '.'
+func (p *Parser) parseTypeAssertion(x ast.ASTNode, pos token.Position) ast.ASTNode {
+	p.nextToken() // '(' を消費
 
-		// struct literal かチェック (Person{...})
-		if p.currentToken.Type == token.LBRACE {
-			return p.parseStructLiteral(name)
+	assert := &ast.TypeAssertNode{Expression: x, Pos: pos}
+	if p.currentToken.Type == token.TYPE {
+		if !p.inSwitchHeader {
+			p.error(p.currentToken.Pos, "use of .(type) outside type switch")
 		}
+		p.nextToken()
+	} else {
+		assert.Type = ast.TypeName(p.parseType("type"))
+	}
 
-		// 変数または struct instance として処理
-		var result ast.ASTNode = &ast.VariableNode{Name: name}
+	p.expect(token.RPAREN, "')' after type") // ')' を消費
+	return assert
+}
 
-		// field access チェック (.field) or index access ([index])
-		for p.currentToken.Type == token.PERIOD || p.currentToken.Type == token.LBRACK {
-			if p.currentToken.Type == token.PERIOD {
-				p.nextToken() // '.' を消費
-				if p.currentToken.Type == token.IDENT {
-					fieldName := p.currentToken.Literal
-					p.nextToken()
-					result = &ast.FieldAccessNode{
-						Object: result,
-						Field:  fieldName,
-					}
-				}
-			} else if p.currentToken.Type == token.LBRACK {
-				p.nextToken() // '[' を消費
-				index := p.ParseExpression()
-				if p.currentToken.Type == token.RBRACK {
-					p.nextToken() // ']' を消費
-				}
-				result = &ast.IndexAccess{
-					Object: result,
-					Index:  index,
-				}
+// parseIndexExpr parses index access x[index] and the slice expressions
+// x[low:high] and x[low:high:max]
+func (p *Parser) parseIndexExpr(x ast.ASTNode) ast.ASTNode {
+	pos := p.currentToken.Pos
+	p.nextToken() // '[' を消費
+
+	restore := p.setNoStructLiteral(false)
+	defer restore()
+
+	var index ast.ASTNode
+	if p.currentToken.Type != token.COLON {
+		index = p.ParseExpression()
+		if p.currentToken.Type != token.COLON {
+			p.expect(token.RBRACK, "']'") // ']' を消費
+			return &ast.IndexAccess{
+				Object: x,
+				Index:  index,
+				Pos:    pos,
 			}
 		}
-
-		return result
 	}
 
-	if p.currentToken.Type == token.LPAREN {
-		p.nextToken() // '(' を消費
-		expr := p.ParseExpression()
-		if p.currentToken.Type == token.RPAREN {
-			p.nextToken() // ')' を消費
+	slice := &ast.SliceExpr{Object: x, Low: index, Pos: pos}
+	p.nextToken() // ':' を消費
+	if p.currentToken.Type != token.COLON && p.currentToken.Type != token.RBRACK {
+		slice.High = p.ParseExpression()
+	}
+	if p.currentToken.Type == token.COLON {
+		slice.Slice3 = true
+		if slice.High == nil {
+			p.error(p.currentToken.Pos, "middle index required in 3-index slice")
+		}
+		p.nextToken() // ':' を消費
+		if p.currentToken.Type == token.RBRACK {
+			p.error(p.currentToken.Pos, "final index required in 3-index slice")
+		} else {
+			slice.Max = p.ParseExpression()
 		}
-		return expr
 	}
 
-	// Slice literal: []type{elements...}
-	if p.currentToken.Type == token.LBRACK {
-		p.nextToken() // '[' を消費
-		if p.currentToken.Type == token.RBRACK {
-			p.nextToken() // ']' を消費
-			if p.currentToken.Type == token.IDENT {
-				elementType := p.currentToken.Literal
-				p.nextToken()
-				if p.currentToken.Type == token.LBRACE {
-					return p.parseSliceLiteral(elementType)
-				}
-			}
+	p.expect(token.RBRACK, "']'") // ']' を消費
+	return slice
+}
+
+// parseCallExpr parses function calls name(arguments...), method calls
+// recv.name(arguments...) and calls of other function values such as f(1)(2)
+func (p *Parser) parseCallExpr(x ast.ASTNode) ast.ASTNode {
+	if fn, ok := x.(*ast.VariableNode); ok && fn.Name == "new" {
+		return p.parseNewExpr(fn.Pos)
+	}
+
+	var call *ast.CallNode
+	switch fn := x.(type) {
+	case *ast.VariableNode:
+		call = &ast.CallNode{Function: fn.Name, Pos: fn.Pos}
+	case *ast.FieldAccessNode:
+		// メソッド呼び出し: recv.Method(...)
+		call = &ast.CallNode{Function: fn.Field, Receiver: fn.Object, Pos: fn.Pos}
+	default:
+		// 関数値の呼び出し: f(1)(2), func() { ... }()
+		call = &ast.CallNode{Callee: x, Pos: x.Position()}
+	}
+	p.nextToken() // '(' を消費
+
+	arguments := []ast.ASTNode{}
+
+	// 引数をパース (括弧の中では struct literal が使える)
+	restore := p.setNoStructLiteral(false)
+	for p.currentToken.Type != token.RPAREN && p.currentToken.Type != token.EOF {
+		arg := p.ParseExpression()
+		arguments = append(arguments, arg)
+
+		if p.currentToken.Type != token.COMMA {
+			break
 		}
+		p.nextToken() // ',' を消費
 	}
+	restore()
 
-	// エラーケース: とりあえず 0 を返す
-	return &ast.NumberNode{Value: 0}
+	p.expect(token.RPAREN, "',' or ')' in argument list") // ')' を消費
+
+	call.Arguments = arguments
+	return p.foldConversion(call)
+}
+
+// parseNewExpr parses the type argument of the built-in new: new(T)
+func (p *Parser) parseNewExpr(pos token.Position) ast.ASTNode {
+	p.nextToken() // '(' を消費
+
+	t := p.parseType("type")
+	p.expect(token.RPAREN, "')' after type")
+
+	return &ast.NewNode{Type: t, Pos: pos}
+}
+
+// parseCompositeLiteral parses struct literals: Person{...}
+func (p *Parser) parseCompositeLiteral(x ast.ASTNode) ast.ASTNode {
+	typeName := x.(*ast.VariableNode)
+	return p.parseStructLiteral(typeName.Name, typeName.Pos)
 }
 
-// parseFuncStatement parses function definitions: func name(param type, ...) returnType { body }
+// isStructLiteralType reports whether a '{' after x starts a struct literal
+func (p *Parser) isStructLiteralType(x ast.ASTNode) bool {
+	_, ok := x.(*ast.VariableNode)
+	return ok && !p.noStructLiteral
+}
+
+// tokenText describes a token for error messages
+func tokenText(tok token.TokenInfo) string {
+	switch {
+	case tok.Type == token.EOF:
+		return "EOF"
+	case tok.Type == token.SEMICOLON && tok.Literal == "\n":
+		return "newline"
+	}
+	return tok.Literal
+}
+
+// parseFuncStatement parses function and method definitions:
+// func [(receiver)] name(param type, ...) results { body }
 func (p *Parser) parseFuncStatement() ast.Statement {
+	pos := p.currentToken.Pos
+	defer p.openScope()()
+
 	// consume 'func'
 	p.nextToken()
 
+	// receiver (methods only)
+	var receiver *ast.Parameter
+	if p.currentToken.Type == token.LPAREN {
+		receiver = p.parseReceiver()
+	}
+
 	// function name
-	name := p.currentToken.Literal
-	p.nextToken()
+	name := p.expectIdent("function name")
 
 	// consume '('
-	if p.currentToken.Type != token.LPAREN {
-		// Error: expected '('
-		return nil
+	if !p.expect(token.LPAREN, "'(' after function name") {
+		return &ast.FuncStatement{Name: name, Receiver: receiver, Pos: pos}
 	}
-	p.nextToken()
 
 	// parse parameters
-	parameters := []ast.Parameter{}
-	for p.currentToken.Type != token.RPAREN && p.currentToken.Type != token.EOF {
-		// parameter name
-		paramName := p.currentToken.Literal
-		p.nextToken()
-
-		// parameter type
-		paramType := p.currentToken.Literal
-		p.nextToken()
-
-		parameters = append(parameters, ast.Parameter{Name: paramName, Type: paramType})
-
-		// skip comma if present
-		if p.currentToken.Type == token.COMMA {
-			p.nextToken()
-		}
+	parameters, ok := p.parseParameterList("parameter")
+	if !ok {
+		return &ast.FuncStatement{Name: name, Receiver: receiver, Parameters: parameters, Pos: pos}
 	}
 
-	// consume ')'
-	if p.currentToken.Type == token.RPAREN {
-		p.nextToken()
-	}
-
-	// return type (optional for now, default to empty)
-	returnType := ""
-	if p.currentToken.Type == token.IDENT {
-		returnType = p.currentToken.Literal
-		p.nextToken()
+	// results (optional)
+	results := p.parseResults()
+	if receiver != nil {
+		p.declareVar(receiver.Name)
 	}
+	p.declareParameters(parameters, results)
 
 	// function body
 	var body *ast.BlockStatement
 	if p.currentToken.Type == token.LBRACE {
 		body = p.parseBlockStatement()
+		p.checkBranches(body.Statements)
+	} else {
+		p.errorExpected("'{' to start function body")
 	}
 
 	return &ast.FuncStatement{
 		Name:       name,
+		Receiver:   receiver,
 		Parameters: parameters,
-		ReturnType: returnType,
+		Results:    results,
 		Body:       body,
+		Pos:        pos,
+	}
+}
+
+// parseFuncLiteral parses function literals: func(param type, ...) results { body }
+func (p *Parser) parseFuncLiteral() ast.ASTNode {
+	pos := p.currentToken.Pos
+	p.nextToken() // 'func' を消費
+	defer p.openScope()()
+
+	literal := &ast.FuncLiteral{Pos: pos}
+	if !p.expect(token.LPAREN, "'(' after func") {
+		return literal
 	}
+	parameters, ok := p.parseParameterList("parameter")
+	literal.Parameters = parameters
+	if !ok {
+		return literal
+	}
+	literal.Results = p.parseResults()
+	p.declareParameters(literal.Parameters, literal.Results)
+
+	if p.currentToken.Type != token.LBRACE {
+		p.errorExpected("'{' to start function body")
+		return literal
+	}
+	literal.Body = p.parseBlockStatement()
+	p.checkBranches(literal.Body.Statements)
+	return literal
 }
 
-// parseReturnStatement parses return statements: return [expression]
+// parseMapLiteral parses map literals: map[string]int{"a": 1, "b": 2}
+func (p *Parser) parseMapLiteral() ast.ASTNode {
+	pos := p.currentToken.Pos
+	t := p.parseMapType()
+	if t == nil {
+		return &ast.MapLiteral{Pos: pos}
+	}
+	if p.currentToken.Type != token.LBRACE {
+		p.errorExpected("'{' after map type")
+		return &ast.MapLiteral{KeyType: t.Key, ValueType: t.Value, Pos: pos}
+	}
+	return p.parseLiteralOf(t, pos)
+}
+
+// parseReceiver parses the receiver of a method: (p Person), (p *Person) or (Person)
+func (p *Parser) parseReceiver() *ast.Parameter {
+	p.nextToken() // '(' を消費
+
+	receiver := &ast.Parameter{Pos: p.currentToken.Pos}
+
+	// 名前は省略できる
+	if p.currentToken.Type == token.IDENT && p.peekToken.Type != token.RPAREN {
+		receiver.Name = p.currentToken.Literal
+		p.nextToken()
+	}
+
+	// ポインタレシーバ
+	typePos := p.currentToken.Pos
+	pointer := p.currentToken.Type == token.MUL
+	if pointer {
+		p.nextToken()
+	}
+
+	namePos := p.currentToken.Pos
+	typeName := p.expectIdent("receiver type")
+	p.expect(token.RPAREN, "')' after receiver")
+
+	if typeName == "" {
+		return nil
+	}
+	receiver.Type = &ast.IdentType{Name: typeName, Pos: namePos}
+	if pointer {
+		receiver.Type = &ast.PointerType{Elem: receiver.Type, Pos: typePos}
+	}
+	return receiver
+}
+
+// parseResults parses an optional result list after the parameters:
+// a single type, (T1, T2) or named results such as (q, r int, err string)
+func (p *Parser) parseResults() []ast.Parameter {
+	if p.currentToken.Type == token.LPAREN {
+		p.nextToken() // '(' を消費
+		results, _ := p.parseParameterList("result")
+		return results
+	}
+	if !p.isTypeStart() {
+		return nil
+	}
+	pos := p.currentToken.Pos
+	return []ast.Parameter{{Type: p.parseType("result type"), Pos: pos}}
+}
+
+// parseParameterList parses a parameter or result list after its '(' up to
+// and including the ')'. Each element is a type alone or a name and a type;
+// names without a type share the following one: (a, b int). ok is false when
+// the closing ')' is missing.
+func (p *Parser) parseParameterList(what string) (params []ast.Parameter, ok bool) {
+	params = []ast.Parameter{}
+	named := false
+	for p.currentToken.Type != token.RPAREN && p.currentToken.Type != token.EOF {
+		pos := p.currentToken.Pos
+		isIdent := p.currentToken.Type == token.IDENT
+		t := p.parseType(what + " type")
+		if t == nil {
+			break
+		}
+
+		param := ast.Parameter{Type: t, Pos: pos}
+		if isIdent && p.isTypeStart() {
+			param.Name = ast.TypeName(t)
+			param.Type = p.parseType(what + " type")
+			named = true
+		}
+		params = append(params, param)
+
+		if p.currentToken.Type != token.COMMA {
+			break
+		}
+		p.nextToken() // ',' を消費
+	}
+
+	ok = p.expect(token.RPAREN, "',' or ')' in "+what+" list")
+
+	if named {
+		// 型を省略した名前は後ろの型を共有する: (q, r int)
+		var shared ast.Type
+		for i := len(params) - 1; i >= 0; i-- {
+			if params[i].Name != "" {
+				shared = params[i].Type
+				continue
+			}
+			name, isName := params[i].Type.(*ast.IdentType)
+			if shared == nil || !isName {
+				p.error(params[i].Pos, "mixed named and unnamed "+what+"s")
+				break
+			}
+			params[i].Name = name.Name
+			params[i].Type = shared
+		}
+	}
+
+	return params, ok
+}
+
+// parseReturnStatement parses return statements: return [expression {, expression}]
 func (p *Parser) parseReturnStatement() ast.Statement {
+	pos := p.currentToken.Pos
+
 	// consume 'return'
 	p.nextToken()
 
-	// parse optional return value
-	var value ast.ASTNode
-	if p.currentToken.Type != token.EOF && p.currentToken.Type != token.RBRACE {
-		value = p.ParseExpression()
+	// parse optional return values
+	var values []ast.ASTNode
+	if p.currentToken.Type != token.EOF && p.currentToken.Type != token.RBRACE &&
+		p.currentToken.Type != token.SEMICOLON {
+		values = p.parseExpressionList()
 	}
 
-	return &ast.ReturnStatement{Value: value}
+	return &ast.ReturnStatement{Values: values, Pos: pos}
 }
 
-// parseStructDefinition parses struct definitions: type Person struct { Name string; Age int }
+func (p *Parser) parseDeferStatement() ast.Statement {
+	pos := p.currentToken.Pos
 
-// parseStructLiteral parses struct literals: Person{Name: "Alice", Age: 25}
-func (p *Parser) parseStructLiteral(typeName string) ast.ASTNode {
-	// '{' は既に確認済み
+	// defer
+	p.nextToken()
+	callPos := p.currentToken.Pos
+	call, ok := p.ParseExpression().(*ast.CallNode)
+	if !ok {
+		p.error(callPos, "expression in defer must be function call")
+		return &ast.DeferStatement{Call: &ast.CallNode{Pos: callPos}, Pos: pos}
+	}
+	return &ast.DeferStatement{Call: call, Pos: pos}
+}
+
+// parseLiteralOf parses the elements of a composite literal of type t
+// from its '{'
+func (p *Parser) parseLiteralOf(t ast.Type, pos token.Position) ast.ASTNode {
+	switch t := t.(type) {
+	case *ast.SliceType:
+		return &ast.SliceLiteral{
+			ElementType: t.Elem,
+			Elements:    p.parseElements(t.Elem, "slice literal"),
+			Pos:         pos,
+		}
+	case *ast.ArrayType:
+		return p.parseArrayLiteral(t, pos)
+	case *ast.MapType:
+		return p.parseMapEntries(t, pos)
+	case *ast.IdentType:
+		if !constant.IsConstType(t.Name) {
+			return p.parseStructLiteral(t.Name, pos)
+		}
+	}
+
+	// 要素は読み飛ばす
+	p.error(pos, "invalid composite literal type "+ast.TypeName(t))
+	p.parseElements(nil, "composite literal")
+	return &ast.SliceLiteral{Pos: pos}
+}
+
+// parseElement parses an element, a key or a value of a composite literal
+// whose elements are of type elem. The type of an element that is itself a
+// composite literal may be elided: {1, 2} stands for []int{1, 2} in a
+// [][]int and for &Point{1, 2} in a []*Point.
+func (p *Parser) parseElement(elem ast.Type) ast.ASTNode {
+	if p.currentToken.Type != token.LBRACE {
+		return p.ParseExpression()
+	}
+	pos := p.currentToken.Pos
+	if pointer, ok := elem.(*ast.PointerType); ok {
+		return &ast.UnaryOpNode{
+			Operator: token.AND,
+			Operand:  p.parseLiteralOf(pointer.Elem, pos),
+			Pos:      pos,
+		}
+	}
+	return p.parseLiteralOf(elem, pos)
+}
+
+// parseElements parses the elements of a slice or array literal from its
+// '{' up to and including the '}'
+func (p *Parser) parseElements(elem ast.Type, what string) []ast.ASTNode {
 	p.nextToken() // '{' を消費
+	defer p.setNoStructLiteral(false)()
 
-	fields := make(map[string]ast.ASTNode)
+	var elements []ast.ASTNode
 
 	for p.currentToken.Type != token.RBRACE && p.currentToken.Type != token.EOF {
-		// field name
-		if p.currentToken.Type != token.IDENT {
+		elements = append(elements, p.parseElement(elem))
+
+		if p.currentToken.Type != token.COMMA {
 			break
 		}
-		fieldName := p.currentToken.Literal
-		p.nextToken()
+		p.nextToken() // ',' を消費
+	}
 
-		// expect ':'
-		if p.currentToken.Type != token.COLON {
-			break // error: expected ':'
-		}
-		p.nextToken() // ':' を消費
+	// consume '}'
+	p.expect(token.RBRACE, "',' or '}' in "+what)
 
-		// field value
-		value := p.ParseExpression()
-		fields[fieldName] = value
+	return elements
+}
 
-		// skip comma if present
-		if p.currentToken.Type == token.COMMA {
-			p.nextToken()
-		}
+// parseArrayLiteral parses array literals: [3]int{1, 2} or [...]int{1, 2, 3}
+func (p *Parser) parseArrayLiteral(t *ast.ArrayType, pos token.Position) ast.ASTNode {
+	elements := p.parseElements(t.Elem, "array literal")
+
+	// [...]T の長さは要素数になる
+	size := t.Len
+	if size < 0 {
+		size = len(elements)
+	} else if len(elements) > size {
+		p.error(elements[size].Position(), "array index "+strconv.Itoa(size)+" out of bounds [0:"+strconv.Itoa(size)+"]")
 	}
 
-	// consume '}'
-	if p.currentToken.Type == token.RBRACE {
-		p.nextToken()
+	return &ast.ArrayLiteral{
+		ElementType: t.Elem,
+		Size:        size,
+		Elements:    elements,
+		Pos:         pos,
 	}
+}
 
-	return &ast.StructLiteral{
-		TypeName: typeName,
-		Fields:   fields,
+// parseMapEntries parses the entries of a map literal from its '{'
+func (p *Parser) parseMapEntries(t *ast.MapType, pos token.Position) ast.ASTNode {
+	literal := &ast.MapLiteral{KeyType: t.Key, ValueType: t.Value, Pos: pos}
+	p.nextToken() // '{' を消費
+	defer p.setNoStructLiteral(false)()
+
+	for p.currentToken.Type != token.RBRACE && p.currentToken.Type != token.EOF {
+		entry := &ast.KeyValue{Key: p.parseElement(t.Key)}
+		if !p.expect(token.COLON, "':' after map key") {
+			return literal
+		}
+		entry.Value = p.parseElement(t.Value)
+		literal.Entries = append(literal.Entries, entry)
+
+		if p.currentToken.Type != token.COMMA {
+			break
+		}
+		p.nextToken() // ',' を消費
 	}
+
+	p.expect(token.RBRACE, "',' or '}' in map literal")
+	return literal
 }
 
-// parseSliceLiteral parses slice literals: []int{1, 2, 3}
-func (p *Parser) parseSliceLiteral(elementType string) ast.ASTNode {
+// parseStructLiteral parses struct literals: Person{Name: "Alice", Age: 25}
+// or Person{"Alice", 25} with the values of all fields in order
+func (p *Parser) parseStructLiteral(typeName string, pos token.Position) ast.ASTNode {
 	// '{' は既に確認済み
 	p.nextToken() // '{' を消費
+	defer p.setNoStructLiteral(false)()
 
-	var elements []ast.ASTNode
+	fields := make(map[string]ast.ASTNode)
+	var values []ast.ASTNode
+	mixed := false
 
 	for p.currentToken.Type != token.RBRACE && p.currentToken.Type != token.EOF {
-		element := p.ParseExpression()
-		elements = append(elements, element)
+		keyed := p.currentToken.Type == token.IDENT && p.peekToken.Type == token.COLON
+		if !mixed && ((keyed && values != nil) || (!keyed && len(fields) > 0)) {
+			p.error(p.currentToken.Pos, "mixture of field:value and value elements in struct literal")
+			mixed = true
+		}
 
-		// skip comma if present
-		if p.currentToken.Type == token.COMMA {
+		if keyed {
+			// field name
+			fieldName := p.currentToken.Literal
 			p.nextToken()
+			p.nextToken() // ':' を消費
+
+			// field value
+			fields[fieldName] = p.ParseExpression()
+		} else {
+			// フィールドの順の値
+			values = append(values, p.ParseExpression())
+		}
+
+		if p.currentToken.Type != token.COMMA {
+			break
 		}
+		p.nextToken() // ',' を消費
 	}
 
 	// consume '}'
-	if p.currentToken.Type == token.RBRACE {
-		p.nextToken()
-	}
+	p.expect(token.RBRACE, "',' or '}' in struct literal")
 
-	return &ast.SliceLiteral{
-		ElementType: elementType,
-		Elements:    elements,
+	return &ast.StructLiteral{
+		TypeName: typeName,
+		Fields:   fields,
+		Values:   values,
+		Pos:      pos,
 	}
 }
 
 // parsePackageStatement parses package declarations: package main
 func (p *Parser) parsePackageStatement() ast.Statement {
+	pos := p.currentToken.Pos
+
 	// consume 'package'
 	p.nextToken()
 
 	// package name
-	if p.currentToken.Type != token.IDENT {
-		// error: expected package name
-		return nil
-	}
-	name := p.currentToken.Literal
-	p.nextToken()
+	name := p.expectIdent("package name")
 
 	return &ast.PackageStatement{
 		Name: name,
+		Pos:  pos,
 	}
 }
 
-// parseImportStatement parses import declarations: import "fmt"
+// parseImportStatement parses import declarations: import "fmt" or a
+// group import ( "fmt"; "os" )
 func (p *Parser) parseImportStatement() ast.Statement {
+	pos := p.currentToken.Pos
+
 	// consume 'import'
 	p.nextToken()
 
-	// import path (string literal)
-	if p.currentToken.Type != token.STRING {
-		// error: expected string literal
-		return nil
+	if p.currentToken.Type == token.LPAREN {
+		return p.parseDeclGroup("import", pos, p.parseImportSpec)
 	}
+	return p.parseImportSpec(pos)
+}
+
+// parseImportSpec parses the path of one imported package
+func (p *Parser) parseImportSpec(pos token.Position) ast.Statement {
+	// import path (string literal)
 	path := p.currentToken.Literal
-	p.nextToken()
+	if !p.expect(token.STRING, "import path") {
+		path = ""
+	}
 
 	return &ast.ImportStatement{
 		Path: path,
+		Pos:  pos,
+	}
+}
+
+// parseIntLiteral converts an integer literal in any Go base to an int.
+// ok is false if the value does not fit in an int.
+func parseIntLiteral(literal string) (value int, ok bool) {
+	const maxInt = int(^uint(0) >> 1)
+
+	base := 10
+	digits := literal
+	if len(literal) >= 2 && literal[0] == '0' {
+		switch literal[1] {
+		case 'x', 'X':
+			base, digits = 16, literal[2:]
+		case 'o', 'O':
+			base, digits = 8, literal[2:]
+		case 'b', 'B':
+			base, digits = 2, literal[2:]
+		default:
+			base, digits = 8, literal[1:]
+		}
+	}
+
+	// 手動で文字列を数値に変換（strconv なしで）
+	for i := 0; i < len(digits); i++ {
+		ch := digits[i]
+		var digit int
+		switch {
+		case ch == '_':
+			continue
+		case ch >= '0' && ch <= '9':
+			digit = int(ch - '0')
+		case ch >= 'a' && ch <= 'f':
+			digit = int(ch-'a') + 10
+		case ch >= 'A' && ch <= 'F':
+			digit = int(ch-'A') + 10
+		}
+		if value > (maxInt-digit)/base {
+			return 0, false
+		}
+		value = value*base + digit
 	}
+	return value, true
 }
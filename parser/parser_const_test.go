@@ -0,0 +1,194 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/scanner"
+)
+
+// parseStatements parses every statement of input and fails on errors
+func parseStatements(t *testing.T, input string) []ast.Statement {
+	t.Helper()
+	p := NewParser(scanner.NewScanner(input))
+	var statements []ast.Statement
+	for {
+		stmt := p.ParseStatement()
+		if stmt == nil {
+			break
+		}
+		statements = append(statements, stmt)
+	}
+	if errs := p.Errors(); len(errs) != 0 {
+		t.Fatalf("%q: unexpected errors %v", input, errs)
+	}
+	return statements
+}
+
+func TestParser_ConstStatement(t *testing.T) {
+	input := `const (
+	A = iota
+	B
+	_
+	D
+	KB = 1 << (10 * (iota - 3))
+	MB
+)`
+	stmt, ok := parseStatements(t, input)[0].(*ast.ConstStatement)
+	if !ok {
+		t.Fatalf("expected *ast.ConstStatement")
+	}
+
+	expected := []struct {
+		name     string
+		repeated bool
+		value    string
+	}{
+		{"A", false, "0"},
+		{"B", true, "1"},
+		{"_", true, "2"},
+		{"D", true, "3"},
+		{"KB", false, "1024"},
+		{"MB", true, "1048576"},
+	}
+	if len(stmt.Specs) != len(expected) {
+		t.Fatalf("expected %d specs, got %d", len(expected), len(stmt.Specs))
+	}
+	for i, tt := range expected {
+		spec := stmt.Specs[i]
+		if spec.Names[0] != tt.name || spec.Iota != i {
+			t.Errorf("spec %d: expected %s with iota %d, got %s with iota %d", i, tt.name, i, spec.Names[0], spec.Iota)
+		}
+		if (spec.Values == nil) != tt.repeated {
+			t.Errorf("%s: expected repeated %v, got values %v", tt.name, tt.repeated, spec.Values)
+		}
+		if got := spec.Constants[0].String(); got != tt.value {
+			t.Errorf("%s: expected value %s, got %s", tt.name, tt.value, got)
+		}
+	}
+}
+
+func TestParser_ConstSpecs(t *testing.T) {
+	tests := []struct {
+		input    string
+		typeName string
+		values   []string
+		types    []string
+	}{
+		{"const x = 1", "", []string{"1"}, []string{"untyped int"}},
+		{"const a, b = 'a', 2.5", "", []string{"97", "2.5"}, []string{"untyped rune", "untyped float"}},
+		{"const s string = \"hi\"", "string", []string{`"hi"`}, []string{"string"}},
+		{"const f float64 = 1 << 3", "float64", []string{"8"}, []string{"float64"}},
+		{"const b byte = 'z' - 'a'", "byte", []string{"25"}, []string{"uint8"}},
+		{"const ok = 1 < 2 && \"a\" != \"b\"", "", []string{"true"}, []string{"untyped bool"}},
+	}
+
+	for _, tt := range tests {
+		stmt := parseStatements(t, tt.input)[0].(*ast.ConstStatement)
+		spec := stmt.Specs[0]
+		if ast.TypeName(spec.Type) != tt.typeName {
+			t.Errorf("%q: expected type %q, got %q", tt.input, tt.typeName, ast.TypeName(spec.Type))
+		}
+		for i, v := range spec.Constants {
+			if v.String() != tt.values[i] || v.Type != tt.types[i] {
+				t.Errorf("%q: expected %s (%s), got %s (%s)", tt.input, tt.values[i], tt.types[i], v, v.Type)
+			}
+		}
+	}
+}
+
+func TestParser_ConstExpr(t *testing.T) {
+	statements := parseStatements(t, "const Big = 1 << 100\nx := Big >> 98 + n\ny := 1 << 62 * 2 / 4\nz := 0.1 + 0.2 == 0.3")
+
+	// 定数の部分式だけが ConstExpr になる
+	assign := statements[1].(*ast.AssignStatement)
+	sum, ok := assign.Value.(*ast.BinaryOpNode)
+	if !ok {
+		t.Fatalf("expected *ast.BinaryOpNode, got %T", assign.Value)
+	}
+	folded, ok := sum.Left.(*ast.ConstExpr)
+	if !ok {
+		t.Fatalf("expected *ast.ConstExpr, got %T", sum.Left)
+	}
+	if folded.Value.String() != "4" {
+		t.Errorf("expected Big >> 98 to be 4, got %s", folded.Value)
+	}
+	if _, ok := folded.Expression.(*ast.BinaryOpNode); !ok {
+		t.Errorf("expected the expression as written, got %T", folded.Expression)
+	}
+
+	// リテラルだけの式も正確な値に畳み込まれる
+	for i, expected := range map[int]string{2: "2305843009213693952", 3: "true"} {
+		value := statements[i].(*ast.AssignStatement).Value
+		if c, ok := value.(*ast.ConstExpr); !ok || c.Value.String() != expected {
+			t.Errorf("statement %d: expected the constant %s, got %v", i, expected, value)
+		}
+	}
+}
+
+func TestParser_ConstScopes(t *testing.T) {
+	input := `const N = 10
+func f(N int) int {
+	return N
+}
+func g() int {
+	N := "shadow"
+	return len(N)
+}
+func h() int {
+	{
+		const N = 20
+	}
+	return N
+}`
+	statements := parseStatements(t, input)
+
+	returned := func(i int) ast.ASTNode {
+		body := statements[i].(*ast.FuncStatement).Body
+		return body.Statements[len(body.Statements)-1].(*ast.ReturnStatement).Values[0]
+	}
+	if _, ok := returned(1).(*ast.VariableNode); !ok {
+		t.Errorf("expected a parameter to hide the constant, got %T", returned(1))
+	}
+	call := returned(2).(*ast.CallNode)
+	if _, ok := call.Arguments[0].(*ast.VariableNode); !ok {
+		t.Errorf("expected a variable to hide the constant, got %T", call.Arguments[0])
+	}
+	if c, ok := returned(3).(*ast.ConstExpr); !ok || c.Value.String() != "10" {
+		t.Errorf("expected the outer constant 10, got %v", returned(3))
+	}
+}
+
+func TestParser_ConstErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"const x uint8 = 256", "1:17: constant 256 overflows uint8"},
+		{"const x = 1 / 0", "1:13: invalid operation: division by zero"},
+		{"const x int = 2.5", "1:15: constant 2.5 truncated to integer"},
+		{"const x = y", "1:11: const initializer is not a constant"},
+		{"const x", "1:7: missing init expr for const declaration"},
+		{"const x, y = 1", "1:7: missing init expr for const declaration"},
+		{"const x = 1, 2", "1:14: extra init expr"},
+		{"const x []int = nil", "1:7: invalid constant type []int"},
+		{"const (\n\tA int32 = 1 << (iota * 16)\n\tB\n\tC\n)", "4:2: constant 4294967296 overflows int32"},
+		{"const (\n\tA = 1\n\tA = 2\n)", "3:2: A redeclared in this block"},
+		{"x := 1 << 63", "1:6: constant 9223372036854775808 overflows int"},
+		{"var b byte = 300", "1:14: constant 300 overflows uint8"},
+		{"x := uint8(256)", "1:12: constant 256 overflows uint8"},
+		{"const c = 1\nc = 2", "2:1: cannot assign to c (neither addressable nor a map index expression)"},
+		{"const c = 1\nc++", "2:1: cannot assign to c (neither addressable nor a map index expression)"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		for p.ParseStatement() != nil {
+		}
+
+		errs := p.Errors()
+		if len(errs) != 1 || errs[0].Error() != tt.expected {
+			t.Errorf("%q: expected error %q, got %v", tt.input, tt.expected, errs)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/scanner"
+)
+
+func TestParser_VarStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		name     string
+		typeName string
+		hasValue bool
+	}{
+		{"var x int = 1", "x", "int", true},
+		{"var x int", "x", "int", false},
+		{"var s = \"hi\"", "s", "", true},
+		{"var xs []int", "xs", "[]int", false},
+		{"var m map[string]int", "m", "map[string]int", false},
+		{"var f func(int) int", "f", "func(int) int", false},
+		{"var p Point", "p", "Point", false},
+	}
+
+	for _, tt := range tests {
+		stmt, ok := parseStatements(t, tt.input)[0].(*ast.VarStatement)
+		if !ok {
+			t.Fatalf("%q: expected *ast.VarStatement", tt.input)
+		}
+		if stmt.Name != tt.name || ast.TypeName(stmt.Type) != tt.typeName || (stmt.Value != nil) != tt.hasValue {
+			t.Errorf("%q: expected %s %q with value %v, got %s %q with value %v",
+				tt.input, tt.name, tt.typeName, tt.hasValue, stmt.Name, ast.TypeName(stmt.Type), stmt.Value)
+		}
+	}
+}
+
+func TestParser_TupleVarStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		names    []string
+		typeName string
+		values   int
+	}{
+		{"var a, b = 1, 2", []string{"a", "b"}, "", 2},
+		{"var q, r int", []string{"q", "r"}, "int", 0},
+		{"var x, y float64 = 1, 2.5", []string{"x", "y"}, "float64", 2},
+		{"var q, r = divmod(7, 2)", []string{"q", "r"}, "", 1},
+		{"var v, ok = m[\"k\"]", []string{"v", "ok"}, "", 1},
+		{"var _, b = 1, 2", []string{"_", "b"}, "", 2},
+	}
+
+	for _, tt := range tests {
+		stmt, ok := parseStatements(t, tt.input)[0].(*ast.TupleVarStatement)
+		if !ok {
+			t.Fatalf("%q: expected *ast.TupleVarStatement", tt.input)
+		}
+		if len(stmt.Names) != len(tt.names) || ast.TypeName(stmt.Type) != tt.typeName || len(stmt.Values) != tt.values {
+			t.Errorf("%q: expected %v %q with %d values, got %v %q with %d values",
+				tt.input, tt.names, tt.typeName, tt.values, stmt.Names, ast.TypeName(stmt.Type), len(stmt.Values))
+			continue
+		}
+		for i, name := range tt.names {
+			if stmt.Names[i] != name {
+				t.Errorf("%q: expected name %s, got %s", tt.input, name, stmt.Names[i])
+			}
+		}
+	}
+}
+
+func TestParser_DeclGroup(t *testing.T) {
+	input := `import (
+	"fmt"
+	"os"
+)
+var (
+	count int
+	name  = "petit"
+	a, b  = 1, 2
+)
+type (
+	Point struct {
+		X int
+		Y int
+	}
+	Shape interface {
+		Area() int
+	}
+)
+var ()`
+	statements := parseStatements(t, input)
+	if len(statements) != 4 {
+		t.Fatalf("expected 4 statements, got %d", len(statements))
+	}
+
+	expected := []struct {
+		keyword string
+		decls   []string
+	}{
+		{"import", []string{"ImportStatement", "ImportStatement"}},
+		{"var", []string{"VarStatement", "VarStatement", "TupleVarStatement"}},
+		{"type", []string{"TypeStatement", "InterfaceStatement"}},
+		{"var", nil},
+	}
+	for i, tt := range expected {
+		group, ok := statements[i].(*ast.DeclGroup)
+		if !ok {
+			t.Fatalf("statement %d: expected *ast.DeclGroup, got %T", i, statements[i])
+		}
+		if group.Keyword != tt.keyword || len(group.Decls) != len(tt.decls) {
+			t.Errorf("statement %d: expected %s group of %d, got %s group of %d", i, tt.keyword, len(tt.decls), group.Keyword, len(group.Decls))
+			continue
+		}
+		for j, decl := range group.Decls {
+			if decl.String() != tt.decls[j] {
+				t.Errorf("statement %d: expected %s, got %s", i, tt.decls[j], decl)
+			}
+		}
+	}
+
+	imports := statements[0].(*ast.DeclGroup).Decls
+	if imports[0].(*ast.ImportStatement).Path != "fmt" || imports[1].(*ast.ImportStatement).Path != "os" {
+		t.Errorf("expected paths fmt and os, got %v", imports)
+	}
+	if point := statements[2].(*ast.DeclGroup).Decls[0].(*ast.TypeStatement); point.Name != "Point" || len(point.Fields) != 2 {
+		t.Errorf("expected Point with 2 fields, got %s with %d", point.Name, len(point.Fields))
+	}
+}
+
+func TestParser_VarErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var x", "1:6: unexpected EOF, expected type or '=' after variable name"},
+		{"var a, b = 1", "1:5: assignment mismatch: 2 variables but 1 value"},
+		{"var a = 1, 2", "1:5: assignment mismatch: 1 variable but 2 values"},
+		{"var a, b int = 1, 2, 3", "1:5: assignment mismatch: 2 variables but 3 values"},
+		{"var b byte = 300", "1:14: constant 300 overflows uint8"},
+		{"var (\n\tx int y\n\tz = 1\n)", "2:8: unexpected y, expected ';' or ')' after var declaration"},
+		{"import (\n\t\"fmt\"\n\tos\n)", "3:2: unexpected os, expected import path"},
+		{"var (\n\tx int\n", "3:1: unexpected EOF, expected ')' at end of var declaration"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		for p.ParseStatement() != nil {
+		}
+
+		errs := p.Errors()
+		if len(errs) != 1 || errs[0].Error() != tt.expected {
+			t.Errorf("%q: expected error %q, got %v", tt.input, tt.expected, errs)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/scanner"
+)
+
+func TestParser_DeferStatements(t *testing.T) {
+	statements := parseStatements(t, `func f() {
+	defer println(1)
+	defer mu.Unlock()
+	defer func() {
+		recover()
+	}()
+	panic("boom")
+}`)
+
+	body := statements[0].(*ast.FuncStatement).Body.Statements
+	if len(body) != 4 {
+		t.Fatalf("expected 4 statements, got %d", len(body))
+	}
+
+	first, ok := body[0].(*ast.DeferStatement)
+	if !ok || first.Call.Function != "println" || len(first.Call.Arguments) != 1 {
+		t.Errorf("expected defer println(1), got %#v", body[0])
+	}
+	if second, ok := body[1].(*ast.DeferStatement); !ok || second.Call.Function != "Unlock" || second.Call.Receiver == nil {
+		t.Errorf("expected defer mu.Unlock(), got %#v", body[1])
+	}
+	third, ok := body[2].(*ast.DeferStatement)
+	if !ok {
+		t.Fatalf("expected a *ast.DeferStatement, got %T", body[2])
+	}
+	if _, ok := third.Call.Callee.(*ast.FuncLiteral); !ok {
+		t.Errorf("expected a deferred function literal, got %#v", third.Call.Callee)
+	}
+	if call, ok := body[3].(*ast.ExpressionStatement).Expression.(*ast.CallNode); !ok || call.Function != "panic" {
+		t.Errorf("expected panic(\"boom\"), got %#v", body[3])
+	}
+}
+
+func TestParser_DeferErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"func f() {\n\tdefer x\n}", []string{"2:8: expression in defer must be function call"}},
+		{"func f() {\n\tdefer 1 + g()\n}", []string{"2:8: expression in defer must be function call"}},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		for p.ParseStatement() != nil {
+		}
+
+		var got []string
+		for _, err := range p.Errors() {
+			got = append(got, err.Error())
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+			t.Errorf("%q: expected errors %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/yuya-takeyama/petitgo/ast"
@@ -26,18 +27,18 @@ func TestParser_FuncStatement(t *testing.T) {
 		t.Errorf("parameters count wrong. expected=2, got=%d", len(funcStmt.Parameters))
 	}
 
-	if funcStmt.Parameters[0].Name != "x" || funcStmt.Parameters[0].Type != "int" {
+	if funcStmt.Parameters[0].Name != "x" || ast.TypeName(funcStmt.Parameters[0].Type) != "int" {
 		t.Errorf("first parameter wrong. expected=x int, got=%s %s",
-			funcStmt.Parameters[0].Name, funcStmt.Parameters[0].Type)
+			funcStmt.Parameters[0].Name, ast.TypeName(funcStmt.Parameters[0].Type))
 	}
 
-	if funcStmt.Parameters[1].Name != "y" || funcStmt.Parameters[1].Type != "int" {
+	if funcStmt.Parameters[1].Name != "y" || ast.TypeName(funcStmt.Parameters[1].Type) != "int" {
 		t.Errorf("second parameter wrong. expected=y int, got=%s %s",
-			funcStmt.Parameters[1].Name, funcStmt.Parameters[1].Type)
+			funcStmt.Parameters[1].Name, ast.TypeName(funcStmt.Parameters[1].Type))
 	}
 
-	if funcStmt.ReturnType != "int" {
-		t.Errorf("return type wrong. expected=int, got=%s", funcStmt.ReturnType)
+	if len(funcStmt.Results) != 1 || ast.TypeName(funcStmt.Results[0].Type) != "int" {
+		t.Errorf("results wrong. expected=[int], got=%v", funcStmt.Results)
 	}
 
 	if funcStmt.Body == nil {
@@ -56,17 +57,17 @@ func TestParser_ReturnStatement(t *testing.T) {
 		t.Fatalf("stmt is not *ast.ReturnStatement. got=%T", stmt)
 	}
 
-	if returnStmt.Value == nil {
-		t.Error("return value is nil")
+	if len(returnStmt.Values) != 1 {
+		t.Fatalf("expected 1 return value, got %d", len(returnStmt.Values))
 	}
 
 	// Check if return value is a NumberNode with value 42
-	if numberNode, ok := returnStmt.Value.(*ast.NumberNode); ok {
+	if numberNode, ok := returnStmt.Values[0].(*ast.NumberNode); ok {
 		if numberNode.Value != 42 {
 			t.Errorf("return value wrong. expected=42, got=%d", numberNode.Value)
 		}
 	} else {
-		t.Errorf("return value is not NumberNode. got=%T", returnStmt.Value)
+		t.Errorf("return value is not NumberNode. got=%T", returnStmt.Values[0])
 	}
 }
 
@@ -81,7 +82,235 @@ func TestParser_EmptyReturn(t *testing.T) {
 		t.Fatalf("stmt is not *ast.ReturnStatement. got=%T", stmt)
 	}
 
-	if returnStmt.Value != nil {
-		t.Error("empty return should have nil value")
+	if returnStmt.Values != nil {
+		t.Error("empty return should have nil values")
+	}
+}
+
+func TestParser_FuncResults(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []ast.Parameter
+	}{
+		{"func f() {}", nil},
+		{"func f() int {}", []ast.Parameter{{Type: ast.NewIdentType("int")}}},
+		{"func f() (int, bool) {}", []ast.Parameter{{Type: ast.NewIdentType("int")}, {Type: ast.NewIdentType("bool")}}},
+		{"func f() (q, r int, ok bool) {}", []ast.Parameter{{Name: "q", Type: ast.NewIdentType("int")}, {Name: "r", Type: ast.NewIdentType("int")}, {Name: "ok", Type: ast.NewIdentType("bool")}}},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		funcStmt, ok := p.ParseStatement().(*ast.FuncStatement)
+		if !ok {
+			t.Fatalf("%q: expected *ast.FuncStatement", tt.input)
+		}
+		if len(p.Errors()) != 0 {
+			t.Fatalf("%q: unexpected errors %v", tt.input, p.Errors())
+		}
+		if len(funcStmt.Results) != len(tt.expected) {
+			t.Fatalf("%q: expected %d results, got %d", tt.input, len(tt.expected), len(funcStmt.Results))
+		}
+		for i, want := range tt.expected {
+			got := funcStmt.Results[i]
+			if got.Name != want.Name || ast.TypeName(got.Type) != ast.TypeName(want.Type) {
+				t.Errorf("%q: result %d: expected %s %s, got %s %s", tt.input, i, want.Name, ast.TypeName(want.Type), got.Name, ast.TypeName(got.Type))
+			}
+		}
+	}
+}
+
+func TestParser_MultipleReturnValues(t *testing.T) {
+	p := NewParser(scanner.NewScanner("return x, y + 1, f()"))
+	returnStmt, ok := p.ParseStatement().(*ast.ReturnStatement)
+	if !ok {
+		t.Fatal("expected *ast.ReturnStatement")
+	}
+	if len(returnStmt.Values) != 3 {
+		t.Fatalf("expected 3 return values, got %d", len(returnStmt.Values))
+	}
+	if _, ok := returnStmt.Values[2].(*ast.CallNode); !ok {
+		t.Errorf("expected third value to be *ast.CallNode, got %T", returnStmt.Values[2])
+	}
+}
+
+func TestParser_TupleAssignStatement(t *testing.T) {
+	tests := []struct {
+		input  string
+		names  []string
+		define bool
+		values int
+	}{
+		{"a, b := 1, 2", []string{"a", "b"}, true, 2},
+		{"a, b = b, a", []string{"a", "b"}, false, 2},
+		{"v, ok := lookup(k)", []string{"v", "ok"}, true, 1},
+		{"_, err = f()", []string{"_", "err"}, false, 1},
+		{"xs[i], xs[j] = xs[j], xs[i]", []string{"", ""}, false, 2},
+		{"p.x, *q = f()", []string{"", ""}, false, 1},
+		{"m[k], ok = v, true", []string{"", "ok"}, false, 2},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		stmt, ok := p.ParseStatement().(*ast.TupleAssignStatement)
+		if !ok {
+			t.Fatalf("%q: expected *ast.TupleAssignStatement", tt.input)
+		}
+		if len(p.Errors()) != 0 {
+			t.Fatalf("%q: unexpected errors %v", tt.input, p.Errors())
+		}
+		if strings.Join(stmt.Names(), ",") != strings.Join(tt.names, ",") {
+			t.Errorf("%q: expected names %v, got %v", tt.input, tt.names, stmt.Names())
+		}
+		if stmt.Define != tt.define {
+			t.Errorf("%q: expected define=%v, got %v", tt.input, tt.define, stmt.Define)
+		}
+		if len(stmt.Values) != tt.values {
+			t.Errorf("%q: expected %d values, got %d", tt.input, tt.values, len(stmt.Values))
+		}
+	}
+}
+
+func TestParser_MultipleResultErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a, b := 1, 2, 3", "1:1: assignment mismatch: 2 variables but 3 values"},
+		{"a, b := 1", "1:1: assignment mismatch: 2 variables but 1 value"},
+		{"a, 1 = 2, 3", "1:4: cannot assign to value (neither addressable nor a map index expression)"},
+		{"a, xs[0] := 2, 3", "1:4: non-name on left side of :="},
+		{"a, b += 1", "1:6: unexpected +=, expected ':=' or '=' after assignment targets"},
+		{"func f() (a int, b) {}", "1:18: mixed named and unnamed results"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		p.ParseStatement()
+
+		errs := p.Errors()
+		if len(errs) != 1 || errs[0].Error() != tt.expected {
+			t.Errorf("%q: expected error %q, got %v", tt.input, tt.expected, errs)
+		}
+	}
+}
+
+func TestParser_MethodDeclarations(t *testing.T) {
+	tests := []struct {
+		input        string
+		receiver     ast.Parameter
+		receiverType string
+	}{
+		{"func (p Person) Greet() string { return p.Name }", ast.Parameter{Name: "p", Type: ast.NewIdentType("Person")}, "Person"},
+		{"func (p *Person) Rename(name string) {}", ast.Parameter{Name: "p", Type: &ast.PointerType{Elem: ast.NewIdentType("Person")}}, "Person"},
+		{"func (Person) Kind() string { return \"person\" }", ast.Parameter{Type: ast.NewIdentType("Person")}, "Person"},
+		{"func (*Person) Reset() {}", ast.Parameter{Type: &ast.PointerType{Elem: ast.NewIdentType("Person")}}, "Person"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		funcStmt, ok := p.ParseStatement().(*ast.FuncStatement)
+		if !ok {
+			t.Fatalf("%q: expected *ast.FuncStatement", tt.input)
+		}
+		if len(p.Errors()) != 0 {
+			t.Fatalf("%q: unexpected errors %v", tt.input, p.Errors())
+		}
+		if funcStmt.Receiver == nil {
+			t.Fatalf("%q: expected a receiver", tt.input)
+		}
+		if funcStmt.Receiver.Name != tt.receiver.Name || ast.TypeName(funcStmt.Receiver.Type) != ast.TypeName(tt.receiver.Type) {
+			t.Errorf("%q: expected receiver %s %s, got %s %s", tt.input,
+				tt.receiver.Name, ast.TypeName(tt.receiver.Type), funcStmt.Receiver.Name, ast.TypeName(funcStmt.Receiver.Type))
+		}
+		if funcStmt.ReceiverType() != tt.receiverType {
+			t.Errorf("%q: expected receiver type %s, got %s", tt.input, tt.receiverType, funcStmt.ReceiverType())
+		}
+	}
+}
+
+func TestParser_MethodCall(t *testing.T) {
+	p := NewParser(scanner.NewScanner("p.Move(1, 2)"))
+	call, ok := p.ParseExpression().(*ast.CallNode)
+	if !ok {
+		t.Fatal("expected *ast.CallNode")
+	}
+	if call.Function != "Move" || len(call.Arguments) != 2 {
+		t.Errorf("expected Move with 2 arguments, got %s with %d", call.Function, len(call.Arguments))
+	}
+	if receiver, ok := call.Receiver.(*ast.VariableNode); !ok || receiver.Name != "p" {
+		t.Errorf("expected receiver p, got %v", call.Receiver)
+	}
+}
+
+func TestParser_ReceiverErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"func (p *) Greet() {}", "1:10: unexpected ), expected receiver type"},
+		{"func (p Person Greet() {}", "1:16: unexpected Greet, expected ')' after receiver"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		p.ParseStatement()
+
+		errs := p.Errors()
+		if len(errs) != 1 || errs[0].Error() != tt.expected {
+			t.Errorf("%q: expected error %q, got %v", tt.input, tt.expected, errs)
+		}
+	}
+}
+
+func TestParser_FuncLiteral(t *testing.T) {
+	p := NewParser(scanner.NewScanner("add := func(a, b int) int { return a + b }"))
+	stmt, ok := p.ParseStatement().(*ast.AssignStatement)
+	if !ok || len(p.Errors()) != 0 {
+		t.Fatalf("expected *ast.AssignStatement, errors %v", p.Errors())
+	}
+	lit, ok := stmt.Value.(*ast.FuncLiteral)
+	if !ok {
+		t.Fatalf("expected *ast.FuncLiteral, got %T", stmt.Value)
+	}
+	if len(lit.Parameters) != 2 || ast.TypeName(lit.Parameters[1].Type) != "int" || len(lit.Body.Statements) != 1 {
+		t.Errorf("unexpected literal %+v", lit)
+	}
+	if lit.TypeName() != "func(int, int) int" {
+		t.Errorf("expected type func(int, int) int, got %s", lit.TypeName())
+	}
+
+	// A literal called right away inside a function body
+	p = NewParser(scanner.NewScanner("func main() {\n\tfunc() { print(1) }()\n}"))
+	funcStmt, ok := p.ParseStatement().(*ast.FuncStatement)
+	if !ok || len(p.Errors()) != 0 {
+		t.Fatalf("expected *ast.FuncStatement, errors %v", p.Errors())
+	}
+	expr, ok := funcStmt.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("expected *ast.ExpressionStatement, got %T", funcStmt.Body.Statements[0])
+	}
+	call, ok := expr.Expression.(*ast.CallNode)
+	if !ok {
+		t.Fatalf("expected *ast.CallNode, got %T", expr.Expression)
+	}
+	if _, ok := call.Callee.(*ast.FuncLiteral); !ok {
+		t.Errorf("expected a call of a function literal, got %v", call.Callee)
+	}
+}
+
+func TestParser_FuncTypes(t *testing.T) {
+	p := NewParser(scanner.NewScanner("func apply(f func(int) int, g func(a, b string) (int, error)) func() {}"))
+	funcStmt, ok := p.ParseStatement().(*ast.FuncStatement)
+	if !ok || len(p.Errors()) != 0 {
+		t.Fatalf("expected *ast.FuncStatement, errors %v", p.Errors())
+	}
+	if ast.TypeName(funcStmt.Parameters[0].Type) != "func(int) int" {
+		t.Errorf("expected func(int) int, got %s", ast.TypeName(funcStmt.Parameters[0].Type))
+	}
+	if ast.TypeName(funcStmt.Parameters[1].Type) != "func(string, string) (int, error)" {
+		t.Errorf("expected func(string, string) (int, error), got %s", ast.TypeName(funcStmt.Parameters[1].Type))
+	}
+	if len(funcStmt.Results) != 1 || ast.TypeName(funcStmt.Results[0].Type) != "func()" {
+		t.Errorf("expected a func() result, got %+v", funcStmt.Results)
 	}
 }
@@ -0,0 +1,170 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/scanner"
+)
+
+func TestParser_InterfaceDeclaration(t *testing.T) {
+	input := `type Shape interface {
+	Area() int
+	Scale(a, b int) (int, error)
+	Reset()
+}`
+	p := NewParser(scanner.NewScanner(input))
+	stmt, ok := p.ParseStatement().(*ast.InterfaceStatement)
+	if !ok {
+		t.Fatal("expected *ast.InterfaceStatement")
+	}
+	if len(p.Errors()) != 0 {
+		t.Fatalf("unexpected errors %v", p.Errors())
+	}
+	if stmt.Name != "Shape" || len(stmt.Methods) != 3 {
+		t.Fatalf("expected Shape with 3 methods, got %s with %d", stmt.Name, len(stmt.Methods))
+	}
+
+	scale := stmt.Methods[1]
+	if scale.Name != "Scale" || len(scale.Parameters) != 2 || len(scale.Results) != 2 {
+		t.Fatalf("expected Scale(a, b int) (int, error), got %+v", scale)
+	}
+	if ast.TypeName(scale.Parameters[0].Type) != "int" || scale.Parameters[1].Name != "b" || ast.TypeName(scale.Results[1].Type) != "error" {
+		t.Errorf("unexpected signature %+v", scale)
+	}
+	if len(stmt.Methods[0].Results) != 1 || len(stmt.Methods[2].Results) != 0 {
+		t.Errorf("unexpected results %+v, %+v", stmt.Methods[0].Results, stmt.Methods[2].Results)
+	}
+}
+
+func TestParser_EmbeddedInterface(t *testing.T) {
+	input := `type Named interface {
+	Stringer
+	Name() string
+	Sizer
+}`
+	p := NewParser(scanner.NewScanner(input))
+	stmt, ok := p.ParseStatement().(*ast.InterfaceStatement)
+	if !ok {
+		t.Fatal("expected *ast.InterfaceStatement")
+	}
+	if len(p.Errors()) != 0 {
+		t.Fatalf("unexpected errors %v", p.Errors())
+	}
+	if len(stmt.Methods) != 1 || stmt.Methods[0].Name != "Name" {
+		t.Errorf("expected the method Name, got %+v", stmt.Methods)
+	}
+	if len(stmt.Embedded) != 2 || stmt.Embedded[0] != "Stringer" || stmt.Embedded[1] != "Sizer" {
+		t.Errorf("expected embedded Stringer and Sizer, got %v", stmt.Embedded)
+	}
+}
+
+func TestParser_InterfaceTypeNames(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var x any = 1", "any"},
+		{"var x interface{} = 1", "interface{}"},
+		{"var s []Shape = nil", "[]Shape"},
+		{"var p *Point = nil", "*Point"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		stmt, ok := p.ParseStatement().(*ast.VarStatement)
+		if !ok || len(p.Errors()) != 0 {
+			t.Fatalf("%q: expected *ast.VarStatement, errors %v", tt.input, p.Errors())
+		}
+		if ast.TypeName(stmt.Type) != tt.expected {
+			t.Errorf("%q: expected type %s, got %s", tt.input, tt.expected, ast.TypeName(stmt.Type))
+		}
+	}
+}
+
+func TestParser_TypeAssertion(t *testing.T) {
+	p := NewParser(scanner.NewScanner("s.(Square).side"))
+	access, ok := p.ParseExpression().(*ast.FieldAccessNode)
+	if !ok {
+		t.Fatal("expected *ast.FieldAccessNode")
+	}
+	assert, ok := access.Object.(*ast.TypeAssertNode)
+	if !ok || assert.Type != "Square" {
+		t.Fatalf("expected assertion to Square, got %v", access.Object)
+	}
+	if x, ok := assert.Expression.(*ast.VariableNode); !ok || x.Name != "s" {
+		t.Errorf("expected assertion on s, got %v", assert.Expression)
+	}
+
+	p = NewParser(scanner.NewScanner("v, ok := a.(*Point)"))
+	tuple, ok := p.ParseStatement().(*ast.TupleAssignStatement)
+	if !ok || len(p.Errors()) != 0 {
+		t.Fatalf("expected *ast.TupleAssignStatement, errors %v", p.Errors())
+	}
+	if assert, ok := tuple.Values[0].(*ast.TypeAssertNode); !ok || assert.Type != "*Point" {
+		t.Errorf("expected comma-ok assertion to *Point, got %v", tuple.Values[0])
+	}
+}
+
+func TestParser_TypeSwitch(t *testing.T) {
+	input := `switch v := x.(type) {
+case int, string:
+	print(v)
+case nil:
+default:
+}`
+	p := NewParser(scanner.NewScanner(input))
+	stmt, ok := p.ParseStatement().(*ast.TypeSwitchStatement)
+	if !ok {
+		t.Fatal("expected *ast.TypeSwitchStatement")
+	}
+	if len(p.Errors()) != 0 {
+		t.Fatalf("unexpected errors %v", p.Errors())
+	}
+	if stmt.Binding != "v" || len(stmt.Cases) != 3 {
+		t.Fatalf("expected binding v and 3 clauses, got %q and %d", stmt.Binding, len(stmt.Cases))
+	}
+	if types := stmt.Cases[0].Types; len(types) != 2 || types[0] != "int" || types[1] != "string" {
+		t.Errorf("expected case int, string, got %v", types)
+	}
+	if types := stmt.Cases[1].Types; len(types) != 1 || types[0] != "nil" {
+		t.Errorf("expected case nil, got %v", types)
+	}
+	if !stmt.Cases[2].IsDefault() {
+		t.Error("expected the last clause to be the default")
+	}
+
+	p = NewParser(scanner.NewScanner("switch y := 1; x.(type) {\n}"))
+	stmt, ok = p.ParseStatement().(*ast.TypeSwitchStatement)
+	if !ok || len(p.Errors()) != 0 {
+		t.Fatalf("expected *ast.TypeSwitchStatement, errors %v", p.Errors())
+	}
+	if stmt.Init == nil || stmt.Binding != "" {
+		t.Errorf("expected an init statement and no binding, got %v and %q", stmt.Init, stmt.Binding)
+	}
+}
+
+func TestParser_InterfaceErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"v := x.(type)", "1:9: use of .(type) outside type switch"},
+		{"switch x.(type) {\ndefault:\ndefault:\n}", "3:1: multiple defaults in switch"},
+		{"switch x.(type) {\ncase int:\n\tfallthrough\ncase string:\n}", "3:2: fallthrough statement out of place"},
+		{"switch x.(type) {\ncase int\n}", "2:9: unexpected newline, expected ':' after case type"},
+		{"type T func", "1:8: unexpected func, expected struct or interface"},
+		{"type T interface {\n\tArea int\n}", "2:7: unexpected int, expected '(' after method name"},
+		{"x.(1)", "1:4: unexpected 1, expected type"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		p.ParseStatement()
+
+		errs := p.Errors()
+		if len(errs) != 1 || errs[0].Error() != tt.expected {
+			t.Errorf("%q: expected error %q, got %v", tt.input, tt.expected, errs)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/scanner"
+)
+
+func TestParser_LabeledStatements(t *testing.T) {
+	statements := parseStatements(t, `func f() {
+outer:
+	for {
+		for {
+			break outer
+		}
+		continue outer
+	}
+	goto done
+done:
+}`)
+
+	body := statements[0].(*ast.FuncStatement).Body.Statements
+	if len(body) != 3 {
+		t.Fatalf("expected 3 statements, got %d", len(body))
+	}
+
+	outer, ok := body[0].(*ast.LabeledStatement)
+	if !ok || outer.Label != "outer" {
+		t.Fatalf("expected the label outer, got %#v", body[0])
+	}
+	loop, ok := outer.Statement.(*ast.ForStatement)
+	if !ok {
+		t.Fatalf("expected a labeled *ast.ForStatement, got %T", outer.Statement)
+	}
+	inner := loop.Body.Statements[0].(*ast.ForStatement)
+	if brk, ok := inner.Body.Statements[0].(*ast.BreakStatement); !ok || brk.Label != "outer" {
+		t.Errorf("expected break outer, got %#v", inner.Body.Statements[0])
+	}
+	if cont, ok := loop.Body.Statements[1].(*ast.ContinueStatement); !ok || cont.Label != "outer" {
+		t.Errorf("expected continue outer, got %#v", loop.Body.Statements[1])
+	}
+
+	if jump, ok := body[1].(*ast.GotoStatement); !ok || jump.Label != "done" {
+		t.Errorf("expected goto done, got %#v", body[1])
+	}
+	// A label before the closing brace labels an empty statement
+	done, ok := body[2].(*ast.LabeledStatement)
+	if !ok || done.Label != "done" {
+		t.Fatalf("expected the label done, got %#v", body[2])
+	}
+	if _, ok := done.Statement.(*ast.EmptyStatement); !ok {
+		t.Errorf("expected an empty statement, got %T", done.Statement)
+	}
+}
+
+func TestParser_BranchesWithoutLabels(t *testing.T) {
+	inputs := []string{
+		"for {\n\tbreak\n}",
+		"for {\n\tcontinue\n}",
+		"switch {\ncase true:\n\tbreak\n}",
+		"for {\n\tswitch {\n\tdefault:\n\t\tcontinue\n\t}\n}",
+		"sw:\nswitch {\ndefault:\n\tbreak sw\n}",
+		"func f() {\nL:\n\tx := 1\n\tprintln(x)\n\tgoto L\n}",
+		"func f() {\n\tgoto L\n\tprintln(1)\nL:\n}",
+	}
+
+	for _, input := range inputs {
+		parseStatements(t, input)
+	}
+}
+
+func TestParser_LabelErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"func f() {\n\tbreak\n}", []string{"2:2: break is not in a loop, switch, or select"}},
+		{"func f() {\n\tswitch {\n\tdefault:\n\t\tcontinue\n\t}\n}", []string{"4:3: continue is not in a loop"}},
+		{"func f() {\n\tfor {\n\t\tbreak L\n\t}\n}", []string{"3:3: label L not declared"}},
+		{"func f() {\n\tgoto L\n}", []string{"2:2: label L not declared"}},
+		{"func f() {\nL:\n\tfor {\n\t}\n}", []string{"2:1: label L declared and not used"}},
+		{"func f() {\nL:\n\tfor {\n\t\tbreak L\n\t}\nL:\n\tfor {\n\t}\n}", []string{"6:1: label L already declared"}},
+		{"func f() {\nL:\n\tif true {\n\t\tbreak L\n\t}\n}", []string{"4:3: invalid break label L"}},
+		{"func f() {\nL:\n\tswitch {\n\tdefault:\n\t\tcontinue L\n\t}\n}", []string{"5:3: invalid continue label L"}},
+		{"func f() {\nL:\n\tfor {\n\t}\n\tfor {\n\t\tbreak L\n\t}\n}", []string{"6:3: invalid break label L"}},
+		{"func f() {\n\tgoto L\n\tx := 1\nL:\n\tprintln(x)\n}", []string{"2:2: goto L jumps over variable declaration at line 3"}},
+		{"func f() {\n\tgoto L\n\t{\n\tL:\n\t}\n}", []string{"2:2: goto L jumps into block"}},
+		{"func f() {\n\tfor {\n\tL:\n\t}\n\tgoto L\n}", []string{"5:2: goto L jumps into block"}},
+		// function literals have labels of their own
+		{"func f() {\nL:\n\tfor {\n\t\tg := func() {\n\t\t\tbreak L\n\t\t}\n\t\tg()\n\t}\n}", []string{
+			"2:1: label L declared and not used",
+			"5:4: label L not declared",
+		}},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		for p.ParseStatement() != nil {
+		}
+
+		var got []string
+		for _, err := range p.Errors() {
+			got = append(got, err.Error())
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+			t.Errorf("%q: expected errors %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+}
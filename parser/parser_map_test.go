@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/scanner"
+)
+
+func TestParser_MapLiteral(t *testing.T) {
+	p := NewParser(scanner.NewScanner(`map[string]int{"a": 1, "b": 2 + 3,}`))
+	literal, ok := p.ParseExpression().(*ast.MapLiteral)
+	if !ok {
+		t.Fatal("expected *ast.MapLiteral")
+	}
+	if len(p.Errors()) != 0 {
+		t.Fatalf("unexpected errors %v", p.Errors())
+	}
+	if ast.TypeName(literal.KeyType) != "string" || ast.TypeName(literal.ValueType) != "int" || len(literal.Entries) != 2 {
+		t.Fatalf("expected map[string]int with 2 entries, got %s with %d", literal.TypeName(), len(literal.Entries))
+	}
+	if key, ok := literal.Entries[1].Key.(*ast.StringNode); !ok || key.Value != "b" {
+		t.Errorf("expected key \"b\", got %v", literal.Entries[1].Key)
+	}
+	if _, ok := unfold(literal.Entries[1].Value).(*ast.BinaryOpNode); !ok {
+		t.Errorf("expected 2 + 3, got %v", literal.Entries[1].Value)
+	}
+
+	p = NewParser(scanner.NewScanner("map[Point][]string{}"))
+	literal, ok = p.ParseExpression().(*ast.MapLiteral)
+	if !ok || len(p.Errors()) != 0 {
+		t.Fatalf("expected *ast.MapLiteral, errors %v", p.Errors())
+	}
+	if literal.TypeName() != "map[Point][]string" || len(literal.Entries) != 0 {
+		t.Errorf("expected an empty map[Point][]string, got %s", literal)
+	}
+}
+
+func TestParser_MapTypeNames(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var m map[string]int = nil", "map[string]int"},
+		{"var m map[int]map[string]bool = nil", "map[int]map[string]bool"},
+		{"var m map[string][]int = nil", "map[string][]int"},
+		{"var m map[string]any = nil", "map[string]any"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		stmt, ok := p.ParseStatement().(*ast.VarStatement)
+		if !ok || len(p.Errors()) != 0 {
+			t.Fatalf("%q: expected *ast.VarStatement, errors %v", tt.input, p.Errors())
+		}
+		if ast.TypeName(stmt.Type) != tt.expected {
+			t.Errorf("%q: expected type %s, got %s", tt.input, tt.expected, ast.TypeName(stmt.Type))
+		}
+	}
+}
+
+func TestParser_IndexAssignment(t *testing.T) {
+	p := NewParser(scanner.NewScanner(`m["a"] = 1 + 2`))
+	stmt, ok := p.ParseStatement().(*ast.ReassignStatement)
+	if !ok {
+		t.Fatal("expected *ast.ReassignStatement")
+	}
+	if len(p.Errors()) != 0 {
+		t.Fatalf("unexpected errors %v", p.Errors())
+	}
+	index, ok := stmt.Target.(*ast.IndexAccess)
+	if !ok {
+		t.Fatalf("expected an index target, got %T", stmt.Target)
+	}
+	if m, ok := index.Object.(*ast.VariableNode); !ok || m.Name != "m" {
+		t.Errorf("expected target m, got %v", index.Object)
+	}
+	if _, ok := unfold(stmt.Value).(*ast.BinaryOpNode); !ok {
+		t.Errorf("expected 1 + 2, got %v", stmt.Value)
+	}
+
+	p = NewParser(scanner.NewScanner(`v, ok := m["a"]`))
+	tuple, ok := p.ParseStatement().(*ast.TupleAssignStatement)
+	if !ok || len(p.Errors()) != 0 {
+		t.Fatalf("expected *ast.TupleAssignStatement, errors %v", p.Errors())
+	}
+	if _, ok := tuple.Values[0].(*ast.IndexAccess); !ok {
+		t.Errorf("expected comma-ok index expression, got %v", tuple.Values[0])
+	}
+}
+
+func TestParser_MapErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"m := map{}", "1:9: unexpected {, expected '[' after map"},
+		{"m := map[string int{}", "1:17: unexpected int, expected ']' after map key type"},
+		{`m := map[string]int{"a" 1}`, "1:25: unexpected 1, expected ':' after map key"},
+		{`m := map[string]int{"a": 1 "b": 2}`, "1:28: unexpected b, expected ',' or '}' in map literal"},
+		{"m := map[string]int", "1:20: unexpected EOF, expected '{' after map type"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		p.ParseStatement()
+
+		errs := p.Errors()
+		if len(errs) == 0 || errs[0].Error() != tt.expected {
+			t.Errorf("%q: expected error %q, got %v", tt.input, tt.expected, errs)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/scanner"
+	"github.com/yuya-takeyama/petitgo/token"
+)
+
+func TestParser_PointerExpressions(t *testing.T) {
+	statements := parseStatements(t, `var p *int
+q := &x
+r := &Point{X: 1}
+v := *p + **pp
+n := new(Point)
+m := p.Next.Value`)
+
+	if stmt := statements[0].(*ast.VarStatement); ast.TypeName(stmt.Type) != "*int" {
+		t.Errorf("expected type *int, got %q", ast.TypeName(stmt.Type))
+	}
+
+	address, ok := statements[1].(*ast.AssignStatement).Value.(*ast.UnaryOpNode)
+	if !ok || address.Operator != token.AND {
+		t.Fatalf("expected &x to be a *ast.UnaryOpNode with &, got %v", statements[1].(*ast.AssignStatement).Value)
+	}
+	if _, ok := address.Operand.(*ast.VariableNode); !ok {
+		t.Errorf("expected the operand x, got %T", address.Operand)
+	}
+
+	literal := statements[2].(*ast.AssignStatement).Value.(*ast.UnaryOpNode)
+	if _, ok := literal.Operand.(*ast.StructLiteral); !ok {
+		t.Errorf("expected &Point{...} to take the address of a literal, got %T", literal.Operand)
+	}
+
+	// * は単項演算子として二項演算より強く結合する
+	sum, ok := statements[3].(*ast.AssignStatement).Value.(*ast.BinaryOpNode)
+	if !ok || sum.Operator != token.ADD {
+		t.Fatalf("expected *p + **pp to be an addition, got %v", statements[3].(*ast.AssignStatement).Value)
+	}
+	if _, ok := sum.Left.(*ast.DerefNode); !ok {
+		t.Errorf("expected *p, got %T", sum.Left)
+	}
+	if outer, ok := sum.Right.(*ast.DerefNode); !ok {
+		t.Errorf("expected **pp, got %T", sum.Right)
+	} else if _, ok := outer.Operand.(*ast.DerefNode); !ok {
+		t.Errorf("expected *pp inside **pp, got %T", outer.Operand)
+	}
+
+	if n, ok := statements[4].(*ast.AssignStatement).Value.(*ast.NewNode); !ok || ast.TypeName(n.Type) != "Point" {
+		t.Errorf("expected new(Point), got %v", statements[4].(*ast.AssignStatement).Value)
+	}
+	if _, ok := statements[5].(*ast.AssignStatement).Value.(*ast.FieldAccessNode); !ok {
+		t.Errorf("expected p.Next.Value to be a field access, got %T", statements[5].(*ast.AssignStatement).Value)
+	}
+}
+
+func TestParser_DerefAssignStatement(t *testing.T) {
+	stmt, ok := parseStatements(t, "*p = *q + 1")[0].(*ast.ReassignStatement)
+	if !ok {
+		t.Fatalf("expected *ast.ReassignStatement")
+	}
+	deref, ok := stmt.Target.(*ast.DerefNode)
+	if !ok {
+		t.Fatalf("expected the target *p, got %T", stmt.Target)
+	}
+	if v, ok := deref.Operand.(*ast.VariableNode); !ok || v.Name != "p" {
+		t.Errorf("expected the target *p, got %v", deref.Operand)
+	}
+	if _, ok := stmt.Value.(*ast.BinaryOpNode); !ok {
+		t.Errorf("expected the value *q + 1, got %T", stmt.Value)
+	}
+
+	if _, ok := parseStatements(t, "*p")[0].(*ast.ExpressionStatement); !ok {
+		t.Errorf("expected *p alone to be an expression statement")
+	}
+}
+
+func TestParser_PointerErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"p := &1", "1:6: invalid operation: cannot take address of 1 (untyped int constant)"},
+		{"p := &f()", "1:6: invalid operation: cannot take address of value"},
+		{"x := *2", "1:6: invalid operation: cannot indirect 2 (untyped int constant)"},
+		{"p := new(1)", "1:10: unexpected 1, expected type"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		for p.ParseStatement() != nil {
+		}
+
+		errs := p.Errors()
+		if len(errs) == 0 || errs[0].Error() != tt.expected {
+			t.Errorf("%q: expected error %q, got %v", tt.input, tt.expected, errs)
+		}
+	}
+}
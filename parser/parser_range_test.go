@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/scanner"
+)
+
+func TestParser_RangeStatement(t *testing.T) {
+	tests := []struct {
+		input      string
+		key        string
+		value      string
+		define     bool
+		expression string
+	}{
+		{"for i, v := range xs {\n}", "i", "v", true, "VariableNode"},
+		{"for k := range map[string]int{} {\n}", "k", "", true, "MapLiteral"},
+		{"for _, r := range \"héllo\" {\n}", "_", "r", true, "StringNode"},
+		{"for i = range 10 {\n}", "i", "", false, "NumberNode"},
+		{"for k, v = range m {\n}", "k", "v", false, "VariableNode"},
+		{"for range []int{1, 2} {\n}", "", "", false, "SliceLiteral"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		stmt, ok := p.ParseStatement().(*ast.RangeStatement)
+		if !ok || len(p.Errors()) != 0 {
+			t.Fatalf("%q: expected *ast.RangeStatement, errors %v", tt.input, p.Errors())
+		}
+		if stmt.Key != tt.key || stmt.Value != tt.value || stmt.Define != tt.define {
+			t.Errorf("%q: expected key %q, value %q, define %v, got %q, %q, %v",
+				tt.input, tt.key, tt.value, tt.define, stmt.Key, stmt.Value, stmt.Define)
+		}
+		if got := stmt.Expression.String(); got != tt.expression {
+			t.Errorf("%q: expected range over %s, got %s", tt.input, tt.expression, got)
+		}
+		if stmt.Body == nil {
+			t.Errorf("%q: expected a body", tt.input)
+		}
+	}
+}
+
+func TestParser_RangeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x := range xs", "1:6: range clause outside for statement"},
+		{"for a, b, c := range xs {\n}", "1:5: range clause permits at most two iteration variables"},
+		{"for i := range xs\n", "1:18: unexpected newline, expected '{' after for clause"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		p.ParseStatement()
+
+		errs := p.Errors()
+		if len(errs) != 1 || errs[0].Error() != tt.expected {
+			t.Errorf("%q: expected error %q, got %v", tt.input, tt.expected, errs)
+		}
+	}
+}
@@ -33,9 +33,17 @@ func TestParseBinaryExpression(t *testing.T) {
 
 	expr := parser.ParseExpression()
 
-	binaryNode, ok := expr.(*ast.BinaryOpNode)
+	// 定数式は値に畳み込まれ、書かれた式も残る
+	folded, ok := expr.(*ast.ConstExpr)
 	if !ok {
-		t.Fatalf("expected *ast.BinaryOpNode, got %T", expr)
+		t.Fatalf("expected *ast.ConstExpr, got %T", expr)
+	}
+	if folded.Value.String() != "3" {
+		t.Errorf("expected 1 + 2 to be 3, got %s", folded.Value)
+	}
+	binaryNode, ok := folded.Expression.(*ast.BinaryOpNode)
+	if !ok {
+		t.Fatalf("expected *ast.BinaryOpNode, got %T", folded.Expression)
 	}
 
 	if binaryNode.Operator != token.ADD {
@@ -67,7 +75,7 @@ func TestParseOperatorPrecedence(t *testing.T) {
 	expr := parser.ParseExpression()
 
 	// (2 + (3 * 4)) の構造になってるはず
-	binaryNode, ok := expr.(*ast.BinaryOpNode)
+	binaryNode, ok := unfold(expr).(*ast.BinaryOpNode)
 	if !ok {
 		t.Fatalf("expected *ast.BinaryOpNode, got %T", expr)
 	}
@@ -77,7 +85,7 @@ func TestParseOperatorPrecedence(t *testing.T) {
 	}
 
 	// 右側が 3 * 4 の ast.BinaryOpNode になってるはず
-	rightBinary, ok := binaryNode.Right.(*ast.BinaryOpNode)
+	rightBinary, ok := unfold(binaryNode.Right).(*ast.BinaryOpNode)
 	if !ok {
 		t.Fatalf("expected right operand to be ast.BinaryOpNode, got %T", binaryNode.Right)
 	}
@@ -87,6 +95,267 @@ func TestParseOperatorPrecedence(t *testing.T) {
 	}
 }
 
+func TestParseIntegerOperatorPrecedence(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a % b * c", "((a % b) * c)"},
+		{"a + b % c", "(a + (b % c))"},
+		{"a | b & c", "(a | (b & c))"},
+		{"a ^ b << 2", "(a ^ (b << 2))"},
+		{"a &^ b | c", "((a &^ b) | c)"},
+		{"1 << n - 1", "((1 << n) - 1)"},
+		{"h ^ h >> 7 == 0", "((h ^ (h >> 7)) == 0)"},
+		{"a | b ^ c", "((a | b) ^ c)"},
+	}
+
+	for _, tt := range tests {
+		parser := NewParser(scanner.NewScanner(tt.input))
+		expr := parser.ParseExpression()
+
+		if got := parenthesize(expr); got != tt.expected {
+			t.Errorf("input %q: expected %s, got %s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestParseLogicalAndUnaryOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"-5", "(-5)"},
+		{"-x * 2", "((-x) * 2)"},
+		{"!done", "(!done)"},
+		{"!!ok", "(!(!ok))"},
+		{"^mask & 3", "((^mask) & 3)"},
+		{"a - -b", "(a - (-b))"},
+		{"-f(x)", "(-f(...))"},
+		{"-(a + b)", "(-(a + b))"},
+		{"a > 0 && !done", "((a > 0) && (!done))"},
+		{"a || b && c", "(a || (b && c))"},
+		{"a && b || c && d", "((a && b) || (c && d))"},
+		{"a == 1 || b != 2 && c < 3", "((a == 1) || ((b != 2) && (c < 3)))"},
+	}
+
+	for _, tt := range tests {
+		parser := NewParser(scanner.NewScanner(tt.input))
+		expr := parser.ParseExpression()
+
+		if got := parenthesize(expr); got != tt.expected {
+			t.Errorf("input %q: expected %s, got %s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestParseIfConditionWithLogicalOperators(t *testing.T) {
+	input := "if a > 0 && !done { x := -1 }"
+	parser := NewParser(scanner.NewScanner(input))
+
+	stmt, ok := parser.ParseStatement().(*ast.IfStatement)
+	if !ok {
+		t.Fatalf("expected *ast.IfStatement")
+	}
+	if got := parenthesize(stmt.Condition); got != "((a > 0) && (!done))" {
+		t.Errorf("condition wrong, got %s", got)
+	}
+	if stmt.ThenBlock == nil || len(stmt.ThenBlock.Statements) != 1 {
+		t.Fatalf("expected then block with 1 statement")
+	}
+	assign, ok := stmt.ThenBlock.Statements[0].(*ast.AssignStatement)
+	if !ok {
+		t.Fatalf("expected *ast.AssignStatement, got %T", stmt.ThenBlock.Statements[0])
+	}
+	if got := parenthesize(assign.Value); got != "(-1)" {
+		t.Errorf("assigned value wrong, got %s", got)
+	}
+	if errors := parser.Errors(); len(errors) != 0 {
+		t.Errorf("unexpected errors: %v", errors)
+	}
+}
+
+func TestParseHeaderIdentifierBeforeBlock(t *testing.T) {
+	// the '{' after n starts the loop body, not a struct literal n{...}
+	parser := NewParser(scanner.NewScanner("for i < n { i++ }"))
+
+	stmt, ok := parser.ParseStatement().(*ast.ForStatement)
+	if !ok {
+		t.Fatalf("expected *ast.ForStatement")
+	}
+	if got := parenthesize(stmt.Condition); got != "(i < n)" {
+		t.Errorf("condition wrong, got %s", got)
+	}
+	if stmt.Body == nil || len(stmt.Body.Statements) != 1 {
+		t.Fatalf("expected body with 1 statement")
+	}
+
+	// struct literals are allowed again inside the body and in parentheses
+	parser = NewParser(scanner.NewScanner("if (p == Point{x: 1}) { q := Point{x: 2} }"))
+	ifStmt, ok := parser.ParseStatement().(*ast.IfStatement)
+	if !ok {
+		t.Fatalf("expected *ast.IfStatement")
+	}
+	if _, ok := ifStmt.Condition.(*ast.BinaryOpNode).Right.(*ast.StructLiteral); !ok {
+		t.Errorf("expected struct literal in parenthesized condition")
+	}
+	assign := ifStmt.ThenBlock.Statements[0].(*ast.AssignStatement)
+	if _, ok := assign.Value.(*ast.StructLiteral); !ok {
+		t.Errorf("expected struct literal in body, got %T", assign.Value)
+	}
+}
+
+// parenthesize renders an expression with every binary operation in parentheses
+// unfold returns the expression as written of a folded constant expression
+func unfold(node ast.ASTNode) ast.ASTNode {
+	if folded, ok := node.(*ast.ConstExpr); ok {
+		return folded.Expression
+	}
+	return node
+}
+
+func parenthesize(node ast.ASTNode) string {
+	operators := map[token.Token]string{
+		token.ADD: "+", token.SUB: "-", token.MUL: "*", token.QUO: "/", token.REM: "%",
+		token.AND: "&", token.OR: "|", token.XOR: "^", token.SHL: "<<", token.SHR: ">>",
+		token.AND_NOT: "&^", token.EQL: "==", token.NEQ: "!=", token.LSS: "<",
+		token.GTR: ">", token.LEQ: "<=", token.GEQ: ">=", token.LAND: "&&", token.LOR: "||",
+		token.NOT: "!",
+	}
+
+	switch n := node.(type) {
+	case *ast.NumberNode:
+		return fmt.Sprint(n.Value)
+	case *ast.ConstExpr:
+		return parenthesize(n.Expression)
+	case *ast.VariableNode:
+		return n.Name
+	case *ast.BinaryOpNode:
+		return "(" + parenthesize(n.Left) + " " + operators[n.Operator] + " " + parenthesize(n.Right) + ")"
+	case *ast.UnaryOpNode:
+		return "(" + operators[n.Operator] + parenthesize(n.Operand) + ")"
+	case *ast.CallNode:
+		if n.Receiver != nil {
+			return parenthesize(n.Receiver) + "." + n.Function + "(...)"
+		}
+		return n.Function + "(...)"
+	case *ast.StringNode:
+		return fmt.Sprintf("%q", n.Value)
+	case *ast.StructLiteral:
+		return n.TypeName + "{...}"
+	case *ast.FieldAccessNode:
+		return parenthesize(n.Object) + "." + n.Field
+	case *ast.IndexAccess:
+		return parenthesize(n.Object) + "[" + parenthesize(n.Index) + "]"
+	}
+	return fmt.Sprintf("%T", node)
+}
+
+func TestParseBinaryPrecedenceLevels(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// one operator from each of Go's five levels, in both orders
+		{"a || b && c == d + e * f", "(a || (b && (c == (d + (e * f)))))"},
+		{"a * b + c == d && e || f", "(((((a * b) + c) == d) && e) || f)"},
+		// operators of the same level are left-associative
+		{"a - b + c", "((a - b) + c)"},
+		{"a << b * c % d", "(((a << b) * c) % d)"},
+		{"a == b != c", "((a == b) != c)"},
+		{"a | b ^ c - d", "(((a | b) ^ c) - d)"},
+		// unary operators bind tighter than any binary operator
+		{"-a * ^b", "((-a) * (^b))"},
+		{"!a && !b || c", "(((!a) && (!b)) || c)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			parser := NewParser(scanner.NewScanner(tt.input))
+			expr := parser.ParseExpression()
+			if errs := parser.Errors(); len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if got := parenthesize(expr); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParsePostfixExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"abc"[1]`, `"abc"[1]`},
+		{"f(x)[0]", "f(...)[0]"},
+		{"(a)[i]", "a[i]"},
+		{"a.b[c].d", "a.b[c].d"},
+		{"Point{x: 1}.x", "Point{...}.x"},
+		{"m[i][j + 1]", "m[i][(j + 1)]"},
+		{"-s[0] * p.x", "((-s[0]) * p.x)"},
+		{"s[Point{x: 1}.x]", "s[Point{...}.x]"},
+		{"p.Greet()", "p.Greet(...)"},
+		{"a.b.Len(1).c", "a.b.Len(...).c"},
+		{"people[0].Name() + 1", "(people[0].Name(...) + 1)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			parser := NewParser(scanner.NewScanner(tt.input))
+			expr := parser.ParseExpression()
+			if errs := parser.Errors(); len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if got := parenthesize(expr); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseCallOfNonIdentifier(t *testing.T) {
+	parser := NewParser(scanner.NewScanner("f(1)(2)"))
+	expr := parser.ParseExpression()
+	if errs := parser.Errors(); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	call, ok := expr.(*ast.CallNode)
+	if !ok || call.Function != "" || len(call.Arguments) != 1 {
+		t.Fatalf("expected a call of a function value, got %#v", expr)
+	}
+	if callee, ok := call.Callee.(*ast.CallNode); !ok || callee.Function != "f" {
+		t.Errorf("expected the callee f(1), got %#v", call.Callee)
+	}
+}
+
+func TestTokenPrecedence(t *testing.T) {
+	tests := []struct {
+		tok      token.Token
+		expected int
+	}{
+		{token.LOR, 1},
+		{token.LAND, 2},
+		{token.EQL, 3},
+		{token.GEQ, 3},
+		{token.ADD, 4},
+		{token.XOR, 4},
+		{token.MUL, 5},
+		{token.AND_NOT, 5},
+		{token.NOT, token.LowestPrec},
+		{token.ASSIGN, token.LowestPrec},
+		{token.LPAREN, token.LowestPrec},
+	}
+
+	for _, tt := range tests {
+		if got := tt.tok.Precedence(); got != tt.expected {
+			t.Errorf("%v: expected precedence %d, got %d", tt.tok, tt.expected, got)
+		}
+	}
+}
+
 func TestParseParentheses(t *testing.T) {
 	input := "(2 + 3) * 4"
 	sc := scanner.NewScanner(input)
@@ -95,7 +364,7 @@ func TestParseParentheses(t *testing.T) {
 	expr := parser.ParseExpression()
 
 	// ((2 + 3) * 4) の構造になってるはず
-	binaryNode, ok := expr.(*ast.BinaryOpNode)
+	binaryNode, ok := unfold(expr).(*ast.BinaryOpNode)
 	if !ok {
 		t.Fatalf("expected *ast.BinaryOpNode, got %T", expr)
 	}
@@ -105,7 +374,7 @@ func TestParseParentheses(t *testing.T) {
 	}
 
 	// 左側が (2 + 3) の ast.BinaryOpNode になってるはず
-	leftBinary, ok := binaryNode.Left.(*ast.BinaryOpNode)
+	leftBinary, ok := unfold(binaryNode.Left).(*ast.BinaryOpNode)
 	if !ok {
 		t.Fatalf("expected left operand to be ast.BinaryOpNode, got %T", binaryNode.Left)
 	}
@@ -264,7 +533,7 @@ func TestParseContinueStatement(t *testing.T) {
 }
 
 func TestParseBlockStatement(t *testing.T) {
-	input := "{ x := 1 y := 2 }"
+	input := "{ x := 1; y := 2 }"
 	sc := scanner.NewScanner(input)
 	parser := NewParser(sc)
 
@@ -297,8 +566,8 @@ func TestParseVarStatement(t *testing.T) {
 		t.Errorf("expected variable name 'x', got %s", varStmt.Name)
 	}
 
-	if varStmt.TypeName != "int" {
-		t.Errorf("expected variable type 'int', got %s", varStmt.TypeName)
+	if ast.TypeName(varStmt.Type) != "int" {
+		t.Errorf("expected variable type 'int', got %s", ast.TypeName(varStmt.Type))
 	}
 
 	numberNode, ok := varStmt.Value.(*ast.NumberNode)
@@ -324,8 +593,8 @@ func TestParseReassignStatement(t *testing.T) {
 		t.Fatalf("expected *ast.ReassignStatement, got %T", stmt)
 	}
 
-	if reassignStmt.Name != "x" {
-		t.Errorf("expected variable name 'x', got %s", reassignStmt.Name)
+	if target, ok := reassignStmt.Target.(*ast.VariableNode); !ok || target.Name != "x" {
+		t.Errorf("expected variable 'x', got %v", reassignStmt.Target)
 	}
 
 	numberNode, ok := reassignStmt.Value.(*ast.NumberNode)
@@ -351,8 +620,8 @@ func TestParseIncStatement(t *testing.T) {
 		t.Fatalf("expected *ast.IncStatement, got %T", stmt)
 	}
 
-	if incStmt.Name != "x" {
-		t.Errorf("expected variable name 'x', got %s", incStmt.Name)
+	if target, ok := incStmt.Target.(*ast.VariableNode); !ok || target.Name != "x" {
+		t.Errorf("expected variable 'x', got %v", incStmt.Target)
 	}
 }
 
@@ -369,8 +638,8 @@ func TestParseDecStatement(t *testing.T) {
 		t.Fatalf("expected *ast.DecStatement, got %T", stmt)
 	}
 
-	if decStmt.Name != "x" {
-		t.Errorf("expected variable name 'x', got %s", decStmt.Name)
+	if target, ok := decStmt.Target.(*ast.VariableNode); !ok || target.Name != "x" {
+		t.Errorf("expected variable 'x', got %v", decStmt.Target)
 	}
 }
 
@@ -384,6 +653,13 @@ func TestParseCompoundAssignStatement(t *testing.T) {
 		{"y -= 3", token.SUB_ASSIGN},
 		{"z *= 2", token.MUL_ASSIGN},
 		{"w /= 4", token.QUO_ASSIGN},
+		{"v %= 3", token.REM_ASSIGN},
+		{"a &= 1", token.AND_ASSIGN},
+		{"b |= 2", token.OR_ASSIGN},
+		{"c ^= 3", token.XOR_ASSIGN},
+		{"d <<= 4", token.SHL_ASSIGN},
+		{"e >>= 5", token.SHR_ASSIGN},
+		{"f &^= 6", token.AND_NOT_ASSIGN},
 	}
 
 	for _, tt := range tests {
@@ -403,6 +679,64 @@ func TestParseCompoundAssignStatement(t *testing.T) {
 	}
 }
 
+// Test assignments to indexes, fields and dereferences
+func TestParseAssignTargets(t *testing.T) {
+	tests := []struct {
+		input      string
+		stmtType   string
+		targetType string
+	}{
+		{"xs[i] = 1", "*ast.ReassignStatement", "*ast.IndexAccess"},
+		{"grid[y][x] += 1", "*ast.CompoundAssignStatement", "*ast.IndexAccess"},
+		{"p.age++", "*ast.IncStatement", "*ast.FieldAccessNode"},
+		{"p.home.x--", "*ast.DecStatement", "*ast.FieldAccessNode"},
+		{"*p *= 2", "*ast.CompoundAssignStatement", "*ast.DerefNode"},
+		{"(x) = 1", "*ast.ReassignStatement", "*ast.VariableNode"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		stmt := p.ParseStatement()
+		if errs := p.Errors(); len(errs) != 0 {
+			t.Fatalf("%q: unexpected errors %v", tt.input, errs)
+		}
+
+		var target ast.ASTNode
+		switch s := stmt.(type) {
+		case *ast.ReassignStatement:
+			target = s.Target
+		case *ast.CompoundAssignStatement:
+			target = s.Target
+		case *ast.IncStatement:
+			target = s.Target
+		case *ast.DecStatement:
+			target = s.Target
+		}
+		if got := fmt.Sprintf("%T", stmt); got != tt.stmtType {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.stmtType, got)
+		}
+		if got := fmt.Sprintf("%T", target); got != tt.targetType {
+			t.Errorf("%q: expected target %s, got %s", tt.input, tt.targetType, got)
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"f() = 1", "1:1: cannot assign to value (neither addressable nor a map index expression)"},
+		{"1++", "1:1: cannot assign to value (neither addressable nor a map index expression)"},
+		{"a + b += 1", "1:1: cannot assign to value (neither addressable nor a map index expression)"},
+	}
+	for _, tt := range errorTests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		p.ParseStatement()
+		if errs := p.Errors(); len(errs) != 1 || errs[0].Error() != tt.expected {
+			t.Errorf("%q: expected error %q, got %v", tt.input, tt.expected, errs)
+		}
+	}
+}
+
 // Test switch statement
 func TestParseSwitchStatement(t *testing.T) {
 	input := `switch x {
@@ -433,12 +767,12 @@ func TestParseSwitchStatement(t *testing.T) {
 	}
 
 	// Check cases
-	if len(switchStmt.Cases) != 2 {
-		t.Errorf("expected 2 cases, got %d", len(switchStmt.Cases))
+	if len(switchStmt.Cases) != 3 {
+		t.Fatalf("expected 3 clauses, got %d", len(switchStmt.Cases))
 	}
 
 	// Check default case exists
-	if switchStmt.Default == nil {
+	if !switchStmt.Cases[2].IsDefault() {
 		t.Errorf("expected default case to exist")
 	}
 }
@@ -568,26 +902,41 @@ func TestParseSwitchStatementDetailed(t *testing.T) {
 				t.Errorf("expected switch value '%s', got '%s'", tt.expected.valueName, varNode.Name)
 			}
 
+			// Separate the default clause from the cases
+			var cases []*ast.CaseStatement
+			var defaultCase *ast.CaseStatement
+			for _, clause := range switchStmt.Cases {
+				if clause.IsDefault() {
+					defaultCase = clause
+				} else {
+					cases = append(cases, clause)
+				}
+			}
+
 			// Check number of cases
-			if len(switchStmt.Cases) != tt.expected.numCases {
-				t.Errorf("expected %d cases, got %d", tt.expected.numCases, len(switchStmt.Cases))
+			if len(cases) != tt.expected.numCases {
+				t.Errorf("expected %d cases, got %d", tt.expected.numCases, len(cases))
 			}
 
 			// Check default
-			if tt.expected.hasDefault && switchStmt.Default == nil {
+			if tt.expected.hasDefault && defaultCase == nil {
 				t.Errorf("expected default case to exist")
 			}
-			if !tt.expected.hasDefault && switchStmt.Default != nil {
+			if !tt.expected.hasDefault && defaultCase != nil {
 				t.Errorf("expected no default case")
 			}
 
 			// Check each case
-			for i, caseStmt := range switchStmt.Cases {
+			for i, caseStmt := range cases {
 				// Check case value
 				if i < len(tt.expected.caseValues) {
-					numNode, ok := caseStmt.Value.(*ast.NumberNode)
+					if len(caseStmt.Values) != 1 {
+						t.Errorf("case %d: expected 1 value, got %d", i, len(caseStmt.Values))
+						continue
+					}
+					numNode, ok := caseStmt.Values[0].(*ast.NumberNode)
 					if !ok {
-						t.Errorf("case %d: expected NumberNode, got %T", i, caseStmt.Value)
+						t.Errorf("case %d: expected NumberNode, got %T", i, caseStmt.Values[0])
 						continue
 					}
 					if numNode.Value != tt.expected.caseValues[i] {
@@ -754,8 +1103,8 @@ func TestParseFullForStatement(t *testing.T) {
 		t.Fatalf("expected update to be IncStatement, got %T", forStmt.Update)
 	}
 
-	if updateStmt.Name != "i" {
-		t.Errorf("expected update variable 'i', got %s", updateStmt.Name)
+	if target, ok := updateStmt.Target.(*ast.VariableNode); !ok || target.Name != "i" {
+		t.Errorf("expected update variable 'i', got %v", updateStmt.Target)
 	}
 }
 
@@ -787,16 +1136,16 @@ func TestParseStructDefinition(t *testing.T) {
 	if typeStmt.Fields[0].Name != "name" {
 		t.Errorf("expected first field name 'name', got %s", typeStmt.Fields[0].Name)
 	}
-	if typeStmt.Fields[0].Type != "string" {
-		t.Errorf("expected first field type 'string', got %s", typeStmt.Fields[0].Type)
+	if ast.TypeName(typeStmt.Fields[0].Type) != "string" {
+		t.Errorf("expected first field type 'string', got %s", ast.TypeName(typeStmt.Fields[0].Type))
 	}
 
 	// Check second field
 	if typeStmt.Fields[1].Name != "age" {
 		t.Errorf("expected second field name 'age', got %s", typeStmt.Fields[1].Name)
 	}
-	if typeStmt.Fields[1].Type != "int" {
-		t.Errorf("expected second field type 'int', got %s", typeStmt.Fields[1].Type)
+	if ast.TypeName(typeStmt.Fields[1].Type) != "int" {
+		t.Errorf("expected second field type 'int', got %s", ast.TypeName(typeStmt.Fields[1].Type))
 	}
 }
 
@@ -844,8 +1193,8 @@ func TestParseSliceLiteral(t *testing.T) {
 		t.Fatalf("expected *ast.SliceLiteral, got %T", expr)
 	}
 
-	if sliceLit.ElementType != "int" {
-		t.Errorf("expected slice type 'int', got %s", sliceLit.ElementType)
+	if ast.TypeName(sliceLit.ElementType) != "int" {
+		t.Errorf("expected slice type 'int', got %s", ast.TypeName(sliceLit.ElementType))
 	}
 
 	if len(sliceLit.Elements) != 3 {
@@ -862,6 +1211,54 @@ func TestParseSliceLiteral(t *testing.T) {
 	}
 }
 
+func TestParseSliceExpr(t *testing.T) {
+	tests := []struct {
+		input  string
+		low    bool
+		high   bool
+		max    bool
+		slice3 bool
+	}{
+		{"s[1:3]", true, true, false, false},
+		{"s[1:]", true, false, false, false},
+		{"s[:3]", false, true, false, false},
+		{"s[:]", false, false, false, false},
+		{"s[1:3:5]", true, true, true, true},
+		{"s[:3:5]", false, true, true, true},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		expr := p.ParseExpression()
+		if errs := p.Errors(); len(errs) != 0 {
+			t.Fatalf("%q: unexpected errors %v", tt.input, errs)
+		}
+
+		slice, ok := expr.(*ast.SliceExpr)
+		if !ok {
+			t.Fatalf("%q: expected *ast.SliceExpr, got %T", tt.input, expr)
+		}
+		if (slice.Low != nil) != tt.low || (slice.High != nil) != tt.high || (slice.Max != nil) != tt.max || slice.Slice3 != tt.slice3 {
+			t.Errorf("%q: unexpected indices low=%v high=%v max=%v slice3=%v", tt.input, slice.Low, slice.High, slice.Max, slice.Slice3)
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"s[1::5]", "1:5: middle index required in 3-index slice"},
+		{"s[1:3:]", "1:7: final index required in 3-index slice"},
+	}
+	for _, tt := range errorTests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		p.ParseExpression()
+		if errs := p.Errors(); len(errs) != 1 || errs[0].Error() != tt.expected {
+			t.Errorf("%q: expected error %q, got %v", tt.input, tt.expected, errs)
+		}
+	}
+}
+
 // Test parseFactor edge cases (72.3% -> 100% coverage)
 func TestParseFactorEdgeCases(t *testing.T) {
 	tests := []struct {
@@ -941,7 +1338,7 @@ func TestParseFactorEdgeCases(t *testing.T) {
 				}
 			case "*ast.SliceLiteral":
 				sliceLit := expr.(*ast.SliceLiteral)
-				if sliceLit.ElementType == "" {
+				if sliceLit.ElementType == nil {
 					t.Errorf("slice literal should have element type")
 				}
 			}
@@ -1116,9 +1513,12 @@ func TestParserEdgeCasesFor100Percent(t *testing.T) {
 				parser := NewParser(sc)
 				stmt := parser.ParseStatement()
 
-				// For error cases, parsePackageStatement returns nil
-				if stmt != nil {
-					t.Errorf("expected nil for error case, got %T", stmt)
+				// Error cases return a statement and record a syntax error
+				if _, ok := stmt.(*ast.PackageStatement); !ok {
+					t.Errorf("expected *ast.PackageStatement, got %T", stmt)
+				}
+				if len(parser.Errors()) != 1 {
+					t.Errorf("expected 1 error, got %v", parser.Errors())
 				}
 			})
 		}
@@ -1142,9 +1542,12 @@ func TestParserEdgeCasesFor100Percent(t *testing.T) {
 				parser := NewParser(sc)
 				stmt := parser.ParseStatement()
 
-				// For error cases, parseImportStatement returns nil
-				if stmt != nil {
-					t.Errorf("expected nil for error case, got %T", stmt)
+				// Error cases return a statement and record a syntax error
+				if _, ok := stmt.(*ast.ImportStatement); !ok {
+					t.Errorf("expected *ast.ImportStatement, got %T", stmt)
+				}
+				if len(parser.Errors()) != 1 {
+					t.Errorf("expected 1 error, got %v", parser.Errors())
 				}
 			})
 		}
@@ -1625,9 +2028,16 @@ func TestParserUltimateFinal100(t *testing.T) {
 				parser := NewParser(sc)
 				stmt := parser.ParseStatement()
 
-				// Missing LPAREN should return nil
-				if stmt != nil {
-					t.Errorf("expected nil for missing lparen, got %T", stmt)
+				// Missing LPAREN is reported and the function is skipped
+				if _, ok := stmt.(*ast.FuncStatement); !ok {
+					t.Errorf("expected *ast.FuncStatement, got %T", stmt)
+				}
+				errs := parser.Errors()
+				if len(errs) != 1 || errs[0].Message != "unexpected x, expected '(' after function name" {
+					t.Errorf("expected missing '(' error, got %v", errs)
+				}
+				if next := parser.ParseStatement(); next != nil {
+					t.Errorf("expected the rest of the function to be skipped, got %T", next)
 				}
 			})
 		}
@@ -1767,3 +2177,479 @@ func TestParserAbsolute100(t *testing.T) {
 		}
 	})
 }
+
+func TestParserNodePositions(t *testing.T) {
+	input := "func add(a int, b int) int {\n    return a + b\n}"
+	sc := scanner.NewFileScanner("add.pg", input)
+	parser := NewParser(sc)
+
+	stmt := parser.ParseStatement()
+	funcStmt, ok := stmt.(*ast.FuncStatement)
+	if !ok {
+		t.Fatalf("expected *ast.FuncStatement, got %T", stmt)
+	}
+
+	if funcStmt.Pos.String() != "add.pg:1:1" {
+		t.Errorf("expected func position add.pg:1:1, got %s", funcStmt.Pos)
+	}
+	if funcStmt.Parameters[1].Pos.String() != "add.pg:1:17" {
+		t.Errorf("expected parameter position add.pg:1:17, got %s", funcStmt.Parameters[1].Pos)
+	}
+
+	returnStmt, ok := funcStmt.Body.Statements[0].(*ast.ReturnStatement)
+	if !ok {
+		t.Fatalf("expected *ast.ReturnStatement, got %T", funcStmt.Body.Statements[0])
+	}
+	if returnStmt.Pos.String() != "add.pg:2:5" {
+		t.Errorf("expected return position add.pg:2:5, got %s", returnStmt.Pos)
+	}
+
+	binaryNode, ok := returnStmt.Values[0].(*ast.BinaryOpNode)
+	if !ok {
+		t.Fatalf("expected *ast.BinaryOpNode, got %T", returnStmt.Values[0])
+	}
+	if binaryNode.Right.Position().String() != "add.pg:2:16" {
+		t.Errorf("expected right operand position add.pg:2:16, got %s", binaryNode.Right.Position())
+	}
+}
+
+func TestParseStatementTerminators(t *testing.T) {
+	t.Run("bare return before closing brace", func(t *testing.T) {
+		input := "func f() {\n\tx := 1\n\treturn\n}"
+		parser := NewParser(scanner.NewScanner(input))
+
+		funcStmt, ok := parser.ParseStatement().(*ast.FuncStatement)
+		if !ok {
+			t.Fatalf("expected *ast.FuncStatement")
+		}
+		if len(funcStmt.Body.Statements) != 2 {
+			t.Fatalf("expected 2 statements, got %d", len(funcStmt.Body.Statements))
+		}
+		returnStmt, ok := funcStmt.Body.Statements[1].(*ast.ReturnStatement)
+		if !ok {
+			t.Fatalf("expected *ast.ReturnStatement, got %T", funcStmt.Body.Statements[1])
+		}
+		if len(returnStmt.Values) != 0 {
+			t.Errorf("expected bare return, got %d values", len(returnStmt.Values))
+		}
+	})
+
+	t.Run("expressions on consecutive lines", func(t *testing.T) {
+		input := "a\n-b\nc(1)"
+		parser := NewParser(scanner.NewScanner(input))
+
+		var statements []ast.Statement
+		for {
+			stmt := parser.ParseStatement()
+			if stmt == nil {
+				break
+			}
+			statements = append(statements, stmt)
+		}
+
+		if len(statements) != 3 {
+			t.Fatalf("expected 3 statements, got %d", len(statements))
+		}
+		if _, ok := statements[0].(*ast.ExpressionStatement).Expression.(*ast.VariableNode); !ok {
+			t.Errorf("expected first statement to be a variable, got %T", statements[0].(*ast.ExpressionStatement).Expression)
+		}
+	})
+
+	t.Run("explicit semicolons", func(t *testing.T) {
+		input := "x := 1; y := 2;; z := x + y"
+		parser := NewParser(scanner.NewScanner(input))
+
+		names := []string{}
+		for {
+			stmt := parser.ParseStatement()
+			if stmt == nil {
+				break
+			}
+			names = append(names, stmt.(*ast.AssignStatement).Name)
+		}
+
+		if len(names) != 3 || names[0] != "x" || names[1] != "y" || names[2] != "z" {
+			t.Errorf("expected assignments to x, y, z, got %v", names)
+		}
+	})
+
+	t.Run("for clauses with empty condition", func(t *testing.T) {
+		input := "for i := 0; ; i++ {\n\tbreak\n}"
+		parser := NewParser(scanner.NewScanner(input))
+
+		forStmt, ok := parser.ParseStatement().(*ast.ForStatement)
+		if !ok {
+			t.Fatalf("expected *ast.ForStatement")
+		}
+		if forStmt.Init == nil || forStmt.Condition != nil || forStmt.Update == nil {
+			t.Errorf("expected init and update without condition, got %+v", forStmt)
+		}
+		if len(forStmt.Body.Statements) != 1 {
+			t.Errorf("expected 1 body statement, got %d", len(forStmt.Body.Statements))
+		}
+	})
+}
+
+func TestParseNumberLiterals(t *testing.T) {
+	intTests := []struct {
+		input    string
+		expected int
+	}{
+		{"1_000", 1000},
+		{"0x1F", 31},
+		{"0Xff", 255},
+		{"0o17", 15},
+		{"0755", 493},
+		{"0b1010", 10},
+		{"9223372036854775807", 9223372036854775807},
+	}
+
+	for _, tt := range intTests {
+		parser := NewParser(scanner.NewScanner(tt.input))
+		expr := parser.ParseExpression()
+
+		numberNode, ok := expr.(*ast.NumberNode)
+		if !ok {
+			t.Fatalf("input %q: expected *ast.NumberNode, got %T", tt.input, expr)
+		}
+		if numberNode.Value != tt.expected {
+			t.Errorf("input %q: expected %d, got %d", tt.input, tt.expected, numberNode.Value)
+		}
+		if len(parser.Errors()) != 0 {
+			t.Errorf("input %q: unexpected errors %v", tt.input, parser.Errors())
+		}
+	}
+
+	floatTests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14", 3.14},
+		{".5", 0.5},
+		{"1e3", 1000},
+		{"1_0.2_5", 10.25},
+		{"0x1p-2", 0.25},
+	}
+
+	for _, tt := range floatTests {
+		parser := NewParser(scanner.NewScanner(tt.input))
+		expr := parser.ParseExpression()
+
+		floatNode, ok := expr.(*ast.FloatNode)
+		if !ok {
+			t.Fatalf("input %q: expected *ast.FloatNode, got %T", tt.input, expr)
+		}
+		if floatNode.Value != tt.expected {
+			t.Errorf("input %q: expected %v, got %v", tt.input, tt.expected, floatNode.Value)
+		}
+	}
+}
+
+func TestParseBigNumberLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"100000000000000000000 / 1e10", "1e+10"},
+		{"0xFFFFFFFFFFFFFFFFF >> 8", "1152921504606846975"},
+		{"1e400 / 1e398", "100"},
+		{"0.1 + 0.2 == 0.3", "true"},
+	}
+
+	for _, tt := range tests {
+		parser := NewParser(scanner.NewScanner(tt.input))
+		expr := parser.ParseExpression()
+		if errors := parser.Errors(); len(errors) != 0 {
+			t.Fatalf("input %q: unexpected errors %v", tt.input, errors)
+		}
+		if c, ok := expr.(*ast.ConstExpr); !ok || c.Value.String() != tt.expected {
+			t.Errorf("input %q: expected the constant %s, got %#v", tt.input, tt.expected, expr)
+		}
+	}
+}
+
+func TestParseNumberLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// 定数としては範囲外の値も使え、型が決まったときに検査する
+		{"x := 9223372036854775808", "1:6: constant 9223372036854775808 overflows int"},
+		{"x := 0xFFFFFFFFFFFFFFFFF", "1:6: constant 295147905179352825855 overflows int"},
+		{"var f float64 = 1e400", "1:17: constant 1e+400 overflows float64"},
+		{"x := 1e100000", "1:6: floating-point constant 1e100000 is too large"},
+	}
+
+	for _, tt := range tests {
+		parser := NewParser(scanner.NewScanner(tt.input))
+		parser.ParseStatement()
+
+		errors := parser.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("input %q: expected 1 error, got %v", tt.input, errors)
+		}
+		if errors[0].Error() != tt.expected {
+			t.Errorf("input %q: expected error %q, got %q", tt.input, tt.expected, errors[0].Error())
+		}
+	}
+}
+
+func TestParseIllegalTokens(t *testing.T) {
+	input := "x := 1 @ 2\ny := )\nz := 3"
+	parser := NewParser(scanner.NewFileScanner("main.pg", input))
+
+	var statements []ast.Statement
+	for {
+		stmt := parser.ParseStatement()
+		if stmt == nil {
+			break
+		}
+		statements = append(statements, stmt)
+	}
+
+	// parsing continues past the errors up to the last statement
+	last, ok := statements[len(statements)-1].(*ast.AssignStatement)
+	if !ok || last.Name != "z" {
+		t.Fatalf("expected last statement to assign z, got %#v", statements[len(statements)-1])
+	}
+
+	expected := []string{
+		"main.pg:1:8: invalid character U+0040 '@'",
+		"main.pg:2:6: unexpected ), expected expression",
+	}
+	errors := parser.Errors()
+	if len(errors) != len(expected) {
+		t.Fatalf("expected %d errors, got %v", len(expected), errors)
+	}
+	for i, err := range errors {
+		if err.Error() != expected[i] {
+			t.Errorf("errors[%d]: expected %q, got %q", i, expected[i], err.Error())
+		}
+	}
+}
+
+func TestParseRuneAndRawStringLiterals(t *testing.T) {
+	parser := NewParser(scanner.NewScanner(`'\n'`))
+	expr := parser.ParseExpression()
+
+	runeNode, ok := expr.(*ast.RuneNode)
+	if !ok {
+		t.Fatalf("expected *ast.RuneNode, got %T", expr)
+	}
+	if runeNode.Value != '\n' {
+		t.Errorf("expected %d, got %d", '\n', runeNode.Value)
+	}
+
+	parser = NewParser(scanner.NewScanner("'é' + 1"))
+	expr = parser.ParseExpression()
+	binOp, ok := unfold(expr).(*ast.BinaryOpNode)
+	if !ok {
+		t.Fatalf("expected *ast.BinaryOpNode, got %T", expr)
+	}
+	if left, ok := binOp.Left.(*ast.RuneNode); !ok || left.Value != 'é' {
+		t.Errorf("expected RuneNode 'é', got %#v", binOp.Left)
+	}
+
+	parser = NewParser(scanner.NewScanner("s := `a\\n\nb`"))
+	stmt, ok := parser.ParseStatement().(*ast.AssignStatement)
+	if !ok {
+		t.Fatalf("expected *ast.AssignStatement")
+	}
+	if str, ok := stmt.Value.(*ast.StringNode); !ok || str.Value != "a\\n\nb" {
+		t.Errorf("expected raw string %q, got %#v", "a\\n\nb", stmt.Value)
+	}
+}
+
+func TestParseErrorRecovery(t *testing.T) {
+	input := `func broken {
+	x := 1
+}
+
+func main() {
+	y := (1 + 2
+	if y > 1 {
+		print(y)
+	} else print(0)
+	z := 3 4
+	print(z)
+}
+
+type Point struct {
+	x int y int
+}
+
+func last() int {
+	return 1
+}`
+	parser := NewParser(scanner.NewFileScanner("main.pg", input))
+
+	var statements []ast.Statement
+	for {
+		stmt := parser.ParseStatement()
+		if stmt == nil {
+			break
+		}
+		statements = append(statements, stmt)
+	}
+
+	expected := []string{
+		"main.pg:1:13: unexpected {, expected '(' after function name",
+		"main.pg:6:13: unexpected newline, expected ')'",
+		"main.pg:9:9: unexpected print, expected if statement or block after else",
+		"main.pg:10:9: unexpected 4, expected end of statement",
+		"main.pg:15:8: unexpected y, expected ';' or '}' after field",
+	}
+	errors := parser.Errors()
+	if len(errors) != len(expected) {
+		t.Fatalf("expected %d errors, got %v", len(expected), errors)
+	}
+	for i, err := range errors {
+		if err.Error() != expected[i] {
+			t.Errorf("errors[%d]: expected %q, got %q", i, expected[i], err.Error())
+		}
+	}
+
+	// every declaration is still parsed after the errors
+	if len(statements) != 4 {
+		t.Fatalf("expected 4 statements, got %d", len(statements))
+	}
+	mainFunc, ok := statements[1].(*ast.FuncStatement)
+	if !ok || mainFunc.Name != "main" {
+		t.Fatalf("expected func main, got %#v", statements[1])
+	}
+	if len(mainFunc.Body.Statements) != 4 {
+		t.Errorf("expected 4 statements in main, got %d", len(mainFunc.Body.Statements))
+	}
+	if last, ok := statements[3].(*ast.FuncStatement); !ok || last.Name != "last" || last.Body == nil {
+		t.Errorf("expected func last with a body, got %#v", statements[3])
+	}
+}
+
+func TestParseElseIf(t *testing.T) {
+	input := `if x == 0 {
+	print(0)
+} else if x == 1 {
+	print(1)
+} else {
+	print(2)
+}`
+	parser := NewParser(scanner.NewScanner(input))
+
+	ifStmt, ok := parser.ParseStatement().(*ast.IfStatement)
+	if !ok {
+		t.Fatalf("expected *ast.IfStatement")
+	}
+	if errs := parser.Errors(); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	// else if is an else block holding a single if statement
+	if ifStmt.ElseBlock == nil || len(ifStmt.ElseBlock.Statements) != 1 {
+		t.Fatalf("expected else block with 1 statement")
+	}
+	nested, ok := ifStmt.ElseBlock.Statements[0].(*ast.IfStatement)
+	if !ok {
+		t.Fatalf("expected nested *ast.IfStatement, got %T", ifStmt.ElseBlock.Statements[0])
+	}
+	if got := parenthesize(nested.Condition); got != "(x == 1)" {
+		t.Errorf("expected nested condition (x == 1), got %s", got)
+	}
+	if nested.ElseBlock == nil {
+		t.Errorf("expected final else block")
+	}
+}
+
+func TestParseGeneralSwitchStatement(t *testing.T) {
+	input := `switch y := f(x); y + 1 {
+case 1, 2, 3:
+	print(1)
+	fallthrough
+default:
+	print(0)
+case Point{x: 1}.x:
+}`
+	parser := NewParser(scanner.NewScanner(input))
+
+	switchStmt, ok := parser.ParseStatement().(*ast.SwitchStatement)
+	if !ok {
+		t.Fatalf("expected *ast.SwitchStatement")
+	}
+	if errs := parser.Errors(); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	if init, ok := switchStmt.Init.(*ast.AssignStatement); !ok || init.Name != "y" {
+		t.Errorf("expected init statement y := f(x), got %#v", switchStmt.Init)
+	}
+	if got := parenthesize(switchStmt.Value); got != "(y + 1)" {
+		t.Errorf("expected switch value (y + 1), got %s", got)
+	}
+
+	if len(switchStmt.Cases) != 3 {
+		t.Fatalf("expected 3 clauses, got %d", len(switchStmt.Cases))
+	}
+	first := switchStmt.Cases[0]
+	if len(first.Values) != 3 || !first.Fallthrough() {
+		t.Errorf("expected 3 values and fallthrough in the first clause, got %d values", len(first.Values))
+	}
+	if !switchStmt.Cases[1].IsDefault() || switchStmt.Cases[1].Fallthrough() {
+		t.Errorf("expected default as the second clause")
+	}
+	if got := parenthesize(switchStmt.Cases[2].Values[0]); got != "Point{...}.x" {
+		t.Errorf("expected struct literal case value, got %s", got)
+	}
+}
+
+func TestParseTaglessSwitchStatement(t *testing.T) {
+	tests := []struct {
+		input string
+		init  bool
+	}{
+		{"switch {\ncase x > 0:\n\tprint(x)\n}", false},
+		{"switch x := 1; {\ncase x > 0:\n\tprint(x)\n}", true},
+	}
+
+	for _, tt := range tests {
+		parser := NewParser(scanner.NewScanner(tt.input))
+		switchStmt, ok := parser.ParseStatement().(*ast.SwitchStatement)
+		if !ok {
+			t.Fatalf("%q: expected *ast.SwitchStatement", tt.input)
+		}
+		if errs := parser.Errors(); len(errs) > 0 {
+			t.Fatalf("%q: unexpected errors: %v", tt.input, errs)
+		}
+		if switchStmt.Value != nil {
+			t.Errorf("%q: expected no switch value, got %T", tt.input, switchStmt.Value)
+		}
+		if (switchStmt.Init != nil) != tt.init {
+			t.Errorf("%q: expected init %v, got %#v", tt.input, tt.init, switchStmt.Init)
+		}
+		if got := parenthesize(switchStmt.Cases[0].Values[0]); got != "(x > 0)" {
+			t.Errorf("%q: expected case (x > 0), got %s", tt.input, got)
+		}
+	}
+}
+
+func TestParseSwitchErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"switch x {\ncase 1:\n\tfallthrough\n}", "3:2: cannot fallthrough final case in switch"},
+		{"switch x {\ncase 1:\n\tfallthrough\n\tprint(1)\ncase 2:\n}", "3:2: fallthrough statement out of place"},
+		{"switch x {\ncase 1:\n\tif x > 0 {\n\t\tfallthrough\n\t}\ncase 2:\n}", "4:3: fallthrough statement out of place"},
+		{"switch x {\ndefault:\ndefault:\n}", "3:1: multiple defaults in switch"},
+		{"switch x := 1 {\n}", "1:8: switch value must be an expression"},
+		{"switch x {\n\tprint(x)\n}", "2:2: unexpected print, expected case or default"},
+	}
+
+	for _, tt := range tests {
+		parser := NewParser(scanner.NewScanner(tt.input))
+		parser.ParseStatement()
+
+		errs := parser.Errors()
+		if len(errs) != 1 || errs[0].Error() != tt.expected {
+			t.Errorf("%q: expected error %q, got %v", tt.input, tt.expected, errs)
+		}
+	}
+}
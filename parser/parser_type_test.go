@@ -0,0 +1,201 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/yuya-takeyama/petitgo/ast"
+	"github.com/yuya-takeyama/petitgo/scanner"
+)
+
+func TestParser_CompositeTypes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var x []Person", "[]Person"},
+		{"var x [][]int", "[][]int"},
+		{"var x []*Node", "[]*Node"},
+		{"var x [3]int", "[3]int"},
+		{"const n = 2\nvar x [n * 2][]string", "[4][]string"},
+		{"var x map[string][]int", "map[string][]int"},
+		{"var x *[]map[int]bool", "*[]map[int]bool"},
+		{"var x func([]int) [][]int", "func([]int) [][]int"},
+		{"var x struct{ X int; Tags []string }", "struct{X int; Tags []string}"},
+		{"var x struct{ X, Y int }", "struct{X int; Y int}"},
+	}
+
+	for _, tt := range tests {
+		statements := parseStatements(t, tt.input)
+		stmt := statements[len(statements)-1].(*ast.VarStatement)
+		if got := ast.TypeName(stmt.Type); got != tt.expected {
+			t.Errorf("%q: expected type %s, got %s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestParser_CompositeTypePositions(t *testing.T) {
+	input := `type Tree struct {
+	Children []*Tree
+	Labels   [2]string
+}
+func (t *Tree) Leaves() []*Tree { return nil }
+func split(xs []int) (left, right []int) { return xs, xs }`
+	statements := parseStatements(t, input)
+
+	tree := statements[0].(*ast.TypeStatement)
+	children, ok := tree.Fields[0].Type.(*ast.SliceType)
+	if !ok {
+		t.Fatalf("expected *ast.SliceType, got %T", tree.Fields[0].Type)
+	}
+	if elem, ok := children.Elem.(*ast.PointerType); !ok || ast.TypeName(elem.Elem) != "Tree" {
+		t.Errorf("expected a slice of *Tree, got %s", children.TypeName())
+	}
+	if got := ast.TypeName(tree.Fields[1].Type); got != "[2]string" {
+		t.Errorf("expected field type [2]string, got %s", got)
+	}
+
+	leaves := statements[1].(*ast.FuncStatement)
+	if _, ok := leaves.Receiver.Type.(*ast.PointerType); !ok || leaves.ReceiverType() != "Tree" {
+		t.Errorf("expected a *Tree receiver, got %s", ast.TypeName(leaves.Receiver.Type))
+	}
+	if got := ast.TypeName(leaves.Results[0].Type); got != "[]*Tree" {
+		t.Errorf("expected result type []*Tree, got %s", got)
+	}
+
+	split := statements[2].(*ast.FuncStatement)
+	for i, name := range []string{"left", "right"} {
+		if result := split.Results[i]; result.Name != name || ast.TypeName(result.Type) != "[]int" {
+			t.Errorf("result %d: expected %s []int, got %s %s", i, name, result.Name, ast.TypeName(result.Type))
+		}
+	}
+}
+
+func TestParser_CompositeLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		size     int
+	}{
+		{"[]int{1, 2, 3}", "*ast.SliceLiteral", 3},
+		{"[3]int{1, 2}", "*ast.ArrayLiteral", 3},
+		{"[...]string{\"a\", \"b\"}", "*ast.ArrayLiteral", 2},
+		{"[][]int{}", "*ast.SliceLiteral", 0},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		x := p.ParseExpression()
+		if errs := p.Errors(); len(errs) != 0 {
+			t.Fatalf("%q: unexpected errors %v", tt.input, errs)
+		}
+		switch x := x.(type) {
+		case *ast.SliceLiteral:
+			if tt.expected != "*ast.SliceLiteral" || len(x.Elements) != tt.size {
+				t.Errorf("%q: expected %s of %d elements, got a slice literal of %d", tt.input, tt.expected, tt.size, len(x.Elements))
+			}
+		case *ast.ArrayLiteral:
+			if tt.expected != "*ast.ArrayLiteral" || x.Size != tt.size {
+				t.Errorf("%q: expected %s of size %d, got an array literal of size %d", tt.input, tt.expected, tt.size, x.Size)
+			}
+		default:
+			t.Errorf("%q: expected %s, got %T", tt.input, tt.expected, x)
+		}
+	}
+}
+
+func TestParser_ElidedElementTypes(t *testing.T) {
+	statements := parseStatements(t, `people := []Person{{Name: "a"}, Person{Name: "b"}}
+grid := [][]int{{1, 2}, {3}}
+nodes := []*Node{{Value: 1}}
+index := map[string][]int{"a": {1, 2}}
+points := map[Point]string{{X: 1}: "one"}
+pairs := []Point{{1, 2}, Point{3, 4}}`)
+
+	value := func(i int) ast.ASTNode {
+		return statements[i].(*ast.AssignStatement).Value
+	}
+
+	people := value(0).(*ast.SliceLiteral)
+	if person, ok := people.Elements[0].(*ast.StructLiteral); !ok || person.TypeName != "Person" {
+		t.Errorf("expected a Person literal, got %T", people.Elements[0])
+	}
+
+	grid := value(1).(*ast.SliceLiteral)
+	if row, ok := grid.Elements[0].(*ast.SliceLiteral); !ok || row.TypeName() != "[]int" || len(row.Elements) != 2 {
+		t.Errorf("expected a []int literal of 2 elements, got %T", grid.Elements[0])
+	}
+
+	nodes := value(2).(*ast.SliceLiteral)
+	address, ok := nodes.Elements[0].(*ast.UnaryOpNode)
+	if !ok {
+		t.Fatalf("expected &Node{...}, got %T", nodes.Elements[0])
+	}
+	if node, ok := address.Operand.(*ast.StructLiteral); !ok || node.TypeName != "Node" {
+		t.Errorf("expected the address of a Node literal, got %T", address.Operand)
+	}
+
+	index := value(3).(*ast.MapLiteral)
+	if list, ok := index.Entries[0].Value.(*ast.SliceLiteral); !ok || list.TypeName() != "[]int" {
+		t.Errorf("expected a []int value, got %T", index.Entries[0].Value)
+	}
+
+	points := value(4).(*ast.MapLiteral)
+	if key, ok := points.Entries[0].Key.(*ast.StructLiteral); !ok || key.TypeName != "Point" {
+		t.Errorf("expected a Point key, got %T", points.Entries[0].Key)
+	}
+
+	pairs := value(5).(*ast.SliceLiteral)
+	if pair, ok := pairs.Elements[0].(*ast.StructLiteral); !ok || pair.TypeName != "Point" || len(pair.Values) != 2 {
+		t.Errorf("expected a Point literal of 2 values, got %T", pairs.Elements[0])
+	}
+}
+
+func TestParser_StructLiteralValues(t *testing.T) {
+	statements := parseStatements(t, `type Point struct {
+	X, Y int
+	Name string
+}
+p := Point{1, 2, "a"}`)
+
+	point := statements[0].(*ast.TypeStatement)
+	if len(point.Fields) != 3 || point.Fields[1].Name != "Y" || ast.TypeName(point.Fields[1].Type) != "int" {
+		t.Errorf("expected fields X, Y int and Name string, got %d fields", len(point.Fields))
+	}
+	if point.Fields[1].Pos.Column != 5 {
+		t.Errorf("expected Y at column 5, got %d", point.Fields[1].Pos.Column)
+	}
+
+	literal := statements[1].(*ast.AssignStatement).Value.(*ast.StructLiteral)
+	fields := literal.FieldValues([]string{"X", "Y", "Name"})
+	if name, ok := fields["Name"].(*ast.StringNode); !ok || name.Value != "a" {
+		t.Errorf("expected Name to be \"a\", got %v", fields["Name"])
+	}
+}
+
+func TestParser_CompositeTypeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"n := 2\nvar a [n]int", "2:8: array length must be constant"},
+		{"var a [-1]int", "1:8: invalid array length -1"},
+		{"var a [...]int", "1:8: invalid use of [...] array (outside a composite literal)"},
+		{"x := [2]int{1, 2, 3}", "1:19: array index 2 out of bounds [0:2]"},
+		{"x := []int{{1}}", "1:12: invalid composite literal type int"},
+		{"p := Point{X: 1, 2}", "1:18: mixture of field:value and value elements in struct literal"},
+		{"type P struct { X, 1 int }", "1:20: unexpected 1, expected field name"},
+		{"x := []int", "1:11: unexpected EOF, expected '{' after []int"},
+		{"func f(a, b []int, []string) {}", "1:20: mixed named and unnamed parameters"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(scanner.NewScanner(tt.input))
+		for p.ParseStatement() != nil {
+		}
+
+		errs := p.Errors()
+		if len(errs) != 1 || errs[0].Error() != tt.expected {
+			t.Errorf("%q: expected error %q, got %v", tt.input, tt.expected, errs)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"os"
 
+	"github.com/yuya-takeyama/petitgo/ast"
 	"github.com/yuya-takeyama/petitgo/eval"
 	"github.com/yuya-takeyama/petitgo/parser"
 	"github.com/yuya-takeyama/petitgo/scanner"
@@ -42,20 +43,45 @@ func StartREPL() {
 	}
 }
 
-func evaluateInput(input string, env *eval.Environment) eval.Value {
+func evaluateInput(input string, env *eval.Environment) (result eval.Value) {
+	// 実行時エラーは REPL を終了せずに報告する
+	defer func() {
+		if r := recover(); r != nil {
+			panicEx, ok := r.(*eval.PanicException)
+			if !ok {
+				panic(r)
+			}
+			print("panic: " + panicEx.Value.String() + "\n")
+			result = &eval.StringValue{Value: ""}
+		}
+	}()
+
 	sc := scanner.NewScanner(input)
 	parser := parser.NewParser(sc)
 
 	// Statement か Expression かを判定
+	var stmt ast.Statement
+	var expr ast.ASTNode
 	if isStatement(input) {
-		stmt := parser.ParseStatement()
+		stmt = parser.ParseStatement()
+	} else {
+		expr = parser.ParseExpression()
+	}
+
+	// 構文エラーがあれば評価せずに報告する
+	if errors := parser.Errors(); len(errors) > 0 {
+		for _, err := range errors {
+			print(err.Error() + "\n")
+		}
+		return &eval.StringValue{Value: ""}
+	}
+
+	if stmt != nil {
 		eval.EvalStatement(stmt, env)
 		// Statement の場合は結果を返さない（空文字列を返す）
 		return &eval.StringValue{Value: ""}
-	} else {
-		expr := parser.ParseExpression()
-		return eval.EvalValueWithEnvironment(expr, env)
 	}
+	return eval.EvalValueWithEnvironment(expr, env)
 }
 
 func isStatement(input string) bool {
@@ -1,37 +1,83 @@
 package scanner
 
-import "github.com/yuya-takeyama/petitgo/token"
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yuya-takeyama/petitgo/token"
+)
 
 // Keywords map for keyword detection
 var keywords = map[string]token.Token{
-	"if":       token.IF,
-	"else":     token.ELSE,
-	"for":      token.FOR,
-	"break":    token.BREAK,
-	"continue": token.CONTINUE,
-	"func":     token.FUNC,
-	"return":   token.RETURN,
-	"true":     token.TRUE,
-	"false":    token.FALSE,
-	"struct":   token.STRUCT,
-	"type":     token.TYPE,
-	"package":  token.PACKAGE,
-	"import":   token.IMPORT,
-	"switch":   token.SWITCH,
-	"case":     token.CASE,
-	"default":  token.DEFAULT,
-	"var":      token.IDENT, // var is handled as token.IDENT for now
+	"if":          token.IF,
+	"else":        token.ELSE,
+	"for":         token.FOR,
+	"break":       token.BREAK,
+	"continue":    token.CONTINUE,
+	"func":        token.FUNC,
+	"return":      token.RETURN,
+	"true":        token.TRUE,
+	"false":       token.FALSE,
+	"struct":      token.STRUCT,
+	"interface":   token.INTERFACE,
+	"map":         token.MAP,
+	"range":       token.RANGE,
+	"type":        token.TYPE,
+	"const":       token.CONST,
+	"package":     token.PACKAGE,
+	"import":      token.IMPORT,
+	"switch":      token.SWITCH,
+	"case":        token.CASE,
+	"default":     token.DEFAULT,
+	"fallthrough": token.FALLTHROUGH,
+	"goto":        token.GOTO,
+	"defer":       token.DEFER,
+	"var":         token.IDENT, // var is handled as token.IDENT for now
+}
+
+// Error is a lexical or syntax error at a source position
+type Error struct {
+	Pos     token.Position
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Pos.String() + ": " + e.Message
 }
 
 type Scanner struct {
-	input    string
-	position int
+	input      string
+	position   int
+	filename   string
+	lines      []int    // byte offsets at which each line starts
+	insertSemi bool     // insert a semicolon before the next newline
+	errors     []*Error // errors for the ILLEGAL tokens returned so far
 }
 
 func NewScanner(input string) *Scanner {
-	return &Scanner{input: input, position: 0}
+	return NewFileScanner("", input)
 }
 
+// NewFileScanner creates a scanner whose token positions report filename
+func NewFileScanner(filename, input string) *Scanner {
+	lines := []int{0}
+	for i := 0; i < len(input); i++ {
+		if input[i] == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	position := 0
+	if len(input) >= len(bom) && input[:len(bom)] == bom {
+		// A byte order mark is ignored at the beginning of the input
+		position = len(bom)
+	}
+	return &Scanner{input: input, position: position, filename: filename, lines: lines}
+}
+
+// bom is the UTF-8 encoding of the byte order mark U+FEFF
+const bom = "\uFEFF"
+
 func (s *Scanner) Input() string {
 	return s.input
 }
@@ -40,10 +86,121 @@ func (s *Scanner) Position() int {
 	return s.position
 }
 
+// Errors returns the errors for the ILLEGAL tokens scanned so far
+func (s *Scanner) Errors() []*Error {
+	return s.errors
+}
+
+// Filename returns the file name used in token positions
+func (s *Scanner) Filename() string {
+	return s.filename
+}
+
+// PositionFor converts a byte offset in the input into a line/column position
+func (s *Scanner) PositionFor(offset int) token.Position {
+	// binary search for the line containing offset
+	lo, hi := 0, len(s.lines)-1
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if s.lines[mid] <= offset {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return token.Position{
+		Filename: s.filename,
+		Offset:   offset,
+		Line:     lo + 1,
+		Column:   offset - s.lines[lo] + 1,
+	}
+}
+
+// NextToken returns the next token in the input.
+//
+// Following the Go specification, a SEMICOLON token with literal "\n" is
+// inserted at the end of a line whose final token is an identifier, a basic
+// literal, one of the keywords break, continue, fallthrough, return, true and
+// false, one of the operators ++ and --, or a closing ), ] or }. At the end of
+// the input no semicolon is produced; the parser accepts EOF as a statement
+// terminator.
 func (s *Scanner) NextToken() token.TokenInfo {
 	// Skip whitespace characters
 	s.skipWhitespace()
 
+	start := s.position
+	if s.insertSemi && s.atLineEnd() {
+		s.insertSemi = false
+		if s.position < len(s.input) && s.input[s.position] == '\n' {
+			s.position++
+		}
+		return token.TokenInfo{Type: token.SEMICOLON, Literal: "\n", Pos: s.PositionFor(start)}
+	}
+
+	tok := s.scanToken()
+	tok.Pos = s.PositionFor(start)
+	if tok.Type == token.ILLEGAL {
+		s.errors = append(s.errors, &Error{Pos: tok.Pos, Message: tok.Literal})
+	}
+
+	// comments do not affect semicolon insertion
+	if tok.Type != token.COMMENT {
+		s.insertSemi = insertsSemicolon(tok.Type)
+	}
+	return tok
+}
+
+// atLineEnd reports whether the scanner is at a newline, or at a comment
+// that ends the current line and therefore acts like one
+func (s *Scanner) atLineEnd() bool {
+	if s.position >= len(s.input) {
+		return false
+	}
+	if s.input[s.position] == '\n' {
+		return true
+	}
+	if s.input[s.position] != '/' || s.position+1 >= len(s.input) {
+		return false
+	}
+	switch s.input[s.position+1] {
+	case '/':
+		return true
+	case '*':
+		// a block comment acts like a newline if it spans lines or
+		// is followed only by whitespace up to the end of the line
+		end := s.position + 2
+		for end+1 < len(s.input) && !(s.input[end] == '*' && s.input[end+1] == '/') {
+			if s.input[end] == '\n' {
+				return true
+			}
+			end++
+		}
+		for end += 2; end < len(s.input); end++ {
+			if s.input[end] == '\n' {
+				return true
+			}
+			if !isWhitespace(s.input[end]) {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+// insertsSemicolon reports whether a newline after a token of type t
+// terminates the statement
+func insertsSemicolon(t token.Token) bool {
+	switch t {
+	case token.IDENT, token.INT, token.FLOAT, token.IMAG, token.CHAR, token.STRING,
+		token.BREAK, token.CONTINUE, token.FALLTHROUGH, token.RETURN, token.TRUE, token.FALSE,
+		token.INC, token.DEC, token.RPAREN, token.RBRACK, token.RBRACE:
+		return true
+	}
+	return false
+}
+
+// scanToken scans the token starting at the current position
+func (s *Scanner) scanToken() token.TokenInfo {
 	// Check for end of input
 	if s.position >= len(s.input) {
 		return token.TokenInfo{Type: token.EOF, Literal: ""}
@@ -82,6 +239,13 @@ func (s *Scanner) NextToken() token.TokenInfo {
 		}
 		s.position++
 		return token.TokenInfo{Type: token.MUL, Literal: "*"}
+	case '%':
+		if s.position+1 < len(s.input) && s.input[s.position+1] == '=' {
+			s.position += 2
+			return token.TokenInfo{Type: token.REM_ASSIGN, Literal: "%="}
+		}
+		s.position++
+		return token.TokenInfo{Type: token.REM, Literal: "%"}
 	case '/':
 		// Check for comments first
 		if s.position+1 < len(s.input) && s.input[s.position+1] == '/' {
@@ -121,6 +285,14 @@ func (s *Scanner) NextToken() token.TokenInfo {
 		s.position++
 		return token.TokenInfo{Type: token.NOT, Literal: "!"}
 	case '<':
+		if s.position+1 < len(s.input) && s.input[s.position+1] == '<' {
+			if s.position+2 < len(s.input) && s.input[s.position+2] == '=' {
+				s.position += 3
+				return token.TokenInfo{Type: token.SHL_ASSIGN, Literal: "<<="}
+			}
+			s.position += 2
+			return token.TokenInfo{Type: token.SHL, Literal: "<<"}
+		}
 		if s.position+1 < len(s.input) && s.input[s.position+1] == '=' {
 			s.position += 2
 			return token.TokenInfo{Type: token.LEQ, Literal: "<="}
@@ -128,6 +300,14 @@ func (s *Scanner) NextToken() token.TokenInfo {
 		s.position++
 		return token.TokenInfo{Type: token.LSS, Literal: "<"}
 	case '>':
+		if s.position+1 < len(s.input) && s.input[s.position+1] == '>' {
+			if s.position+2 < len(s.input) && s.input[s.position+2] == '=' {
+				s.position += 3
+				return token.TokenInfo{Type: token.SHR_ASSIGN, Literal: ">>="}
+			}
+			s.position += 2
+			return token.TokenInfo{Type: token.SHR, Literal: ">>"}
+		}
 		if s.position+1 < len(s.input) && s.input[s.position+1] == '=' {
 			s.position += 2
 			return token.TokenInfo{Type: token.GEQ, Literal: ">="}
@@ -139,11 +319,38 @@ func (s *Scanner) NextToken() token.TokenInfo {
 			s.position += 2
 			return token.TokenInfo{Type: token.LAND, Literal: "&&"}
 		}
+		if s.position+1 < len(s.input) && s.input[s.position+1] == '^' {
+			if s.position+2 < len(s.input) && s.input[s.position+2] == '=' {
+				s.position += 3
+				return token.TokenInfo{Type: token.AND_NOT_ASSIGN, Literal: "&^="}
+			}
+			s.position += 2
+			return token.TokenInfo{Type: token.AND_NOT, Literal: "&^"}
+		}
+		if s.position+1 < len(s.input) && s.input[s.position+1] == '=' {
+			s.position += 2
+			return token.TokenInfo{Type: token.AND_ASSIGN, Literal: "&="}
+		}
+		s.position++
+		return token.TokenInfo{Type: token.AND, Literal: "&"}
 	case '|':
 		if s.position+1 < len(s.input) && s.input[s.position+1] == '|' {
 			s.position += 2
 			return token.TokenInfo{Type: token.LOR, Literal: "||"}
 		}
+		if s.position+1 < len(s.input) && s.input[s.position+1] == '=' {
+			s.position += 2
+			return token.TokenInfo{Type: token.OR_ASSIGN, Literal: "|="}
+		}
+		s.position++
+		return token.TokenInfo{Type: token.OR, Literal: "|"}
+	case '^':
+		if s.position+1 < len(s.input) && s.input[s.position+1] == '=' {
+			s.position += 2
+			return token.TokenInfo{Type: token.XOR_ASSIGN, Literal: "^="}
+		}
+		s.position++
+		return token.TokenInfo{Type: token.XOR, Literal: "^"}
 	case '(':
 		s.position++
 		return token.TokenInfo{Type: token.LPAREN, Literal: "("}
@@ -160,6 +367,14 @@ func (s *Scanner) NextToken() token.TokenInfo {
 		s.position++
 		return token.TokenInfo{Type: token.COMMA, Literal: ","}
 	case '.':
+		if s.position+1 < len(s.input) && isDigit(s.input[s.position+1]) {
+			// fraction without integer part: .5
+			return s.scanNumber()
+		}
+		if s.position+2 < len(s.input) && s.input[s.position+1] == '.' && s.input[s.position+2] == '.' {
+			s.position += 3
+			return token.TokenInfo{Type: token.ELLIPSIS, Literal: "..."}
+		}
 		s.position++
 		return token.TokenInfo{Type: token.PERIOD, Literal: "."}
 	case ';':
@@ -173,60 +388,278 @@ func (s *Scanner) NextToken() token.TokenInfo {
 		return token.TokenInfo{Type: token.RBRACK, Literal: "]"}
 	case '"':
 		return s.readString()
+	case '`':
+		return s.readRawString()
+	case '\'':
+		return s.readRune()
 	}
 
-	// Read identifier (starts with letter)
-	if isLetter(ch) {
-		start := s.position
-		for s.position < len(s.input) && (isLetter(s.input[s.position]) || isDigit(s.input[s.position])) {
+	// Non-ASCII characters are decoded as UTF-8
+	if ch >= utf8.RuneSelf {
+		r, width := utf8.DecodeRuneInString(s.input[s.position:])
+		if r == utf8.RuneError && width == 1 {
 			s.position++
+			return token.TokenInfo{Type: token.ILLEGAL, Literal: "invalid UTF-8 encoding"}
 		}
-
-		literal := s.input[start:s.position]
-
-		// Check if it's a keyword
-		if tokenType, isKeyword := keywords[literal]; isKeyword {
-			return token.TokenInfo{
-				Type:    tokenType,
-				Literal: literal,
-			}
+		if s.input[s.position:s.position+width] == bom {
+			s.position += width
+			return token.TokenInfo{Type: token.ILLEGAL, Literal: "invalid BOM in the middle of the file"}
 		}
-
-		return token.TokenInfo{
-			Type:    token.IDENT,
-			Literal: literal,
+		if isLetterRune(r) {
+			return s.scanIdentifier()
 		}
+		s.position += width
+		return illegalCharacter(r)
+	}
+
+	// Read identifier (starts with letter)
+	if isLetter(ch) {
+		return s.scanIdentifier()
 	}
 
 	// Read number
 	if isDigit(ch) {
-		start := s.position
-		for s.position < len(s.input) && isDigit(s.input[s.position]) {
+		return s.scanNumber()
+	}
+
+	// Unknown character
+	s.position++
+	return illegalCharacter(rune(ch))
+}
+
+// illegalCharacter returns an ILLEGAL token for a character that cannot
+// start a token
+func illegalCharacter(r rune) token.TokenInfo {
+	message := fmt.Sprintf("invalid character %#U", r)
+	if !unicode.IsPrint(r) {
+		message = fmt.Sprintf("invalid character U+%04X", r)
+	}
+	return token.TokenInfo{Type: token.ILLEGAL, Literal: message}
+}
+
+// scanIdentifier reads an identifier or keyword. Identifiers consist of
+// Unicode letters, digits and '_', and do not start with a digit.
+func (s *Scanner) scanIdentifier() token.TokenInfo {
+	start := s.position
+	for s.position < len(s.input) {
+		ch := s.input[s.position]
+		if ch < utf8.RuneSelf {
+			if !isLetter(ch) && !isDigit(ch) {
+				break
+			}
 			s.position++
+			continue
+		}
+		r, width := utf8.DecodeRuneInString(s.input[s.position:])
+		if !isLetterRune(r) && !unicode.IsDigit(r) {
+			// also stops at invalid encodings, which are reported by the next token
+			break
 		}
+		s.position += width
+	}
+
+	literal := s.input[start:s.position]
 
-		literal := s.input[start:s.position]
+	// Check if it's a keyword
+	if tokenType, isKeyword := keywords[literal]; isKeyword {
 		return token.TokenInfo{
-			Type:    token.INT,
+			Type:    tokenType,
 			Literal: literal,
 		}
 	}
 
-	// Unknown character
-	s.position++
-	return token.TokenInfo{Type: token.EOF, Literal: ""}
+	return token.TokenInfo{
+		Type:    token.IDENT,
+		Literal: literal,
+	}
 }
 
 func isDigit(ch byte) bool {
 	return ch >= '0' && ch <= '9'
 }
 
+func isHexDigit(ch byte) bool {
+	return isDigit(ch) || (lower(ch) >= 'a' && lower(ch) <= 'f')
+}
+
+// lower returns the lower-case version of an ASCII letter
+func lower(ch byte) byte {
+	return ch | 0x20
+}
+
+// scanNumber reads an integer, floating-point or imaginary literal following
+// the Go syntax: 0x/0o/0b prefixes, legacy 0-prefixed octals, '_' digit
+// separators, decimal and hexadecimal exponents and the 'i' suffix.
+// The literal is returned verbatim; conversion happens in the parser.
+func (s *Scanner) scanNumber() token.TokenInfo {
+	start := s.position
+	tok := token.INT
+	base := 10
+	prefix := byte(0)
+	invalidDigit := -1 // offset of the first digit that is invalid for base
+
+	if s.input[s.position] != '.' {
+		if s.input[s.position] == '0' {
+			s.position++
+			if s.position < len(s.input) {
+				switch lower(s.input[s.position]) {
+				case 'x':
+					s.position++
+					base, prefix = 16, 'x'
+				case 'o':
+					s.position++
+					base, prefix = 8, 'o'
+				case 'b':
+					s.position++
+					base, prefix = 2, 'b'
+				default:
+					base, prefix = 8, '0'
+				}
+			}
+		}
+		s.scanDigits(base, &invalidDigit)
+		if prefix != 0 && prefix != '0' && s.position == start+2 {
+			return s.illegalNumber(start, "literal has no digits")
+		}
+	}
+
+	// fractional part
+	if s.position < len(s.input) && s.input[s.position] == '.' {
+		tok = token.FLOAT
+		if prefix == 'o' || prefix == 'b' {
+			return s.illegalNumber(start, "invalid radix point in literal")
+		}
+		s.position++
+		s.scanDigits(base, &invalidDigit)
+	}
+
+	// exponent
+	hasExponent := false
+	if s.position < len(s.input) {
+		if e := lower(s.input[s.position]); e == 'e' || e == 'p' {
+			hasExponent = true
+			if e == 'e' && prefix != 0 && prefix != '0' {
+				return s.illegalNumber(start, "'e' exponent requires decimal mantissa")
+			}
+			if e == 'p' && prefix != 'x' {
+				return s.illegalNumber(start, "'p' exponent requires hexadecimal mantissa")
+			}
+			s.position++
+			tok = token.FLOAT
+			if s.position < len(s.input) && (s.input[s.position] == '+' || s.input[s.position] == '-') {
+				s.position++
+			}
+			expStart := s.position
+			ignored := -1
+			s.scanDigits(10, &ignored)
+			if s.position == expStart {
+				return s.illegalNumber(start, "exponent has no digits")
+			}
+		}
+	}
+	if prefix == 'x' && tok == token.FLOAT && !hasExponent {
+		return s.illegalNumber(start, "hexadecimal mantissa requires a 'p' exponent")
+	}
+
+	// imaginary suffix
+	if s.position < len(s.input) && s.input[s.position] == 'i' {
+		s.position++
+		tok = token.IMAG
+	}
+
+	literal := s.input[start:s.position]
+
+	// legacy octal literals may contain 8 and 9 only if they turn out to be floats
+	if tok == token.INT && invalidDigit >= 0 {
+		return s.illegalNumber(start, "invalid digit '"+string(s.input[invalidDigit])+"' in "+baseName(base)+" literal")
+	}
+	if !validSeparators(literal) {
+		return s.illegalNumber(start, "'_' must separate successive digits")
+	}
+
+	return token.TokenInfo{Type: tok, Literal: literal}
+}
+
+// scanDigits consumes digits and '_' separators. Decimal digits that are not
+// valid for base are consumed too and the first one is recorded in invalid.
+func (s *Scanner) scanDigits(base int, invalid *int) {
+	for s.position < len(s.input) {
+		ch := s.input[s.position]
+		if base == 16 {
+			if !isHexDigit(ch) && ch != '_' {
+				return
+			}
+		} else if !isDigit(ch) && ch != '_' {
+			return
+		} else if ch != '_' && int(ch-'0') >= base && *invalid < 0 {
+			*invalid = s.position
+		}
+		s.position++
+	}
+}
+
+// illegalNumber skips the rest of a malformed number and reports it
+func (s *Scanner) illegalNumber(start int, message string) token.TokenInfo {
+	for s.position < len(s.input) && (isLetter(s.input[s.position]) || isDigit(s.input[s.position]) || s.input[s.position] == '.') {
+		s.position++
+	}
+	return token.TokenInfo{Type: token.ILLEGAL, Literal: message}
+}
+
+func baseName(base int) string {
+	switch base {
+	case 2:
+		return "binary"
+	case 8:
+		return "octal"
+	case 16:
+		return "hexadecimal"
+	}
+	return "decimal"
+}
+
+// validSeparators reports whether every '_' in a number literal sits
+// between two digits or between the base prefix and a digit
+func validSeparators(literal string) bool {
+	digit := isDigit
+	i := 0
+	if len(literal) >= 2 && literal[0] == '0' {
+		switch lower(literal[1]) {
+		case 'x':
+			digit = isHexDigit
+			i = 2
+		case 'o', 'b':
+			i = 2
+		}
+	}
+	prefixEnd := i
+	for ; i < len(literal); i++ {
+		if literal[i] != '_' {
+			continue
+		}
+		prevOK := (prefixEnd > 0 && i == prefixEnd) || (i > 0 && digit(literal[i-1]))
+		nextOK := i+1 < len(literal) && digit(literal[i+1])
+		if !prevOK || !nextOK {
+			return false
+		}
+	}
+	return true
+}
+
 func isLetter(ch byte) bool {
 	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch == '_'
 }
 
+// isLetterRune reports whether r is a Unicode letter or '_'
+func isLetterRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
 func (s *Scanner) skipWhitespace() {
 	for s.position < len(s.input) && isWhitespace(s.input[s.position]) {
+		if s.input[s.position] == '\n' && s.insertSemi {
+			return
+		}
 		s.position++
 	}
 }
@@ -258,41 +691,214 @@ func (s *Scanner) readString() token.TokenInfo {
 	literal := s.input[start+1 : s.position]
 	s.position++ // skip closing quote
 
+	if !utf8.ValidString(literal) {
+		return token.TokenInfo{Type: token.ILLEGAL, Literal: "invalid UTF-8 encoding in string literal"}
+	}
+
 	// Process escape sequences
 	processed := processEscapeSequences(literal)
 
 	return token.TokenInfo{Type: token.STRING, Literal: processed}
 }
 
-// processEscapeSequences handles basic escape sequences
+// readRawString reads a raw string literal enclosed in backquotes.
+// No escape processing is done and carriage returns are discarded.
+func (s *Scanner) readRawString() token.TokenInfo {
+	start := s.position
+	s.position++ // skip opening backquote
+
+	for s.position < len(s.input) && s.input[s.position] != '`' {
+		s.position++
+	}
+
+	if s.position >= len(s.input) {
+		// Unclosed raw string literal - return error token
+		return token.TokenInfo{Type: token.ILLEGAL, Literal: "unclosed raw string"}
+	}
+
+	literal := s.input[start+1 : s.position]
+	s.position++ // skip closing backquote
+
+	if !utf8.ValidString(literal) {
+		return token.TokenInfo{Type: token.ILLEGAL, Literal: "invalid UTF-8 encoding in raw string literal"}
+	}
+
+	result := ""
+	for i := 0; i < len(literal); i++ {
+		if literal[i] != '\r' {
+			result += literal[i : i+1]
+		}
+	}
+
+	return token.TokenInfo{Type: token.STRING, Literal: result}
+}
+
+// readRune reads a rune literal such as 'a', '\n' or '\x41'.
+// The literal of the resulting CHAR token is the UTF-8 encoding of the rune.
+func (s *Scanner) readRune() token.TokenInfo {
+	start := s.position
+	s.position++ // skip opening quote
+
+	// Find the closing quote on the same line
+	for s.position < len(s.input) && s.input[s.position] != '\'' && s.input[s.position] != '\n' {
+		if s.input[s.position] == '\\' && s.position+1 < len(s.input) {
+			s.position += 2 // skip escape sequence
+		} else {
+			s.position++
+		}
+	}
+
+	if s.position >= len(s.input) || s.input[s.position] != '\'' {
+		return token.TokenInfo{Type: token.ILLEGAL, Literal: "rune literal not terminated"}
+	}
+
+	body := s.input[start+1 : s.position]
+	s.position++ // skip closing quote
+
+	if len(body) == 0 {
+		return token.TokenInfo{Type: token.ILLEGAL, Literal: "empty rune literal or unescaped ' in rune literal"}
+	}
+
+	var value rune
+	var width int
+	if body[0] == '\\' {
+		value, _, width = unescape(body, 0, '\'')
+		if width == 0 {
+			return token.TokenInfo{Type: token.ILLEGAL, Literal: "invalid escape sequence in rune literal: " + body}
+		}
+	} else {
+		value, width = utf8.DecodeRuneInString(body)
+		if value == utf8.RuneError && width == 1 {
+			return token.TokenInfo{Type: token.ILLEGAL, Literal: "invalid UTF-8 encoding in rune literal"}
+		}
+	}
+
+	if width != len(body) {
+		return token.TokenInfo{Type: token.ILLEGAL, Literal: "more than one character in rune literal"}
+	}
+
+	return token.TokenInfo{Type: token.CHAR, Literal: string(value)}
+}
+
+// processEscapeSequences handles escape sequences in interpreted string literals.
+// Invalid escape sequences are kept as is.
 func processEscapeSequences(input string) string {
 	result := ""
 	for i := 0; i < len(input); i++ {
 		if input[i] == '\\' && i+1 < len(input) {
-			switch input[i+1] {
-			case 'n':
-				result += "\n"
-			case 't':
-				result += "\t"
-			case 'r':
-				result += "\r"
-			case '"':
-				result += "\""
-			case '\\':
-				result += "\\"
-			default:
+			value, isByte, width := unescape(input, i, '"')
+			if width == 0 {
 				// Unknown escape sequence, keep as is
-				result += string(input[i])
-				result += string(input[i+1])
+				result += input[i : i+2]
+				i++ // skip next character
+				continue
 			}
-			i++ // skip next character
+			if isByte {
+				result += string([]byte{byte(value)})
+			} else {
+				result += string(value)
+			}
+			i += width - 1 // skip the rest of the escape sequence
 		} else {
-			result += string(input[i])
+			// Copy the byte as is so that UTF-8 sequences stay intact
+			result += input[i : i+1]
 		}
 	}
 	return result
 }
 
+// unescape decodes the escape sequence starting at input[i] (a backslash).
+// quote is the delimiter of the enclosing literal, which may be escaped.
+// It returns the decoded value, whether the value is a single byte (\x and
+// octal escapes) and the length of the sequence; a length of 0 means the
+// sequence is invalid.
+func unescape(input string, i int, quote byte) (rune, bool, int) {
+	if i+1 >= len(input) {
+		return 0, false, 0
+	}
+
+	switch c := input[i+1]; c {
+	case 'a':
+		return '\a', false, 2
+	case 'b':
+		return '\b', false, 2
+	case 'f':
+		return '\f', false, 2
+	case 'n':
+		return '\n', false, 2
+	case 'r':
+		return '\r', false, 2
+	case 't':
+		return '\t', false, 2
+	case 'v':
+		return '\v', false, 2
+	case '\\':
+		return '\\', false, 2
+	case 'x':
+		value, ok := readHexDigits(input, i+2, 2)
+		if !ok {
+			return 0, false, 0
+		}
+		return value, true, 4
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		value, ok := readHexDigits(input, i+2, n)
+		if !ok || value > utf8.MaxRune || (value >= 0xD800 && value < 0xE000) {
+			// Surrogate halves and values above the maximum are not valid code points
+			return 0, false, 0
+		}
+		return value, false, n + 2
+	default:
+		if c == quote {
+			return rune(c), false, 2
+		}
+		if c >= '0' && c <= '7' {
+			// Octal escape: exactly three digits, value at most 255
+			if i+4 > len(input) {
+				return 0, false, 0
+			}
+			value := rune(0)
+			for j := i + 1; j < i+4; j++ {
+				if input[j] < '0' || input[j] > '7' {
+					return 0, false, 0
+				}
+				value = value*8 + rune(input[j]-'0')
+			}
+			if value > 255 {
+				return 0, false, 0
+			}
+			return value, true, 4
+		}
+	}
+
+	return 0, false, 0
+}
+
+// readHexDigits reads exactly n hexadecimal digits starting at input[start]
+func readHexDigits(input string, start, n int) (rune, bool) {
+	if start+n > len(input) {
+		return 0, false
+	}
+	value := rune(0)
+	for j := start; j < start+n; j++ {
+		ch := input[j]
+		var digit rune
+		switch {
+		case isDigit(ch):
+			digit = rune(ch - '0')
+		case isHexDigit(ch):
+			digit = rune(lower(ch)-'a') + 10
+		default:
+			return 0, false
+		}
+		value = value*16 + digit
+	}
+	return value, true
+}
+
 // scanLineComment scans a line comment starting with //
 func (s *Scanner) scanLineComment() token.TokenInfo {
 	start := s.position
@@ -303,10 +909,7 @@ func (s *Scanner) scanLineComment() token.TokenInfo {
 		s.position++
 	}
 
-	return token.TokenInfo{
-		Type:    token.COMMENT,
-		Literal: string(s.input[start:s.position]),
-	}
+	return commentToken(s.input[start:s.position])
 }
 
 // scanBlockComment scans a block comment starting with /* and ending with */
@@ -318,13 +921,23 @@ func (s *Scanner) scanBlockComment() token.TokenInfo {
 	for s.position+1 < len(s.input) {
 		if s.input[s.position] == '*' && s.input[s.position+1] == '/' {
 			s.position += 2 // skip */
-			break
+			return commentToken(s.input[start:s.position])
 		}
 		s.position++
 	}
 
+	s.position = len(s.input)
+	return token.TokenInfo{Type: token.ILLEGAL, Literal: "comment not terminated"}
+}
+
+// commentToken returns a COMMENT token, or an ILLEGAL token if the comment
+// is not valid UTF-8
+func commentToken(literal string) token.TokenInfo {
+	if !utf8.ValidString(literal) {
+		return token.TokenInfo{Type: token.ILLEGAL, Literal: "invalid UTF-8 encoding in comment"}
+	}
 	return token.TokenInfo{
 		Type:    token.COMMENT,
-		Literal: string(s.input[start:s.position]),
+		Literal: literal,
 	}
 }
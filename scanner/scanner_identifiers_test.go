@@ -65,3 +65,91 @@ func TestLexerAssignmentOperators(t *testing.T) {
 		}
 	}
 }
+
+func TestLexerUnicodeIdentifiers(t *testing.T) {
+	input := "café größe _x1 日本語 αβγ٣"
+
+	expected := []string{"café", "größe", "_x1", "日本語", "αβγ٣"}
+
+	scanner := NewScanner(input)
+	for i, literal := range expected {
+		tok := scanner.NextToken()
+		if tok.Type != token.IDENT {
+			t.Fatalf("tests[%d] - expected IDENT, got %q (%q)", i, tok.Type, tok.Literal)
+		}
+		if tok.Literal != literal {
+			t.Fatalf("tests[%d] - expected literal %q, got %q", i, literal, tok.Literal)
+		}
+	}
+
+	if tok := scanner.NextToken(); tok.Type != token.EOF {
+		t.Fatalf("expected EOF, got %q (%q)", tok.Type, tok.Literal)
+	}
+}
+
+func TestLexerByteOrderMark(t *testing.T) {
+	scanner := NewScanner("\uFEFFx := 1")
+
+	tok := scanner.NextToken()
+	if tok.Type != token.IDENT || tok.Literal != "x" {
+		t.Fatalf("expected IDENT x after BOM, got %q (%q)", tok.Type, tok.Literal)
+	}
+	if tok.Pos.Offset != 3 || tok.Pos.Column != 4 {
+		t.Errorf("expected x at offset 3, column 4, got %+v", tok.Pos)
+	}
+
+	// A BOM elsewhere is an error
+	scanner = NewScanner("x\uFEFF")
+	scanner.NextToken()
+	tok = scanner.NextToken()
+	if tok.Type != token.ILLEGAL || tok.Literal != "invalid BOM in the middle of the file" {
+		t.Errorf("expected ILLEGAL for BOM, got %q (%q)", tok.Type, tok.Literal)
+	}
+}
+
+func TestLexerInvalidUTF8(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedLiteral string
+		expectedColumn  int
+	}{
+		{"x \xff", "invalid UTF-8 encoding", 3},
+		{"x \"a\xc3\"", "invalid UTF-8 encoding in string literal", 3},
+		{"x `a\xe2\x82`", "invalid UTF-8 encoding in raw string literal", 3},
+		{"x '\xff'", "invalid UTF-8 encoding in rune literal", 3},
+		{"x /* \xff */", "invalid UTF-8 encoding in comment", 3},
+	}
+
+	for _, tt := range tests {
+		scanner := NewScanner(tt.input)
+		scanner.NextToken() // x
+
+		tok := scanner.NextToken()
+		if tok.Type != token.ILLEGAL {
+			t.Fatalf("input %q: expected ILLEGAL, got %q (%q)", tt.input, tok.Type, tok.Literal)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Errorf("input %q: expected literal %q, got %q", tt.input, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.Pos.Line != 1 || tok.Pos.Column != tt.expectedColumn {
+			t.Errorf("input %q: expected position 1:%d, got %s", tt.input, tt.expectedColumn, tok.Pos)
+		}
+	}
+
+	// An invalid byte ends the identifier it follows
+	scanner := NewScanner("ab\xffc")
+	expected := []struct {
+		typ     token.Token
+		literal string
+	}{
+		{token.IDENT, "ab"},
+		{token.ILLEGAL, "invalid UTF-8 encoding"},
+		{token.IDENT, "c"},
+	}
+	for i, tt := range expected {
+		tok := scanner.NextToken()
+		if tok.Type != tt.typ || tok.Literal != tt.literal {
+			t.Errorf("tests[%d] - expected %q (%q), got %q (%q)", i, tt.typ, tt.literal, tok.Type, tok.Literal)
+		}
+	}
+}
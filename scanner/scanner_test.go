@@ -213,6 +213,47 @@ func TestLexer_NextToken_LogicalOperators(t *testing.T) {
 	}
 }
 
+func TestLexer_NextToken_IntegerOperators(t *testing.T) {
+	input := "% & | ^ << >> &^ %= &= |= ^= <<= >>= &^= a<<b"
+
+	tests := []struct {
+		expectedType    token.Token
+		expectedLiteral string
+	}{
+		{token.REM, "%"},
+		{token.AND, "&"},
+		{token.OR, "|"},
+		{token.XOR, "^"},
+		{token.SHL, "<<"},
+		{token.SHR, ">>"},
+		{token.AND_NOT, "&^"},
+		{token.REM_ASSIGN, "%="},
+		{token.AND_ASSIGN, "&="},
+		{token.OR_ASSIGN, "|="},
+		{token.XOR_ASSIGN, "^="},
+		{token.SHL_ASSIGN, "<<="},
+		{token.SHR_ASSIGN, ">>="},
+		{token.AND_NOT_ASSIGN, "&^="},
+		{token.IDENT, "a"},
+		{token.SHL, "<<"},
+		{token.IDENT, "b"},
+		{token.EOF, ""},
+	}
+
+	scanner := NewScanner(input)
+	for i, tt := range tests {
+		tok := scanner.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected=%d, got=%d", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token literal wrong. expected=%s, got=%s", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
 func TestLexer_NextToken_Comma(t *testing.T) {
 	input := ","
 	scanner := NewScanner(input)
@@ -262,6 +303,8 @@ func TestLexer_NextToken_Keywords(t *testing.T) {
 		{"for", token.FOR, "for"},
 		{"break", token.BREAK, "break"},
 		{"continue", token.CONTINUE, "continue"},
+		{"goto", token.GOTO, "goto"},
+		{"defer", token.DEFER, "defer"},
 		{"func", token.FUNC, "func"},
 		{"return", token.RETURN, "return"},
 	}
@@ -587,6 +630,7 @@ func TestScanner_AdditionalPunctuation(t *testing.T) {
 		expectedLiteral string
 	}{
 		{".", token.PERIOD, "."},
+		{"...", token.ELLIPSIS, "..."},
 		{";", token.SEMICOLON, ";"},
 		{"[", token.LBRACK, "["},
 		{"]", token.RBRACK, "]"},
@@ -606,15 +650,15 @@ func TestScanner_AdditionalPunctuation(t *testing.T) {
 	}
 }
 
-// Test single & and | characters (fallthrough cases)
+// Test single & and | characters
 func TestScanner_SingleAmpersandPipe(t *testing.T) {
 	tests := []struct {
 		input           string
 		expectedType    token.Token
 		expectedLiteral string
 	}{
-		{"&", token.EOF, ""}, // & alone falls through to EOF
-		{"|", token.EOF, ""}, // | alone falls through to EOF
+		{"&", token.AND, "&"},
+		{"|", token.OR, "|"},
 	}
 
 	for _, tt := range tests {
@@ -634,27 +678,388 @@ func TestScanner_SingleAmpersandPipe(t *testing.T) {
 // Test unknown characters
 func TestScanner_UnknownCharacters(t *testing.T) {
 	tests := []struct {
-		input string
+		input           string
+		expectedLiteral string
 	}{
-		{"@"}, // Unknown character
-		{"#"}, // Unknown character
-		{"$"}, // Unknown character
-		{"%"}, // Unknown character
-		{"^"}, // Unknown character
-		{"~"}, // Unknown character
-		{"`"}, // Unknown character
+		{"@", "invalid character U+0040 '@'"},
+		{"#", "invalid character U+0023 '#'"},
+		{"$", "invalid character U+0024 '$'"},
+		{"~", "invalid character U+007E '~'"},
+		{"\x00", "invalid character U+0000"},
+		{"€", "invalid character U+20AC '€'"},
 	}
 
 	for _, tt := range tests {
 		scanner := NewScanner(tt.input)
 		tok := scanner.NextToken()
 
-		if tok.Type != token.EOF {
-			t.Fatalf("token type wrong for unknown char %s. expected=%d, got=%d", tt.input, token.EOF, tok.Type)
+		if tok.Type != token.ILLEGAL {
+			t.Fatalf("token type wrong for unknown char %q. expected=%d, got=%d", tt.input, token.ILLEGAL, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("token literal wrong for unknown char %q. expected=%q, got=%q", tt.input, tt.expectedLiteral, tok.Literal)
+		}
+
+		// scanning continues after the illegal character
+		if next := scanner.NextToken(); next.Type != token.EOF {
+			t.Fatalf("expected EOF after %q, got=%d", tt.input, next.Type)
+		}
+	}
+}
+
+func TestScanner_Errors(t *testing.T) {
+	input := "x := 1 @ 2\ny = 3 # 4\n/* unterminated"
+	scanner := NewFileScanner("main.pg", input)
+
+	var types []token.Token
+	for {
+		tok := scanner.NextToken()
+		types = append(types, tok.Type)
+		if tok.Type == token.EOF {
+			break
+		}
+	}
+
+	// the identifiers on the second line are still scanned
+	count := 0
+	for _, typ := range types {
+		if typ == token.IDENT {
+			count++
+		}
+	}
+	if count != 2 {
+		t.Fatalf("expected 2 identifiers, got=%d (%v)", count, types)
+	}
+
+	expected := []string{
+		"main.pg:1:8: invalid character U+0040 '@'",
+		"main.pg:2:7: invalid character U+0023 '#'",
+		"main.pg:3:1: comment not terminated",
+	}
+	errors := scanner.Errors()
+	if len(errors) != len(expected) {
+		t.Fatalf("expected %d errors, got=%d (%v)", len(expected), len(errors), errors)
+	}
+	for i, err := range errors {
+		if err.Error() != expected[i] {
+			t.Errorf("errors[%d] wrong. expected=%q, got=%q", i, expected[i], err.Error())
+		}
+	}
+}
+
+func TestScanner_TokenPositions(t *testing.T) {
+	input := "x := 1\n  y = \"a\"\n/* c\n */ z"
+
+	tests := []struct {
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{"x", 1, 1},
+		{":=", 1, 3},
+		{"1", 1, 6},
+		{"\n", 1, 7},
+		{"y", 2, 3},
+		{"=", 2, 5},
+		{"a", 2, 7},
+		{"\n", 2, 10},
+		{"/* c\n */", 3, 1},
+		{"z", 4, 5},
+		{"", 4, 6},
+	}
+
+	scanner := NewFileScanner("main.pg", input)
+
+	for i, tt := range tests {
+		tok := scanner.NextToken()
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.Pos.Line != tt.expectedLine || tok.Pos.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - position wrong. expected=%d:%d, got=%d:%d",
+				i, tt.expectedLine, tt.expectedColumn, tok.Pos.Line, tok.Pos.Column)
+		}
+		if tok.Pos.Filename != "main.pg" {
+			t.Fatalf("tests[%d] - filename wrong. expected=%q, got=%q", i, "main.pg", tok.Pos.Filename)
+		}
+	}
+}
+
+func TestScanner_PositionString(t *testing.T) {
+	tests := []struct {
+		filename string
+		expected string
+	}{
+		{"main.pg", "main.pg:2:3"},
+		{"", "2:3"},
+	}
+
+	for _, tt := range tests {
+		scanner := NewFileScanner(tt.filename, "(\n  b")
+		scanner.NextToken()
+		tok := scanner.NextToken()
+
+		if tok.Pos.String() != tt.expected {
+			t.Fatalf("position string wrong. expected=%s, got=%s", tt.expected, tok.Pos.String())
 		}
+	}
+}
 
-		if tok.Literal != "" {
-			t.Fatalf("token literal wrong for unknown char %s. expected=\"\", got=%s", tt.input, tok.Literal)
+func TestScanner_SemicolonInsertion(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []token.Token
+	}{
+		{
+			name:     "after identifier and literal",
+			input:    "x := 1\ny := \"a\"\n",
+			expected: []token.Token{token.IDENT, token.ASSIGN, token.INT, token.SEMICOLON, token.IDENT, token.ASSIGN, token.STRING, token.SEMICOLON, token.EOF},
+		},
+		{
+			name:     "return followed by newline",
+			input:    "return\n}",
+			expected: []token.Token{token.RETURN, token.SEMICOLON, token.RBRACE, token.EOF},
+		},
+		{
+			name:     "closing delimiters and inc/dec",
+			input:    "f()\na[0]\n}\ni++\nj--\n",
+			expected: []token.Token{token.IDENT, token.LPAREN, token.RPAREN, token.SEMICOLON, token.IDENT, token.LBRACK, token.INT, token.RBRACK, token.SEMICOLON, token.RBRACE, token.SEMICOLON, token.IDENT, token.INC, token.SEMICOLON, token.IDENT, token.DEC, token.SEMICOLON, token.EOF},
+		},
+		{
+			name:     "no semicolon after operators and open braces",
+			input:    "x +\ny\nif x {\n",
+			expected: []token.Token{token.IDENT, token.ADD, token.IDENT, token.SEMICOLON, token.IF, token.IDENT, token.LBRACE, token.EOF},
+		},
+		{
+			name:     "blank lines produce a single semicolon",
+			input:    "x\n\n\ny",
+			expected: []token.Token{token.IDENT, token.SEMICOLON, token.IDENT, token.EOF},
+		},
+		{
+			name:     "line comment acts like a newline",
+			input:    "x // note\ny",
+			expected: []token.Token{token.IDENT, token.SEMICOLON, token.COMMENT, token.IDENT, token.EOF},
+		},
+		{
+			name:     "inline block comment does not terminate",
+			input:    "x /* note */ + y",
+			expected: []token.Token{token.IDENT, token.COMMENT, token.ADD, token.IDENT, token.EOF},
+		},
+		{
+			name:     "multi-line block comment acts like a newline",
+			input:    "x /* a\nb */ y",
+			expected: []token.Token{token.IDENT, token.SEMICOLON, token.COMMENT, token.IDENT, token.EOF},
+		},
+		{
+			name:     "no semicolon at end of input",
+			input:    "x",
+			expected: []token.Token{token.IDENT, token.EOF},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			for i, expectedType := range tt.expected {
+				tok := scanner.NextToken()
+				if tok.Type != expectedType {
+					t.Fatalf("tokens[%d] - type wrong. expected=%d, got=%d (%q)", i, expectedType, tok.Type, tok.Literal)
+				}
+			}
+		})
+	}
+}
+
+func TestScanner_NumberLiterals(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.Token
+		expectedLiteral string
+	}{
+		{"0", token.INT, "0"},
+		{"1_000_000", token.INT, "1_000_000"},
+		{"0x1F", token.INT, "0x1F"},
+		{"0X_ff", token.INT, "0X_ff"},
+		{"0o17", token.INT, "0o17"},
+		{"0755", token.INT, "0755"},
+		{"0b1010", token.INT, "0b1010"},
+		{"3.14", token.FLOAT, "3.14"},
+		{"1.", token.FLOAT, "1."},
+		{".5", token.FLOAT, ".5"},
+		{"09.5", token.FLOAT, "09.5"},
+		{"1e10", token.FLOAT, "1e10"},
+		{"6.02E+23", token.FLOAT, "6.02E+23"},
+		{"1_5.2_5e-1_0", token.FLOAT, "1_5.2_5e-1_0"},
+		{"0x1p-2", token.FLOAT, "0x1p-2"},
+		{"0x1.8P3", token.FLOAT, "0x1.8P3"},
+		{"1i", token.IMAG, "1i"},
+		{"2.5i", token.IMAG, "2.5i"},
+	}
+
+	for _, tt := range tests {
+		s := NewScanner(tt.input)
+		tok := s.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Errorf("input %q: expected type %v, got %v (%q)", tt.input, tt.expectedType, tok.Type, tok.Literal)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Errorf("input %q: expected literal %q, got %q", tt.input, tt.expectedLiteral, tok.Literal)
+		}
+		if next := s.NextToken(); next.Type != token.EOF {
+			t.Errorf("input %q: expected EOF after literal, got %v (%q)", tt.input, next.Type, next.Literal)
+		}
+	}
+}
+
+func TestScanner_InvalidNumberLiterals(t *testing.T) {
+	tests := []string{
+		"09",
+		"0x",
+		"0b102",
+		"0o8",
+		"1__0",
+		"1_",
+		"0x1.8",
+		"1e",
+		"0b1.0",
+	}
+
+	for _, input := range tests {
+		s := NewScanner(input)
+		tok := s.NextToken()
+
+		if tok.Type != token.ILLEGAL {
+			t.Errorf("input %q: expected ILLEGAL, got %v (%q)", input, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestScanner_DotAndNumber(t *testing.T) {
+	s := NewScanner("p.x")
+	expected := []token.Token{token.IDENT, token.PERIOD, token.IDENT, token.EOF}
+	for i, tt := range expected {
+		tok := s.NextToken()
+		if tok.Type != tt {
+			t.Errorf("tests[%d]: expected %v, got %v (%q)", i, tt, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestScanner_RawStringLiterals(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.Token
+		expectedLiteral string
+	}{
+		{"`hello`", token.STRING, "hello"},
+		{"`a\\nb`", token.STRING, `a\nb`},
+		{"`line1\nline2`", token.STRING, "line1\nline2"},
+		{"`crlf\r\nline`", token.STRING, "crlf\nline"},
+		{"`say \"hi\"`", token.STRING, `say "hi"`},
+		{"``", token.STRING, ""},
+		{"`unclosed", token.ILLEGAL, "unclosed raw string"},
+	}
+
+	for _, tt := range tests {
+		s := NewScanner(tt.input)
+		tok := s.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Errorf("input %q: expected type %v, got %v", tt.input, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Errorf("input %q: expected literal %q, got %q", tt.input, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestScanner_RuneLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected rune
+	}{
+		{`'a'`, 'a'},
+		{`'0'`, '0'},
+		{`' '`, ' '},
+		{`'\n'`, '\n'},
+		{`'\t'`, '\t'},
+		{`'\''`, '\''},
+		{`'\\'`, '\\'},
+		{`'\x41'`, 'A'},
+		{`'\101'`, 'A'},
+		{`'é'`, 'é'},
+		{`'\U0001F600'`, '\U0001F600'},
+		{`'é'`, 'é'},
+		{`'\xff'`, 0xff},
+	}
+
+	for _, tt := range tests {
+		s := NewScanner(tt.input)
+		tok := s.NextToken()
+
+		if tok.Type != token.CHAR {
+			t.Fatalf("input %s: expected CHAR, got %v (%q)", tt.input, tok.Type, tok.Literal)
+		}
+		if tok.Literal != string(tt.expected) {
+			t.Errorf("input %s: expected literal %q, got %q", tt.input, string(tt.expected), tok.Literal)
+		}
+	}
+}
+
+func TestScanner_InvalidRuneLiterals(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedLiteral string
+	}{
+		{`''`, "empty rune literal or unescaped ' in rune literal"},
+		{`'ab'`, "more than one character in rune literal"},
+		{`'\"'`, `invalid escape sequence in rune literal: \"`},
+		{`'\x4'`, `invalid escape sequence in rune literal: \x4`},
+		{`'\400'`, `invalid escape sequence in rune literal: \400`},
+		{`'\uD800'`, `invalid escape sequence in rune literal: \uD800`},
+		{"'a", "rune literal not terminated"},
+		{"'a\n'", "rune literal not terminated"},
+	}
+
+	for _, tt := range tests {
+		s := NewScanner(tt.input)
+		tok := s.NextToken()
+
+		if tok.Type != token.ILLEGAL {
+			t.Errorf("input %q: expected ILLEGAL, got %v (%q)", tt.input, tok.Type, tok.Literal)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Errorf("input %q: expected literal %q, got %q", tt.input, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestScanner_StringHexUnicodeEscapes(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedLiteral string
+	}{
+		{`"\x41\x42"`, "AB"},
+		{`"\101"`, "A"},
+		{`"café"`, "café"},
+		{`"caf\u00e9"`, "café"},
+		{`"\a\b\f\v"`, "\a\b\f\v"},
+		{`"\xff"`, "\xff"},
+	}
+
+	for _, tt := range tests {
+		s := NewScanner(tt.input)
+		tok := s.NextToken()
+
+		if tok.Type != token.STRING {
+			t.Fatalf("input %s: expected STRING, got %v", tt.input, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Errorf("input %s: expected literal %q, got %q", tt.input, tt.expectedLiteral, tok.Literal)
 		}
 	}
 }
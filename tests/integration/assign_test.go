@@ -0,0 +1,265 @@
+package main
+
+import "testing"
+
+func TestNative_Assignment(t *testing.T) {
+	runNativeTests(t, []nativeTest{
+		{
+			name: "elements, fields and dereferences",
+			code: `type Point struct {
+    x, y int
+}
+
+type Box struct {
+    corner *Point
+    sizes  [2]int
+}
+
+func main() {
+    grid := [][]int{{1, 2}, {3, 4}}
+    grid[1][0] = 30
+    grid[0][1] += 5
+    grid[1][1]++
+    println(grid[1][0] + grid[0][1] + grid[1][1])
+    b := Box{corner: &Point{1, 2}}
+    b.corner.y *= 10
+    b.sizes[1] = 6
+    b.sizes[1]--
+    println(b.corner.y + b.sizes[1])
+    n := 4
+    p := &n
+    *p <<= 2
+    println(n)
+    ps := []*Point{&Point{}, b.corner}
+    ps[1].x = 100
+    println(b.corner.x)
+    k := 5
+    grid[0][k] = 1
+}`,
+			stdout:   "42\n25\n16\n100\n",
+			stderr:   "panic: runtime error: index out of range\n",
+			exitCode: 2,
+		},
+		{
+			name: "index out of range",
+			code: `func main() {
+    var xs []int
+    ys := []int{1}
+    k := 0 - 1
+    println(ys[0])
+    println(xs[k])
+}`,
+			stdout:   "1\n",
+			stderr:   "panic: runtime error: index out of range\n",
+			exitCode: 2,
+		},
+	})
+}
+
+func TestNative_TupleAssignment(t *testing.T) {
+	runNativeTests(t, []nativeTest{
+		{
+			name: "swapping elements and fields",
+			code: `type Point struct {
+    x int
+    y int
+}
+
+func main() {
+    xs := []int{5, 6, 7}
+    xs[0], xs[2] = xs[2], xs[0]
+    println(xs[0])
+    println(xs[2])
+    p := Point{x: 1, y: 2}
+    p.x, p.y = p.y, p.x
+    println(p.x)
+    println(p.y)
+}`,
+			stdout: "7\n5\n2\n1\n",
+		},
+		{
+			name: "operands are evaluated first",
+			code: `func main() {
+    xs := []int{5, 6, 7}
+    i := 0
+    i, xs[i] = 2, 9
+    println(i)
+    println(xs[0])
+}`,
+			stdout: "2\n9\n",
+		},
+		{
+			name: "results and comma-ok values",
+			code: `func divmod(a int, b int) (int, int) {
+    return a / b, a % b
+}
+
+func main() {
+    m := map[string]int{}
+    n := 0
+    q := &n
+    m["k"], *q = divmod(7, 2)
+    println(m["k"])
+    println(n)
+    xs := []int{0, 0}
+    var ok bool
+    xs[1], ok = m["k"]
+    println(xs[1])
+    println(ok)
+}`,
+			stdout: "3\n1\n3\n1\n",
+		},
+	})
+}
+
+func TestNative_StructCopies(t *testing.T) {
+	runNativeTests(t, []nativeTest{
+		{
+			name: "assignments copy structs",
+			code: `type Point struct {
+    X, Y int
+}
+
+func main() {
+    p := Point{1, 2}
+    q := p
+    q.X = 5
+    println(p.X)
+    var r Point
+    r = p
+    r.Y = 7
+    println(p.Y)
+}`,
+			stdout: "1\n2\n",
+		},
+		{
+			name: "elements and map entries are copies",
+			code: `type Point struct {
+    X, Y int
+}
+
+func main() {
+    p := Point{1, 2}
+    ps := []Point{p}
+    x := ps[0]
+    x.X = 5
+    println(ps[0].X)
+    m := map[int]Point{}
+    m[0] = p
+    p.Y = 9
+    println(m[0].Y)
+    for _, v := range ps {
+        v.Y = 6
+    }
+    println(ps[0].Y)
+}`,
+			stdout: "1\n2\n2\n",
+		},
+		{
+			name: "fields and results are copies",
+			code: `type Point struct {
+    X, Y int
+}
+
+type Line struct {
+    From, To Point
+}
+
+var origin Point
+
+func get() Point {
+    return origin
+}
+
+func main() {
+    p := Point{1, 2}
+    l := Line{From: p, To: p}
+    l.From.X = 3
+    println(p.X)
+    g := get()
+    g.X = 4
+    println(origin.X)
+}`,
+			stdout: "1\n0\n",
+		},
+	})
+}
+
+func TestNative_ArrayCopies(t *testing.T) {
+	runNativeTests(t, []nativeTest{
+		{
+			name: "assignments and parameters copy arrays",
+			code: `type Point struct {
+    x int
+}
+
+func set(a [3]int) {
+    a[0] = 100
+}
+
+func main() {
+    arr := [3]int{1, 2, 3}
+    brr := arr
+    brr[0] = 9
+    println(arr[0])
+    set(arr)
+    println(arr[0])
+    grid := [2][2]int{{1, 2}, {3, 4}}
+    g := grid
+    g[1][1] = 0
+    println(grid[1][1])
+    ps := [1]Point{{5}}
+    qs := ps
+    qs[0].x = 6
+    println(ps[0].x)
+}`,
+			stdout: "1\n1\n4\n5\n",
+		},
+		{
+			name: "arrays of functions and interfaces",
+			code: `func main() {
+    var fs [2]func() int
+    fs[0] = func() int { return 1 }
+    gs := fs
+    gs[0] = func() int { return 2 }
+    println(fs[0](), gs[0]())
+    var xs [2]interface{}
+    xs[0] = 1
+    ys := xs
+    ys[0] = "a"
+    println(xs[0].(int), ys[0].(string))
+}`,
+			stdout: "1 2\n1 a\n",
+		},
+	})
+}
+
+func TestNative_AssignErrors(t *testing.T) {
+	output := buildErrors(t, `type Point struct {
+    x, y int
+}
+
+func get() Point {
+    return Point{1, 2}
+}
+
+func main() {
+    s := "abc"
+    s[0] = 100
+    get().x = 5
+    m := map[string]Point{"a": {1, 2}}
+    m["a"].x = 5
+    m["a"].y++
+    ptrs := map[string]*Point{"a": &Point{}}
+    ptrs["a"].x = 5
+    println(s, m["a"].x, ptrs["a"].x)
+}`)
+
+	expected := "test.pg:11:5: cannot assign to element of string (strings are immutable)\n" +
+		"test.pg:12:5: cannot assign to struct field x of call result (neither addressable nor a map index expression)\n" +
+		"test.pg:14:5: cannot assign to struct field x in map\n" +
+		"test.pg:15:5: cannot assign to struct field y in map\n"
+	if output != expected {
+		t.Errorf("Expected errors:\n%s\ngot:\n%s", expected, output)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+func TestNative_Branches(t *testing.T) {
+	runNativeTests(t, []nativeTest{
+		{
+			name: "labeled break and continue and goto",
+			code: `func find(grid [][]int, want int) int {
+    found := -1
+outer:
+    for i, row := range grid {
+        for j, v := range row {
+            if v == want {
+                found = i*10 + j
+                break outer
+            }
+            if v < 0 {
+                continue outer
+            }
+        }
+    }
+    return found
+}
+
+func main() {
+    grid := [][]int{{1, -1, 5}, {2, 5}, {5}}
+    println(find(grid, 5))
+    println(find(grid, 9))
+    n := 0
+loop:
+    if n < 3 {
+        n++
+        goto loop
+    }
+    println(n)
+    count := 0
+rows:
+    for i := 0; i < 3; i++ {
+        switch {
+        case i == 1:
+            continue rows
+        case i == 2:
+            break rows
+        }
+        count += 10
+    }
+    println(count)
+}`,
+			stdout: "11\n-1\n3\n10\n",
+		},
+	})
+}
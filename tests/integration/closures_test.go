@@ -0,0 +1,64 @@
+package main
+
+import "testing"
+
+func TestNative_Closures(t *testing.T) {
+	runNativeTests(t, []nativeTest{
+		{
+			name: "captured variables and function values",
+			code: `func counter() func() int {
+    n := 0
+    return func() int {
+        n++
+        return n
+    }
+}
+
+func apply(f func(int) int, x int) int {
+    return f(x)
+}
+
+func double(n int) int {
+    return n * 2
+}
+
+func main() {
+    next := counter()
+    next()
+    next()
+    println(next())
+    other := counter()
+    println(other())
+    total := 0
+    for i := 1; i <= 4; i++ {
+        add := func(d int) { total += d }
+        add(i)
+    }
+    println(total)
+    println(apply(double, 21))
+    k := 3
+    println(apply(func(x int) int { return x * k }, 5))
+    println(func(a int, b int) int { return a - b }(10, 4))
+}`,
+			stdout: "3\n1\n10\n42\n15\n6\n",
+		},
+		{
+			name: "each iteration of a for loop has its own variables",
+			code: `func main() {
+    fs := []func() int{nil, nil, nil}
+    for i := 0; i < 3; i++ {
+        fs[i] = func() int { return i }
+    }
+    for _, f := range fs {
+        println(f())
+    }
+    ps := []*int{nil, nil}
+    for j, k := 0, 10; j < 2; j, k = j+1, k+1 {
+        ps[j] = &k
+    }
+    println(*ps[0], *ps[1])
+}`,
+			stdout: "0\n1\n2\n10 11\n",
+		},
+	})
+}
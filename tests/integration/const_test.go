@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestNative_Constants(t *testing.T) {
+	runNativeTests(t, []nativeTest{
+		{
+			name: "converted initializers",
+			code: `const G = 1.5 * 2
+
+func main() {
+    var i int = 3.0
+    var j int = G
+    var f float64 = 1 / 2
+    println(i)
+    println(j)
+    println(f)
+}`,
+			stdout: "3\n3\n+0.000000e+000\n",
+		},
+		{
+			name: "exact constant expressions",
+			code: `const Big = 1 << 100
+
+func main() {
+    println(1 << 62 * 2 / 4)
+    println(Big >> 98)
+    println(100000000000000000000 / 10000000000)
+    if 0.1 + 0.2 == 0.3 {
+        println(1)
+    }
+}`,
+			stdout: "2305843009213693952\n4\n10000000000\n1\n",
+		},
+		{
+			name: "iota",
+			code: `const (
+    A = iota * 10
+    B
+    C
+)
+
+func main() {
+    println(A + B + C)
+}`,
+			stdout: "30\n",
+		},
+	})
+}
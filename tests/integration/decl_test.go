@@ -0,0 +1,147 @@
+package main
+
+import "testing"
+
+func TestNative_PackageVariables(t *testing.T) {
+	runNativeTests(t, []nativeTest{
+		{
+			name: "values and zero values",
+			code: `var gx int = 4
+
+var (
+    ga, gb = 1, 2
+    gz     int
+    gs     string
+    gp     Point
+    gq, gr = divmod(7, 2)
+)
+
+type Point struct {
+    x int
+    y int
+}
+
+func divmod(a int, b int) (int, int) {
+    return a / b, a % b
+}
+
+func main() {
+    println(gx)
+    println(ga)
+    println(gb)
+    println(gz)
+    println(len(gs))
+    println(gp.x)
+    println(gq)
+    println(gr)
+}`,
+			stdout: "4\n1\n2\n0\n0\n0\n3\n1\n",
+		},
+		{
+			name: "shared by functions, closures and pointers",
+			code: `var count int
+
+func bump() {
+    count++
+}
+
+func main() {
+    bump()
+    bump()
+    println(count)
+    f := func() int {
+        count += 10
+        return count
+    }
+    println(f())
+    p := &count
+    *p = 40
+    bump()
+    println(count)
+}`,
+			stdout: "2\n12\n41\n",
+		},
+		{
+			name: "grouped declarations",
+			code: `import (
+    "fmt"
+    "strings"
+)
+
+type (
+    Point struct {
+        x, y int
+    }
+    Size struct {
+        w, h int
+    }
+)
+
+const (
+    origin = 0
+    step   = 2
+)
+
+func length(p Point) int {
+    return p.x + p.y
+}
+
+func main() {
+    var (
+        a, b = 3, 4
+        p    Point
+        s    string
+    )
+    p = Point{a, b}
+    println(length(p))
+    println(len(s))
+    var q, r Point
+    q.x = step
+    println(q.x + r.x + origin)
+    z := Size{w: 5}
+    println(z.w * step)
+}`,
+			stdout: "7\n0\n2\n10\n",
+		},
+		{
+			name: "initialized after the variables they refer to",
+			code: `var a = b + 1
+var b = 2
+var c = total()
+
+var (
+    x, y = d, 5
+    d    = "d"
+)
+
+func total() int {
+    return a + b
+}
+
+func main() {
+    println(a, b, c, x, y)
+}`,
+			stdout: "3 2 5 d 5\n",
+		},
+	})
+}
+
+func TestNative_InitializationCycles(t *testing.T) {
+	output := buildErrors(t, `var p = q
+var q = p + 1
+var r = f()
+
+func f() int {
+    return r
+}
+
+func main() {
+    println(p, q, r)
+}`)
+
+	expected := "test.pg:1:1: initialization cycle: p refers to q\n" +
+		"test.pg:3:1: initialization cycle: r refers to itself\n"
+	if output != expected {
+		t.Errorf("Expected errors:\n%s\ngot:\n%s", expected, output)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import "testing"
+
+func TestNative_Defer(t *testing.T) {
+	runNativeTests(t, []nativeTest{
+		{
+			name: "deferred calls run in reverse order",
+			code: `func main() {
+    for i := 0; i < 3; i++ {
+        defer println(i)
+    }
+    println(10)
+}`,
+			stdout: "10\n2\n1\n0\n",
+		},
+		{
+			name: "recover stops a panic",
+			code: `func safe() (n int) {
+    defer func() {
+        r := recover()
+        if r != nil {
+            println(r.(string))
+            n = 1
+        }
+    }()
+    panic("boom")
+    return 0
+}
+
+func main() {
+    println(safe())
+}`,
+			stdout: "boom\n1\n",
+		},
+		{
+			name: "recover stops a run-time error",
+			code: `func deref() int {
+    defer func() {
+        r := recover()
+        println(r.(string))
+    }()
+    var p *int
+    return *p
+}
+
+func main() {
+    println(deref())
+}`,
+			stdout: "runtime error: invalid memory address or nil pointer dereference\n0\n",
+		},
+		{
+			name: "run-time error runs deferred calls",
+			code: `func main() {
+    defer println(9)
+    xs := []int{1, 2}
+    n := 5
+    println(len(xs[0:n]))
+}`,
+			stdout:   "9\n",
+			stderr:   "panic: runtime error: slice bounds out of range\n",
+			exitCode: 2,
+		},
+		{
+			name: "uncaught panic",
+			code: `func main() {
+    defer println(1)
+    panic("boom")
+}`,
+			stdout:   "1\n",
+			stderr:   "panic: boom\n",
+			exitCode: 2,
+		},
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiagnostics_ScannerErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "bad.pg")
+
+	code := `func main() {
+    x := 1 @ 2
+    println(x)
+    /* never closed
+}`
+
+	err := os.WriteFile(testFile, []byte(code), 0644)
+	if err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	expected := []string{
+		testFile + ":2:12: invalid character U+0040 '@'",
+		testFile + ":4:5: comment not terminated",
+	}
+
+	// Every command that parses the file must fail with the diagnostics
+	for _, command := range []string{"ast", "run", "build"} {
+		cmd := exec.Command("go", "run", "../../main.go", command, testFile)
+		output, err := cmd.CombinedOutput()
+		if err == nil {
+			t.Fatalf("%s: expected petitgo to fail\nOutput: %s", command, output)
+		}
+
+		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		// go run adds its own "exit status 1" line
+		if len(lines) < len(expected) {
+			t.Fatalf("%s: expected at least %d lines, got %d\nOutput:\n%s", command, len(expected), len(lines), output)
+		}
+		for i, want := range expected {
+			if lines[i] != want {
+				t.Errorf("%s: line %d: expected %q, got %q", command, i+1, want, lines[i])
+			}
+		}
+	}
+}
+
+func TestDiagnostics_SyntaxErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "bad.pg")
+
+	code := `func helper {
+    return
+}
+
+func main() {
+    x := (1 + 2
+    println(x)
+    y := 3 4
+}`
+
+	err := os.WriteFile(testFile, []byte(code), 0644)
+	if err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	// All syntax errors are reported in one run
+	expected := []string{
+		testFile + ":1:13: unexpected {, expected '(' after function name",
+		testFile + ":6:16: unexpected newline, expected ')'",
+		testFile + ":8:12: unexpected 4, expected end of statement",
+	}
+
+	cmd := exec.Command("go", "run", "../../main.go", "build", testFile)
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("expected petitgo to fail\nOutput: %s", output)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) < len(expected) {
+		t.Fatalf("expected at least %d lines, got %d\nOutput:\n%s", len(expected), len(lines), output)
+	}
+	for i, want := range expected {
+		if lines[i] != want {
+			t.Errorf("line %d: expected %q, got %q", i+1, want, lines[i])
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import "testing"
+
+const shapesProgram = `type Shape interface {
+    Area() int
+    Scale(f int) int
+}
+
+type Named interface {
+    Shape
+    Name() int
+}
+
+type Square struct {
+    side int
+}
+
+func (s Square) Area() int { return s.side * s.side }
+func (s Square) Scale(f int) int { return s.side * f }
+func (s Square) Name() int { return 4 }
+
+type Circle struct {
+    r int
+}
+
+func (c Circle) Area() int { return 3 * c.r * c.r }
+func (c *Circle) Scale(f int) int { return c.r * f }
+`
+
+func TestNative_Interfaces(t *testing.T) {
+	runNativeTests(t, []nativeTest{
+		{
+			name: "dynamic dispatch",
+			code: shapesProgram + `
+func describe(s Shape) int { return s.Area() + s.Scale(10) }
+
+func main() {
+    var s Shape = Square{side: 4}
+    println(describe(s))
+    var c Shape = &Circle{r: 1}
+    println(c.Scale(3))
+}`,
+			stdout: "56\n3\n",
+		},
+		{
+			name: "embedded interface",
+			code: shapesProgram + `
+func main() {
+    var n Named = Square{side: 2}
+    println(n.Name())
+    var s Shape = n
+    println(s.Area())
+    _, ok := s.(Named)
+    println(ok)
+}`,
+			stdout: "4\n4\n1\n",
+		},
+		{
+			name: "type assertions and type switches",
+			code: shapesProgram + `
+func kind(v any) int {
+    switch x := v.(type) {
+    case int:
+        return x
+    case Square:
+        return x.side * 100
+    case *Circle:
+        return x.r * 1000
+    case nil:
+        return -1
+    }
+    return 0
+}
+
+func main() {
+    var s Shape = Square{side: 3}
+    sq, ok := s.(Square)
+    println(sq.side)
+    println(kind(7))
+    println(kind(sq))
+    println(kind(&Circle{r: 2}))
+    println(kind(nil))
+    println(kind("x"))
+    _, ok = s.(*Circle)
+    if !ok {
+        println(9)
+    }
+}`,
+			stdout: "3\n7\n300\n2000\n-1\n0\n9\n",
+		},
+		{
+			name: "method call on nil interface",
+			code: shapesProgram + `
+func main() {
+    var s Shape
+    println(1)
+    println(s.Area())
+}`,
+			stdout:   "1\n",
+			stderr:   "panic: runtime error: invalid memory address or nil pointer dereference\n",
+			exitCode: 2,
+		},
+		{
+			name: "failed type assertion",
+			code: shapesProgram + `
+func main() {
+    var s Shape = Square{side: 4}
+    c := s.(*Circle)
+    println(c.r)
+}`,
+			stderr:   "panic: interface conversion: type assertion failed\n",
+			exitCode: 2,
+		},
+	})
+}
+
+func TestNative_InterfaceErrors(t *testing.T) {
+	output := buildErrors(t, shapesProgram+`
+func main() {
+    var c Shape = Circle{r: 1}
+    var n Named = 5
+    var a any = 1
+    var s Shape = a
+    println(c.Area())
+}`)
+
+	expected := `test.pg:27:19: Circle does not implement Shape (method Scale has pointer receiver)
+test.pg:28:19: int does not implement Named (missing method Name)
+test.pg:30:19: any does not implement Shape (missing method Area)
+`
+	if output != expected {
+		t.Errorf("Expected errors:\n%s\ngot:\n%s", expected, output)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import "testing"
+
+func TestNative_Maps(t *testing.T) {
+	runNativeTests(t, []nativeTest{
+		{
+			name: "string keys",
+			code: `func main() {
+    m := map[string]int{"a": 1}
+    m["b"] = 2
+    m["a"] += 10
+    v, ok := m["a"]
+    println(v)
+    println(ok)
+    delete(m, "a")
+    _, ok = m["a"]
+    println(ok)
+    println(len(m))
+}`,
+			stdout: "11\n1\n0\n1\n",
+		},
+		{
+			name: "growing",
+			code: `func main() {
+    m := map[int]int{}
+    for i := 0; i < 100; i++ {
+        m[i*7] = i
+    }
+    println(len(m))
+    println(m[693])
+}`,
+			stdout: "100\n99\n",
+		},
+		{
+			name: "struct and array keys",
+			code: `type Point struct {
+    x int
+    y int
+}
+
+func main() {
+    m := map[Point]int{Point{x: 1, y: 2}: 3}
+    k := Point{x: 1, y: 2}
+    m[k] = 5
+    k.x = 7
+    m[k] = 8
+    println(len(m))
+    println(m[Point{x: 1, y: 2}])
+    println(m[Point{x: 7, y: 2}])
+
+    a := map[[2]int]int{}
+    for i := 0; i < 20; i++ {
+        a[[2]int{i, i + 1}] = i * 10
+    }
+    b := [2]int{4, 5}
+    println(a[b])
+    b[0] = 19
+    b[1] = 20
+    delete(a, b)
+    println(len(a))
+}`,
+			stdout: "2\n5\n8\n40\n19\n",
+		},
+		{
+			name: "assignment to nil map",
+			code: `func main() {
+    var m map[string]int
+    println(len(m))
+    m["a"] = 1
+}`,
+			stdout:   "0\n",
+			stderr:   "panic: assignment to entry in nil map\n",
+			exitCode: 2,
+		},
+	})
+}
+
+func TestNative_MapKeyErrors(t *testing.T) {
+	output := buildErrors(t, `type Named struct {
+    name string
+}
+
+func main() {
+    m := map[Named]int{}
+    println(len(m))
+}`)
+
+	expected := "test.pg:6:10: invalid map key type Named: field name of type string is not supported\n"
+	if output != expected {
+		t.Errorf("Expected errors:\n%s\ngot:\n%s", expected, output)
+	}
+}
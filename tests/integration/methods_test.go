@@ -0,0 +1,130 @@
+package main
+
+import "testing"
+
+func TestNative_Methods(t *testing.T) {
+	runNativeTests(t, []nativeTest{
+		{
+			name: "value and pointer receivers",
+			code: `type Counter struct {
+    n int
+}
+
+func (c Counter) Value() int {
+    return c.n
+}
+
+func (c Counter) Bumped() Counter {
+    c.n++
+    return c
+}
+
+func (c *Counter) Add(k int) {
+    c.n += k
+}
+
+func main() {
+    c := Counter{n: 1}
+    c.Add(4)
+    println(c.Value())
+    d := c.Bumped()
+    println(c.n)
+    println(d.n)
+    p := &c
+    p.Add(10)
+    println(p.Value())
+    println(c.n)
+}`,
+			stdout: "5\n5\n6\n15\n15\n",
+		},
+		{
+			name: "struct parameters",
+			code: `type Point struct {
+    x, y int
+}
+
+func moved(p Point) Point {
+    p.x += 10
+    return p
+}
+
+func main() {
+    a := Point{1, 2}
+    b := moved(a)
+    println(a.x)
+    println(b.x)
+}`,
+			stdout: "1\n11\n",
+		},
+		{
+			name: "nested fields of copies",
+			code: `type Inner struct {
+    a int
+}
+
+type Out struct {
+    in  Inner
+    arr [2]int
+}
+
+func (o Out) Mut() {
+    o.in.a = 5
+    o.arr[0] = 6
+}
+
+func set(o Out) {
+    o.in.a = 7
+    o.arr[1] = 8
+}
+
+func main() {
+    o := Out{}
+    o.Mut()
+    set(o)
+    println(o.in.a)
+    println(o.arr[0])
+    println(o.arr[1])
+}`,
+			stdout: "0\n0\n0\n",
+		},
+		{
+			name: "function fields",
+			code: `type Grid struct {
+    Fn func(int) int
+    n  int
+}
+
+func main() {
+    var g Grid
+    g.Fn = func(x int) int {
+        return x * 2
+    }
+    println(g.Fn(5))
+    p := &g
+    println(p.Fn(6))
+    h := Grid{Fn: func(x int) int { return x + 1 }, n: 3}
+    defer println(h.Fn(h.n))
+    defer h.Fn(0)
+}`,
+			stdout: "10\n12\n4\n",
+		},
+	})
+}
+
+func TestNative_MethodErrors(t *testing.T) {
+	output := buildErrors(t, `type Grid struct {
+    n int
+}
+
+func main() {
+    var g Grid
+    println(g.Size())
+    g.n()
+}`)
+
+	expected := "test.pg:7:13: g.Size undefined (type Grid has no field or method Size)\n" +
+		"test.pg:8:5: invalid operation: cannot call non-function g.n (variable of type int)\n"
+	if output != expected {
+		t.Errorf("Expected errors:\n%s\ngot:\n%s", expected, output)
+	}
+}
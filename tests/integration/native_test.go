@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// nativeTest is a program compiled with petitgo build and run, together
+// with what it prints to stdout and stderr and its exit status
+type nativeTest struct {
+	name     string
+	code     string
+	stdout   string
+	stderr   string
+	exitCode int
+}
+
+var (
+	petitgoOnce sync.Once
+	petitgoPath string
+	petitgoErr  error
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if petitgoPath != "" {
+		os.RemoveAll(filepath.Dir(petitgoPath))
+	}
+	os.Exit(code)
+}
+
+// buildPetitgo builds the petitgo command once for all native tests
+func buildPetitgo(t *testing.T) string {
+	t.Helper()
+	petitgoOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "petitgo-test")
+		if err != nil {
+			petitgoErr = err
+			return
+		}
+		petitgoPath = filepath.Join(dir, "petitgo")
+		output, err := exec.Command("go", "build", "-o", petitgoPath, "../../").CombinedOutput()
+		if err != nil {
+			petitgoErr = errors.New(err.Error() + "\n" + string(output))
+		}
+	})
+	if petitgoErr != nil {
+		t.Fatalf("Failed to build petitgo: %v", petitgoErr)
+	}
+	return petitgoPath
+}
+
+// runNativeTests compiles and runs each program, and compares its output
+// and exit status with the expected ones
+func runNativeTests(t *testing.T, tests []nativeTest) {
+	// Skip on unsupported platforms
+	if !(runtime.GOOS == "darwin" && runtime.GOARCH == "arm64") &&
+		!(runtime.GOOS == "linux" && runtime.GOARCH == "amd64") {
+		t.Skip("Native compilation only supported on macOS ARM64 and Linux x86_64")
+	}
+	petitgo := buildPetitgo(t)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			testFile := filepath.Join(tmpDir, "test.pg")
+			if err := os.WriteFile(testFile, []byte(tt.code), 0644); err != nil {
+				t.Fatalf("Failed to write test file: %v", err)
+			}
+
+			output, err := exec.Command(petitgo, "build", testFile).CombinedOutput()
+			if err != nil {
+				t.Fatalf("Failed to build: %v\nOutput: %s", err, output)
+			}
+
+			var stdout, stderr strings.Builder
+			cmd := exec.Command(filepath.Join(tmpDir, "test"))
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+			exitCode := 0
+			if err := cmd.Run(); err != nil {
+				exitErr, ok := err.(*exec.ExitError)
+				if !ok {
+					t.Fatalf("Failed to run: %v", err)
+				}
+				exitCode = exitErr.ExitCode()
+			}
+
+			if got := stdout.String(); got != tt.stdout {
+				t.Errorf("Expected stdout:\n%s\ngot:\n%s", tt.stdout, got)
+			}
+			if got := stderr.String(); got != tt.stderr {
+				t.Errorf("Expected stderr:\n%s\ngot:\n%s", tt.stderr, got)
+			}
+			if exitCode != tt.exitCode {
+				t.Errorf("Expected exit status %d, got %d", tt.exitCode, exitCode)
+			}
+		})
+	}
+}
+
+// buildErrors compiles a program that is not valid and returns the errors
+// petitgo build prints
+func buildErrors(t *testing.T, code string) string {
+	t.Helper()
+	if !(runtime.GOOS == "darwin" && runtime.GOARCH == "arm64") &&
+		!(runtime.GOOS == "linux" && runtime.GOARCH == "amd64") {
+		t.Skip("Native compilation only supported on macOS ARM64 and Linux x86_64")
+	}
+	petitgo := buildPetitgo(t)
+
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.pg")
+	if err := os.WriteFile(testFile, []byte(code), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	output, err := exec.Command(petitgo, "build", testFile).CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected the build to fail\nOutput: %s", output)
+	}
+	return strings.ReplaceAll(string(output), testFile, "test.pg")
+}
+
+func TestNative_IntegerOperators(t *testing.T) {
+	runNativeTests(t, []nativeTest{
+		{
+			name: "signed division and remainder",
+			code: `func main() {
+    a := 0 - 7
+    b := 2
+    println(a / b)
+    println(a % b)
+    println(1 << b)
+    println(a >> 1)
+}`,
+			stdout: "-3\n-1\n4\n-4\n",
+		},
+		{
+			name: "division by zero",
+			code: `func main() {
+    z := 0
+    println(7 % 2)
+    println(7 / z)
+}`,
+			stdout:   "1\n",
+			stderr:   "panic: runtime error: integer divide by zero\n",
+			exitCode: 2,
+		},
+		{
+			name: "negative shift",
+			code: `func main() {
+    n := 0 - 1
+    println(1 << n)
+}`,
+			stderr:   "panic: runtime error: negative shift amount\n",
+			exitCode: 2,
+		},
+	})
+}
@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+func TestNative_Pointers(t *testing.T) {
+	runNativeTests(t, []nativeTest{
+		{
+			name: "address-of, dereference and linked structs",
+			code: `type Node struct {
+    value int
+    next  *Node
+}
+
+func bump(p *int) {
+    *p = *p + 1
+}
+
+func escape() *int {
+    n := 41
+    return &n
+}
+
+func main() {
+    x := 1
+    bump(&x)
+    bump(&x)
+    println(x)
+    e := escape()
+    *e++
+    println(*e)
+    var head *Node
+    for i := 1; i <= 3; i++ {
+        head = &Node{value: i, next: head}
+    }
+    sum := 0
+    for n := head; n != nil; n = n.next {
+        sum = sum*10 + n.value
+    }
+    println(sum)
+    q := new(int)
+    println(*q)
+    pp := &q
+    **pp = 9
+    println(*q)
+    if head.next.next.next == nil {
+        println(1)
+    }
+    var np *Node
+    println(np.value)
+}`,
+			stdout:   "3\n42\n321\n0\n9\n1\n",
+			stderr:   "panic: runtime error: invalid memory address or nil pointer dereference\n",
+			exitCode: 2,
+		},
+	})
+}
@@ -0,0 +1,81 @@
+package main
+
+import "testing"
+
+func TestNative_Ranges(t *testing.T) {
+	runNativeTests(t, []nativeTest{
+		{
+			name: "slices, strings, maps, integers and arrays",
+			code: `func main() {
+    sum := 0
+    for i, v := range []int{10, 20, 30} {
+        sum += i * v
+    }
+    println(sum)
+    n := 0
+    for _, r := range "héllo" {
+        if r == 'é' {
+            n += 100
+        }
+        n++
+    }
+    println(n)
+    for i, r := range "aé" {
+        println(i)
+        println(r)
+    }
+    m := map[string]int{"a": 1, "b": 2, "c": 3}
+    total := 0
+    for k, v := range m {
+        total += v
+        if k == "b" {
+            total += 10
+        }
+    }
+    println(total)
+    count := 0
+    for i := range 5 {
+        count += i
+    }
+    println(count)
+    arr := [3]int{4, 5, 6}
+    for i := range arr {
+        arr[i] *= 2
+    }
+    println(arr[2])
+    for _, x := range []int{1, 2, 3, 4} {
+        if x == 2 {
+            continue
+        }
+        if x == 4 {
+            break
+        }
+        println(x)
+    }
+}`,
+			stdout: "80\n105\n0\n97\n1\n233\n16\n10\n12\n1\n3\n",
+		},
+		{
+			name: "arrays are ranged over as copies",
+			code: `type Point struct {
+    x int
+}
+
+func main() {
+    arr := [3]int{1, 2, 3}
+    for i, v := range arr {
+        arr[2] = 50
+        if i == 2 {
+            println(v)
+        }
+    }
+    ps := [2]Point{{1}, {2}}
+    for _, p := range ps {
+        p.x = 9
+    }
+    println(ps[0].x)
+}`,
+			stdout: "3\n1\n",
+		},
+	})
+}
@@ -0,0 +1,87 @@
+package main
+
+import "testing"
+
+func TestNative_MultipleResults(t *testing.T) {
+	runNativeTests(t, []nativeTest{
+		{
+			name: "results and named results",
+			code: `func divmod(a int, b int) (int, int) {
+    return a / b, a % b
+}
+
+func split(n int) (lo, hi int) {
+    lo = n % 10
+    hi = n / 10
+    return
+}
+
+func swap(a int, b int) (int, int) {
+    return b, a
+}
+
+func main() {
+    q, r := divmod(17, 5)
+    println(q)
+    println(r)
+    q, r = r, q
+    println(q)
+    _, hi := split(42)
+    println(hi)
+    x, y := swap(q, r)
+    println(x)
+    println(y)
+}`,
+			stdout: "3\n2\n2\n4\n3\n2\n",
+		},
+	})
+}
+
+func TestNative_ResultsAsArguments(t *testing.T) {
+	runNativeTests(t, []nativeTest{
+		{
+			name: "results of a sole argument are spread",
+			code: `func divmod(a int, b int) (int, int) {
+    return a / b, a % b
+}
+
+func add(a int, b int) int {
+    return a + b
+}
+
+type Acc struct {
+    n int
+}
+
+func (a *Acc) Mul(x int, y int) {
+    a.n += x * y
+}
+
+func main() {
+    println(divmod(7, 2))
+    println(add(divmod(9, 4)))
+    f := add
+    println(f(divmod(11, 3)))
+    acc := &Acc{}
+    acc.Mul(divmod(7, 2))
+    println(acc.n, "done")
+}`,
+			stdout: "3 1\n3\n5\n3 done\n",
+		},
+	})
+}
+
+func TestNative_MultipleResultErrors(t *testing.T) {
+	output := buildErrors(t, `func divmod(a int, b int) (int, int) {
+    return a / b, a % b
+}
+
+func main() {
+    println(divmod(7, 2), 1)
+}`)
+
+	expected := "test.pg:6:13: multiple-value divmod(...) in single-value context\n"
+	if output != expected {
+		t.Errorf("Expected errors:\n%s\ngot:\n%s", expected, output)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestNative_SliceExpressions(t *testing.T) {
+	runNativeTests(t, []nativeTest{
+		{
+			name: "slices, arrays and strings",
+			code: `func sum(xs []int) int {
+    total := 0
+    for _, x := range xs {
+        total += x
+    }
+    return total
+}
+
+func main() {
+    s := []int{1, 2, 3, 4, 5}
+    t := s[1:4]
+    println(len(t))
+    println(cap(t))
+    println(sum(t))
+    t[0] = 20
+    println(s[1])
+    u := t[:cap(t)]
+    println(len(u))
+    v := s[1:2:3]
+    println(cap(v))
+    a := [4]int{9, 8, 7, 6}
+    println(sum(a[2:]))
+    str := "hello, world"
+    println(str[7:])
+    println(str[:5])
+    println(len(str[3:3]))
+    i := 6
+    println(s[i:])
+}`,
+			stdout:   "3\n4\n9\n20\n4\n2\n13\nworld\nhello\n0\n",
+			stderr:   "panic: runtime error: slice bounds out of range\n",
+			exitCode: 2,
+		},
+	})
+}
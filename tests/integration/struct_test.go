@@ -0,0 +1,85 @@
+package main
+
+import "testing"
+
+func TestNative_Structs(t *testing.T) {
+	runNativeTests(t, []nativeTest{
+		{
+			name: "literals",
+			code: `type Point struct {
+    x, y int
+}
+
+func main() {
+    p := Point{5, 6}
+    q := Point{y: 2}
+    println(p.x)
+    println(p.y)
+    println(q.x + q.y)
+    ps := []Point{{1, 2}, {3, 4}}
+    println(ps[1].x + ps[0].y)
+    r := &Point{7, 8}
+    r.x++
+    println(r.x)
+}`,
+			stdout: "5\n6\n2\n5\n8\n",
+		},
+		{
+			name: "nested composite types",
+			code: `type Point struct {
+    x, y int
+}
+
+type Shape struct {
+    name   string
+    points [3]Point
+    origin Point
+    tags   []string
+}
+
+func grid() [][]int {
+    return [][]int{{0, 0, 0}, {0, 1, 2}, {0, 2, 4}}
+}
+
+func main() {
+    var s Shape
+    s.points[1].x = 7
+    s.origin.y = 3
+    println(s.points[1].x + s.origin.y)
+    println(len(s.tags))
+    var ps [2]Point
+    ps[1].y = 4
+    println(ps[1].y)
+    t := Shape{name: "tri"}
+    println(t.points[2].x)
+    g := grid()
+    println(g[2][2])
+    lines := map[string][]int{"a": {1, 2}, "b": {3}}
+    println(len(lines["a"]) + lines["b"][0])
+    nested := [][]Point{{{1, 2}}, {{3, 4}, {5, 6}}}
+    println(nested[1][1].y)
+    ptrs := []*Point{{8, 9}, &Point{x: 1}}
+    println(ptrs[0].y + ptrs[1].x)
+}`,
+			stdout: "10\n0\n4\n0\n4\n5\n6\n10\n",
+		},
+	})
+}
+
+func TestNative_StructLiteralErrors(t *testing.T) {
+	output := buildErrors(t, `type Point struct {
+    x, y int
+}
+
+func main() {
+    p := Point{1}
+    q := Point{1, 2, 3}
+    println(p.x + q.x)
+}`)
+
+	expected := "test.pg:6:10: too few values in struct literal of type Point\n" +
+		"test.pg:7:22: too many values in struct literal of type Point\n"
+	if output != expected {
+		t.Errorf("Expected errors:\n%s\ngot:\n%s", expected, output)
+	}
+}
@@ -27,13 +27,27 @@ const (
 	QUO // /
 	REM // %
 
-	ASSIGN     // := or =
-	INC        // ++
-	DEC        // --
-	ADD_ASSIGN // +=
-	SUB_ASSIGN // -=
-	MUL_ASSIGN // *=
-	QUO_ASSIGN // /=
+	AND     // &
+	OR      // |
+	XOR     // ^
+	SHL     // <<
+	SHR     // >>
+	AND_NOT // &^
+
+	ASSIGN         // := or =
+	INC            // ++
+	DEC            // --
+	ADD_ASSIGN     // +=
+	SUB_ASSIGN     // -=
+	MUL_ASSIGN     // *=
+	QUO_ASSIGN     // /=
+	REM_ASSIGN     // %=
+	AND_ASSIGN     // &=
+	OR_ASSIGN      // |=
+	XOR_ASSIGN     // ^=
+	SHL_ASSIGN     // <<=
+	SHR_ASSIGN     // >>=
+	AND_NOT_ASSIGN // &^=
 
 	// Comparison operators
 	EQL // ==
@@ -54,6 +68,7 @@ const (
 	RBRACE    // }
 	COMMA     // ,
 	PERIOD    // .
+	ELLIPSIS  // ...
 	COLON     // :
 	SEMICOLON // ;
 	LBRACK    // [
@@ -62,26 +77,134 @@ const (
 
 	keyword_beg
 	// Keywords
-	IF       // if
-	ELSE     // else
-	FOR      // for
-	BREAK    // break
-	CONTINUE // continue
-	FUNC     // func
-	RETURN   // return
-	TRUE     // true
-	FALSE    // false
-	STRUCT   // struct
-	TYPE     // type
-	PACKAGE  // package
-	IMPORT   // import
-	SWITCH   // switch
-	CASE     // case
-	DEFAULT  // default
+	IF          // if
+	ELSE        // else
+	FOR         // for
+	BREAK       // break
+	CONTINUE    // continue
+	FUNC        // func
+	RETURN      // return
+	TRUE        // true
+	FALSE       // false
+	STRUCT      // struct
+	INTERFACE   // interface
+	MAP         // map
+	RANGE       // range
+	TYPE        // type
+	CONST       // const
+	PACKAGE     // package
+	IMPORT      // import
+	SWITCH      // switch
+	CASE        // case
+	DEFAULT     // default
+	FALLTHROUGH // fallthrough
+	GOTO        // goto
+	DEFER       // defer
 	keyword_end
 )
 
+// compoundOperators maps each compound assignment operator to the binary
+// operator it applies
+var compoundOperators = map[Token]Token{
+	ADD_ASSIGN:     ADD,
+	SUB_ASSIGN:     SUB,
+	MUL_ASSIGN:     MUL,
+	QUO_ASSIGN:     QUO,
+	REM_ASSIGN:     REM,
+	AND_ASSIGN:     AND,
+	OR_ASSIGN:      OR,
+	XOR_ASSIGN:     XOR,
+	SHL_ASSIGN:     SHL,
+	SHR_ASSIGN:     SHR,
+	AND_NOT_ASSIGN: AND_NOT,
+}
+
+// BinaryOperator returns the binary operator applied by a compound
+// assignment operator (ADD for ADD_ASSIGN), or ILLEGAL for other tokens
+func (tok Token) BinaryOperator() Token {
+	if op, ok := compoundOperators[tok]; ok {
+		return op
+	}
+	return ILLEGAL
+}
+
+// LowestPrec is the precedence of tokens that are not binary operators
+const LowestPrec = 0
+
+// binaryPrecedences lists the precedence of each binary operator, following
+// the five levels of the Go specification
+var binaryPrecedences = map[Token]int{
+	LOR:     1,
+	LAND:    2,
+	EQL:     3,
+	NEQ:     3,
+	LSS:     3,
+	LEQ:     3,
+	GTR:     3,
+	GEQ:     3,
+	ADD:     4,
+	SUB:     4,
+	OR:      4,
+	XOR:     4,
+	MUL:     5,
+	QUO:     5,
+	REM:     5,
+	SHL:     5,
+	SHR:     5,
+	AND:     5,
+	AND_NOT: 5,
+}
+
+// Precedence returns the precedence of a binary operator, or LowestPrec for
+// other tokens
+func (tok Token) Precedence() int {
+	return binaryPrecedences[tok]
+}
+
+// Position describes a location in source code. Line and Column are
+// 1-based; Column counts bytes like go/token does.
+type Position struct {
+	Filename string `json:"filename,omitempty"`
+	Offset   int    `json:"offset"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+// IsValid reports whether the position carries line information
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String returns the position as "file:line:column" (file omitted when empty)
+func (p Position) String() string {
+	if !p.IsValid() {
+		if p.Filename != "" {
+			return p.Filename
+		}
+		return "-"
+	}
+	s := itoa(p.Line) + ":" + itoa(p.Column)
+	if p.Filename != "" {
+		s = p.Filename + ":" + s
+	}
+	return s
+}
+
+// itoa converts a non-negative integer to its decimal representation
+func itoa(n int) string {
+	if n == 0 {
+		return "0"
+	}
+	digits := []byte{}
+	for n > 0 {
+		digits = append([]byte{byte('0' + n%10)}, digits...)
+		n /= 10
+	}
+	return string(digits)
+}
+
 type TokenInfo struct {
 	Type    Token
 	Literal string
+	Pos     Position
 }